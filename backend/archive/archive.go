@@ -0,0 +1,685 @@
+// Package archive implements a read only backend to browse zip and
+// tar files as remotes
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/fspath"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/readers"
+)
+
+var errorReadOnly = errors.New("archive remotes are read only")
+
+// Archive formats
+const (
+	FormatZip    = "zip"
+	FormatTar    = "tar"
+	FormatTarGz  = "tar.gz"
+	FormatTarZst = "tar.zst"
+)
+
+// Register with Fs
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "archive",
+		Description: "Read zip and tar archives",
+		NewFs:       NewFs,
+		Options: []fs.Option{{
+			Name: "remote",
+			Help: `Remote or path of the archive to read, e.g. "myremote:path/to/bundle.zip".
+
+Leave this blank to give the archive in the path instead, e.g.
+
+    archive:myremote:path/to/bundle.zip/dir/in/archive
+
+In this case the archive is found by looking for the first path
+segment with a known archive extension.`,
+		}, {
+			Name: "format",
+			Help: `The format of the archive.
+
+Normally this is found from the extension of the archive, but set this
+if the archive doesn't have a standard extension.`,
+			Default: "auto",
+			Examples: []fs.OptionExample{{
+				Value: "auto",
+				Help:  "Use the extension: .zip, .tar, .tar.gz, .tgz, .tar.zst or .tzst",
+			}, {
+				Value: FormatZip,
+				Help:  "Zip archive",
+			}, {
+				Value: FormatTar,
+				Help:  "Uncompressed tar archive",
+			}, {
+				Value: FormatTarGz,
+				Help:  "Gzip compressed tar archive",
+			}, {
+				Value: FormatTarZst,
+				Help:  "Zstandard compressed tar archive",
+			}},
+			Advanced: true,
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	Remote string `config:"remote"`
+	Format string `config:"format"`
+}
+
+// Fs represents an archive opened as a read only remote
+type Fs struct {
+	name        string       // name of this remote
+	root        string       // the path we are working on inside the archive
+	opt         Options      // options for this Fs
+	features    *fs.Features // optional features
+	archivePath string       // remote:path of the archive
+	archive     fs.Object    // the archive itself
+	format      string       // format of the archive
+	index       *index       // files and directories in the archive
+}
+
+// openFn opens the data of a file in the archive from offset,
+// returning limit bytes
+type openFn func(ctx context.Context, offset, limit int64) (io.ReadCloser, error)
+
+// file describes a file in the archive
+type file struct {
+	size    int64     // uncompressed size
+	modTime time.Time // modification time
+	crc32   string    // CRC-32 as hex if known
+	open    openFn    // how to read the data
+}
+
+// dir describes a directory in the archive
+type dir struct {
+	modTime time.Time
+	dirs    map[string]struct{} // leaf names of sub directories
+	files   map[string]struct{} // leaf names of files
+}
+
+// index is the directory tree of the archive
+type index struct {
+	defaultTime time.Time        // time for directories without one
+	dirs        map[string]*dir  // directories by path, "" is the root
+	files       map[string]*file // files by path
+}
+
+// newIndex makes a new empty index
+func newIndex(defaultTime time.Time) *index {
+	x := &index{
+		defaultTime: defaultTime,
+		dirs:        map[string]*dir{},
+		files:       map[string]*file{},
+	}
+	x.addDir("", time.Time{})
+	return x
+}
+
+// cleanName turns a name in the archive into a path for the index
+//
+// It returns "" for the root
+func cleanName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// addDir adds the directory p and any missing parents to the index
+// returning it or nil if p is a file
+func (x *index) addDir(p string, modTime time.Time) *dir {
+	d, ok := x.dirs[p]
+	if !ok {
+		if _, isFile := x.files[p]; isFile {
+			return nil
+		}
+		if p != "" {
+			parent, leaf := splitPath(p)
+			parentDir := x.addDir(parent, time.Time{})
+			if parentDir == nil {
+				return nil
+			}
+			parentDir.dirs[leaf] = struct{}{}
+		}
+		d = &dir{
+			modTime: x.defaultTime,
+			dirs:    map[string]struct{}{},
+			files:   map[string]struct{}{},
+		}
+		x.dirs[p] = d
+	}
+	if !modTime.IsZero() {
+		d.modTime = modTime
+	}
+	return d
+}
+
+// addFile adds the file at p to the index
+//
+// Later files replace earlier ones with the same name
+func (x *index) addFile(p string, f *file) error {
+	if _, isDir := x.dirs[p]; isDir {
+		return errors.Errorf("%q is a directory", p)
+	}
+	parent, leaf := splitPath(p)
+	d := x.addDir(parent, time.Time{})
+	if d == nil {
+		return errors.Errorf("parent of %q is a file", p)
+	}
+	d.files[leaf] = struct{}{}
+	x.files[p] = f
+	return nil
+}
+
+// splitPath splits p into its parent directory and leaf
+func splitPath(p string) (parent, leaf string) {
+	parent, leaf = path.Split(p)
+	return strings.TrimSuffix(parent, "/"), leaf
+}
+
+// FormatFromName returns the archive format for name from its
+// extension or "" if not known
+func FormatFromName(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip
+	case strings.HasSuffix(name, ".tar"):
+		return FormatTar
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz
+	case strings.HasSuffix(name, ".tar.zst"), strings.HasSuffix(name, ".tzst"):
+		return FormatTarZst
+	}
+	return ""
+}
+
+// splitArchivePath splits root into the path of the archive and the
+// path inside it by looking for the first segment with a known
+// archive extension
+func splitArchivePath(root string) (archivePath, inner string, err error) {
+	for i := 0; i <= len(root); i++ {
+		if i < len(root) && root[i] != '/' {
+			continue
+		}
+		if FormatFromName(root[:i]) != "" {
+			return root[:i], strings.TrimPrefix(root[i:], "/"), nil
+		}
+	}
+	return "", "", errors.Errorf("can't find an archive in %q - use a .zip, .tar, .tar.gz, .tgz, .tar.zst or .tzst file or set the remote option", root)
+}
+
+// NewFs constructs an Fs from the path.
+//
+// The returned Fs is the actual Fs, referenced by remote in the config
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	// Parse config into Options struct
+	opt := new(Options)
+	err := configstruct.Set(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	archivePath, inner := opt.Remote, root
+	if archivePath == "" {
+		if opt.Format != "" && opt.Format != "auto" {
+			archivePath, inner = root, ""
+		} else {
+			archivePath, inner, err = splitArchivePath(root)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if strings.HasPrefix(archivePath, name+":") {
+		return nil, errors.New("can't point archive remote at itself - check the value of the remote setting")
+	}
+	format, err := findFormat(archivePath, opt.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the archive
+	wrappedFs, err := cache.Get(ctx, archivePath)
+	if err == nil {
+		return nil, errors.Errorf("archive %q is not a file", archivePath)
+	} else if err != fs.ErrorIsFile {
+		return nil, errors.Wrapf(err, "failed to make remote %q to read the archive from", archivePath)
+	}
+	_, leaf, err := fspath.Split(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := wrappedFs.NewObject(ctx, leaf)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find archive %q", archivePath)
+	}
+
+	f := newFs(ctx, name, archivePath, archive, format)
+	f.opt = *opt
+	cache.PinUntilFinalized(wrappedFs, f)
+	err = f.readIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check to see if the root is a file
+	f.root = cleanName(inner)
+	if _, ok := f.index.files[f.root]; ok && f.root != "" {
+		f.root, _ = splitPath(f.root)
+		return f, fs.ErrorIsFile
+	}
+	return f, nil
+}
+
+// NewFsFromObject makes an Fs showing the contents of the archive o
+//
+// If format is "" or "auto" then it is found from the name of o.
+func NewFsFromObject(ctx context.Context, name string, o fs.Object, format string) (*Fs, error) {
+	format, err := findFormat(o.Remote(), format)
+	if err != nil {
+		return nil, err
+	}
+	f := newFs(ctx, name, objectPath(o), o, format)
+	err = f.readIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// objectPath returns the remote:path of o
+func objectPath(o fs.Object) string {
+	return fmt.Sprintf("%s:%s", o.Fs().Name(), path.Join(o.Fs().Root(), o.Remote()))
+}
+
+// findFormat checks format, finding it from the extension of
+// archivePath if it is "" or "auto"
+func findFormat(archivePath, format string) (string, error) {
+	if format == "" || format == "auto" {
+		format = FormatFromName(archivePath)
+		if format == "" {
+			return "", errors.Errorf("can't work out the format of %q - set the format option", archivePath)
+		}
+	}
+	switch format {
+	case FormatZip, FormatTar, FormatTarGz, FormatTarZst:
+	default:
+		return "", errors.Errorf("unknown archive format %q", format)
+	}
+	return format, nil
+}
+
+// newFs makes an Fs for the archive with an empty index
+func newFs(ctx context.Context, name, archivePath string, archive fs.Object, format string) *Fs {
+	f := &Fs{
+		name:        name,
+		opt:         Options{Format: format},
+		archivePath: archivePath,
+		archive:     archive,
+		format:      format,
+		index:       newIndex(archive.ModTime(ctx)),
+	}
+	f.features = (&fs.Features{
+		CanHaveEmptyDirectories: true,
+	}).Fill(ctx, f)
+	return f
+}
+
+// readIndex reads the index of the archive
+func (f *Fs) readIndex(ctx context.Context) (err error) {
+	switch f.format {
+	case FormatZip:
+		err = f.indexZip(ctx)
+	default:
+		err = f.indexTar(ctx)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to read archive %q", f.archivePath)
+	}
+	fs.Debugf(f, "Read %d files and %d directories from archive", len(f.index.files), len(f.index.dirs)-1)
+	return nil
+}
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	return fmt.Sprintf("archive root '%s' of %s", f.root, f.archivePath)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// Precision of the ModTimes in this Fs
+func (f *Fs) Precision() time.Duration {
+	if f.format == FormatZip {
+		// MS-DOS times have a 2 second resolution
+		return 2 * time.Second
+	}
+	return time.Second
+}
+
+// Hashes returns the supported hash sets.
+func (f *Fs) Hashes() hash.Set {
+	if f.format == FormatZip {
+		return hash.Set(hash.CRC32)
+	}
+	return hash.Set(hash.None)
+}
+
+// List the objects and directories in dir into entries.  The
+// entries can be returned in any order but should be for a
+// complete directory.
+//
+// dir should be "" to list the root, and should not have
+// trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+func (f *Fs) List(ctx context.Context, dirPath string) (entries fs.DirEntries, err error) {
+	d, ok := f.index.dirs[path.Join(f.root, dirPath)]
+	if !ok {
+		return nil, fs.ErrorDirNotFound
+	}
+	entries = make(fs.DirEntries, 0, len(d.dirs)+len(d.files))
+	for leaf := range d.dirs {
+		remote := path.Join(dirPath, leaf)
+		entries = append(entries, fs.NewDir(remote, f.index.dirs[path.Join(f.root, remote)].modTime))
+	}
+	for leaf := range d.files {
+		remote := path.Join(dirPath, leaf)
+		entries = append(entries, f.newObject(remote, f.index.files[path.Join(f.root, remote)]))
+	}
+	return entries, nil
+}
+
+// NewObject finds the Object at remote.  If it can't be found
+// it returns the error ErrorObjectNotFound.
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	p := path.Join(f.root, remote)
+	if file, ok := f.index.files[p]; ok {
+		return f.newObject(remote, file), nil
+	}
+	return nil, fs.ErrorObjectNotFound
+}
+
+// Put in to the remote path with the modTime given of the given size
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	return nil, errorReadOnly
+}
+
+// Mkdir makes the directory
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	return errorReadOnly
+}
+
+// Rmdir removes the directory
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	return errorReadOnly
+}
+
+// openRange opens count bytes of the archive from offset
+func (f *Fs) openRange(ctx context.Context, offset, count int64) (io.ReadCloser, error) {
+	if count <= 0 {
+		return ioutil.NopCloser(bytes.NewReader(nil)), nil
+	}
+	in, err := f.archive.Open(ctx, &fs.RangeOption{Start: offset, End: offset + count - 1})
+	if err != nil {
+		return nil, err
+	}
+	return readers.NewLimitedReadCloser(in, count), nil
+}
+
+// readCloser reads from Reader and closes all the closers
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close all the closers returning the first error
+func (r *readCloser) Close() (err error) {
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		closeErr := r.closers[i].Close()
+		if err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// skipLimit discards offset bytes from in then returns a reader of
+// the next limit bytes
+func skipLimit(in io.ReadCloser, offset, limit int64) (io.ReadCloser, error) {
+	if offset > 0 {
+		_, err := io.CopyN(ioutil.Discard, in, offset)
+		if err != nil {
+			_ = in.Close()
+			return nil, errors.Wrap(err, "failed to skip to offset")
+		}
+	}
+	return readers.NewLimitedReadCloser(in, limit), nil
+}
+
+// crcReader checks the CRC-32 of the data when it reaches EOF
+type crcReader struct {
+	io.ReadCloser
+	crc string // expected CRC-32 as hex
+	sum uint32 // CRC-32 so far
+}
+
+// newCRCReader returns a reader which checks in has the CRC-32 crc
+func newCRCReader(in io.ReadCloser, crc string) io.ReadCloser {
+	return &crcReader{
+		ReadCloser: in,
+		crc:        crc,
+	}
+}
+
+// Read bytes checking the CRC-32 at the end
+func (r *crcReader) Read(p []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(p)
+	r.sum = crc32.Update(r.sum, crc32.IEEETable, p[:n])
+	if err == io.EOF {
+		if got := fmt.Sprintf("%08x", r.sum); got != r.crc {
+			return n, errors.Errorf("corrupted file: CRC-32 differs %q vs %q", got, r.crc)
+		}
+	}
+	return n, err
+}
+
+// Object describes a file in the archive
+type Object struct {
+	fs     *Fs
+	remote string
+	file   *file
+}
+
+// newObject makes an Object for file at remote
+func (f *Fs) newObject(remote string, file *file) *Object {
+	return &Object{
+		fs:     f,
+		remote: remote,
+		file:   file,
+	}
+}
+
+// Fs returns read only access to the Fs that this object is part of
+func (o *Object) Fs() fs.Info {
+	return o.fs
+}
+
+// Return a string version
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.remote
+}
+
+// Remote returns the remote path
+func (o *Object) Remote() string {
+	return o.remote
+}
+
+// Hash returns the selected checksum of the file
+// If no checksum is available it returns ""
+func (o *Object) Hash(ctx context.Context, ht hash.Type) (string, error) {
+	if !o.fs.Hashes().Contains(ht) {
+		return "", hash.ErrUnsupported
+	}
+	return o.file.crc32, nil
+}
+
+// Size returns the size of the file
+func (o *Object) Size() int64 {
+	return o.file.size
+}
+
+// ModTime returns the modification time of the file
+func (o *Object) ModTime(ctx context.Context) time.Time {
+	return o.file.modTime
+}
+
+// SetModTime sets the modification time of the file
+func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
+	return errorReadOnly
+}
+
+// Storable returns whether the object is storable
+func (o *Object) Storable() bool {
+	return true
+}
+
+// Open opens the file for read.  Call Close() on the returned io.ReadCloser
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	var offset, limit int64 = 0, -1
+	for _, option := range options {
+		switch x := option.(type) {
+		case *fs.SeekOption:
+			offset = x.Offset
+		case *fs.RangeOption:
+			offset, limit = x.Decode(o.file.size)
+		default:
+			if option.Mandatory() {
+				fs.Logf(o, "Unsupported mandatory option: %v", option)
+			}
+		}
+	}
+	if offset > o.file.size {
+		offset = o.file.size
+	}
+	if limit < 0 || offset+limit > o.file.size {
+		limit = o.file.size - offset
+	}
+	in, err := o.file.open(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	// Check the CRC if reading the whole file
+	if o.file.crc32 != "" && offset == 0 && limit == o.file.size {
+		in = newCRCReader(in, o.file.crc32)
+	}
+	return in, nil
+}
+
+// Update in to the object with the modTime given of the given size
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	return errorReadOnly
+}
+
+// Remove an object
+func (o *Object) Remove(ctx context.Context) error {
+	return errorReadOnly
+}
+
+// readerAt reads the archive at random offsets caching the last
+// block read
+type readerAt struct {
+	ctx   context.Context
+	o     fs.Object
+	mu    sync.Mutex
+	start int64  // offset of buf in the object
+	buf   []byte // last block read
+}
+
+// readerAtBlockSize is the size of the blocks read by readerAt
+const readerAtBlockSize = 64 * 1024
+
+// newReaderAt makes a readerAt for o
+func newReaderAt(ctx context.Context, o fs.Object) *readerAt {
+	return &readerAt{
+		ctx: ctx,
+		o:   o,
+	}
+}
+
+// ReadAt reads len(p) bytes at offset off
+func (r *readerAt) ReadAt(p []byte, off int64) (n int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	size := r.o.Size()
+	for len(p) > 0 {
+		if off >= size {
+			return n, io.EOF
+		}
+		if off < r.start || off >= r.start+int64(len(r.buf)) {
+			start := off - off%readerAtBlockSize
+			end := start + readerAtBlockSize
+			if end > size {
+				end = size
+			}
+			in, err := r.o.Open(r.ctx, &fs.RangeOption{Start: start, End: end - 1})
+			if err != nil {
+				return n, err
+			}
+			if cap(r.buf) < readerAtBlockSize {
+				r.buf = make([]byte, readerAtBlockSize)
+			}
+			r.buf = r.buf[:end-start]
+			_, err = io.ReadFull(in, r.buf)
+			_ = in.Close()
+			if err != nil {
+				r.buf = r.buf[:0]
+				return n, err
+			}
+			r.start = start
+		}
+		copied := copy(p, r.buf[off-r.start:])
+		p = p[copied:]
+		off += int64(copied)
+		n += copied
+	}
+	return n, nil
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs       = (*Fs)(nil)
+	_ fs.Object   = (*Object)(nil)
+	_ io.ReaderAt = (*readerAt)(nil)
+)
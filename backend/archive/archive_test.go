@@ -0,0 +1,347 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testTime  = time.Date(2020, 1, 2, 3, 4, 6, 0, time.UTC)
+	testFiles = []struct {
+		name     string
+		contents []byte
+		store    bool // don't compress in zip files
+	}{
+		{"file1.txt", []byte("hello world"), true},
+		{"dir/file2.txt", bytes.Repeat([]byte("potato "), 1000), false},
+		{"dir/sub/file3.bin", randomBytes(200 * 1024), false},
+		{"dir/sub/file4.bin", randomBytes(100 * 1024), true},
+	}
+	testDirs = []string{"empty/"}
+)
+
+// randomBytes makes n reproducible random bytes
+func randomBytes(n int) []byte {
+	buf := make([]byte, n)
+	_, _ = rand.New(rand.NewSource(int64(n))).Read(buf)
+	return buf
+}
+
+// makeZip makes a zip archive of the test files
+func makeZip(t *testing.T) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, dir := range testDirs {
+		_, err := w.CreateHeader(&zip.FileHeader{Name: dir, Modified: testTime})
+		require.NoError(t, err)
+	}
+	for _, file := range testFiles {
+		method := zip.Deflate
+		if file.store {
+			method = zip.Store
+		}
+		out, err := w.CreateHeader(&zip.FileHeader{Name: file.name, Method: method, Modified: testTime})
+		require.NoError(t, err)
+		_, err = out.Write(file.contents)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+// makeTar makes a tar archive of the test files with the compression given
+func makeTar(t *testing.T, compression string) []byte {
+	var buf bytes.Buffer
+	var out io.WriteCloser = nopWriteCloser{&buf}
+	switch compression {
+	case "gz":
+		out = gzip.NewWriter(&buf)
+	case "zst":
+		var err error
+		out, err = zstd.NewWriter(&buf)
+		require.NoError(t, err)
+	}
+	w := tar.NewWriter(out)
+	for _, dir := range testDirs {
+		require.NoError(t, w.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "./" + dir, Mode: 0755, ModTime: testTime}))
+	}
+	for _, file := range testFiles {
+		require.NoError(t, w.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: file.name, Mode: 0644, Size: int64(len(file.contents)), ModTime: testTime}))
+		_, err := w.Write(file.contents)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, out.Close())
+	return buf.Bytes()
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// newTestFs writes data to leaf in a temporary directory and opens
+// root inside it
+func newTestFs(t *testing.T, leaf string, data []byte, root string, m configmap.Simple) (*Fs, string, error) {
+	dir, err := ioutil.TempDir("", "rclone-archive-test")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	archivePath := filepath.Join(dir, leaf)
+	require.NoError(t, ioutil.WriteFile(archivePath, data, 0600))
+	if m == nil {
+		m = configmap.Simple{}
+		root = archivePath + "/" + root
+	} else {
+		m["remote"] = archivePath
+	}
+	f, err := NewFs(context.Background(), "TestArchive", root, m)
+	if f == nil {
+		return nil, archivePath, err
+	}
+	return f.(*Fs), archivePath, err
+}
+
+// readAll reads the object with the options given
+func readAll(t *testing.T, o fs.Object, options ...fs.OpenOption) ([]byte, error) {
+	in, err := o.Open(context.Background(), options...)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(in)
+	require.NoError(t, in.Close())
+	return data, err
+}
+
+// listNames returns the sorted names in dir
+func listNames(t *testing.T, f fs.Fs, dir string) []string {
+	entries, err := f.List(context.Background(), dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		name := entry.Remote()
+		if _, ok := entry.(fs.Directory); ok {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestArchive(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		leaf string
+		data []byte
+	}{
+		{"bundle.zip", makeZip(t)},
+		{"bundle.tar", makeTar(t, "")},
+		{"bundle.tar.gz", makeTar(t, "gz")},
+		{"bundle.TGZ", makeTar(t, "gz")},
+		{"bundle.tar.zst", makeTar(t, "zst")},
+	} {
+		t.Run(test.leaf, func(t *testing.T) {
+			f, _, err := newTestFs(t, test.leaf, test.data, "", nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"dir/", "empty/", "file1.txt"}, listNames(t, f, ""))
+			assert.Equal(t, []string{"dir/file2.txt", "dir/sub/"}, listNames(t, f, "dir"))
+			assert.Equal(t, []string{"dir/sub/file3.bin", "dir/sub/file4.bin"}, listNames(t, f, "dir/sub"))
+			assert.Equal(t, []string(nil), listNames(t, f, "empty"))
+			_, err = f.List(ctx, "potato")
+			assert.Equal(t, fs.ErrorDirNotFound, err)
+
+			for _, file := range testFiles {
+				o, err := f.NewObject(ctx, file.name)
+				require.NoError(t, err)
+				assert.Equal(t, int64(len(file.contents)), o.Size())
+				assert.True(t, testTime.Equal(o.ModTime(ctx)), o.ModTime(ctx))
+
+				data, err := readAll(t, o)
+				require.NoError(t, err)
+				assert.Equal(t, file.contents, data)
+
+				size := int64(len(file.contents))
+				data, err = readAll(t, o, &fs.RangeOption{Start: size / 3, End: size/2 - 1})
+				require.NoError(t, err)
+				assert.Equal(t, file.contents[size/3:size/2], data)
+
+				data, err = readAll(t, o, &fs.SeekOption{Offset: size - 5})
+				require.NoError(t, err)
+				assert.Equal(t, file.contents[size-5:], data)
+
+				crc, err := o.Hash(ctx, hash.CRC32)
+				if f.format == FormatZip {
+					require.NoError(t, err)
+					assert.Equal(t, fmt.Sprintf("%08x", crc32.ChecksumIEEE(file.contents)), crc)
+				} else {
+					assert.Equal(t, hash.ErrUnsupported, err)
+				}
+			}
+			_, err = f.NewObject(ctx, "dir")
+			assert.Equal(t, fs.ErrorObjectNotFound, err)
+			_, err = f.NewObject(ctx, "potato")
+			assert.Equal(t, fs.ErrorObjectNotFound, err)
+
+			// Check it is read only
+			assert.Equal(t, errorReadOnly, f.Mkdir(ctx, "dir"))
+			o, err := f.NewObject(ctx, "file1.txt")
+			require.NoError(t, err)
+			assert.Equal(t, errorReadOnly, o.Remove(ctx))
+		})
+	}
+}
+
+func TestArchiveRoot(t *testing.T) {
+	data := makeZip(t)
+
+	// Root in the path
+	f, _, err := newTestFs(t, "bundle.zip", data, "dir", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "dir", f.Root())
+	assert.Equal(t, []string{"file2.txt", "sub/"}, listNames(t, f, ""))
+
+	// Root pointing to a file
+	f, _, err = newTestFs(t, "bundle.zip", data, "dir/sub/file3.bin", nil)
+	assert.Equal(t, fs.ErrorIsFile, err)
+	assert.Equal(t, "dir/sub", f.Root())
+	_, err = f.NewObject(context.Background(), "file3.bin")
+	require.NoError(t, err)
+
+	// Archive in the remote option
+	f, _, err = newTestFs(t, "bundle.dat", data, "dir/sub", configmap.Simple{"format": "zip"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file3.bin", "file4.bin"}, listNames(t, f, ""))
+
+	// Errors
+	_, _, err = newTestFs(t, "bundle.dat", data, "", nil)
+	assert.Error(t, err)
+	_, _, err = newTestFs(t, "bundle.dat", data, "", configmap.Simple{})
+	assert.Error(t, err)
+	_, _, err = newTestFs(t, "bundle.zip", []byte("potato"), "", nil)
+	assert.Error(t, err)
+}
+
+func TestArchiveCorrupt(t *testing.T) {
+	data := makeZip(t)
+	i := bytes.Index(data, []byte("hello world"))
+	require.True(t, i >= 0)
+	data[i] = 'j'
+	f, _, err := newTestFs(t, "bundle.zip", data, "", nil)
+	require.NoError(t, err)
+	o, err := f.NewObject(context.Background(), "file1.txt")
+	require.NoError(t, err)
+	_, err = readAll(t, o)
+	assert.Error(t, err)
+	// Partial reads can't be checked
+	got, err := readAll(t, o, &fs.RangeOption{Start: 0, End: 4})
+	require.NoError(t, err)
+	assert.Equal(t, "jello", string(got))
+}
+
+func TestSplitArchivePath(t *testing.T) {
+	for _, test := range []struct {
+		root        string
+		archivePath string
+		inner       string
+		err         bool
+	}{
+		{"remote:bundle.zip", "remote:bundle.zip", "", false},
+		{"remote:dir/bundle.tar.gz/a/b", "remote:dir/bundle.tar.gz", "a/b", false},
+		{"/tmp/x.tgz/", "/tmp/x.tgz", "", false},
+		{"remote:a.zip/b.tar/c", "remote:a.zip", "b.tar/c", false},
+		{"remote:bundle.zipper/a", "", "", true},
+		{"remote:dir", "", "", true},
+	} {
+		archivePath, inner, err := splitArchivePath(test.root)
+		assert.Equal(t, test.err, err != nil, test.root)
+		assert.Equal(t, test.archivePath, archivePath, test.root)
+		assert.Equal(t, test.inner, inner, test.root)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	x := newIndex(testTime)
+	require.NoError(t, x.addFile("a/b/c", &file{}))
+	assert.NotNil(t, x.dirs["a/b"])
+	assert.Error(t, x.addFile("a/b", &file{}))
+	assert.Nil(t, x.addDir("a/b/c", time.Time{}))
+	assert.Nil(t, x.addDir("a/b/c/d", time.Time{}))
+	assert.Equal(t, "a/b", cleanName("./a//b/"))
+	assert.Equal(t, "a", cleanName("/../a"))
+	assert.Equal(t, "", cleanName("./"))
+}
+
+func TestStream(t *testing.T) {
+	ctx := context.Background()
+	for _, compression := range []string{"", "gz", "zst"} {
+		format := FormatTar
+		if compression != "" {
+			format += "." + compression
+		}
+		f, _, err := newTestFs(t, "bundle."+format, makeTar(t, compression), "", nil)
+		require.NoError(t, err)
+		var names []string
+		i := 0
+		err = Stream(ctx, "TestStream", f.archive, "", func(entry fs.DirEntry) error {
+			switch x := entry.(type) {
+			case fs.Directory:
+				names = append(names, x.Remote()+"/")
+			case fs.Object:
+				names = append(names, x.Remote())
+				file := testFiles[i]
+				i++
+				assert.Equal(t, file.name, x.Remote())
+				assert.Equal(t, int64(len(file.contents)), x.Size())
+				assert.True(t, testTime.Equal(x.ModTime(ctx)))
+				if i%2 == 0 {
+					// Check unread files are skipped
+					return nil
+				}
+				data, err := readAll(t, x)
+				require.NoError(t, err)
+				assert.Equal(t, file.contents, data)
+				_, err = x.Open(ctx)
+				assert.Error(t, err)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"empty/", "file1.txt", "dir/file2.txt", "dir/sub/file3.bin", "dir/sub/file4.bin"}, names)
+	}
+
+	// Stop when fn returns an error
+	f, _, err := newTestFs(t, "bundle.tar", makeTar(t, ""), "", nil)
+	require.NoError(t, err)
+	errStop := errors.New("stop")
+	err = Stream(ctx, "TestStream", f.archive, "", func(entry fs.DirEntry) error {
+		return errStop
+	})
+	assert.Equal(t, errStop, err)
+
+	// Zip files can't be streamed
+	f, _, err = newTestFs(t, "bundle.zip", makeZip(t), "", nil)
+	require.NoError(t, err)
+	assert.Error(t, Stream(ctx, "TestStream", f.archive, "", nil))
+}
@@ -0,0 +1,220 @@
+// Read tar archives
+
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+)
+
+// openTar opens the archive as a tar stream
+func (f *Fs) openTar(ctx context.Context) (*tar.Reader, *readCloser, error) {
+	in, err := f.archive.Open(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc := &readCloser{
+		Reader:  in,
+		closers: []io.Closer{in},
+	}
+	switch f.format {
+	case FormatTarGz:
+		decompressor, err := gzip.NewReader(in)
+		if err != nil {
+			_ = rc.Close()
+			return nil, nil, err
+		}
+		rc.Reader = decompressor
+		rc.closers = append(rc.closers, decompressor)
+	case FormatTarZst:
+		decompressor, err := zstd.NewReader(in)
+		if err != nil {
+			_ = rc.Close()
+			return nil, nil, err
+		}
+		rc.Reader = decompressor
+		rc.closers = append(rc.closers, decompressor.IOReadCloser())
+	}
+	return tar.NewReader(rc), rc, nil
+}
+
+// isSparse returns true if the file in hdr is stored sparsely
+func isSparse(hdr *tar.Header) bool {
+	if hdr.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for key := range hdr.PAXRecords {
+		if strings.HasPrefix(key, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+// indexTar reads the headers of a tar archive into the index
+//
+// Uncompressed archives are read by seeking past the file data so
+// only the headers are read. Compressed archives have to be read all
+// the way through.
+func (f *Fs) indexTar(ctx context.Context) (err error) {
+	var (
+		tr      *tar.Reader
+		section *io.SectionReader
+	)
+	if f.format == FormatTar {
+		section = io.NewSectionReader(newReaderAt(ctx, f.archive), 0, f.archive.Size())
+		tr = tar.NewReader(section)
+	} else {
+		var rc *readCloser
+		tr, rc, err = f.openTar(ctx)
+		if err != nil {
+			return err
+		}
+		defer fs.CheckClose(rc, &err)
+	}
+	for i := 0; ; i++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return errors.Wrap(err, "failed to read tar header")
+		}
+		name := cleanName(hdr.Name)
+		info := hdr.FileInfo()
+		if info.IsDir() {
+			if f.index.addDir(name, hdr.ModTime) == nil {
+				fs.Debugf(f, "Skipping directory %q: a file has the same name", hdr.Name)
+			}
+			continue
+		}
+		if !info.Mode().IsRegular() {
+			fs.Debugf(f, "Skipping %q: not a regular file", hdr.Name)
+			continue
+		}
+		if name == "" {
+			fs.Debugf(f, "Skipping %q: bad name", hdr.Name)
+			continue
+		}
+		// Note the offset of the data if it can be read directly
+		offset := int64(-1)
+		if section != nil && !isSparse(hdr) {
+			offset, err = section.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return err
+			}
+		}
+		err = f.index.addFile(name, &file{
+			size:    hdr.Size,
+			modTime: hdr.ModTime,
+			open:    f.tarOpen(i, offset),
+		})
+		if err != nil {
+			fs.Debugf(f, "Skipping %q: %v", hdr.Name, err)
+		}
+	}
+	return nil
+}
+
+// tarOpen returns a function to open the data of the i-th entry of
+// the archive which starts at dataOffset
+//
+// If dataOffset is -1 then the archive is read from the start to
+// find the data.
+func (f *Fs) tarOpen(i int, dataOffset int64) openFn {
+	return func(ctx context.Context, offset, limit int64) (io.ReadCloser, error) {
+		if dataOffset >= 0 {
+			return f.openRange(ctx, dataOffset+offset, limit)
+		}
+		tr, rc, err := f.openTar(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j <= i; j++ {
+			_, err = tr.Next()
+			if err != nil {
+				_ = rc.Close()
+				return nil, errors.Wrap(err, "failed to find file in tar")
+			}
+		}
+		return skipLimit(&readCloser{
+			Reader:  tr,
+			closers: []io.Closer{rc},
+		}, offset, limit)
+	}
+}
+
+// StreamFn is called by Stream for each directory and file in the
+// archive
+type StreamFn func(entry fs.DirEntry) error
+
+// Stream reads the tar archive o once from start to finish calling
+// fn for each directory and file in it.
+//
+// This is for reading compressed tar archives without decompressing
+// them again for every file. The files passed to fn can only be
+// opened once and only before fn returns.
+//
+// If format is "" or "auto" then it is found from the name of o.
+func Stream(ctx context.Context, name string, o fs.Object, format string, fn StreamFn) (err error) {
+	format, err = findFormat(o.Remote(), format)
+	if err != nil {
+		return err
+	}
+	if format == FormatZip {
+		return errors.New("can't stream zip archives")
+	}
+	f := newFs(ctx, name, objectPath(o), o, format)
+	tr, rc, err := f.openTar(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open archive %q", f.archivePath)
+	}
+	defer fs.CheckClose(rc, &err)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return errors.Wrap(err, "failed to read tar header")
+		}
+		name := cleanName(hdr.Name)
+		info := hdr.FileInfo()
+		if name == "" {
+			if !info.IsDir() {
+				fs.Debugf(f, "Skipping %q: bad name", hdr.Name)
+			}
+			continue
+		}
+		if info.IsDir() {
+			err = fn(fs.NewDir(name, hdr.ModTime))
+		} else if info.Mode().IsRegular() {
+			opened := false
+			o := f.newObject(name, &file{
+				size:    hdr.Size,
+				modTime: hdr.ModTime,
+				open: func(ctx context.Context, offset, limit int64) (io.ReadCloser, error) {
+					if opened {
+						return nil, errors.Errorf("can't open %q again: archive is being streamed", name)
+					}
+					opened = true
+					return skipLimit(&readCloser{Reader: tr}, offset, limit)
+				},
+			})
+			err = fn(o)
+			opened = true
+		} else {
+			fs.Debugf(f, "Skipping %q: not a regular file", hdr.Name)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
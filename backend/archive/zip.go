@@ -0,0 +1,86 @@
+// Read zip archives
+
+package archive
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+)
+
+// zip flag bits
+const zipFlagEncrypted = 0x1
+
+// indexZip reads the central directory of a zip archive into the
+// index
+func (f *Fs) indexZip(ctx context.Context) error {
+	r, err := zip.NewReader(newReaderAt(ctx, f.archive), f.archive.Size())
+	if err != nil {
+		return err
+	}
+	for _, zf := range r.File {
+		name := cleanName(zf.Name)
+		if strings.HasSuffix(zf.Name, "/") || zf.FileInfo().IsDir() {
+			if f.index.addDir(name, zf.Modified) == nil {
+				fs.Debugf(f, "Skipping directory %q: a file has the same name", zf.Name)
+			}
+			continue
+		}
+		if !zf.Mode().IsRegular() {
+			fs.Debugf(f, "Skipping %q: not a regular file", zf.Name)
+			continue
+		}
+		if name == "" {
+			fs.Debugf(f, "Skipping %q: bad name", zf.Name)
+			continue
+		}
+		err = f.index.addFile(name, &file{
+			size:    int64(zf.UncompressedSize64),
+			modTime: zf.Modified,
+			crc32:   fmt.Sprintf("%08x", zf.CRC32),
+			open:    f.zipOpen(zf),
+		})
+		if err != nil {
+			fs.Debugf(f, "Skipping %q: %v", zf.Name, err)
+		}
+	}
+	return nil
+}
+
+// zipOpen returns a function to open the data of zf
+//
+// Stored files are read with range requests. Compressed files are
+// decompressed from the start.
+func (f *Fs) zipOpen(zf *zip.File) openFn {
+	return func(ctx context.Context, offset, limit int64) (io.ReadCloser, error) {
+		if zf.Flags&zipFlagEncrypted != 0 {
+			return nil, errors.Errorf("can't read %q: encrypted zip files are not supported", zf.Name)
+		}
+		dataOffset, err := zf.DataOffset()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find data of %q", zf.Name)
+		}
+		switch zf.Method {
+		case zip.Store:
+			return f.openRange(ctx, dataOffset+offset, limit)
+		case zip.Deflate:
+			in, err := f.openRange(ctx, dataOffset, int64(zf.CompressedSize64))
+			if err != nil {
+				return nil, err
+			}
+			decompressor := flate.NewReader(in)
+			return skipLimit(&readCloser{
+				Reader:  decompressor,
+				closers: []io.Closer{in, decompressor},
+			}, offset, limit)
+		default:
+			return nil, errors.Errorf("can't read %q: unsupported zip compression method %d", zf.Name, zf.Method)
+		}
+	}
+}
@@ -0,0 +1,831 @@
+// Package combine implements a backend to combine multiple remotes
+// into one directory tree with each remote as a top level directory
+package combine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/fspath"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// Register with Fs
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "combine",
+		Description: "Combine several remotes into one",
+		NewFs:       NewFs,
+		Options: []fs.Option{{
+			Name: "upstreams",
+			Help: `Upstreams for combining
+
+These should be in the form
+
+    dir=remote:path dir2=remote2:path
+
+Where before the = is specified the top level directory and after it
+is the remote to use for it.
+
+If the directory or the remote contain spaces then the whole upstream
+should be in quotes, e.g.
+
+    "dir=remote:path with space" "dir2 with space=remote2:path"
+`,
+			Required: true,
+			Default:  fs.SpaceSepList(nil),
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	Upstreams fs.SpaceSepList `config:"upstreams"`
+}
+
+// Fs represents a combine of upstreams
+type Fs struct {
+	name      string               // name of this remote
+	features  *fs.Features         // optional features
+	opt       Options              // options for this Fs
+	root      string               // the path we are working on
+	hashSet   hash.Set             // common hashes
+	when      time.Time            // time this was created
+	upstreams map[string]*upstream // map of upstreams by top level directory
+}
+
+// upstream is a remote mounted at a directory of the combine
+type upstream struct {
+	f   fs.Fs  // the remote
+	dir string // the directory it is at relative to the root, "" if the root is in it
+}
+
+// parseUpstream parses an upstream of the form "dir=remote:path"
+func parseUpstream(u string) (dir, remote string, err error) {
+	equal := strings.IndexRune(u, '=')
+	if equal < 0 {
+		return "", "", errors.Errorf("no \"=\" in upstream %q", u)
+	}
+	dir, remote = strings.Trim(u[:equal], "/"), u[equal+1:]
+	if dir == "" || dir == "." || dir == ".." || strings.ContainsRune(dir, '/') {
+		return "", "", errors.Errorf("upstream directory %q must be a single top level directory", u[:equal])
+	}
+	if remote == "" {
+		return "", "", errors.Errorf("no remote in upstream %q", u)
+	}
+	return dir, remote, nil
+}
+
+// NewFs constructs an Fs from the path.
+//
+// The returned Fs is the actual Fs, referenced by remote in the config
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	// Parse config into Options struct
+	opt := new(Options)
+	err := configstruct.Set(m, opt)
+	if err != nil {
+		return nil, err
+	}
+	if len(opt.Upstreams) == 0 {
+		return nil, errors.New("combine can't point to an empty upstream - check the value of the upstreams setting")
+	}
+	root = strings.Trim(root, "/")
+	first, rest := root, ""
+	if slash := strings.IndexRune(root, '/'); slash >= 0 {
+		first, rest = root[:slash], root[slash+1:]
+	}
+
+	f := &Fs{
+		name:      name,
+		root:      root,
+		opt:       *opt,
+		when:      time.Now(),
+		upstreams: make(map[string]*upstream, len(opt.Upstreams)),
+	}
+	remotes := make(map[string]string, len(opt.Upstreams))
+	for _, u := range opt.Upstreams {
+		dir, remote, err := parseUpstream(u)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(remote, name+":") {
+			return nil, errors.New("can't point combine remote at itself - check the value of the upstreams setting")
+		}
+		if _, found := remotes[dir]; found {
+			return nil, errors.Errorf("duplicate directory %q in upstreams", dir)
+		}
+		remotes[dir] = remote
+	}
+
+	// If the root is in an upstream then that is the only one we need
+	var isFile bool
+	if root != "" {
+		remote, found := remotes[first]
+		if !found {
+			return nil, fs.ErrorDirNotFound
+		}
+		uFs, err := cache.Get(ctx, fspath.JoinRootPath(remote, rest))
+		if err == fs.ErrorIsFile {
+			isFile = true
+			f.root = path.Dir(root)
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "failed to create upstream %q", first)
+		}
+		f.upstreams[""] = &upstream{f: uFs}
+	} else {
+		var (
+			mu   sync.Mutex
+			wg   sync.WaitGroup
+			errs []error
+		)
+		for dir, remote := range remotes {
+			dir, remote := dir, remote
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				uFs, err := cache.Get(ctx, remote)
+				if err == fs.ErrorIsFile {
+					err = errors.New("upstream must be a directory not a file")
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					errs = append(errs, errors.Wrapf(err, "failed to create upstream %q", dir))
+					return
+				}
+				f.upstreams[dir] = &upstream{f: uFs, dir: dir}
+			}()
+		}
+		wg.Wait()
+		if len(errs) > 0 {
+			return nil, errs[0]
+		}
+	}
+
+	f.features = (&fs.Features{
+		CaseInsensitive:         true,
+		DuplicateFiles:          false,
+		ReadMimeType:            true,
+		WriteMimeType:           true,
+		CanHaveEmptyDirectories: true,
+		BucketBased:             true,
+		SetTier:                 true,
+		GetTier:                 true,
+	}).Fill(ctx, f)
+	canMove, canCopy, canDirMove, canAbout, canPurge, canPublicLink, canChangeNotify := false, false, false, false, false, false, false
+	firstHashes := true
+	for _, u := range f.upstreams {
+		f.features = f.features.Mask(ctx, u.f) // Mask all upstream fs
+		ft := u.f.Features()
+		canMove = canMove || ft.Move != nil
+		canCopy = canCopy || ft.Copy != nil
+		canDirMove = canDirMove || ft.DirMove != nil
+		canAbout = canAbout || ft.About != nil
+		canPurge = canPurge || ft.Purge != nil
+		canPublicLink = canPublicLink || ft.PublicLink != nil
+		canChangeNotify = canChangeNotify || ft.ChangeNotify != nil
+		if firstHashes {
+			f.hashSet = u.f.Hashes()
+			firstHashes = false
+		} else {
+			f.hashSet = f.hashSet.Overlap(u.f.Hashes())
+		}
+	}
+	// These are tried on the upstream and fall back if not
+	// supported so are enabled if any upstream supports them
+	if canMove {
+		f.features.Move = f.Move
+	}
+	if canCopy {
+		f.features.Copy = f.Copy
+	}
+	if canDirMove {
+		f.features.DirMove = f.DirMove
+	}
+	if canAbout {
+		f.features.About = f.About
+	}
+	if canPurge {
+		f.features.Purge = f.Purge
+	}
+	if canPublicLink {
+		f.features.PublicLink = f.PublicLink
+	}
+	if canChangeNotify {
+		f.features.ChangeNotify = f.ChangeNotify
+	}
+	// These are always supported
+	f.features.DirCacheFlush = f.DirCacheFlush
+	f.features.Shutdown = f.Shutdown
+	f.features.CleanUp = f.CleanUp
+
+	if isFile {
+		return f, fs.ErrorIsFile
+	}
+	return f, nil
+}
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	return fmt.Sprintf("combine root '%s'", f.root)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// Hashes returns the hashes supported by all the upstreams
+func (f *Fs) Hashes() hash.Set {
+	return f.hashSet
+}
+
+// Precision is the greatest precision of all the upstreams
+func (f *Fs) Precision() time.Duration {
+	var greatestPrecision time.Duration
+	for _, u := range f.upstreams {
+		if p := u.f.Precision(); p > greatestPrecision {
+			greatestPrecision = p
+		}
+	}
+	return greatestPrecision
+}
+
+// isTop returns true if dir is the top level directory which holds
+// the upstream directories
+func (f *Fs) isTop(dir string) bool {
+	_, inUpstream := f.upstreams[""]
+	return dir == "" && !inUpstream
+}
+
+// findUpstream finds the upstream for the remote and the path in it
+//
+// It returns fs.ErrorDirNotFound if remote isn't in an upstream.
+func (f *Fs) findUpstream(remote string) (u *upstream, uRemote string, err error) {
+	if u, ok := f.upstreams[""]; ok {
+		return u, remote, nil
+	}
+	dir, uRemote := remote, ""
+	if slash := strings.IndexRune(remote, '/'); slash >= 0 {
+		dir, uRemote = remote[:slash], remote[slash+1:]
+	}
+	u, ok := f.upstreams[dir]
+	if !ok {
+		return nil, "", fs.ErrorDirNotFound
+	}
+	return u, uRemote, nil
+}
+
+// findUpstreamObject finds the upstream for the object at remote
+// and the path of the object in it
+func (f *Fs) findUpstreamObject(remote string) (u *upstream, uRemote string, err error) {
+	u, uRemote, err = f.findUpstream(remote)
+	if err != nil || uRemote == "" {
+		return nil, "", fs.ErrorObjectNotFound
+	}
+	return u, uRemote, nil
+}
+
+// remote returns the path in the combine of uRemote in the upstream
+func (u *upstream) remote(uRemote string) string {
+	if u.dir == "" {
+		return uRemote
+	}
+	if uRemote == "" {
+		return u.dir
+	}
+	return u.dir + "/" + uRemote
+}
+
+// wrapEntries converts the upstream entries to be in the combine
+func (f *Fs) wrapEntries(ctx context.Context, u *upstream, entries fs.DirEntries) (fs.DirEntries, error) {
+	for i, entry := range entries {
+		switch x := entry.(type) {
+		case fs.Object:
+			entries[i] = f.newObject(u, x)
+		case fs.Directory:
+			if u.dir != "" {
+				entries[i] = fs.NewDirCopy(ctx, x).SetRemote(u.remote(x.Remote()))
+			}
+		default:
+			return nil, errors.Errorf("unknown entry type %T", entry)
+		}
+	}
+	return entries, nil
+}
+
+// topEntries returns the directories for the upstreams
+func (f *Fs) topEntries() (entries fs.DirEntries) {
+	for dir := range f.upstreams {
+		entries = append(entries, fs.NewDir(dir, f.when))
+	}
+	sort.Sort(entries)
+	return entries
+}
+
+// List the objects and directories in dir into entries.  The
+// entries can be returned in any order but should be for a
+// complete directory.
+//
+// dir should be "" to list the root, and should not have
+// trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
+	if f.isTop(dir) {
+		return f.topEntries(), nil
+	}
+	u, uRemote, err := f.findUpstream(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err = u.f.List(ctx, uRemote)
+	if err != nil {
+		return nil, err
+	}
+	return f.wrapEntries(ctx, u, entries)
+}
+
+// ListR lists the objects and directories of the Fs starting
+// from dir recursively into out.
+//
+// dir should be "" to start from the root, and should not
+// have trailing slashes.
+//
+// This should return ErrDirNotFound if the directory isn't
+// found.
+//
+// It should call callback for each tranche of entries read.
+// These need not be returned in any particular order.  If
+// callback returns an error then the listing will stop
+// immediately.
+//
+// This is only enabled if all the upstreams support ListR.
+func (f *Fs) ListR(ctx context.Context, dir string, callback fs.ListRCallback) (err error) {
+	listR := func(u *upstream, uRemote string) error {
+		return u.f.Features().ListR(ctx, uRemote, func(entries fs.DirEntries) error {
+			entries, err := f.wrapEntries(ctx, u, entries)
+			if err != nil {
+				return err
+			}
+			return callback(entries)
+		})
+	}
+	if !f.isTop(dir) {
+		u, uRemote, err := f.findUpstream(dir)
+		if err != nil {
+			return err
+		}
+		return listR(u, uRemote)
+	}
+	err = callback(f.topEntries())
+	if err != nil {
+		return err
+	}
+	for _, entry := range f.topEntries() {
+		err = listR(f.upstreams[entry.Remote()], "")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewObject creates a new remote combine file object
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	u, uRemote, err := f.findUpstreamObject(remote)
+	if err != nil {
+		return nil, err
+	}
+	o, err := u.f.NewObject(ctx, uRemote)
+	if err != nil {
+		return nil, err
+	}
+	return f.newObject(u, o), nil
+}
+
+// Mkdir makes the directory (container, bucket)
+//
+// Shouldn't return an error if it already exists
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	if f.isTop(dir) {
+		return nil
+	}
+	u, uRemote, err := f.findUpstream(dir)
+	if err != nil {
+		return errors.Errorf("can't make directory %q outside the upstreams", dir)
+	}
+	return u.f.Mkdir(ctx, uRemote)
+}
+
+// Rmdir removes the directory (container, bucket) if empty
+//
+// Return an error if it doesn't exist or isn't empty
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	if f.isTop(dir) {
+		return errors.New("can't remove the directory holding the upstreams")
+	}
+	u, uRemote, err := f.findUpstream(dir)
+	if err != nil {
+		return err
+	}
+	if u.dir != "" && uRemote == "" {
+		return errors.Errorf("can't remove upstream directory %q", dir)
+	}
+	return u.f.Rmdir(ctx, uRemote)
+}
+
+type putFn func(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error)
+
+// put implements Put and PutStream
+func (f *Fs) put(ctx context.Context, in io.Reader, src fs.ObjectInfo, stream bool, options ...fs.OpenOption) (fs.Object, error) {
+	u, uRemote, err := f.findUpstreamObject(src.Remote())
+	if err != nil {
+		return nil, errors.Errorf("can't upload %q outside the upstreams", src.Remote())
+	}
+	do := u.f.Put
+	if stream {
+		do = u.f.Features().PutStream
+		if do == nil {
+			return nil, errors.New("can't PutStream")
+		}
+	}
+	o, err := do(ctx, in, operations.NewOverrideRemote(src, uRemote), options...)
+	if err != nil {
+		return nil, err
+	}
+	return f.newObject(u, o), nil
+}
+
+// Put in to the remote path with the modTime given of the given size
+//
+// May create the object even if it returns an error - if so
+// will return the object and the error, otherwise will return
+// nil and the error
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	return f.put(ctx, in, src, false, options...)
+}
+
+// PutStream uploads to the remote path with the modTime given of indeterminate size
+//
+// May create the object even if it returns an error - if so
+// will return the object and the error, otherwise will return
+// nil and the error
+func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	return f.put(ctx, in, src, true, options...)
+}
+
+// Purge all files in the directory
+//
+// Implement this if you have a way of deleting all the files
+// quicker than just running Remove() on the result of List()
+//
+// Return an error if it doesn't exist
+func (f *Fs) Purge(ctx context.Context, dir string) error {
+	if f.isTop(dir) {
+		return fs.ErrorCantPurge
+	}
+	u, uRemote, err := f.findUpstream(dir)
+	if err != nil {
+		return err
+	}
+	if u.dir != "" && uRemote == "" {
+		return fs.ErrorCantPurge
+	}
+	do := u.f.Features().Purge
+	if do == nil {
+		return fs.ErrorCantPurge
+	}
+	return do(ctx, uRemote)
+}
+
+// sameUpstreamFs returns true if a server-side operation can be
+// done from srcFs to u
+func sameUpstreamFs(srcFs fs.Info, u *upstream) bool {
+	return operations.SameConfig(srcFs, u.f) || u.f.Features().ServerSideAcrossConfigs
+}
+
+// Copy src to this remote using server-side copy operations.
+//
+// This is stored with the remote path given
+//
+// It returns the destination Object and a possible error
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantCopy
+func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*Object)
+	if !ok {
+		fs.Debugf(src, "Can't copy - not same remote type")
+		return nil, fs.ErrorCantCopy
+	}
+	u, uRemote, err := f.findUpstreamObject(remote)
+	if err != nil {
+		return nil, fs.ErrorCantCopy
+	}
+	do := u.f.Features().Copy
+	if do == nil || !sameUpstreamFs(srcObj.Object.Fs(), u) {
+		return nil, fs.ErrorCantCopy
+	}
+	o, err := do(ctx, srcObj.Object, uRemote)
+	if err != nil {
+		return nil, err
+	}
+	return f.newObject(u, o), nil
+}
+
+// Move src to this remote using server-side move operations.
+//
+// This is stored with the remote path given
+//
+// It returns the destination Object and a possible error
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantMove
+func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
+	srcObj, ok := src.(*Object)
+	if !ok {
+		fs.Debugf(src, "Can't move - not same remote type")
+		return nil, fs.ErrorCantMove
+	}
+	u, uRemote, err := f.findUpstreamObject(remote)
+	if err != nil {
+		return nil, fs.ErrorCantMove
+	}
+	do := u.f.Features().Move
+	if do == nil || !sameUpstreamFs(srcObj.Object.Fs(), u) {
+		return nil, fs.ErrorCantMove
+	}
+	o, err := do(ctx, srcObj.Object, uRemote)
+	if err != nil {
+		return nil, err
+	}
+	return f.newObject(u, o), nil
+}
+
+// DirMove moves src, srcRemote to this remote at dstRemote
+// using server-side move operations.
+//
+// Will only be called if src.Fs().Name() == f.Name()
+//
+// If it isn't possible then return fs.ErrorCantDirMove
+//
+// If destination exists then return fs.ErrorDirExists
+func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string) error {
+	srcFs, ok := src.(*Fs)
+	if !ok {
+		fs.Debugf(src, "Can't move directory - not same remote type")
+		return fs.ErrorCantDirMove
+	}
+	srcU, srcURemote, err := srcFs.findUpstream(srcRemote)
+	if err != nil || (srcU.dir != "" && srcURemote == "") {
+		return fs.ErrorCantDirMove
+	}
+	dstU, dstURemote, err := f.findUpstream(dstRemote)
+	if err != nil || (dstU.dir != "" && dstURemote == "") {
+		return fs.ErrorCantDirMove
+	}
+	do := dstU.f.Features().DirMove
+	if do == nil || !sameUpstreamFs(srcU.f, dstU) {
+		return fs.ErrorCantDirMove
+	}
+	return do(ctx, srcU.f, srcURemote, dstURemote)
+}
+
+// ChangeNotify calls the passed function with a path
+// that has had changes. If the implementation
+// uses polling, it should adhere to the given interval.
+// At least one value will be written to the channel,
+// specifying the initial value and updated values might
+// follow. A 0 Duration should pause the polling.
+// The ChangeNotify implementation must empty the channel
+// regularly. When the channel gets closed, the implementation
+// should stop polling and release resources.
+func (f *Fs) ChangeNotify(ctx context.Context, fn func(string, fs.EntryType), ch <-chan time.Duration) {
+	var uChans []chan time.Duration
+
+	for _, u := range f.upstreams {
+		u := u
+		if do := u.f.Features().ChangeNotify; do != nil {
+			ch := make(chan time.Duration)
+			uChans = append(uChans, ch)
+			wrappedFn := func(path string, entryType fs.EntryType) {
+				fn(u.remote(path), entryType)
+			}
+			do(ctx, wrappedFn, ch)
+		}
+	}
+
+	go func() {
+		for i := range ch {
+			for _, c := range uChans {
+				c <- i
+			}
+		}
+		for _, c := range uChans {
+			close(c)
+		}
+	}()
+}
+
+// About gets quota information from the Fs
+//
+// The usage of the upstreams which support it are added up.
+func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
+	usage := &fs.Usage{}
+	add := func(total **int64, value *int64) {
+		if value == nil {
+			return
+		}
+		if *total == nil {
+			*total = new(int64)
+		}
+		**total += *value
+	}
+	for _, u := range f.upstreams {
+		do := u.f.Features().About
+		if do == nil {
+			fs.Debugf(u.f, "About not supported - ignoring")
+			continue
+		}
+		usg, err := do(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "about failed for upstream %q", u.dir)
+		}
+		add(&usage.Total, usg.Total)
+		add(&usage.Used, usg.Used)
+		add(&usage.Trashed, usg.Trashed)
+		add(&usage.Other, usg.Other)
+		add(&usage.Free, usg.Free)
+		add(&usage.Objects, usg.Objects)
+	}
+	return usage, nil
+}
+
+// CleanUp the trash in the upstreams which support it
+func (f *Fs) CleanUp(ctx context.Context) error {
+	for _, u := range f.upstreams {
+		if do := u.f.Features().CleanUp; do != nil {
+			err := do(ctx)
+			if err != nil {
+				return errors.Wrapf(err, "cleanup failed for upstream %q", u.dir)
+			}
+		}
+	}
+	return nil
+}
+
+// PublicLink generates a public link to the remote path (usually readable by anyone)
+func (f *Fs) PublicLink(ctx context.Context, remote string, expire fs.Duration, unlink bool) (string, error) {
+	u, uRemote, err := f.findUpstream(remote)
+	if err != nil {
+		return "", err
+	}
+	do := u.f.Features().PublicLink
+	if do == nil {
+		return "", errors.New("PublicLink not supported")
+	}
+	return do(ctx, uRemote, expire, unlink)
+}
+
+// DirCacheFlush resets the directory cache - used in testing
+// as an optional interface
+func (f *Fs) DirCacheFlush() {
+	for _, u := range f.upstreams {
+		if do := u.f.Features().DirCacheFlush; do != nil {
+			do()
+		}
+	}
+}
+
+// Shutdown the backend, closing any background tasks and any
+// cached connections.
+func (f *Fs) Shutdown(ctx context.Context) error {
+	for _, u := range f.upstreams {
+		if do := u.f.Features().Shutdown; do != nil {
+			err := do(ctx)
+			if err != nil {
+				return errors.Wrapf(err, "shutdown failed for upstream %q", u.dir)
+			}
+		}
+	}
+	return nil
+}
+
+// Object describes a wrapped Object
+//
+// This is a wrapped Object which knows its path prefix
+type Object struct {
+	fs.Object
+	f *Fs
+	u *upstream
+}
+
+func (f *Fs) newObject(u *upstream, o fs.Object) *Object {
+	return &Object{
+		Object: o,
+		f:      f,
+		u:      u,
+	}
+}
+
+// Fs returns read only access to the Fs that this object is part of
+func (o *Object) Fs() fs.Info {
+	return o.f
+}
+
+// String returns the remote path
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.Remote()
+}
+
+// Remote returns the remote path
+func (o *Object) Remote() string {
+	return o.u.remote(o.Object.Remote())
+}
+
+// UnWrap returns the Object that this Object is wrapping or
+// nil if it isn't wrapping anything
+func (o *Object) UnWrap() fs.Object {
+	return o.Object
+}
+
+// Update in to the object with the modTime given of the given size
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	return o.Object.Update(ctx, in, operations.NewOverrideRemote(src, o.Object.Remote()), options...)
+}
+
+// ID returns the ID of the Object if known, or "" if not
+func (o *Object) ID() string {
+	if do, ok := o.Object.(fs.IDer); ok {
+		return do.ID()
+	}
+	return ""
+}
+
+// GetTier returns storage tier or class of the Object
+func (o *Object) GetTier() string {
+	if do, ok := o.Object.(fs.GetTierer); ok {
+		return do.GetTier()
+	}
+	return ""
+}
+
+// SetTier performs changing storage tier of the Object if
+// multiple storage classes supported
+func (o *Object) SetTier(tier string) error {
+	if do, ok := o.Object.(fs.SetTierer); ok {
+		return do.SetTier(tier)
+	}
+	return errors.New("underlying remote does not support SetTier")
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs              = (*Fs)(nil)
+	_ fs.Purger          = (*Fs)(nil)
+	_ fs.PutStreamer     = (*Fs)(nil)
+	_ fs.Copier          = (*Fs)(nil)
+	_ fs.Mover           = (*Fs)(nil)
+	_ fs.DirMover        = (*Fs)(nil)
+	_ fs.DirCacheFlusher = (*Fs)(nil)
+	_ fs.ChangeNotifier  = (*Fs)(nil)
+	_ fs.Abouter         = (*Fs)(nil)
+	_ fs.ListRer         = (*Fs)(nil)
+	_ fs.Shutdowner      = (*Fs)(nil)
+	_ fs.PublicLinker    = (*Fs)(nil)
+	_ fs.CleanUpper      = (*Fs)(nil)
+	_ fs.Object          = (*Object)(nil)
+	_ fs.ObjectUnWrapper = (*Object)(nil)
+	_ fs.IDer            = (*Object)(nil)
+	_ fs.GetTierer       = (*Object)(nil)
+	_ fs.SetTierer       = (*Object)(nil)
+)
@@ -0,0 +1,216 @@
+// Test Combine filesystem interface
+package combine
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/local"
+	_ "github.com/rclone/rclone/backend/memory"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/walk"
+	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/fstest/fstests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIntegration runs integration tests against the remote
+func TestIntegration(t *testing.T) {
+	if *fstest.RemoteName == "" {
+		t.Skip("Skipping as -remote not set")
+	}
+	fstests.Run(t, &fstests.Opt{
+		RemoteName: *fstest.RemoteName,
+		NilObject:  (*Object)(nil),
+	})
+}
+
+// TestLocal runs the integration tests on upstreams on the local disk
+func TestLocal(t *testing.T) {
+	if *fstest.RemoteName != "" {
+		t.Skip("Skipping as -remote set")
+	}
+	dirs := makeTestDirs(t, 3)
+	upstreams := "dir1=" + dirs[0] + " dir2=" + dirs[1] + " dir3=" + dirs[2]
+	name := "TestCombineLocal"
+	fstests.Run(t, &fstests.Opt{
+		RemoteName: name + ":dir1",
+		ExtraConfig: []fstests.ExtraConfigItem{
+			{Name: name, Key: "type", Value: "combine"},
+			{Name: name, Key: "upstreams", Value: upstreams},
+		},
+		NilObject: (*Object)(nil),
+		UnimplementableFsMethods: []string{
+			"OpenWriterAt",
+			"MergeDirs",
+			"PutUnchecked",
+			"UserInfo",
+			"Disconnect",
+		},
+		UnimplementableObjectMethods: []string{"MimeType"},
+	})
+}
+
+// makeTestDirs makes n temporary directories for upstreams
+func makeTestDirs(t *testing.T, n int) (dirs []string) {
+	tmp := t.TempDir()
+	for i := 1; i <= n; i++ {
+		dir := filepath.Join(tmp, "upstream"+string(rune('0'+i)))
+		require.NoError(t, os.Mkdir(dir, 0777))
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// newTestFs makes a combine remote with upstreams "local" on a
+// temporary directory and "mem" on the memory backend
+func newTestFs(t *testing.T, root string) (*Fs, string) {
+	dirs := makeTestDirs(t, 1)
+	f, err := NewFs(context.Background(), "TestCombine", root, configmap.Simple{
+		"upstreams": `local=` + dirs[0] + ` "mem=:memory:combine` + strings.Replace(t.Name(), "/", "", -1) + `"`,
+	})
+	if err != fs.ErrorIsFile {
+		require.NoError(t, err)
+	}
+	return f.(*Fs), dirs[0]
+}
+
+func TestParseUpstream(t *testing.T) {
+	for _, test := range []struct {
+		in      string
+		dir     string
+		remote  string
+		wantErr bool
+	}{
+		{"dir=remote:path", "dir", "remote:path", false},
+		{"/dir/=remote:path=x", "dir", "remote:path=x", false},
+		{"dir with space=remote:", "dir with space", "remote:", false},
+		{"remote:path", "", "", true},
+		{"=remote:path", "", "", true},
+		{"a/b=remote:path", "", "", true},
+		{"..=remote:path", "", "", true},
+		{"dir=", "", "", true},
+	} {
+		dir, remote, err := parseUpstream(test.in)
+		if test.wantErr {
+			assert.Error(t, err, test.in)
+			continue
+		}
+		require.NoError(t, err, test.in)
+		assert.Equal(t, test.dir, dir, test.in)
+		assert.Equal(t, test.remote, remote, test.in)
+	}
+}
+
+func TestNewFsErrors(t *testing.T) {
+	ctx := context.Background()
+	for _, upstreams := range []string{
+		"",
+		"dir=remote:path dir=remote2:path",
+		"dir=TestCombine:path",
+		"potato",
+	} {
+		_, err := NewFs(ctx, "TestCombine", "", configmap.Simple{"upstreams": upstreams})
+		assert.Error(t, err, upstreams)
+	}
+	_, err := NewFs(ctx, "TestCombine", "potato", configmap.Simple{"upstreams": "dir=:memory:"})
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+}
+
+func TestCombineTop(t *testing.T) {
+	ctx := context.Background()
+	f, localDir := newTestFs(t, "")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(localDir, "file.txt"), []byte("hello"), 0600))
+
+	// the top level has the upstreams as directories
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(entries))
+	assert.Equal(t, "local", entries[0].Remote())
+	assert.Equal(t, "mem", entries[1].Remote())
+	_, err = f.List(ctx, "potato")
+	assert.Equal(t, fs.ErrorDirNotFound, err)
+
+	entries, err = f.List(ctx, "local")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+	assert.Equal(t, "local/file.txt", entries[0].Remote())
+
+	// upload to an upstream
+	modTime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	o, err := operations.Rcat(ctx, f, "mem/dir/new.txt", ioutil.NopCloser(strings.NewReader("potato")), modTime)
+	require.NoError(t, err)
+	assert.Equal(t, "mem/dir/new.txt", o.Remote())
+	_, err = operations.Rcat(ctx, f, "outside.txt", ioutil.NopCloser(strings.NewReader("potato")), modTime)
+	assert.Error(t, err)
+
+	// move between upstreams
+	src, err := f.NewObject(ctx, "local/file.txt")
+	require.NoError(t, err)
+	dst, err := operations.Move(ctx, f, nil, "mem/file.txt", src)
+	require.NoError(t, err)
+	assert.Equal(t, "mem/file.txt", dst.Remote())
+	_, err = os.Stat(filepath.Join(localDir, "file.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	// copy within an upstream is server-side
+	dst, err = f.Copy(ctx, dst, "mem/copy.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "mem/copy.txt", dst.Remote())
+
+	// but not between upstreams
+	_, err = f.Copy(ctx, dst, "local/copy.txt")
+	assert.Equal(t, fs.ErrorCantCopy, err)
+
+	// ListR sees everything
+	var remotes []string
+	err = walk.ListR(ctx, f, "", true, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			remotes = append(remotes, entry.Remote())
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"mem/copy.txt", "mem/dir/new.txt", "mem/file.txt"}, remotes)
+
+	// the upstream directories can't be removed
+	assert.Error(t, f.Rmdir(ctx, "local"))
+	assert.Error(t, f.Rmdir(ctx, ""))
+
+	// About adds up the upstreams which support it
+	usage, err := f.About(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, usage.Free)
+}
+
+func TestCombineRoot(t *testing.T) {
+	ctx := context.Background()
+	_, localDir := newTestFs(t, "")
+	require.NoError(t, os.MkdirAll(filepath.Join(localDir, "sub"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(localDir, "sub", "file.txt"), []byte("hello"), 0600))
+	f, err := NewFs(ctx, "TestCombine", "local/sub", configmap.Simple{
+		"upstreams": "local=" + localDir,
+	})
+	require.NoError(t, err)
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+	assert.Equal(t, "file.txt", entries[0].Remote())
+
+	// pointing at a file
+	f, err = NewFs(ctx, "TestCombine", "local/sub/file.txt", configmap.Simple{
+		"upstreams": "local=" + localDir,
+	})
+	assert.Equal(t, fs.ErrorIsFile, err)
+	assert.Equal(t, "local/sub", f.Root())
+	_, err = f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+}
@@ -12,6 +12,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"path"
 	"regexp"
 	"strings"
 	"time"
@@ -40,6 +41,7 @@ const (
 	minCompressionRatio = 1.1
 
 	gzFileExt           = ".gz"
+	zstdFileExt         = ".zst"
 	metaFileExt         = ".json"
 	uncompressedFileExt = ".bin"
 )
@@ -48,6 +50,7 @@ const (
 const (
 	Uncompressed = 0
 	Gzip         = 2
+	Zstd         = 3
 )
 
 var nameRegexp = regexp.MustCompile("^(.+?)\\.([A-Za-z0-9+_]{11})$")
@@ -59,6 +62,9 @@ func init() {
 		{ // Default compression mode options {
 			Value: "gzip",
 			Help:  "Standard gzip compression with fastest parameters.",
+		}, {
+			Value: "zstd",
+			Help:  "Zstandard compression, faster and stronger than gzip.",
 		},
 	}
 
@@ -78,26 +84,44 @@ func init() {
 			Examples: compressionModeOptions,
 		}, {
 			Name: "level",
-			Help: `GZIP compression level (-2 to 9).
-
-			Generally -1 (default, equivalent to 5) is recommended.
-			Levels 1 to 9 increase compressiong at the cost of speed.. Going past 6 
-			generally offers very little return.
-			
-			Level -2 uses Huffmann encoding only. Only use if you now what you
-			are doing
-			Level 0 turns off compression.`,
+			Help: `Compression level for the chosen mode.
+
+-1 (the default) uses the default level of each mode, which is
+recommended.
+
+For gzip the level is -2 to 9. Levels 1 to 9 increase compression at
+the cost of speed. Going past 6 generally offers very little return.
+Level -2 uses Huffman encoding only. Only use if you know what you
+are doing. Level 0 turns off compression.
+
+For zstd the level is 1 to 22 as for the zstd command, though levels
+are grouped so only fastest (1-2), default (3-5) and better (6 and
+above) are different.`,
 			Default:  sgzip.DefaultCompression,
 			Advanced: true,
+		}, {
+			Name: "no_compress",
+			Help: `Comma separated list of file types never to compress.
+
+Entries starting with "." are file extensions, e.g. ".jpg". Other
+entries are MIME types, detected from the contents of the file, which
+may end in "/*" to match all subtypes, e.g. "video/*".
+
+Files of these types are stored uncompressed without checking whether
+they would compress. Other files are compressed if a sample from the
+start of the file compresses well.`,
+			Default:  fs.CommaSepList{".7z", ".br", ".bz2", ".gz", ".lz4", ".rar", ".xz", ".zip", ".zst", "application/gzip", "application/x-7z-compressed", "application/x-bzip2", "application/x-xz", "application/zip", "application/zstd", "audio/*", "image/gif", "image/jpeg", "image/png", "image/webp", "video/*"},
+			Advanced: true,
 		}},
 	})
 }
 
 // Options defines the configuration for this backend
 type Options struct {
-	Remote           string `config:"remote"`
-	CompressionMode  string `config:"mode"`
-	CompressionLevel int    `config:"level"`
+	Remote           string          `config:"remote"`
+	CompressionMode  string          `config:"mode"`
+	CompressionLevel int             `config:"level"`
+	NoCompress       fs.CommaSepList `config:"no_compress"`
 }
 
 /*** FILESYSTEM FUNCTIONS ***/
@@ -127,6 +151,11 @@ func NewFs(ctx context.Context, name, rpath string, m configmap.Mapper) (fs.Fs,
 		return nil, errors.New("can't point press remote at itself - check the value of the remote setting")
 	}
 
+	mode := compressionModeFromName(opt.CompressionMode)
+	if err = checkCompressionLevel(mode, opt.CompressionLevel); err != nil {
+		return nil, err
+	}
+
 	wInfo, wName, wPath, wConfig, err := fs.ConfigFs(remote)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to parse remote %q to wrap", remote)
@@ -153,7 +182,7 @@ func NewFs(ctx context.Context, name, rpath string, m configmap.Mapper) (fs.Fs,
 		name: name,
 		root: rpath,
 		opt:  *opt,
-		mode: compressionModeFromName(opt.CompressionMode),
+		mode: mode,
 	}
 	// the features here are ones we could support, and they are
 	// ANDed with the ones from wrappedFs
@@ -181,11 +210,36 @@ func compressionModeFromName(name string) int {
 	switch name {
 	case "gzip":
 		return Gzip
+	case "zstd":
+		return Zstd
 	default:
 		return Uncompressed
 	}
 }
 
+// checkCompressionLevel checks level is valid for mode
+func checkCompressionLevel(mode int, level int) error {
+	switch mode {
+	case Gzip:
+		if level < sgzip.HuffmanOnly || level > sgzip.BestCompression {
+			return errors.Errorf("gzip compression level must be %d to %d, got %d", sgzip.HuffmanOnly, sgzip.BestCompression, level)
+		}
+	case Zstd:
+		if level != sgzip.DefaultCompression && (level < 1 || level > 22) {
+			return errors.Errorf("zstd compression level must be 1 to 22 or %d for the default, got %d", sgzip.DefaultCompression, level)
+		}
+	}
+	return nil
+}
+
+// compressedFileExt returns the extension of data files compressed with mode
+func compressedFileExt(mode int) string {
+	if mode == Zstd {
+		return zstdFileExt
+	}
+	return gzFileExt
+}
+
 // Converts an int64 to base64
 func int64ToBase64(number int64) string {
 	intBytes := make([]byte, 8)
@@ -215,6 +269,9 @@ func processFileName(compressedFileName string) (origFileName string, extension
 	if extension == uncompressedFileExt {
 		return nameWithSize, extension, -2, nil
 	}
+	if extension != gzFileExt && extension != zstdFileExt {
+		return "", "", 0, errors.New("Invalid file extension")
+	}
 	match := nameRegexp.FindStringSubmatch(nameWithSize)
 	if match == nil || len(match) != 3 {
 		return "", "", 0, errors.New("Invalid filename")
@@ -223,7 +280,7 @@ func processFileName(compressedFileName string) (origFileName string, extension
 	if err != nil {
 		return "", "", 0, errors.New("Could not decode size")
 	}
-	return match[1], gzFileExt, size, nil
+	return match[1], extension, size, nil
 }
 
 // Generates the file name for a metadata file
@@ -239,7 +296,7 @@ func isMetadataFile(filename string) bool {
 // makeDataName generates the file name for a data file with specified compression mode
 func makeDataName(remote string, size int64, mode int) (newRemote string) {
 	if mode != Uncompressed {
-		newRemote = remote + "." + int64ToBase64(size) + gzFileExt
+		newRemote = remote + "." + int64ToBase64(size) + compressedFileExt(mode)
 	} else {
 		newRemote = remote + uncompressedFileExt
 	}
@@ -352,13 +409,13 @@ func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
 		return nil, errors.New("error decoding metadata")
 	}
 	// Create our Object
-	o, err := f.Fs.NewObject(ctx, makeDataName(remote, meta.CompressionMetadata.Size, meta.Mode))
+	o, err := f.Fs.NewObject(ctx, makeDataName(remote, meta.Size, meta.Mode))
 	return f.newObject(o, mo, meta), err
 }
 
 // checkCompressAndType checks if an object is compressible and determines it's mime type
 // returns a multireader with the bytes that were read to determine mime type
-func checkCompressAndType(in io.Reader) (newReader io.Reader, compressible bool, mimeType string, err error) {
+func (f *Fs) checkCompressAndType(in io.Reader, remote string) (newReader io.Reader, compressible bool, mimeType string, err error) {
 	in, wrap := accounting.UnWrap(in)
 	buf := make([]byte, heuristicBytes)
 	n, err := in.Read(buf)
@@ -367,14 +424,50 @@ func checkCompressAndType(in io.Reader) (newReader io.Reader, compressible bool,
 		return nil, false, "", err
 	}
 	mime := mimetype.Detect(buf)
-	compressible, err = isCompressible(bytes.NewReader(buf))
-	if err != nil {
-		return nil, false, "", err
+	if f.noCompress(remote, mime) {
+		compressible = false
+	} else {
+		compressible, err = isCompressible(bytes.NewReader(buf))
+		if err != nil {
+			return nil, false, "", err
+		}
 	}
 	in = io.MultiReader(bytes.NewReader(buf), in)
 	return wrap(in), compressible, mime.String(), nil
 }
 
+// noCompress returns true if remote, with contents of type mime,
+// matches the no_compress list
+func (f *Fs) noCompress(remote string, mime *mimetype.MIME) bool {
+	ext := strings.ToLower(path.Ext(remote))
+	for _, entry := range f.opt.NoCompress {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+		case strings.HasPrefix(entry, "."):
+			if entry == ext {
+				return true
+			}
+		default:
+			// check the detected type and the types it is derived from
+			for m := mime; m != nil; m = m.Parent() {
+				mimeType := strings.ToLower(m.String())
+				if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+					mimeType = mimeType[:i]
+				}
+				if strings.HasSuffix(entry, "/*") {
+					if strings.HasPrefix(mimeType, entry[:len(entry)-1]) {
+						return true
+					}
+				} else if m.Is(entry) || mimeType == entry {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // isCompressible checks the compression ratio of the provided data and returns true if the ratio exceeds
 // the configured threshold
 func isCompressible(r io.Reader) (bool, error) {
@@ -412,8 +505,9 @@ func (f *Fs) verifyObjectHash(ctx context.Context, o fs.Object, hasher *hash.Mul
 type putFn func(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error)
 
 type compressionResult struct {
-	err  error
-	meta sgzip.GzipMetadata
+	err      error
+	meta     sgzip.GzipMetadata
+	zstdMeta *ZstdMetadata
 }
 
 // Put a compressed version of a file. Returns a wrappable object and metadata.
@@ -429,8 +523,21 @@ func (f *Fs) putCompress(ctx context.Context, in io.Reader, src fs.ObjectInfo, o
 	pipeReader, pipeWriter := io.Pipe()
 	results := make(chan compressionResult)
 	go func() {
-		gz, err := sgzip.NewWriterLevel(pipeWriter, f.opt.CompressionLevel)
+		var (
+			gz   io.WriteCloser
+			err  error
+			sgz  *sgzip.Writer
+			zstw *zstdWriter
+		)
+		if f.mode == Zstd {
+			zstw, err = newZstdWriter(pipeWriter, f.opt.CompressionLevel)
+			gz = zstw
+		} else {
+			sgz, err = sgzip.NewWriterLevel(pipeWriter, f.opt.CompressionLevel)
+			gz = sgz
+		}
 		if err != nil {
+			_ = pipeWriter.CloseWithError(err)
 			results <- compressionResult{err: err, meta: sgzip.GzipMetadata{}}
 			return
 		}
@@ -449,7 +556,12 @@ func (f *Fs) putCompress(ctx context.Context, in io.Reader, src fs.ObjectInfo, o
 				err = closeErr
 			}
 		}
-		results <- compressionResult{err: err, meta: gz.MetaData()}
+		if zstw != nil {
+			zstdMeta := zstw.MetaData()
+			results <- compressionResult{err: err, zstdMeta: &zstdMeta}
+		} else {
+			results <- compressionResult{err: err, meta: sgz.MetaData()}
+		}
 	}()
 	wrappedIn := wrap(bufio.NewReaderSize(pipeReader, bufferSize)) // Probably no longer needed as sgzip has it's own buffering
 
@@ -496,7 +608,11 @@ func (f *Fs) putCompress(ctx context.Context, in io.Reader, src fs.ObjectInfo, o
 	}
 
 	// Generate metadata
-	meta := newMetadata(result.meta.Size, f.mode, result.meta, hex.EncodeToString(metaHasher.Sum(nil)), mimeType)
+	size := result.meta.Size
+	if result.zstdMeta != nil {
+		size = result.zstdMeta.Size
+	}
+	meta := newMetadata(size, f.mode, result.meta, result.zstdMeta, hex.EncodeToString(metaHasher.Sum(nil)), mimeType)
 
 	// Check the hashes of the compressed data if we were comparing them
 	if ht != hash.None && hasher != nil {
@@ -550,7 +666,7 @@ func (f *Fs) putUncompress(ctx context.Context, in io.Reader, src fs.ObjectInfo,
 	if err != nil {
 		return nil, nil, err
 	}
-	return o, newMetadata(o.Size(), Uncompressed, sgzip.GzipMetadata{}, hex.EncodeToString(sum), mimeType), nil
+	return o, newMetadata(o.Size(), Uncompressed, sgzip.GzipMetadata{}, nil, hex.EncodeToString(sum), mimeType), nil
 }
 
 // This function will write a metadata struct to a metadata Object for an src. Returns a wrappable metadata object.
@@ -617,7 +733,7 @@ func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options .
 	o, err := f.NewObject(ctx, src.Remote())
 	if err == fs.ErrorObjectNotFound {
 		// Get our file compressibility
-		in, compressible, mimeType, err := checkCompressAndType(in)
+		in, compressible, mimeType, err := f.checkCompressAndType(in, src.Remote())
 		if err != nil {
 			return nil, err
 		}
@@ -637,7 +753,7 @@ func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, opt
 	}
 	found := err == nil
 
-	in, compressible, mimeType, err := checkCompressAndType(in)
+	in, compressible, mimeType, err := f.checkCompressAndType(in, src.Remote())
 	if err != nil {
 		return nil, err
 	}
@@ -941,6 +1057,7 @@ type ObjectMetadata struct {
 	MD5                 string // MD5 hash of the file.
 	MimeType            string // Mime type of the file
 	CompressionMetadata sgzip.GzipMetadata
+	ZstdMetadata        *ZstdMetadata `json:",omitempty"` // Frame index if Mode is Zstd
 }
 
 // Object with external metadata
@@ -954,11 +1071,12 @@ type Object struct {
 }
 
 // This function generates a metadata object
-func newMetadata(size int64, mode int, cmeta sgzip.GzipMetadata, md5 string, mimeType string) *ObjectMetadata {
+func newMetadata(size int64, mode int, cmeta sgzip.GzipMetadata, zmeta *ZstdMetadata, md5 string, mimeType string) *ObjectMetadata {
 	meta := new(ObjectMetadata)
 	meta.Size = size
 	meta.Mode = mode
 	meta.CompressionMetadata = cmeta
+	meta.ZstdMetadata = zmeta
 	meta.MD5 = md5
 	meta.MimeType = mimeType
 	return meta
@@ -1016,7 +1134,7 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 		return o.mo, o.mo.Update(ctx, in, src, options...)
 	}
 
-	in, compressible, mimeType, err := checkCompressAndType(in)
+	in, compressible, mimeType, err := o.f.checkCompressAndType(in, src.Remote())
 	if err != nil {
 		return err
 	}
@@ -1208,12 +1326,23 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (rc io.Read
 	chunkedReader := chunkedreader.New(ctx, o.Object, initialChunkSize, maxChunkSize)
 	// Get file handle
 	var file io.Reader
-	if offset != 0 {
+	var closer io.Closer = chunkedReader
+	switch {
+	case o.meta.Mode == Zstd:
+		zr, zErr := newZstdReader(chunkedReader, o.meta.ZstdMetadata, offset)
+		if zErr != nil {
+			_ = chunkedReader.Close()
+			return nil, zErr
+		}
+		file = zr
+		closer = multiCloser{zr, chunkedReader}
+	case offset != 0:
 		file, err = sgzip.NewReaderAt(chunkedReader, &o.meta.CompressionMetadata, offset)
-	} else {
+	default:
 		file, err = sgzip.NewReader(chunkedReader)
 	}
 	if err != nil {
+		_ = chunkedReader.Close()
 		return nil, err
 	}
 
@@ -1224,7 +1353,20 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (rc io.Read
 		fileReader = file
 	}
 	// Return a ReadCloser
-	return ReadCloserWrapper{Reader: fileReader, Closer: chunkedReader}, nil
+	return ReadCloserWrapper{Reader: fileReader, Closer: closer}, nil
+}
+
+// multiCloser closes all its Closers, returning the first error
+type multiCloser []io.Closer
+
+// Close all the Closers
+func (mc multiCloser) Close() (err error) {
+	for _, c := range mc {
+		if closeErr := c.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
 }
 
 // ObjectInfo describes a wrapped fs.ObjectInfo for being the source
@@ -6,10 +6,13 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/gabriel-vasile/mimetype"
 	_ "github.com/rclone/rclone/backend/dropbox"
 	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fstest"
 	"github.com/rclone/rclone/fstest/fstests"
+	"github.com/stretchr/testify/assert"
 )
 
 // TestIntegration runs integration tests against the remote
@@ -66,3 +69,80 @@ func TestRemoteGzip(t *testing.T) {
 		},
 	})
 }
+
+// TestRemoteZstd tests ZSTD compression
+func TestRemoteZstd(t *testing.T) {
+	if *fstest.RemoteName != "" {
+		t.Skip("Skipping as -remote set")
+	}
+	tempdir := filepath.Join(os.TempDir(), "rclone-compress-test-zstd")
+	name := "TestCompressZstd"
+	fstests.Run(t, &fstests.Opt{
+		RemoteName: name + ":",
+		NilObject:  (*Object)(nil),
+		UnimplementableFsMethods: []string{
+			"OpenWriterAt",
+			"MergeDirs",
+			"DirCacheFlush",
+			"PutUnchecked",
+			"PutStream",
+			"UserInfo",
+			"Disconnect",
+		},
+		UnimplementableObjectMethods: []string{
+			"GetTier",
+			"SetTier",
+		},
+		ExtraConfig: []fstests.ExtraConfigItem{
+			{Name: name, Key: "type", Value: "compress"},
+			{Name: name, Key: "remote", Value: tempdir},
+			{Name: name, Key: "mode", Value: "zstd"},
+			{Name: name, Key: "level", Value: "7"},
+		},
+	})
+}
+
+func TestCheckCompressionLevel(t *testing.T) {
+	assert.NoError(t, checkCompressionLevel(Gzip, -1))
+	assert.NoError(t, checkCompressionLevel(Gzip, 9))
+	assert.Error(t, checkCompressionLevel(Gzip, 10))
+	assert.NoError(t, checkCompressionLevel(Zstd, -1))
+	assert.NoError(t, checkCompressionLevel(Zstd, 22))
+	assert.Error(t, checkCompressionLevel(Zstd, 0))
+	assert.Error(t, checkCompressionLevel(Zstd, 23))
+}
+
+func TestProcessFileName(t *testing.T) {
+	for _, mode := range []int{Gzip, Zstd, Uncompressed} {
+		name := makeDataName("dir/file.txt", 12345, mode)
+		origName, _, size, err := processFileName(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, "dir/file.txt", origName, name)
+		if mode == Uncompressed {
+			assert.Equal(t, int64(-2), size, name)
+		} else {
+			assert.Equal(t, int64(12345), size, name)
+		}
+	}
+	_, _, _, err := processFileName("file.AAAAAAAAAAA.potato")
+	assert.Error(t, err)
+}
+
+func TestNoCompress(t *testing.T) {
+	f := &Fs{opt: Options{NoCompress: fs.CommaSepList{".JPG", "application/zip", "video/*"}}}
+	text := mimetype.Detect([]byte("hello world"))
+	zip := mimetype.Detect([]byte("PK\x03\x04"))
+	for _, test := range []struct {
+		remote string
+		mime   *mimetype.MIME
+		want   bool
+	}{
+		{"file.txt", text, false},
+		{"dir/file.jpg", text, true},
+		{"file.jpg.txt", text, false},
+		{"file.txt", zip, true},
+		{"file.txt", mimetype.Detect([]byte("\x1aE\xdf\xa3\x93B\x82\x88matroska")), true},
+	} {
+		assert.Equal(t, test.want, f.noCompress(test.remote, test.mime), test.remote+" "+test.mime.String())
+	}
+}
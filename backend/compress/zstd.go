@@ -0,0 +1,204 @@
+package compress
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// zstdBlockSize is the amount of uncompressed data in each zstd
+// frame. Each frame is compressed independently so reading can start
+// at the beginning of any frame.
+const zstdBlockSize = 1048576
+
+// ZstdMetadata describes the frames of a seekable zstd stream.
+type ZstdMetadata struct {
+	BlockSize int      // Uncompressed size of each frame, except the last which may be shorter
+	Size      int64    // Uncompressed size of the stream
+	BlockData []uint32 // Compressed size of each frame
+}
+
+// zstdEncoderLevel converts a compression level from the config into
+// an encoder level, using the zstd default if level is not positive
+func zstdEncoderLevel(level int) zstd.EncoderLevel {
+	if level <= 0 {
+		return zstd.SpeedDefault
+	}
+	return zstd.EncoderLevelFromZstd(level)
+}
+
+// zstdWriter compresses data written to it into a series of
+// independent zstd frames, recording the size of each one.
+//
+// The output is a valid zstd stream which can be decompressed by any
+// zstd tool.
+type zstdWriter struct {
+	w    io.Writer
+	enc  *zstd.Encoder
+	buf  []byte
+	out  []byte
+	meta ZstdMetadata
+}
+
+// newZstdWriter returns a zstdWriter compressing to w at level
+func newZstdWriter(w io.Writer, level int) (*zstdWriter, error) {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstdEncoderLevel(level)), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &zstdWriter{
+		w:    w,
+		enc:  enc,
+		buf:  make([]byte, 0, zstdBlockSize),
+		meta: ZstdMetadata{BlockSize: zstdBlockSize},
+	}, nil
+}
+
+// flush compresses and writes out the buffered data as one frame
+func (z *zstdWriter) flush() error {
+	if len(z.buf) == 0 {
+		return nil
+	}
+	z.out = z.enc.EncodeAll(z.buf, z.out[:0])
+	if _, err := z.w.Write(z.out); err != nil {
+		return err
+	}
+	z.meta.Size += int64(len(z.buf))
+	z.meta.BlockData = append(z.meta.BlockData, uint32(len(z.out)))
+	z.buf = z.buf[:0]
+	return nil
+}
+
+// Write compresses p
+func (z *zstdWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		chunk := zstdBlockSize - len(z.buf)
+		if chunk > len(p) {
+			chunk = len(p)
+		}
+		z.buf = append(z.buf, p[:chunk]...)
+		p = p[chunk:]
+		n += chunk
+		if len(z.buf) == zstdBlockSize {
+			if err = z.flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Close writes out the final frame
+func (z *zstdWriter) Close() error {
+	err := z.flush()
+	closeErr := z.enc.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// MetaData returns the frame index of the data written so far
+func (z *zstdWriter) MetaData() ZstdMetadata {
+	return z.meta
+}
+
+// zstdReader decompresses a seekable zstd stream a frame at a time
+type zstdReader struct {
+	r     io.Reader
+	dec   *zstd.Decoder
+	meta  *ZstdMetadata
+	block int    // index of the next frame to read
+	in    []byte // compressed frame
+	out   []byte // decompressed frame
+	pos   int    // read position in out
+}
+
+// newZstdReader returns a reader for the uncompressed stream from
+// offset.
+//
+// rs must be the start of the compressed stream. It is seeked to the
+// frame containing offset.
+func newZstdReader(rs io.ReadSeeker, meta *ZstdMetadata, offset int64) (*zstdReader, error) {
+	if meta == nil || meta.BlockSize <= 0 {
+		return nil, errors.New("missing zstd metadata")
+	}
+	if offset < 0 {
+		return nil, errors.New("negative offset")
+	}
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	z := &zstdReader{
+		r:    rs,
+		dec:  dec,
+		meta: meta,
+	}
+	if offset >= meta.Size {
+		z.block = len(meta.BlockData)
+		return z, nil
+	}
+	z.block = int(offset / int64(meta.BlockSize))
+	var compressedOffset int64
+	for _, size := range meta.BlockData[:z.block] {
+		compressedOffset += int64(size)
+	}
+	if compressedOffset != 0 {
+		if _, err = rs.Seek(compressedOffset, io.SeekStart); err != nil {
+			dec.Close()
+			return nil, err
+		}
+	}
+	if err = z.next(); err != nil {
+		dec.Close()
+		return nil, err
+	}
+	z.pos = int(offset % int64(meta.BlockSize))
+	return z, nil
+}
+
+// next reads and decompresses the next frame
+func (z *zstdReader) next() error {
+	if z.block >= len(z.meta.BlockData) {
+		return io.EOF
+	}
+	size := int(z.meta.BlockData[z.block])
+	if cap(z.in) < size {
+		z.in = make([]byte, size)
+	}
+	z.in = z.in[:size]
+	if _, err := io.ReadFull(z.r, z.in); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return errors.Wrap(err, "failed to read zstd frame")
+	}
+	var err error
+	z.out, err = z.dec.DecodeAll(z.in, z.out[:0])
+	if err != nil {
+		return errors.Wrap(err, "failed to decompress zstd frame")
+	}
+	z.block++
+	z.pos = 0
+	return nil
+}
+
+// Read decompressed data into p
+func (z *zstdReader) Read(p []byte) (n int, err error) {
+	for z.pos >= len(z.out) {
+		if err = z.next(); err != nil {
+			return 0, err
+		}
+	}
+	n = copy(p, z.out[z.pos:])
+	z.pos += n
+	return n, nil
+}
+
+// Close releases the decoder
+func (z *zstdReader) Close() error {
+	z.dec.Close()
+	return nil
+}
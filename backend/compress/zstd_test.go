@@ -0,0 +1,108 @@
+package compress
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeTestData makes size bytes of partly compressible data
+func makeTestData(size int) []byte {
+	r := rand.New(rand.NewSource(1))
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = "abcdefgh"[r.Intn(8)]
+	}
+	return data
+}
+
+// zstdCompress compresses data with a zstdWriter
+func zstdCompress(t *testing.T, data []byte) ([]byte, *ZstdMetadata) {
+	var buf bytes.Buffer
+	zw, err := newZstdWriter(&buf, -1)
+	require.NoError(t, err)
+	// write in odd sized pieces to cross the frame boundaries
+	for in := data; len(in) > 0; {
+		n := 100000
+		if n > len(in) {
+			n = len(in)
+		}
+		_, err = zw.Write(in[:n])
+		require.NoError(t, err)
+		in = in[n:]
+	}
+	require.NoError(t, zw.Close())
+	meta := zw.MetaData()
+	return buf.Bytes(), &meta
+}
+
+func TestZstdSeekable(t *testing.T) {
+	data := makeTestData(2*zstdBlockSize + 12345)
+	compressed, meta := zstdCompress(t, data)
+	assert.Equal(t, int64(len(data)), meta.Size)
+	assert.Equal(t, zstdBlockSize, meta.BlockSize)
+	require.Equal(t, 3, len(meta.BlockData))
+	var total int64
+	for _, size := range meta.BlockData {
+		total += int64(size)
+	}
+	assert.Equal(t, int64(len(compressed)), total)
+	assert.True(t, total < int64(len(data)))
+
+	// the whole stream can be read by a standard zstd decoder
+	dec, err := zstd.NewReader(bytes.NewReader(compressed))
+	require.NoError(t, err)
+	got, err := ioutil.ReadAll(dec)
+	dec.Close()
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	// the metadata survives being stored
+	metaJSON, err := json.Marshal(meta)
+	require.NoError(t, err)
+	meta = new(ZstdMetadata)
+	require.NoError(t, json.Unmarshal(metaJSON, meta))
+
+	for _, offset := range []int64{0, 1, zstdBlockSize - 1, zstdBlockSize, zstdBlockSize + 1, 2*zstdBlockSize + 12344, int64(len(data)), int64(len(data)) + 10} {
+		zr, err := newZstdReader(bytes.NewReader(compressed), meta, offset)
+		require.NoError(t, err, offset)
+		got, err := ioutil.ReadAll(zr)
+		require.NoError(t, err, offset)
+		require.NoError(t, zr.Close())
+		want := []byte{}
+		if offset < int64(len(data)) {
+			want = data[offset:]
+		}
+		assert.Equal(t, want, append([]byte{}, got...), offset)
+	}
+}
+
+func TestZstdEmpty(t *testing.T) {
+	compressed, meta := zstdCompress(t, nil)
+	assert.Equal(t, 0, len(compressed))
+	assert.Equal(t, int64(0), meta.Size)
+	zr, err := newZstdReader(bytes.NewReader(compressed), meta, 0)
+	require.NoError(t, err)
+	n, err := zr.Read(make([]byte, 10))
+	assert.Equal(t, 0, n)
+	assert.Equal(t, io.EOF, err)
+	require.NoError(t, zr.Close())
+}
+
+func TestZstdCorrupt(t *testing.T) {
+	data := makeTestData(1000)
+	compressed, meta := zstdCompress(t, data)
+
+	_, err := newZstdReader(bytes.NewReader(compressed), nil, 0)
+	assert.Error(t, err)
+
+	_, err = newZstdReader(bytes.NewReader(compressed[:len(compressed)/2]), meta, 0)
+	assert.Error(t, err)
+}
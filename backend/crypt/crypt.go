@@ -7,6 +7,7 @@ import (
 	"io"
 	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -101,6 +102,51 @@ names, or for debugging purposes.`,
 			Default:  false,
 			Hide:     fs.OptionHideConfigurator,
 			Advanced: true,
+		}, {
+			Name: "filename_encoding",
+			Help: `How to encode the encrypted filename to text string.
+
+This option could help with shortening the encrypted filename. The
+suitable option would depend on the way your remote count the filename
+length and if it's case sensitive.
+
+NB If filename_encryption is not "standard" then this option will do
+nothing.`,
+			Default: "base32",
+			Examples: []fs.OptionExample{
+				{
+					Value: "base32",
+					Help:  "Encode using base32. Suitable for all remotes.",
+				},
+				{
+					Value: "base64",
+					Help:  "Encode using base64. Suitable for case sensitive remotes.",
+				},
+				{
+					Value: "base32768",
+					Help:  "Encode using base32768. Suitable if your remote counts UTF-16 or\nUnicode codepoints instead of UTF-8 byte length, e.g. OneDrive.",
+				},
+			},
+			Advanced: true,
+		}, {
+			Name: "manifest",
+			Help: `Keep an authenticated manifest in each directory.
+
+If this is set then crypt stores an encrypted and authenticated file
+called ".rclone_manifest" in each directory listing the name, size and
+nonce of every file in it. This is updated whenever files are
+uploaded, moved or deleted through crypt.
+
+Listing a directory checks it against its manifest and reports files
+which have been deleted, added or changed size. Opening a file checks
+its nonce against the manifest so files which have been swapped or
+rolled back to an earlier version are refused. cryptcheck reports
+these files as tampered.
+
+Use "rclone backend manifest" to build the manifests for files
+uploaded before this was set.`,
+			Default:  false,
+			Advanced: true,
 		}},
 	})
 }
@@ -111,6 +157,10 @@ func newCipherForConfig(opt *Options) (*Cipher, error) {
 	if err != nil {
 		return nil, err
 	}
+	enc, err := newNameEncoding(opt.FilenameEncoding)
+	if err != nil {
+		return nil, err
+	}
 	if opt.Password == "" {
 		return nil, errors.New("password not set in config file")
 	}
@@ -125,7 +175,7 @@ func newCipherForConfig(opt *Options) (*Cipher, error) {
 			return nil, errors.Wrap(err, "failed to decrypt password2")
 		}
 	}
-	cipher, err := newCipher(mode, password, salt, opt.DirectoryNameEncryption)
+	cipher, err := newCipher(mode, password, salt, opt.DirectoryNameEncryption, enc)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to make cipher")
 	}
@@ -180,11 +230,12 @@ func NewFs(ctx context.Context, name, rpath string, m configmap.Mapper) (fs.Fs,
 		return nil, errors.Wrapf(err, "failed to make remote %q to wrap", remote)
 	}
 	f := &Fs{
-		Fs:     wrappedFs,
-		name:   name,
-		root:   rpath,
-		opt:    *opt,
-		cipher: cipher,
+		Fs:        wrappedFs,
+		name:      name,
+		root:      rpath,
+		opt:       *opt,
+		cipher:    cipher,
+		manifests: map[string]*manifest{},
 	}
 	cache.PinUntilFinalized(f.Fs, f)
 	// the features here are ones we could support, and they are
@@ -213,6 +264,8 @@ type Options struct {
 	Password2               string `config:"password2"`
 	ServerSideAcrossConfigs bool   `config:"server_side_across_configs"`
 	ShowMapping             bool   `config:"show_mapping"`
+	FilenameEncoding        string `config:"filename_encoding"`
+	Manifest                bool   `config:"manifest"`
 }
 
 // Fs represents a wrapped fs.Fs
@@ -224,6 +277,9 @@ type Fs struct {
 	opt      Options
 	features *fs.Features // optional features
 	cipher   *Cipher
+
+	manifestMu sync.Mutex           // protects manifests
+	manifests  map[string]*manifest // cached manifests by underlying directory
 }
 
 // Name of the remote (as passed into NewFs)
@@ -249,6 +305,9 @@ func (f *Fs) String() string {
 // Encrypt an object file name to entries.
 func (f *Fs) add(entries *fs.DirEntries, obj fs.Object) {
 	remote := obj.Remote()
+	if isManifest(remote) {
+		return
+	}
 	decryptedRemote, err := f.cipher.DecryptFileName(remote)
 	if err != nil {
 		fs.Debugf(remote, "Skipping undecryptable file name: %v", err)
@@ -300,10 +359,12 @@ func (f *Fs) encryptEntries(ctx context.Context, entries fs.DirEntries) (newEntr
 // This should return ErrDirNotFound if the directory isn't
 // found.
 func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err error) {
-	entries, err = f.Fs.List(ctx, f.cipher.EncryptDirName(dir))
+	encryptedDir := f.cipher.EncryptDirName(dir)
+	entries, err = f.Fs.List(ctx, encryptedDir)
 	if err != nil {
 		return nil, err
 	}
+	f.manifestVerify(ctx, encryptedDir, entries)
 	return f.encryptEntries(ctx, entries)
 }
 
@@ -371,7 +432,8 @@ func (f *Fs) put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options [
 	}
 
 	// Transfer the data
-	o, err := put(ctx, wrappedIn, f.newObjectInfo(src, encrypter.nonce), options...)
+	info := f.newObjectInfo(src, encrypter.nonce)
+	o, err := put(ctx, wrappedIn, info, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -394,6 +456,10 @@ func (f *Fs) put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options [
 		}
 	}
 
+	err = f.manifestAdd(ctx, o, info.nonce)
+	if err != nil {
+		return f.newObject(o), err
+	}
 	return f.newObject(o), nil
 }
 
@@ -427,7 +493,9 @@ func (f *Fs) Mkdir(ctx context.Context, dir string) error {
 //
 // Return an error if it doesn't exist or isn't empty
 func (f *Fs) Rmdir(ctx context.Context, dir string) error {
-	return f.Fs.Rmdir(ctx, f.cipher.EncryptDirName(dir))
+	encryptedDir := f.cipher.EncryptDirName(dir)
+	f.manifestForget(encryptedDir)
+	return f.Fs.Rmdir(ctx, encryptedDir)
 }
 
 // Purge all files in the directory specified
@@ -441,7 +509,9 @@ func (f *Fs) Purge(ctx context.Context, dir string) error {
 	if do == nil {
 		return fs.ErrorCantPurge
 	}
-	return do(ctx, f.cipher.EncryptDirName(dir))
+	encryptedDir := f.cipher.EncryptDirName(dir)
+	defer f.manifestForget(encryptedDir)
+	return do(ctx, encryptedDir)
 }
 
 // Copy src to this remote using server-side copy operations.
@@ -462,10 +532,22 @@ func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	if !ok {
 		return nil, fs.ErrorCantCopy
 	}
+	var n nonce
+	if f.opt.Manifest {
+		var err error
+		n, err = o.nonce(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
 	oResult, err := do(ctx, o.Object, f.cipher.EncryptFileName(remote))
 	if err != nil {
 		return nil, err
 	}
+	err = f.manifestAdd(ctx, oResult, n)
+	if err != nil {
+		return f.newObject(oResult), err
+	}
 	return f.newObject(oResult), nil
 }
 
@@ -487,10 +569,27 @@ func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	if !ok {
 		return nil, fs.ErrorCantMove
 	}
+	var n nonce
+	if f.opt.Manifest {
+		var err error
+		n, err = o.nonce(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	srcRemote := o.Object.Remote()
 	oResult, err := do(ctx, o.Object, f.cipher.EncryptFileName(remote))
 	if err != nil {
 		return nil, err
 	}
+	err = f.manifestAdd(ctx, oResult, n)
+	if err != nil {
+		return f.newObject(oResult), err
+	}
+	err = o.f.manifestRemove(ctx, srcRemote)
+	if err != nil {
+		return f.newObject(oResult), err
+	}
 	return f.newObject(oResult), nil
 }
 
@@ -512,7 +611,12 @@ func (f *Fs) DirMove(ctx context.Context, src fs.Fs, srcRemote, dstRemote string
 		fs.Debugf(srcFs, "Can't move directory - not same remote type")
 		return fs.ErrorCantDirMove
 	}
-	return do(ctx, srcFs.Fs, f.cipher.EncryptDirName(srcRemote), f.cipher.EncryptDirName(dstRemote))
+	encryptedSrcRemote := srcFs.cipher.EncryptDirName(srcRemote)
+	encryptedDstRemote := f.cipher.EncryptDirName(dstRemote)
+	// the manifests move with the directories
+	defer srcFs.manifestForget(encryptedSrcRemote)
+	defer f.manifestForget(encryptedDstRemote)
+	return do(ctx, srcFs.Fs, encryptedSrcRemote, encryptedDstRemote)
 }
 
 // PutUnchecked uploads the object
@@ -528,10 +632,15 @@ func (f *Fs) PutUnchecked(ctx context.Context, in io.Reader, src fs.ObjectInfo,
 	if err != nil {
 		return nil, err
 	}
-	o, err := do(ctx, wrappedIn, f.newObjectInfo(src, encrypter.nonce))
+	info := f.newObjectInfo(src, encrypter.nonce)
+	o, err := do(ctx, wrappedIn, info)
 	if err != nil {
 		return nil, err
 	}
+	err = f.manifestAdd(ctx, o, info.nonce)
+	if err != nil {
+		return f.newObject(o), err
+	}
 	return f.newObject(o), nil
 }
 
@@ -617,18 +726,10 @@ func (f *Fs) computeHashWithNonce(ctx context.Context, nonce nonce, src fs.Objec
 //
 // Note that we break lots of encapsulation in this function.
 func (f *Fs) ComputeHash(ctx context.Context, o *Object, src fs.Object, hashType hash.Type) (hashStr string, err error) {
-	// Read the nonce - opening the file is sufficient to read the nonce in
-	// use a limited read so we only read the header
-	in, err := o.Object.Open(ctx, &fs.RangeOption{Start: 0, End: int64(fileHeaderSize) - 1})
+	nonce, err := f.readNonce(ctx, o.Object)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to open object to read nonce")
+		return "", err
 	}
-	d, err := f.cipher.newDecrypter(in)
-	if err != nil {
-		_ = in.Close()
-		return "", errors.Wrap(err, "failed to open object to read nonce")
-	}
-	nonce := d.nonce
 	// fs.Debugf(o, "Read nonce % 2x", nonce)
 
 	// Check nonce isn't all zeros
@@ -642,15 +743,39 @@ func (f *Fs) ComputeHash(ctx context.Context, o *Object, src fs.Object, hashType
 		fs.Errorf(o, "empty nonce read")
 	}
 
-	// Close d (and hence in) once we have read the nonce
-	err = d.Close()
+	// Check the nonce against the directory manifest
+	err = f.manifestCheckNonce(ctx, o.Object, nonce)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to close nonce read")
+		return "", err
 	}
 
 	return f.computeHashWithNonce(ctx, nonce, src, hashType)
 }
 
+// readNonce reads the initial nonce from the header of the
+// underlying object o
+func (f *Fs) readNonce(ctx context.Context, o fs.Object) (n nonce, err error) {
+	// Read the nonce - opening the file is sufficient to read the nonce in
+	// use a limited read so we only read the header
+	in, err := o.Open(ctx, &fs.RangeOption{Start: 0, End: int64(fileHeaderSize) - 1})
+	if err != nil {
+		return n, errors.Wrap(err, "failed to open object to read nonce")
+	}
+	d, err := f.cipher.newDecrypter(in)
+	if err != nil {
+		_ = in.Close()
+		return n, errors.Wrap(err, "failed to open object to read nonce")
+	}
+	n = d.nonce
+
+	// Close d (and hence in) once we have read the nonce
+	err = d.Close()
+	if err != nil {
+		return n, errors.Wrap(err, "failed to close nonce read")
+	}
+	return n, nil
+}
+
 // MergeDirs merges the contents of all the directories passed
 // in into the first one and rmdirs the other directories.
 func (f *Fs) MergeDirs(ctx context.Context, dirs []fs.Directory) error {
@@ -744,6 +869,22 @@ Usage Example:
 
     rclone backend decode crypt: encryptedfile1 [encryptedfile2...]
     rclone rc backend/command command=decode fs=crypt: encryptedfile1 [encryptedfile2...]
+`,
+	},
+	{
+		Name:  "manifest",
+		Short: "Rebuild the directory manifests",
+		Long: `This rebuilds the directory manifests of the remote from the files
+found there. It returns the number of manifests written.
+
+Use this after setting the manifest option on a remote with existing
+files, or to accept changes made to the remote outside rclone. Any
+tampering done before this is run will not be detected.
+
+Usage Example:
+
+    rclone backend manifest crypt:path
+    rclone rc backend/command command=manifest fs=crypt:path
 `,
 	},
 }
@@ -776,6 +917,11 @@ func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[str
 			out = append(out, encryptedFileName)
 		}
 		return out, nil
+	case "manifest":
+		if !f.opt.Manifest {
+			return nil, errors.New("manifest option not set on this remote")
+		}
+		return f.rebuildManifests(ctx, "")
 	default:
 		return nil, fs.ErrorCommandNotFound
 	}
@@ -874,6 +1020,13 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (rc io.Read
 	if err != nil {
 		return nil, err
 	}
+	if d, ok := rc.(*decrypter); ok {
+		err = o.f.manifestCheckNonce(ctx, o.Object, d.initialNonce)
+		if err != nil {
+			_ = rc.Close()
+			return nil, err
+		}
+	}
 	return rc, nil
 }
 
@@ -886,6 +1039,28 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	return err
 }
 
+// Remove an object
+func (o *Object) Remove(ctx context.Context) error {
+	err := o.Object.Remove(ctx)
+	if err != nil {
+		return err
+	}
+	return o.f.manifestRemove(ctx, o.Object.Remote())
+}
+
+// nonce returns the initial nonce of the object, from the directory
+// manifest if there is one, otherwise from the file header
+func (o *Object) nonce(ctx context.Context) (n nonce, err error) {
+	if !o.f.opt.Manifest {
+		return o.f.readNonce(ctx, o.Object)
+	}
+	n, err = o.f.manifestNonce(ctx, o.Object, false)
+	if err != nil {
+		n, err = o.f.manifestNonce(ctx, o.Object, true)
+	}
+	return n, err
+}
+
 // newDir returns a dir with the Name decrypted
 func (f *Fs) newDir(ctx context.Context, dir fs.Directory) fs.Directory {
 	newDir := fs.NewDirCopy(ctx, dir)
@@ -6,9 +6,11 @@ import (
 	"crypto/md5"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/object"
@@ -135,9 +137,165 @@ func testComputeHash(t *testing.T, f *Fs) {
 	assert.Equal(t, remoteObjHash, computedHash)
 }
 
+// Read the decrypted contents of an object
+func readObject(ctx context.Context, o fs.Object) (string, error) {
+	in, err := o.Open(ctx)
+	if err != nil {
+		return "", err
+	}
+	data, err := ioutil.ReadAll(in)
+	fs.CheckClose(in, &err)
+	return string(data), err
+}
+
+// Read the raw contents of an underlying object
+func readRaw(t *testing.T, o fs.Object) []byte {
+	in, err := o.Open(context.Background())
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	return data
+}
+
+// Overwrite the raw contents of an underlying object
+//
+// This uses a new object so o notices the change as an external one
+func writeRaw(t *testing.T, f fs.Fs, o fs.Object, data []byte) {
+	ctx := context.Background()
+	newO, err := f.NewObject(ctx, o.Remote())
+	require.NoError(t, err)
+	src := object.NewStaticObjectInfo(o.Remote(), time.Now(), int64(len(data)), true, nil, nil)
+	require.NoError(t, newO.Update(ctx, bytes.NewReader(data), src))
+}
+
+func testManifest(t *testing.T, f *Fs) {
+	if !f.opt.Manifest {
+		t.Skip("manifest option not set")
+	}
+	var (
+		ctx    = context.Background()
+		dir    = "manifest_test"
+		encDir = f.cipher.EncryptDirName(dir)
+	)
+	problems := func() []string {
+		entries, err := f.Fs.List(ctx, encDir)
+		require.NoError(t, err)
+		return f.manifestProblems(ctx, encDir, entries)
+	}
+	assertMismatch := func(o fs.Object, want error) {
+		_, err := readObject(ctx, o)
+		require.Error(t, err)
+		assert.Equal(t, want, errors.Cause(err))
+	}
+	rebuild := func() {
+		_, err := f.Command(ctx, "manifest", nil, nil)
+		require.NoError(t, err)
+	}
+
+	objA, _ := uploadFile(t, f, dir+"/a.txt", "hello")
+	objB, _ := uploadFile(t, f, dir+"/b.txt", "world")
+	rawA, rawB := objA.(*Object).Object, objB.(*Object).Object
+
+	// Check a clean directory
+	assert.Empty(t, problems())
+	entries, err := f.List(ctx, dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(entries))
+	got, err := readObject(ctx, objA)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", got)
+
+	// Swap the contents of two files of the same size
+	dataA, dataB := readRaw(t, rawA), readRaw(t, rawB)
+	writeRaw(t, f.Fs, rawA, dataB)
+	writeRaw(t, f.Fs, rawB, dataA)
+	assert.Empty(t, problems())
+	assertMismatch(objA, ErrorManifestMismatch)
+	assertMismatch(objB, ErrorManifestMismatch)
+	writeRaw(t, f.Fs, rawA, dataA)
+	writeRaw(t, f.Fs, rawB, dataB)
+	_, err = readObject(ctx, objA)
+	require.NoError(t, err)
+
+	// Roll a file back to an earlier version
+	uploadFile(t, f, dir+"/a.txt", "HELLO!")
+	got, err = readObject(ctx, objA)
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO!", got)
+	writeRaw(t, f.Fs, rawA, dataA)
+	assertMismatch(objA, ErrorManifestMismatch)
+	assert.Equal(t, 1, len(problems()))
+
+	// Add a file behind the manifest's back
+	objC, _ := uploadFile(t, f, dir+"/c.txt", "potato")
+	require.NoError(t, f.manifestRemove(ctx, objC.(*Object).Object.Remote()))
+	assert.Equal(t, 2, len(problems()))
+	assertMismatch(objC, ErrorManifestMismatch)
+
+	// Rebuilding the manifests accepts the current state
+	rebuild()
+	assert.Empty(t, problems())
+	got, err = readObject(ctx, objA)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", got)
+
+	// Delete a file behind the manifest's back
+	require.NoError(t, rawB.Remove(ctx))
+	assert.Equal(t, 1, len(problems()))
+	rebuild()
+	assert.Empty(t, problems())
+
+	// Tamper with the manifest itself
+	rawManifest, err := f.Fs.NewObject(ctx, manifestRemote(encDir))
+	require.NoError(t, err)
+	data := readRaw(t, rawManifest)
+	data[len(data)-1] ^= 1
+	writeRaw(t, f.Fs, rawManifest, data)
+	f.manifestForget(encDir)
+	assert.Equal(t, []string{ErrorManifestBad.Error()}, problems())
+	assertMismatch(objA, ErrorManifestBad)
+	rebuild()
+	assert.Empty(t, problems())
+
+	// Moving and copying keep the manifests up to date
+	toRemove := []fs.Object{objA}
+	if f.Features().Copy != nil {
+		objD, err := f.Copy(ctx, objA, dir+"/sub/d.txt")
+		require.NoError(t, err)
+		got, err = readObject(ctx, objD)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", got)
+		toRemove = append(toRemove, objD)
+	}
+	if f.Features().Move != nil {
+		objE, err := f.Move(ctx, objC, dir+"/sub/e.txt")
+		require.NoError(t, err)
+		got, err = readObject(ctx, objE)
+		require.NoError(t, err)
+		assert.Equal(t, "potato", got)
+		toRemove = append(toRemove, objE)
+	} else {
+		toRemove = append(toRemove, objC)
+	}
+	assert.Empty(t, problems())
+
+	// Removing all the files removes the manifest
+	for _, o := range toRemove {
+		require.NoError(t, o.Remove(ctx))
+	}
+	_, err = f.Fs.NewObject(ctx, manifestRemote(encDir))
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+	if f.Features().Copy != nil || f.Features().Move != nil {
+		require.NoError(t, f.Rmdir(ctx, dir+"/sub"))
+	}
+	require.NoError(t, f.Rmdir(ctx, dir))
+}
+
 // InternalTest is called by fstests.Run to extra tests
 func (f *Fs) InternalTest(t *testing.T) {
 	t.Run("ObjectInfo", func(t *testing.T) { testObjectInfo(t, f, false) })
 	t.Run("ObjectInfoWrap", func(t *testing.T) { testObjectInfo(t, f, true) })
 	t.Run("ComputeHash", func(t *testing.T) { testComputeHash(t, f) })
+	t.Run("Manifest", func(t *testing.T) { testManifest(t, f) })
 }
@@ -0,0 +1,412 @@
+// Authenticated per-directory manifests
+
+package crypt
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/fs/walk"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Constants for the manifests
+const (
+	manifestName    = ".rclone_manifest"      // leaf name of the manifest in each directory
+	manifestMagic   = "RCLONEM\x00"           // magic at the start of each manifest
+	manifestKeyInfo = "rclone crypt manifest" // used to derive the manifest key
+	manifestVersion = 1                       // version of the JSON inside the manifest
+	manifestMaxSize = 64 * 1024 * 1024        // refuse to read manifests bigger than this
+)
+
+// Errors returned by the manifests
+var (
+	ErrorManifestMismatch = errors.New("file doesn't match the directory manifest - has it been tampered with?")
+	ErrorManifestBad      = errors.New("failed to authenticate directory manifest - has it been tampered with?")
+	ErrorManifestTooShort = errors.New("directory manifest too short")
+	ErrorManifestBadMagic = errors.New("not a directory manifest - bad magic string")
+)
+
+// manifestEntry describes one encrypted file in a directory
+type manifestEntry struct {
+	Size  int64  `json:"size"`  // size of the encrypted file
+	Nonce string `json:"nonce"` // initial nonce of the file in hex
+}
+
+// manifest describes the encrypted files in a directory
+//
+// It is keyed on the encrypted leaf name of the file
+type manifest struct {
+	Version int                      `json:"version"`
+	Files   map[string]manifestEntry `json:"files"`
+}
+
+// newManifest makes a new empty manifest
+func newManifest() *manifest {
+	return &manifest{
+		Version: manifestVersion,
+		Files:   map[string]manifestEntry{},
+	}
+}
+
+// sealManifest encrypts and authenticates the plaintext of a manifest
+func (c *Cipher) sealManifest(plaintext []byte) ([]byte, error) {
+	var n nonce
+	err := n.fromReader(c.cryptoRand)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(manifestMagic)+fileNonceSize+len(plaintext)+secretbox.Overhead)
+	out = append(out, manifestMagic...)
+	out = append(out, n[:]...)
+	return secretbox.Seal(out, plaintext, n.pointer(), &c.manifestKey), nil
+}
+
+// openManifest checks and decrypts a sealed manifest
+func (c *Cipher) openManifest(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < len(manifestMagic)+fileNonceSize+secretbox.Overhead {
+		return nil, ErrorManifestTooShort
+	}
+	if !bytes.Equal(ciphertext[:len(manifestMagic)], []byte(manifestMagic)) {
+		return nil, ErrorManifestBadMagic
+	}
+	ciphertext = ciphertext[len(manifestMagic):]
+	var n nonce
+	n.fromBuf(ciphertext[:fileNonceSize])
+	plaintext, ok := secretbox.Open(nil, ciphertext[fileNonceSize:], n.pointer(), &c.manifestKey)
+	if !ok {
+		return nil, ErrorManifestBad
+	}
+	return plaintext, nil
+}
+
+// isManifest returns true if the underlying remote is a manifest
+func isManifest(remote string) bool {
+	return path.Base(remote) == manifestName
+}
+
+// splitRemote returns the underlying directory and leaf of remote
+func splitRemote(remote string) (dir, leaf string) {
+	dir, leaf = path.Split(remote)
+	return strings.TrimSuffix(dir, "/"), leaf
+}
+
+// manifestRemote returns the underlying path of the manifest for dir
+func manifestRemote(dir string) string {
+	return path.Join(dir, manifestName)
+}
+
+// readManifest reads the manifest for the underlying directory dir
+//
+// It returns nil and no error if there is no manifest
+func (f *Fs) readManifest(ctx context.Context, dir string) (*manifest, error) {
+	o, err := f.Fs.NewObject(ctx, manifestRemote(dir))
+	if err == fs.ErrorObjectNotFound || err == fs.ErrorDirNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to find directory manifest")
+	}
+	if o.Size() > manifestMaxSize {
+		return nil, errors.Errorf("directory manifest too big (%d bytes)", o.Size())
+	}
+	in, err := o.Open(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open directory manifest")
+	}
+	ciphertext, err := ioutil.ReadAll(in)
+	fs.CheckClose(in, &err)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read directory manifest")
+	}
+	plaintext, err := f.cipher.openManifest(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	m := newManifest()
+	err = json.Unmarshal(plaintext, m)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode directory manifest")
+	}
+	if m.Version != manifestVersion {
+		return nil, errors.Errorf("unsupported directory manifest version %d", m.Version)
+	}
+	if m.Files == nil {
+		m.Files = map[string]manifestEntry{}
+	}
+	return m, nil
+}
+
+// writeManifest writes the manifest for the underlying directory dir
+//
+// If the manifest is empty then it is removed so the directory can
+// be removed with Rmdir.
+func (f *Fs) writeManifest(ctx context.Context, dir string, m *manifest) error {
+	remote := manifestRemote(dir)
+	o, err := f.Fs.NewObject(ctx, remote)
+	if err == fs.ErrorObjectNotFound || err == fs.ErrorDirNotFound {
+		o = nil
+	} else if err != nil {
+		return errors.Wrap(err, "failed to find directory manifest")
+	}
+	if len(m.Files) == 0 {
+		if o == nil {
+			return nil
+		}
+		return errors.Wrap(o.Remove(ctx), "failed to remove directory manifest")
+	}
+	plaintext, err := json.Marshal(m)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode directory manifest")
+	}
+	ciphertext, err := f.cipher.sealManifest(plaintext)
+	if err != nil {
+		return errors.Wrap(err, "failed to seal directory manifest")
+	}
+	src := object.NewStaticObjectInfo(remote, time.Now(), int64(len(ciphertext)), true, nil, f.Fs)
+	if o != nil {
+		err = o.Update(ctx, bytes.NewReader(ciphertext), src)
+	} else {
+		_, err = f.Fs.Put(ctx, bytes.NewReader(ciphertext), src)
+	}
+	return errors.Wrap(err, "failed to write directory manifest")
+}
+
+// getManifest returns the manifest for the underlying directory dir
+//
+// If fresh is set it will always be read from the remote, otherwise
+// the cached copy will be used if there is one.
+//
+// It returns nil and no error if there is no manifest
+func (f *Fs) getManifest(ctx context.Context, dir string, fresh bool) (*manifest, error) {
+	f.manifestMu.Lock()
+	defer f.manifestMu.Unlock()
+	if !fresh {
+		if m, ok := f.manifests[dir]; ok {
+			return m, nil
+		}
+	}
+	m, err := f.readManifest(ctx, dir)
+	if err != nil {
+		delete(f.manifests, dir)
+		return nil, err
+	}
+	f.manifests[dir] = m
+	return m, nil
+}
+
+// updateManifest reads the manifest for the underlying directory
+// dir, calls fn to modify it then writes it back
+func (f *Fs) updateManifest(ctx context.Context, dir string, fn func(m *manifest)) error {
+	f.manifestMu.Lock()
+	defer f.manifestMu.Unlock()
+	delete(f.manifests, dir)
+	m, err := f.readManifest(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		m = newManifest()
+	}
+	fn(m)
+	err = f.writeManifest(ctx, dir, m)
+	if err != nil {
+		return err
+	}
+	if len(m.Files) == 0 {
+		m = nil
+	}
+	f.manifests[dir] = m
+	return nil
+}
+
+// manifestAdd records the underlying object o with initial nonce n
+// in the manifest of its directory
+func (f *Fs) manifestAdd(ctx context.Context, o fs.Object, n nonce) error {
+	if !f.opt.Manifest {
+		return nil
+	}
+	dir, leaf := splitRemote(o.Remote())
+	err := f.updateManifest(ctx, dir, func(m *manifest) {
+		m.Files[leaf] = manifestEntry{
+			Size:  o.Size(),
+			Nonce: hex.EncodeToString(n[:]),
+		}
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to add %q to directory manifest", o.Remote())
+	}
+	return nil
+}
+
+// manifestRemove removes the underlying remote from the manifest of
+// its directory
+func (f *Fs) manifestRemove(ctx context.Context, remote string) error {
+	if !f.opt.Manifest {
+		return nil
+	}
+	dir, leaf := splitRemote(remote)
+	err := f.updateManifest(ctx, dir, func(m *manifest) {
+		delete(m.Files, leaf)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove %q from directory manifest", remote)
+	}
+	return nil
+}
+
+// manifestForget drops any cached manifests at or below the
+// underlying directory dir
+func (f *Fs) manifestForget(dir string) {
+	f.manifestMu.Lock()
+	defer f.manifestMu.Unlock()
+	for cachedDir := range f.manifests {
+		if dir == "" || cachedDir == dir || strings.HasPrefix(cachedDir, dir+"/") {
+			delete(f.manifests, cachedDir)
+		}
+	}
+}
+
+// manifestNonce returns the initial nonce recorded for the
+// underlying object o
+func (f *Fs) manifestNonce(ctx context.Context, o fs.Object, fresh bool) (n nonce, err error) {
+	dir, leaf := splitRemote(o.Remote())
+	m, err := f.getManifest(ctx, dir, fresh)
+	if err != nil {
+		return n, err
+	}
+	if m == nil {
+		return n, errors.Wrap(ErrorManifestMismatch, "directory manifest missing")
+	}
+	entry, ok := m.Files[leaf]
+	if !ok {
+		return n, errors.Wrap(ErrorManifestMismatch, "file not in directory manifest")
+	}
+	buf, err := hex.DecodeString(entry.Nonce)
+	if err != nil || len(buf) != fileNonceSize {
+		return n, errors.Wrap(ErrorManifestMismatch, "bad nonce in directory manifest")
+	}
+	n.fromBuf(buf)
+	return n, nil
+}
+
+// manifestCheckNonce checks the initial nonce n read from the
+// underlying object o against the manifest of its directory
+func (f *Fs) manifestCheckNonce(ctx context.Context, o fs.Object, n nonce) error {
+	if !f.opt.Manifest {
+		return nil
+	}
+	expected, err := f.manifestNonce(ctx, o, false)
+	if err == nil && expected == n {
+		return nil
+	}
+	// The cached manifest may be out of date so try again with a
+	// fresh copy
+	expected, err = f.manifestNonce(ctx, o, true)
+	if err != nil {
+		return err
+	}
+	if expected != n {
+		return errors.Wrap(ErrorManifestMismatch, "nonce differs from directory manifest")
+	}
+	return nil
+}
+
+// manifestProblems checks the underlying entries of the directory
+// dir against its manifest returning a description of each problem
+// found
+func (f *Fs) manifestProblems(ctx context.Context, dir string, entries fs.DirEntries) (problems []string) {
+	m, err := f.getManifest(ctx, dir, true)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if m == nil {
+		m = newManifest()
+	}
+	seen := make(map[string]struct{}, len(m.Files))
+	for _, entry := range entries {
+		o, ok := entry.(fs.Object)
+		if !ok || isManifest(o.Remote()) {
+			continue
+		}
+		_, leaf := splitRemote(o.Remote())
+		expected, ok := m.Files[leaf]
+		if !ok {
+			problems = append(problems, leaf+": not in directory manifest - added outside rclone?")
+			continue
+		}
+		seen[leaf] = struct{}{}
+		if expected.Size != o.Size() {
+			problems = append(problems, leaf+": size differs from directory manifest - modified or rolled back?")
+		}
+	}
+	for leaf := range m.Files {
+		if _, ok := seen[leaf]; !ok {
+			problems = append(problems, leaf+": in directory manifest but missing - deleted?")
+		}
+	}
+	return problems
+}
+
+// manifestVerify checks the underlying entries of the directory dir
+// against its manifest logging any problems found
+func (f *Fs) manifestVerify(ctx context.Context, dir string, entries fs.DirEntries) {
+	if !f.opt.Manifest {
+		return
+	}
+	for _, problem := range f.manifestProblems(ctx, dir, entries) {
+		err := errors.Wrapf(ErrorManifestMismatch, "%s", problem)
+		fs.CountError(err)
+		fs.Errorf(f, "directory %q: %v", dir, err)
+	}
+}
+
+// rebuildManifests rewrites the manifests at and below the
+// underlying directory dir from the files found there
+//
+// It returns the number of manifests written.
+func (f *Fs) rebuildManifests(ctx context.Context, dir string) (count int, err error) {
+	f.manifestForget(dir)
+	err = walk.Walk(ctx, f.Fs, dir, true, -1, func(dirPath string, entries fs.DirEntries, err error) error {
+		if err != nil {
+			return err
+		}
+		m := newManifest()
+		for _, entry := range entries {
+			o, ok := entry.(fs.Object)
+			if !ok || isManifest(o.Remote()) {
+				continue
+			}
+			_, leaf := splitRemote(o.Remote())
+			n, err := f.readNonce(ctx, o)
+			if err != nil {
+				fs.Errorf(o, "Skipping file not in directory manifest: %v", err)
+				continue
+			}
+			m.Files[leaf] = manifestEntry{
+				Size:  o.Size(),
+				Nonce: hex.EncodeToString(n[:]),
+			}
+		}
+		f.manifestMu.Lock()
+		defer f.manifestMu.Unlock()
+		delete(f.manifests, dirPath)
+		err = f.writeManifest(ctx, dirPath, m)
+		if err != nil {
+			return errors.Wrapf(err, "directory %q", dirPath)
+		}
+		if len(m.Files) != 0 {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
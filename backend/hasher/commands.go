@@ -0,0 +1,198 @@
+package hasher
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+)
+
+var commandHelp = []fs.CommandHelp{{
+	Name:  "drop",
+	Short: "Drop cached checksums",
+	Long: `This removes the cached checksums for all the files under the root
+of the remote. They will be worked out again when needed.
+
+Usage Example:
+
+    rclone backend drop hasher:
+    rclone backend drop hasher:path/to/dir
+`,
+}, {
+	Name:  "export",
+	Short: "Export cached checksums to a SUM file",
+	Long: `This writes the cached checksums of the given type for all the files
+under the root of the remote in the same format as md5sum and
+sha1sum, with paths relative to the root.
+
+The checksum type defaults to the first one in "hashes". Checksums
+which are out of date are exported too.
+
+Usage Example:
+
+    rclone backend export hasher:path/to/dir md5 > dir.md5
+`,
+}, {
+	Name:  "import",
+	Short: "Import checksums from a SUM file",
+	Long: `This reads a local file in the same format as md5sum and sha1sum with
+paths relative to the root of the remote and stores the checksums of
+the given type in the cache. The files must exist on the remote.
+
+The checksums are trusted, so only use this with SUM files made from
+the same files, for example with "rclone hashsum" or "md5sum" on the
+original data.
+
+Usage Example:
+
+    rclone backend import hasher:path/to/dir md5 /path/to/dir.md5
+`,
+}}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out interface{}, err error) {
+	switch name {
+	case "drop":
+		n, err := f.db.purge(f.key(""))
+		if err != nil {
+			return nil, err
+		}
+		fs.Infof(f, "Dropped %d cached checksums", n)
+		return nil, nil
+	case "export":
+		ht := f.keepHashes.GetOne()
+		if len(arg) > 0 {
+			ht, err = f.cachedHash(arg[0])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return f.export(ht)
+	case "import":
+		if len(arg) != 2 {
+			return nil, errors.New("need checksum type and SUM file to import")
+		}
+		ht, err := f.cachedHash(arg[0])
+		if err != nil {
+			return nil, err
+		}
+		return f.importSums(ctx, ht, arg[1])
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
+// cachedHash parses name as a hash type which is kept in the database
+func (f *Fs) cachedHash(name string) (hash.Type, error) {
+	ht, err := parseHash(name)
+	if err != nil {
+		return ht, err
+	}
+	if !f.keepHashes.Contains(ht) {
+		return ht, errors.Errorf("%v checksums are not cached by this remote", ht)
+	}
+	return ht, nil
+}
+
+// export returns the cached hashes of type ht as a SUM file
+func (f *Fs) export(ht hash.Type) (string, error) {
+	if ht == hash.None {
+		return "", errors.New("no checksums are cached by this remote")
+	}
+	prefix := string(prefixOf(f.key("")))
+	sums := map[string]string{}
+	var remotes []string
+	err := f.db.walk(f.key(""), func(key string, r *record) error {
+		sum := r.Hashes[ht.String()]
+		if sum != "" {
+			remote := strings.TrimPrefix(key, prefix)
+			sums[remote] = sum
+			remotes = append(remotes, remote)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(remotes)
+	var out strings.Builder
+	for _, remote := range remotes {
+		_, _ = fmt.Fprintf(&out, "%s  %s\n", sums[remote], remote)
+	}
+	return out.String(), nil
+}
+
+// parseSumLine parses a line of a SUM file into its checksum and path
+func parseSumLine(line string) (sum, remote string, ok bool) {
+	i := strings.IndexAny(line, " \t")
+	if i <= 0 {
+		return "", "", false
+	}
+	sum = strings.ToLower(line[:i])
+	remote = strings.TrimLeft(line[i:], " \t")
+	// a leading * marks binary mode in md5sum
+	remote = strings.TrimPrefix(remote, "*")
+	if remote == "" {
+		return "", "", false
+	}
+	return sum, remote, true
+}
+
+// importSums reads the checksums of type ht from the SUM file at
+// sumPath into the database
+func (f *Fs) importSums(ctx context.Context, ht hash.Type, sumPath string) (string, error) {
+	in, err := os.Open(sumPath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open SUM file")
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	width := hash.Width(ht)
+	imported, missing := 0, 0
+	scanner := bufio.NewScanner(in)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sum, remote, ok := parseSumLine(line)
+		if !ok || len(sum) != width {
+			return "", errors.Errorf("%s:%d: bad %v SUM line", sumPath, lineNumber, ht)
+		}
+		obj, err := f.NewObject(ctx, remote)
+		if err != nil {
+			fs.Errorf(remote, "hasher: not importing checksum: %v", err)
+			missing++
+			continue
+		}
+		o := obj.(*Object)
+		hashes := map[string]string{}
+		if r := o.getRecord(ctx); r != nil {
+			hashes = r.Hashes
+		}
+		hashes[ht.String()] = sum
+		o.putRecord(ctx, hashes)
+		imported++
+	}
+	if err = scanner.Err(); err != nil {
+		return "", errors.Wrap(err, "failed to read SUM file")
+	}
+	return fmt.Sprintf("Imported %d %v checksums, %d files not found", imported, ht, missing), nil
+}
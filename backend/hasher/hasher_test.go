@@ -0,0 +1,277 @@
+// Test Hasher filesystem interface
+package hasher
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/crypt"
+	_ "github.com/rclone/rclone/backend/local"
+	_ "github.com/rclone/rclone/backend/memory"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/fstest/fstests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setCacheDir points the cache directory at a temporary directory
+// for the duration of the test
+func setCacheDir(t *testing.T) {
+	oldCacheDir := config.CacheDir
+	config.CacheDir = t.TempDir()
+	t.Cleanup(func() {
+		config.CacheDir = oldCacheDir
+	})
+}
+
+// TestIntegration runs integration tests against the remote
+func TestIntegration(t *testing.T) {
+	if *fstest.RemoteName != "" {
+		t.Skip("Skipping as -remote set")
+	}
+	setCacheDir(t)
+	name := "TestHasher"
+	fstests.Run(t, &fstests.Opt{
+		RemoteName: name + ":",
+		NilObject:  (*Object)(nil),
+		UnimplementableFsMethods: []string{
+			"OpenWriterAt",
+		},
+		UnimplementableObjectMethods: []string{},
+		ExtraConfig: []fstests.ExtraConfigItem{
+			{Name: name, Key: "type", Value: "hasher"},
+			{Name: name, Key: "remote", Value: ":memory:"},
+			{Name: name, Key: "hashes", Value: "md5,sha1"},
+		},
+	})
+}
+
+// newTestFs makes a hasher remote wrapping a memory remote which
+// only supports MD5, caching SHA-1 and Whirlpool
+func newTestFs(t *testing.T, name string, autoSize string) *Fs {
+	return newTestFsRemote(t, name, ":memory:"+name, autoSize)
+}
+
+// newTestFsRemote makes a hasher remote wrapping remote, caching
+// SHA-1 and Whirlpool
+func newTestFsRemote(t *testing.T, name, remote, autoSize string) *Fs {
+	setCacheDir(t)
+	ctx := context.Background()
+	f, err := NewFs(ctx, name, "", configmap.Simple{
+		"remote":    remote,
+		"hashes":    "md5,sha1,whirlpool",
+		"auto_size": autoSize,
+		"max_age":   "off",
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, f.Features().Shutdown(ctx))
+	})
+	return f.(*Fs)
+}
+
+// put uploads contents to remote on f
+func put(t *testing.T, f fs.Fs, remote, contents string) fs.Object {
+	ctx := context.Background()
+	modTime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	o, err := operations.Rcat(ctx, f, remote, ioutil.NopCloser(strings.NewReader(contents)), modTime)
+	require.NoError(t, err)
+	return o
+}
+
+func TestHasherHashes(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFs(t, "TestHasherHashes", "0")
+	assert.Equal(t, hash.NewHashSet(hash.MD5, hash.SHA1, hash.Whirlpool), f.Hashes())
+	assert.Equal(t, hash.NewHashSet(hash.SHA1, hash.Whirlpool), f.keepHashes)
+
+	o := put(t, f, "file.txt", "hello")
+	sha1, err := o.Hash(ctx, hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", sha1)
+	md5, err := o.Hash(ctx, hash.MD5)
+	require.NoError(t, err)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", md5)
+	_, err = o.Hash(ctx, hash.CRC32)
+	assert.Equal(t, hash.ErrUnsupported, err)
+
+	// the hashes survive listing the object again
+	o2, err := f.NewObject(ctx, "file.txt")
+	require.NoError(t, err)
+	sha1, err = o2.Hash(ctx, hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", sha1)
+
+	// the hashes follow a move
+	o3, err := operations.Move(ctx, f, nil, "moved.txt", o2)
+	require.NoError(t, err)
+	sha1, err = o3.Hash(ctx, hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", sha1)
+	r, err := f.db.get(f.key("file.txt"))
+	require.NoError(t, err)
+	assert.Nil(t, r)
+
+	// changing the object behind the hasher's back invalidates the hashes
+	inner, err := f.Fs.NewObject(ctx, "moved.txt")
+	require.NoError(t, err)
+	require.NoError(t, inner.Update(ctx, strings.NewReader("hello world"), object.NewStaticObjectInfo("moved.txt", time.Now(), 11, true, nil, nil)))
+	o3, err = f.NewObject(ctx, "moved.txt")
+	require.NoError(t, err)
+	sha1, err = o3.Hash(ctx, hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, "", sha1)
+
+	// reading the whole object stores the hashes
+	in, err := o3.Open(ctx)
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	sha1, err = o3.Hash(ctx, hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, "2aae6c35c94fcfb415dbe95f408b9ce91ee846ed", sha1)
+
+	// removing the object removes the hashes
+	require.NoError(t, o3.Remove(ctx))
+	r, err = f.db.get(f.key("moved.txt"))
+	require.NoError(t, err)
+	assert.Nil(t, r)
+}
+
+// Check that moving between hasher remotes with different
+// databases moves the hashes from one to the other
+func TestHasherMoveBetweenRemotes(t *testing.T) {
+	ctx := context.Background()
+	// crypt can move files and directories but doesn't support
+	// any hashes so the hasher stores all of them
+	const crypt = "TestHasherMoveCrypt"
+	for key, value := range map[string]string{
+		"type":     "crypt",
+		"remote":   t.TempDir(),
+		"password": obscure.MustObscure("potato"),
+	} {
+		config.FileSet(crypt, key, value)
+	}
+	src := newTestFsRemote(t, "TestHasherMoveSrc", crypt+":src", "0")
+	dst := newTestFsRemote(t, "TestHasherMoveDst", crypt+":dst", "0")
+	require.True(t, src.db.db != dst.db.db)
+	const sha1 = "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"
+
+	// check the SHA-1 of remote on f is in its database or not
+	checkRecord := func(f *Fs, remote string, want bool) {
+		r, err := f.db.get(f.key(remote))
+		require.NoError(t, err)
+		if want {
+			require.NotNil(t, r, remote)
+			assert.Equal(t, sha1, r.Hashes[hash.SHA1.String()], remote)
+		} else {
+			assert.Nil(t, r, remote)
+		}
+	}
+
+	// Move
+	o := put(t, src, "file.txt", "hello")
+	_, err := o.Hash(ctx, hash.SHA1)
+	require.NoError(t, err)
+	checkRecord(src, "file.txt", true)
+	moved, err := dst.Move(ctx, o, "moved.txt")
+	require.NoError(t, err)
+	checkRecord(src, "file.txt", false)
+	checkRecord(dst, "moved.txt", true)
+	got, err := moved.Hash(ctx, hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, sha1, got)
+
+	// DirMove
+	put(t, src, "dir/file.txt", "hello")
+	checkRecord(src, "dir/file.txt", true)
+	require.NoError(t, dst.DirMove(ctx, src, "dir", "newdir"))
+	checkRecord(src, "dir/file.txt", false)
+	checkRecord(dst, "newdir/file.txt", true)
+}
+
+func TestHasherAutoSize(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFs(t, "TestHasherAutoSize", "10b")
+
+	// upload directly to the wrapped remote so nothing is cached
+	modTime := time.Now()
+	_, err := operations.Rcat(ctx, f.Fs, "small.txt", ioutil.NopCloser(strings.NewReader("hello")), modTime)
+	require.NoError(t, err)
+	_, err = operations.Rcat(ctx, f.Fs, "big.txt", ioutil.NopCloser(strings.NewReader("hello world")), modTime)
+	require.NoError(t, err)
+
+	o, err := f.NewObject(ctx, "small.txt")
+	require.NoError(t, err)
+	sha1, err := o.Hash(ctx, hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", sha1)
+	r, err := f.db.get(f.key("small.txt"))
+	require.NoError(t, err)
+	require.NotNil(t, r)
+
+	o, err = f.NewObject(ctx, "big.txt")
+	require.NoError(t, err)
+	sha1, err = o.Hash(ctx, hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, "", sha1)
+}
+
+func TestHasherCommands(t *testing.T) {
+	ctx := context.Background()
+	f := newTestFs(t, "TestHasherCommands", "0")
+	put(t, f, "a.txt", "hello")
+	put(t, f, "dir/b.txt", "hello world")
+
+	out, err := f.Command(ctx, "export", []string{"sha1"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d  a.txt
+2aae6c35c94fcfb415dbe95f408b9ce91ee846ed  dir/b.txt
+`, out)
+
+	_, err = f.Command(ctx, "export", []string{"md5"}, nil)
+	assert.Error(t, err)
+
+	_, err = f.Command(ctx, "drop", nil, nil)
+	require.NoError(t, err)
+	out, err = f.Command(ctx, "export", []string{"sha1"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "", out)
+
+	sumFile := filepath.Join(t.TempDir(), "sums.sha1")
+	require.NoError(t, ioutil.WriteFile(sumFile, []byte(`# comment
+aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d  a.txt
+2AAE6C35C94FCFB415DBE95F408B9CE91EE846ED *dir/b.txt
+2aae6c35c94fcfb415dbe95f408b9ce91ee846ed  missing.txt
+`), 0600))
+	out, err = f.Command(ctx, "import", []string{"sha1", sumFile}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Imported 2 SHA-1 checksums, 1 files not found", out)
+	o, err := f.NewObject(ctx, "dir/b.txt")
+	require.NoError(t, err)
+	sha1, err := o.Hash(ctx, hash.SHA1)
+	require.NoError(t, err)
+	assert.Equal(t, "2aae6c35c94fcfb415dbe95f408b9ce91ee846ed", sha1)
+
+	require.NoError(t, ioutil.WriteFile(sumFile, []byte("potato  a.txt\n"), 0600))
+	_, err = f.Command(ctx, "import", []string{"sha1", sumFile}, nil)
+	assert.Error(t, err)
+	_, err = f.Command(ctx, "import", []string{"sha1", filepath.Join(os.TempDir(), "missing-sum-file")}, nil)
+	assert.Error(t, err)
+
+	_, err = f.Command(ctx, "potato", nil, nil)
+	assert.Equal(t, fs.ErrorCommandNotFound, err)
+}
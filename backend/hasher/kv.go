@@ -0,0 +1,232 @@
+package hasher
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/lib/boltdb"
+	"github.com/rclone/rclone/lib/file"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucket is the name of the bolt bucket the hashes are stored in
+var bucket = []byte("hashes")
+
+// record is what is stored in the database for each object
+type record struct {
+	Size    int64             `json:"size"`    // size of the object when hashed
+	ModTime time.Time         `json:"modtime"` // modification time of the object when hashed
+	Created time.Time         `json:"created"` // when the record was made
+	Hashes  map[string]string `json:"hashes"`  // hashes by hash name
+}
+
+// db is the hash database for a hasher remote
+//
+// This is shared by all the hasher remotes with the same name in
+// this process.
+type db struct {
+	db *boltdb.DB
+}
+
+var dbNameClean = strings.NewReplacer("/", "_", "\\", "_", ":", "_")
+
+// dbPath returns the path of the database for the remote called name
+func dbPath(name string) (string, error) {
+	cacheDir, err := filepath.Abs(config.CacheDir)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to make --cache-dir absolute")
+	}
+	return file.UNCPath(filepath.Join(cacheDir, "hasher", dbNameClean.Replace(name)+".db")), nil
+}
+
+// openDB opens the database at path creating it if necessary
+//
+// Each openDB should be balanced with a close.
+func openDB(path string) (*db, error) {
+	shared, err := boltdb.Open(path, bucket, "hash")
+	if err != nil {
+		return nil, err
+	}
+	return &db{db: shared}, nil
+}
+
+// close the database if this is the last user
+func (d *db) close() error {
+	return d.db.Close()
+}
+
+// get the record for key returning nil if not found
+func (d *db) get(key string) (r *record, err error) {
+	err = d.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		r = new(record)
+		return json.Unmarshal(data, r)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read hashes for %q", key)
+	}
+	return r, nil
+}
+
+// put the record for key
+func (d *db) put(key string, r *record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	err = d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(key), data)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to write hashes for %q", key)
+	}
+	return nil
+}
+
+// del removes the record for key
+func (d *db) del(key string) error {
+	err := d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete([]byte(key))
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete hashes for %q", key)
+	}
+	return nil
+}
+
+// copy the record from srcKey to dstKey if it exists, deleting the
+// source if move is set
+func (d *db) copy(srcKey, dstKey string, move bool) error {
+	err := d.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		data := b.Get([]byte(srcKey))
+		if data == nil {
+			return nil
+		}
+		// data is only valid for the life of the transaction and
+		// can't be passed back into Put
+		err := b.Put([]byte(dstKey), append([]byte(nil), data...))
+		if err != nil || !move {
+			return err
+		}
+		return b.Delete([]byte(srcKey))
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to copy hashes from %q to %q", srcKey, dstKey)
+	}
+	return nil
+}
+
+// prefixOf returns the key prefix for everything in the directory dir
+func prefixOf(dir string) []byte {
+	if dir == "" || strings.HasSuffix(dir, "/") {
+		return []byte(dir)
+	}
+	return []byte(dir + "/")
+}
+
+// walk calls fn for each record under the directory dir
+func (d *db) walk(dir string, fn func(key string, r *record) error) error {
+	prefix := prefixOf(dir)
+	return d.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var r record
+			err := json.Unmarshal(v, &r)
+			if err != nil {
+				fs.Debugf(string(k), "hasher: ignoring bad record: %v", err)
+				continue
+			}
+			err = fn(string(k), &r)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// moveDir moves every record under srcDir in d to be under dstDir
+// in dst, which may be the same database
+func (d *db) moveDir(srcDir string, dst *db, dstDir string) error {
+	srcPrefix, dstPrefix := prefixOf(srcDir), prefixOf(dstDir)
+	type kv struct{ k, v []byte }
+	var moves []kv
+	read := func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+		for k, v := c.Seek(srcPrefix); k != nil && bytes.HasPrefix(k, srcPrefix); k, v = c.Next() {
+			moves = append(moves, kv{append([]byte(nil), k...), append([]byte(nil), v...)})
+		}
+		return nil
+	}
+	remove := func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		for _, m := range moves {
+			err := b.Delete(m.k)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	write := func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		for _, m := range moves {
+			newKey := append(append([]byte(nil), dstPrefix...), m.k[len(srcPrefix):]...)
+			err := b.Put(newKey, m.v)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if d.db == dst.db {
+		return d.db.Update(func(tx *bolt.Tx) error {
+			err := read(tx)
+			if err == nil {
+				err = remove(tx)
+			}
+			if err == nil {
+				err = write(tx)
+			}
+			return err
+		})
+	}
+	// Write the records to dst before removing them from d so they
+	// aren't lost if something goes wrong
+	err := d.db.View(read)
+	if err == nil {
+		err = dst.db.Update(write)
+	}
+	if err == nil {
+		err = d.db.Update(remove)
+	}
+	return err
+}
+
+// purge removes every record under the directory dir, returning the
+// number removed
+func (d *db) purge(dir string) (n int, err error) {
+	prefix := prefixOf(dir)
+	err = d.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+			err := c.Delete()
+			if err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
@@ -0,0 +1,300 @@
+package hasher
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+)
+
+// Object describes a wrapped object which has its hashes cached
+type Object struct {
+	fs.Object
+	f *Fs
+}
+
+func (f *Fs) newObject(o fs.Object) *Object {
+	return &Object{
+		Object: o,
+		f:      f,
+	}
+}
+
+// Fs returns read only access to the Fs that this object is part of
+func (o *Object) Fs() fs.Info {
+	return o.f
+}
+
+// String returns a description of the Object
+func (o *Object) String() string {
+	if o == nil {
+		return "<nil>"
+	}
+	return o.Object.String()
+}
+
+// UnWrap returns the wrapped Object
+func (o *Object) UnWrap() fs.Object {
+	return o.Object
+}
+
+// key returns the database key for the object
+func (o *Object) key() string {
+	return o.f.key(o.Remote())
+}
+
+// getRecord returns the record for the object from the database if
+// it is still valid for the object, or nil if not
+func (o *Object) getRecord(ctx context.Context) *record {
+	r, err := o.f.db.get(o.key())
+	if err != nil {
+		fs.Errorf(o, "hasher: %v", err)
+		return nil
+	}
+	if r == nil {
+		return nil
+	}
+	if r.Size != o.Size() {
+		fs.Debugf(o, "hasher: ignoring hashes as size changed")
+		return nil
+	}
+	if o.f.fpTime && !r.ModTime.Equal(o.ModTime(ctx)) {
+		fs.Debugf(o, "hasher: ignoring hashes as modification time changed")
+		return nil
+	}
+	if o.f.opt.MaxAge.IsSet() && time.Since(r.Created) > time.Duration(o.f.opt.MaxAge) {
+		fs.Debugf(o, "hasher: ignoring hashes as they are too old")
+		return nil
+	}
+	return r
+}
+
+// putRecord stores the hashes for the object in the database along
+// with its current size and modification time
+func (o *Object) putRecord(ctx context.Context, hashes map[string]string) {
+	if o.f.opt.MaxAge == 0 || len(hashes) == 0 {
+		return
+	}
+	r := &record{
+		Size:    o.Size(),
+		Created: time.Now(),
+		Hashes:  hashes,
+	}
+	if o.f.fpTime {
+		r.ModTime = o.ModTime(ctx)
+	}
+	err := o.f.db.put(o.key(), r)
+	if err != nil {
+		fs.Errorf(o, "hasher: %v", err)
+	}
+}
+
+// putHashes stores the hashes from hasher if it read the whole object
+func (o *Object) putHashes(ctx context.Context, hasher *hash.MultiHasher) {
+	if size := o.Size(); size >= 0 && hasher.Size() != size {
+		fs.Debugf(o, "hasher: not storing hashes as read %d bytes of %d", hasher.Size(), size)
+		return
+	}
+	hashes := make(map[string]string)
+	for ht, sum := range hasher.Sums() {
+		hashes[ht.String()] = sum
+	}
+	o.putRecord(ctx, hashes)
+}
+
+// updateHashes reads the object to work out the hashes which are
+// kept and stores them
+func (o *Object) updateHashes(ctx context.Context) (*record, error) {
+	fs.Debugf(o, "hasher: reading object to work out hashes")
+	in, err := o.Object.Open(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open object to hash")
+	}
+	sums, err := hash.StreamTypes(in, o.f.keepHashes)
+	closeErr := in.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to hash object")
+	}
+	hashes := make(map[string]string, len(sums))
+	for ht, sum := range sums {
+		hashes[ht.String()] = sum
+	}
+	o.putRecord(ctx, hashes)
+	return &record{Hashes: hashes}, nil
+}
+
+// Hash returns the selected checksum of the file
+//
+// Hashes the wrapped remote supports are passed through, others are
+// read from the database. If they aren't there then they are worked
+// out by reading the object if it is no bigger than --hasher-auto-size.
+func (o *Object) Hash(ctx context.Context, ht hash.Type) (string, error) {
+	if o.f.passHashes.Contains(ht) {
+		return o.Object.Hash(ctx, ht)
+	}
+	if !o.f.keepHashes.Contains(ht) {
+		return "", hash.ErrUnsupported
+	}
+	r := o.getRecord(ctx)
+	if r == nil || r.Hashes[ht.String()] == "" {
+		size := o.Size()
+		if size < 0 || size > int64(o.f.opt.AutoSize) {
+			return "", nil
+		}
+		var err error
+		r, err = o.updateHashes(ctx)
+		if err != nil {
+			return "", err
+		}
+	}
+	return r.Hashes[ht.String()], nil
+}
+
+// SetModTime sets the modification time of the file, keeping the
+// hashes as the contents haven't changed
+func (o *Object) SetModTime(ctx context.Context, t time.Time) error {
+	r := o.getRecord(ctx)
+	err := o.Object.SetModTime(ctx, t)
+	if err != nil {
+		return err
+	}
+	if r != nil {
+		o.putRecord(ctx, r.Hashes)
+	}
+	return nil
+}
+
+// hashingReader works out the hashes of an object as it is read and
+// stores them if it is read to the end
+type hashingReader struct {
+	io.ReadCloser
+	ctx    context.Context
+	o      *Object
+	hasher *hash.MultiHasher
+	done   bool
+}
+
+// Read bytes from the object, storing the hashes at the end
+func (hr *hashingReader) Read(p []byte) (n int, err error) {
+	n, err = hr.ReadCloser.Read(p)
+	_, _ = hr.hasher.Write(p[:n])
+	if err == io.EOF && !hr.done {
+		hr.done = true
+		hr.o.putHashes(hr.ctx, hr.hasher)
+	}
+	return n, err
+}
+
+// Open an object for read
+//
+// If the whole object is read and the hashes aren't in the database
+// then they are worked out and stored as it is read.
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	in, err := o.Object.Open(ctx, options...)
+	if err != nil || o.f.keepHashes.Count() == 0 {
+		return in, err
+	}
+	for _, option := range options {
+		switch option.(type) {
+		case *fs.RangeOption, *fs.SeekOption:
+			return in, nil
+		}
+	}
+	if r := o.getRecord(ctx); r != nil && len(r.Hashes) == o.f.keepHashes.Count() {
+		return in, nil
+	}
+	hasher, err := hash.NewMultiHasherTypes(o.f.keepHashes)
+	if err != nil {
+		_ = in.Close()
+		return nil, err
+	}
+	return &hashingReader{
+		ReadCloser: in,
+		ctx:        ctx,
+		o:          o,
+		hasher:     hasher,
+	}, nil
+}
+
+// Update in to the object with the modTime given of the given size
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	in, hasher, err := o.f.hashReader(in)
+	if err != nil {
+		return err
+	}
+	err = o.Object.Update(ctx, in, src, options...)
+	if err != nil {
+		return err
+	}
+	if hasher != nil {
+		o.putHashes(ctx, hasher)
+	}
+	return nil
+}
+
+// Remove an object
+func (o *Object) Remove(ctx context.Context) error {
+	err := o.Object.Remove(ctx)
+	if err != nil {
+		return err
+	}
+	err = o.f.db.del(o.key())
+	if err != nil {
+		fs.Errorf(o, "hasher: %v", err)
+	}
+	return nil
+}
+
+// ID returns the ID of the Object if known, or "" if not
+func (o *Object) ID() string {
+	do, ok := o.Object.(fs.IDer)
+	if !ok {
+		return ""
+	}
+	return do.ID()
+}
+
+// MimeType returns the content type of the Object if
+// known, or "" if not
+func (o *Object) MimeType(ctx context.Context) string {
+	do, ok := o.Object.(fs.MimeTyper)
+	if !ok {
+		return ""
+	}
+	return do.MimeType(ctx)
+}
+
+// SetTier performs changing storage tier of the Object if
+// multiple storage classes supported
+func (o *Object) SetTier(tier string) error {
+	do, ok := o.Object.(fs.SetTierer)
+	if !ok {
+		return errors.New("hasher: underlying remote does not support SetTier")
+	}
+	return do.SetTier(tier)
+}
+
+// GetTier returns storage tier or class of the Object
+func (o *Object) GetTier() string {
+	do, ok := o.Object.(fs.GetTierer)
+	if !ok {
+		return ""
+	}
+	return do.GetTier()
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Object          = (*Object)(nil)
+	_ fs.ObjectUnWrapper = (*Object)(nil)
+	_ fs.IDer            = (*Object)(nil)
+	_ fs.MimeTyper       = (*Object)(nil)
+	_ fs.SetTierer       = (*Object)(nil)
+	_ fs.GetTierer       = (*Object)(nil)
+)
@@ -12,21 +12,27 @@ import (
 	"path"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/configmap"
 	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/fserrors"
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/walk"
 	"github.com/rclone/rclone/lib/bucket"
+	"github.com/rclone/rclone/lib/env"
 )
 
 var (
 	hashType = hash.MD5
 	// the object storage is persistent
 	buckets = newBucketsInfo()
+	// ErrorQuotaExceeded is returned when an upload would take the
+	// remote over max_size
+	ErrorQuotaExceeded = errors.New("memory remote is full - max_size exceeded")
 )
 
 // Register with Fs
@@ -35,12 +41,42 @@ func init() {
 		Name:        "memory",
 		Description: "In memory object storage system.",
 		NewFs:       NewFs,
-		Options:     []fs.Option{},
+		Options: []fs.Option{{
+			Name: "snapshot",
+			Help: `File to save the contents of the remote in.
+
+If this is set then the contents of the remote are restored from this
+file when it is first used and saved to it when rclone exits and
+every snapshot_interval, so they survive rclone being restarted.
+
+Remotes with the same snapshot file share their contents. Remotes
+without one share an in memory store which is lost when rclone exits.` + env.ShellExpandHelp,
+		}, {
+			Name: "snapshot_interval",
+			Help: `How often to save the snapshot.
+
+The snapshot is only written if the contents have changed since it was
+last saved. Set to 0 to only save it when rclone exits.`,
+			Default:  fs.Duration(5 * time.Minute),
+			Advanced: true,
+		}, {
+			Name: "max_size",
+			Help: `Maximum size of the data stored.
+
+Uploads which would take the total size of the objects in the store
+used by this remote over this will fail. This is also reported as the
+total space by "rclone about". Set to 0 for no limit.`,
+			Default:  fs.SizeSuffix(0),
+			Advanced: true,
+		}},
 	})
 }
 
 // Options defines the configuration for this backend
 type Options struct {
+	Snapshot         string        `config:"snapshot"`
+	SnapshotInterval fs.Duration   `config:"snapshot_interval"`
+	MaxSize          fs.SizeSuffix `config:"max_size"`
 }
 
 // Fs represents a remote memory server
@@ -51,12 +87,20 @@ type Fs struct {
 	rootBucket    string       // bucket part of root (if any)
 	rootDirectory string       // directory part of root (if any)
 	features      *fs.Features // optional features
+	buckets       *bucketsInfo // the store the objects are kept in
 }
 
 // bucketsInfo holds info about all the buckets
 type bucketsInfo struct {
-	mu      sync.RWMutex
-	buckets map[string]*bucketInfo
+	mu           sync.RWMutex
+	buckets      map[string]*bucketInfo
+	sizeMu       sync.Mutex
+	size         int64      // total size of the objects - protected by sizeMu
+	objects      int64      // number of objects - protected by sizeMu
+	changes      uint64     // count of changes - use atomic
+	snapshotPath string     // file the store is saved in if set
+	saveMu       sync.Mutex // held while saving
+	saved        uint64     // changes when last saved - protected by saveMu
 }
 
 func newBucketsInfo() *bucketsInfo {
@@ -83,6 +127,7 @@ func (bi *bucketsInfo) makeBucket(name string) (b *bucketInfo) {
 	}
 	b = newBucketInfo()
 	bi.buckets[name] = b
+	bi.changed()
 	return b
 }
 
@@ -98,9 +143,22 @@ func (bi *bucketsInfo) deleteBucket(name string) error {
 		return fs.ErrorDirectoryNotEmpty
 	}
 	delete(bi.buckets, name)
+	bi.changed()
 	return nil
 }
 
+// changed notes that the store has changed
+func (bi *bucketsInfo) changed() {
+	atomic.AddUint64(&bi.changes, 1)
+}
+
+// usage returns the total size and number of the objects
+func (bi *bucketsInfo) usage() (size, objects int64) {
+	bi.sizeMu.Lock()
+	defer bi.sizeMu.Unlock()
+	return bi.size, bi.objects
+}
+
 // getObjectData gets an object from (bucketName, bucketPath) or nil
 func (bi *bucketsInfo) getObjectData(bucketName, bucketPath string) (od *objectData) {
 	b := bi.getBucket(bucketName)
@@ -111,11 +169,53 @@ func (bi *bucketsInfo) getObjectData(bucketName, bucketPath string) (od *objectD
 }
 
 // updateObjectData updates an object from (bucketName, bucketPath)
-func (bi *bucketsInfo) updateObjectData(bucketName, bucketPath string, od *objectData) {
+//
+// If maxSize is > 0 then it returns ErrorQuotaExceeded if the update
+// would take the total size of the objects over it.
+func (bi *bucketsInfo) updateObjectData(bucketName, bucketPath string, od *objectData, maxSize int64) error {
 	b := bi.makeBucket(bucketName)
 	b.mu.Lock()
+	defer b.mu.Unlock()
+	old := b.objects[bucketPath]
+	delta, count := int64(len(od.data)), int64(1)
+	if old != nil {
+		delta -= int64(len(old.data))
+		count = 0
+	}
+	bi.sizeMu.Lock()
+	if maxSize > 0 && delta > 0 && bi.size+delta > maxSize {
+		bi.sizeMu.Unlock()
+		return fserrors.NoRetryError(ErrorQuotaExceeded)
+	}
+	bi.size += delta
+	bi.objects += count
+	bi.sizeMu.Unlock()
 	b.objects[bucketPath] = od
-	b.mu.Unlock()
+	bi.changed()
+	return nil
+}
+
+// setModTime sets the modTime of the object at (bucketName,
+// bucketPath) if it still has the data in old, returning the new
+// object data.
+//
+// The object data is copied so it isn't modified while a snapshot is
+// being saved.
+func (bi *bucketsInfo) setModTime(bucketName, bucketPath string, old *objectData, modTime time.Time) *objectData {
+	od := *old
+	od.modTime = modTime
+	b := bi.getBucket(bucketName)
+	if b == nil {
+		return &od
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if cur := b.objects[bucketPath]; cur != nil && cur.sameData(old) {
+		od = *cur
+		od.modTime = modTime
+		b.objects[bucketPath] = &od
+	}
+	return &od
 }
 
 // removeObjectData removes an object from (bucketName, bucketPath) returning true if removed
@@ -127,6 +227,11 @@ func (bi *bucketsInfo) removeObjectData(bucketName, bucketPath string) (removed
 		if od != nil {
 			delete(b.objects, bucketPath)
 			removed = true
+			bi.sizeMu.Lock()
+			bi.size -= int64(len(od.data))
+			bi.objects--
+			bi.sizeMu.Unlock()
+			bi.changed()
 		}
 		b.mu.Unlock()
 	}
@@ -162,6 +267,9 @@ func (bi *bucketInfo) isEmpty() (empty bool) {
 }
 
 // the object data and metadata
+//
+// This isn't modified once it is stored in a bucket so it can be read
+// without locking - changes are made to a copy which is swapped in.
 type objectData struct {
 	modTime  time.Time
 	hash     string
@@ -169,6 +277,15 @@ type objectData struct {
 	data     []byte
 }
 
+// sameData returns true if od and other share the same data, so are
+// copies of the same upload
+func (od *objectData) sameData(other *objectData) bool {
+	if len(od.data) != len(other.data) {
+		return false
+	}
+	return len(od.data) == 0 || &od.data[0] == &other.data[0]
+}
+
 // Object describes a memory object
 type Object struct {
 	fs     *Fs         // what this object is part of
@@ -229,11 +346,16 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	if err != nil {
 		return nil, err
 	}
+	store, err := getStore(env.ShellExpand(opt.Snapshot), time.Duration(opt.SnapshotInterval))
+	if err != nil {
+		return nil, err
+	}
 	root = strings.Trim(root, "/")
 	f := &Fs{
-		name: name,
-		root: root,
-		opt:  *opt,
+		name:    name,
+		root:    root,
+		opt:     *opt,
+		buckets: store,
 	}
 	f.setRoot(root)
 	f.features = (&fs.Features{
@@ -243,7 +365,7 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 		BucketBasedRootOK: true,
 	}).Fill(ctx, f)
 	if f.rootBucket != "" && f.rootDirectory != "" {
-		od := buckets.getObjectData(f.rootBucket, f.rootDirectory)
+		od := f.buckets.getObjectData(f.rootBucket, f.rootDirectory)
 		if od != nil {
 			newRoot := path.Dir(f.root)
 			if newRoot == "." {
@@ -266,7 +388,7 @@ func (f *Fs) newObject(remote string, od *objectData) *Object {
 // it returns the error fs.ErrorObjectNotFound.
 func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
 	bucket, bucketPath := f.split(remote)
-	od := buckets.getObjectData(bucket, bucketPath)
+	od := f.buckets.getObjectData(bucket, bucketPath)
 	if od == nil {
 		return nil, fs.ErrorObjectNotFound
 	}
@@ -284,7 +406,7 @@ func (f *Fs) list(ctx context.Context, bucket, directory, prefix string, addBuck
 	if directory != "" {
 		directory += "/"
 	}
-	b := buckets.getBucket(bucket)
+	b := f.buckets.getBucket(bucket)
 	if b == nil {
 		return fs.ErrorDirNotFound
 	}
@@ -339,9 +461,9 @@ func (f *Fs) listDir(ctx context.Context, bucket, directory, prefix string, addB
 
 // listBuckets lists the buckets to entries
 func (f *Fs) listBuckets(ctx context.Context) (entries fs.DirEntries, err error) {
-	buckets.mu.RLock()
-	defer buckets.mu.RUnlock()
-	for name := range buckets.buckets {
+	f.buckets.mu.RLock()
+	defer f.buckets.mu.RUnlock()
+	for name := range f.buckets.buckets {
 		entries = append(entries, fs.NewDir(name, time.Time{}))
 	}
 	return entries, nil
@@ -442,7 +564,7 @@ func (f *Fs) PutStream(ctx context.Context, in io.Reader, src fs.ObjectInfo, opt
 // Mkdir creates the bucket if it doesn't exist
 func (f *Fs) Mkdir(ctx context.Context, dir string) error {
 	bucket, _ := f.split(dir)
-	buckets.makeBucket(bucket)
+	f.buckets.makeBucket(bucket)
 	return nil
 }
 
@@ -454,7 +576,7 @@ func (f *Fs) Rmdir(ctx context.Context, dir string) error {
 	if bucket == "" || directory != "" {
 		return nil
 	}
-	return buckets.deleteBucket(bucket)
+	return f.buckets.deleteBucket(bucket)
 }
 
 // Precision of the remote
@@ -473,21 +595,51 @@ func (f *Fs) Precision() time.Duration {
 // If it isn't possible then return fs.ErrorCantCopy
 func (f *Fs) Copy(ctx context.Context, src fs.Object, remote string) (fs.Object, error) {
 	dstBucket, dstPath := f.split(remote)
-	_ = buckets.makeBucket(dstBucket)
+	_ = f.buckets.makeBucket(dstBucket)
 	srcObj, ok := src.(*Object)
 	if !ok {
 		fs.Debugf(src, "Can't copy - not same remote type")
 		return nil, fs.ErrorCantCopy
 	}
 	srcBucket, srcPath := srcObj.split()
-	od := buckets.getObjectData(srcBucket, srcPath)
+	od := srcObj.fs.buckets.getObjectData(srcBucket, srcPath)
 	if od == nil {
 		return nil, fs.ErrorObjectNotFound
 	}
-	buckets.updateObjectData(dstBucket, dstPath, od)
+	err := f.buckets.updateObjectData(dstBucket, dstPath, od, int64(f.opt.MaxSize))
+	if err != nil {
+		return nil, err
+	}
 	return f.NewObject(ctx, remote)
 }
 
+// About gets quota information
+func (f *Fs) About(ctx context.Context) (*fs.Usage, error) {
+	used, objects := f.buckets.usage()
+	usage := &fs.Usage{
+		Used:    fs.NewUsageValue(used),
+		Objects: fs.NewUsageValue(objects),
+	}
+	if f.opt.MaxSize > 0 {
+		total := int64(f.opt.MaxSize)
+		free := total - used
+		if free < 0 {
+			free = 0
+		}
+		usage.Total = fs.NewUsageValue(total)
+		usage.Free = fs.NewUsageValue(free)
+	}
+	return usage, nil
+}
+
+// Shutdown the backend, saving the snapshot if there is one
+func (f *Fs) Shutdown(ctx context.Context) error {
+	if f.buckets.snapshotPath == "" {
+		return nil
+	}
+	return f.buckets.save()
+}
+
 // Hashes returns the supported hash sets.
 func (f *Fs) Hashes() hash.Set {
 	return hash.Set(hashType)
@@ -520,7 +672,9 @@ func (o *Object) Hash(ctx context.Context, t hash.Type) (string, error) {
 	}
 	if o.od.hash == "" {
 		sum := md5.Sum(o.od.data)
-		o.od.hash = hex.EncodeToString(sum[:])
+		od := *o.od
+		od.hash = hex.EncodeToString(sum[:])
+		o.od = &od
 	}
 	return o.od.hash, nil
 }
@@ -542,7 +696,9 @@ func (o *Object) ModTime(ctx context.Context) (result time.Time) {
 
 // SetModTime sets the modification time of the local fs object
 func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
-	o.od.modTime = modTime
+	bucket, bucketPath := o.split()
+	o.od = o.fs.buckets.setModTime(bucket, bucketPath, o.od, modTime)
+	o.fs.buckets.changed()
 	return nil
 }
 
@@ -588,20 +744,24 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	if err != nil {
 		return errors.Wrap(err, "failed to update memory object")
 	}
-	o.od = &objectData{
+	od := &objectData{
 		data:     data,
 		hash:     "",
 		modTime:  src.ModTime(ctx),
 		mimeType: fs.MimeType(ctx, src),
 	}
-	buckets.updateObjectData(bucket, bucketPath, o.od)
+	err = o.fs.buckets.updateObjectData(bucket, bucketPath, od, int64(o.fs.opt.MaxSize))
+	if err != nil {
+		return err
+	}
+	o.od = od
 	return nil
 }
 
 // Remove an object
 func (o *Object) Remove(ctx context.Context) error {
 	bucket, bucketPath := o.split()
-	removed := buckets.removeObjectData(bucket, bucketPath)
+	removed := o.fs.buckets.removeObjectData(bucket, bucketPath)
 	if !removed {
 		return fs.ErrorObjectNotFound
 	}
@@ -619,6 +779,8 @@ var (
 	_ fs.Copier      = &Fs{}
 	_ fs.PutStreamer = &Fs{}
 	_ fs.ListRer     = &Fs{}
+	_ fs.Abouter     = &Fs{}
+	_ fs.Shutdowner  = &Fs{}
 	_ fs.Object      = &Object{}
 	_ fs.MimeTyper   = &Object{}
 )
@@ -2,9 +2,24 @@
 package memory
 
 import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
 	"testing"
 
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/object"
+	"github.com/rclone/rclone/fstest"
 	"github.com/rclone/rclone/fstest/fstests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestIntegration runs integration tests against the remote
@@ -14,3 +29,184 @@ func TestIntegration(t *testing.T) {
 		NilObject:  (*Object)(nil),
 	})
 }
+
+// forgetStore removes the store for snapshotPath as if rclone had
+// been restarted
+func forgetStore(t *testing.T, snapshotPath string) {
+	snapshotPath, err := filepath.Abs(snapshotPath)
+	require.NoError(t, err)
+	storesMu.Lock()
+	delete(stores, snapshotPath)
+	storesMu.Unlock()
+}
+
+// entryRemotes returns the sorted remotes of entries
+func entryRemotes(entries fs.DirEntries) (remotes []string) {
+	for _, entry := range entries {
+		remotes = append(remotes, entry.Remote())
+	}
+	sort.Strings(remotes)
+	return remotes
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "rclone-memory-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	snapshotPath := filepath.Join(dir, "snapshot")
+	m := configmap.Simple{
+		"snapshot":          snapshotPath,
+		"snapshot_interval": "0",
+	}
+	t1 := fstest.Time("2001-02-03T04:05:06.499999999Z")
+
+	f, err := NewFs(ctx, "memory", "", m)
+	require.NoError(t, err)
+	defer forgetStore(t, snapshotPath)
+	require.NoError(t, f.Mkdir(ctx, "empty"))
+	file1 := fstest.NewItem("bucket/dir/file1.txt", "hello world", t1)
+	_, _ = fstests.PutTestContents(ctx, t, f, &file1, "hello world", true)
+
+	// Remotes without the snapshot don't see the contents
+	fOther, err := NewFs(ctx, "memory", "", configmap.Simple{})
+	require.NoError(t, err)
+	_, err = fOther.NewObject(ctx, file1.Path)
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+
+	// Save and restore the snapshot as if rclone restarted
+	require.NoError(t, f.Features().Shutdown(ctx))
+	forgetStore(t, snapshotPath)
+	f, err = NewFs(ctx, "memory", "", m)
+	require.NoError(t, err)
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bucket", "empty"}, entryRemotes(entries))
+	o, err := f.NewObject(ctx, file1.Path)
+	require.NoError(t, err)
+	file1.Check(t, o, f.Precision())
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "hello world", string(data))
+	usage, err := f.Features().About(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), *usage.Used)
+	assert.Equal(t, int64(1), *usage.Objects)
+
+	// A bad snapshot is an error
+	forgetStore(t, snapshotPath)
+	require.NoError(t, ioutil.WriteFile(snapshotPath, []byte("potato"), 0600))
+	_, err = NewFs(ctx, "memory", "", m)
+	assert.Error(t, err)
+}
+
+func TestMaxSize(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "rclone-memory-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	snapshotPath := filepath.Join(dir, "snapshot")
+	f, err := NewFs(ctx, "memory", "bucket", configmap.Simple{
+		"snapshot": snapshotPath,
+		"max_size": "10B",
+	})
+	require.NoError(t, err)
+	defer forgetStore(t, snapshotPath)
+	t1 := fstest.Time("2001-02-03T04:05:06.499999999Z")
+
+	file1 := fstest.NewItem("file1.txt", "0123456", t1)
+	_, obj := fstests.PutTestContents(ctx, t, f, &file1, "0123456", true)
+	usage, err := f.Features().About(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), *usage.Total)
+	assert.Equal(t, int64(7), *usage.Used)
+	assert.Equal(t, int64(3), *usage.Free)
+
+	// Too big
+	src := object.NewStaticObjectInfo("file2.txt", t1, 4, true, nil, nil)
+	_, err = f.Put(ctx, bytes.NewBufferString("0123"), src)
+	assert.Equal(t, ErrorQuotaExceeded, errors.Cause(err))
+	assert.False(t, fserrors.ShouldRetry(err))
+	_, err = f.NewObject(ctx, "file2.txt")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+
+	// Overwriting replaces the space used
+	src = object.NewStaticObjectInfo(file1.Path, t1, 10, true, nil, nil)
+	require.NoError(t, obj.Update(ctx, bytes.NewBufferString("0123456789"), src))
+	_, err = f.Features().Copy(ctx, obj, "file3.txt")
+	assert.Equal(t, ErrorQuotaExceeded, errors.Cause(err))
+
+	// Removing frees the space
+	require.NoError(t, obj.Remove(ctx))
+	usage, err = f.Features().About(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), *usage.Used)
+	assert.Equal(t, int64(10), *usage.Free)
+	assert.Equal(t, int64(0), *usage.Objects)
+}
+
+// TestSnapshotConcurrent checks the snapshot can be saved while the
+// objects are being changed - run with -race
+func TestSnapshotConcurrent(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	snapshotPath := filepath.Join(dir, "snapshot")
+	m := configmap.Simple{
+		"snapshot":          snapshotPath,
+		"snapshot_interval": "0",
+	}
+	f, err := NewFs(ctx, "memory", "bucket", m)
+	require.NoError(t, err)
+	defer forgetStore(t, snapshotPath)
+	t1 := fstest.Time("2001-02-03T04:05:06.499999999Z")
+	t2 := fstest.Time("2011-12-25T12:59:59.123456789Z")
+	file1 := fstest.NewItem("file1.txt", "hello world", t1)
+	_, obj := fstests.PutTestContents(ctx, t, f, &file1, "hello world", true)
+
+	// Change the object until the saves are done
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			f.(*Fs).buckets.changed()
+			assert.NoError(t, f.Features().Shutdown(ctx))
+		}
+	}()
+loop:
+	for i := 0; ; i++ {
+		select {
+		case <-done:
+			break loop
+		default:
+		}
+		o, err := f.NewObject(ctx, file1.Path)
+		require.NoError(t, err)
+		_, err = o.Hash(ctx, hash.MD5)
+		require.NoError(t, err)
+		modTime := t1
+		if i%2 == 0 {
+			modTime = t2
+		}
+		require.NoError(t, o.SetModTime(ctx, modTime))
+	}
+	require.NoError(t, obj.SetModTime(ctx, t2))
+
+	// The last change is saved and new objects see it
+	require.NoError(t, f.Features().Shutdown(ctx))
+	forgetStore(t, snapshotPath)
+	f, err = NewFs(ctx, "memory", "bucket", m)
+	require.NoError(t, err)
+	o, err := f.NewObject(ctx, file1.Path)
+	require.NoError(t, err)
+	assert.Equal(t, t2, o.ModTime(ctx))
+	md5sum, err := o.Hash(ctx, hash.MD5)
+	require.NoError(t, err)
+	assert.Equal(t, "5eb63bbbe01eeed093cb22bb8f5acdc3", md5sum)
+}
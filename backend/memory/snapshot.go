@@ -0,0 +1,192 @@
+package memory
+
+import (
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/atexit"
+)
+
+var (
+	storesMu   sync.Mutex
+	stores     = map[string]*bucketsInfo{} // stores with a snapshot by path
+	atexitOnce sync.Once
+)
+
+// snapshotObject is an object as saved in a snapshot
+type snapshotObject struct {
+	ModTime  time.Time
+	Hash     string
+	MimeType string
+	Data     []byte
+}
+
+// snapshot is the contents of a store as saved to disk
+type snapshot struct {
+	Buckets map[string]map[string]snapshotObject
+}
+
+// getStore returns the store saved to snapshotPath, reading it from
+// the snapshot if it isn't in use yet, or the shared in memory store
+// if snapshotPath is empty.
+//
+// If interval is set then the store will be saved that often.
+func getStore(snapshotPath string, interval time.Duration) (*bucketsInfo, error) {
+	if snapshotPath == "" {
+		return buckets, nil
+	}
+	snapshotPath, err := filepath.Abs(snapshotPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make snapshot path absolute")
+	}
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	if bi, ok := stores[snapshotPath]; ok {
+		return bi, nil
+	}
+	bi := newBucketsInfo()
+	bi.snapshotPath = snapshotPath
+	err = bi.load()
+	if err != nil {
+		return nil, err
+	}
+	atexitOnce.Do(func() {
+		atexit.Register(saveAllStores)
+	})
+	if interval > 0 {
+		go bi.saveEvery(interval)
+	}
+	stores[snapshotPath] = bi
+	return bi, nil
+}
+
+// saveAllStores saves the stores which have a snapshot
+func saveAllStores() {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	for _, bi := range stores {
+		err := bi.save()
+		if err != nil {
+			fs.Errorf(nil, "memory: %v", err)
+		}
+	}
+}
+
+// saveEvery saves the store every interval
+func (bi *bucketsInfo) saveEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		err := bi.save()
+		if err != nil {
+			fs.Errorf(nil, "memory: %v", err)
+		}
+	}
+}
+
+// load reads the store from its snapshot if it exists
+func (bi *bucketsInfo) load() (err error) {
+	in, err := os.Open(bi.snapshotPath)
+	if os.IsNotExist(err) {
+		fs.Debugf(nil, "memory: no snapshot found at %q - starting empty", bi.snapshotPath)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to open snapshot")
+	}
+	defer fs.CheckClose(in, &err)
+	var snap snapshot
+	err = gob.NewDecoder(in).Decode(&snap)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read snapshot %q", bi.snapshotPath)
+	}
+	for bucketName, objects := range snap.Buckets {
+		b := bi.makeBucket(bucketName)
+		for bucketPath, so := range objects {
+			b.objects[bucketPath] = &objectData{
+				modTime:  so.ModTime,
+				hash:     so.Hash,
+				mimeType: so.MimeType,
+				data:     so.Data,
+			}
+			bi.size += int64(len(so.Data))
+			bi.objects++
+		}
+	}
+	bi.saved = atomic.LoadUint64(&bi.changes)
+	fs.Debugf(nil, "memory: restored %d objects from snapshot %q", bi.objects, bi.snapshotPath)
+	return nil
+}
+
+// save writes the store to its snapshot if it has changed since it
+// was last saved
+//
+// The snapshot is written to a temporary file which is renamed over
+// the old one so it is never left half written.
+func (bi *bucketsInfo) save() (err error) {
+	bi.saveMu.Lock()
+	defer bi.saveMu.Unlock()
+	changes := atomic.LoadUint64(&bi.changes)
+	if changes == bi.saved {
+		return nil
+	}
+
+	// Copy the contents under the locks - the object data isn't
+	// modified once stored so doesn't need copying and the rest of
+	// the object data is only changed with the bucket lock held
+	snap := snapshot{Buckets: map[string]map[string]snapshotObject{}}
+	bi.mu.RLock()
+	for bucketName, b := range bi.buckets {
+		objects := make(map[string]snapshotObject, len(b.objects))
+		b.mu.RLock()
+		for bucketPath, od := range b.objects {
+			objects[bucketPath] = snapshotObject{
+				ModTime:  od.modTime,
+				Hash:     od.hash,
+				MimeType: od.mimeType,
+				Data:     od.data,
+			}
+		}
+		b.mu.RUnlock()
+		snap.Buckets[bucketName] = objects
+	}
+	bi.mu.RUnlock()
+
+	dir, leaf := filepath.Split(bi.snapshotPath)
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return errors.Wrap(err, "failed to make snapshot directory")
+	}
+	out, err := ioutil.TempFile(dir, leaf+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create snapshot")
+	}
+	tmpPath := out.Name()
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+	err = gob.NewEncoder(out).Encode(&snap)
+	closeErr := out.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to write snapshot %q", bi.snapshotPath)
+	}
+	err = os.Rename(tmpPath, bi.snapshotPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to replace snapshot")
+	}
+	bi.saved = changes
+	fs.Debugf(nil, "memory: saved snapshot %q", bi.snapshotPath)
+	return nil
+}
@@ -40,6 +40,7 @@ const (
 	minSleep                = 100 * time.Millisecond
 	maxSleep                = 2 * time.Second
 	decayConstant           = 2 // bigger for slower decay, exponential
+	linkSuffix              = ".rclonelink"
 )
 
 var (
@@ -179,6 +180,20 @@ Home directory can be found in a shared folder called "home"
 			Default:  false,
 			Help:     "Set to skip any symlinks and any other non regular files.",
 			Advanced: true,
+		}, {
+			Name:    "links",
+			Default: false,
+			Help: `Translate symlinks to/from regular files with a '` + linkSuffix + `' extension.
+
+Symlinks are shown as files with the extension added containing the
+target of the link, and uploading such a file makes a symlink on the
+server. This takes precedence over skip_links for symlinks.
+
+If a symlink "foo" and a file "foo` + linkSuffix + `" are in the same
+directory then the file is shown and the symlink is skipped. Uploading
+a "foo` + linkSuffix + `" file only replaces a symlink "foo" - if "foo"
+is a file or directory an error is returned and it is left alone.`,
+			Advanced: true,
 		}, {
 			Name:     "subsystem",
 			Default:  "sftp",
@@ -216,6 +231,7 @@ type Options struct {
 	Md5sumCommand     string `config:"md5sum_command"`
 	Sha1sumCommand    string `config:"sha1sum_command"`
 	SkipLinks         bool   `config:"skip_links"`
+	TranslateSymlinks bool   `config:"links"`
 	Subsystem         string `config:"subsystem"`
 	ServerCommand     string `config:"server_command"`
 }
@@ -248,6 +264,8 @@ type Object struct {
 	mode    os.FileMode // mode bits from the file
 	md5sum  *string     // Cached MD5 checksum
 	sha1sum *string     // Cached SHA1 checksum
+
+	translatedLink bool // Is this object a translated link
 }
 
 // dial starts a client connection to the given SSH server. It is a
@@ -718,10 +736,7 @@ func (f *Fs) Precision() time.Duration {
 
 // NewObject creates a new remote sftp file object
 func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
-	o := &Object{
-		fs:     f,
-		remote: remote,
-	}
+	o := f.newObject(remote)
 	err := o.stat(ctx)
 	if err != nil {
 		return nil, err
@@ -729,6 +744,18 @@ func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
 	return o, nil
 }
 
+// newObject makes an Object for remote
+//
+// If translating symlinks then remotes ending in linkSuffix refer to
+// the symlink without the suffix.
+func (f *Fs) newObject(remote string) *Object {
+	return &Object{
+		fs:             f,
+		remote:         remote,
+		translatedLink: f.opt.TranslateSymlinks && strings.HasSuffix(remote, linkSuffix),
+	}
+}
+
 // dirExists returns true,nil if the directory exists, false, nil if
 // it doesn't or false, err
 func (f *Fs) dirExists(ctx context.Context, dir string) (bool, error) {
@@ -784,11 +811,34 @@ func (f *Fs) List(ctx context.Context, dir string) (entries fs.DirEntries, err e
 	if err != nil {
 		return nil, errors.Wrapf(err, "error listing %q", dir)
 	}
+	var names map[string]struct{}
+	if f.opt.TranslateSymlinks {
+		names = make(map[string]struct{}, len(infos))
+		for _, info := range infos {
+			names[info.Name()] = struct{}{}
+		}
+	}
 	for _, info := range infos {
 		remote := path.Join(dir, info.Name())
 		// If file is a symlink (not a regular file is the best cross platform test we can do), do a stat to
 		// pick up the size and type of the destination, instead of the size and type of the symlink.
 		if !info.Mode().IsRegular() && !info.IsDir() {
+			if f.opt.TranslateSymlinks && info.Mode()&os.ModeSymlink != 0 {
+				if _, found := names[info.Name()+linkSuffix]; found {
+					fs.Logf(remote, "Skipping symlink as %q exists", info.Name()+linkSuffix)
+					continue
+				}
+				o := f.newObject(remote + linkSuffix)
+				o.setMetadata(info)
+				target, err := f.readLink(ctx, o.path())
+				if err != nil {
+					fs.Errorf(remote, "failed to read symlink: %v", err)
+					continue
+				}
+				o.size = int64(len(target))
+				entries = append(entries, o)
+				continue
+			}
 			if f.opt.SkipLinks {
 				// skip non regular file if SkipLinks is set
 				continue
@@ -824,10 +874,7 @@ func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options .
 		return nil, errors.Wrap(err, "Put mkParentDir failed")
 	}
 	// Temporary object under construction
-	o := &Object{
-		fs:     f,
-		remote: src.Remote(),
-	}
+	o := f.newObject(src.Remote())
 	err = o.Update(ctx, in, src, options...)
 	if err != nil {
 		return nil, err
@@ -913,6 +960,11 @@ func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object,
 		fs.Debugf(src, "Can't move - not same remote type")
 		return nil, fs.ErrorCantMove
 	}
+	dstObj := f.newObject(remote)
+	if srcObj.translatedLink != dstObj.translatedLink {
+		fs.Debugf(src, "Can't move - can't change a symlink into a file or vice versa")
+		return nil, fs.ErrorCantMove
+	}
 	err := f.mkParentDir(ctx, remote)
 	if err != nil {
 		return nil, errors.Wrap(err, "Move mkParentDir failed")
@@ -923,13 +975,13 @@ func (f *Fs) Move(ctx context.Context, src fs.Object, remote string) (fs.Object,
 	}
 	err = c.sftpClient.Rename(
 		srcObj.path(),
-		path.Join(f.absRoot, remote),
+		dstObj.path(),
 	)
 	f.putSftpConnection(&c, err)
 	if err != nil {
 		return nil, errors.Wrap(err, "Move Rename failed")
 	}
-	dstObj, err := f.NewObject(ctx, remote)
+	err = dstObj.stat(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "Move NewObject failed")
 	}
@@ -1124,7 +1176,7 @@ func (o *Object) Remote() string {
 // Hash returns the selected checksum of the file
 // If no checksum is available it returns ""
 func (o *Object) Hash(ctx context.Context, r hash.Type) (string, error) {
-	if o.fs.opt.DisableHashCheck {
+	if o.fs.opt.DisableHashCheck || o.translatedLink {
 		return "", nil
 	}
 	_ = o.fs.Hashes()
@@ -1243,7 +1295,12 @@ func (o *Object) ModTime(ctx context.Context) time.Time {
 }
 
 // path returns the native path of the object
+//
+// For translated symlinks this is the path of the symlink itself.
 func (o *Object) path() string {
+	if o.translatedLink {
+		return path.Join(o.fs.absRoot, strings.TrimSuffix(o.remote, linkSuffix))
+	}
 	return path.Join(o.fs.absRoot, o.remote)
 }
 
@@ -1266,8 +1323,54 @@ func (f *Fs) stat(ctx context.Context, remote string) (info os.FileInfo, err err
 	return info, err
 }
 
+// lstat stats the native path given without following symlinks
+func (f *Fs) lstat(ctx context.Context, absPath string) (info os.FileInfo, err error) {
+	c, err := f.getSftpConnection(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "lstat")
+	}
+	info, err = c.sftpClient.Lstat(absPath)
+	f.putSftpConnection(&c, err)
+	return info, err
+}
+
+// readLink reads the target of the symlink at the native path given
+func (f *Fs) readLink(ctx context.Context, absPath string) (target string, err error) {
+	c, err := f.getSftpConnection(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "readLink")
+	}
+	target, err = c.sftpClient.ReadLink(absPath)
+	f.putSftpConnection(&c, err)
+	return target, err
+}
+
+// statLink updates the info in the Object for a translated symlink
+func (o *Object) statLink(ctx context.Context) error {
+	info, err := o.fs.lstat(ctx, o.path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fs.ErrorObjectNotFound
+		}
+		return errors.Wrap(err, "stat failed")
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return fs.ErrorObjectNotFound
+	}
+	target, err := o.fs.readLink(ctx, o.path())
+	if err != nil {
+		return errors.Wrap(err, "stat readlink failed")
+	}
+	o.setMetadata(info)
+	o.size = int64(len(target))
+	return nil
+}
+
 // stat updates the info in the Object
 func (o *Object) stat(ctx context.Context) error {
+	if o.translatedLink {
+		return o.statLink(ctx)
+	}
 	info, err := o.fs.stat(ctx, o.remote)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -1286,7 +1389,8 @@ func (o *Object) stat(ctx context.Context) error {
 //
 // it also updates the info field
 func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
-	if o.fs.opt.SetModTime {
+	// Chtimes follows symlinks so don't set the time on translated links
+	if o.fs.opt.SetModTime && !o.translatedLink {
 		c, err := o.fs.getSftpConnection(ctx)
 		if err != nil {
 			return errors.Wrap(err, "SetModTime")
@@ -1306,7 +1410,7 @@ func (o *Object) SetModTime(ctx context.Context, modTime time.Time) error {
 
 // Storable returns whether the remote sftp file is a regular file (not a directory, symbolic link, block device, character device, named pipe, etc.)
 func (o *Object) Storable() bool {
-	return o.mode.IsRegular()
+	return o.translatedLink || o.mode.IsRegular()
 }
 
 // objectReader represents a file open for reading on the SFTP server
@@ -1370,6 +1474,16 @@ func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (in io.Read
 			}
 		}
 	}
+	if o.translatedLink {
+		target, err := o.fs.readLink(ctx, o.path())
+		if err != nil {
+			return nil, errors.Wrap(err, "Open readlink failed")
+		}
+		if offset > int64(len(target)) {
+			offset = int64(len(target))
+		}
+		return readers.NewLimitedReadCloser(ioutil.NopCloser(strings.NewReader(target[offset:])), limit), nil
+	}
 	c, err := o.fs.getSftpConnection(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "Open")
@@ -1394,6 +1508,9 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	// Clear the hash cache since we are about to update the object
 	o.md5sum = nil
 	o.sha1sum = nil
+	if o.translatedLink {
+		return o.updateLink(ctx, in)
+	}
 	c, err := o.fs.getSftpConnection(ctx)
 	if err != nil {
 		return errors.Wrap(err, "Update")
@@ -1435,6 +1552,44 @@ func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, op
 	return nil
 }
 
+// updateLink makes a symlink pointing to the target read from in
+// replacing any symlink already at the path of the object
+//
+// If there is a file or directory at the path instead then it is left
+// alone and an error is returned.
+func (o *Object) updateLink(ctx context.Context, in io.Reader) error {
+	target, err := ioutil.ReadAll(in)
+	if err != nil {
+		return errors.Wrap(err, "Update read link target failed")
+	}
+	c, err := o.fs.getSftpConnection(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Update")
+	}
+	info, err := c.sftpClient.Lstat(o.path())
+	if err == nil {
+		if info.Mode()&os.ModeSymlink == 0 {
+			o.fs.putSftpConnection(&c, nil)
+			return errors.Errorf("can't make symlink %q as a file or directory with that name exists", strings.TrimSuffix(o.remote, linkSuffix))
+		}
+		err = c.sftpClient.Remove(o.path())
+	}
+	if err != nil && !os.IsNotExist(err) {
+		o.fs.putSftpConnection(&c, err)
+		return errors.Wrap(err, "Update remove old link failed")
+	}
+	err = c.sftpClient.Symlink(string(target), o.path())
+	o.fs.putSftpConnection(&c, err)
+	if err != nil {
+		return errors.Wrap(err, "Update Symlink failed")
+	}
+	err = o.stat(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Update stat failed")
+	}
+	return nil
+}
+
 // Remove a remote sftp file object
 func (o *Object) Remove(ctx context.Context) error {
 	c, err := o.fs.getSftpConnection(ctx)
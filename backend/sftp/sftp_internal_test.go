@@ -3,10 +3,27 @@
 package sftp
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
 	"testing"
+	"time"
 
+	"github.com/pkg/sftp"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/object"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
 )
 
 func TestShellEscape(t *testing.T) {
@@ -49,3 +66,165 @@ func TestParseUsage(t *testing.T) {
 		assert.Equal(t, test.usage, [3]int64{gotSpaceTotal, gotSpaceUsed, gotSpaceAvail}, fmt.Sprintf("Test %d sshOutput = %q", i, test.sshOutput))
 	}
 }
+
+// newTestServer starts an SSH server with the sftp subsystem serving
+// the local disk, returning its port
+func newTestServer(t *testing.T) string {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(private)
+	require.NoError(t, err)
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == "user" && string(pass) == "pass" {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("password rejected for %q", c.User())
+		},
+	}
+	config.AddHostKey(signer)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestConn(conn, config)
+		}
+	}()
+	_, port, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	return port
+}
+
+// serveTestConn serves the sftp subsystem on conn
+func serveTestConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(ok, nil)
+				if !ok {
+					continue
+				}
+				server, err := sftp.NewServer(channel)
+				if err == nil {
+					_ = server.Serve()
+				}
+				_ = channel.Close()
+			}
+		}()
+	}
+}
+
+func TestLinks(t *testing.T) {
+	ctx := context.Background()
+	port := newTestServer(t)
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte("hello"), 0600))
+	require.NoError(t, os.Symlink("file.txt", filepath.Join(dir, "link")))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "foo"), []byte("real"), 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "subdir"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "both.rclonelink"), []byte("a file"), 0600))
+	require.NoError(t, os.Symlink("file.txt", filepath.Join(dir, "both")))
+
+	name := "TestSFTPLinks"
+	for key, value := range map[string]string{
+		"type":            "sftp",
+		"host":            "127.0.0.1",
+		"port":            port,
+		"user":            "user",
+		"pass":            obscure.MustObscure("pass"),
+		"links":           "true",
+		"md5sum_command":  "none",
+		"sha1sum_command": "none",
+	} {
+		config.FileSet(name, key, value)
+	}
+	f, err := fs.NewFs(ctx, name+":"+dir)
+	require.NoError(t, err)
+
+	// List shows the link with the suffix, and the real file when
+	// a link would have the same name
+	entries, err := f.List(ctx, "")
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, fmt.Sprintf("%s:%d", entry.Remote(), entry.Size()))
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"both.rclonelink:6", "file.txt:5", "foo:4", "link.rclonelink:8", "subdir:-1"}, names)
+
+	// Read the link
+	o, err := f.NewObject(ctx, "link.rclonelink")
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), o.Size())
+	in, err := o.Open(ctx)
+	require.NoError(t, err)
+	target, err := ioutil.ReadAll(in)
+	require.NoError(t, err)
+	require.NoError(t, in.Close())
+	assert.Equal(t, "file.txt", string(target))
+	_, err = f.NewObject(ctx, "foo.rclonelink")
+	assert.Equal(t, fs.ErrorObjectNotFound, err)
+
+	put := func(remote, contents string) (fs.Object, error) {
+		src := object.NewStaticObjectInfo(remote, time.Now(), int64(len(contents)), true, nil, nil)
+		return f.Put(ctx, bytes.NewBufferString(contents), src)
+	}
+	readLink := func(name string) string {
+		target, err := os.Readlink(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return target
+	}
+
+	// Put makes a new link
+	o, err = put("new.rclonelink", "subdir")
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), o.Size())
+	assert.Equal(t, "subdir", readLink("new"))
+
+	// Update replaces an existing link
+	src := object.NewStaticObjectInfo("new.rclonelink", time.Now(), 3, true, nil, nil)
+	require.NoError(t, o.Update(ctx, bytes.NewBufferString("foo"), src))
+	assert.Equal(t, int64(3), o.Size())
+	assert.Equal(t, "foo", readLink("new"))
+
+	// A file or directory isn't replaced by a link
+	for _, name := range []string{"foo", "subdir"} {
+		_, err = put(name+".rclonelink", "file.txt")
+		require.Error(t, err, name)
+		assert.Contains(t, err.Error(), "exists")
+		fi, err := os.Lstat(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.True(t, fi.Mode()&os.ModeSymlink == 0, name)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "foo"))
+	require.NoError(t, err)
+	assert.Equal(t, "real", string(data))
+
+	// Remove removes the link not its target
+	require.NoError(t, o.Remove(ctx))
+	_, err = os.Lstat(filepath.Join(dir, "new"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "subdir"))
+	assert.NoError(t, err)
+}
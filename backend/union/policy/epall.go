@@ -82,6 +82,10 @@ func (p *EpAll) Create(ctx context.Context, upstreams []*upstream.Fs, path strin
 	if len(upstreams) == 0 {
 		return nil, fs.ErrorPermissionDenied
 	}
+	upstreams = filterFull(upstreams)
+	if len(upstreams) == 0 {
+		return nil, ErrorNotEnoughSpace
+	}
 	upstreams, err := p.epall(ctx, upstreams, path+"/..")
 	return upstreams, err
 }
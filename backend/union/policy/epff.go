@@ -82,6 +82,10 @@ func (p *EpFF) Create(ctx context.Context, upstreams []*upstream.Fs, path string
 	if len(upstreams) == 0 {
 		return nil, fs.ErrorPermissionDenied
 	}
+	upstreams = filterFull(upstreams)
+	if len(upstreams) == 0 {
+		return nil, ErrorNotEnoughSpace
+	}
 	u, err := p.epff(ctx, upstreams, path+"/..")
 	return []*upstream.Fs{u}, err
 }
@@ -28,5 +28,9 @@ func (p *FF) Create(ctx context.Context, upstreams []*upstream.Fs, path string)
 	if len(upstreams) == 0 {
 		return upstreams, fs.ErrorPermissionDenied
 	}
+	upstreams = filterFull(upstreams)
+	if len(upstreams) == 0 {
+		return upstreams, ErrorNotEnoughSpace
+	}
 	return upstreams[:1], nil
 }
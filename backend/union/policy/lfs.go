@@ -28,6 +28,10 @@ func (p *Lfs) Create(ctx context.Context, upstreams []*upstream.Fs, path string)
 	if len(upstreams) == 0 {
 		return nil, fs.ErrorPermissionDenied
 	}
+	upstreams = filterFull(upstreams)
+	if len(upstreams) == 0 {
+		return nil, ErrorNotEnoughSpace
+	}
 	u, err := p.lfs(upstreams)
 	return []*upstream.Fs{u}, err
 }
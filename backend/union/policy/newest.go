@@ -115,6 +115,10 @@ func (p *Newest) Create(ctx context.Context, upstreams []*upstream.Fs, path stri
 	if len(upstreams) == 0 {
 		return nil, fs.ErrorPermissionDenied
 	}
+	upstreams = filterFull(upstreams)
+	if len(upstreams) == 0 {
+		return nil, ErrorNotEnoughSpace
+	}
 	u, err := p.newest(ctx, upstreams, path+"/..")
 	return []*upstream.Fs{u}, err
 }
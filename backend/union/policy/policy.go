@@ -14,6 +14,10 @@ import (
 
 var policies = make(map[string]Policy)
 
+// ErrorNotEnoughSpace is returned by the create policies if all the
+// upstreams have less free space than min_free_space
+var ErrorNotEnoughSpace = errors.New("not enough free space on any upstream - check min_free_space")
+
 // Policy is the interface of a set of defined behavior choosing
 // the upstream Fs to operate on
 type Policy interface {
@@ -76,6 +80,15 @@ func filterNC(ufs []*upstream.Fs) (wufs []*upstream.Fs) {
 	return wufs
 }
 
+func filterFull(ufs []*upstream.Fs) (wufs []*upstream.Fs) {
+	for _, u := range ufs {
+		if u.HasMinFreeSpace() {
+			wufs = append(wufs, u)
+		}
+	}
+	return wufs
+}
+
 func filterNCEntries(ue []upstream.Entry) (wue []upstream.Entry) {
 	for _, e := range ue {
 		if e.UpstreamFs().IsCreatable() {
@@ -0,0 +1,59 @@
+package policy
+
+import (
+	"context"
+	"math"
+
+	"github.com/rclone/rclone/backend/union/upstream"
+	"github.com/rclone/rclone/fs"
+)
+
+func init() {
+	registerPolicy("proportional", &Proportional{})
+}
+
+// Proportional stands for proportional fill
+// Search category: same as epff.
+// Action category: same as epall.
+// Create category: Pick the drive with the least percentage of its space used,
+// so the drives fill up in proportion to their size.
+type Proportional struct {
+	EpAll
+}
+
+func (p *Proportional) lup(upstreams []*upstream.Fs) (*upstream.Fs, error) {
+	minUsedFraction := math.Inf(1)
+	var lupupstream *upstream.Fs
+	for _, u := range upstreams {
+		fraction, err := u.GetUsedFraction()
+		if err != nil {
+			fs.LogPrintf(fs.LogLevelNotice, nil,
+				"Used fraction is not supported for upstream %s, treating as 0", u.Name())
+		}
+		if fraction < minUsedFraction {
+			minUsedFraction = fraction
+			lupupstream = u
+		}
+	}
+	if lupupstream == nil {
+		return nil, fs.ErrorObjectNotFound
+	}
+	return lupupstream, nil
+}
+
+// Create category policy, governing the creation of files and directories
+func (p *Proportional) Create(ctx context.Context, upstreams []*upstream.Fs, path string) ([]*upstream.Fs, error) {
+	if len(upstreams) == 0 {
+		return nil, fs.ErrorObjectNotFound
+	}
+	upstreams = filterNC(upstreams)
+	if len(upstreams) == 0 {
+		return nil, fs.ErrorPermissionDenied
+	}
+	upstreams = filterFull(upstreams)
+	if len(upstreams) == 0 {
+		return nil, ErrorNotEnoughSpace
+	}
+	u, err := p.lup(upstreams)
+	return []*upstream.Fs{u}, err
+}
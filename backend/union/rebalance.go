@@ -0,0 +1,230 @@
+package union
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/union/upstream"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/walk"
+)
+
+var commandHelp = []fs.CommandHelp{{
+	Name:  "rebalance",
+	Short: "Move files between upstreams to even out their usage",
+	Long: `This moves files from the upstreams which have the largest percentage
+of their space used to the ones with the smallest, until each upstream
+has about the same percentage used.
+
+Files are only moved off upstreams which are writable, so not ones
+tagged with ":ro", and only on to upstreams where files can be
+created, so not ones tagged with ":ro" or ":nc". Upstreams which can't
+report their total and free space are left alone, and files aren't
+moved on to an upstream if that would leave it with less than its
+minimum free space (min_free_space or its ":min=" tag) free.
+
+Files which already exist on the upstream they would be moved to are
+skipped. Filters can be used to choose which files are moved, and
+--dry-run shows which files would be moved.
+
+Usage Example:
+
+    rclone backend rebalance union:
+    rclone backend rebalance union: --dry-run -v
+
+It returns the number of files and bytes moved and the percentage of
+each upstream used before and after.
+`,
+}}
+
+// Command the backend to run a named command
+//
+// The command run is name
+// args may be used to read arguments from
+// opts may be used to read optional arguments from
+//
+// The result should be capable of being JSON encoded
+// If it is a string or a []string it will be shown to the user
+// otherwise it will be JSON encoded and shown to the user like that
+func (f *Fs) Command(ctx context.Context, name string, arg []string, opt map[string]string) (out interface{}, err error) {
+	switch name {
+	case "rebalance":
+		return f.rebalance(ctx)
+	default:
+		return nil, fs.ErrorCommandNotFound
+	}
+}
+
+// rebalanceUpstream is an upstream and its space while rebalancing
+type rebalanceUpstream struct {
+	u       *upstream.Fs
+	name    string
+	total   int64 // total space
+	free    int64 // free space
+	minFree int64 // free space to leave when moving files on to it
+	before  float64
+	source  bool // files can be moved off it
+	dest    bool // files can be moved on to it
+}
+
+// used returns the fraction of the space which is used
+func (r *rebalanceUpstream) used() float64 {
+	return r.usedAfter(0)
+}
+
+// usedAfter returns the fraction of the space which would be used
+// after adding size bytes
+func (r *rebalanceUpstream) usedAfter(size int64) float64 {
+	return 1 - float64(r.free-size)/float64(r.total)
+}
+
+// pickDestination returns the upstream to move a file of size bytes
+// from src on to or nil if there isn't one
+//
+// This is the destination with the least percentage used which
+// would still have its minFree bytes free and would be less full than
+// src is now.
+func pickDestination(src *rebalanceUpstream, rs []*rebalanceUpstream, size int64) *rebalanceUpstream {
+	var best *rebalanceUpstream
+	for _, r := range rs {
+		if r == src || !r.dest || r.free-size < r.minFree {
+			continue
+		}
+		if r.usedAfter(size) >= src.used() {
+			continue
+		}
+		if best == nil || r.used() < best.used() {
+			best = r
+		}
+	}
+	return best
+}
+
+// rebalance moves files between the upstreams to even out the
+// percentage of their space which is used
+func (f *Fs) rebalance(ctx context.Context) (out map[string]interface{}, err error) {
+	// Read the space of the upstreams
+	var (
+		rs                    []*rebalanceUpstream
+		totalSpace, freeSpace int64
+	)
+	for _, u := range f.upstreams {
+		name := fs.ConfigString(u)
+		do := u.RootFs.Features().About
+		if do == nil {
+			fs.Logf(name, "Not rebalancing upstream as it doesn't support About")
+			continue
+		}
+		usage, err := do(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read usage of %s", name)
+		}
+		if _, err = upstream.UsedFraction(usage); err != nil {
+			fs.Logf(name, "Not rebalancing upstream as it doesn't report its free and total space")
+			continue
+		}
+		total := *usage.Free
+		if usage.Total != nil {
+			total = *usage.Total
+		} else {
+			total += *usage.Used
+		}
+		r := &rebalanceUpstream{
+			u:       u,
+			name:    name,
+			total:   total,
+			free:    *usage.Free,
+			minFree: u.MinFreeSpace(),
+			source:  u.IsWritable(),
+			dest:    u.IsCreatable(),
+		}
+		r.before = r.used()
+		rs = append(rs, r)
+		totalSpace += r.total
+		freeSpace += r.free
+	}
+	if len(rs) < 2 {
+		return nil, errors.New("need at least two upstreams which report their space to rebalance")
+	}
+	target := 1 - float64(freeSpace)/float64(totalSpace)
+	fs.Infof(f, "Rebalancing to %.1f%% used", 100*target)
+
+	// Move files off the fullest upstreams first
+	sort.SliceStable(rs, func(i, j int) bool {
+		return rs[i].used() > rs[j].used()
+	})
+	var (
+		moved, movedBytes int64
+		lastErr           error
+	)
+	for _, src := range rs {
+		if !src.source || src.used() <= target {
+			continue
+		}
+		var objs []fs.Object
+		err = walk.ListR(ctx, src.u, "", false, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+			entries.ForObject(func(o fs.Object) {
+				objs = append(objs, o)
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list %s", src.name)
+		}
+		// Move the largest files first so fewer files are moved
+		sort.SliceStable(objs, func(i, j int) bool {
+			return objs[i].Size() > objs[j].Size()
+		})
+		for _, o := range objs {
+			if src.used() <= target {
+				break
+			}
+			size := o.Size()
+			if size <= 0 {
+				continue
+			}
+			dst := pickDestination(src, rs, size)
+			if dst == nil {
+				continue
+			}
+			_, err := dst.u.NewObject(ctx, o.Remote())
+			if err == nil {
+				fs.Debugf(o, "Not moving to %s as it exists there", dst.name)
+				continue
+			} else if err != fs.ErrorObjectNotFound {
+				fs.Errorf(o, "Failed to check %s: %v", dst.name, err)
+				lastErr = fs.CountError(err)
+				continue
+			}
+			fs.Debugf(o, "Moving from %s to %s", src.name, dst.name)
+			_, err = operations.Move(ctx, dst.u, nil, o.Remote(), o)
+			if err != nil {
+				// Move logs and counts its errors
+				lastErr = err
+				continue
+			}
+			src.free += size
+			dst.free -= size
+			moved++
+			movedBytes += size
+		}
+	}
+
+	upstreams := make([]map[string]interface{}, len(rs))
+	for i, r := range rs {
+		upstreams[i] = map[string]interface{}{
+			"upstream":   r.name,
+			"usedBefore": 100 * r.before,
+			"usedAfter":  100 * r.used(),
+		}
+	}
+	out = map[string]interface{}{
+		"moved":     moved,
+		"bytes":     movedBytes,
+		"target":    100 * target,
+		"upstreams": upstreams,
+	}
+	return out, lastErr
+}
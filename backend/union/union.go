@@ -52,7 +52,23 @@ func init() {
 			Help:     "Cache time of usage and free space (in seconds). This option is only useful when a path preserving policy is used.",
 			Required: true,
 			Default:  120,
+		}, {
+			Name: "min_free_space",
+			Help: `Minimum free space an upstream needs to be used for new files.
+
+The create policies won't use an upstream which has less free space
+than this, so an upstream which is nearly full is skipped rather than
+filling it up completely. Upstreams which can't report their free space
+are always used.
+
+This applies to each upstream separately and can be set for a single
+upstream by adding a ":min=" tag to it, e.g. "remote:dir:min=10G".
+
+Set to 0 to disable.`,
+			Default:  fs.SizeSuffix(0),
+			Advanced: true,
 		}},
+		CommandHelp: commandHelp,
 	}
 	fs.Register(fsi)
 }
@@ -65,6 +81,7 @@ type Options struct {
 	CreatePolicy string          `config:"create_policy"`
 	SearchPolicy string          `config:"search_policy"`
 	CacheTime    int             `config:"cache_time"`
+	MinFreeSpace fs.SizeSuffix   `config:"min_free_space"`
 }
 
 // Fs represents a union of upstreams
@@ -801,7 +818,7 @@ func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, e
 	errs := Errors(make([]error, len(opt.Upstreams)))
 	multithread(len(opt.Upstreams), func(i int) {
 		u := opt.Upstreams[i]
-		upstreams[i], errs[i] = upstream.New(ctx, u, root, time.Duration(opt.CacheTime)*time.Second)
+		upstreams[i], errs[i] = upstream.New(ctx, u, root, time.Duration(opt.CacheTime)*time.Second, int64(opt.MinFreeSpace))
 	})
 	var usedUpstreams []*upstream.Fs
 	var fserr error
@@ -911,4 +928,5 @@ var (
 	_ fs.Abouter         = (*Fs)(nil)
 	_ fs.ListRer         = (*Fs)(nil)
 	_ fs.Shutdowner      = (*Fs)(nil)
+	_ fs.Commander       = (*Fs)(nil)
 )
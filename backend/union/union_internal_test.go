@@ -0,0 +1,39 @@
+package union
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickDestination(t *testing.T) {
+	src := &rebalanceUpstream{name: "src", total: 100, free: 10, source: true, dest: true}
+	a := &rebalanceUpstream{name: "a", total: 100, free: 50, source: true, dest: true}
+	b := &rebalanceUpstream{name: "b", total: 1000, free: 800, source: true, dest: true}
+	nc := &rebalanceUpstream{name: "nc", total: 100, free: 100, source: true}
+	rs := []*rebalanceUpstream{src, a, b, nc}
+
+	assert.InDelta(t, 0.9, src.used(), 1e-9)
+	assert.InDelta(t, 0.6, a.usedAfter(10), 1e-9)
+
+	// Least used upstream which can be created on
+	assert.Equal(t, b, pickDestination(src, rs, 10))
+
+	assert.Equal(t, a, pickDestination(src, []*rebalanceUpstream{src, a, nc}, 10))
+
+	// Not if it would leave less than its minFree
+	b.minFree = 790
+	assert.Equal(t, b, pickDestination(src, rs, 10))
+	b.minFree = 791
+	assert.Equal(t, a, pickDestination(src, rs, 10))
+	a.minFree = 40
+	assert.Equal(t, a, pickDestination(src, rs, 10))
+	a.minFree = 41
+	assert.Nil(t, pickDestination(src, rs, 10))
+	a.minFree, b.minFree = 0, 0
+
+	// Not if the destination would end up fuller than the source
+	assert.Nil(t, pickDestination(a, []*rebalanceUpstream{a, src}, 10))
+	assert.Equal(t, b, pickDestination(src, rs, 500))
+	assert.Nil(t, pickDestination(src, rs, 790))
+}
@@ -2,13 +2,22 @@
 package union_test
 
 import (
+	"bytes"
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
 	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/backend/union/policy"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/object"
 	"github.com/rclone/rclone/fstest"
 	"github.com/rclone/rclone/fstest/fstests"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -179,3 +188,79 @@ func TestPolicy3(t *testing.T) {
 		UnimplementableObjectMethods: []string{"MimeType"},
 	})
 }
+
+func TestPolicyProportional(t *testing.T) {
+	if *fstest.RemoteName != "" {
+		t.Skip("Skipping as -remote set")
+	}
+	tempdir1 := filepath.Join(os.TempDir(), "rclone-union-test-proportional1")
+	tempdir2 := filepath.Join(os.TempDir(), "rclone-union-test-proportional2")
+	tempdir3 := filepath.Join(os.TempDir(), "rclone-union-test-proportional3")
+	require.NoError(t, os.MkdirAll(tempdir1, 0744))
+	require.NoError(t, os.MkdirAll(tempdir2, 0744))
+	require.NoError(t, os.MkdirAll(tempdir3, 0744))
+	upstreams := tempdir1 + " " + tempdir2 + " " + tempdir3
+	name := "TestUnionProportional"
+	fstests.Run(t, &fstests.Opt{
+		RemoteName: name + ":",
+		ExtraConfig: []fstests.ExtraConfigItem{
+			{Name: name, Key: "type", Value: "union"},
+			{Name: name, Key: "upstreams", Value: upstreams},
+			{Name: name, Key: "action_policy", Value: "epall"},
+			{Name: name, Key: "create_policy", Value: "proportional"},
+			{Name: name, Key: "search_policy", Value: "ff"},
+			{Name: name, Key: "min_free_space", Value: "1k"},
+		},
+		UnimplementableFsMethods:     []string{"OpenWriterAt", "DuplicateFiles"},
+		UnimplementableObjectMethods: []string{"MimeType"},
+	})
+}
+
+func TestMinFreeSpace(t *testing.T) {
+	if *fstest.RemoteName != "" {
+		t.Skip("Skipping as -remote set")
+	}
+	ctx := context.Background()
+	tempdir1 := filepath.Join(os.TempDir(), "rclone-union-test-minfree1")
+	tempdir2 := filepath.Join(os.TempDir(), "rclone-union-test-minfree2")
+	require.NoError(t, os.MkdirAll(tempdir1, 0744))
+	require.NoError(t, os.MkdirAll(tempdir2, 0744))
+	defer func() {
+		_ = os.RemoveAll(tempdir1)
+		_ = os.RemoveAll(tempdir2)
+	}()
+	name := "TestUnionMinFreeSpace"
+	config.FileSet(name, "type", "union")
+	config.FileSet(name, "upstreams", tempdir1+" "+tempdir2)
+	config.FileSet(name, "create_policy", "proportional")
+	config.FileSet(name, "min_free_space", "1P")
+	f, err := fs.NewFs(ctx, name+":")
+	require.NoError(t, err)
+
+	// No upstream has 1 PiB free
+	contents := []byte("hello")
+	src := object.NewStaticObjectInfo("file.txt", time.Now(), int64(len(contents)), true, nil, nil)
+	_, err = f.Put(ctx, bytes.NewReader(contents), src)
+	assert.Equal(t, policy.ErrorNotEnoughSpace, errors.Cause(err))
+
+	// Rebalancing does nothing as the upstreams are on the same disk
+	out, err := f.Features().Command(ctx, "rebalance", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), out.(map[string]interface{})["moved"])
+
+	// The :min= tag overrides min_free_space for an upstream
+	config.FileSet(name, "upstreams", tempdir1+" "+tempdir2+":min=1k")
+	f, err = fs.NewFs(ctx, name+":")
+	require.NoError(t, err)
+	_, err = f.Put(ctx, bytes.NewReader(contents), src)
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(tempdir1, "file.txt"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(tempdir2, "file.txt"))
+	assert.NoError(t, err)
+
+	// A bad tag is an error
+	config.FileSet(name, "upstreams", tempdir1+" "+tempdir2+":min=potato")
+	_, err = fs.NewFs(ctx, name+":")
+	assert.Error(t, err)
+}
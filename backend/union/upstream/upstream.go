@@ -24,16 +24,17 @@ var (
 // Fs is a wrap of any fs and its configs
 type Fs struct {
 	fs.Fs
-	RootFs      fs.Fs
-	RootPath    string
-	writable    bool
-	creatable   bool
-	usage       *fs.Usage     // Cache the usage
-	cacheTime   time.Duration // cache duration
-	cacheExpiry int64         // usage cache expiry time
-	cacheMutex  sync.RWMutex
-	cacheOnce   sync.Once
-	cacheUpdate bool // if the cache is updating
+	RootFs       fs.Fs
+	RootPath     string
+	writable     bool
+	creatable    bool
+	minFreeSpace int64         // don't create files if the free space is less than this
+	usage        *fs.Usage     // Cache the usage
+	cacheTime    time.Duration // cache duration
+	cacheExpiry  int64         // usage cache expiry time
+	cacheMutex   sync.RWMutex
+	cacheOnce    sync.Once
+	cacheUpdate  bool // if the cache is updating
 }
 
 // Directory describes a wrapped Directory
@@ -60,19 +61,32 @@ type Entry interface {
 }
 
 // New creates a new Fs based on the
-// string formatted `type:root_path(:ro/:nc)`
-func New(ctx context.Context, remote, root string, cacheTime time.Duration) (*Fs, error) {
+// string formatted `type:root_path(:ro/:nc)(:min=size)`
+//
+// Create policies won't use the Fs if it has less than minFreeSpace
+// bytes free, or less than the size given with the :min= tag if set.
+func New(ctx context.Context, remote, root string, cacheTime time.Duration, minFreeSpace int64) (*Fs, error) {
 	_, configName, fsPath, err := fs.ParseRemote(remote)
 	if err != nil {
 		return nil, err
 	}
 	f := &Fs{
-		RootPath:    root,
-		writable:    true,
-		creatable:   true,
-		cacheExpiry: time.Now().Unix(),
-		cacheTime:   cacheTime,
-		usage:       &fs.Usage{},
+		RootPath:     root,
+		writable:     true,
+		creatable:    true,
+		minFreeSpace: minFreeSpace,
+		cacheExpiry:  time.Now().Unix(),
+		cacheTime:    cacheTime,
+		usage:        &fs.Usage{},
+	}
+	if i := strings.LastIndex(fsPath, ":min="); i >= 0 {
+		var size fs.SizeSuffix
+		err = size.Set(fsPath[i+len(":min="):])
+		if err != nil {
+			return nil, errors.Wrapf(err, "bad :min= tag in upstream %q", remote)
+		}
+		f.minFreeSpace = int64(size)
+		fsPath = fsPath[:i]
 	}
 	if strings.HasSuffix(fsPath, ":ro") {
 		f.writable = false
@@ -164,6 +178,26 @@ func (f *Fs) IsWritable() bool {
 	return f.writable
 }
 
+// MinFreeSpace returns the free space the fs needs to be used for
+// new files
+func (f *Fs) MinFreeSpace() int64 {
+	return f.minFreeSpace
+}
+
+// HasMinFreeSpace returns false if the fs is known to have less free
+// space than the minimum set for it
+func (f *Fs) HasMinFreeSpace() bool {
+	if f.minFreeSpace <= 0 {
+		return true
+	}
+	space, err := f.GetFreeSpace()
+	if err != nil {
+		// Treat unknown free space as infinite
+		return true
+	}
+	return space >= f.minFreeSpace
+}
+
 // Put in to the remote path with the modTime given of the given size
 //
 // May create the object even if it returns an error - if so
@@ -273,6 +307,43 @@ func (f *Fs) GetFreeSpace() (int64, error) {
 	return *f.usage.Free, nil
 }
 
+// GetUsedFraction get the fraction of the space of the fs which is used
+//
+// This is worked out from the free space and the total space, or the
+// used space if the total isn't known.
+func (f *Fs) GetUsedFraction() (float64, error) {
+	if atomic.LoadInt64(&f.cacheExpiry) <= time.Now().Unix() {
+		err := f.updateUsage()
+		if err != nil {
+			return 0, ErrUsageFieldNotSupported
+		}
+	}
+	f.cacheMutex.RLock()
+	defer f.cacheMutex.RUnlock()
+	return UsedFraction(f.usage)
+}
+
+// UsedFraction returns the fraction of the space in usage which is
+// used
+func UsedFraction(usage *fs.Usage) (float64, error) {
+	if usage.Free == nil {
+		return 0, ErrUsageFieldNotSupported
+	}
+	var total int64
+	switch {
+	case usage.Total != nil:
+		total = *usage.Total
+	case usage.Used != nil:
+		total = *usage.Used + *usage.Free
+	default:
+		return 0, ErrUsageFieldNotSupported
+	}
+	if total <= 0 {
+		return 0, ErrUsageFieldNotSupported
+	}
+	return 1 - float64(*usage.Free)/float64(total), nil
+}
+
 // GetUsedSpace get the used space of the fs
 func (f *Fs) GetUsedSpace() (int64, error) {
 	if atomic.LoadInt64(&f.cacheExpiry) <= time.Now().Unix() {
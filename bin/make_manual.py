@@ -29,6 +29,7 @@ docs = [
     "alias.md",
     "amazonclouddrive.md",
     "s3.md",
+    "archive.md",
     "b2.md",
     "box.md",
     "cache.md",
@@ -36,12 +37,14 @@ docs = [
     "sharefile.md",
     "crypt.md",
     "compress.md",
+    "combine.md",
     "dropbox.md",
     "filefabric.md",
     "ftp.md",
     "googlecloudstorage.md",
     "drive.md",
     "googlephotos.md",
+    "hasher.md",
     "http.md",
     "hubic.md",
     "jottacloud.md",
@@ -0,0 +1,42 @@
+// Package archive provides the archive create and extract commands
+package archive
+
+import (
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/spf13/cobra"
+)
+
+var (
+	format = ""
+)
+
+func init() {
+	cmd.Root.AddCommand(Command)
+	Command.AddCommand(createCommand, extractCommand)
+	for _, command := range []*cobra.Command{createCommand, extractCommand} {
+		cmdFlags := command.Flags()
+		flags.StringVarP(cmdFlags, &format, "format", "", format, "Archive format - zip, tar, tar.gz or tar.zst - if not set use the extension")
+	}
+}
+
+// Command definition for cobra
+var Command = &cobra.Command{
+	Use:   "archive <action> [opts] <source> <destination>",
+	Short: `Create or extract archives.`,
+	Long: `rclone archive is used to make archives from the files on a remote and
+to extract archives to a remote, e.g.
+
+    rclone archive create remote:dir dst:backup.tar.gz
+    rclone archive extract src:bundle.zip dst:dir
+
+The data is streamed between the remotes and the archive so it isn't
+stored on local disk where possible.
+
+The supported formats are zip, tar, tar.gz and tar.zst. The format is
+found from the extension of the archive (.zip, .tar, .tar.gz, .tgz,
+.tar.zst or .tzst) unless the ` + "`--format`" + ` flag is given.
+
+To browse an archive without extracting it, use the archive backend.
+`,
+}
@@ -0,0 +1,90 @@
+package archive
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/fspath"
+	"github.com/rclone/rclone/fstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	t1 = fstest.Time("2017-02-03T04:05:06Z")
+	t2 = fstest.Time("2019-10-11T12:13:14Z")
+)
+
+// TestMain drives the tests
+func TestMain(m *testing.M) {
+	fstest.TestMain(m)
+}
+
+func TestCreateExtract(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	items := []fstest.Item{
+		r.WriteFile("file1.txt", "hello world", t1),
+		r.WriteFile("dir/file2.txt", strings.Repeat("potato ", 1000), t2),
+		r.WriteFile("dir/sub/file3.txt", "", t1),
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(r.LocalName, "empty"), 0777))
+	dirs := []string{"dir", "dir/sub", "empty"}
+
+	for _, leaf := range []string{"backup.zip", "backup.tar", "backup.tar.gz", "backup.tzst"} {
+		t.Run(leaf, func(t *testing.T) {
+			require.NoError(t, Create(ctx, r.Flocal, r.Fremote, leaf, ""))
+			src, err := r.Fremote.NewObject(ctx, leaf)
+			require.NoError(t, err)
+
+			fdst, err := fs.NewFs(ctx, fspath.JoinRootPath(r.FremoteName, "extract-"+leaf))
+			require.NoError(t, err)
+			require.NoError(t, Extract(ctx, fdst, src, ""))
+			fstest.CheckListingWithPrecision(t, fdst, items, dirs, 2*time.Second)
+
+			// Extracting again doesn't transfer anything
+			require.NoError(t, Extract(ctx, fdst, src, ""))
+			fstest.CheckListingWithPrecision(t, fdst, items, dirs, 2*time.Second)
+
+			// Extract with a filter
+			fi, err := filter.NewFilter(nil)
+			require.NoError(t, err)
+			require.NoError(t, fi.AddRule("- /dir/sub/"))
+			filterCtx := filter.ReplaceConfig(ctx, fi)
+			fdst, err = fs.NewFs(ctx, fspath.JoinRootPath(r.FremoteName, "filtered-"+leaf))
+			require.NoError(t, err)
+			require.NoError(t, Extract(filterCtx, fdst, src, ""))
+			fstest.CheckListingWithPrecision(t, fdst, items[:2], []string{"dir", "empty"}, 2*time.Second)
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	ctx := context.Background()
+	r := fstest.NewRun(t)
+	defer r.Finalise()
+	file1 := r.WriteFile("file1.txt", "hello world", t1)
+
+	// Format from the flag
+	require.NoError(t, Create(ctx, r.Flocal, r.Fremote, "backup.dat", "tar.gz"))
+	src, err := r.Fremote.NewObject(ctx, "backup.dat")
+	require.NoError(t, err)
+	fdst, err := fs.NewFs(ctx, fspath.JoinRootPath(r.FremoteName, "extract"))
+	require.NoError(t, err)
+	assert.Error(t, Extract(ctx, fdst, src, ""))
+	assert.Error(t, Extract(ctx, fdst, src, "zip"))
+	require.NoError(t, Extract(ctx, fdst, src, "tar.gz"))
+	fstest.CheckListingWithPrecision(t, fdst, []fstest.Item{file1}, nil, time.Second)
+
+	// Bad formats
+	assert.Error(t, Create(ctx, r.Flocal, r.Fremote, "backup.dat", ""))
+	assert.Error(t, Create(ctx, r.Flocal, r.Fremote, "backup.zip", "rar"))
+}
@@ -0,0 +1,326 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/archive"
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/walk"
+	"github.com/spf13/cobra"
+)
+
+var createCommand = &cobra.Command{
+	Use:   "create source:path dest:path/archive",
+	Short: `Create an archive of source:path in dest:path.`,
+	Long: `
+Create an archive of the files and directories in source:path and
+upload it to dest:path/archive, e.g.
+
+    rclone archive create remote:dir dst:backup.tar.gz
+
+The archive is written as the files are read so it is never stored on
+local disk, unless the destination doesn't support streaming uploads,
+in which case it is spooled to a temporary file first as with ` + "`rclone rcat`" + `.
+
+Filters can be used to choose which files go into the archive.
+Up to ` + "`--transfers`" + ` files are read from the source at once
+to keep the archive being written, though they are added to the
+archive one at a time in sorted order.
+
+The modification times of the files and directories are stored in
+the archive. Files in a tar archive need to have a known size, so
+any files which don't will be skipped with an error.
+
+The format of the archive is found from the extension of dest:path
+unless ` + "`--format`" + ` is given.
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(2, 2, command, args)
+		fsrc := cmd.NewFsSrc(args)
+		fdst, dstFileName := cmd.NewFsDstFile(args[1:])
+		cmd.Run(false, true, command, func() error {
+			return Create(context.Background(), fsrc, fdst, dstFileName, format)
+		})
+	},
+}
+
+// archiveWriter writes directories and files to an archive
+type archiveWriter interface {
+	// addDir adds the directory d
+	addDir(ctx context.Context, d fs.Directory) error
+	// addFile adds the file o reading its data from in and
+	// returning the number of bytes written
+	addFile(ctx context.Context, o fs.Object, in io.Reader) (int64, error)
+	// Close finishes the archive
+	Close() error
+}
+
+// zipWriter writes zip archives
+type zipWriter struct {
+	w *zip.Writer
+}
+
+// addDir adds the directory d
+func (z *zipWriter) addDir(ctx context.Context, d fs.Directory) error {
+	hdr := &zip.FileHeader{
+		Name:     d.Remote() + "/",
+		Modified: d.ModTime(ctx),
+	}
+	hdr.SetMode(os.ModeDir | 0755)
+	_, err := z.w.CreateHeader(hdr)
+	return err
+}
+
+// addFile adds the file o reading its data from in
+func (z *zipWriter) addFile(ctx context.Context, o fs.Object, in io.Reader) (int64, error) {
+	hdr := &zip.FileHeader{
+		Name:     o.Remote(),
+		Method:   zip.Deflate,
+		Modified: o.ModTime(ctx),
+	}
+	hdr.SetMode(0644)
+	out, err := z.w.CreateHeader(hdr)
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(out, in)
+}
+
+// Close finishes the archive
+func (z *zipWriter) Close() error {
+	return z.w.Close()
+}
+
+// tarWriter writes tar archives, optionally compressed
+type tarWriter struct {
+	w          *tar.Writer
+	compressor io.WriteCloser // compressor if set
+}
+
+// addDir adds the directory d
+func (t *tarWriter) addDir(ctx context.Context, d fs.Directory) error {
+	return t.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     d.Remote() + "/",
+		Mode:     0755,
+		ModTime:  d.ModTime(ctx),
+	})
+}
+
+// addFile adds the file o reading its data from in
+func (t *tarWriter) addFile(ctx context.Context, o fs.Object, in io.Reader) (int64, error) {
+	err := t.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     o.Remote(),
+		Mode:     0644,
+		Size:     o.Size(),
+		ModTime:  o.ModTime(ctx),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(t.w, in)
+}
+
+// Close finishes the archive
+func (t *tarWriter) Close() error {
+	err := t.w.Close()
+	if t.compressor != nil {
+		closeErr := t.compressor.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// newArchiveWriter makes an archiveWriter for format writing to out
+func newArchiveWriter(out io.Writer, format string) (archiveWriter, error) {
+	switch format {
+	case archive.FormatZip:
+		return &zipWriter{w: zip.NewWriter(out)}, nil
+	case archive.FormatTar:
+		return &tarWriter{w: tar.NewWriter(out)}, nil
+	case archive.FormatTarGz:
+		compressor := gzip.NewWriter(out)
+		return &tarWriter{w: tar.NewWriter(compressor), compressor: compressor}, nil
+	case archive.FormatTarZst:
+		compressor, err := zstd.NewWriter(out)
+		if err != nil {
+			return nil, err
+		}
+		return &tarWriter{w: tar.NewWriter(compressor), compressor: compressor}, nil
+	}
+	return nil, errors.Errorf("unknown archive format %q", format)
+}
+
+// checkFormat checks format is valid, finding it from the extension
+// of name if it is "" or "auto"
+func checkFormat(name, format string) (string, error) {
+	if format == "" || format == "auto" {
+		format = archive.FormatFromName(name)
+		if format == "" {
+			return "", errors.Errorf("can't work out the archive format of %q - use --format", name)
+		}
+	}
+	switch format {
+	case archive.FormatZip, archive.FormatTar, archive.FormatTarGz, archive.FormatTarZst:
+	default:
+		return "", errors.Errorf("unknown archive format %q", format)
+	}
+	return format, nil
+}
+
+// Create makes an archive of the files and directories in fsrc and
+// uploads it to dstFileName in fdst.
+//
+// If format is "" or "auto" then it is found from dstFileName.
+func Create(ctx context.Context, fsrc, fdst fs.Fs, dstFileName, format string) error {
+	format, err := checkFormat(dstFileName, format)
+	if err != nil {
+		return err
+	}
+
+	// Find the directories and files to put in the archive
+	var entries fs.DirEntries
+	err = walk.ListR(ctx, fsrc, "", false, -1, walk.ListAll, func(newEntries fs.DirEntries) error {
+		for _, entry := range newEntries {
+			if o, ok := entry.(fs.Object); ok && o.Size() < 0 && format != archive.FormatZip {
+				err := fs.CountError(errors.New("can't add file of unknown size to a tar archive"))
+				fs.Errorf(o, "Skipping: %v", err)
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list source")
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Remote() < entries[j].Remote()
+	})
+
+	// Write the archive into a pipe which is uploaded with Rcat
+	pr, pw := io.Pipe()
+	writeErrChan := make(chan error, 1)
+	go func() {
+		err := writeArchive(ctx, pw, format, entries)
+		_ = pw.CloseWithError(err)
+		writeErrChan <- err
+	}()
+	_, err = operations.Rcat(ctx, fdst, dstFileName, pr, time.Now())
+	if err != nil {
+		// stop the writer if it is still running
+		_ = pr.CloseWithError(err)
+	}
+	writeErr := <-writeErrChan
+	if writeErr != nil {
+		return writeErr
+	}
+	return err
+}
+
+// openResult is the result of opening a file for the archive
+type openResult struct {
+	tr  *accounting.Transfer
+	in  io.ReadCloser
+	err error
+}
+
+// writeArchive writes an archive of entries in format to out
+//
+// The files are opened up to --transfers at a time ahead of being
+// written so they can be read in parallel.
+func writeArchive(ctx context.Context, out io.Writer, format string, entries fs.DirEntries) (err error) {
+	ci := fs.GetConfig(ctx)
+	w, err := newArchiveWriter(out, format)
+	if err != nil {
+		return err
+	}
+
+	// Open the files in the background in the order they are written
+	openCtx, cancel := context.WithCancel(ctx)
+	results := make(chan chan openResult, ci.Transfers)
+	go func() {
+		defer close(results)
+		for _, entry := range entries {
+			o, ok := entry.(fs.Object)
+			if !ok {
+				continue
+			}
+			result := make(chan openResult, 1)
+			select {
+			case results <- result:
+			case <-openCtx.Done():
+				return
+			}
+			go func() {
+				tr := accounting.Stats(ctx).NewTransfer(o)
+				in, err := operations.NewReOpen(ctx, o, ci.LowLevelRetries)
+				if err != nil {
+					result <- openResult{tr: tr, err: err}
+					return
+				}
+				result <- openResult{tr: tr, in: tr.Account(ctx, in).WithBuffer()}
+			}()
+		}
+	}()
+	defer func() {
+		// Close any files opened but not written
+		cancel()
+		for result := range results {
+			r := <-result
+			if r.in != nil {
+				_ = r.in.Close()
+			}
+			r.tr.Done(ctx, context.Canceled)
+		}
+	}()
+
+	for _, entry := range entries {
+		switch x := entry.(type) {
+		case fs.Directory:
+			err = w.addDir(ctx, x)
+			if err != nil {
+				return errors.Wrapf(err, "failed to add directory %q to archive", x.Remote())
+			}
+		case fs.Object:
+			r := <-<-results
+			var n int64
+			err = r.err
+			if err == nil {
+				n, err = w.addFile(ctx, x, r.in)
+				closeErr := r.in.Close()
+				if err == nil {
+					err = closeErr
+				}
+			}
+			if err == nil && x.Size() >= 0 && n != x.Size() {
+				err = errors.Errorf("size changed from %d to %d while reading", x.Size(), n)
+			}
+			if err != nil {
+				err = fs.CountError(err)
+				fs.Errorf(x, "Failed to add to archive: %v", err)
+			}
+			r.tr.Done(ctx, err)
+			if err != nil {
+				return errors.Wrapf(err, "failed to add %q to archive", x.Remote())
+			}
+			fs.Debugf(x, "Added to archive")
+		}
+	}
+	return w.Close()
+}
@@ -0,0 +1,178 @@
+package archive
+
+import (
+	"context"
+	"log"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/backend/archive"
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/sync"
+	"github.com/spf13/cobra"
+)
+
+var extractCommand = &cobra.Command{
+	Use:   "extract source:path/archive dest:path",
+	Short: `Extract the archive source:path/archive to dest:path.`,
+	Long: `
+Extract the files and directories in source:path/archive into
+dest:path, e.g.
+
+    rclone archive extract src:bundle.zip dst:dir
+
+The files are read from the archive and uploaded to dest:path
+directly, so the archive is never stored on local disk.
+
+Like ` + "`rclone copy`" + `, files which are already the same in
+dest:path aren't extracted again, and filters can be used to choose
+which files are extracted. The modification times of the files are set
+from the archive.
+
+Files in zip and tar archives are read with range requests so
+` + "`--transfers`" + ` files are extracted at once. Compressed tar
+archives (tar.gz and tar.zst) can only be read from start to finish so
+their files are extracted one at a time as the archive is read.
+` + "`--exclude-if-present`" + ` can't be used with compressed tar
+archives.
+
+The format of the archive is found from the extension of
+source:path/archive unless ` + "`--format`" + ` is given.
+`,
+	Run: func(command *cobra.Command, args []string) {
+		cmd.CheckArgs(2, 2, command, args)
+		fsrc, srcFileName := cmd.NewFsFile(args[0])
+		if srcFileName == "" {
+			log.Fatalf("%q is not a file", args[0])
+		}
+		fdst := cmd.NewFsDir(args[1:])
+		cmd.Run(true, true, command, func() error {
+			ctx := context.Background()
+			src, err := fsrc.NewObject(ctx, srcFileName)
+			if err != nil {
+				return err
+			}
+			return Extract(ctx, fdst, src, format)
+		})
+	},
+}
+
+// Extract copies the files and directories in the archive src into
+// fdst.
+//
+// If format is "" or "auto" then it is found from the name of src.
+func Extract(ctx context.Context, fdst fs.Fs, src fs.Object, format string) error {
+	format, err := checkFormat(src.Remote(), format)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case archive.FormatTarGz, archive.FormatTarZst:
+		return extractStream(ctx, fdst, src, format)
+	}
+	farchive, err := archive.NewFsFromObject(ctx, ":archive", src, format)
+	if err != nil {
+		return err
+	}
+	return sync.CopyDir(ctx, fdst, farchive, true)
+}
+
+// extractStream copies the files and directories in src into fdst
+// reading the archive once from start to finish
+func extractStream(ctx context.Context, fdst fs.Fs, src fs.Object, format string) error {
+	fi := filter.GetConfig(ctx)
+	if fi.Opt.ExcludeFile != "" {
+		return errors.Errorf("can't use --exclude-if-present with %s archives", format)
+	}
+
+	// The files can only be read once so multi-thread copies
+	// can't be used
+	ctx, ci := fs.AddConfig(ctx)
+	ci.MultiThreadStreams = 0
+
+	// Directories are checked with their parents to apply
+	// directory filters to the files within them
+	includeDirectory := fi.IncludeDirectory(ctx, nil)
+	includedDirs := map[string]bool{"": true}
+	var included func(dir string) (bool, error)
+	included = func(dir string) (bool, error) {
+		if include, ok := includedDirs[dir]; ok {
+			return include, nil
+		}
+		include, err := included(parentDir(dir))
+		if err != nil {
+			return false, err
+		}
+		if include {
+			include, err = includeDirectory(dir)
+			if err != nil {
+				return false, err
+			}
+		}
+		includedDirs[dir] = include
+		return include, nil
+	}
+
+	var lastErr error
+	err := archive.Stream(ctx, ":archive", src, format, func(entry fs.DirEntry) error {
+		switch x := entry.(type) {
+		case fs.Directory:
+			include, err := included(x.Remote())
+			if err != nil {
+				return err
+			}
+			if !include {
+				fs.Debugf(x, "Excluded")
+				return nil
+			}
+			err = operations.Mkdir(ctx, fdst, x.Remote())
+			if err != nil {
+				fs.Errorf(fs.LogDirName(fdst, x.Remote()), "Failed to make directory: %v", err)
+				lastErr = err
+			}
+		case fs.Object:
+			include, err := included(parentDir(x.Remote()))
+			if err != nil {
+				return err
+			}
+			if !include || !fi.IncludeObject(ctx, x) {
+				fs.Debugf(x, "Excluded")
+				return nil
+			}
+			dst, err := fdst.NewObject(ctx, x.Remote())
+			if err == fs.ErrorObjectNotFound {
+				dst = nil
+			} else if err != nil {
+				err = fs.CountError(err)
+				fs.Errorf(x, "Failed to read destination: %v", err)
+				lastErr = err
+				return nil
+			}
+			if !operations.NeedTransfer(ctx, dst, x) {
+				return nil
+			}
+			// Copy logs and counts its errors
+			_, err = operations.Copy(ctx, fdst, dst, x.Remote(), x)
+			if err != nil {
+				lastErr = err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return lastErr
+}
+
+// parentDir returns the parent directory of remote or "" for the root
+func parentDir(remote string) string {
+	dir := path.Dir(remote)
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
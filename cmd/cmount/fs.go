@@ -154,6 +154,8 @@ func (fsys *FS) stat(node vfs.Node, stat *fuse.Stat_t) (errc int) {
 	Mode := node.Mode().Perm()
 	if node.IsDir() {
 		Mode |= fuse.S_IFDIR
+	} else if node.Mode()&os.ModeSymlink != 0 {
+		Mode |= fuse.S_IFLNK
 	} else {
 		Mode |= fuse.S_IFREG
 	}
@@ -161,8 +163,7 @@ func (fsys *FS) stat(node vfs.Node, stat *fuse.Stat_t) (errc int) {
 	stat.Ino = node.Inode() // FIXME do we need to set the inode number?
 	stat.Mode = uint32(Mode)
 	stat.Nlink = 1
-	stat.Uid = fsys.VFS.Opt.UID
-	stat.Gid = fsys.VFS.Opt.GID
+	stat.Uid, stat.Gid = node.Owner()
 	//stat.Rdev
 	stat.Size = int64(Size)
 	t := fuse.NewTimespec(modTime)
@@ -331,6 +332,7 @@ func (fsys *FS) CreateEx(filePath string, mode uint32, fi *fuse.FileInfo_t) (err
 	if err != nil {
 		return translateError(err)
 	}
+	vfs.SaveCreateMode(file, os.FileMode(mode))
 	// translate the fuse flags to os flags
 	flags := translateOpenFlags(fi.Flags) | os.O_CREATE
 	handle, err := file.Open(flags)
@@ -436,8 +438,12 @@ func (fsys *FS) Mkdir(dirPath string, mode uint32) (errc int) {
 	if errc != 0 {
 		return errc
 	}
-	_, err := parentDir.Mkdir(leaf)
-	return translateError(err)
+	dir, err := parentDir.Mkdir(leaf)
+	if err != nil {
+		return translateError(err)
+	}
+	vfs.SaveCreateMode(dir, os.FileMode(mode))
+	return 0
 }
 
 // Rmdir removes a directory
@@ -503,27 +509,53 @@ func (fsys *FS) Link(oldpath string, newpath string) (errc int) {
 // Symlink creates a symbolic link.
 func (fsys *FS) Symlink(target string, newpath string) (errc int) {
 	defer log.Trace(target, "newpath=%q", newpath)("errc=%d", &errc)
-	return -fuse.ENOSYS
+	leaf, parentDir, errc := fsys.lookupParentDir(newpath)
+	if errc != 0 {
+		return errc
+	}
+	_, err := parentDir.Symlink(target, leaf)
+	return translateError(err)
 }
 
 // Readlink reads the target of a symbolic link.
 func (fsys *FS) Readlink(path string) (errc int, linkPath string) {
 	defer log.Trace(path, "")("linkPath=%q, errc=%d", &linkPath, &errc)
-	return -fuse.ENOSYS, ""
+	file, errc := fsys.lookupFile(path)
+	if errc != 0 {
+		return errc, ""
+	}
+	linkPath, err := file.Readlink()
+	return translateError(err), linkPath
 }
 
 // Chmod changes the permission bits of a file.
 func (fsys *FS) Chmod(path string, mode uint32) (errc int) {
 	defer log.Trace(path, "mode=0%o", mode)("errc=%d", &errc)
-	// This is a no-op for rclone
-	return 0
+	node, errc := fsys.lookupNode(path)
+	if errc != 0 {
+		return errc
+	}
+	// This is a no-op for rclone unless --vfs-metadata-store is set
+	return translateError(node.Chmod(os.FileMode(mode).Perm()))
 }
 
 // Chown changes the owner and group of a file.
 func (fsys *FS) Chown(path string, uid uint32, gid uint32) (errc int) {
 	defer log.Trace(path, "uid=%d, gid=%d", uid, gid)("errc=%d", &errc)
-	// This is a no-op for rclone
-	return 0
+	node, errc := fsys.lookupNode(path)
+	if errc != 0 {
+		return errc
+	}
+	// A uid or gid of ^uint32(0) means leave unchanged
+	newUID, newGID := -1, -1
+	if uid != ^uint32(0) {
+		newUID = int(uid)
+	}
+	if gid != ^uint32(0) {
+		newGID = int(gid)
+	}
+	// This is a no-op for rclone unless --vfs-metadata-store is set
+	return translateError(node.Chown(newUID, newGID))
 }
 
 // Access checks file access permissions.
@@ -542,22 +574,59 @@ func (fsys *FS) Fsyncdir(path string, datasync bool, fh uint64) (errc int) {
 
 // Setxattr sets extended attributes.
 func (fsys *FS) Setxattr(path string, name string, value []byte, flags int) (errc int) {
-	return -fuse.ENOSYS
+	defer log.Trace(path, "name=%q, flags=%d", name, flags)("errc=%d", &errc)
+	node, errc := fsys.lookupNode(path)
+	if errc != 0 {
+		return errc
+	}
+	vfsFlags := 0
+	if flags&fuse.XATTR_CREATE != 0 {
+		vfsFlags |= vfs.XattrCreate
+	}
+	if flags&fuse.XATTR_REPLACE != 0 {
+		vfsFlags |= vfs.XattrReplace
+	}
+	return translateError(node.Setxattr(name, value, vfsFlags))
 }
 
 // Getxattr gets extended attributes.
 func (fsys *FS) Getxattr(path string, name string) (errc int, value []byte) {
-	return -fuse.ENOSYS, nil
+	defer log.Trace(path, "name=%q", name)("errc=%d", &errc)
+	node, errc := fsys.lookupNode(path)
+	if errc != 0 {
+		return errc, nil
+	}
+	value, err := node.Getxattr(name)
+	return translateError(err), value
 }
 
 // Removexattr removes extended attributes.
 func (fsys *FS) Removexattr(path string, name string) (errc int) {
-	return -fuse.ENOSYS
+	defer log.Trace(path, "name=%q", name)("errc=%d", &errc)
+	node, errc := fsys.lookupNode(path)
+	if errc != 0 {
+		return errc
+	}
+	return translateError(node.Removexattr(name))
 }
 
 // Listxattr lists extended attributes.
 func (fsys *FS) Listxattr(path string, fill func(name string) bool) (errc int) {
-	return -fuse.ENOSYS
+	defer log.Trace(path, "")("errc=%d", &errc)
+	node, errc := fsys.lookupNode(path)
+	if errc != 0 {
+		return errc
+	}
+	names, err := node.Listxattr()
+	if err != nil {
+		return translateError(err)
+	}
+	for _, name := range names {
+		if !fill(name) {
+			return -fuse.ERANGE
+		}
+	}
+	return 0
 }
 
 // Translate errors from mountlib
@@ -588,6 +657,8 @@ func translateError(err error) (errc int) {
 		return -fuse.ENOSYS
 	case vfs.EINVAL:
 		return -fuse.EINVAL
+	case vfs.ENOATTR:
+		return -fuse.ENOATTR
 	}
 	fs.Errorf(nil, "IO error: %v", err)
 	return -fuse.EIO
@@ -44,6 +44,15 @@ the files in remote:path.
 
     rclone cryptcheck remote:path encryptedremote:path
 
+The file names on encryptedremote: are decrypted using its
+filename_encryption and filename_encoding settings, so these must
+match the ones the files were uploaded with.
+
+If the manifest option is set on encryptedremote: then each file is
+also checked against its directory manifest, and files which have been
+swapped or rolled back to an earlier version are reported as tampered
+with.
+
 After it has run it will log the status of the encryptedremote:.
 ` + check.FlagsHelp,
 	Run: func(command *cobra.Command, args []string) {
@@ -91,6 +100,10 @@ func cryptCheck(ctx context.Context, fdst, fsrc fs.Fs) error {
 			return false, true, nil
 		}
 		cryptHash, err := fcrypt.ComputeHash(ctx, cryptDst, src, hashType)
+		if cause := errors.Cause(err); cause == crypt.ErrorManifestMismatch || cause == crypt.ErrorManifestBad {
+			fs.Errorf(src, "tampered with: %v", err)
+			return true, false, nil
+		}
 		if err != nil {
 			return true, false, errors.Wrap(err, "error computing hash")
 		}
@@ -31,6 +31,9 @@ a list of encrypted file names. List limit is 10 items.
 
 If you supply the --reverse flag, it will return encrypted file names.
 
+The names are encrypted and encoded using the filename_encryption and
+filename_encoding settings of encryptedremote:.
+
 use it like this
 
 	rclone cryptdecode encryptedremote: encryptedfilename1 encryptedfilename2
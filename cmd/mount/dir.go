@@ -30,9 +30,8 @@ var _ fusefs.Node = (*Dir)(nil)
 func (d *Dir) Attr(ctx context.Context, a *fuse.Attr) (err error) {
 	defer log.Trace(d, "")("attr=%+v, err=%v", a, &err)
 	a.Valid = d.fsys.opt.AttrTimeout
-	a.Gid = d.VFS().Opt.GID
-	a.Uid = d.VFS().Opt.UID
-	a.Mode = os.ModeDir | d.VFS().Opt.DirPerms
+	a.Uid, a.Gid = d.Dir.Owner()
+	a.Mode = d.Dir.Mode()
 	modTime := d.ModTime()
 	a.Atime = modTime
 	a.Mtime = modTime
@@ -46,9 +45,14 @@ func (d *Dir) Attr(ctx context.Context, a *fuse.Attr) (err error) {
 // Check interface satisfied
 var _ fusefs.NodeSetattrer = (*Dir)(nil)
 
-// Setattr handles attribute changes from FUSE. Currently supports ModTime only.
+// Setattr handles attribute changes from FUSE. Currently supports
+// ModTime and Mode and owner if --vfs-metadata-store is set.
 func (d *Dir) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse.SetattrResponse) (err error) {
 	defer log.Trace(d, "stat=%+v", req)("err=%v", &err)
+	err = setattrOwnerMode(d.Dir, req)
+	if err != nil {
+		return translateError(err)
+	}
 	if d.VFS().Opt.NoModTime {
 		return nil
 	}
@@ -128,6 +132,8 @@ func (d *Dir) ReadDirAll(ctx context.Context) (dirents []fuse.Dirent, err error)
 		}
 		if node.IsDir() {
 			dirent.Type = fuse.DT_Dir
+		} else if node.Mode()&os.ModeSymlink != 0 {
+			dirent.Type = fuse.DT_Link
 		}
 		dirents = append(dirents, dirent)
 	}
@@ -144,6 +150,7 @@ func (d *Dir) Create(ctx context.Context, req *fuse.CreateRequest, resp *fuse.Cr
 	if err != nil {
 		return nil, nil, translateError(err)
 	}
+	vfs.SaveCreateMode(file, req.Mode)
 	fh, err := file.Open(int(req.Flags) | os.O_CREATE)
 	if err != nil {
 		return nil, nil, translateError(err)
@@ -162,11 +169,26 @@ func (d *Dir) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (node fusefs.No
 	if err != nil {
 		return nil, translateError(err)
 	}
+	vfs.SaveCreateMode(dir, req.Mode)
 	node = &Dir{dir, d.fsys}
 	dir.SetSys(node) // cache the FUSE node for later
 	return node, nil
 }
 
+var _ fusefs.NodeSymlinker = (*Dir)(nil)
+
+// Symlink creates a new symbolic link in the receiver
+func (d *Dir) Symlink(ctx context.Context, req *fuse.SymlinkRequest) (node fusefs.Node, err error) {
+	defer log.Trace(d, "name=%q, target=%q", req.NewName, req.Target)("node=%+v, err=%v", &node, &err)
+	file, err := d.Dir.Symlink(req.Target, req.NewName)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	node = &File{file, d.fsys}
+	file.SetSys(node) // cache the FUSE node for later
+	return node, nil
+}
+
 var _ fusefs.NodeRemover = (*Dir)(nil)
 
 // Remove removes the entry with the given name from
@@ -252,3 +274,37 @@ func (d *Dir) Mknod(ctx context.Context, req *fuse.MknodRequest) (node fusefs.No
 	}
 	return node, nil
 }
+
+// Getxattr gets an extended attribute by the given name from the
+// node.
+//
+// If there is no xattr by that name, returns fuse.ErrNoXattr.
+func (d *Dir) Getxattr(ctx context.Context, req *fuse.GetxattrRequest, resp *fuse.GetxattrResponse) error {
+	return getxattr(d.Dir, req, resp)
+}
+
+var _ fusefs.NodeGetxattrer = (*Dir)(nil)
+
+// Listxattr lists the extended attributes recorded for the node.
+func (d *Dir) Listxattr(ctx context.Context, req *fuse.ListxattrRequest, resp *fuse.ListxattrResponse) error {
+	return listxattr(d.Dir, req, resp)
+}
+
+var _ fusefs.NodeListxattrer = (*Dir)(nil)
+
+// Setxattr sets an extended attribute with the given name and
+// value for the node.
+func (d *Dir) Setxattr(ctx context.Context, req *fuse.SetxattrRequest) error {
+	return translateError(d.Dir.Setxattr(req.Name, req.Xattr, setxattrFlags(req.Flags)))
+}
+
+var _ fusefs.NodeSetxattrer = (*Dir)(nil)
+
+// Removexattr removes an extended attribute for the name.
+//
+// If there is no xattr by that name, returns fuse.ErrNoXattr.
+func (d *Dir) Removexattr(ctx context.Context, req *fuse.RemovexattrRequest) error {
+	return translateError(d.Dir.Removexattr(req.Name))
+}
+
+var _ fusefs.NodeRemovexattrer = (*Dir)(nil)
@@ -4,6 +4,7 @@ package mount
 
 import (
 	"context"
+	"os"
 	"time"
 
 	"bazil.org/fuse"
@@ -28,9 +29,8 @@ func (f *File) Attr(ctx context.Context, a *fuse.Attr) (err error) {
 	modTime := f.File.ModTime()
 	Size := uint64(f.File.Size())
 	Blocks := (Size + 511) / 512
-	a.Gid = f.VFS().Opt.GID
-	a.Uid = f.VFS().Opt.UID
-	a.Mode = f.VFS().Opt.FilePerms
+	a.Uid, a.Gid = f.File.Owner()
+	a.Mode = f.File.Mode() &^ os.ModeAppend
 	a.Size = Size
 	a.Atime = modTime
 	a.Mtime = modTime
@@ -43,9 +43,14 @@ func (f *File) Attr(ctx context.Context, a *fuse.Attr) (err error) {
 // Check interface satisfied
 var _ fusefs.NodeSetattrer = (*File)(nil)
 
-// Setattr handles attribute changes from FUSE. Currently supports ModTime and Size only
+// Setattr handles attribute changes from FUSE. Currently supports
+// ModTime and Size and Mode and owner if --vfs-metadata-store is set.
 func (f *File) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse.SetattrResponse) (err error) {
 	defer log.Trace(f, "a=%+v", req)("err=%v", &err)
+	err = setattrOwnerMode(f.File, req)
+	if err != nil {
+		return translateError(err)
+	}
 	if !f.VFS().Opt.NoModTime {
 		if req.Valid.Mtime() {
 			err = f.File.SetModTime(req.Mtime)
@@ -82,6 +87,18 @@ func (f *File) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenR
 }
 
 // Check interface satisfied
+var _ fusefs.NodeReadlinker = (*File)(nil)
+
+// Readlink reads the target of a symlink
+func (f *File) Readlink(ctx context.Context, req *fuse.ReadlinkRequest) (target string, err error) {
+	defer log.Trace(f, "")("target=%q, err=%v", &target, &err)
+	target, err = f.File.Readlink()
+	if err != nil {
+		return "", translateError(err)
+	}
+	return target, nil
+}
+
 var _ fusefs.NodeFsyncer = (*File)(nil)
 
 // Fsync the file
@@ -97,14 +114,14 @@ func (f *File) Fsync(ctx context.Context, req *fuse.FsyncRequest) (err error) {
 //
 // If there is no xattr by that name, returns fuse.ErrNoXattr.
 func (f *File) Getxattr(ctx context.Context, req *fuse.GetxattrRequest, resp *fuse.GetxattrResponse) error {
-	return fuse.ENOSYS // we never implement this
+	return getxattr(f.File, req, resp)
 }
 
 var _ fusefs.NodeGetxattrer = (*File)(nil)
 
 // Listxattr lists the extended attributes recorded for the node.
 func (f *File) Listxattr(ctx context.Context, req *fuse.ListxattrRequest, resp *fuse.ListxattrResponse) error {
-	return fuse.ENOSYS // we never implement this
+	return listxattr(f.File, req, resp)
 }
 
 var _ fusefs.NodeListxattrer = (*File)(nil)
@@ -112,7 +129,7 @@ var _ fusefs.NodeListxattrer = (*File)(nil)
 // Setxattr sets an extended attribute with the given name and
 // value for the node.
 func (f *File) Setxattr(ctx context.Context, req *fuse.SetxattrRequest) error {
-	return fuse.ENOSYS // we never implement this
+	return translateError(f.File.Setxattr(req.Name, req.Xattr, setxattrFlags(req.Flags)))
 }
 
 var _ fusefs.NodeSetxattrer = (*File)(nil)
@@ -121,7 +138,7 @@ var _ fusefs.NodeSetxattrer = (*File)(nil)
 //
 // If there is no xattr by that name, returns fuse.ErrNoXattr.
 func (f *File) Removexattr(ctx context.Context, req *fuse.RemovexattrRequest) error {
-	return fuse.ENOSYS // we never implement this
+	return translateError(f.File.Removexattr(req.Name))
 }
 
 var _ fusefs.NodeRemovexattrer = (*File)(nil)
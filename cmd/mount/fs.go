@@ -15,6 +15,7 @@ import (
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/log"
 	"github.com/rclone/rclone/vfs"
+	"github.com/rclone/rclone/vfs/vfslock"
 )
 
 // FS represents the top level filing system
@@ -70,6 +71,50 @@ func (f *FS) Statfs(ctx context.Context, req *fuse.StatfsRequest, resp *fuse.Sta
 	return nil
 }
 
+// setattrOwnerMode applies any mode or owner changes in req to node
+func setattrOwnerMode(node vfs.Node, req *fuse.SetattrRequest) error {
+	if req.Valid.Mode() {
+		err := node.Chmod(req.Mode)
+		if err != nil {
+			return err
+		}
+	}
+	if req.Valid.Uid() || req.Valid.Gid() {
+		uid, gid := -1, -1
+		if req.Valid.Uid() {
+			uid = int(req.Uid)
+		}
+		if req.Valid.Gid() {
+			gid = int(req.Gid)
+		}
+		err := node.Chown(uid, gid)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getxattr reads the extended attribute in req from node
+func getxattr(node vfs.Node, req *fuse.GetxattrRequest, resp *fuse.GetxattrResponse) error {
+	value, err := node.Getxattr(req.Name)
+	if err != nil {
+		return translateError(err)
+	}
+	resp.Xattr = value
+	return nil
+}
+
+// listxattr lists the extended attributes of node
+func listxattr(node vfs.Node, req *fuse.ListxattrRequest, resp *fuse.ListxattrResponse) error {
+	names, err := node.Listxattr()
+	if err != nil {
+		return translateError(err)
+	}
+	resp.Append(names...)
+	return nil
+}
+
 // Translate errors from mountlib
 func translateError(err error) error {
 	if err == nil {
@@ -98,6 +143,29 @@ func translateError(err error) error {
 		return fuse.ENOSYS
 	case vfs.EINVAL:
 		return fuse.Errno(syscall.EINVAL)
+	case vfs.ENOATTR:
+		return fuse.ErrNoXattr
+	case vfslock.ErrLocked:
+		return fuse.Errno(syscall.EAGAIN)
 	}
 	return err
 }
+
+// Flags in a Setxattr request - these are from setxattr(2) on Linux.
+// FreeBSD doesn't have them so always sends 0.
+const (
+	fuseXattrCreate  = 0x1
+	fuseXattrReplace = 0x2
+)
+
+// setxattrFlags converts the flags from a Setxattr request into the
+// flags for vfs Setxattr
+func setxattrFlags(flags uint32) (vfsFlags int) {
+	if flags&fuseXattrCreate != 0 {
+		vfsFlags |= vfs.XattrCreate
+	}
+	if flags&fuseXattrReplace != 0 {
+		vfsFlags |= vfs.XattrReplace
+	}
+	return vfsFlags
+}
@@ -4,12 +4,15 @@ package mount
 
 import (
 	"context"
+	"fmt"
 	"io"
 
 	"bazil.org/fuse"
 	fusefs "bazil.org/fuse/fs"
+	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/log"
 	"github.com/rclone/rclone/vfs"
+	"github.com/rclone/rclone/vfs/vfslock"
 )
 
 // FileHandle is an open for read file handle on a File
@@ -69,6 +72,8 @@ var _ fusefs.HandleFlusher = (*FileHandle)(nil)
 // some writes, or that if will be called at all.
 func (fh *FileHandle) Flush(ctx context.Context, req *fuse.FlushRequest) (err error) {
 	defer log.Trace(fh, "")("err=%v", &err)
+	// POSIX locks are released when any file descriptor is closed
+	fh.unlockAll(ctx, req.LockOwner)
 	return translateError(fh.Handle.Flush())
 }
 
@@ -80,5 +85,91 @@ var _ fusefs.HandleReleaser = (*FileHandle)(nil)
 // the kernel
 func (fh *FileHandle) Release(ctx context.Context, req *fuse.ReleaseRequest) (err error) {
 	defer log.Trace(fh, "")("err=%v", &err)
+	if req.ReleaseFlags&fuse.ReleaseFlockUnlock != 0 {
+		fh.unlockAll(ctx, req.LockOwner)
+	}
 	return translateError(fh.Handle.Release())
 }
+
+// lockOwner returns the owner of the VFS locks for owner
+func lockOwner(owner fuse.LockOwner) string {
+	return fmt.Sprintf("mount:%x", uint64(owner))
+}
+
+// vfsLock converts a FUSE lock request into a VFS lock
+func (fh *FileHandle) vfsLock(owner fuse.LockOwner, lk fuse.FileLock) vfslock.Lock {
+	end := int64(vfslock.EOF)
+	if lk.End < uint64(vfslock.EOF) {
+		end = int64(lk.End)
+	}
+	lockType := vfslock.Read
+	if lk.Type == fuse.LockWrite {
+		lockType = vfslock.Write
+	}
+	return vfslock.Lock{
+		Path:  fh.Handle.Node().Path(),
+		Owner: lockOwner(owner),
+		Type:  lockType,
+		Start: int64(lk.Start),
+		End:   end,
+	}
+}
+
+// unlockAll releases all the locks held by owner on the file
+func (fh *FileHandle) unlockAll(ctx context.Context, owner fuse.LockOwner) {
+	p := fh.Handle.Node().Path()
+	err := fh.Handle.Node().VFS().Locks().UnlockAll(ctx, p, lockOwner(owner))
+	if err != nil {
+		fs.Errorf(p, "Failed to release locks: %v", err)
+	}
+}
+
+// Check interface satisfied
+var (
+	_ fusefs.HandleFlockLocker = (*FileHandle)(nil)
+	_ fusefs.HandlePOSIXLocker = (*FileHandle)(nil)
+)
+
+// Lock tries to acquire a lock on a byte range of the file
+func (fh *FileHandle) Lock(ctx context.Context, req *fuse.LockRequest) (err error) {
+	defer log.Trace(fh, "owner=%v, range=%d..%d, type=%v", req.LockOwner, req.Lock.Start, req.Lock.End, req.Lock.Type)("err=%v", &err)
+	return translateError(fh.Handle.Node().VFS().Locks().Lock(ctx, fh.vfsLock(req.LockOwner, req.Lock)))
+}
+
+// LockWait acquires a lock on a byte range of the file, waiting
+// until it can be obtained
+func (fh *FileHandle) LockWait(ctx context.Context, req *fuse.LockWaitRequest) (err error) {
+	defer log.Trace(fh, "owner=%v, range=%d..%d, type=%v", req.LockOwner, req.Lock.Start, req.Lock.End, req.Lock.Type)("err=%v", &err)
+	err = fh.Handle.Node().VFS().Locks().LockWait(ctx, fh.vfsLock(req.LockOwner, req.Lock))
+	if err == context.Canceled {
+		return fuse.EINTR
+	}
+	return translateError(err)
+}
+
+// Unlock releases the lock on a byte range of the file
+func (fh *FileHandle) Unlock(ctx context.Context, req *fuse.UnlockRequest) (err error) {
+	defer log.Trace(fh, "owner=%v, range=%d..%d", req.LockOwner, req.Lock.Start, req.Lock.End)("err=%v", &err)
+	lk := fh.vfsLock(req.LockOwner, req.Lock)
+	return translateError(fh.Handle.Node().VFS().Locks().Unlock(ctx, lk.Path, lk.Owner, lk.Start, lk.End))
+}
+
+// QueryLock returns a lock which conflicts with the one asked for if
+// there is one
+func (fh *FileHandle) QueryLock(ctx context.Context, req *fuse.QueryLockRequest, resp *fuse.QueryLockResponse) (err error) {
+	defer log.Trace(fh, "owner=%v, range=%d..%d, type=%v", req.LockOwner, req.Lock.Start, req.Lock.End, req.Lock.Type)("err=%v", &err)
+	conflict := fh.Handle.Node().VFS().Locks().Test(ctx, fh.vfsLock(req.LockOwner, req.Lock))
+	if conflict == nil {
+		return nil
+	}
+	resp.Lock = fuse.FileLock{
+		Start: uint64(conflict.Start),
+		End:   uint64(conflict.End),
+		Type:  fuse.LockRead,
+		PID:   -1, // the owner may not be a local process
+	}
+	if conflict.Type == vfslock.Write {
+		resp.Lock.Type = fuse.LockWrite
+	}
+	return nil
+}
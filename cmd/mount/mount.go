@@ -28,6 +28,11 @@ func mountOptions(VFS *vfs.VFS, device string, opt *mountlib.Options) (options [
 		fuse.FSName(device),
 		fuse.VolumeName(opt.VolumeName),
 
+		// Send flock and fcntl locks to rclone so they are
+		// shared with the other users of the VFS
+		fuse.LockingFlock(),
+		fuse.LockingPOSIX(),
+
 		// Options from benchmarking in the fuse module
 		//fuse.MaxReadahead(64 * 1024 * 1024),
 		//fuse.WritebackCache(),
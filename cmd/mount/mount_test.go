@@ -6,7 +6,9 @@ import (
 	"runtime"
 	"testing"
 
+	"github.com/rclone/rclone/vfs"
 	"github.com/rclone/rclone/vfs/vfstest"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMount(t *testing.T) {
@@ -15,3 +17,10 @@ func TestMount(t *testing.T) {
 	}
 	vfstest.RunTests(t, false, mount)
 }
+
+func TestSetxattrFlags(t *testing.T) {
+	assert.Equal(t, 0, setxattrFlags(0))
+	assert.Equal(t, vfs.XattrCreate, setxattrFlags(fuseXattrCreate))
+	assert.Equal(t, vfs.XattrReplace, setxattrFlags(fuseXattrReplace))
+	assert.Equal(t, 0, setxattrFlags(0x100), "unknown flags ignored")
+}
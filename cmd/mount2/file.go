@@ -6,10 +6,12 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sync"
 	"syscall"
 
 	fusefs "github.com/hanwen/go-fuse/v2/fs"
 	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/log"
 	"github.com/rclone/rclone/vfs"
 )
@@ -33,8 +35,10 @@ import (
 // FOPEN_DIRECT_IO flag from their `Open` method. See directio_test.go
 // for an example.
 type FileHandle struct {
-	h    vfs.Handle
-	fsys *FS
+	h          vfs.Handle
+	fsys       *FS
+	mu         sync.Mutex
+	lockOwners map[uint64]struct{} // owners which have taken locks through this handle
 }
 
 // Create a new FileHandle
@@ -95,9 +99,44 @@ var _ fusefs.FileFlusher = (*FileHandle)(nil)
 // so any cleanup that requires specific synchronization or
 // could fail with I/O errors should happen in Flush instead.
 func (f *FileHandle) Release(ctx context.Context) syscall.Errno {
+	f.unlockAll(ctx)
 	return translateError(f.h.Release())
 }
 
+// lockOwner returns the owner of the VFS locks for owner
+func lockOwner(owner uint64) string {
+	return fmt.Sprintf("mount2:%x", owner)
+}
+
+// addLockOwner records that owner has taken locks through this handle
+func (f *FileHandle) addLockOwner(owner uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.lockOwners == nil {
+		f.lockOwners = make(map[uint64]struct{}, 1)
+	}
+	f.lockOwners[owner] = struct{}{}
+}
+
+// unlockAll releases the locks taken through this handle
+//
+// go-fuse doesn't tell us the lock owner on Flush so the locks are
+// released when the file is finally closed rather than on every close
+// as POSIX requires.
+func (f *FileHandle) unlockAll(ctx context.Context) {
+	f.mu.Lock()
+	owners := f.lockOwners
+	f.lockOwners = nil
+	f.mu.Unlock()
+	p := f.h.Node().Path()
+	for owner := range owners {
+		err := f.h.Node().VFS().Locks().UnlockAll(ctx, p, lockOwner(owner))
+		if err != nil {
+			fs.Errorf(p, "Failed to release locks: %v", err)
+		}
+	}
+}
+
 var _ fusefs.FileReleaser = (*FileHandle)(nil)
 
 // Fsync is a signal to ensure writes to the Inode are flushed
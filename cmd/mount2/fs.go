@@ -14,6 +14,7 @@ import (
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/log"
 	"github.com/rclone/rclone/vfs"
+	"github.com/rclone/rclone/vfs/vfslock"
 )
 
 // FS represents the top level filing system
@@ -53,6 +54,8 @@ func getMode(node os.FileInfo) uint32 {
 	Mode := node.Mode().Perm()
 	if node.IsDir() {
 		Mode |= fuse.S_IFDIR
+	} else if node.Mode()&os.ModeSymlink != 0 {
+		Mode |= fuse.S_IFLNK
 	} else {
 		Mode |= fuse.S_IFREG
 	}
@@ -66,9 +69,7 @@ func setAttr(node vfs.Node, attr *fuse.Attr) {
 	Blocks := (Size + BlockSize - 1) / BlockSize
 	modTime := node.ModTime()
 	// set attributes
-	vfs := node.VFS()
-	attr.Owner.Gid = vfs.Opt.GID
-	attr.Owner.Uid = vfs.Opt.UID
+	attr.Owner.Uid, attr.Owner.Gid = node.Owner()
 	attr.Mode = getMode(node)
 	attr.Size = Size
 	attr.Nlink = 1
@@ -126,6 +127,10 @@ func translateError(err error) syscall.Errno {
 		return syscall.ENOSYS
 	case vfs.EINVAL:
 		return syscall.EINVAL
+	case vfs.ENOATTR:
+		return syscall.Errno(fuse.ENOATTR)
+	case vfslock.ErrLocked:
+		return syscall.EAGAIN
 	}
 	fs.Errorf(nil, "IO error: %v", err)
 	return syscall.EIO
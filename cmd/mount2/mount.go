@@ -34,6 +34,10 @@ func mountOptions(fsys *FS, f fs.Fs) (mountOpts *fuse.MountOptions) {
 		Debug:         fsys.opt.DebugFUSE,
 		MaxReadAhead:  int(fsys.opt.MaxReadAhead),
 
+		// Send flock and fcntl locks to rclone so they are
+		// shared with the other users of the VFS
+		EnableLocks: true,
+
 		// RememberInodes: true,
 		// SingleThreaded: true,
 
@@ -14,6 +14,7 @@ import (
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/log"
 	"github.com/rclone/rclone/vfs"
+	"github.com/rclone/rclone/vfs/vfslock"
 )
 
 // Node represents a directory or file
@@ -139,6 +140,30 @@ func (n *Node) Setattr(ctx context.Context, f fusefs.FileHandle, in *fuse.SetAtt
 		out.Attr.Mtime = uint64(mtime.Unix())
 		out.Attr.Mtimensec = uint32(mtime.Nanosecond())
 	}
+	mode, ok := in.GetMode()
+	if ok {
+		err = n.node.Chmod(os.FileMode(mode).Perm())
+		if err != nil {
+			return translateError(err)
+		}
+		out.Attr.Mode = getMode(n.node)
+	}
+	uid, uidOK := in.GetUID()
+	gid, gidOK := in.GetGID()
+	if uidOK || gidOK {
+		newUID, newGID := -1, -1
+		if uidOK {
+			newUID = int(uid)
+		}
+		if gidOK {
+			newGID = int(gid)
+		}
+		err = n.node.Chown(newUID, newGID)
+		if err != nil {
+			return translateError(err)
+		}
+		out.Attr.Owner.Uid, out.Attr.Owner.Gid = n.node.Owner()
+	}
 	return 0
 }
 
@@ -305,6 +330,7 @@ func (n *Node) Mkdir(ctx context.Context, name string, mode uint32, out *fuse.En
 	if err != nil {
 		return nil, translateError(err)
 	}
+	vfs.SaveCreateMode(newDir, os.FileMode(mode))
 	newNode := newNode(n.fsys, newDir)
 	n.fsys.setEntryOut(newNode.node, out)
 	newInode := n.NewInode(ctx, newNode, fusefs.StableAttr{Mode: out.Attr.Mode})
@@ -329,6 +355,7 @@ func (n *Node) Create(ctx context.Context, name string, flags uint32, mode uint3
 	if err != nil {
 		return nil, nil, 0, translateError(err)
 	}
+	vfs.SaveCreateMode(file, os.FileMode(mode))
 	handle, err := file.Open(osFlags)
 	if err != nil {
 		return nil, nil, 0, translateError(err)
@@ -355,6 +382,42 @@ func (n *Node) Create(ctx context.Context, name string, flags uint32, mode uint3
 
 var _ = (fusefs.NodeCreater)((*Node)(nil))
 
+// Symlink is similar to Lookup, but must create a new symbolic link
+// pointing at target.
+func (n *Node) Symlink(ctx context.Context, target, name string, out *fuse.EntryOut) (node *fusefs.Inode, errno syscall.Errno) {
+	defer log.Trace(n, "name=%q, target=%q", name, target)("node=%v, errno=%v", &node, &errno)
+	dir, ok := n.node.(*vfs.Dir)
+	if !ok {
+		return nil, syscall.ENOTDIR
+	}
+	file, err := dir.Symlink(target, name)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	newNode := newNode(n.fsys, file)
+	n.fsys.setEntryOut(newNode.node, out)
+	newInode := n.NewInode(ctx, newNode, fusefs.StableAttr{Mode: out.Attr.Mode})
+	return newInode, 0
+}
+
+var _ = (fusefs.NodeSymlinker)((*Node)(nil))
+
+// Readlink reads the content of a symlink.
+func (n *Node) Readlink(ctx context.Context) (target []byte, errno syscall.Errno) {
+	defer log.Trace(n, "")("target=%q, errno=%v", &target, &errno)
+	file, ok := n.node.(*vfs.File)
+	if !ok {
+		return nil, syscall.EINVAL
+	}
+	linkPath, err := file.Readlink()
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return []byte(linkPath), 0
+}
+
+var _ = (fusefs.NodeReadlinker)((*Node)(nil))
+
 // Unlink should remove a child from this directory.  If the
 // return status is OK, the Inode is removed as child in the
 // FS tree automatically. Default is to return EROFS.
@@ -404,3 +467,149 @@ func (n *Node) Rename(ctx context.Context, oldName string, newParent fusefs.Inod
 }
 
 var _ = (fusefs.NodeRenamer)((*Node)(nil))
+
+// Getxattr should read data for the given attribute into
+// `dest` and return the number of bytes. If `dest` is too
+// small, it should return ERANGE and the size of the attribute.
+// If not defined, Getxattr will return ENOATTR.
+func (n *Node) Getxattr(ctx context.Context, attr string, dest []byte) (size uint32, errno syscall.Errno) {
+	defer log.Trace(n, "attr=%q", attr)("size=%d, errno=%v", &size, &errno)
+	value, err := n.node.Getxattr(attr)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return copyXattr(dest, value)
+}
+
+var _ = (fusefs.NodeGetxattrer)((*Node)(nil))
+
+// Setxattr should store data for the given attribute.  See
+// setxattr(2) for information about flags.
+// If not defined, Setxattr will return ENOATTR.
+func (n *Node) Setxattr(ctx context.Context, attr string, data []byte, flags uint32) (errno syscall.Errno) {
+	defer log.Trace(n, "attr=%q, flags=%d", attr, flags)("errno=%v", &errno)
+	return translateError(n.node.Setxattr(attr, data, int(flags)))
+}
+
+var _ = (fusefs.NodeSetxattrer)((*Node)(nil))
+
+// Removexattr should delete the given attribute.
+// If not defined, Removexattr will return ENOATTR.
+func (n *Node) Removexattr(ctx context.Context, attr string) (errno syscall.Errno) {
+	defer log.Trace(n, "attr=%q", attr)("errno=%v", &errno)
+	return translateError(n.node.Removexattr(attr))
+}
+
+var _ = (fusefs.NodeRemovexattrer)((*Node)(nil))
+
+// Listxattr should read all attributes (null terminated) into
+// `dest`. If the `dest` buffer is too small, it should return ERANGE
+// and the correct size.  If not defined, return an empty list and
+// success.
+func (n *Node) Listxattr(ctx context.Context, dest []byte) (size uint32, errno syscall.Errno) {
+	defer log.Trace(n, "")("size=%d, errno=%v", &size, &errno)
+	names, err := n.node.Listxattr()
+	if err != nil {
+		return 0, translateError(err)
+	}
+	var list []byte
+	for _, name := range names {
+		list = append(list, name...)
+		list = append(list, 0)
+	}
+	return copyXattr(dest, list)
+}
+
+var _ = (fusefs.NodeListxattrer)((*Node)(nil))
+
+// vfsLock converts a FUSE lock into a VFS lock
+func (n *Node) vfsLock(owner uint64, lk *fuse.FileLock) vfslock.Lock {
+	end := int64(vfslock.EOF)
+	if lk.End < uint64(vfslock.EOF) {
+		end = int64(lk.End)
+	}
+	lockType := vfslock.Read
+	if lk.Typ == syscall.F_WRLCK {
+		lockType = vfslock.Write
+	}
+	return vfslock.Lock{
+		Path:  n.node.Path(),
+		Owner: lockOwner(owner),
+		Type:  lockType,
+		Start: int64(lk.Start),
+		End:   end,
+	}
+}
+
+// Getlk returns locks that would conflict with the given input
+// lock. If no locks conflict, the output has type L_UNLCK. See
+// fcntl(2) for more information.
+func (n *Node) Getlk(ctx context.Context, f fusefs.FileHandle, owner uint64, lk *fuse.FileLock, flags uint32, out *fuse.FileLock) (errno syscall.Errno) {
+	defer log.Trace(n, "owner=%x, lk=%v", owner, lk)("out=%v, errno=%v", out, &errno)
+	conflict := n.node.VFS().Locks().Test(ctx, n.vfsLock(owner, lk))
+	if conflict == nil {
+		*out = fuse.FileLock{Typ: syscall.F_UNLCK}
+		return 0
+	}
+	*out = fuse.FileLock{
+		Start: uint64(conflict.Start),
+		End:   uint64(conflict.End),
+		Typ:   syscall.F_RDLCK,
+		Pid:   0, // the owner may not be a local process
+	}
+	if conflict.Type == vfslock.Write {
+		out.Typ = syscall.F_WRLCK
+	}
+	return 0
+}
+
+var _ = (fusefs.NodeGetlker)((*Node)(nil))
+
+// Setlk obtains a lock on a file, or fail if the lock could not
+// obtained. See fcntl(2) for more information.
+func (n *Node) Setlk(ctx context.Context, f fusefs.FileHandle, owner uint64, lk *fuse.FileLock, flags uint32) (errno syscall.Errno) {
+	defer log.Trace(n, "owner=%x, lk=%v", owner, lk)("errno=%v", &errno)
+	return n.setlk(ctx, f, owner, lk, false)
+}
+
+var _ = (fusefs.NodeSetlker)((*Node)(nil))
+
+// Setlkw obtains a lock on a file, waiting if necessary. See fcntl(2)
+// for more information.
+func (n *Node) Setlkw(ctx context.Context, f fusefs.FileHandle, owner uint64, lk *fuse.FileLock, flags uint32) (errno syscall.Errno) {
+	defer log.Trace(n, "owner=%x, lk=%v", owner, lk)("errno=%v", &errno)
+	return n.setlk(ctx, f, owner, lk, true)
+}
+
+var _ = (fusefs.NodeSetlkwer)((*Node)(nil))
+
+// setlk takes or releases a lock, waiting for it if wait is set
+func (n *Node) setlk(ctx context.Context, f fusefs.FileHandle, owner uint64, lk *fuse.FileLock, wait bool) syscall.Errno {
+	locks := n.node.VFS().Locks()
+	vlk := n.vfsLock(owner, lk)
+	if lk.Typ == syscall.F_UNLCK {
+		return translateError(locks.Unlock(ctx, vlk.Path, vlk.Owner, vlk.Start, vlk.End))
+	}
+	// Remember the owner so the locks can be released when the
+	// file is closed
+	if fh, ok := f.(*FileHandle); ok {
+		fh.addLockOwner(owner)
+	}
+	if !wait {
+		return translateError(locks.Lock(ctx, vlk))
+	}
+	err := locks.LockWait(ctx, vlk)
+	if err == context.Canceled {
+		return syscall.EINTR
+	}
+	return translateError(err)
+}
+
+// copyXattr copies value into dest returning ERANGE and the size
+// needed if it doesn't fit.
+func copyXattr(dest, value []byte) (uint32, syscall.Errno) {
+	if len(value) > len(dest) {
+		return uint32(len(value)), syscall.ERANGE
+	}
+	return uint32(copy(dest, value)), 0
+}
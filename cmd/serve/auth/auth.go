@@ -0,0 +1,350 @@
+// Package auth implements authentication backends shared by the
+// rclone serve commands and the remote control server
+package auth
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	goauth "github.com/abbot/go-http-auth"
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/cmd/serve/httplib"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/fspath"
+	libcache "github.com/rclone/rclone/lib/cache"
+	"github.com/rclone/rclone/vfs"
+	"github.com/rclone/rclone/vfs/vfsflags"
+)
+
+// Help contains text describing the authentication backends
+var Help = strings.Replace(`
+### Authentication backends
+
+As well as the authentication described above, rclone can check
+users against these backends. Any number of them can be used at once
+and a user is let in if any of them accept them.
+
+- |--htpasswd /path/to/htpasswd| checks user names and passwords in an
+  apache style htpasswd file.
+- |--auth-tokens /path/to/tokens| checks static bearer tokens. The file
+  has one token per line followed by the user name it authenticates,
+  separated by white space. Lines starting with |#| are ignored.
+- |--auth-jwks /path/to/jwks.json| or |--auth-jwks https://idp/jwks|
+  checks OAuth2/OIDC JWTs signed with the keys in a JSON Web Key Set.
+  Use |--auth-jwt-issuer| and |--auth-jwt-audience| to check the |iss|
+  and |aud| claims, which you should do if the keys are used by anyone
+  else. The user name is taken from the claim given by
+  |--auth-user-claim| (default |sub|). RSA (RS*, PS*) and EC (ES*)
+  signatures are supported.
+
+The token and htpasswd files are re-read when they change and the
+JWKS is refetched when a token is signed with a key which isn't in it.
+
+HTTP based servers accept the tokens and JWTs in an
+|Authorization: Bearer| header. For all servers they can be used as
+the password with the user name they authenticate.
+`, "|", "`", -1)
+
+// RootHelp contains text describing --auth-root
+var RootHelp = strings.Replace(`
+Use |--auth-root| to give each user their own root. This is a remote
+path which may contain |{user}| for the user name and |{claim:NAME}|
+for the JWT claim |NAME|, e.g. |--auth-root "s3:bucket/home/{user}"|.
+If it doesn't name a remote it is relative to the remote being served.
+Values containing |/|, |\| or |:| aren't allowed so users can't
+escape their root.
+`, "|", "`", -1)
+
+// Options is options for the authentication backends
+type Options struct {
+	HtPasswd    string // htpasswd file
+	Tokens      string // file of static bearer tokens and their users
+	JWKS        string // file or URL of the JWKS to check JWTs with
+	JWTIssuer   string // required iss claim in JWTs if set
+	JWTAudience string // required aud claim in JWTs if set
+	UserClaim   string // JWT claim to use as the user name
+	Root        string // remote root for each user
+}
+
+// DefaultOpt is the default values used for Options
+var DefaultOpt = Options{
+	UserClaim: "sub",
+}
+
+// Enabled returns true if any authentication backends are configured
+func (opt *Options) Enabled() bool {
+	return opt.HtPasswd != "" || opt.Tokens != "" || opt.JWKS != ""
+}
+
+// HTTPEnabled returns true if an HTTP server needs to use Auth rather
+// than checking the --htpasswd file itself
+func (opt *Options) HTTPEnabled() bool {
+	return opt.Tokens != "" || opt.JWKS != "" || opt.Root != ""
+}
+
+// Auth checks credentials against the configured backends and finds
+// the VFS for each user
+type Auth struct {
+	ctx      context.Context
+	opt      Options
+	f        fs.Fs    // remote being served or nil
+	vfs      *vfs.VFS // VFS for f shared by all users if there is no root for each
+	htpasswd *goauth.BasicAuth
+	tokens   *tokenFile
+	jwt      *jwtVerifier
+	vfsCache *libcache.Cache
+}
+
+// New makes a new Auth serving f, which may be nil if no VFS is
+// needed, checking that the backends can be read.
+func New(ctx context.Context, f fs.Fs, opt *Options) (a *Auth, err error) {
+	a = &Auth{
+		ctx:      ctx,
+		opt:      *opt,
+		f:        f,
+		vfsCache: libcache.New(),
+	}
+	if a.opt.UserClaim == "" {
+		a.opt.UserClaim = DefaultOpt.UserClaim
+	}
+	if a.opt.Root != "" && !a.opt.Enabled() {
+		return nil, errors.New("auth: a root for each user needs an authentication backend")
+	}
+	if a.opt.Root == "" && f != nil {
+		a.vfs = vfs.New(f, &vfsflags.Opt)
+	}
+	if a.opt.HtPasswd != "" {
+		fs.Infof(nil, "Using %q as htpasswd storage", a.opt.HtPasswd)
+		a.htpasswd = goauth.NewBasicAuthenticator("rclone", goauth.HtpasswdFileProvider(a.opt.HtPasswd))
+	}
+	if a.opt.Tokens != "" {
+		a.tokens, err = newTokenFile(a.opt.Tokens)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if a.opt.JWKS != "" {
+		a.jwt, err = newJWTVerifier(ctx, a.opt.JWKS, a.opt.JWTIssuer, a.opt.JWTAudience)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// checkHtpasswd returns true if user and pass are in the htpasswd file
+func (a *Auth) checkHtpasswd(user, pass string) bool {
+	if a.htpasswd == nil {
+		return false
+	}
+	r := &http.Request{Header: make(http.Header)}
+	r.SetBasicAuth(user, pass)
+	return a.htpasswd.CheckAuth(r) == user
+}
+
+// checkToken checks the token is valid returning the user and any
+// claims from a JWT
+func (a *Auth) checkToken(token string) (user string, claims map[string]interface{}, err error) {
+	if a.tokens != nil {
+		if user = a.tokens.user(token); user != "" {
+			return user, nil, nil
+		}
+	}
+	if a.jwt != nil && isJWT(token) {
+		claims, err = a.jwt.verify(token)
+		if err != nil {
+			return "", nil, err
+		}
+		user, ok := claims[a.opt.UserClaim].(string)
+		if !ok || user == "" {
+			return "", nil, errors.Errorf("JWT has no %q claim", a.opt.UserClaim)
+		}
+		return user, claims, nil
+	}
+	return "", nil, errors.New("unknown token")
+}
+
+// rootPlaceholder matches the placeholders in --auth-root
+var rootPlaceholder = regexp.MustCompile(`\{(user|claim:[^{}]+)\}`)
+
+// expandRoot expands the placeholders in root
+func expandRoot(root, user string, claims map[string]interface{}) (string, error) {
+	var err error
+	expanded := rootPlaceholder.ReplaceAllStringFunc(root, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		var value string
+		if name == "user" {
+			value = user
+		} else {
+			name = strings.TrimPrefix(name, "claim:")
+			switch x := claims[name].(type) {
+			case string:
+				value = x
+			case float64:
+				value = strconv.FormatFloat(x, 'f', -1, 64)
+			case bool:
+				value = strconv.FormatBool(x)
+			}
+		}
+		if value == "" || value == "." || value == ".." || strings.ContainsAny(value, `/\:`) {
+			if err == nil {
+				err = errors.Errorf("auth: can't use %q for %s in root", value, placeholder)
+			}
+			return ""
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
+
+// getVFS returns the VFS for the user and the key it is cached under
+// or nil if there isn't a remote
+func (a *Auth) getVFS(user string, claims map[string]interface{}) (VFS *vfs.VFS, vfsKey string, err error) {
+	if a.opt.Root == "" {
+		if a.vfs == nil {
+			return nil, "", nil
+		}
+		return a.vfs, fs.ConfigString(a.f), nil
+	}
+	fsString, err := expandRoot(a.opt.Root, user, claims)
+	if err != nil {
+		return nil, "", err
+	}
+	configName, _, err := fspath.Parse(fsString)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "auth: bad root")
+	}
+	if configName == "" && a.f != nil {
+		fsString = fspath.JoinRootPath(fs.ConfigString(a.f), fsString)
+	}
+	value, err := a.vfsCache.Get(fsString, func(key string) (value interface{}, ok bool, err error) {
+		f, err := cache.Get(a.ctx, fsString)
+		if err == fs.ErrorIsFile {
+			return nil, false, errors.Errorf("auth: root %q is a file", fsString)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return vfs.New(f, &vfsflags.Opt), true, nil
+	})
+	if err != nil {
+		return nil, "", errors.Wrap(err, "auth: failed to create backend")
+	}
+	return value.(*vfs.VFS), fsString, nil
+}
+
+// Call checks the user and password returning the *vfs.VFS for the
+// user and the key it is cached under.
+//
+// The password can be a bearer token or JWT which authenticates the
+// user as well as a password from the htpasswd file.
+//
+// The VFS is nil if Auth was made without a remote and there is no
+// root for each user.
+func (a *Auth) Call(user, pass string) (VFS *vfs.VFS, vfsKey string, err error) {
+	var claims map[string]interface{}
+	if !a.checkHtpasswd(user, pass) {
+		var tokenUser string
+		tokenUser, claims, err = a.checkToken(pass)
+		if err != nil || tokenUser != user {
+			return nil, "", errors.New("auth: bad user name or password")
+		}
+	}
+	return a.getVFS(user, claims)
+}
+
+// CallBearer checks the bearer token returning the user it
+// authenticates, their *vfs.VFS and the key it is cached under.
+//
+// The VFS is nil if Auth was made without a remote and there is no
+// root for each user.
+func (a *Auth) CallBearer(token string) (user string, VFS *vfs.VFS, vfsKey string, err error) {
+	user, claims, err := a.checkToken(token)
+	if err != nil {
+		return "", nil, "", errors.Wrap(err, "auth: bad bearer token")
+	}
+	VFS, vfsKey, err = a.getVFS(user, claims)
+	if err != nil {
+		return "", nil, "", err
+	}
+	return user, VFS, vfsKey, nil
+}
+
+// Get VFS from the cache using key - returns nil if not found
+func (a *Auth) Get(key string) *vfs.VFS {
+	if a.vfs != nil {
+		if key == fs.ConfigString(a.f) {
+			return a.vfs
+		}
+		return nil
+	}
+	value, ok := a.vfsCache.GetMaybe(key)
+	if !ok {
+		return nil
+	}
+	return value.(*vfs.VFS)
+}
+
+// VFS returns the VFS shared by all the users or nil if each user
+// has their own root or Auth was made without a remote
+func (a *Auth) VFS() *vfs.VFS {
+	return a.vfs
+}
+
+// authValue returns the value to store in the context for VFS
+func authValue(VFS *vfs.VFS) interface{} {
+	if VFS == nil {
+		return nil
+	}
+	return VFS
+}
+
+// HTTPOptions returns a copy of opt which authenticates with a. The
+// *vfs.VFS for each user is stored under httplib.ContextAuthKey.
+func (a *Auth) HTTPOptions(opt *httplib.Options) *httplib.Options {
+	copyOpt := *opt
+	copyOpt.Auth = func(user, pass string) (value interface{}, err error) {
+		VFS, _, err := a.Call(user, pass)
+		if err != nil {
+			return nil, err
+		}
+		return authValue(VFS), nil
+	}
+	copyOpt.BearerAuth = func(token string) (user string, value interface{}, err error) {
+		user, VFS, _, err := a.CallBearer(token)
+		if err != nil {
+			return "", nil, err
+		}
+		return user, authValue(VFS), nil
+	}
+	return &copyOpt
+}
+
+// NewHTTPOptions returns opt set up to authenticate with the backends
+// in authOpt serving f, or opt unchanged if the HTTP server can do the
+// authentication itself. The HtPasswd in authOpt is ignored in favour
+// of the one in opt.
+//
+// The *vfs.VFS for each user is stored under httplib.ContextAuthKey.
+func NewHTTPOptions(ctx context.Context, f fs.Fs, authOpt *Options, opt *httplib.Options) (*httplib.Options, error) {
+	copyAuthOpt := *authOpt
+	copyAuthOpt.HtPasswd = opt.HtPasswd
+	if !copyAuthOpt.HTTPEnabled() {
+		return opt, nil
+	}
+	if opt.BasicUser != "" {
+		return nil, errors.New("can't use --user and --pass with the authentication backends - use --htpasswd instead")
+	}
+	a, err := New(ctx, f, &copyAuthOpt)
+	if err != nil {
+		return nil, err
+	}
+	return a.HTTPOptions(opt), nil
+}
@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/cmd/serve/httplib"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// htpasswdLine makes an htpasswd line for user with a SHA password
+func htpasswdLine(user, pass string) string {
+	sum := sha1.Sum([]byte(pass))
+	return user + ":{SHA}" + base64.StdEncoding.EncodeToString(sum[:]) + "\n"
+}
+
+func TestParseTokens(t *testing.T) {
+	users, err := parseTokens([]byte(`
+# comment
+token1 alice
+  token2   bob
+`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(users))
+
+	_, err = parseTokens([]byte("token1\n"))
+	assert.EqualError(t, err, `line 1: expecting "token user"`)
+	_, err = parseTokens([]byte("token1 alice bob\n"))
+	assert.Error(t, err)
+}
+
+func TestTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tokens")
+	require.NoError(t, ioutil.WriteFile(path, []byte("token1 alice\n"), 0600))
+
+	tokens, err := newTokenFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", tokens.user("token1"))
+	assert.Equal(t, "", tokens.user("token2"))
+
+	// changes to the file are picked up
+	require.NoError(t, ioutil.WriteFile(path, []byte("token2 bob\n"), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	assert.Equal(t, "", tokens.user("token1"))
+	assert.Equal(t, "bob", tokens.user("token2"))
+
+	// a bad file keeps the old tokens
+	require.NoError(t, ioutil.WriteFile(path, []byte("potato\n"), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)))
+	assert.Equal(t, "bob", tokens.user("token2"))
+
+	_, err = newTokenFile(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestExpandRoot(t *testing.T) {
+	claims := map[string]interface{}{
+		"tenant": "acme",
+		"id":     float64(42),
+		"admin":  true,
+		"bad":    "../etc",
+	}
+	for _, test := range []struct {
+		root    string
+		user    string
+		want    string
+		wantErr bool
+	}{
+		{"s3:bucket/{user}", "alice", "s3:bucket/alice", false},
+		{"home/{claim:tenant}/{user}", "alice", "home/acme/alice", false},
+		{"{claim:id}-{claim:admin}", "alice", "42-true", false},
+		{"plain", "alice", "plain", false},
+		{"{user}", "..", "", true},
+		{"{user}", "", "", true},
+		{"{user}", "a/b", "", true},
+		{"{user}", `a\b`, "", true},
+		{"{user}", "remote:", "", true},
+		{"{claim:bad}", "alice", "", true},
+		{"{claim:missing}", "alice", "", true},
+	} {
+		got, err := expandRoot(test.root, test.user, claims)
+		if test.wantErr {
+			assert.Error(t, err, test.root)
+		} else {
+			require.NoError(t, err, test.root)
+			assert.Equal(t, test.want, got, test.root)
+		}
+	}
+}
+
+// newTestAuth makes an Auth for testing with an htpasswd file, a
+// token file and a JWKS
+func newTestAuth(t *testing.T, f fs.Fs, root string) (a *Auth, key *testKey) {
+	dir := t.TempDir()
+	key = newTestRSAKey(t, "rsa")
+	opt := DefaultOpt
+	opt.HtPasswd = filepath.Join(dir, "htpasswd")
+	require.NoError(t, ioutil.WriteFile(opt.HtPasswd, []byte(htpasswdLine("alice", "secret")), 0600))
+	opt.Tokens = filepath.Join(dir, "tokens")
+	require.NoError(t, ioutil.WriteFile(opt.Tokens, []byte("token1 bob\n"), 0600))
+	opt.JWKS = filepath.Join(dir, "jwks.json")
+	require.NoError(t, ioutil.WriteFile(opt.JWKS, makeJWKS(t, key), 0600))
+	opt.Root = root
+	a, err := New(context.Background(), f, &opt)
+	require.NoError(t, err)
+	return a, key
+}
+
+func TestAuthCall(t *testing.T) {
+	ctx := context.Background()
+	f, err := fs.NewFs(ctx, t.TempDir())
+	require.NoError(t, err)
+	a, key := newTestAuth(t, f, "")
+	jwt := key.sign(t, validClaims("carol"))
+
+	for _, test := range []struct {
+		user string
+		pass string
+		ok   bool
+	}{
+		{"alice", "secret", true},
+		{"alice", "wrong", false},
+		{"bob", "token1", true},
+		{"alice", "token1", false},
+		{"carol", jwt, true},
+		{"alice", jwt, false},
+		{"nobody", "", false},
+	} {
+		VFS, vfsKey, err := a.Call(test.user, test.pass)
+		if test.ok {
+			require.NoError(t, err, test.user)
+			assert.True(t, VFS == a.VFS())
+			assert.True(t, a.Get(vfsKey) == VFS)
+		} else {
+			assert.Error(t, err, test.user)
+		}
+	}
+
+	user, VFS, _, err := a.CallBearer(jwt)
+	require.NoError(t, err)
+	assert.Equal(t, "carol", user)
+	assert.True(t, VFS == a.VFS())
+	_, _, _, err = a.CallBearer("wrong")
+	assert.Error(t, err)
+
+	// no remote means no VFS
+	a, _ = newTestAuth(t, nil, "")
+	VFS, _, err = a.Call("alice", "secret")
+	require.NoError(t, err)
+	assert.Nil(t, VFS)
+}
+
+func TestAuthRoot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "bob"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bob", "file.txt"), []byte("hello"), 0600))
+	f, err := fs.NewFs(ctx, dir)
+	require.NoError(t, err)
+	a, _ := newTestAuth(t, f, "{user}")
+	assert.Nil(t, a.VFS())
+
+	VFS, vfsKey, err := a.Call("bob", "token1")
+	require.NoError(t, err)
+	data, err := VFS.ReadFile("file.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+	assert.True(t, a.Get(vfsKey) == VFS)
+	assert.Nil(t, a.Get("potato"))
+
+	// the same VFS is returned each time
+	VFS2, _, err := a.Call("bob", "token1")
+	require.NoError(t, err)
+	assert.True(t, VFS == VFS2)
+
+	// a root without an authentication backend is an error
+	_, err = New(ctx, f, &Options{Root: "{user}"})
+	assert.Error(t, err)
+}
+
+func TestHTTPOptions(t *testing.T) {
+	ctx := context.Background()
+	f, err := fs.NewFs(ctx, t.TempDir())
+	require.NoError(t, err)
+	dir := t.TempDir()
+	key := newTestECKey(t, "ec")
+	authOpt := DefaultOpt
+	authOpt.JWKS = filepath.Join(dir, "jwks.json")
+	require.NoError(t, ioutil.WriteFile(authOpt.JWKS, makeJWKS(t, key), 0600))
+
+	// only --htpasswd is left to httplib
+	opt := httplib.DefaultOpt
+	opt.ListenAddr = "localhost:0"
+	got, err := NewHTTPOptions(ctx, f, &Options{HtPasswd: "htpasswd"}, &opt)
+	require.NoError(t, err)
+	assert.True(t, got == &opt)
+
+	// --user can't be used with the backends
+	opt.BasicUser = "user"
+	_, err = NewHTTPOptions(ctx, f, &authOpt, &opt)
+	assert.Error(t, err)
+	opt.BasicUser = ""
+
+	httpOpt, err := NewHTTPOptions(ctx, f, &authOpt, &opt)
+	require.NoError(t, err)
+	s := httplib.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ := r.Context().Value(httplib.ContextUserKey).(string)
+		_, isVFS := r.Context().Value(httplib.ContextAuthKey).(*vfs.VFS)
+		if !isVFS {
+			user = "no VFS"
+		}
+		_, _ = w.Write([]byte(user))
+	}), httpOpt)
+	require.NoError(t, s.Serve())
+	defer func() {
+		s.Close()
+		s.Wait()
+	}()
+
+	get := func(setAuth func(r *http.Request)) (int, string) {
+		req, err := http.NewRequest("GET", s.URL(), nil)
+		require.NoError(t, err)
+		setAuth(req)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp.StatusCode, string(body)
+	}
+	jwt := key.sign(t, validClaims("dave"))
+
+	status, body := get(func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+jwt) })
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "dave", body)
+
+	status, body = get(func(r *http.Request) { r.SetBasicAuth("dave", jwt) })
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "dave", body)
+
+	status, _ = get(func(r *http.Request) { r.Header.Set("Authorization", "Bearer potato") })
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = get(func(r *http.Request) {})
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
@@ -0,0 +1,48 @@
+// Package authflags implements command line flags to set up the
+// authentication backends
+package authflags
+
+import (
+	"context"
+
+	"github.com/rclone/rclone/cmd/serve/auth"
+	"github.com/rclone/rclone/cmd/serve/httplib"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/flags"
+	"github.com/spf13/pflag"
+)
+
+// Options set by command line flags
+var (
+	Opt = auth.DefaultOpt
+)
+
+// AddFlagsPrefix adds flags for the authentication backends except
+// --htpasswd, which the HTTP servers already have, and --auth-root for
+// servers which don't serve a VFS
+func AddFlagsPrefix(flagSet *pflag.FlagSet, prefix string, Opt *auth.Options) {
+	flags.StringVarP(flagSet, &Opt.Tokens, prefix+"auth-tokens", "", Opt.Tokens, "File of bearer tokens, one per line followed by the user name")
+	flags.StringVarP(flagSet, &Opt.JWKS, prefix+"auth-jwks", "", Opt.JWKS, "File or URL of the JWKS to check OAuth2/OIDC JWTs with")
+	flags.StringVarP(flagSet, &Opt.JWTIssuer, prefix+"auth-jwt-issuer", "", Opt.JWTIssuer, "Issuer (iss) JWTs must have")
+	flags.StringVarP(flagSet, &Opt.JWTAudience, prefix+"auth-jwt-audience", "", Opt.JWTAudience, "Audience (aud) JWTs must have")
+	flags.StringVarP(flagSet, &Opt.UserClaim, prefix+"auth-user-claim", "", Opt.UserClaim, "JWT claim to use as the user name")
+}
+
+// AddFlags adds flags for the authentication backends
+func AddFlags(flagSet *pflag.FlagSet) {
+	AddFlagsPrefix(flagSet, "", &Opt)
+	flags.StringVarP(flagSet, &Opt.Root, "auth-root", "", Opt.Root, "Root for each user, may contain {user} and {claim:NAME}")
+}
+
+// AddHtPasswdFlag adds the --htpasswd flag for servers which don't
+// get it from the HTTP flags
+func AddHtPasswdFlag(flagSet *pflag.FlagSet) {
+	flags.StringVarP(flagSet, &Opt.HtPasswd, "htpasswd", "", Opt.HtPasswd, "htpasswd file to check user names and passwords with")
+}
+
+// HTTPOptions returns opt set up to authenticate with the backends
+// from the command line flags serving f, or opt unchanged if the HTTP
+// server can do the authentication itself.
+func HTTPOptions(ctx context.Context, f fs.Fs, opt *httplib.Options) (*httplib.Options, error) {
+	return auth.NewHTTPOptions(ctx, f, &Opt, opt)
+}
@@ -0,0 +1,371 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+)
+
+// How often the JWKS may be refetched when a token uses an unknown
+// key and how long the keys are used for before refetching anyway
+var (
+	jwksMinRefresh = time.Minute
+	jwksMaxAge     = time.Hour
+)
+
+// jwtLeeway is the allowance for clock skew when checking the times
+// in a JWT
+const jwtLeeway = time.Minute
+
+// jwk is a JSON Web Key as found in a JWKS
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// decodeBigInt decodes a base64url encoded big endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// publicKey returns the public key in k
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, errors.Wrap(err, "bad RSA modulus")
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, errors.Wrap(err, "bad RSA exponent")
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, errors.Wrap(err, "bad EC x")
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, errors.Wrap(err, "bad EC y")
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, errors.Errorf("unsupported key type %q", k.Kty)
+}
+
+// parseJWKS parses a JSON Web Key Set returning the signing keys in it
+// by key ID. Keys which can't be used are skipped.
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	err := json.Unmarshal(data, &set)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse JWKS")
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for i := range set.Keys {
+		k := &set.Keys[i]
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			fs.Debugf(nil, "JWKS: skipping key %q: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no usable keys found in JWKS")
+	}
+	return keys, nil
+}
+
+// jwtVerifier checks the signature and claims of JWTs using the keys
+// in a JWKS read from a file or URL
+type jwtVerifier struct {
+	ctx      context.Context
+	source   string // file name or URL of the JWKS
+	issuer   string // required iss claim if set
+	audience string // required aud claim if set
+	mu       sync.Mutex
+	keys     map[string]crypto.PublicKey // by key ID
+	fetched  time.Time                   // when the keys were read
+}
+
+// newJWTVerifier makes a jwtVerifier and loads the keys
+func newJWTVerifier(ctx context.Context, source, issuer, audience string) (*jwtVerifier, error) {
+	v := &jwtVerifier{
+		ctx:      ctx,
+		source:   source,
+		issuer:   issuer,
+		audience: audience,
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	err := v._load()
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// read reads the JWKS from the file or URL
+func (v *jwtVerifier) read() (data []byte, err error) {
+	if !strings.HasPrefix(v.source, "http://") && !strings.HasPrefix(v.source, "https://") {
+		return ioutil.ReadFile(v.source)
+	}
+	req, err := http.NewRequest("GET", v.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := fshttp.NewClient(v.ctx).Do(req.WithContext(v.ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer fs.CheckClose(resp.Body, &err)
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("HTTP error %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// _load reads the keys - call with mu held
+func (v *jwtVerifier) _load() error {
+	v.fetched = time.Now()
+	data, err := v.read()
+	if err != nil {
+		return errors.Wrapf(err, "failed to read JWKS from %q", v.source)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return errors.Wrapf(err, "bad JWKS from %q", v.source)
+	}
+	v.keys = keys
+	return nil
+}
+
+// key finds the key with kid, refreshing the keys if it isn't found
+// or they are old
+func (v *jwtVerifier) key(kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	find := func() crypto.PublicKey {
+		if kid == "" && len(v.keys) == 1 {
+			for _, key := range v.keys {
+				return key
+			}
+		}
+		return v.keys[kid]
+	}
+	key := find()
+	age := time.Since(v.fetched)
+	if (key == nil && age >= jwksMinRefresh) || age >= jwksMaxAge {
+		err := v._load()
+		if err != nil {
+			fs.Errorf(nil, "Keeping old JWKS: %v", err)
+		}
+		key = find()
+	}
+	if key == nil {
+		return nil, errors.Errorf("no key %q in JWKS", kid)
+	}
+	return key, nil
+}
+
+// verifySignature checks sig is the signature of signed made with
+// alg by key
+func verifySignature(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return errors.Errorf("unsupported algorithm %q", alg)
+	}
+	h := hash.New()
+	_, _ = h.Write(signed)
+	digest := h.Sum(nil)
+	switch alg[:2] {
+	case "RS", "PS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.Errorf("key isn't RSA for %q", alg)
+		}
+		if alg[0] == 'P' {
+			return rsa.VerifyPSS(rsaKey, hash, digest, sig, nil)
+		}
+		return rsa.VerifyPKCS1v15(rsaKey, hash, digest, sig)
+	case "ES":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.Errorf("key isn't EC for %q", alg)
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errors.New("bad EC signature length")
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("bad EC signature")
+		}
+		return nil
+	}
+	return errors.Errorf("unsupported algorithm %q", alg)
+}
+
+// claimTime reads the numeric time claim name returning false if it
+// isn't present
+func claimTime(claims map[string]interface{}, name string) (t time.Time, ok bool, err error) {
+	value, ok := claims[name]
+	if !ok {
+		return t, false, nil
+	}
+	seconds, isNumber := value.(float64)
+	if !isNumber {
+		return t, false, errors.Errorf("claim %q isn't a number", name)
+	}
+	return time.Unix(int64(seconds), 0), true, nil
+}
+
+// hasAudience returns true if the aud claim, which may be a string or
+// a list of strings, contains audience
+func hasAudience(claims map[string]interface{}, audience string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, item := range aud {
+			if item == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isJWT returns true if token looks like a JWT
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// verify checks the signature and claims of token returning the
+// claims if it is OK
+func (v *jwtVerifier) verify(token string) (claims map[string]interface{}, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("JWT doesn't have 3 parts")
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "bad JWT header")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	err = json.Unmarshal(headerJSON, &header)
+	if err != nil {
+		return nil, errors.Wrap(err, "bad JWT header")
+	}
+	if len(header.Alg) != 5 {
+		// this rejects "none" - verifySignature rejects the
+		// HMAC algorithms as they can't be checked with a
+		// public key
+		return nil, errors.Errorf("unsupported JWT algorithm %q", header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrap(err, "bad JWT signature")
+	}
+	key, err := v.key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	err = verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig)
+	if err != nil {
+		return nil, errors.Wrap(err, "JWT signature check failed")
+	}
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.Wrap(err, "bad JWT claims")
+	}
+	err = json.Unmarshal(claimsJSON, &claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "bad JWT claims")
+	}
+	now := time.Now()
+	exp, ok, err := claimTime(claims, "exp")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("JWT has no expiry")
+	}
+	if now.After(exp.Add(jwtLeeway)) {
+		return nil, errors.New("JWT has expired")
+	}
+	nbf, ok, err := claimTime(claims, "nbf")
+	if err != nil {
+		return nil, err
+	}
+	if ok && now.Add(jwtLeeway).Before(nbf) {
+		return nil, errors.New("JWT isn't valid yet")
+	}
+	if v.issuer != "" && claims["iss"] != v.issuer {
+		return nil, errors.Errorf("JWT issuer %v isn't %q", claims["iss"], v.issuer)
+	}
+	if v.audience != "" && !hasAudience(claims, v.audience) {
+		return nil, errors.Errorf("JWT audience %v doesn't include %q", claims["aud"], v.audience)
+	}
+	return claims, nil
+}
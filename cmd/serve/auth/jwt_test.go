@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKey is a signing key for making JWTs
+type testKey struct {
+	kid     string
+	alg     string
+	private crypto.Signer
+}
+
+// newTestRSAKey makes an RSA key for RS256
+func newTestRSAKey(t *testing.T, kid string) *testKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return &testKey{kid: kid, alg: "RS256", private: key}
+}
+
+// newTestECKey makes an EC key for ES256
+func newTestECKey(t *testing.T, kid string) *testKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return &testKey{kid: kid, alg: "ES256", private: key}
+}
+
+// b64 encodes big endian bytes as base64url
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// jwk returns the public part of the key as a JWK
+func (k *testKey) jwk() map[string]string {
+	switch key := k.private.(type) {
+	case *rsa.PrivateKey:
+		return map[string]string{
+			"kty": "RSA",
+			"kid": k.kid,
+			"use": "sig",
+			"n":   b64(key.N.Bytes()),
+			"e":   b64(big.NewInt(int64(key.E)).Bytes()),
+		}
+	case *ecdsa.PrivateKey:
+		return map[string]string{
+			"kty": "EC",
+			"kid": k.kid,
+			"crv": "P-256",
+			"x":   b64(key.X.FillBytes(make([]byte, 32))),
+			"y":   b64(key.Y.FillBytes(make([]byte, 32))),
+		}
+	}
+	panic("unknown key type")
+}
+
+// makeJWKS makes a JWKS from the keys
+func makeJWKS(t *testing.T, keys ...*testKey) []byte {
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for _, k := range keys {
+		set.Keys = append(set.Keys, k.jwk())
+	}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+	return data
+}
+
+// sign makes a JWT with the claims signed by k
+func (k *testKey) sign(t *testing.T, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": k.alg, "kid": k.kid, "typ": "JWT"})
+	require.NoError(t, err)
+	body, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := b64(header) + "." + b64(body)
+	digest := crypto.SHA256.New()
+	_, _ = digest.Write([]byte(signed))
+	var sig []byte
+	switch key := k.private.(type) {
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest.Sum(nil))
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest.Sum(nil))
+		require.NoError(t, err)
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + b64(sig)
+}
+
+// validClaims returns claims for user which are valid for an hour
+func validClaims(user string) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"sub": user,
+		"iss": "https://idp.example.com",
+		"aud": []interface{}{"other", "rclone"},
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey := newTestRSAKey(t, "rsa")
+	ecKey := newTestECKey(t, "ec")
+	keys, err := parseJWKS(makeJWKS(t, rsaKey, ecKey))
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(keys))
+	assert.IsType(t, &rsa.PublicKey{}, keys["rsa"])
+	assert.IsType(t, &ecdsa.PublicKey{}, keys["ec"])
+
+	// encryption keys and unknown key types are skipped
+	keys, err = parseJWKS([]byte(`{"keys":[
+		{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"},
+		{"kty":"oct","kid":"hmac","k":"c2VjcmV0"},
+		{"kty":"EC","kid":"bad","crv":"P-256","x":"AQ","y":"AQ"}
+	]}`))
+	assert.Error(t, err)
+	assert.Nil(t, keys)
+
+	_, err = parseJWKS([]byte("potato"))
+	assert.Error(t, err)
+}
+
+func TestJWTVerify(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := newTestRSAKey(t, "rsa")
+	ecKey := newTestECKey(t, "ec")
+	otherKey := newTestRSAKey(t, "rsa")
+	jwksFile := filepath.Join(dir, "jwks.json")
+	require.NoError(t, ioutil.WriteFile(jwksFile, makeJWKS(t, rsaKey, ecKey), 0600))
+
+	v, err := newJWTVerifier(context.Background(), jwksFile, "https://idp.example.com", "rclone")
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		name   string
+		key    *testKey
+		modify func(claims map[string]interface{})
+		ok     bool
+	}{
+		{"RS256", rsaKey, nil, true},
+		{"ES256", ecKey, nil, true},
+		{"audience string", rsaKey, func(c map[string]interface{}) { c["aud"] = "rclone" }, true},
+		{"expired", rsaKey, func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, false},
+		{"no expiry", rsaKey, func(c map[string]interface{}) { delete(c, "exp") }, false},
+		{"not yet valid", rsaKey, func(c map[string]interface{}) { c["nbf"] = time.Now().Add(time.Hour).Unix() }, false},
+		{"wrong issuer", rsaKey, func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }, false},
+		{"wrong audience", rsaKey, func(c map[string]interface{}) { c["aud"] = "other" }, false},
+		{"wrong key", otherKey, nil, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			claims := validClaims("alice")
+			if test.modify != nil {
+				test.modify(claims)
+			}
+			got, err := v.verify(test.key.sign(t, claims))
+			if test.ok {
+				require.NoError(t, err)
+				assert.Equal(t, "alice", got["sub"])
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	// alg none is rejected
+	parts := strings.Split(rsaKey.sign(t, validClaims("alice")), ".")
+	_, err = v.verify(b64([]byte(`{"alg":"none"}`)) + "." + parts[1] + ".")
+	assert.Error(t, err)
+
+	// an unknown kid is looked for in the JWKS again
+	newKey := newTestECKey(t, "new")
+	require.NoError(t, ioutil.WriteFile(jwksFile, makeJWKS(t, rsaKey, newKey), 0600))
+	_, err = v.verify(newKey.sign(t, validClaims("alice")))
+	assert.Error(t, err, "refetched too soon")
+	v.fetched = time.Now().Add(-jwksMinRefresh)
+	_, err = v.verify(newKey.sign(t, validClaims("alice")))
+	assert.NoError(t, err)
+}
+
+func TestJWKSURL(t *testing.T) {
+	key := newTestRSAKey(t, "rsa")
+	jwks := makeJWKS(t, key)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(jwks)
+	}))
+	defer ts.Close()
+
+	v, err := newJWTVerifier(context.Background(), ts.URL, "", "")
+	require.NoError(t, err)
+	claims, err := v.verify(key.sign(t, validClaims("bob")))
+	require.NoError(t, err)
+	assert.Equal(t, "bob", claims["sub"])
+
+	_, err = newJWTVerifier(context.Background(), ts.URL+"/missing", "", "")
+	assert.Error(t, err)
+}
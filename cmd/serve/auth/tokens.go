@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/file"
+)
+
+// tokenFile is a file of static bearer tokens, one per line followed
+// by the user it authenticates, which is reloaded when it changes.
+//
+// Only the SHA-256 hashes of the tokens are kept in memory.
+type tokenFile struct {
+	file *file.Reloader
+}
+
+// newTokenFile reads the tokens from path
+func newTokenFile(path string) (*tokenFile, error) {
+	f, err := file.NewReloader(path, func(data []byte) (interface{}, error) {
+		users, err := parseTokens(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse bearer tokens in %q", path)
+		}
+		return users, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read bearer tokens")
+	}
+	return &tokenFile{file: f}, nil
+}
+
+// parseTokens parses the token file contents
+func parseTokens(data []byte) (map[[sha256.Size]byte]string, error) {
+	users := make(map[[sha256.Size]byte]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, errors.Errorf("line %d: expecting \"token user\"", lineNumber)
+		}
+		users[sha256.Sum256([]byte(fields[0]))] = fields[1]
+	}
+	return users, scanner.Err()
+}
+
+// user returns the user token authenticates or "" if it isn't valid
+func (t *tokenFile) user(token string) string {
+	users, err := t.file.Get()
+	if err != nil {
+		fs.Errorf(nil, "Keeping old bearer tokens: %v", err)
+	}
+	return users.(map[[sha256.Size]byte]string)[sha256.Sum256([]byte(token))]
+}
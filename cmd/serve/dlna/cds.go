@@ -13,6 +13,8 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/anacrolix/dms/dlna"
 	"github.com/anacrolix/dms/upnp"
@@ -59,6 +61,7 @@ func init() {
 type contentDirectoryService struct {
 	*server
 	upnp.Eventing
+	searchCache searchCache
 }
 
 func (cds *contentDirectoryService) updateIDString() string {
@@ -67,14 +70,28 @@ func (cds *contentDirectoryService) updateIDString() string {
 
 var mediaMimeTypeRegexp = regexp.MustCompile("^(video|audio|image)/")
 
+// resURL returns the URL the resource at remote is served on
+func resURL(host, remote string) string {
+	return (&url.URL{
+		Scheme: "http",
+		Host:   host,
+		Path:   path.Join(resPath, remote),
+	}).String()
+}
+
 // Turns the given entry and DMS host into a UPnP object. A nil object is
 // returned if the entry is not of interest.
-func (cds *contentDirectoryService) cdsObjectToUpnpavObject(cdsObject object, fileInfo vfs.Node, resources vfs.Nodes, host string) (ret interface{}, err error) {
+//
+// coverArt is the image to use as the album art or nil for none.
+func (cds *contentDirectoryService) cdsObjectToUpnpavObject(cdsObject object, fileInfo vfs.Node, resources vfs.Nodes, coverArt vfs.Node, host string) (ret interface{}, err error) {
 	obj := upnpav.Object{
 		ID:         cdsObject.ID(),
 		Restricted: 1,
 		ParentID:   cdsObject.ParentID(),
 	}
+	if coverArt != nil {
+		obj.AlbumArtURI = resURL(host, coverArt.Path())
+	}
 
 	if fileInfo.IsDir() {
 		defaultChildCount := 1
@@ -100,7 +117,7 @@ func (cds *contentDirectoryService) cdsObjectToUpnpavObject(cdsObject object, fi
 	}
 
 	mediaType := mediaMimeTypeRegexp.FindStringSubmatch(mimeType)
-	if mediaType == nil {
+	if mediaType == nil || !cds.mediaTypes[mediaType[1]] {
 		return
 	}
 
@@ -114,11 +131,7 @@ func (cds *contentDirectoryService) cdsObjectToUpnpavObject(cdsObject object, fi
 	}
 
 	item.Res = append(item.Res, upnpav.Resource{
-		URL: (&url.URL{
-			Scheme: "http",
-			Host:   host,
-			Path:   path.Join(resPath, cdsObject.Path),
-		}).String(),
+		URL: resURL(host, cdsObject.Path),
 		ProtocolInfo: fmt.Sprintf("http-get:*:%s:%s", mimeType, dlna.ContentFeatures{
 			SupportRange: true,
 		}.String()),
@@ -126,13 +139,8 @@ func (cds *contentDirectoryService) cdsObjectToUpnpavObject(cdsObject object, fi
 	})
 
 	for _, resource := range resources {
-		subtitleURL := (&url.URL{
-			Scheme: "http",
-			Host:   host,
-			Path:   path.Join(resPath, resource.Path()),
-		}).String()
 		item.Res = append(item.Res, upnpav.Resource{
-			URL:          subtitleURL,
+			URL:          resURL(host, resource.Path()),
 			ProtocolInfo: fmt.Sprintf("http-get:*:%s:*", "text/srt"),
 		})
 	}
@@ -160,12 +168,17 @@ func (cds *contentDirectoryService) readContainer(o object, host string) (ret []
 		return
 	}
 
+	coverArt := findCoverArt(dirEntries)
 	dirEntries, mediaResources := mediaWithResources(dirEntries)
 	for _, de := range dirEntries {
 		child := object{
 			path.Join(o.Path, de.Name()),
 		}
-		obj, err := cds.cdsObjectToUpnpavObject(child, de, mediaResources[de], host)
+		var childCoverArt vfs.Node
+		if !de.IsDir() && de != coverArt {
+			childCoverArt = coverArt
+		}
+		obj, err := cds.cdsObjectToUpnpavObject(child, de, mediaResources[de], childCoverArt, host)
 		if err != nil {
 			fs.Errorf(cds, "error with %s: %s", child.FilePath(), err)
 			continue
@@ -227,6 +240,215 @@ func mediaWithResources(nodes vfs.Nodes) (vfs.Nodes, map[vfs.Node]vfs.Nodes) {
 	return media, mediaResources
 }
 
+// coverArtExtensions are the extensions of the images which can be
+// used as cover art
+var coverArtExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+}
+
+// findCoverArt returns the image in nodes to use as the album art for
+// the media in their directory or nil if there isn't one.
+//
+// This looks for folder.jpg, then cover.jpg (or the .jpeg and .png
+// versions), ignoring case.
+func findCoverArt(nodes vfs.Nodes) (coverArt vfs.Node) {
+	bestRank := -1
+	for _, node := range nodes {
+		if node.IsDir() {
+			continue
+		}
+		baseName, ext := splitExt(strings.ToLower(node.Name()))
+		if !coverArtExtensions[ext] {
+			continue
+		}
+		rank := -1
+		switch baseName {
+		case "folder":
+			rank = 2
+		case "cover":
+			rank = 1
+		}
+		if rank > bestRank {
+			coverArt, bestRank = node, rank
+		}
+	}
+	if bestRank < 0 {
+		return nil
+	}
+	return coverArt
+}
+
+// coverArtFor returns the cover art for the media in the directory
+// dirPath or nil if there isn't any
+func (cds *contentDirectoryService) coverArtFor(dirPath string) vfs.Node {
+	node, err := cds.vfs.Stat(dirPath)
+	if err != nil || !node.IsDir() {
+		return nil
+	}
+	nodes, err := node.(*vfs.Dir).ReadDirAll()
+	if err != nil {
+		return nil
+	}
+	return findCoverArt(nodes)
+}
+
+// Limits on the work a single Search can make the server do
+var (
+	searchMaxDepth   = 32    // levels of containers searched below the one asked for
+	searchMaxResults = 10000 // results found before the search stops
+)
+
+// search returns the objects in the container o and the containers
+// below it which match criteria.
+//
+// The results are cached for a short while so that clients paging
+// through them don't cause the tree to be walked for each page.
+func (cds *contentDirectoryService) search(o object, criteria string, match matcher, host string) (objs []interface{}, err error) {
+	key := searchCacheKey{host: host, path: o.Path, criteria: criteria}
+	objs, found := cds.searchCache.get(key, time.Now())
+	if found {
+		return objs, nil
+	}
+	objs = []interface{}{}
+	err = cds.searchContainer(o, host, match, 0, &objs)
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) >= searchMaxResults {
+		fs.Logf(cds, "search: stopped after finding %d results in %s", len(objs), o.Path)
+	}
+	cds.searchCache.put(key, objs, time.Now())
+	return objs, nil
+}
+
+// searchContainer adds the objects in the container o and the
+// containers below it which match to results.
+//
+// It stops when searchMaxResults have been found and doesn't look in
+// containers more than searchMaxDepth below the first one.
+func (cds *contentDirectoryService) searchContainer(o object, host string, match matcher, depth int, results *[]interface{}) error {
+	objs, err := cds.readContainer(o, host)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		if len(*results) >= searchMaxResults {
+			return nil
+		}
+		var upnpObj *upnpav.Object
+		switch x := obj.(type) {
+		case upnpav.Container:
+			upnpObj = &x.Object
+		case upnpav.Item:
+			upnpObj = &x.Object
+		default:
+			continue
+		}
+		if match(upnpObj) {
+			*results = append(*results, obj)
+		}
+		if _, isContainer := obj.(upnpav.Container); isContainer {
+			child, err := cds.objectFromID(upnpObj.ID)
+			if err != nil {
+				return err
+			}
+			if depth >= searchMaxDepth {
+				fs.Debugf(cds, "search: not looking in %s as it is too deep", child.Path)
+				continue
+			}
+			err = cds.searchContainer(child, host, match, depth+1, results)
+			if err != nil {
+				fs.Errorf(cds, "search: failed to read %s: %v", child.Path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// searchCacheSize is the number of search results kept
+const searchCacheSize = 8
+
+// searchCacheExpiry is how long search results are kept for
+const searchCacheExpiry = time.Minute
+
+// searchCacheKey identifies a search - the host is included as it
+// is used in the URLs of the results
+type searchCacheKey struct {
+	host     string
+	path     string
+	criteria string
+}
+
+// searchCacheEntry is the results of a search
+type searchCacheEntry struct {
+	key  searchCacheKey
+	objs []interface{}
+	when time.Time
+}
+
+// searchCache holds the results of the most recent searches
+type searchCache struct {
+	mu      sync.Mutex
+	entries []searchCacheEntry // oldest first
+}
+
+// get returns the results for key if they are cached and not
+// expired at now
+func (c *searchCache) get(key searchCacheKey, now time.Time) (objs []interface{}, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, entry := range c.entries {
+		if entry.key == key && now.Sub(entry.when) < searchCacheExpiry {
+			return entry.objs, true
+		}
+	}
+	return nil, false
+}
+
+// put stores the results for key, removing any expired entries and
+// the oldest entry if the cache is full
+func (c *searchCache) put(key searchCacheKey, objs []interface{}, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := c.entries[:0]
+	for _, entry := range c.entries {
+		if entry.key != key && now.Sub(entry.when) < searchCacheExpiry {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) >= searchCacheSize {
+		entries = entries[len(entries)-searchCacheSize+1:]
+	}
+	c.entries = append(entries, searchCacheEntry{key: key, objs: objs, when: now})
+}
+
+// page returns the part of objs asked for by startingIndex and
+// requestedCount (0 for all)
+func page(objs []interface{}, startingIndex, requestedCount int) []interface{} {
+	if startingIndex > len(objs) {
+		startingIndex = len(objs)
+	}
+	if startingIndex < 0 {
+		startingIndex = 0
+	}
+	objs = objs[startingIndex:]
+	if requestedCount > 0 && requestedCount < len(objs) {
+		objs = objs[:requestedCount]
+	}
+	return objs
+}
+
+// searchArgs are the arguments of the Search action
+type searchArgs struct {
+	ContainerID    string
+	SearchCriteria string
+	Filter         string
+	StartingIndex  int
+	RequestedCount int
+}
+
 type browse struct {
 	ObjectID       string
 	BrowseFlag     string
@@ -280,16 +502,7 @@ func (cds *contentDirectoryService) Handle(action string, argsXML []byte, r *htt
 				return nil, upnp.Errorf(upnpav.NoSuchObjectErrorCode, err.Error())
 			}
 			totalMatches := len(objs)
-			objs = objs[func() (low int) {
-				low = browse.StartingIndex
-				if low > len(objs) {
-					low = len(objs)
-				}
-				return
-			}():]
-			if browse.RequestedCount != 0 && browse.RequestedCount < len(objs) {
-				objs = objs[:browse.RequestedCount]
-			}
+			objs = page(objs, browse.StartingIndex, browse.RequestedCount)
 			result, err := xml.Marshal(objs)
 			if err != nil {
 				return nil, err
@@ -306,7 +519,15 @@ func (cds *contentDirectoryService) Handle(action string, argsXML []byte, r *htt
 				return nil, err
 			}
 			// TODO: External subtitles won't appear in the metadata here, but probably should.
-			upnpObject, err := cds.cdsObjectToUpnpavObject(obj, node, vfs.Nodes{}, host)
+			coverArtDir := path.Dir(obj.Path)
+			if node.IsDir() {
+				coverArtDir = obj.Path
+			}
+			coverArt := cds.coverArtFor(coverArtDir)
+			if coverArt != nil && coverArt.Path() == node.Path() {
+				coverArt = nil
+			}
+			upnpObject, err := cds.cdsObjectToUpnpavObject(obj, node, vfs.Nodes{}, coverArt, host)
 			if err != nil {
 				return nil, err
 			}
@@ -322,7 +543,36 @@ func (cds *contentDirectoryService) Handle(action string, argsXML []byte, r *htt
 		}
 	case "GetSearchCapabilities":
 		return map[string]string{
-			"SearchCaps": "",
+			"SearchCaps": searchCaps,
+		}, nil
+	case "Search":
+		var search searchArgs
+		if err := xml.Unmarshal(argsXML, &search); err != nil {
+			return nil, err
+		}
+		obj, err := cds.objectFromID(search.ContainerID)
+		if err != nil {
+			return nil, upnp.Errorf(upnpav.NoSuchObjectErrorCode, err.Error())
+		}
+		match, err := parseSearchCriteria(search.SearchCriteria)
+		if err != nil {
+			return nil, upnp.Errorf(upnpav.InvalidSearchCriteriaErrorCode, err.Error())
+		}
+		objs, err := cds.search(obj, search.SearchCriteria, match, host)
+		if err != nil {
+			return nil, upnp.Errorf(upnpav.NoSuchContainerErrorCode, err.Error())
+		}
+		totalMatches := len(objs)
+		objs = page(objs, search.StartingIndex, search.RequestedCount)
+		result, err := xml.Marshal(objs)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{
+			"TotalMatches":   fmt.Sprint(totalMatches),
+			"NumberReturned": fmt.Sprint(len(objs)),
+			"Result":         didlLite(string(result)),
+			"UpdateID":       cds.updateIDString(),
 		}, nil
 	// Samsung Extensions
 	case "X_GetFeatureList":
@@ -86,8 +86,9 @@ type server struct {
 	// Time interval between SSPD announces
 	AnnounceInterval time.Duration
 
-	f   fs.Fs
-	vfs *vfs.VFS
+	f          fs.Fs
+	vfs        *vfs.VFS
+	mediaTypes map[string]bool // the media types to serve
 }
 
 func newServer(f fs.Fs, opt *dlnaflags.Options) *server {
@@ -104,8 +105,9 @@ func newServer(f fs.Fs, opt *dlnaflags.Options) *server {
 
 		httpListenAddr: opt.ListenAddr,
 
-		f:   f,
-		vfs: vfs.New(f, &vfsflags.Opt),
+		f:          f,
+		vfs:        vfs.New(f, &vfsflags.Opt),
+		mediaTypes: parseMediaTypes(opt.MediaTypes),
 	}
 
 	s.services = map[string]UPnPService{
@@ -139,6 +141,23 @@ func newServer(f fs.Fs, opt *dlnaflags.Options) *server {
 	return s
 }
 
+// parseMediaTypes parses the comma separated list of media types
+// ignoring any which aren't known
+func parseMediaTypes(mediaTypes string) map[string]bool {
+	out := make(map[string]bool)
+	for _, mediaType := range strings.Split(mediaTypes, ",") {
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		switch mediaType {
+		case "video", "audio", "image":
+			out[mediaType] = true
+		case "":
+		default:
+			fs.Errorf(nil, "Ignoring unknown media type %q", mediaType)
+		}
+	}
+	return out
+}
+
 // UPnPService is the interface for the SOAP service.
 type UPnPService interface {
 	Handle(action string, argsXML []byte, r *http.Request) (respArgs map[string]string, err error)
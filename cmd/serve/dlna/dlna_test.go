@@ -8,8 +8,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/anacrolix/dms/soap"
 
@@ -17,6 +19,7 @@ import (
 
 	_ "github.com/rclone/rclone/backend/local"
 	"github.com/rclone/rclone/cmd/serve/dlna/dlnaflags"
+	"github.com/rclone/rclone/cmd/serve/dlna/upnpav"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config"
 	"github.com/stretchr/testify/assert"
@@ -203,3 +206,272 @@ func TestContentDirectoryBrowseDirectChildren(t *testing.T) {
 	require.Contains(t, string(body), "/r/subdir/video.mp4")
 	require.Contains(t, string(body), "/r/subdir/video.srt")
 }
+
+// soapCall does the ContentDirectory action with the arguments in
+// argsXML returning the body of the response.
+func soapCall(t *testing.T, action, argsXML string) string {
+	req, err := http.NewRequest("POST", baseURL+serviceControlURL, strings.NewReader(`
+<?xml version="1.0" encoding="utf-8"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"
+            s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+    <s:Body>
+        <u:`+action+` xmlns:u="urn:schemas-upnp-org:service:ContentDirectory:1">`+argsXML+`</u:`+action+`>
+    </s:Body>
+</s:Envelope>`))
+	require.NoError(t, err)
+	req.Header.Set("SOAPACTION", `"urn:schemas-upnp-org:service:ContentDirectory:1#`+action+`"`)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	return html.UnescapeString(string(body))
+}
+
+// search does a ContentDirectory#Search returning the body of the
+// response
+func search(t *testing.T, containerID, criteria string) string {
+	return soapCall(t, "Search", `
+            <ContainerID>`+containerID+`</ContainerID>
+            <SearchCriteria>`+html.EscapeString(criteria)+`</SearchCriteria>
+            <Filter>*</Filter>
+            <StartingIndex>0</StartingIndex>
+            <RequestedCount>0</RequestedCount>
+            <SortCriteria></SortCriteria>`)
+}
+
+// Check that ContentDirectory#Search finds the expected items.
+func TestContentDirectorySearch(t *testing.T) {
+	body := soapCall(t, "GetSearchCapabilities", "")
+	assert.Contains(t, body, "upnp:class")
+	assert.Contains(t, body, "dc:title")
+
+	// all the videos in the library
+	body = search(t, "0", `upnp:class derivedfrom "object.item.videoItem"`)
+	assert.Contains(t, body, "<TotalMatches>2</TotalMatches>")
+	assert.Contains(t, body, "/r/video.mp4<")
+	assert.Contains(t, body, "/r/subdir/video.mp4<")
+	assert.NotContains(t, body, "small_jpeg.jpg")
+
+	// the images in a subdirectory
+	body = search(t, "%2Fsubdir", `upnp:class = "object.item.imageItem"`)
+	assert.Contains(t, body, "<TotalMatches>1</TotalMatches>")
+	assert.Contains(t, body, "/r/subdir/cover.jpg<")
+
+	// title search combined with class
+	body = search(t, "0", `(upnp:class derivedfrom "object.item" and dc:title contains "JPEG") or @id = "%2Fsubdir"`)
+	assert.Contains(t, body, "<TotalMatches>2</TotalMatches>")
+	assert.Contains(t, body, "/r/small_jpeg.jpg<")
+	assert.Contains(t, body, `<container id="%2Fsubdir"`)
+
+	// everything
+	body = search(t, "0", "*")
+	assert.Contains(t, body, "<TotalMatches>5</TotalMatches>")
+
+	// bad criteria
+	req, err := http.NewRequest("POST", baseURL+serviceControlURL, strings.NewReader(`
+<?xml version="1.0" encoding="utf-8"?>
+<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"
+            s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+    <s:Body>
+        <u:Search xmlns:u="urn:schemas-upnp-org:service:ContentDirectory:1">
+            <ContainerID>0</ContainerID>
+            <SearchCriteria>dc:title potato "x"</SearchCriteria>
+        </u:Search>
+    </s:Body>
+</s:Envelope>`))
+	require.NoError(t, err)
+	req.Header.Set("SOAPACTION", `"urn:schemas-upnp-org:service:ContentDirectory:1#Search"`)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+// Check that the media in a directory with a cover image get album art.
+func TestContentDirectoryAlbumArt(t *testing.T) {
+	browse := func(objectID, flag string) string {
+		return soapCall(t, "Browse", `
+            <ObjectID>`+objectID+`</ObjectID>
+            <BrowseFlag>`+flag+`</BrowseFlag>
+            <Filter>*</Filter>
+            <StartingIndex>0</StartingIndex>
+            <RequestedCount>0</RequestedCount>
+            <SortCriteria></SortCriteria>`)
+	}
+	body := browse("%2Fsubdir", "BrowseDirectChildren")
+	assert.Contains(t, body, "/r/subdir/cover.jpg</upnp:albumArtURI>")
+	assert.Equal(t, 1, strings.Count(body, "<upnp:albumArtURI>"), "only the video should have album art")
+
+	body = browse("%2Fsubdir%2Fvideo.mp4", "BrowseMetadata")
+	assert.Contains(t, body, "/r/subdir/cover.jpg</upnp:albumArtURI>")
+
+	body = browse("%2Fsubdir", "BrowseMetadata")
+	assert.Contains(t, body, "/r/subdir/cover.jpg</upnp:albumArtURI>")
+
+	// no cover in the root
+	body = browse("0", "BrowseDirectChildren")
+	assert.NotContains(t, body, "<upnp:albumArtURI>")
+}
+
+func TestFindCoverArt(t *testing.T) {
+	VFS := dlnaServer.vfs
+	node, err := VFS.Stat("/subdir")
+	require.NoError(t, err)
+	nodes, err := node.(*vfs.Dir).ReadDirAll()
+	require.NoError(t, err)
+	coverArt := findCoverArt(nodes)
+	require.NotNil(t, coverArt)
+	assert.Equal(t, "subdir/cover.jpg", coverArt.Path())
+
+	node, err = VFS.Stat("/")
+	require.NoError(t, err)
+	nodes, err = node.(*vfs.Dir).ReadDirAll()
+	require.NoError(t, err)
+	assert.Nil(t, findCoverArt(nodes), "small_jpeg.jpg isn't a cover")
+}
+
+func TestMediaTypes(t *testing.T) {
+	assert.Equal(t, map[string]bool{"audio": true, "video": true}, parseMediaTypes(" Audio,video,,potato"))
+
+	s := &server{
+		vfs:        dlnaServer.vfs,
+		mediaTypes: parseMediaTypes("image"),
+	}
+	cds := &contentDirectoryService{server: s}
+	objs, err := cds.readContainer(object{Path: "/"}, "localhost")
+	require.NoError(t, err)
+	var titles []string
+	for _, obj := range objs {
+		switch x := obj.(type) {
+		case upnpav.Item:
+			titles = append(titles, x.Title)
+		case upnpav.Container:
+			titles = append(titles, x.Title)
+		}
+	}
+	assert.Equal(t, []string{"small_jpeg.jpg", "subdir"}, titles)
+}
+
+func TestParseSearchCriteria(t *testing.T) {
+	video := &upnpav.Object{ID: "%2Fa.mp4", ParentID: "0", Class: "object.item.videoItem", Title: "Holiday Movie.mp4"}
+	folder := &upnpav.Object{ID: "%2Fdir", ParentID: "0", Class: "object.container.storageFolder", Title: "dir"}
+	for _, test := range []struct {
+		criteria string
+		video    bool
+		folder   bool
+	}{
+		{"*", true, true},
+		{"", true, true},
+		{`upnp:class derivedfrom "object.item"`, true, false},
+		{`upnp:class derivedfrom "object.item.video"`, false, false},
+		{`upnp:class = "object.container.storageFolder"`, false, true},
+		{`upnp:class != "object.container.storageFolder"`, true, false},
+		{`dc:title contains "movie"`, true, false},
+		{`dc:title doesNotContain "movie"`, false, true},
+		{`dc:title startsWith "hol"`, true, false},
+		{`dc:title < "e"`, false, true},
+		{`@refID exists false and @id exists true`, true, true},
+		{`upnp:artist exists true`, false, false},
+		{`dc:title = "dir" or (upnp:class derivedfrom "object.item" and dc:title contains "\"")`, false, true},
+		{`dc:title = "dir" or upnp:class derivedfrom "object.item" and dc:title contains "x"`, false, true},
+		{strings.Repeat("(", searchMaxNesting) + `@id exists true` + strings.Repeat(")", searchMaxNesting), true, true},
+	} {
+		match, err := parseSearchCriteria(test.criteria)
+		require.NoError(t, err, test.criteria)
+		assert.Equal(t, test.video, match(video), test.criteria)
+		assert.Equal(t, test.folder, match(folder), test.criteria)
+	}
+	for _, criteria := range []string{
+		`dc:title`,
+		`dc:title = "x`,
+		`(dc:title = "x"`,
+		`dc:title = "x")`,
+		`dc:title potato "x"`,
+		`@id exists maybe`,
+		`"x" = "x"`,
+		strings.Repeat("(", searchMaxNesting+1) + `@id exists true` + strings.Repeat(")", searchMaxNesting+1),
+	} {
+		_, err := parseSearchCriteria(criteria)
+		assert.Error(t, err, criteria)
+	}
+}
+
+func TestContentDirectorySearchLimits(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "a", "b", "c"), 0777))
+	for _, name := range []string{"a/one.mp4", "a/b/two.mp4", "a/b/c/three.mp4"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte("video"), 0666))
+	}
+	f, err := fs.NewFs(context.Background(), dir)
+	require.NoError(t, err)
+	opt := dlnaflags.DefaultOpt
+	s := newServer(f, &opt)
+	cds := s.services["ContentDirectory"].(*contentDirectoryService)
+	root, err := cds.objectFromID("0")
+	require.NoError(t, err)
+
+	search := func(criteria, host string) []interface{} {
+		match, err := parseSearchCriteria(criteria)
+		require.NoError(t, err)
+		objs, err := cds.search(root, criteria, match, host)
+		require.NoError(t, err)
+		return objs
+	}
+	const videos = `upnp:class derivedfrom "object.item.videoItem"`
+
+	// all of the tree is searched by default
+	assert.Equal(t, 3, len(search(videos, "host")))
+
+	// results are cached so adding a file isn't noticed by the
+	// same search but is by a different one
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "four.mp4"), []byte("video"), 0666))
+	s.vfs.FlushDirCache()
+	assert.Equal(t, 3, len(search(videos, "host")))
+	assert.Equal(t, 4, len(search(videos, "otherhost")))
+	assert.Equal(t, 4, len(search(videos+" or @id exists false", "host")))
+
+	// the depth searched is limited
+	oldMaxDepth, oldMaxResults := searchMaxDepth, searchMaxResults
+	defer func() {
+		searchMaxDepth, searchMaxResults = oldMaxDepth, oldMaxResults
+	}()
+	searchMaxDepth = 1
+	assert.Equal(t, 2, len(search(videos+" and @id exists true", "host")))
+	searchMaxDepth = oldMaxDepth
+
+	// as is the number of results
+	searchMaxResults = 2
+	assert.Equal(t, 2, len(search("*", "host")))
+}
+
+func TestSearchCache(t *testing.T) {
+	var c searchCache
+	now := time.Now()
+	key := func(i int) searchCacheKey {
+		return searchCacheKey{host: "host", path: "/", criteria: fmt.Sprint(i)}
+	}
+	for i := 0; i < searchCacheSize+1; i++ {
+		c.put(key(i), []interface{}{i}, now)
+	}
+	assert.Equal(t, searchCacheSize, len(c.entries))
+	_, found := c.get(key(0), now)
+	assert.False(t, found, "oldest entry removed")
+	objs, found := c.get(key(1), now)
+	assert.True(t, found)
+	assert.Equal(t, []interface{}{1}, objs)
+
+	// replacing an entry doesn't duplicate it
+	c.put(key(1), []interface{}{"new"}, now)
+	assert.Equal(t, searchCacheSize, len(c.entries))
+	objs, _ = c.get(key(1), now)
+	assert.Equal(t, []interface{}{"new"}, objs)
+
+	// entries expire
+	later := now.Add(searchCacheExpiry)
+	_, found = c.get(key(1), later)
+	assert.False(t, found)
+	c.put(key(100), nil, later)
+	assert.Equal(t, 1, len(c.entries))
+}
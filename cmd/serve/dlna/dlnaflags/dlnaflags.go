@@ -19,6 +19,20 @@ default "rclone (hostname)".
 
 Use ` + "`--log-trace` in conjunction with `-vv`" + ` to enable additional debug
 logging of all UPNP traffic.
+
+Use ` + "`--media-types`" + ` to choose which types of media are shown as a
+comma separated list of video, audio and image, e.g. ` + "`--media-types audio`" + `
+for a music server. The default is to show all of them.
+
+### Search and album art
+
+The server supports the ContentDirectory Search action so clients
+can search the whole library, for example for all the audio items or
+for titles containing some text.
+
+If a directory contains an image called folder.jpg or cover.jpg (or
+.jpeg or .png) it is used as the album art for the media in that
+directory, with folder.* preferred over cover.*.
 `
 
 // Options is the type for DLNA serving options.
@@ -26,6 +40,7 @@ type Options struct {
 	ListenAddr   string
 	FriendlyName string
 	LogTrace     bool
+	MediaTypes   string
 }
 
 // DefaultOpt contains the defaults options for DLNA serving.
@@ -33,6 +48,7 @@ var DefaultOpt = Options{
 	ListenAddr:   ":7879",
 	FriendlyName: "",
 	LogTrace:     false,
+	MediaTypes:   "video,audio,image",
 }
 
 // Opt contains the options for DLNA serving.
@@ -45,6 +61,7 @@ func addFlagsPrefix(flagSet *pflag.FlagSet, prefix string, Opt *Options) {
 	flags.StringVarP(flagSet, &Opt.ListenAddr, prefix+"addr", "", Opt.ListenAddr, "ip:port or :port to bind the DLNA http server to.")
 	flags.StringVarP(flagSet, &Opt.FriendlyName, prefix+"name", "", Opt.FriendlyName, "name of DLNA server")
 	flags.BoolVarP(flagSet, &Opt.LogTrace, prefix+"log-trace", "", Opt.LogTrace, "enable trace logging of SOAP traffic")
+	flags.StringVarP(flagSet, &Opt.MediaTypes, prefix+"media-types", "", Opt.MediaTypes, "comma separated list of the media types to serve: video, audio, image")
 }
 
 // AddFlags add the command line flags for DLNA serving.
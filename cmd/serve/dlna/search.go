@@ -0,0 +1,272 @@
+package dlna
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/rclone/rclone/cmd/serve/dlna/upnpav"
+)
+
+// searchCaps are the properties which can be used in search criteria
+const searchCaps = "@id,@parentID,@refID,upnp:class,dc:title,dc:date"
+
+// searchMaxNesting is the deepest the brackets in search criteria
+// can be nested
+const searchMaxNesting = 32
+
+// matcher returns true if the object matches the search criteria
+type matcher func(obj *upnpav.Object) bool
+
+// parseSearchCriteria parses a UPnP ContentDirectory search criteria
+// string into a matcher.
+//
+// This supports the grammar from the ContentDirectory spec - "*",
+// relational expressions joined with "and" and "or" and grouped with
+// brackets - with the operators =, !=, <, <=, >, >=, contains,
+// doesNotContain, startsWith, derivedfrom and exists. Comparisons are
+// case insensitive.
+func parseSearchCriteria(criteria string) (matcher, error) {
+	criteria = strings.TrimSpace(criteria)
+	if criteria == "*" || criteria == "" {
+		return func(*upnpav.Object) bool { return true }, nil
+	}
+	tokens, err := tokenizeSearchCriteria(criteria)
+	if err != nil {
+		return nil, err
+	}
+	p := &searchParser{tokens: tokens}
+	m, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in search criteria", p.tokens[p.pos].text)
+	}
+	return m, nil
+}
+
+// searchToken is a token in the search criteria
+type searchToken struct {
+	text   string
+	quoted bool // set if this was a quoted string
+}
+
+// tokenizeSearchCriteria splits the criteria into tokens
+func tokenizeSearchCriteria(criteria string) (tokens []searchToken, err error) {
+	rs := []rune(criteria)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, searchToken{text: string(r)})
+			i++
+		case r == '"':
+			var value strings.Builder
+			i++
+			for ; i < len(rs) && rs[i] != '"'; i++ {
+				if rs[i] == '\\' && i+1 < len(rs) {
+					i++
+				}
+				value.WriteRune(rs[i])
+			}
+			if i >= len(rs) {
+				return nil, fmt.Errorf("unterminated string in search criteria")
+			}
+			i++
+			tokens = append(tokens, searchToken{text: value.String(), quoted: true})
+		case r == '!' || r == '<' || r == '>' || r == '=':
+			op := string(r)
+			i++
+			if i < len(rs) && rs[i] == '=' {
+				op += "="
+				i++
+			}
+			tokens = append(tokens, searchToken{text: op})
+		default:
+			start := i
+			for i < len(rs) && !unicode.IsSpace(rs[i]) && !strings.ContainsRune(`()"!<>=`, rs[i]) {
+				i++
+			}
+			tokens = append(tokens, searchToken{text: string(rs[start:i])})
+		}
+	}
+	return tokens, nil
+}
+
+// searchParser is a recursive descent parser for search criteria
+type searchParser struct {
+	tokens  []searchToken
+	pos     int
+	nesting int // depth of brackets being parsed
+}
+
+// next returns the next token or nil if there aren't any
+func (p *searchParser) next() *searchToken {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	t := &p.tokens[p.pos]
+	p.pos++
+	return t
+}
+
+// peekWord returns true if the next token is the unquoted word
+func (p *searchParser) peekWord(word string) bool {
+	if p.pos >= len(p.tokens) {
+		return false
+	}
+	t := p.tokens[p.pos]
+	return !t.quoted && strings.EqualFold(t.text, word)
+}
+
+// parseOr parses searchExp: relExp or logExp
+func (p *searchParser) parseOr() (matcher, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekWord("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(obj *upnpav.Object) bool { return l(obj) || right(obj) }
+	}
+	return left, nil
+}
+
+// parseAnd parses expressions joined by "and" which binds tighter
+// than "or"
+func (p *searchParser) parseAnd() (matcher, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekWord("and") {
+		p.pos++
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(obj *upnpav.Object) bool { return l(obj) && right(obj) }
+	}
+	return left, nil
+}
+
+// parsePrimary parses a bracketed expression or a relExp
+func (p *searchParser) parsePrimary() (matcher, error) {
+	t := p.next()
+	if t == nil {
+		return nil, fmt.Errorf("search criteria ended unexpectedly")
+	}
+	if !t.quoted && t.text == "(" {
+		if p.nesting >= searchMaxNesting {
+			return nil, fmt.Errorf("brackets nested too deeply in search criteria")
+		}
+		p.nesting++
+		m, err := p.parseOr()
+		p.nesting--
+		if err != nil {
+			return nil, err
+		}
+		closing := p.next()
+		if closing == nil || closing.quoted || closing.text != ")" {
+			return nil, fmt.Errorf("missing ) in search criteria")
+		}
+		return m, nil
+	}
+	if t.quoted {
+		return nil, fmt.Errorf("expecting property but got %q in search criteria", t.text)
+	}
+	property := t.text
+	opToken := p.next()
+	valueToken := p.next()
+	if opToken == nil || valueToken == nil || opToken.quoted {
+		return nil, fmt.Errorf("incomplete expression for %q in search criteria", property)
+	}
+	op := strings.ToLower(opToken.text)
+	value := strings.ToLower(valueToken.text)
+	get := func(obj *upnpav.Object) (string, bool) {
+		return searchProperty(obj, property)
+	}
+	switch op {
+	case "exists":
+		want := value == "true"
+		if value != "true" && value != "false" {
+			return nil, fmt.Errorf("exists needs true or false not %q in search criteria", valueToken.text)
+		}
+		return func(obj *upnpav.Object) bool {
+			_, found := get(obj)
+			return found == want
+		}, nil
+	case "derivedfrom":
+		return func(obj *upnpav.Object) bool {
+			v, found := get(obj)
+			v = strings.ToLower(v)
+			return found && (v == value || strings.HasPrefix(v, value+"."))
+		}, nil
+	}
+	var compare func(v string) bool
+	switch op {
+	case "=":
+		compare = func(v string) bool { return v == value }
+	case "!=":
+		compare = func(v string) bool { return v != value }
+	case "<":
+		compare = func(v string) bool { return v < value }
+	case "<=":
+		compare = func(v string) bool { return v <= value }
+	case ">":
+		compare = func(v string) bool { return v > value }
+	case ">=":
+		compare = func(v string) bool { return v >= value }
+	case "contains":
+		compare = func(v string) bool { return strings.Contains(v, value) }
+	case "doesnotcontain":
+		compare = func(v string) bool { return !strings.Contains(v, value) }
+	case "startswith":
+		compare = func(v string) bool { return strings.HasPrefix(v, value) }
+	default:
+		return nil, fmt.Errorf("unknown operator %q in search criteria", opToken.text)
+	}
+	return func(obj *upnpav.Object) bool {
+		v, found := get(obj)
+		return found && compare(strings.ToLower(v))
+	}, nil
+}
+
+// searchProperty returns the value of property for obj and whether
+// it has that property
+func searchProperty(obj *upnpav.Object, property string) (string, bool) {
+	var value string
+	switch property {
+	case "@id":
+		value = obj.ID
+	case "@parentID":
+		value = obj.ParentID
+	case "upnp:class":
+		value = obj.Class
+	case "dc:title":
+		value = obj.Title
+	case "dc:date":
+		if obj.Date.IsZero() {
+			return "", false
+		}
+		value = obj.Date.Format("2006-01-02")
+	case "upnp:artist":
+		value = obj.Artist
+	case "upnp:album":
+		value = obj.Album
+	case "upnp:genre":
+		value = obj.Genre
+	case "upnp:albumArtURI":
+		value = obj.AlbumArtURI
+	}
+	return value, value != ""
+}
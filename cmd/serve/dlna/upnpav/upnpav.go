@@ -8,6 +8,11 @@ import (
 const (
 	// NoSuchObjectErrorCode : The specified ObjectID is invalid.
 	NoSuchObjectErrorCode = 701
+	// InvalidSearchCriteriaErrorCode : The search criteria specified is
+	// not supported or is invalid.
+	InvalidSearchCriteriaErrorCode = 708
+	// NoSuchContainerErrorCode : The specified ContainerID is invalid.
+	NoSuchContainerErrorCode = 710
 )
 
 // Resource description
@@ -16,6 +16,9 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/cmd/serve/auth"
+	"github.com/rclone/rclone/cmd/serve/auth/authflags"
+	"github.com/rclone/rclone/cmd/serve/httplib"
 	"github.com/rclone/rclone/cmd/serve/proxy"
 	"github.com/rclone/rclone/cmd/serve/proxy/proxyflags"
 	"github.com/rclone/rclone/fs"
@@ -33,7 +36,7 @@ import (
 // Options contains options for the http Server
 type Options struct {
 	//TODO add more options
-	ListenAddr   string // Port to listen on
+	ListenAddr   string // Port to listen on - may be a comma separated list
 	PublicIP     string // Passive ports range
 	PassivePorts string // Passive ports range
 	BasicUser    string // single username for basic auth if not using Htpasswd
@@ -57,7 +60,7 @@ var Opt = DefaultOpt
 // AddFlags adds flags for ftp
 func AddFlags(flagSet *pflag.FlagSet) {
 	rc.AddOption("ftp", &Opt)
-	flags.StringVarP(flagSet, &Opt.ListenAddr, "addr", "", Opt.ListenAddr, "IPaddress:Port or :Port to bind server to.")
+	flags.StringVarP(flagSet, &Opt.ListenAddr, "addr", "", Opt.ListenAddr, "IPaddress:Port or :Port to bind server to, or a comma separated list of them.")
 	flags.StringVarP(flagSet, &Opt.PublicIP, "public-ip", "", Opt.PublicIP, "Public IP address to advertise for passive connections.")
 	flags.StringVarP(flagSet, &Opt.PassivePorts, "passive-port", "", Opt.PassivePorts, "Passive port range to use.")
 	flags.StringVarP(flagSet, &Opt.BasicUser, "user", "", Opt.BasicUser, "User name for authentication.")
@@ -69,6 +72,8 @@ func AddFlags(flagSet *pflag.FlagSet) {
 func init() {
 	vfsflags.AddFlags(Command.Flags())
 	proxyflags.AddFlags(Command.Flags())
+	authflags.AddFlags(Command.Flags())
+	authflags.AddHtPasswdFlag(Command.Flags())
 	AddFlags(Command.Flags())
 }
 
@@ -88,6 +93,13 @@ listen on, e.g. --addr 1.2.3.4:8000 or --addr :8080 to listen to all
 IPs.  By default it only listens on localhost.  You can use port
 :0 to let the OS choose an available port.
 
+--addr may be a comma separated list of addresses to listen on more
+than one, e.g. --addr 192.168.1.2:2121,[::1]:2121
+
+If --cert and --key are set the server uses TLS. Unlike the HTTP
+based servers the certificate is only read when the server starts, so
+it must be restarted to use a new one.
+
 If you set --addr to listen on a public or LAN accessible IP address
 then using Authentication is advised - see the next section for info.
 
@@ -96,7 +108,13 @@ then using Authentication is advised - see the next section for info.
 By default this will serve files without needing a login.
 
 You can set a single username and password with the --user and --pass flags.
-` + vfs.Help + proxy.Help,
+
+If any of the authentication backends described below are used then
+anonymous logins are no longer allowed and users must log in with
+them, or with --user and --pass if --pass is set. The bearer tokens
+and JWTs are used as the password with the user name they
+authenticate.
+` + auth.Help + auth.RootHelp + vfs.Help + proxy.Help,
 	Run: func(command *cobra.Command, args []string) {
 		var f fs.Fs
 		if proxyflags.Opt.AuthProxy == "" {
@@ -118,66 +136,100 @@ You can set a single username and password with the --user and --pass flags.
 // server contains everything to run the server
 type server struct {
 	f      fs.Fs
-	srv    *ftp.Server
+	srvs   []*ftp.Server   // one for each address in --addr
 	ctx    context.Context // for global config
 	opt    Options
 	vfs    *vfs.VFS
 	proxy  *proxy.Proxy
+	auth   *auth.Auth // authentication backends if in use
 	useTLS bool
 }
 
 // Make a new FTP to serve the remote
 func newServer(ctx context.Context, f fs.Fs, opt *Options) (*server, error) {
-	host, port, err := net.SplitHostPort(opt.ListenAddr)
-	if err != nil {
-		return nil, errors.New("Failed to parse host:port")
-	}
-	portNum, err := strconv.Atoi(port)
-	if err != nil {
-		return nil, errors.New("Failed to parse host:port")
-	}
-
 	s := &server{
 		f:   f,
 		ctx: ctx,
 		opt: *opt,
 	}
+	var err error
 	if proxyflags.Opt.AuthProxy != "" {
+		if authflags.Opt.Enabled() {
+			return nil, errors.New("can't use --auth-proxy with the authentication backends")
+		}
 		s.proxy = proxy.New(ctx, &proxyflags.Opt)
 	} else {
-		s.vfs = vfs.New(f, &vfsflags.Opt)
+		if authflags.Opt.Enabled() {
+			s.auth, err = auth.New(ctx, f, &authflags.Opt)
+			if err != nil {
+				return nil, err
+			}
+			// share the VFS with the authentication backends if possible
+			s.vfs = s.auth.VFS()
+		}
+		if s.vfs == nil {
+			s.vfs = vfs.New(f, &vfsflags.Opt)
+		}
 	}
 	s.useTLS = s.opt.TLSKey != ""
 
-	ftpopt := &ftp.ServerOpts{
-		Name:           "Rclone FTP Server",
-		WelcomeMessage: "Welcome to Rclone " + fs.Version + " FTP Server",
-		Factory:        s, // implemented by NewDriver method
-		Hostname:       host,
-		Port:           portNum,
-		PublicIP:       opt.PublicIP,
-		PassivePorts:   opt.PassivePorts,
-		Auth:           s, // implemented by CheckPasswd method
-		Logger:         &Logger{},
-		TLS:            s.useTLS,
-		CertFile:       s.opt.TLSCert,
-		KeyFile:        s.opt.TLSKey,
-		//TODO implement a maximum of https://godoc.org/goftp.io/server#ServerOpts
-	}
-	s.srv = ftp.NewServer(ftpopt)
+	for _, addr := range httplib.SplitListenAddrs(opt.ListenAddr) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, errors.Errorf("Failed to parse host:port %q", addr)
+		}
+		portNum, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, errors.Errorf("Failed to parse host:port %q", addr)
+		}
+		ftpopt := &ftp.ServerOpts{
+			Name:           "Rclone FTP Server",
+			WelcomeMessage: "Welcome to Rclone " + fs.Version + " FTP Server",
+			Factory:        s, // implemented by NewDriver method
+			Hostname:       host,
+			Port:           portNum,
+			PublicIP:       opt.PublicIP,
+			PassivePorts:   opt.PassivePorts,
+			Auth:           s, // implemented by CheckPasswd method
+			Logger:         &Logger{},
+			TLS:            s.useTLS,
+			CertFile:       s.opt.TLSCert,
+			KeyFile:        s.opt.TLSKey,
+			//TODO implement a maximum of https://godoc.org/goftp.io/server#ServerOpts
+		}
+		s.srvs = append(s.srvs, ftp.NewServer(ftpopt))
+	}
 	return s, nil
 }
 
-// serve runs the ftp server
+// serve runs the ftp servers until they are closed, returning
+// ftp.ErrServerClosed, or one of them fails
 func (s *server) serve() error {
-	fs.Logf(s.f, "Serving FTP on %s", s.srv.Hostname+":"+strconv.Itoa(s.srv.Port))
-	return s.srv.ListenAndServe()
+	errs := make(chan error, len(s.srvs))
+	for _, srv := range s.srvs {
+		fs.Logf(s.f, "Serving FTP on %s", srv.Hostname+":"+strconv.Itoa(srv.Port))
+		go func(srv *ftp.Server) {
+			errs <- srv.ListenAndServe()
+		}(srv)
+	}
+	for range s.srvs {
+		err := <-errs
+		if err != ftp.ErrServerClosed {
+			return err
+		}
+	}
+	return ftp.ErrServerClosed
 }
 
-// serve runs the ftp server
-func (s *server) close() error {
-	fs.Logf(s.f, "Stopping FTP on %s", s.srv.Hostname+":"+strconv.Itoa(s.srv.Port))
-	return s.srv.Shutdown()
+// close stops the ftp servers
+func (s *server) close() (err error) {
+	for _, srv := range s.srvs {
+		fs.Logf(s.f, "Stopping FTP on %s", srv.Hostname+":"+strconv.Itoa(srv.Port))
+		if srvErr := srv.Shutdown(); srvErr != nil && err == nil {
+			err = srvErr
+		}
+	}
+	return err
 }
 
 //Logger ftp logger output formatted message
@@ -228,9 +280,10 @@ func (s *server) NewDriver() (ftp.Driver, error) {
 
 //Driver implementation of ftp server
 type Driver struct {
-	s    *server
-	vfs  *vfs.VFS
-	lock sync.Mutex
+	s      *server
+	vfs    *vfs.VFS
+	limits *proxy.Limits // per-user limits from the proxy or nil
+	lock   sync.Mutex
 }
 
 // CheckPasswd handle auth based on configuration
@@ -238,12 +291,25 @@ func (d *Driver) CheckPasswd(user, pass string) (ok bool, err error) {
 	s := d.s
 	if s.proxy != nil {
 		var VFS *vfs.VFS
-		VFS, _, err = s.proxy.Call(user, pass, false)
+		var vfsKey string
+		VFS, vfsKey, err = s.proxy.Call(user, pass, false)
 		if err != nil {
 			fs.Infof(nil, "proxy login failed: %v", err)
 			return false, nil
 		}
 		d.vfs = VFS
+		d.limits = s.proxy.Limits(vfsKey)
+	} else if s.auth != nil {
+		VFS, _, err := s.auth.Call(user, pass)
+		if err == nil {
+			d.vfs = VFS
+			return true, nil
+		}
+		// --user and --pass can still be used if --pass is set
+		if s.opt.BasicPass == "" || s.opt.BasicUser != user || s.opt.BasicPass != pass {
+			fs.Infof(nil, "login failed: %v", err)
+			return false, nil
+		}
 	} else {
 		ok = s.opt.BasicUser == user && (s.opt.BasicPass == "" || s.opt.BasicPass == pass)
 		if !ok {
@@ -346,10 +412,12 @@ func (d *Driver) DeleteFile(path string) (err error) {
 	if !node.IsFile() {
 		return errors.New("Not a file")
 	}
+	freed := d.limits.Size(d.vfs, path)
 	err = node.Remove()
 	if err != nil {
 		return err
 	}
+	d.limits.Freed(freed)
 	return nil
 }
 
@@ -358,7 +426,16 @@ func (d *Driver) Rename(oldName, newName string) (err error) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 	defer log.Trace(oldName, "newName=%q", newName)("err = %v", &err)
-	return d.vfs.Rename(oldName, newName)
+	var freed int64
+	if oldName != newName {
+		freed = d.limits.Size(d.vfs, newName)
+	}
+	err = d.vfs.Rename(oldName, newName)
+	if err != nil {
+		return err
+	}
+	d.limits.Freed(freed)
+	return nil
 }
 
 //MakeDir create a folder
@@ -432,18 +509,29 @@ func (d *Driver) PutFile(path string, data io.Reader, appendData bool) (n int64,
 
 	if !appendData {
 		if isExist {
+			freed := d.limits.Size(d.vfs, path)
 			err = node.Remove()
 			if err != nil {
 				return 0, err
 			}
+			d.limits.Freed(freed)
 		}
 		f, err := d.vfs.OpenFile(path, os.O_RDWR|os.O_CREATE, 0660)
 		if err != nil {
 			return 0, err
 		}
-		defer closeIO(path, f)
-		bytes, err := io.Copy(f, data)
+		upload := d.limits.NewUpload(path, 0)
+		bytes, err := io.Copy(f, upload.Reader(data))
+		closeIO(path, f)
 		if err != nil {
+			if err == proxy.ErrUploadTooLarge || err == proxy.ErrQuotaExceeded {
+				// don't leave a partial file using up the quota
+				if removeErr := d.vfs.Remove(path); removeErr != nil {
+					fs.Errorf(path, "Failed to remove partial upload: %v", removeErr)
+				} else {
+					upload.Removed()
+				}
+			}
 			return 0, err
 		}
 		return bytes, nil
@@ -455,12 +543,12 @@ func (d *Driver) PutFile(path string, data io.Reader, appendData bool) (n int64,
 	}
 	defer closeIO(path, of)
 
-	_, err = of.Seek(0, os.SEEK_END)
+	size, err := of.Seek(0, os.SEEK_END)
 	if err != nil {
 		return 0, err
 	}
 
-	bytes, err := io.Copy(of, data)
+	bytes, err := io.Copy(of, d.limits.NewUpload(path, size).Reader(data))
 	if err != nil {
 		return 0, err
 	}
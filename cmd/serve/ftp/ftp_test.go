@@ -8,8 +8,14 @@
 package ftp
 
 import (
+	"bufio"
 	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/rclone/rclone/backend/local"
 	"github.com/rclone/rclone/cmd/serve/servetest"
@@ -17,6 +23,7 @@ import (
 	"github.com/rclone/rclone/fs/config/configmap"
 	"github.com/rclone/rclone/fs/config/obscure"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	ftp "goftp.io/server/core"
 )
 
@@ -69,3 +76,50 @@ func TestFTP(t *testing.T) {
 
 	servetest.Run(t, "ftp", start)
 }
+
+// TestFTPListenAddrs checks the server listens on all the addresses
+func TestFTPListenAddrs(t *testing.T) {
+	addrs := []string{testHOST + ":51781", testHOST + ":51782"}
+	opt := DefaultOpt
+	opt.ListenAddr = strings.Join(addrs, ",")
+	opt.PassivePorts = testPASSIVEPORTRANGE
+	dir, err := ioutil.TempDir("", "rclone-ftp-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	f, err := fs.NewFs(context.Background(), dir)
+	require.NoError(t, err)
+	w, err := newServer(context.Background(), f, &opt)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(w.srvs))
+
+	quit := make(chan error, 1)
+	go func() {
+		quit <- w.serve()
+	}()
+
+	for _, addr := range addrs {
+		var conn net.Conn
+		for i := 0; i < 100; i++ {
+			conn, err = net.Dial("tcp", addr)
+			if err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		require.NoError(t, err, addr)
+		greeting, err := bufio.NewReader(conn).ReadString('\n')
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(greeting, "220 "), greeting)
+		require.NoError(t, conn.Close())
+	}
+
+	require.NoError(t, w.close())
+	assert.Equal(t, ftp.ErrServerClosed, <-quit)
+
+	// bad addresses
+	opt.ListenAddr = testHOST + ":51781,potato"
+	_, err = newServer(context.Background(), f, &opt)
+	assert.Error(t, err)
+}
@@ -0,0 +1,220 @@
+package http
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/vfs"
+)
+
+// archiveWriter writes the entries of an archive to a stream
+type archiveWriter interface {
+	// addDir adds the directory name
+	addDir(name string, modTime time.Time) error
+	// addFile adds the file name of size bytes read from in
+	addFile(name string, size int64, modTime time.Time, in io.Reader) error
+	// Close finishes the archive
+	Close() error
+}
+
+// archiveFormat describes a format the archive can be made in
+type archiveFormat struct {
+	extension   string
+	contentType string
+	new         func(out io.Writer) archiveWriter
+}
+
+// archiveFormats are the possible values of ?archive=
+var archiveFormats = map[string]archiveFormat{
+	"zip": {
+		extension:   ".zip",
+		contentType: "application/zip",
+		new:         newZipWriter,
+	},
+	"tar.gz": {
+		extension:   ".tar.gz",
+		contentType: "application/gzip",
+		new:         newTarGzWriter,
+	},
+}
+
+// zipWriter writes a zip archive
+//
+// The entries are streamed with data descriptors so the sizes don't
+// need to be known in advance, and archive/zip switches to zip64
+// records for files and archives bigger than 4 GiB.
+type zipWriter struct {
+	zw *zip.Writer
+}
+
+func newZipWriter(out io.Writer) archiveWriter {
+	return &zipWriter{zw: zip.NewWriter(out)}
+}
+
+func (z *zipWriter) addDir(name string, modTime time.Time) error {
+	_, err := z.zw.CreateHeader(&zip.FileHeader{
+		Name:     name + "/",
+		Method:   zip.Store,
+		Modified: modTime,
+	})
+	return err
+}
+
+func (z *zipWriter) addFile(name string, size int64, modTime time.Time, in io.Reader) error {
+	w, err := z.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, in)
+	return err
+}
+
+func (z *zipWriter) Close() error {
+	return z.zw.Close()
+}
+
+// tarGzWriter writes a gzipped tar archive
+//
+// archive/tar uses the PAX format automatically for files which are
+// too big or whose names are too long for plain tar.
+type tarGzWriter struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func newTarGzWriter(out io.Writer) archiveWriter {
+	gz := gzip.NewWriter(out)
+	return &tarGzWriter{
+		gz: gz,
+		tw: tar.NewWriter(gz),
+	}
+}
+
+func (t *tarGzWriter) addDir(name string, modTime time.Time) error {
+	return t.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     name + "/",
+		Mode:     0755,
+		ModTime:  modTime,
+	})
+}
+
+func (t *tarGzWriter) addFile(name string, size int64, modTime time.Time, in io.Reader) error {
+	if size < 0 {
+		return errors.New("can't add file of unknown size to tar archive")
+	}
+	err := t.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0644,
+		ModTime:  modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(t.tw, in)
+	return err
+}
+
+func (t *tarGzWriter) Close() error {
+	err := t.tw.Close()
+	if err != nil {
+		return err
+	}
+	return t.gz.Close()
+}
+
+// serveArchive streams an archive of everything below dir in the
+// format asked for
+//
+// The archive is generated on the fly so if something goes wrong part
+// way through the best we can do is log it and stop, which leaves the
+// client with a truncated archive.
+func (s *server) serveArchive(w http.ResponseWriter, r *http.Request, dir *vfs.Dir, format string) {
+	archive, ok := archiveFormats[format]
+	if !ok {
+		http.Error(w, "Unknown archive format", http.StatusBadRequest)
+		return
+	}
+	dirRemote := dir.Path()
+	leaf := path.Base(dirRemote)
+	if dirRemote == "" {
+		leaf = "rclone"
+	}
+	w.Header().Set("Content-Type", archive.contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": leaf + archive.extension}))
+	if r.Method == "HEAD" {
+		return
+	}
+	fs.Infof(dirRemote, "%s: Serving %s archive", r.RemoteAddr, format)
+	aw := archive.new(w)
+	err := s.archiveDir(r.Context(), aw, dir, leaf)
+	if err == nil {
+		err = aw.Close()
+	}
+	if err != nil {
+		err = fs.CountError(err)
+		fs.Errorf(dirRemote, "Didn't finish writing %s archive: %v", format, err)
+	}
+}
+
+// archiveDir adds dir, as name, and everything below it to aw
+func (s *server) archiveDir(ctx context.Context, aw archiveWriter, dir *vfs.Dir, name string) error {
+	err := aw.addDir(name, dir.ModTime())
+	if err != nil {
+		return errors.Wrapf(err, "failed to add directory %q", dir.Path())
+	}
+	nodes, err := dir.ReadDirAll()
+	if err != nil {
+		return errors.Wrapf(err, "failed to list directory %q", dir.Path())
+	}
+	for _, node := range nodes {
+		childName := path.Join(name, node.Name())
+		switch node := node.(type) {
+		case *vfs.Dir:
+			err = s.archiveDir(ctx, aw, node, childName)
+		case *vfs.File:
+			err = s.archiveFile(ctx, aw, node, childName)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveFile adds file, as name, to aw
+func (s *server) archiveFile(ctx context.Context, aw archiveWriter, file *vfs.File, name string) (err error) {
+	in, err := file.Open(os.O_RDONLY)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %q", file.Path())
+	}
+	defer fs.CheckClose(in, &err)
+	// Account the transfer and apply --bwlimit
+	tr := accounting.Stats(ctx).NewTransferRemoteSize(file.Path(), file.Size())
+	defer func() {
+		tr.Done(ctx, err)
+	}()
+	err = aw.addFile(name, file.Size(), file.ModTime(), tr.Account(ctx, ioutil.NopCloser(in)))
+	if err != nil {
+		return errors.Wrapf(err, "failed to add file %q", file.Path())
+	}
+	return nil
+}
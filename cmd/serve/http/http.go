@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"os"
@@ -9,20 +10,37 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/cmd/serve/auth"
+	"github.com/rclone/rclone/cmd/serve/auth/authflags"
 	"github.com/rclone/rclone/cmd/serve/httplib"
 	"github.com/rclone/rclone/cmd/serve/httplib/httpflags"
 	"github.com/rclone/rclone/cmd/serve/httplib/serve"
+	"github.com/rclone/rclone/cmd/serve/proxy"
+	"github.com/rclone/rclone/cmd/serve/proxy/proxyflags"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/vfs"
 	"github.com/rclone/rclone/vfs/vfsflags"
 	"github.com/spf13/cobra"
 )
 
+// Options for the http server
+var (
+	readWrite     = false
+	maxUploadSize = fs.SizeSuffix(-1)
+)
+
 func init() {
-	httpflags.AddFlags(Command.Flags())
-	vfsflags.AddFlags(Command.Flags())
+	flagSet := Command.Flags()
+	httpflags.AddFlags(flagSet)
+	vfsflags.AddFlags(flagSet)
+	proxyflags.AddFlags(flagSet)
+	authflags.AddFlags(flagSet)
+	flags.BoolVarP(flagSet, &readWrite, "read-write", "", readWrite, "Allow uploading, making directories and deleting")
+	flags.FVarP(flagSet, &maxUploadSize, "max-upload-size", "", "Maximum size of an uploaded file with --read-write")
 }
 
 // Command definition for cobra
@@ -40,13 +58,68 @@ The server will log errors.  Use -v to see access logs.
 
 --bwlimit will be respected for file transfers.  Use --stats to
 control the stats printing.
-` + httplib.Help + vfs.Help,
+
+### Archives
+
+Add ?archive=zip or ?archive=tar.gz to the URL of any directory to
+download it and everything below it as a single archive. The archive
+is streamed as it is made, without using temporary files, and
+respects the filter flags and --bwlimit. Zip archives use zip64
+records for files bigger than 4 GiB.
+
+The directory listings have links to download them as archives.
+
+### JSON listings
+
+Request a directory with ?format=json or with an Accept header of
+application/json to get the listing as JSON instead of HTML. This
+returns the same structure as the operations/list rc call, a "list"
+of the objects described in the lsjson command. These query
+parameters control the listing (use =true to set them):
+
+- recurse - list everything below the directory
+- noModTime - don't read the modification times
+- noMimeType - don't read the mime types
+- showHash - include the hashes of the objects
+- hashTypes - comma separated list of hashes to show, e.g. MD5,SHA-1
+- dirsOnly - only list directories
+- filesOnly - only list files
+
+For example
+
+    curl -H "Accept: application/json" http://localhost:8080/dir/
+    curl "http://localhost:8080/dir/?format=json&recurse=true"
+
+### Uploading
+
+By default the server is read only. Use --read-write to allow
+changes. The directory listings then have a form to upload files and
+one to make a new directory, and a button to delete each file or
+empty directory. The same can be done without a browser:
+
+    curl -F file=@local.txt http://localhost:8080/dir/
+    curl -T local.txt http://localhost:8080/dir/local.txt
+    curl -X DELETE http://localhost:8080/dir/local.txt
+
+Uploads are streamed through the VFS so they respect the
+--vfs-cache-mode flag: use --vfs-cache-mode writes if the remote
+can't stream uploads. Use --max-upload-size to limit the size of each
+uploaded file. Uploads which are too big are rejected and the partial
+file removed. Changes made from a web page served from a different
+origin (as given by the Origin or Referer headers) are rejected.
+
+Use authentication (see below) if the server is reachable by anyone
+you don't want to be able to change the remote.
+` + httplib.Help + auth.Help + auth.RootHelp + vfs.Help + proxy.Help,
 	Run: func(command *cobra.Command, args []string) {
 		cmd.CheckArgs(1, 1, command, args)
 		f := cmd.NewFsSrc(args)
 		cmd.Run(false, true, command, func() error {
-			s := newServer(f, &httpflags.Opt)
-			err := s.Serve()
+			s, err := newServer(context.Background(), f, &httpflags.Opt)
+			if err != nil {
+				return err
+			}
+			err = s.Serve()
 			if err != nil {
 				return err
 			}
@@ -59,19 +132,67 @@ control the stats printing.
 // server contains everything to run the server
 type server struct {
 	*httplib.Server
-	f   fs.Fs
-	vfs *vfs.VFS
+	f             fs.Fs
+	_vfs          *vfs.VFS // don't use directly, use getVFS
+	proxy         *proxy.Proxy
+	readWrite     bool          // if set allow changes
+	maxUploadSize fs.SizeSuffix // largest file upload allowed or -1 for no limit
 }
 
-func newServer(f fs.Fs, opt *httplib.Options) *server {
+func newServer(ctx context.Context, f fs.Fs, opt *httplib.Options) (*server, error) {
 	mux := http.NewServeMux()
 	s := &server{
-		Server: httplib.NewServer(mux, opt),
-		f:      f,
-		vfs:    vfs.New(f, &vfsflags.Opt),
+		f:             f,
+		readWrite:     readWrite,
+		maxUploadSize: maxUploadSize,
+	}
+	if proxyflags.Opt.AuthProxy != "" {
+		if authflags.Opt.HTTPEnabled() {
+			return nil, errors.New("can't use --auth-proxy with the authentication backends")
+		}
+		s.proxy = proxy.New(ctx, &proxyflags.Opt)
+		// override auth
+		copyOpt := *opt
+		copyOpt.Auth = s.auth
+		opt = &copyOpt
+	} else {
+		authOpt, err := authflags.HTTPOptions(ctx, f, opt)
+		if err != nil {
+			return nil, err
+		}
+		if authOpt == opt {
+			s._vfs = vfs.New(f, &vfsflags.Opt)
+		}
+		opt = authOpt
 	}
+	s.Server = httplib.NewServer(mux, opt)
 	mux.HandleFunc(s.Opt.BaseURL+"/", s.handler)
-	return s
+	return s, nil
+}
+
+// Gets the VFS in use for this request
+func (s *server) getVFS(ctx context.Context) (VFS *vfs.VFS, err error) {
+	if s._vfs != nil {
+		return s._vfs, nil
+	}
+	value := ctx.Value(httplib.ContextAuthKey)
+	if value == nil {
+		return nil, errors.New("no VFS found in context")
+	}
+	VFS, ok := value.(*vfs.VFS)
+	if !ok {
+		return nil, errors.Errorf("context value is not VFS: %#v", value)
+	}
+	return VFS, nil
+}
+
+// auth does proxy authorization
+func (s *server) auth(user, pass string) (value interface{}, err error) {
+	VFS, _, err := s.proxy.Call(user, pass, false)
+	if err != nil {
+		return nil, err
+	}
+	return VFS, err
 }
 
 // Serve runs the http server in the background.
@@ -88,7 +209,18 @@ func (s *server) Serve() error {
 
 // handler reads incoming requests and dispatches them
 func (s *server) handler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "GET" && r.Method != "HEAD" {
+	switch r.Method {
+	case "GET", "HEAD":
+	case "POST", "PUT", "DELETE":
+		if !s.readWrite {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !sameOrigin(r) {
+			http.Error(w, "Cross-origin request forbidden", http.StatusForbidden)
+			return
+		}
+	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
@@ -101,17 +233,32 @@ func (s *server) handler(w http.ResponseWriter, r *http.Request) {
 	}
 	isDir := strings.HasSuffix(urlPath, "/")
 	remote := strings.Trim(urlPath, "/")
-	if isDir {
-		s.serveDir(w, r, remote)
-	} else {
-		s.serveFile(w, r, remote)
+	VFS, err := s.getVFS(r.Context())
+	if err != nil {
+		http.Error(w, "Root directory not found", http.StatusNotFound)
+		fs.Errorf(nil, "Failed to serve %q: %v", remote, err)
+		return
+	}
+	switch {
+	case r.Method == "DELETE":
+		s.deleteNode(w, r, VFS, remote)
+	case isDir && r.Method == "POST":
+		s.postDir(w, r, VFS, remote)
+	case !isDir && r.Method == "PUT":
+		s.putFile(w, r, VFS, remote)
+	case r.Method == "POST" || r.Method == "PUT":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	case isDir:
+		s.serveDir(w, r, VFS, remote)
+	default:
+		s.serveFile(w, r, VFS, remote)
 	}
 }
 
 // serveDir serves a directory index at dirRemote
-func (s *server) serveDir(w http.ResponseWriter, r *http.Request, dirRemote string) {
+func (s *server) serveDir(w http.ResponseWriter, r *http.Request, VFS *vfs.VFS, dirRemote string) {
 	// List the directory
-	node, err := s.vfs.Stat(dirRemote)
+	node, err := VFS.Stat(dirRemote)
 	if err == vfs.ENOENT {
 		http.Error(w, "Directory not found", http.StatusNotFound)
 		return
@@ -124,6 +271,14 @@ func (s *server) serveDir(w http.ResponseWriter, r *http.Request, dirRemote stri
 		return
 	}
 	dir := node.(*vfs.Dir)
+	if format := r.URL.Query().Get("archive"); format != "" {
+		s.serveArchive(w, r, dir, format)
+		return
+	}
+	if wantsJSON(r) {
+		s.serveJSON(w, r, VFS, dir)
+		return
+	}
 	dirEntries, err := dir.ReadDirAll()
 	if err != nil {
 		serve.Error(dirRemote, w, "Failed to list directory", err)
@@ -132,8 +287,10 @@ func (s *server) serveDir(w http.ResponseWriter, r *http.Request, dirRemote stri
 
 	// Make the entries for display
 	directory := serve.NewDirectory(dirRemote, s.HTMLTemplate)
+	directory.ReadWrite = s.readWrite
+	directory.Archive = true
 	for _, node := range dirEntries {
-		if vfsflags.Opt.NoModTime {
+		if VFS.Opt.NoModTime {
 			directory.AddHTMLEntry(node.Path(), node.IsDir(), node.Size(), time.Time{})
 		} else {
 			directory.AddHTMLEntry(node.Path(), node.IsDir(), node.Size(), node.ModTime().UTC())
@@ -151,8 +308,8 @@ func (s *server) serveDir(w http.ResponseWriter, r *http.Request, dirRemote stri
 }
 
 // serveFile serves a file object at remote
-func (s *server) serveFile(w http.ResponseWriter, r *http.Request, remote string) {
-	node, err := s.vfs.Stat(remote)
+func (s *server) serveFile(w http.ResponseWriter, r *http.Request, VFS *vfs.VFS, remote string) {
+	node, err := VFS.Stat(remote)
 	if err == vfs.ENOENT {
 		fs.Infof(remote, "%s: File not found", r.RemoteAddr)
 		http.Error(w, "File not found", http.StatusNotFound)
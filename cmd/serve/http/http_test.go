@@ -1,16 +1,27 @@
 package http
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"flag"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
 	_ "github.com/rclone/rclone/backend/local"
 	"github.com/rclone/rclone/cmd/serve/httplib"
+	"github.com/rclone/rclone/cmd/serve/proxy/proxyflags"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config"
 	"github.com/rclone/rclone/fs/filter"
@@ -33,7 +44,9 @@ func startServer(t *testing.T, f fs.Fs) {
 	opt := httplib.DefaultOpt
 	opt.ListenAddr = testBindAddress
 	opt.Template = testTemplate
-	httpServer = newServer(f, &opt)
+	var err error
+	httpServer, err = newServer(context.Background(), f, &opt)
+	require.NoError(t, err)
 	assert.NoError(t, httpServer.Serve())
 	testURL = httpServer.Server.URL()
 
@@ -226,7 +239,369 @@ func TestGET(t *testing.T) {
 	}
 }
 
+func TestArchive(t *testing.T) {
+	get := func(url string) (*http.Response, []byte) {
+		resp, err := http.Get(testURL + url)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp, body
+	}
+
+	// zip of the root - hidden files are filtered out
+	resp, body := get("?archive=zip")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/zip", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=rclone.zip`, resp.Header.Get("Content-Disposition"))
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		if f.Name == "rclone/two.txt" {
+			assert.Equal(t, expectedTime, f.Modified.UTC())
+			in, err := f.Open()
+			require.NoError(t, err)
+			data, err := ioutil.ReadAll(in)
+			require.NoError(t, err)
+			require.NoError(t, in.Close())
+			assert.Equal(t, "0123456789\n", string(data))
+		}
+	}
+	assert.Equal(t, []string{"rclone/", "rclone/one%.txt", "rclone/three/", "rclone/three/a.txt", "rclone/three/b.txt", "rclone/two.txt"}, names)
+
+	// tar.gz of a subdirectory
+	resp, body = get("three/?archive=tar.gz")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `attachment; filename=three.tar.gz`, resp.Header.Get("Content-Disposition"))
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	names = nil
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, hdr.Name)
+	}
+	assert.Equal(t, []string{"three/", "three/a.txt", "three/b.txt"}, names)
+
+	resp, _ = get("?archive=potato")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestJSON(t *testing.T) {
+	getList := func(url string, accept string) (list []map[string]interface{}) {
+		req, err := http.NewRequest("GET", testURL+url, nil)
+		require.NoError(t, err)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, resp.Body.Close())
+		}()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		var out struct {
+			List []map[string]interface{} `json:"list"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return out.List
+	}
+	paths := func(list []map[string]interface{}) (out []string) {
+		for _, item := range list {
+			out = append(out, item["Path"].(string))
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	// hidden files are filtered out
+	list := getList("", "text/html;q=0.9, application/json")
+	assert.Equal(t, []string{"one%.txt", "three", "two.txt"}, paths(list))
+	for _, item := range list {
+		switch item["Path"] {
+		case "two.txt":
+			assert.Equal(t, false, item["IsDir"])
+			assert.Equal(t, float64(11), item["Size"])
+			assert.Equal(t, "two.txt", item["Name"])
+			assert.Equal(t, "2000-01-02T03:04:05.000000000Z", item["ModTime"])
+		case "three":
+			assert.Equal(t, true, item["IsDir"])
+		}
+	}
+
+	list = getList("three/?format=json", "")
+	assert.Equal(t, []string{"three/a.txt", "three/b.txt"}, paths(list))
+
+	list = getList("?format=json&recurse=true&filesOnly=true", "")
+	assert.Equal(t, []string{"one%.txt", "three/a.txt", "three/b.txt", "two.txt"}, paths(list))
+
+	list = getList("?format=json&showHash=true&hashTypes=MD5", "")
+	for _, item := range list {
+		if item["Path"] == "two.txt" {
+			assert.Equal(t, map[string]interface{}{"MD5": "3749f52bb326ae96782b42dc0a97b4c1"}, item["Hashes"])
+		}
+	}
+
+	// ?format=html overrides the Accept header
+	req, err := http.NewRequest("GET", testURL+"?format=html", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+}
+
 func TestFinalise(t *testing.T) {
 	httpServer.Close()
 	httpServer.Wait()
 }
+
+func TestReadWrite(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "rclone-serve-http-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	f, err := fs.NewFs(ctx, dir)
+	require.NoError(t, err)
+
+	opt := httplib.DefaultOpt
+	opt.ListenAddr = testBindAddress
+	oldReadWrite, oldMaxUploadSize := readWrite, maxUploadSize
+	readWrite, maxUploadSize = true, 10
+	s, err := newServer(context.Background(), f, &opt)
+	require.NoError(t, err)
+	readWrite, maxUploadSize = oldReadWrite, oldMaxUploadSize
+	require.NoError(t, s.Serve())
+	defer func() {
+		s.Close()
+		s.Wait()
+	}()
+	baseURL := s.Server.URL()
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	origin := ""
+	do := func(method, url, contentType string, body io.Reader) *http.Response {
+		req, err := http.NewRequest(method, baseURL+url, body)
+		require.NoError(t, err)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		require.NoError(t, resp.Body.Close())
+		return resp
+	}
+	upload := func(url string, files map[string]string) *http.Response {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for name, contents := range files {
+			fw, err := mw.CreateFormFile("file", name)
+			require.NoError(t, err)
+			_, err = fw.Write([]byte(contents))
+			require.NoError(t, err)
+		}
+		require.NoError(t, mw.Close())
+		return do("POST", url, mw.FormDataContentType(), &buf)
+	}
+	readFile := func(name string) string {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	// the listing has the forms
+	resp, err := http.Get(baseURL)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Contains(t, string(body), `name="mkdir"`)
+	assert.Contains(t, string(body), `enctype="multipart/form-data"`)
+
+	// mkdir
+	resp = do("POST", "", "application/x-www-form-urlencoded", strings.NewReader("mkdir=sub"))
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	assert.DirExists(t, filepath.Join(dir, "sub"))
+	resp = do("POST", "", "application/x-www-form-urlencoded", strings.NewReader("mkdir=..%2Fescape"))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// multipart upload
+	resp = upload("sub/", map[string]string{"one.txt": "one", "two.txt": "two"})
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	assert.Equal(t, "/sub/", resp.Header.Get("Location"))
+	assert.Equal(t, "one", readFile("sub/one.txt"))
+	assert.Equal(t, "two", readFile("sub/two.txt"))
+
+	// too big
+	resp = upload("sub/", map[string]string{"big.txt": "0123456789A"})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	assert.NoFileExists(t, filepath.Join(dir, "sub", "big.txt"))
+
+	// PUT
+	resp = do("PUT", "sub/three.txt", "", strings.NewReader("three"))
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "three", readFile("sub/three.txt"))
+	resp = do("PUT", "sub/big.txt", "", strings.NewReader("0123456789A"))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	assert.NoFileExists(t, filepath.Join(dir, "sub", "big.txt"))
+
+	// overwrite
+	resp = do("PUT", "sub/three.txt", "", strings.NewReader("THREE"))
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "THREE", readFile("sub/three.txt"))
+
+	// a failed overwrite leaves the existing file alone
+	resp = upload("sub/", map[string]string{"three.txt": "0123456789A"})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	assert.Equal(t, "THREE", readFile("sub/three.txt"))
+	entries, err := ioutil.ReadDir(filepath.Join(dir, "sub"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(entries), "temporary file removed")
+
+	// cross-origin changes are rejected
+	origin = "http://evil.example.com"
+	resp = do("POST", "sub/", "application/x-www-form-urlencoded", strings.NewReader("delete=one.txt"))
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = do("DELETE", "sub/one.txt", "", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = do("PUT", "sub/one.txt", "", strings.NewReader("evil"))
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	origin = "null"
+	resp = do("DELETE", "sub/one.txt", "", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "one", readFile("sub/one.txt"))
+
+	// same origin changes from the forms are allowed
+	origin = strings.TrimSuffix(baseURL, "/")
+	// delete
+	resp = do("POST", "sub/", "application/x-www-form-urlencoded", strings.NewReader("delete=one.txt"))
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	assert.NoFileExists(t, filepath.Join(dir, "sub", "one.txt"))
+	resp = do("DELETE", "sub/two.txt", "", nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.NoFileExists(t, filepath.Join(dir, "sub", "two.txt"))
+	resp = do("DELETE", "sub/", "", nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp = do("DELETE", "sub/missing.txt", "", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = do("DELETE", "", "", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestAuthProxy(t *testing.T) {
+	ctx := context.Background()
+	f, err := fs.NewFs(ctx, "testdata/files")
+	require.NoError(t, err)
+
+	opt := httplib.DefaultOpt
+	opt.ListenAddr = testBindAddress
+	oldAuthProxy := proxyflags.Opt.AuthProxy
+	proxyflags.Opt.AuthProxy = "go run ../proxy/proxy_code.go"
+	s, err := newServer(ctx, f, &opt)
+	require.NoError(t, err)
+	proxyflags.Opt.AuthProxy = oldAuthProxy
+	assert.Nil(t, s._vfs)
+	require.NoError(t, s.Serve())
+	defer func() {
+		s.Close()
+		s.Wait()
+	}()
+	baseURL := s.Server.URL()
+
+	// no credentials
+	resp, err := http.Get(baseURL + "?format=json")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// the test proxy serves the current directory
+	req, err := http.NewRequest("GET", baseURL+"?format=json", nil)
+	require.NoError(t, err)
+	req.SetBasicAuth("user", "pass")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, resp.Body.Close())
+	}()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var out struct {
+		List []struct {
+			Path string
+		} `json:"list"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	var found bool
+	for _, item := range out.List {
+		if item.Path == "http_test.go" {
+			found = true
+		}
+	}
+	assert.True(t, found, "http_test.go not found in listing")
+}
+
+func TestJSONUsesVFS(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "one.txt"), []byte("one"), 0666))
+	f, err := fs.NewFs(ctx, dir)
+	require.NoError(t, err)
+
+	opt := httplib.DefaultOpt
+	opt.ListenAddr = testBindAddress
+	s, err := newServer(ctx, f, &opt)
+	require.NoError(t, err)
+	require.NoError(t, s.Serve())
+	defer func() {
+		s.Close()
+		s.Wait()
+	}()
+	baseURL := s.Server.URL()
+
+	getPaths := func() (paths []string) {
+		resp, err := http.Get(baseURL + "?format=json")
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, resp.Body.Close())
+		}()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var out struct {
+			List []struct {
+				Path string
+			} `json:"list"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		for _, item := range out.List {
+			paths = append(paths, item.Path)
+		}
+		sort.Strings(paths)
+		return paths
+	}
+
+	assert.Equal(t, []string{"one.txt"}, getPaths())
+
+	// the listing comes from the VFS directory cache so a file
+	// added behind its back isn't seen until the cache is flushed
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "two.txt"), []byte("two"), 0666))
+	assert.Equal(t, []string{"one.txt"}, getPaths())
+	VFS, err := s.getVFS(ctx)
+	require.NoError(t, err)
+	VFS.FlushDirCache()
+	assert.Equal(t, []string{"one.txt", "two.txt"}, getPaths())
+}
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rclone/rclone/cmd/serve/httplib/serve"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/vfs"
+)
+
+// jsonTimeFormat is the format the modification times are shown in
+const jsonTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// wantsJSON returns true if the request asked for a JSON listing
+// either with ?format=json or with an Accept header
+func wantsJSON(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "json"
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == "application/json" {
+			return true
+		}
+	}
+	return false
+}
+
+// queryBool reads the boolean query parameter name, returning false
+// if it is absent or can't be parsed
+func queryBool(r *http.Request, name string) bool {
+	value, err := strconv.ParseBool(r.URL.Query().Get(name))
+	return err == nil && value
+}
+
+// jsonListOpt are the options for serveJSON read from the query
+type jsonListOpt struct {
+	operations.ListJSONOpt
+	maxDepth  int
+	hashTypes []hash.Type
+}
+
+// serveJSON serves the listing of dir in the same format as the
+// operations/list rc call
+//
+// The listing is read through the VFS so it sees the same files as
+// the HTML listing, including ones which haven't been uploaded yet.
+func (s *server) serveJSON(w http.ResponseWriter, r *http.Request, VFS *vfs.VFS, dir *vfs.Dir) {
+	dirRemote := dir.Path()
+	opt := jsonListOpt{
+		ListJSONOpt: operations.ListJSONOpt{
+			Recurse:    queryBool(r, "recurse"),
+			NoModTime:  queryBool(r, "noModTime") || VFS.Opt.NoModTime,
+			NoMimeType: queryBool(r, "noMimeType"),
+			ShowHash:   queryBool(r, "showHash"),
+			DirsOnly:   queryBool(r, "dirsOnly"),
+			FilesOnly:  queryBool(r, "filesOnly"),
+		},
+	}
+	opt.maxDepth = operations.ConfigMaxDepth(r.Context(), opt.Recurse)
+	opt.hashTypes = VFS.Fs().Hashes().Array()
+	if hashTypes := r.URL.Query().Get("hashTypes"); hashTypes != "" {
+		opt.ShowHash = true
+		opt.hashTypes = nil
+		for _, hashType := range strings.Split(hashTypes, ",") {
+			var ht hash.Type
+			err := ht.Set(hashType)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			opt.hashTypes = append(opt.hashTypes, ht)
+		}
+	}
+	var list = []*operations.ListJSONItem{}
+	err := listJSON(r.Context(), dir, &opt, 1, &list)
+	if err != nil {
+		serve.Error(dirRemote, w, "Failed to list directory", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == "HEAD" {
+		return
+	}
+	err = json.NewEncoder(w).Encode(map[string]interface{}{
+		"list": list,
+	})
+	if err != nil {
+		fs.Errorf(dirRemote, "Failed to write JSON listing: %v", err)
+	}
+}
+
+// listJSON appends the entries of dir to list, descending into
+// subdirectories until opt.maxDepth is reached
+func listJSON(ctx context.Context, dir *vfs.Dir, opt *jsonListOpt, depth int, list *[]*operations.ListJSONItem) error {
+	nodes, err := dir.ReadDirAll()
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if (node.IsDir() && !opt.FilesOnly) || (!node.IsDir() && !opt.DirsOnly) {
+			*list = append(*list, jsonItem(ctx, node, opt))
+		}
+		if subDir, ok := node.(*vfs.Dir); ok && (opt.maxDepth < 0 || depth < opt.maxDepth) {
+			err = listJSON(ctx, subDir, opt, depth+1, list)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonItem makes the listing entry for node
+func jsonItem(ctx context.Context, node vfs.Node, opt *jsonListOpt) *operations.ListJSONItem {
+	item := &operations.ListJSONItem{
+		Path:  node.Path(),
+		Name:  node.Name(),
+		Size:  node.Size(),
+		IsDir: node.IsDir(),
+	}
+	if !opt.NoModTime {
+		item.ModTime = operations.Timestamp{When: node.ModTime(), Format: jsonTimeFormat}
+	}
+	entry := node.DirEntry()
+	if !opt.NoMimeType {
+		if node.IsDir() {
+			item.MimeType = "inode/directory"
+		} else if entry != nil {
+			item.MimeType = fs.MimeTypeDirEntry(ctx, entry)
+		} else {
+			item.MimeType = fs.MimeTypeFromName(node.Path())
+		}
+	}
+	// Files which haven't been uploaded yet don't have an object
+	// to read the hashes from
+	if o, ok := entry.(fs.Object); ok && opt.ShowHash {
+		item.Hashes = make(map[string]string)
+		for _, hashType := range opt.hashTypes {
+			hash, err := o.Hash(ctx, hashType)
+			if err != nil {
+				fs.Errorf(o, "Failed to read hash: %v", err)
+			} else if hash != "" {
+				item.Hashes[hashType.String()] = hash
+			}
+		}
+	}
+	return item
+}
@@ -0,0 +1,243 @@
+package http
+
+import (
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/cmd/serve/httplib/serve"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/lib/random"
+	"github.com/rclone/rclone/vfs"
+)
+
+// errUploadTooLarge is returned when an upload exceeds --max-upload-size
+var errUploadTooLarge = errors.New("upload too large")
+
+// writeError writes an HTTP error for err which came from modifying
+// remote
+func writeError(remote string, w http.ResponseWriter, text string, err error) {
+	switch errors.Cause(err) {
+	case vfs.ENOENT:
+		http.Error(w, "Not found", http.StatusNotFound)
+	case vfs.EEXIST:
+		http.Error(w, "Already exists", http.StatusConflict)
+	case vfs.ENOTEMPTY:
+		http.Error(w, "Directory not empty", http.StatusConflict)
+	case vfs.EROFS, vfs.EPERM:
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	case errUploadTooLarge:
+		http.Error(w, "Upload too large", http.StatusRequestEntityTooLarge)
+	default:
+		serve.Error(remote, w, text, err)
+		return
+	}
+	fs.Infof(remote, "%s: %v", text, err)
+}
+
+// sameOrigin returns true if r was made by a page served by this
+// server, or by a client which isn't a browser.
+//
+// Browsers set the Origin header (or failing that the Referer) on
+// cross-site form submissions, so rejecting requests where these
+// don't match the Host stops other web sites making changes with the
+// credentials of a logged in user.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = r.Header.Get("Referer")
+	}
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// validLeaf returns true if name is usable as the name of a file or
+// directory in the current directory
+func validLeaf(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.Contains(name, "/")
+}
+
+// postDir handles a POST to the directory dirRemote from the forms
+// in the directory listing. This can upload files (multipart), make
+// a directory (mkdir=name) or delete an entry (delete=name).
+func (s *server) postDir(w http.ResponseWriter, r *http.Request, VFS *vfs.VFS, dirRemote string) {
+	node, err := VFS.Stat(dirRemote)
+	if err != nil {
+		writeError(dirRemote, w, "Failed to find directory", err)
+		return
+	}
+	if !node.IsDir() {
+		http.Error(w, "Not a directory", http.StatusNotFound)
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if !s.uploadMultipart(w, r, VFS, dirRemote) {
+			return
+		}
+	} else {
+		err = r.ParseForm()
+		if err != nil {
+			http.Error(w, "Bad form: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if name := r.PostForm.Get("mkdir"); name != "" {
+			if !validLeaf(name) {
+				http.Error(w, "Bad directory name", http.StatusBadRequest)
+				return
+			}
+			remote := path.Join(dirRemote, name)
+			err = VFS.Mkdir(remote, 0777)
+			if err != nil {
+				writeError(remote, w, "Failed to make directory", err)
+				return
+			}
+			fs.Infof(remote, "%s: Made directory", r.RemoteAddr)
+		} else if name := strings.TrimSuffix(r.PostForm.Get("delete"), "/"); name != "" {
+			if !validLeaf(name) {
+				http.Error(w, "Bad name", http.StatusBadRequest)
+				return
+			}
+			if !s.remove(w, r, VFS, path.Join(dirRemote, name)) {
+				return
+			}
+		} else {
+			http.Error(w, "Unknown action", http.StatusBadRequest)
+			return
+		}
+	}
+	// Show the directory listing again
+	http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+}
+
+// uploadMultipart streams the files in a multipart form into
+// dirRemote, returning false if an error response was written.
+func (s *server) uploadMultipart(w http.ResponseWriter, r *http.Request, VFS *vfs.VFS, dirRemote string) bool {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Bad form: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return true
+		}
+		if err != nil {
+			http.Error(w, "Bad form: "+err.Error(), http.StatusBadRequest)
+			return false
+		}
+		name := part.FileName()
+		if part.FormName() != "file" || name == "" {
+			// Not a file so ignore it
+			continue
+		}
+		if !validLeaf(name) {
+			http.Error(w, "Bad file name", http.StatusBadRequest)
+			return false
+		}
+		remote := path.Join(dirRemote, name)
+		err = s.upload(r, VFS, remote, part, -1)
+		if err != nil {
+			writeError(remote, w, "Failed to upload file", err)
+			return false
+		}
+	}
+}
+
+// putFile handles a PUT of the request body to remote
+func (s *server) putFile(w http.ResponseWriter, r *http.Request, VFS *vfs.VFS, remote string) {
+	if s.maxUploadSize >= 0 && r.ContentLength > int64(s.maxUploadSize) {
+		writeError(remote, w, "Failed to upload file", errUploadTooLarge)
+		return
+	}
+	err := s.upload(r, VFS, remote, r.Body, r.ContentLength)
+	if err != nil {
+		writeError(remote, w, "Failed to upload file", err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// upload streams in, which is size bytes long or -1 if unknown, to
+// remote through the VFS so it respects --vfs-cache-mode.
+//
+// If remote exists then the upload is written to a temporary name
+// which is renamed over remote when it is complete, so a failed
+// upload never destroys the existing file. If the upload fails the
+// partial file is removed.
+func (s *server) upload(r *http.Request, VFS *vfs.VFS, remote string, in io.Reader, size int64) (err error) {
+	ctx := r.Context()
+	uploadRemote := remote
+	if _, err := VFS.Stat(remote); err == nil {
+		dir, leaf := path.Split(remote)
+		uploadRemote = path.Join(dir, "."+leaf+".rclone-upload-"+random.String(8))
+	}
+	fd, err := VFS.OpenFile(uploadRemote, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	tr := accounting.Stats(ctx).NewTransferRemoteSize(remote, size)
+	defer func() {
+		tr.Done(ctx, err)
+	}()
+	in = tr.Account(ctx, ioutil.NopCloser(in)) // account the transfer and apply --bwlimit
+	if s.maxUploadSize >= 0 {
+		in = io.LimitReader(in, int64(s.maxUploadSize)+1)
+	}
+	n, err := io.Copy(fd, in)
+	if err == nil && s.maxUploadSize >= 0 && n > int64(s.maxUploadSize) {
+		err = errUploadTooLarge
+	}
+	closeErr := fd.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && uploadRemote != remote {
+		err = VFS.Rename(uploadRemote, remote)
+	}
+	if err != nil {
+		if removeErr := VFS.Remove(uploadRemote); removeErr != nil && removeErr != vfs.ENOENT {
+			fs.Errorf(uploadRemote, "Failed to remove partial upload: %v", removeErr)
+		}
+		return err
+	}
+	fs.Infof(remote, "%s: Uploaded %d bytes", r.RemoteAddr, n)
+	return nil
+}
+
+// remove removes the file or empty directory at remote, returning
+// false if an error response was written.
+func (s *server) remove(w http.ResponseWriter, r *http.Request, VFS *vfs.VFS, remote string) bool {
+	if remote == "" {
+		http.Error(w, "Can't delete the root", http.StatusForbidden)
+		return false
+	}
+	err := VFS.Remove(remote)
+	if err != nil {
+		writeError(remote, w, "Failed to delete", err)
+		return false
+	}
+	fs.Infof(remote, "%s: Deleted", r.RemoteAddr)
+	return true
+}
+
+// deleteNode handles a DELETE of the file or empty directory at remote
+func (s *server) deleteNode(w http.ResponseWriter, r *http.Request, VFS *vfs.VFS, remote string) {
+	if s.remove(w, r, VFS, remote) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
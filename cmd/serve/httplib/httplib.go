@@ -4,11 +4,9 @@ package httplib
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/base64"
 	"fmt"
 	"html/template"
-	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
@@ -34,6 +32,10 @@ listen on, e.g. --addr 1.2.3.4:8000 or --addr :8080 to listen to all
 IPs.  By default it only listens on localhost.  You can use port
 :0 to let the OS choose an available port.
 
+--addr may be a comma separated list of addresses to listen on more
+than one, and an address of the form unix:/path/to/socket listens on
+a unix socket, e.g. --addr localhost:8080,unix:/run/rclone.sock
+
 If you set --addr to listen on a public or LAN accessible IP address
 then using Authentication is advised - see the next section for info.
 
@@ -107,11 +109,27 @@ supply --client-ca also.
 of that with the CA certificate.  --key should be the PEM encoded
 private key and --client-ca should be the PEM encoded client
 certificate authority certificate.
+
+The --cert, --key and --client-ca files are checked for changes every
+10 seconds and reloaded if they have changed, or straight away if
+rclone is sent SIGHUP, so certificates can be rotated without
+restarting the server. If the new files can't be loaded the old ones
+are kept and an error is logged.
+
+Use --client-cert-user to authenticate clients by their certificate.
+This needs --client-ca and sets the user name from the field of the
+verified client certificate given, which can be "cn" for the Subject
+Common Name, or "email", "dns" or "uri" for the first Subject
+Alternative Name of that type. Clients whose certificate doesn't have
+the field fall back to the --user/--htpasswd authentication if there
+is any, otherwise they are refused. When there is another way to log
+in, clients don't need to present a certificate at all, but any they
+do present must be signed by the --client-ca.
 `
 
 // Options contains options for the http Server
 type Options struct {
-	ListenAddr         string        // Port to listen on
+	ListenAddr         string        // Port to listen on - may be a comma separated list
 	BaseURL            string        // prefix to strip from URLs
 	ServerReadTimeout  time.Duration // Timeout for server reading data
 	ServerWriteTimeout time.Duration // Timeout for server writing data
@@ -119,11 +137,13 @@ type Options struct {
 	SslCert            string        // SSL PEM key (concatenation of certificate and CA certificate)
 	SslKey             string        // SSL PEM Private key
 	ClientCA           string        // Client certificate authority to verify clients with
+	ClientCertUser     string        // field of the client certificate to use as the user name, if set
 	HtPasswd           string        // htpasswd file - if not provided no authentication is done
 	Realm              string        // realm for authentication
 	BasicUser          string        // single username for basic auth if not using Htpasswd
 	BasicPass          string        // password for BasicUser
 	Auth               AuthFn        `json:"-"` // custom Auth (not set by command line flags)
+	BearerAuth         BearerAuthFn  `json:"-"` // custom Auth for bearer tokens (not set by command line flags)
 	Template           string        // User specified template
 }
 
@@ -133,6 +153,13 @@ type Options struct {
 // If a non nil value is returned then it is added to the context under the key
 type AuthFn func(user, pass string) (value interface{}, err error)
 
+// BearerAuthFn if used will be used to authenticate bearer tokens. If
+// an error is returned then the token is not authenticated.
+//
+// It returns the user the token is for and a value which, if non nil,
+// is added to the context under ContextAuthKey.
+type BearerAuthFn func(token string) (user string, value interface{}, err error)
+
 // DefaultOpt is the default values used for Options
 var DefaultOpt = Options{
 	ListenAddr:         "localhost:8080",
@@ -146,7 +173,9 @@ var DefaultOpt = Options{
 type Server struct {
 	Opt             Options
 	handler         http.Handler // original handler
-	listener        net.Listener
+	listenAddrs     []string
+	listeners       []net.Listener
+	certs           *certReloader // set if using SSL/TLS when serving
 	waitChan        chan struct{} // for waiting on the listener to close
 	httpServer      *http.Server
 	basicPassHashed string
@@ -194,6 +223,15 @@ func parseAuthorization(r *http.Request) (user, pass string, ok bool) {
 	return
 }
 
+// parseBearer parses a bearer token from the Authorization header
+func parseBearer(r *http.Request) (token string, ok bool) {
+	s := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(s) == 2 && strings.EqualFold(s[0], "Bearer") && s[1] != "" {
+		return strings.TrimSpace(s[1]), true
+	}
+	return "", false
+}
+
 // NewServer creates an http server.  The opt can be nil in which case
 // the default options will be used.
 func NewServer(handler http.Handler, opt *Options) *Server {
@@ -209,6 +247,8 @@ func NewServer(handler http.Handler, opt *Options) *Server {
 	}
 
 	// Use htpasswd if required on everything
+	unauthenticatedHandler := handler
+	var basicAuthHandler http.Handler
 	if s.Opt.HtPasswd != "" || s.Opt.BasicUser != "" || s.Opt.Auth != nil {
 		var authenticator *auth.BasicAuth
 		if s.Opt.Auth == nil {
@@ -233,8 +273,25 @@ func NewServer(handler http.Handler, opt *Options) *Server {
 			unauthorized := func() {
 				w.Header().Set("Content-Type", "text/plain")
 				w.Header().Set("WWW-Authenticate", `Basic realm="`+s.Opt.Realm+`"`)
+				if s.Opt.BearerAuth != nil {
+					w.Header().Add("WWW-Authenticate", `Bearer realm="`+s.Opt.Realm+`"`)
+				}
 				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 			}
+			if token, ok := parseBearer(r); ok && s.Opt.BearerAuth != nil {
+				user, value, err := s.Opt.BearerAuth(token)
+				if err != nil {
+					fs.Infof(r.URL.Path, "%s: Bearer auth failed: %v", r.RemoteAddr, err)
+					unauthorized()
+					return
+				}
+				if value != nil {
+					r = r.WithContext(context.WithValue(r.Context(), ContextAuthKey, value))
+				}
+				r = r.WithContext(context.WithValue(r.Context(), ContextUserKey, user))
+				oldHandler.ServeHTTP(w, r)
+				return
+			}
 			user, pass, authValid := parseAuthorization(r)
 			if !authValid {
 				unauthorized()
@@ -261,6 +318,35 @@ func NewServer(handler http.Handler, opt *Options) *Server {
 			r = r.WithContext(context.WithValue(r.Context(), ContextUserKey, user))
 			oldHandler.ServeHTTP(w, r)
 		})
+		basicAuthHandler = handler
+		s.usingAuth = true
+	}
+
+	// Use the client certificate to find the user if required
+	if s.Opt.ClientCertUser != "" {
+		if clientCertUserFields[s.Opt.ClientCertUser] == nil {
+			log.Fatalf("Unknown --client-cert-user %q - use cn, email, dns or uri", s.Opt.ClientCertUser)
+		}
+		if s.Opt.ClientCA == "" {
+			log.Fatalf("Can't use --client-cert-user without --client-ca")
+		}
+		if s.Opt.Auth != nil {
+			log.Fatalf("Can't use --client-cert-user with custom authentication such as --auth-proxy")
+		}
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := clientCertUser(r, s.Opt.ClientCertUser)
+			if user != "" {
+				r = r.WithContext(context.WithValue(r.Context(), ContextUserKey, user))
+				unauthenticatedHandler.ServeHTTP(w, r)
+				return
+			}
+			if basicAuthHandler != nil {
+				basicAuthHandler.ServeHTTP(w, r)
+				return
+			}
+			fs.Infof(r.URL.Path, "%s: Unauthorized request: no %s in client certificate", r.RemoteAddr, s.Opt.ClientCertUser)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		})
 		s.usingAuth = true
 	}
 
@@ -275,6 +361,8 @@ func NewServer(handler http.Handler, opt *Options) *Server {
 		s.Opt.BaseURL = "/" + s.Opt.BaseURL
 	}
 
+	s.listenAddrs = SplitListenAddrs(s.Opt.ListenAddr)
+
 	// FIXME make a transport?
 	s.httpServer = &http.Server{
 		Addr:              s.Opt.ListenAddr,
@@ -286,23 +374,18 @@ func NewServer(handler http.Handler, opt *Options) *Server {
 		IdleTimeout:       60 * time.Second, // time to keep idle connections open
 		TLSConfig: &tls.Config{
 			MinVersion: tls.VersionTLS10, // disable SSL v3.0 and earlier
+			NextProtos: []string{"h2", "http/1.1"},
 		},
 	}
 
-	if s.Opt.ClientCA != "" {
-		if !s.useSSL {
-			log.Fatalf("Can't use --client-ca without --cert and --key")
-		}
-		certpool := x509.NewCertPool()
-		pem, err := ioutil.ReadFile(s.Opt.ClientCA)
-		if err != nil {
-			log.Fatalf("Failed to read client certificate authority: %v", err)
-		}
-		if !certpool.AppendCertsFromPEM(pem) {
-			log.Fatalf("Can't parse client certificate authority")
-		}
-		s.httpServer.TLSConfig.ClientCAs = certpool
-		s.httpServer.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	if s.Opt.ClientCA != "" && !s.useSSL {
+		log.Fatalf("Can't use --client-ca without --cert and --key")
+	}
+
+	// Let clients without a certificate through the TLS handshake
+	// if they can log in another way
+	if s.Opt.ClientCertUser != "" && basicAuthHandler != nil {
+		s.httpServer.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
 	}
 
 	htmlTemplate, templateErr := data.GetTemplate(s.Opt.Template)
@@ -318,42 +401,42 @@ func NewServer(handler http.Handler, opt *Options) *Server {
 // the listener was not started; does not block, so
 // use s.Wait() to block on the listener indefinitely.
 func (s *Server) Serve() error {
-	ln, err := net.Listen("tcp", s.httpServer.Addr)
-	if err != nil {
-		return errors.Wrapf(err, "start server failed")
+	if s.useSSL {
+		certs, err := newCertReloader(s.httpServer.TLSConfig, s.Opt.SslCert, s.Opt.SslKey, s.Opt.ClientCA)
+		if err != nil {
+			return errors.Wrapf(err, "start server failed")
+		}
+		s.certs = certs
+		s.httpServer.TLSConfig = certs.TLSConfig()
 	}
-	s.listener = ln
-	s.waitChan = make(chan struct{})
-	go func() {
-		var err error
-		if s.useSSL {
-			// hacky hack to get this to work with old Go versions, which
-			// don't have ServeTLS on http.Server; see PR #2194.
-			type tlsServer interface {
-				ServeTLS(ln net.Listener, cert, key string) error
+	s.listeners = nil
+	for _, addr := range s.listenAddrs {
+		ln, err := Listen(addr)
+		if err != nil {
+			for _, ln := range s.listeners {
+				_ = ln.Close()
 			}
-			srvIface := interface{}(s.httpServer)
-			if tlsSrv, ok := srvIface.(tlsServer); ok {
-				// yay -- we get easy TLS support with HTTP/2
-				err = tlsSrv.ServeTLS(s.listener, s.Opt.SslCert, s.Opt.SslKey)
-			} else {
-				// oh well -- we can still do TLS but might not have HTTP/2
-				tlsConfig := new(tls.Config)
-				tlsConfig.Certificates = make([]tls.Certificate, 1)
-				tlsConfig.Certificates[0], err = tls.LoadX509KeyPair(s.Opt.SslCert, s.Opt.SslKey)
-				if err != nil {
-					log.Printf("Error loading key pair: %v", err)
-				}
-				tlsLn := tls.NewListener(s.listener, tlsConfig)
-				err = s.httpServer.Serve(tlsLn)
+			s.listeners = nil
+			if s.certs != nil {
+				s.certs.close()
+				s.certs = nil
 			}
-		} else {
-			err = s.httpServer.Serve(s.listener)
+			return errors.Wrapf(err, "start server failed")
 		}
-		if err != nil {
-			log.Printf("Error on serving HTTP server: %v", err)
+		s.listeners = append(s.listeners, ln)
+	}
+	s.waitChan = make(chan struct{})
+	for _, ln := range s.listeners {
+		if s.useSSL {
+			ln = tls.NewListener(ln, s.httpServer.TLSConfig)
 		}
-	}()
+		go func(ln net.Listener) {
+			err := s.httpServer.Serve(ln)
+			if err != nil {
+				log.Printf("Error on serving HTTP server: %v", err)
+			}
+		}(ln)
+	}
 	return nil
 }
 
@@ -364,6 +447,10 @@ func (s *Server) Wait() {
 
 // Close shuts the running server down
 func (s *Server) Close() {
+	if s.certs != nil {
+		s.certs.close()
+		s.certs = nil
+	}
 	err := s.httpServer.Close()
 	if err != nil {
 		log.Printf("Error on closing HTTP server: %v", err)
@@ -373,20 +460,28 @@ func (s *Server) Close() {
 }
 
 // URL returns the serving address of this server
+//
+// If the server is listening on more than one address this is the
+// first one.
 func (s *Server) URL() string {
+	return s.URLs()[0]
+}
+
+// URLs returns the serving addresses of this server, one for each
+// address it is listening on
+func (s *Server) URLs() (urls []string) {
 	proto := "http"
 	if s.useSSL {
 		proto = "https"
 	}
-	addr := s.Opt.ListenAddr
-	// prefer actual listener address if using ":port" or "addr:0"
-	useActualAddress := addr == "" || addr[0] == ':' || addr[len(addr)-1] == ':' || strings.HasSuffix(addr, ":0")
-	if s.listener != nil && useActualAddress {
-		// use actual listener address; required if using 0-port
-		// (i.e. port assigned by operating system)
-		addr = s.listener.Addr().String()
+	for i, addr := range s.listenAddrs {
+		var ln net.Listener
+		if i < len(s.listeners) {
+			ln = s.listeners[i]
+		}
+		urls = append(urls, fmt.Sprintf("%s%s/", listenURL(proto, ln, addr), s.Opt.BaseURL))
 	}
-	return fmt.Sprintf("%s://%s%s/", proto, addr, s.Opt.BaseURL)
+	return urls
 }
 
 // UsingAuth returns true if authentication is required
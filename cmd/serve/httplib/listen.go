@@ -0,0 +1,72 @@
+package httplib
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SplitListenAddrs splits the comma separated list of addresses the
+// server should listen on
+func SplitListenAddrs(addrs string) (out []string) {
+	for _, addr := range strings.Split(addrs, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr != "" {
+			out = append(out, addr)
+		}
+	}
+	if len(out) == 0 {
+		out = append(out, "")
+	}
+	return out
+}
+
+// unixSocketPath returns the path of the unix socket if addr is of
+// the form unix:PATH or unix://PATH
+func unixSocketPath(addr string) (path string, ok bool) {
+	if strings.HasPrefix(addr, "unix://") {
+		return addr[len("unix://"):], true
+	}
+	if strings.HasPrefix(addr, "unix:") {
+		return addr[len("unix:"):], true
+	}
+	return "", false
+}
+
+// Listen listens on addr which is either a TCP address like
+// "localhost:8080" or a unix socket like "unix:/run/rclone.sock"
+func Listen(addr string) (net.Listener, error) {
+	path, ok := unixSocketPath(addr)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	// Remove the socket if a previous server didn't clean up
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			_ = conn.Close()
+			return nil, errors.Errorf("unix socket %q is in use", path)
+		}
+		_ = os.Remove(path)
+	}
+	return net.Listen("unix", path)
+}
+
+// listenURL returns the URL the server can be reached at for the
+// listener ln opened on addr
+func listenURL(proto string, ln net.Listener, addr string) string {
+	if path, ok := unixSocketPath(addr); ok {
+		return proto + "+unix://" + url.PathEscape(path)
+	}
+	// prefer actual listener address if using ":port" or "addr:0"
+	useActualAddress := addr == "" || addr[0] == ':' || addr[len(addr)-1] == ':' || strings.HasSuffix(addr, ":0")
+	if ln != nil && useActualAddress {
+		// use actual listener address; required if using 0-port
+		// (i.e. port assigned by operating system)
+		addr = ln.Addr().String()
+	}
+	return proto + "://" + addr
+}
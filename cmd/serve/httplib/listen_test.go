@@ -0,0 +1,83 @@
+package httplib
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitListenAddrs(t *testing.T) {
+	assert.Equal(t, []string{""}, SplitListenAddrs(""))
+	assert.Equal(t, []string{"localhost:8080"}, SplitListenAddrs("localhost:8080"))
+	assert.Equal(t, []string{"localhost:8080", "[::1]:8081", "unix:/run/rclone.sock"}, SplitListenAddrs("localhost:8080, [::1]:8081,,unix:/run/rclone.sock"))
+}
+
+func TestUnixSocketPath(t *testing.T) {
+	for _, test := range []struct {
+		addr string
+		path string
+		ok   bool
+	}{
+		{"localhost:8080", "", false},
+		{"unix:/run/rclone.sock", "/run/rclone.sock", true},
+		{"unix:///run/rclone.sock", "/run/rclone.sock", true},
+		{"unix:rclone.sock", "rclone.sock", true},
+	} {
+		path, ok := unixSocketPath(test.addr)
+		assert.Equal(t, test.path, path, test.addr)
+		assert.Equal(t, test.ok, ok, test.addr)
+	}
+}
+
+func TestServeMultipleAddrs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets not supported")
+	}
+	socket := filepath.Join(t.TempDir(), "rclone.sock")
+	opt := DefaultOpt
+	opt.ListenAddr = "localhost:0,unix:" + socket
+	s := NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}), &opt)
+	require.NoError(t, s.Serve())
+	defer func() {
+		s.Close()
+		s.Wait()
+	}()
+
+	urls := s.URLs()
+	require.Equal(t, 2, len(urls))
+	assert.True(t, strings.HasPrefix(urls[0], "http://127.0.0.1:"), urls[0])
+	assert.Equal(t, s.URL(), urls[0])
+	assert.Equal(t, "http+unix://"+strings.Replace(socket, "/", "%2F", -1)+"/", urls[1])
+
+	get := func(client *http.Client, url string) string {
+		resp, err := client.Get(url)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return string(body)
+	}
+	assert.Equal(t, "hello", get(http.DefaultClient, urls[0]))
+	unixClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	assert.Equal(t, "hello", get(unixClient, "http://unix/"))
+
+	// the socket is in use so can't be listened on again
+	s2 := NewServer(http.NotFoundHandler(), &Options{ListenAddr: "unix:" + socket})
+	assert.Error(t, s2.Serve())
+}
@@ -21,14 +21,14 @@ var Assets = func() http.FileSystem {
 	fs := vfsgen۰FS{
 		"/": &vfsgen۰DirInfo{
 			name:    "/",
-			modTime: time.Date(2020, 5, 5, 16, 40, 6, 115915195, time.UTC),
+			modTime: time.Date(2026, 10, 18, 14, 16, 56, 505073000, time.UTC),
 		},
 		"/index.html": &vfsgen۰CompressedFileInfo{
 			name:             "index.html",
-			modTime:          time.Date(2020, 5, 5, 16, 40, 5, 919909715, time.UTC),
-			uncompressedSize: 15424,
+			modTime:          time.Date(2026, 10, 18, 14, 16, 56, 505073000, time.UTC),
+			uncompressedSize: 16354,
 
-			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x7b\x6d\x73\xdb\x46\xf2\xe7\x6b\xe9\x53\x4c\x98\xcd\x8a\x4a\xc0\xe1\x3c\x3f\x48\xa4\xf6\x6c\xc6\x59\xbb\x56\x71\x52\xb1\x9d\xad\x6c\x2a\x2f\x20\x62\x48\xe2\x0c\x02\x0c\x00\xea\xc1\x3a\x55\xdd\x87\xb8\x4f\x78\x9f\xe4\xaa\x67\x00\x12\x90\x28\x27\x7b\xf5\xdf\xbf\xec\xa2\x80\x9e\x99\x9e\x7e\xf8\x75\x4f\x37\x08\x4d\xbe\x18\x8d\xd0\xf1\x78\x8c\x66\xc5\xe6\xae\x4c\x97\xab\x1a\x31\x42\x25\xfa\x3e\xae\xeb\x95\xbb\x41\xaf\x8b\xac\x46\x71\x9e\xa0\xf7\x2b\x87\x66\x71\x92\xdc\xa1\x17\xdb\x7a\x55\x94\xd5\xf1\x78\x0c\xeb\x2e\xd3\xb9\xcb\x2b\x97\xa0\x6d\x9e\xb8\x12\xd5\x2b\x87\x5e\x6c\xe2\xf9\xca\xb5\x23\x11\xfa\xd9\x95\x55\x5a\xe4\x88\x61\x82\x86\x30\x61\xd0\x0c\x0d\x4e\xcf\x81\xc5\x5d\xb1\x45\xeb\xf8\x0e\xe5\x45\x8d\xb6\x95\x43\xf5\x2a\xad\xd0\x22\xcd\x1c\x72\xb7\x73\xb7\xa9\x51\x9a\xa3\x79\xb1\xde\x64\x69\x9c\xcf\x1d\xba\x49\xeb\x95\xdf\xa7\xe1\x82\x81\xc7\x2f\x0d\x8f\xe2\xaa\x8e\xd3\x1c\xc5\x68\x5e\x6c\xee\x50\xb1\xe8\x4e\x44\x71\xdd\x08\x0d\x3f\xab\xba\xde\x9c\x8d\xc7\x37\x37\x37\x38\xf6\x02\xe3\xa2\x5c\x8e\xb3\x30\xb5\x1a\x5f\xbe\x99\xbd\x7a\xfb\xee\xd5\x88\x61\xd2\x2c\xfa\x90\x67\xae\xaa\x50\xe9\x7e\xdf\xa6\xa5\x4b\xd0\xd5\x1d\x8a\x37\x9b\x2c\x9d\xc7\x57\x99\x43\x59\x7c\x83\x8a\x12\xc5\xcb\xd2\xb9\x04\xd5\x05\x08\x7d\x53\xa6\x75\x9a\x2f\x23\x54\x15\x8b\xfa\x26\x2e\x1d\xb0\x49\xd2\xaa\x2e\xd3\xab\x6d\xdd\xb3\x59\x2b\x62\x5a\xf5\x26\x14\x39\x8a\x73\x34\x78\xf1\x0e\xbd\x79\x37\x40\x2f\x5f\xbc\x7b\xf3\x2e\x02\x26\xff\x7c\xf3\xfe\xf5\x0f\x1f\xde\xa3\x7f\xbe\xf8\xe9\xa7\x17\x6f\xdf\xbf\x79\xf5\x0e\xfd\xf0\x13\x9a\xfd\xf0\xf6\xdb\x37\xef\xdf\xfc\xf0\xf6\x1d\xfa\xe1\x3b\xf4\xe2\xed\x2f\xe8\x1f\x6f\xde\x7e\x1b\x21\x97\xd6\x2b\x57\x22\x77\xbb\x29\x41\x83\xa2\x44\x29\x58\xd3\x25\xde\x74\xef\x9c\xeb\x89\xb0\x28\x82\x48\xd5\xc6\xcd\xd3\x45\x3a\x47\x59\x9c\x2f\xb7\xf1\xd2\xa1\x65\x71\xed\xca\x3c\xcd\x97\x68\xe3\xca\x75\x5a\x81\x57\x2b\x40\x07\xb0\xc9\xd2\x75\x5a\xc7\xb5\x27\x3d\xd1\x0b\xa3\xe3\xef\x8b\x04\xb8\x85\x19\x67\x08\xbd\x48\xe2\x4d\x1d\x4c\x55\xce\xb3\x22\x77\x68\x1d\x97\x1f\xb7\x1b\x34\x1a\x5d\x1c\x1f\x4f\xbe\xf8\xf6\x87\xd9\xfb\x5f\x7e\x7c\x85\x56\xf5\x3a\xbb\x38\x9e\x84\x5f\x47\x93\x95\x8b\x93\x8b\xe3\xa3\xa3\x49\x9d\xd6\x99\xbb\xb8\xbf\x87\x01\x84\xdf\xc6\x6b\xf7\xf0\x30\x19\x07\x2a\x8c\xaf\x5d\x1d\xa3\xf9\x2a\x2e\x2b\x57\x4f\x07\xdb\x7a\x31\x32\x83\xfd\x40\x1e\xaf\xdd\x74\x70\x9d\xba\x9b\x4d\x51\xd6\x03\x34\x2f\xf2\xda\xe5\xf5\x74\x70\x93\x26\xf5\x6a\x9a\xb8\xeb\x74\xee\x46\xfe\x26\x42\x69\x9e\xd6\x69\x9c\x8d\xaa\x79\x9c\xb9\x29\xc5\xe4\x09\xa3\x65\x51\x2c\x33\xd7\x61\x93\x17\x75\x19\xe7\x55\x16\xd7\x6e\x70\x71\x3c\xa9\xea\x3b\x10\xeb\x6b\x74\x8f\x36\x71\x92\xa4\xf9\xf2\x0c\x91\x73\xd0\x78\x99\xe6\xfe\xf2\xe1\xf8\xaa\x48\xee\xd0\xfd\xf1\xd1\xa2\xc8\xeb\xd1\x22\x5e\xa7\xd9\xdd\x19\xaa\xe2\xbc\x1a\x55\xae\x4c\x17\xe7\xc7\x47\xb5\xbb\xad\x47\xa5\x03\xe3\x7a\x0e\xc5\xa6\x4e\xd7\xe9\x27\x57\x6d\x9c\x4b\xce\x8f\x8f\xae\xe2\xf9\xc7\x65\x59\x6c\xf3\x64\x34\x2f\xb2\xa2\x3c\x43\x5f\x2e\xfc\xcf\xf9\xf1\xc3\x71\x0c\xbc\x5b\x32\x21\xca\x25\xbc\x65\x99\xb8\x79\x51\x7a\xc7\x9c\xa1\xbc\xc8\x9d\x9f\x7e\xb6\x02\x6f\x47\xc7\x2b\x8a\x9a\xeb\x2e\x03\x4e\xed\x3c\xf0\x05\x87\xc0\xbc\x2f\xab\xed\x7a\x1d\x97\x5e\x85\x46\xc7\x51\xe6\x16\xf5\x19\x92\x5f\x9d\xef\x49\x3e\xc7\x04\xda\xc3\x71\xbd\x3a\x5b\xa4\x65\x55\x8f\xe6\xab\x34\x4b\xa2\xe3\x3a\xe9\xde\x03\x27\xef\x81\x33\x44\xbf\x3a\x47\xe3\xaf\x51\x0d\x8b\x5d\xe9\x21\xba\x2e\xae\x20\x45\x7c\x3d\x0e\x7c\xb2\xb8\xc7\x66\x7f\xfb\xe7\xb9\x04\x4d\xba\xf2\xd7\xc5\xe6\x0c\x31\xb9\xb9\xed\x28\x70\x55\xd4\x75\xb1\x3e\x43\x34\x90\x0f\xd9\x9c\xc1\x3f\x6f\x1b\xba\x73\x68\x95\x7e\x72\x67\x88\x11\xbf\xc8\x53\x6e\x5c\x30\x45\x5e\x94\xeb\x38\x3b\x3f\x3e\xba\x59\xa5\xb5\x1b\x55\x9b\x78\xee\x80\x7a\x53\xc6\x9b\xf3\xe3\x23\xb0\xfc\x22\x2b\x6e\x46\xb7\x67\x68\x95\x26\x89\xcb\x5b\xb7\xb5\x23\x67\xc8\x65\x59\xba\xa9\xd2\xea\x7c\xef\x20\x6b\x6d\x23\xc1\x23\xc7\x93\xf3\xe3\xa3\x1d\xee\x90\x00\x79\x1e\x1e\x39\xf9\x09\x28\x7c\x3c\x67\x69\x40\x86\x9f\xfb\xc8\x4d\x7b\x20\x1f\x3f\x1c\xaf\x21\x03\xdf\x1f\x1f\x25\x69\xb5\xc9\xe2\xbb\x33\x74\x95\x15\xf3\x8f\x30\x82\x7d\xc8\xf4\x4d\x42\xd9\xde\x24\x2d\xea\x7f\x76\x65\x12\xe7\x71\xd4\x87\xff\x55\x51\x26\xae\xdc\x3b\x60\x73\x8b\xaa\x22\x4b\x13\xf4\xa5\x9d\xc1\xbf\xf3\x47\x8e\xa3\xe4\xb0\xe3\x48\xd0\xd9\x0b\x33\x4a\x6b\xb7\xde\x6b\xd0\xc2\x93\xba\x35\x4c\xf9\x72\x91\x66\x75\x0f\x12\x67\xc1\x62\x8d\x2c\x3d\x21\x66\xb3\x99\xc7\xb4\x3f\x0e\x3a\xa0\x23\xe4\xab\xbd\xf0\xf3\x22\xcb\xe2\x4d\xe5\xce\x50\x7b\xe5\xd7\xf8\x2d\x0e\xe8\x97\xc4\xd5\xca\x25\xe8\xcb\x24\x86\x7f\x7e\xaa\x4f\x13\x75\xb9\xf7\xd6\x33\x51\xef\xe6\x21\xc2\x20\x1c\x76\x4e\x8d\xb3\x74\x99\x9f\x21\x88\xcb\xf3\x8e\x4e\x60\x12\x04\x7e\x80\xf0\x98\xad\xe2\x7c\xe9\x12\xb4\x28\x8b\x35\x22\x90\x9f\x59\x1b\x65\x4f\x62\xe3\xb3\x26\xee\x79\x59\x79\xca\x41\x88\x7b\xce\x5d\x94\x5e\x65\x71\xc0\x4b\xbd\x42\xd5\xf5\x12\x46\xae\x5d\x59\xa7\xf3\x38\x6b\x35\x58\xa7\x49\x92\x05\xdb\x85\x08\x3f\x18\x3b\x5d\x01\x1a\xa4\xd7\xc9\x59\x5e\xaf\x02\x72\x87\xec\xb4\xe3\x28\x43\xbe\x7a\x32\x81\x9f\xf6\x7c\x4f\x7c\x00\x37\xbf\x9a\x04\xb6\x9f\x2c\x4e\xa3\xfe\x6a\x71\xfa\xd8\xf0\x1e\x5e\x87\xc4\x68\xd4\xdc\x14\x55\x1a\x42\x2e\xbe\xaa\x8a\x6c\x5b\xb7\x2a\x62\x38\x67\xbc\x2b\xf1\xb2\xd8\x6e\x3a\x88\x0d\x39\x96\x62\x2d\x01\xb3\x47\x37\x45\x99\x8c\xae\x4a\x17\x7f\x3c\x43\xfe\xd7\x28\xce\xb2\x6e\x1a\x01\xd3\xb4\x43\x30\xf9\xb1\x57\x36\xa5\x1b\xb5\x7e\xc1\xe9\xbc\xc8\x9f\x46\x87\x6c\x02\x08\x46\x71\x55\x94\x75\x2f\xda\xd3\x1c\x32\xc5\xa8\x09\xfa\x5d\x18\x78\xe9\x56\xae\x13\x5f\x1d\x6d\x4b\x97\xc5\x75\x7a\xed\x20\xb5\x01\xae\x30\x0b\x01\xd8\xd9\x02\xd7\xc5\xe6\x39\x13\x1d\x05\x23\x90\x76\xf9\x88\x3e\x91\x10\x07\x6c\x3e\xcb\xa1\x85\x6e\x58\xba\x67\xf8\x70\xbc\x28\x8a\x27\x39\xc0\xc7\xcb\x53\x90\x87\x54\xd6\x75\xf8\xdc\xe5\xb5\x2b\x81\xcd\xff\x58\xbb\x24\x8d\xd1\x70\x1d\xdf\x8e\x1a\x9b\x28\x42\x36\xb7\x80\x91\xf1\xd7\x47\x78\x95\x26\xae\x4d\x1d\x7b\x63\x86\xe3\xf8\xe8\x01\x95\x6e\x5d\x5c\x87\x72\xe9\xa3\x73\x1b\x94\xc4\xb5\xab\xa0\x3e\xdc\x9f\x60\x47\x07\xb0\xdd\x9a\x3f\xde\xd6\x05\xf0\x39\x3e\xea\x41\x96\x9f\x46\x8f\x96\x05\xc4\x1f\x3a\xae\x8f\x0e\x21\x19\x38\x86\x53\xee\xd1\x11\x13\xe8\x3e\xaa\xbb\xa7\x03\xd0\x3b\x59\xf5\xa8\x63\x0d\x4a\x82\x41\x1f\x8e\x1f\x8e\x27\xe3\xa6\x62\x3a\x9a\x8c\x9b\x8a\x6f\xe2\x13\x5f\x91\x67\x45\x9c\x4c\x4f\x02\x8b\xe1\xe9\x79\x5d\x2c\x97\x99\x1b\x0e\x7c\xee\x1c\x9c\x9e\xcf\x7d\xf6\x7a\x97\x7e\x72\xc3\xd3\x13\x5f\xa6\x41\x68\x5d\x87\x16\x64\x3a\xa0\x98\x0e\xd0\xed\x3a\xcb\xab\xe9\xa0\xd3\x01\xdc\x70\x5f\xfd\x33\x42\xc8\xb8\xba\x5e\x36\x53\xce\x6e\xb3\x34\xff\x78\x68\x22\xb5\xd6\x8e\xfd\xe8\x00\x05\x4c\x4f\x07\x64\x80\x42\xf1\x08\x57\x5e\xfc\xe9\xe0\x00\xd4\x7c\xed\x78\x34\x49\xdc\xa2\xf2\x57\x47\xbe\x05\xfb\xae\xc8\xa0\xf6\x80\xda\xd7\xd3\x96\x28\x4d\xa6\x83\x85\xa7\x0e\xa0\x19\xca\x46\xe5\x16\x38\xe6\x45\xfe\xc9\x95\x45\xa0\xf9\x5b\x17\x38\x1e\x1d\x4d\x36\x71\xbd\x42\xc9\x74\xf0\x3d\x33\x12\x33\x86\xb8\xc6\x52\xae\x46\x54\x30\xac\x2e\x29\x25\xd8\x22\xf2\x9a\x53\xac\x67\x54\x60\x26\x11\x41\x04\x51\x05\xd4\x30\xf5\x5a\x4b\x4c\x57\x1c\x48\xec\x67\xb8\x9e\x93\x11\x23\x58\xc9\x11\xcc\x57\x23\x3f\x69\x04\x0c\xc2\xe5\xa7\x56\x8a\x2f\xbf\xfb\xee\x05\x21\x64\x30\x7e\x56\x12\xd5\xdd\x97\x2b\x44\x90\x24\x98\x19\x44\x90\xd2\x58\x8b\x6b\x2a\x0d\xd6\x73\x82\xa8\xc6\x42\x23\xbf\x1d\x82\x15\xd2\x7f\x86\xcb\xd7\x9e\xd9\x1c\xa6\x08\x10\x19\xe4\xa0\x02\xf3\x70\xe5\xa7\xfc\x0c\xdc\xe4\x9c\x8c\x3c\x9f\x56\x6c\x18\x19\xed\x27\x75\xc5\x9e\xbd\x60\xa6\x15\x7b\x32\x5e\x1e\xb0\xfe\xa8\x5a\x15\x65\x3d\xdf\xd6\xe0\xd4\xb2\xf8\xe8\x1a\xa3\x37\x77\xa3\xc6\xe7\xb4\xe7\x91\xae\xc7\xdc\xb5\xcb\x8b\x24\xd9\x79\xe9\x20\xf3\x11\x9c\xe0\x9b\x83\x9e\x6e\xd6\x3d\xb7\xb0\x5a\xc5\x9b\x1d\x04\x9e\x9a\x5e\x18\xad\x22\xf0\x96\x30\xca\x12\x86\x2e\x3d\x1a\x28\x13\xdc\xf4\xc9\x00\x0f\x46\xb4\x91\x11\x41\x97\x9c\x62\x65\xa9\x92\xcc\x46\x04\x79\xaf\x35\x4b\x08\x22\x11\x55\xd8\x58\x65\x29\x51\x88\xf4\x78\x90\x88\x52\x86\x95\x50\x44\x53\xe0\xa1\x70\xc3\xe3\x19\xb2\x96\x98\x58\xcd\x0d\x91\x68\xd6\x21\x4b\x81\x85\x90\x8a\x10\x83\x38\x61\x58\x49\xc9\x8c\xec\x6e\x74\x58\xb3\x7f\x0d\xbc\x7d\xde\x79\x7b\x3c\x02\xe6\xc5\x64\x0c\x76\xf9\x03\x2b\xa9\x9e\xe2\x5c\xf5\x34\x07\xd0\x46\x1e\xb4\xdc\x48\x65\x10\x89\x3c\x72\xa9\x25\xdc\x82\xea\x8c\x29\x2c\x24\x15\x4c\xa0\x19\x89\x98\xe0\xd8\x12\x2b\x34\x45\x1d\x1e\x4c\x1a\x4c\x2d\xe7\xcc\xa0\xce\x46\x1d\xea\x65\x47\x9c\x0e\x79\xd6\xb1\x43\x8f\xc7\xce\x66\x9d\xfd\xba\xd4\xbd\x4c\x5d\xbb\x77\x04\xef\xd9\x7d\xaf\x5c\xd7\xee\x0a\xf5\x6d\xf4\x8c\x9d\x7d\x24\x3d\xb2\xf3\x2e\xa2\xba\x16\xa7\x4c\x61\x2a\x05\xe5\x22\x62\x92\x60\x29\x2d\x35\x02\xcd\x80\x6c\x24\xb1\x1a\xc8\x14\x1b\xc3\x95\xe6\x88\x32\x8d\x39\x21\x52\x80\x99\x38\x26\x44\x51\xc6\x3c\x55\x6b\xa6\x08\x8b\x98\x14\x98\x06\xea\x8c\x32\x83\x85\xb2\x42\x00\x59\x62\xd6\x4e\x36\xd8\x52\x4b\x28\x98\x54\x61\x4a\x04\x31\x40\xb5\x58\x71\xc3\x39\x58\x54\x63\x42\x18\x11\x14\xcd\x28\xf7\x12\x59\xc5\xbc\xa1\x39\x53\x92\x53\x44\x21\x6f\x30\x63\x24\x4c\xb6\x88\x72\x8e\x29\x21\x44\x6a\x7f\x3b\xa3\x5c\x60\x61\xb9\xe6\xba\x19\x96\x58\x50\xc9\x95\xf0\x3c\xa4\xa4\x84\x21\xca\x15\xa6\x94\x31\x22\xfc\x7e\x4a\x4b\xe9\xb7\x53\xd8\x10\x4b\x84\xe8\x4a\x41\xb9\xc6\x4c\x1a\x45\xad\xd7\xc3\x1e\xa0\x0a\x2c\x75\xcb\xa2\x43\x06\x10\x04\xf5\xba\x54\x86\xcd\x9e\x4a\x38\x37\x9c\x79\x1b\x0b\xa9\xa9\xe0\x41\x0a\x6d\x94\x54\x2a\x62\xc2\x62\x4b\x0c\x55\xdc\x4b\x2c\x15\xd5\xda\x7a\x2a\xf1\xb6\xe8\x53\x0d\x96\xc1\x4d\x9e\x05\x31\x56\x33\x60\xc1\xb0\xa1\x82\x19\xe5\x2d\x61\x94\xb0\xdc\x46\x8c\x6b\xc8\x2f\x82\x98\x3e\x95\x63\xa6\xb9\x50\xde\x8a\x7b\x32\x93\x98\x04\xe1\xba\xb2\x51\x8d\x65\xcb\xd8\x60\x6a\x08\x13\x40\x25\xd8\x08\x65\xb9\x67\x61\xb1\xb6\x46\x53\x1e\x31\x22\x30\x6b\x0c\x27\x00\x4e\x96\x71\x11\x51\x6b\xb0\x82\xed\x04\xa2\x42\x60\xc1\x2c\x67\x26\xa2\x96\x63\xad\x40\x3f\x88\x78\x8d\x19\x55\xca\xd8\x88\x1a\x83\x8d\xb2\xdc\x18\x44\x25\xc1\x4a\x1b\x41\x69\x44\x8d\xc0\x26\x88\x4c\xa5\xc0\x86\x2b\xab\x79\x44\x0d\x6d\xc1\x32\x83\xb3\xcc\x5a\x29\xb9\x8c\xa8\x06\xa0\x5a\x69\x19\xa2\x8a\x63\xc5\x14\x15\x36\xa2\x5a\xec\xf0\xad\x0c\x16\x86\x4a\xc9\x22\xaa\x19\x56\x80\x58\x08\x06\xcd\x31\xe7\xca\x4a\x11\x51\x4d\xb0\xe0\x46\x6b\x85\xa8\xb6\x98\x52\x6e\x0d\x8f\x60\x9d\x52\x92\x13\x85\xa8\x91\x58\x1a\x6d\x80\x85\xd2\x98\x0b\x70\x1f\x9a\x51\xcb\x30\x51\x54\x33\x20\x2b\xcc\x28\x6c\x88\xc0\x00\x5a\x11\xae\x4d\x44\x95\xc4\x5c\x30\x23\x35\x62\x44\x7a\x29\xa8\x88\xa8\x12\x58\x05\xa5\x67\x8c\x32\xb0\x32\xd5\x9e\xca\x82\xf7\x18\xb5\x58\x5a\x43\x85\x8a\x40\x25\x6b\x21\x7e\x11\x63\x06\x53\xc5\xbc\xce\x7b\xea\x25\x13\x0a\x13\x09\x21\xfe\x2c\xd9\xca\xd6\xa9\xb3\x1e\x59\x63\x0d\x16\x92\x08\xa8\x5a\x32\xc1\x81\x6a\x31\x44\x13\x11\x08\xc0\xc7\x35\x31\xd6\x46\x8c\x50\x4c\x9a\x2c\x02\x19\x85\x51\x88\xbe\x88\x41\x0e\x0b\x50\x86\x10\x20\xda\x1a\xa3\x22\x46\x38\x96\x21\x31\x40\x14\x71\xcd\x34\x95\x5d\xea\x0c\x92\x84\x50\x9c\xf1\x47\x93\x0d\x96\x9c\x32\xad\x7b\x8c\x15\xc1\x60\x62\xc6\xbb\x52\x5c\x72\x48\x71\x84\x31\x00\x11\xd7\x58\x5b\x80\x00\x9a\x71\x48\x5b\x8c\x68\xa9\x23\x80\x35\x13\xc6\x1a\xc4\x99\xc1\x4a\x30\x6e\x44\xe4\xf3\x88\x8f\xea\x1e\x91\x61\x06\x8e\xa6\xc0\xa0\x43\x26\x98\x00\x95\xa1\x2e\x5b\x66\x30\x0b\x81\xd3\x95\x81\x29\xac\x83\xc0\x97\x1d\x89\x15\xc7\x42\xb4\xae\xf6\x89\x8a\x6b\xa9\x22\x45\xb1\xb1\x01\xb3\x1d\x53\x28\x1a\xec\x65\x25\xb5\x02\xee\x66\x1d\xa3\xfa\x41\x82\x19\x57\x8a\xb3\x1e\x03\xf0\x92\xe5\x5c\xeb\xfe\x6e\xe0\x52\x2d\x2c\x8d\x94\x68\x40\x21\xbc\x9f\x89\x36\x44\x47\x4a\x61\x0d\x33\xb5\xe9\x12\x21\xa8\x02\x88\x2f\xf7\x54\x4a\x48\x8b\x88\xcb\x2e\x06\xf7\xe4\x19\xa0\xdf\x28\x4e\xc0\x68\x7b\x32\xe4\x7f\xaa\x14\x33\x2c\xa2\x54\x63\xaa\x34\x87\xc2\x93\x4a\xcc\xb4\x10\x5c\x47\x10\xf2\xa2\x3d\x9b\x28\xc1\x4a\x29\x4e\x00\xc6\x14\x2a\x0e\x6b\x10\x25\x06\x73\x49\xac\xe5\x11\xd5\x12\xf3\x86\x6f\x87\x6a\x29\xd6\x21\xc0\x66\x1d\x32\x04\x1b\x6b\x72\x02\x85\x84\x1d\xa0\xc6\x38\xb6\x10\xfc\x12\x51\x26\x20\x46\x85\x14\x11\x13\xba\x0d\xfe\x19\x85\xa4\x48\xb4\x66\x3e\xf1\xd2\x76\xae\x84\x34\xce\xac\x08\x49\xba\x55\xee\xd0\x11\xfb\xcc\xc1\x0d\x3f\x03\xe4\x1f\x57\x2f\x8a\x72\x3d\x1d\xec\x9e\x5c\x0f\x19\x35\x58\x58\x9f\x0c\x11\x55\x04\x13\xff\x73\x8a\xfc\x83\xf0\xe1\x88\x46\x88\x9e\xa2\xfd\xf4\x51\x77\xfe\xa8\xbb\xe0\x51\x61\xb0\xaf\xb4\x77\x17\xbe\x09\x82\x46\xf6\x71\x0b\x94\x66\x6e\x5f\x79\x43\x73\xf9\xb8\xf2\x66\xb2\xab\xcc\xc1\xd2\xbb\x5d\x91\xa5\xb9\x9b\xc7\x9b\xe9\xc0\x3f\x2e\xeb\x91\xff\x67\x91\xe6\x2d\xfd\x49\x17\x43\x39\x62\x02\x53\x76\xcd\x34\xb8\x66\x4e\x90\xc2\x54\x21\x89\x0d\x40\x06\x53\x38\x59\x31\x6d\xae\x5f\x33\x6e\xe7\x1a\x73\x68\xae\x80\x3a\x12\xd8\xaa\xe6\xd2\x4f\xf8\xd9\xd7\x02\xf2\x1d\x44\x36\x0c\xf8\x0a\x85\x6b\x44\xf9\x6b\xf0\x9b\x9e\x51\xe3\x19\x73\xff\x5f\x87\xd5\x41\x80\x4f\x07\x5a\x2c\x40\xb2\x5f\x7d\x49\x99\x0d\x90\x82\x46\x8a\x60\x69\x90\x86\x3e\x8a\x5a\x4c\xa1\xcf\x63\xda\x5f\xbe\x66\xc2\x5e\xee\x16\x7d\x7a\xb6\xfb\x49\x33\xf7\x9f\xea\x7d\xba\xac\xdb\xce\xe7\x20\x00\x29\x6f\x20\x14\xa1\xdd\xe5\xe9\x93\x8e\xa8\xc7\x2e\xf4\x43\x3d\xc4\xfc\x21\x68\x3c\x6e\xfe\xbf\x30\xd2\x75\x04\xb4\x3f\x98\x32\x2a\x8c\x51\xbe\x23\x30\x00\x10\x23\xb4\xf6\x1d\x01\x9c\xc7\xdc\x5a\x26\x3c\x6e\x84\x35\xbe\xc8\xb7\x0a\x5b\x6b\xad\xe1\x01\x21\xcc\x70\xcd\xbb\xd4\x4b\xa8\x85\xac\xd5\x70\xe8\x77\xc8\x33\x5f\x39\x59\xe9\xcb\xe5\x3d\x99\x71\x8b\xa9\x26\x86\xed\xb6\x13\xac\x4b\xdc\x4b\x74\xb9\xa7\x52\xc6\x31\x15\x32\x64\xe6\x43\x54\x0a\x47\xbe\x91\x82\x46\x0c\x1b\xc1\xa8\x26\x56\xb8\x11\x15\x3e\x5d\x72\x65\x05\x1c\x7f\xfd\x91\xcb\x46\x1b\x09\x3a\xf6\x87\x66\x20\x83\x24\xc4\x12\x1d\x8d\x28\xd6\x54\x68\x6b\x0d\x73\x23\x22\x11\x89\x20\x58\x08\x63\xd6\xc2\x4d\xc7\x9e\x4d\xf2\x2a\xdd\xbc\xa6\x54\xd3\xcf\x74\x74\x94\x2a\xa8\x0c\x88\x6f\x64\x29\x55\x3e\xeb\x5b\x22\xac\xf2\x99\x5c\x45\x94\x52\x2c\x0c\x9c\x55\x08\x94\x64\xd2\x10\x62\x22\xca\x20\x5e\x19\x94\xa3\x70\x5c\xc1\xed\x25\x24\x66\x7f\xf1\x98\xe7\xee\xa6\x2b\x96\xb6\xe2\x4f\x35\x40\x42\x63\x43\x38\x05\x73\x5a\x81\x49\x38\xe8\x66\x02\x52\xa7\xb5\x86\x02\x59\x62\x1a\xca\x7b\x61\xb0\x15\x56\x4a\x19\xbc\x4f\x42\x01\x25\x2c\x16\x8c\x2a\xb2\xc3\x84\xa7\xce\x24\xc1\x94\x1a\x21\x0c\x60\x42\x63\x13\xc8\x70\x00\x28\x43\x18\xd3\x11\xf3\xe5\xaf\x2f\x1a\x24\xc5\x0c\xca\x58\x28\x7e\xac\xc5\x3c\xd4\x92\x33\xc9\x30\x23\xc6\x2a\x63\x22\x4e\x08\x16\xfe\xa4\x93\x1c\x73\xad\x7d\x33\xc1\x09\x45\x52\x60\x2d\x2c\x51\x5c\xf8\xdb\x19\x34\x55\x82\x69\xc1\x55\x18\xd6\x98\x28\xc1\x35\xb1\x9e\x85\x0a\x7d\x83\xd4\x58\x2b\xca\xbc\x76\x16\x3a\x4e\xce\x34\x9a\x49\x83\x85\x34\x44\x36\xe4\x46\x0a\xa8\x9f\x89\x56\x0c\x0e\x40\x0b\x2d\xdd\x53\xaa\xc6\x1c\xea\x19\xee\x59\xec\xc9\x0a\x9b\x46\xbd\x2e\x55\x62\xbb\xa3\x2a\x03\x3d\x2e\xf3\xa6\x37\x80\x4f\x1a\xa4\xe0\x52\x6a\x06\x93\x39\xb4\x37\x50\x84\x4b\x83\x19\x25\xbe\xae\x86\x60\x32\x8d\x2d\xfa\x54\xd1\x74\x61\xa0\x1e\x37\x9a\x43\x24\x18\x8d\x75\xa8\xc1\x24\x34\x2c\xdc\x0a\x49\x23\x66\x38\xd6\xa1\x8c\xeb\x52\xb5\xc5\xd6\xf7\x87\xb3\x1e\x95\x63\x16\x64\xeb\x8a\xa6\x74\xdb\x14\x49\x8b\x0d\xb3\x4c\x42\xb7\xa5\x28\x56\x4d\xf3\xaa\x28\x16\xc2\x17\x08\xe0\x92\x60\x35\xc5\xb1\xe4\x86\x09\xc2\x7d\xcb\xa7\x42\x81\xa0\x7c\xfd\xc4\x39\xb4\xd5\x42\x63\xc5\x84\xb0\x68\xa6\xa0\xdf\x91\xbe\xeb\x60\x02\xba\x15\x5f\xf0\x6b\x86\x39\xd3\x82\xfa\xc2\x83\x60\xee\xc5\xd5\x0a\x0b\x23\xad\xb6\xcc\x77\x76\xc1\x36\x33\x43\xb0\x12\x42\x0a\x0a\x54\x81\x65\x68\xcb\x0c\xd4\x3b\x92\x4a\x45\x23\xc6\x59\x8b\x6c\x4b\x30\xe5\x44\x4a\xf0\x05\x07\xb6\xa1\xd4\xb2\x02\x5b\x23\xad\x02\x79\x99\xc1\x32\x74\x33\x10\xc2\x5a\x31\x28\xf6\x99\xf6\x8d\x26\x1c\x8a\x44\x43\xc9\x69\x64\x78\xd0\x41\xda\xa7\x00\x94\x63\x4d\x89\x66\x26\xf4\x91\x06\xf6\x43\x94\x11\x2c\x20\xd6\x64\xc4\x18\xd4\xfd\x54\xc0\x69\xc9\xb4\x97\x82\xf9\xfa\xcb\x04\x85\x67\xd0\xdf\x1b\x66\x29\xd4\xfa\x8c\x63\x11\xdc\x06\x6d\x24\x13\x9a\x36\x93\x59\xd3\x19\x0a\x8b\x0d\xa5\x52\xf4\xa8\x97\xd0\x89\x69\x22\xa4\x35\xcf\x92\xa1\x5c\x6b\x1b\xf0\x0e\x59\x12\x48\x8f\x92\x86\xd6\x90\xd0\xf0\xdc\x88\xed\x1e\x45\x68\x82\x09\xb5\x96\x48\xdf\xee\xcb\x26\x7b\x50\x4d\xa1\xc8\xf5\xcf\x38\x04\x36\x01\xc1\xd0\x45\x6a\x66\x0c\x14\x9d\x52\x62\x19\xf2\x01\xd5\x0a\x13\xe6\x1b\xc3\x0e\x75\x46\x75\x53\x54\xf6\xc8\xd4\x10\xdf\x68\x43\x4a\xe9\x30\x36\x14\x1b\x46\x01\xec\x7b\x19\x2e\x01\x49\x5a\x52\x66\x95\x6f\x86\x4c\xe8\xb3\x67\xa0\x28\x34\xc9\x0a\x4a\x5f\xc8\x45\xbe\xd2\xf6\xfd\x82\xa5\xdc\x86\xae\x8e\x86\x84\xd0\xa3\x6a\xcc\x9a\xc6\xa9\x47\x96\x58\xb4\xcd\xc5\x8e\x31\x85\x44\x1a\x22\xa6\x23\x05\xb4\xc0\x3a\x48\x7c\xb9\x17\x19\xfc\xb8\x7b\xdc\x63\x08\x66\x84\x79\x16\xdc\x62\xdd\x3c\x1a\xd8\x9b\x82\x72\x1b\x0c\x26\x04\x83\xea\xdf\x3f\x65\xd8\x9b\x35\x0c\x53\x6c\x8c\x54\xdc\xf6\x79\x10\x4c\x9a\x56\xad\xbb\x21\x38\x95\x71\x0b\x3d\xb5\x60\x3b\x10\x81\xff\x99\x26\x70\xee\x08\x60\x1e\x9e\x93\x74\xa9\x12\xc2\x18\xfa\x80\xcb\x2e\x59\xef\x9e\x3a\x5c\x76\x80\xd8\x21\xcf\x8c\xc1\x92\x32\x62\xe1\x84\xdb\x93\x01\x64\x54\x32\xe8\xdd\xa8\x11\xd8\x86\x67\x54\x5c\x61\xcb\xb8\xef\x7e\x7c\xeb\xdf\x60\x8b\x33\xcc\xa9\xe4\x44\x43\xe8\x50\xcc\x82\x03\x39\xf1\xd1\x2c\x03\x43\xb8\x13\x12\xdb\x10\x56\x33\xb8\x85\x73\x20\x24\x00\x2e\xb1\x94\x60\x4e\x16\x31\xcd\xc0\x93\x86\x6b\x24\x14\x04\xa4\x50\x04\xce\x25\xda\x46\xfa\x4c\x28\xac\xa4\xd2\x4c\xa9\x50\xc3\x34\x93\x35\xa6\x44\x71\x42\x6c\x48\xc6\x61\xd7\x83\x27\x69\xb7\xcd\x19\xcd\x8a\xcd\xdd\xae\xd2\x6b\x4b\xc1\x43\x5f\xa7\x1c\x2e\x3f\x05\x81\x1a\x48\x59\x19\x21\xc6\xfe\xb8\xff\xe9\xce\x1f\x75\x17\xfc\xb9\xfe\xe7\xc3\x06\xc5\x65\x59\xdc\x3c\xee\x81\xb6\x9b\x91\xa7\x3f\x23\xe5\x08\x4e\x11\xc6\xd0\x88\x11\x83\x29\x3b\xed\xf7\x2f\x9d\x25\xeb\xb8\x2e\xd3\xdb\x21\x66\x4c\x50\xee\xbf\xfd\xc1\x14\x4e\x7b\xc4\xb9\xc4\x4a\x23\xaa\x04\xe6\xf2\xf4\x71\xad\x4c\x06\x50\xb6\xac\x47\x10\x64\x54\x23\x41\x19\x26\x74\x35\x62\x06\x1b\xa6\x9b\x5f\x19\x15\x58\x50\x31\x62\x50\xbf\x49\x74\xe8\x0e\x85\xbb\x03\x0d\x07\xe8\xfe\x6d\x71\x93\x1f\xd6\x3e\x29\x6e\xf2\xff\x94\xfe\xa3\xbe\x01\x00\xb3\x96\xff\x77\x1b\x60\x32\x6e\xbf\x0c\x9c\x8c\xab\x6b\x4f\x9b\x84\x77\x91\xc2\xf0\x8a\x86\xf9\xf7\xf7\x65\x9c\x2f\x1d\xfa\x4b\x1a\xa1\xbf\xcc\xcb\xed\xfa\x0a\x9d\x4d\x11\x7e\x59\xba\x38\xf1\xb7\x0f\x0f\x93\x18\xad\x4a\xb7\x98\x0e\x9a\xf7\xe2\xc2\x34\x7c\x99\xe6\x1f\x1f\x1e\x06\x17\x7d\xea\x7b\x77\x5b\x3f\x3c\x4c\xc6\xf1\xc5\xfd\x7d\xba\x40\x39\x70\x46\xe4\xe1\x61\x7c\x7f\xef\xf2\xe4\xe1\xa1\xf9\x15\x44\x0c\x42\x84\x6f\x63\x83\x60\x93\x75\x9c\xe6\xcd\x97\x99\xe9\x35\x9a\x67\x71\x55\x4d\x07\x6b\x57\xc7\x8d\x03\x3c\x19\x3c\xd8\xbc\x19\xb6\xf3\x4b\xb5\x89\xf3\xee\x7c\xff\x12\xce\xe0\x62\x92\xe6\x9b\x6d\x8d\xea\xbb\x8d\x9b\x0e\x6a\x77\x5b\x0f\xd0\x26\x8b\xe7\x6e\xe5\xbf\xf1\xf2\x7d\x5e\xed\xca\x41\xdb\xf3\xf9\xeb\x22\xff\xe8\xee\xb6\x9b\xfd\x17\xc2\x27\x17\x93\x31\xf0\x6f\x4d\x9c\xa4\xd7\xad\x91\xdb\xab\x8e\xb4\x59\x5a\xd5\x69\xbe\x6c\x05\x0e\xef\xee\xc4\x65\x1a\x8f\x12\x57\xcd\xcb\xf4\xca\x25\x57\x77\x4f\x15\xa8\xdb\xb7\x10\xfd\x4d\xb9\x2b\xf1\xeb\xd5\xc5\x64\xdc\xa9\xfe\xbb\xfd\x49\xeb\x99\xbf\x55\x45\x59\x4f\xf3\x78\xed\x92\xb4\xf4\xaf\x51\xfd\xd5\x7f\x77\x3d\x8d\xab\xf9\xa0\x95\xcb\xbf\x77\xe1\xdf\x5b\x08\xdf\x6b\x5f\xf8\x6f\xb1\x9b\xc1\xba\xd8\xec\xbe\x6a\xa6\x6e\xbd\xff\x06\x1a\x4b\xb8\xeb\x7f\xd7\x7d\x9d\xba\x9b\x97\xc5\xed\x74\xe0\xbf\xec\x65\xd8\x32\x46\xad\x40\x0a\x13\x2e\x8d\xb1\x76\x70\x31\xd9\x56\x0e\xf9\xef\xb2\xcf\x82\x84\x5f\xee\xf2\xcd\xc5\x64\xbc\xad\xdc\x45\x80\x65\x57\x84\xf0\xb6\xc4\x7f\x56\x8a\x4e\xdc\xf7\xe5\x18\xc7\x3b\xab\x7e\xc6\xba\x07\xac\xda\xd8\xf2\x6d\xbc\x76\x1d\x26\x7f\xd2\x63\x55\xfa\xe9\x33\x3c\xdf\xa5\x9f\x3e\xc3\xb3\x9d\xdc\xbe\xe3\x31\x78\x6e\x93\x3a\xfd\x9c\xe0\xe1\x15\x5a\x97\xfc\x3b\x1b\x75\x26\x4c\xc6\x3b\xa8\x02\xb5\x0b\xe1\xab\x22\xb9\x3b\x84\xe7\x04\xd6\x27\xdd\xfb\x27\x82\x63\xbc\xd7\xa6\x1f\xda\xcb\x62\xbb\x19\x5c\xfc\xbd\x40\xdb\x4d\x37\x26\xfd\xf6\x5d\x05\x7a\xfc\xff\xba\x4e\xe2\x6a\x75\xfe\x88\xfc\x54\xaf\x3f\x3b\xaf\x33\xa1\xa3\xff\xfd\xfd\x08\x85\x64\x8a\x5f\xe5\x75\x99\xba\x2a\xe4\x39\xaf\x7e\xcb\xc4\x3f\x7a\x3c\xa0\xfb\x73\x26\x01\xa6\xe9\x02\xe1\x37\xd5\xb7\x69\xd9\xf2\x6b\x5e\x40\x69\x03\x25\x04\x47\x1b\x2a\xf4\x0f\x22\x85\x53\x38\x93\x0e\x46\x47\xf3\x6a\x48\x2f\x32\xba\x82\xb8\xac\x72\xff\x25\x32\x30\x25\x11\x67\xfc\xa0\x0c\x69\xb0\xf0\x33\x12\xb4\x87\xc7\x13\x60\x40\x78\x0e\x2e\x1e\x9f\x55\xf8\xc3\x4f\x97\x9d\x43\x0a\x5f\xba\x78\x11\x8e\xa7\x3e\x7a\xba\xe6\x3f\x6c\x72\x00\x42\x12\xd7\xf1\x28\x44\xd2\x60\x44\x0f\xe2\xe5\x89\x99\x1e\xaf\xbb\xbf\xc7\x10\xd7\x20\xd4\x04\xc2\xff\x62\x47\x98\x8c\xfd\xfd\x13\x6e\x1d\x95\x5b\xd1\xbe\x2f\x92\xf7\xe9\xda\xa1\xff\x85\xe2\x45\xed\xca\x57\x9b\x62\xbe\x42\xbd\x2d\x9f\x62\x16\xd2\x80\x7f\xc3\x0b\x2e\xbc\x1c\x2d\x97\x60\xa0\xce\xed\x64\x0c\x73\x9e\x4a\xf2\x58\xaf\x27\x9b\xfc\xdf\xff\xfd\x7f\x3e\x27\xfe\xbf\x19\x4c\x9d\xa5\x93\x71\x27\x9d\x4c\xc6\xfe\x4c\xed\x1f\xc1\x93\x71\x5b\x3a\x4c\xe0\x90\xdd\xd4\x7e\xf8\x3a\x2e\x51\x38\xc5\x5f\x65\x68\x8a\x92\x62\xbe\x5d\xbb\xbc\xc6\x4b\x57\xbf\xca\x1c\x5c\xbe\xbc\x7b\x93\x0c\x9b\x93\xfe\xe4\xf4\x1c\x16\xb5\x0b\xf0\xa2\x98\x6f\xab\x61\x43\xdc\xe6\xf3\x3a\x2d\x72\xd4\x16\x05\xfe\x55\xb3\xb0\xc3\xef\x68\xba\xdb\x05\x5f\xc7\xd9\xd6\xe1\xba\x4c\xd7\xc3\x53\x5c\x17\x97\xc5\x8d\x2b\x67\x71\xe5\x1a\x3e\x7e\x81\xcb\xdc\xba\xea\xca\xf3\xfb\xd6\x95\x77\xef\x5c\xe6\xe6\x75\x51\xbe\xc8\xb2\xe1\x49\x5d\x62\x08\x85\x46\xa4\x23\xbf\x02\x2f\x8a\xf2\x55\x3c\x5f\x0d\x5b\x61\x86\x2e\x6b\xe5\x38\x4a\x17\x68\xf8\xc5\xef\xbb\xdb\x23\x97\x61\xff\xc2\x18\x6e\xde\xfb\x43\x53\x74\x72\x72\xde\x0c\x96\xae\xde\x96\x79\x73\xd7\xd8\x18\x04\x83\x28\xf2\x96\x72\x59\x5f\xa6\xe1\x89\x7f\x5b\xb4\x15\x67\x37\xf9\xe7\x18\x66\x87\x65\x18\xea\xab\x59\xf8\x6b\x85\xcf\x18\xc0\x4b\xda\xac\xc5\x69\x9e\xb8\xdb\x1f\x16\xc3\xdf\x4f\xd1\x17\xd3\x29\x1a\xd1\x3f\xa7\xc0\x83\x07\xe3\x67\xa7\xe6\x45\xee\x4e\x7a\x1a\x3e\x04\x01\x1e\x7a\xee\xcc\x8a\x79\x9c\xa5\x9f\xdc\xb7\x4d\x64\x0c\x5d\x84\xbc\x50\x11\x8a\xcb\x56\x18\x90\xd8\x75\xd5\x43\xd3\xe9\xd4\xbf\xc1\xbe\x48\x73\x97\xec\x64\xee\x9a\xf5\x61\xe7\xed\x04\x2c\xe4\x6e\x10\x6c\x31\x74\x80\xbd\x17\x75\xf3\xd7\x38\xc3\x93\x36\x22\x4f\x4e\x1b\xf3\xc0\x5e\x69\xf5\x36\x7e\x3b\x4c\x4e\x77\x8c\x1f\xb1\xe8\x48\xd2\x35\xea\x93\x65\x87\xfc\x1c\x3e\x1f\x69\x83\x12\xef\x29\x68\x41\xdf\xd5\x65\x9a\x2f\x87\xbf\xfe\x16\xa1\xfb\x24\xbe\x3b\x43\x03\x36\x4a\xd2\x65\x5a\x0f\x22\xb4\x2e\xf2\x7a\xd5\xa3\xdc\xb9\xb8\x3c\x43\x83\x7c\xbb\x76\x65\x3a\x1f\x44\x68\x55\x6c\xcb\xfe\x9a\x34\xdf\xd6\xae\x47\xaa\xdc\xbc\xc8\x93\x0e\xa9\xeb\x19\xb0\x18\x18\xe4\x32\xad\x40\xb0\x17\x65\x19\xdf\xe1\x4d\x59\xd4\x05\x14\xf1\xb8\xca\xd2\xb9\xc3\xf3\x38\xcb\x86\x07\xa2\xb9\x7a\x79\xf7\x3e\x5e\x42\x31\x36\x1c\x00\x93\x41\x63\xd5\x96\xe1\x2e\x82\x1e\xbb\xfd\xf4\xfc\xb8\xdd\x7c\xe9\xea\x0f\x65\xf6\x63\x5c\xc6\x6b\x57\xbb\x12\x62\xbb\x05\xcb\xa3\xa1\x61\xe5\x2f\xbb\xa9\xa0\xfa\x31\x5e\xba\x0f\x3f\x5d\xa2\x29\xba\x49\xf3\xa4\xb8\xc1\xb0\x13\x2c\xc6\x95\x8b\xcb\xf9\x0a\x57\xdb\xab\x2a\x98\x98\x9e\x46\x7e\x5d\xf5\xe1\xa7\xcb\x9f\xa1\x41\xb8\xca\x1c\x64\x85\x96\x07\xae\x36\x59\x5a\x0f\x4f\xfe\x7a\xd2\x4e\xdc\xed\xfc\xd6\xbf\xb9\xed\xfd\x1e\x04\x3f\x5a\x14\x25\x1a\xa6\x68\x8a\xc8\x39\x4a\xd1\x04\xf5\x98\xe2\xcc\xe5\xcb\x7a\x75\x8e\xd2\x6f\xbe\xd9\x81\xa3\xcf\x0d\xf6\xed\x2e\xf9\x35\xfd\xad\xdd\x7f\x7a\xd2\x58\x27\xa0\xac\xbf\xee\x57\xf2\x9b\x0f\x86\xbe\x29\x5a\xe4\xa1\x47\x93\xe9\x6f\xfd\xc8\x41\x7f\x43\x75\xb9\x75\xe8\x0c\x25\x6e\x5e\x24\xee\xc3\x4f\x6f\x66\xc5\x7a\x53\xe4\x2e\xaf\x1f\x6f\x44\x7f\x3b\x7d\x0a\xe4\x87\x7e\x72\x6e\xde\xdc\xf5\x87\x0c\x2c\x3a\xdd\x7b\xc6\x9f\xbf\x68\xfa\xc4\x87\x27\x7e\xe0\xe4\x51\x76\x06\x2c\x1d\x3e\x30\xaa\x97\x77\xb3\x96\x7d\x67\xa3\xf3\xd6\x0b\x43\x60\xe1\x1d\x11\xa1\x60\x76\x9f\x4e\xc3\xda\xbd\x23\xd0\x04\x1d\x72\x0a\x2c\x9e\x6f\xcb\xf2\x75\xe9\x16\x9d\x75\xe0\x0d\x28\x6c\x76\xc1\x3e\x0c\xe5\xc4\xf4\x04\x7a\xca\x93\xd3\x7b\xd4\x98\xdd\xaf\x5f\x2d\xd1\x74\xc7\x05\x97\xce\x77\xbc\xc3\x30\x35\x42\x27\x31\xac\x38\xdf\xa5\xce\xfe\x0e\xb0\x72\xb5\xec\x9f\x0c\x9d\xed\xe2\x3f\xbd\x5b\x1c\x36\x0b\xf2\xfd\x1b\xbb\x1d\x72\x6b\xe9\xe2\x04\x50\xf9\x5d\x9a\x85\xd7\xb0\xa1\x52\xea\x86\xdd\x36\x4f\xbd\xbf\x7e\x3d\x79\x09\x9b\xfe\xc3\x7f\x7e\xef\x3f\xff\xee\x3f\xdf\xfb\xcf\x1f\xfd\xe7\x2b\xff\xf9\x2f\xff\xf9\xcb\xcb\x93\xdf\xf6\x9e\x0f\xf1\xe3\x6f\x6f\x56\x69\x16\xf6\x41\x17\x53\x44\x09\x13\xfb\xc0\x01\xe2\x38\x10\x1b\xd1\xbf\xf9\x26\xed\x66\xfd\x06\xfc\x9b\xb8\xac\xdc\x77\x59\x11\xd7\x41\x60\x5c\x17\xdf\xa5\xb7\xce\xbf\x47\xff\x0d\x3a\x41\x27\xe8\x9b\x20\xf9\xaf\xe9\x6f\x4d\x02\xec\xa9\xdd\x7d\xef\xbc\x9b\x63\xd2\x4f\xee\x79\x70\xee\xf2\x1f\x4c\x1b\x9c\x76\xd3\xc3\x5e\xc5\x90\x22\x80\xcf\xc1\xd4\xb0\xda\xae\xe3\x1c\xf6\x45\xd3\xc3\xb6\xf7\x0e\x4c\xf3\xdc\x95\xaf\xdf\x7f\x7f\xd9\xba\xf7\xe9\x08\x9a\xa2\x1d\xaf\x8e\x77\xc3\x63\xa9\xb6\x4c\x9b\x8c\x43\x6d\x37\x19\x87\x3f\xc8\xfc\x7f\x01\x00\x00\xff\xff\xd1\xe7\x7f\xef\x40\x3c\x00\x00"),
+			compressedContent: []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xbd\x5b\xeb\x76\xdb\x46\x92\xfe\x2d\x3d\x45\x87\x99\x8c\xa8\x98\x80\xd0\xb8\x43\x17\x67\x65\xda\x19\xfb\x0c\xe3\xcc\x89\x9d\xcc\xc9\xe4\xe4\x07\x44\xb4\x44\x8c\x41\x82\x01\x40\x5d\xac\xd5\x39\xfb\x10\xfb\x84\xfb\x24\xfb\x55\x35\x40\x36\x28\xca\x71\xe6\xec\xac\xec\x23\x01\x85\xee\xea\xba\x7c\x55\x5d\x05\x36\x4f\xbf\xb0\x2c\xb1\x7f\x74\x24\xc6\xe5\xf2\xae\xca\xaf\x66\x8d\x70\x1d\x19\x88\xef\xd2\xa6\x99\xa9\x1b\xf1\xba\x2c\x1a\x91\x2e\x32\xf1\x7e\xa6\xc4\x38\xcd\xb2\x3b\x71\xbe\x6a\x66\x65\x55\x63\x12\xcd\x9b\xe4\x53\xb5\xa8\x55\x26\x56\x8b\x4c\x55\x02\x93\xc4\xf9\x32\x9d\xe2\x4f\xfb\x64\x24\x7e\x52\x55\x9d\x97\x0b\xe1\xda\x8e\x18\xd2\x80\x41\xfb\x68\x70\x78\x42\x2c\xee\xca\x95\x98\xa7\x77\x62\x51\x36\x62\x55\x2b\xf0\xc8\x6b\x71\x99\x17\x4a\xa8\xdb\xa9\x5a\x36\x22\x5f\x88\x69\x39\x5f\x16\x79\xba\x98\x2a\x71\x93\x37\x33\x5e\xa7\xe5\x62\x13\x8f\x9f\x5b\x1e\xe5\x45\x93\x62\x78\x8a\x09\x4b\xdc\x5d\x9a\x03\x45\xda\xb4\x42\xd3\xcf\xac\x69\x96\xc7\x47\x47\x37\x37\x37\x76\xca\x02\xdb\x65\x75\x75\x54\xe8\xa1\xf5\xd1\xe4\xcd\xf8\xd5\xdb\x77\xaf\x2c\x08\xdd\x4e\xfa\x71\x51\xa8\xba\x16\x95\xfa\x6d\x95\x57\x50\xf8\xe2\x4e\xa4\x4b\x08\x35\x4d\x2f\x20\x6a\x91\xde\x88\xb2\x12\xe9\x55\xa5\xf0\xac\x29\x49\xe8\x9b\x2a\x6f\xf2\xc5\xd5\x48\xd4\xe5\x65\x73\x93\x56\x8a\xd8\x64\x79\xdd\x54\xf9\xc5\xaa\xe9\xd9\xac\x13\x11\x9a\x9b\x03\x60\xb5\x74\x21\x06\xe7\xef\xc4\x9b\x77\x03\xf1\xe2\xfc\xdd\x9b\x77\x23\x62\xf2\xf7\x37\xef\x5f\x7f\xff\xe3\x7b\xf1\xf7\xf3\x1f\x7e\x38\x7f\xfb\xfe\xcd\xab\x77\xe2\xfb\x1f\xc4\xf8\xfb\xb7\x2f\xdf\xbc\x7f\xf3\xfd\x5b\xdc\x7d\x2b\xce\xdf\xfe\x2c\xfe\xfa\xe6\xed\xcb\x91\x50\xb0\x18\xd6\x51\xb7\xcb\x8a\x34\x80\x98\x39\x59\x53\x65\x6c\xba\x77\x4a\xf5\x44\xb8\x2c\xb5\x48\xf5\x52\x4d\xf3\xcb\x7c\x0a\xd5\x16\x57\xab\xf4\x4a\x89\xab\xf2\x5a\x55\x0b\x68\x24\x96\xaa\x9a\xe7\x35\x79\xb5\x26\x74\x10\x9b\x22\x9f\xe7\x4d\xda\x30\xe9\x91\x5e\xb6\xd8\xff\xae\xcc\x88\x9b\x1e\x71\x2c\xc4\x79\x96\x2e\x1b\x6d\xaa\x6a\x5a\x94\x0b\x05\xff\x55\x1f\x56\x4b\x61\x59\xcf\xf7\xf7\x4f\xbf\x78\xf9\xfd\xf8\xfd\xcf\x7f\x7b\x05\x3f\xcd\x8b\xe7\xfb\xa7\xfa\xcf\xde\xe9\x4c\xa5\x19\xfe\xee\x9d\x36\x79\x53\xa8\xe7\xf7\xf7\xf4\x40\xd8\x6f\xd3\xb9\x7a\x78\x38\x3d\xd2\x54\x7a\x3e\x57\x0d\x50\x30\x4b\xab\x5a\x35\x67\x83\x55\x73\x69\xc5\x83\xcd\x83\x05\xc6\x9f\x0d\xae\x73\x75\xb3\x2c\xab\x66\x00\xb8\x2c\x1a\xb5\xc0\xc0\x9b\x3c\x6b\x66\x67\x99\xba\x86\xe0\x16\xdf\x8c\xe0\x4a\xf8\x31\x2d\xac\x7a\x9a\x16\xea\x4c\xda\xce\x23\x46\x57\x65\x79\x55\x28\x83\x0d\xb0\x5c\xa5\x8b\xba\x48\x1b\x85\xc1\xa7\x75\x73\x47\x62\x7d\x2d\xee\xc5\x12\x41\x04\x13\x1e\x0b\xe7\x84\x34\xbe\xca\x17\x7c\xf9\xb0\x7f\x51\x22\xb8\xee\xf7\xf7\x2e\xc1\xc3\xba\x4c\xe7\x79\x71\x77\x2c\x6a\x30\xb1\x6a\x55\xe5\x97\x27\xfb\x7b\x8d\xba\x6d\xac\x4a\x91\x71\x99\x43\xb9\x6c\x60\xf4\x8f\x0a\x9e\x52\x19\x9e\x5f\xa4\xd3\x0f\x57\x55\x09\xeb\x5b\xd3\xb2\x28\xab\x63\xf1\xe5\x25\xff\x9c\xec\x3f\xec\xa7\xc4\xbb\x23\x3b\x4e\xa8\x32\xaf\x63\x99\xa9\x69\x59\xb1\x63\x8e\x11\x84\x0b\xc5\xc3\x8f\x67\xe4\xed\xd1\xfe\x4c\x8a\xf6\xda\x64\xe0\xc9\x64\xaa\xf9\x92\x43\x68\xdc\x97\xf5\x6a\x0e\x7d\x58\x85\x56\x47\xab\x50\x97\xcd\xb1\x08\xbe\x3a\xd9\x90\x38\xc7\x68\xda\xc3\x7e\x33\x3b\xbe\xcc\xab\xba\xb1\xa6\xb3\xbc\xc8\x46\xfb\x4d\x66\xde\x13\x27\xf6\xc0\xb1\x90\x5f\x9d\x88\xa3\xaf\x45\x43\x93\x21\x08\x41\x74\x5e\x5e\x50\x8a\xf8\xfa\x48\xf3\x29\xd2\x1e\x9b\xcd\xed\xe7\x73\xd1\x9a\x98\xf2\x37\xe5\xf2\x58\xb8\xc1\xf2\xd6\x50\xe0\xa2\x6c\x9a\x72\x0e\x66\x9a\xbc\xcb\xe6\x2e\xfd\x63\xdb\xc8\xb5\x43\x6b\xf8\x09\xbc\x1c\x9e\xc4\x94\x1b\xa5\x4d\xb1\x28\xab\x79\x5a\x80\x7a\x33\xcb\x1b\x65\xd5\x48\x46\x8a\xa8\x37\x55\xba\x04\x95\x2c\x7f\x59\x94\x37\xd6\xed\xb1\x98\xe5\x59\xa6\x16\x9d\xdb\xba\x27\xc7\x42\x15\x45\xbe\xac\xf3\xfa\x64\xe3\xa0\x24\x49\x5a\x09\xb6\x1c\xef\x60\xd0\x1a\x77\xc2\x27\x79\x1e\xb6\x9c\xfc\x08\x14\x1c\xcf\x45\xae\x91\xc1\x63\xb7\xdc\xb4\x01\x32\x06\xcc\x29\x03\x83\x88\x44\xb6\x2c\x52\x80\xf8\xa2\x28\xa7\x1f\xe8\x89\xcd\x21\xd3\x37\x89\x74\x37\x26\xe9\x50\x8f\x1d\x23\x4b\x17\xe9\xa8\x0f\xff\x8b\xb2\x82\x18\x1b\x07\x2c\x6f\x91\x58\x8b\x3c\x83\xb2\x63\xfa\x77\xb2\xe5\x38\xe9\xec\x76\x9c\xa3\x75\x66\x61\x2c\x98\x7c\xbe\xd1\xa0\x83\xa7\x54\x73\x1a\xf2\x25\x76\xa1\xa6\x07\x89\x63\x6d\xb1\x56\x96\x9e\x10\xe3\xf1\x98\x31\xcd\xdb\x81\x01\x3a\xc7\xf9\x6a\x23\x3c\xfc\x50\xa4\xcb\x1a\x7a\x77\x57\x3c\x87\x97\xd8\xa1\x5f\x96\xd6\x33\xe4\xc8\x2f\xb3\x94\xfe\xf1\x50\x4e\x13\x4d\xb5\xf1\xd6\x13\x51\xaf\xa6\x3a\xc2\x28\x1c\xd6\x4e\x4d\x8b\xfc\x0a\x6e\xa2\xb8\x3c\x31\x74\x22\x93\x08\xf2\x03\x85\xc7\x78\x86\x7c\x8f\x45\x2f\xab\x72\x0e\x84\x20\x3f\xbb\x5d\x94\x3d\x8a\x8d\x4f\x9a\xb8\xe7\xe5\x90\x29\x3b\x21\xce\x9c\x4d\x94\x5e\x14\xa9\xc6\x0b\xe8\xf5\xf5\x15\x3d\x81\xae\x0d\x36\x8f\xa2\xd3\x60\x8e\x48\x28\xb4\xed\x74\x84\xef\x8c\x1d\x53\x80\x16\xe9\xc8\x0d\x8b\x66\xa6\x91\x3b\x74\x0f\x0d\x47\xc5\xce\x57\x8f\x06\x78\x87\x3d\xdf\x3b\x1c\xc0\xed\x9f\x36\x81\x6d\x06\xfb\x87\xa3\xfe\x6c\xff\x70\xdb\xf0\x0c\xaf\x5d\x62\xb4\x6a\x2e\xcb\x3a\xd7\x21\x97\x5e\x00\x56\xa8\x01\x5a\x15\x6d\xda\x67\xd8\x95\xf6\x55\x89\x4d\x72\x83\x58\x9d\x63\xa5\x1d\x05\x84\xd9\xbd\x1b\x80\xc8\xba\xa8\x54\xfa\x01\x76\xa4\x3f\x58\xba\x30\xd3\x08\x99\xa6\x7b\x44\x83\xb7\xbd\x82\x1a\xc1\xea\xfc\x62\xe7\xd8\xd0\x1e\x47\x47\xd0\x06\x10\x3d\xb5\x6b\xec\x9f\xbd\x68\xcf\x17\x94\x29\xac\x36\xe8\xd7\x61\xc0\xd2\xcd\x94\x11\x5f\x86\xb6\x95\xc2\x4e\x99\x5f\x2b\x4a\x6d\x84\x2b\xdb\xd5\x01\x68\x2c\x61\xe3\xc1\x53\x26\xda\xd3\x46\x70\xba\xe9\x96\x7c\x24\xa1\xad\xb1\xf9\x24\x87\x0e\xba\x7a\xea\x86\xe1\xc3\xfe\x65\x59\x3e\xca\x01\x1c\x2f\x8f\x41\xae\x53\x99\xe9\x70\x54\x3f\x98\x4c\x6c\xfe\x63\xae\xb2\x3c\x15\xc3\x79\x7a\x6b\xb5\x36\x09\x1d\xb0\x20\x8c\x1c\x7d\xbd\x67\x23\xb7\xab\x2e\x75\x6c\x8c\xa9\xb7\xe3\xbd\x07\x58\x68\x0e\x17\x72\xb9\xf4\x41\xa9\x25\x32\x43\xa3\x6a\xaa\x0f\x37\x3b\xd8\xde\x0e\x6c\x77\xe6\x4f\x57\x4d\x49\x7c\x30\x68\xd6\xc3\xf7\x68\x6b\x9a\x46\xfc\xae\xed\x7a\x6f\x17\x92\x89\xa3\xde\xe5\xb6\xb6\x18\x4d\xe7\xa8\x36\x77\x07\xa2\x1b\x59\x75\xcf\xb0\x86\x74\xb4\x41\x1f\x60\xac\xd3\xa3\xb6\x62\xda\x3b\x3d\x6a\x2b\xbe\x53\x4e\x7c\xe5\xa2\x28\xd3\xec\xec\x40\xb3\x18\x1e\x9e\x34\xe5\x15\x2a\xaf\xe1\x80\x73\x27\x1a\x8a\x29\x67\xaf\x77\xf0\xc7\xf0\xf0\x80\xcb\x34\x0a\xad\x6b\xdd\x82\x9c\x0d\xa4\x2d\x07\xe2\x76\x5e\x2c\xea\xb3\x81\xd1\x01\xdc\x78\x5c\xfd\xbb\x90\xfd\x08\xe3\xdb\x21\xc7\xb7\x40\xf2\x87\x5d\x03\x25\xb6\xd7\x23\x7e\x3a\x10\x1a\xd3\x67\x03\x67\x20\x74\xf1\x48\x57\x2c\xfe\xd9\x60\x07\xd4\xb8\x76\xdc\x3b\xcd\xd4\x65\xcd\x57\x7b\xa7\xd4\x82\x7d\x5b\x16\x54\x7b\x50\xed\xcb\xb4\x2b\x91\x67\x67\x83\x4b\xa6\x0e\xa8\x19\x2a\xac\x6a\x45\x1c\x01\x88\x8f\xaa\x2a\x35\x8d\x6f\x95\xe6\x88\x49\xcb\x14\x09\x13\xd3\xbe\x73\xe3\xc0\x76\x5d\xe1\x45\x76\x10\xcc\x2c\xe9\xbb\x76\x38\x91\xd2\xb1\x13\xe1\xbc\xf6\x90\x2a\xc6\xd2\xb7\xdd\x00\x79\xcc\x41\x62\x26\xaa\x1e\x7a\x1d\x05\xb6\x9c\x79\x44\x72\x7f\xa2\xeb\xa9\x63\xb9\x8e\x1d\x06\x16\x8d\x0f\x2d\x1e\x64\x11\x03\x7d\xf9\xb1\x93\xe2\xcb\x6f\xbf\x3d\x87\xe9\x06\x47\x4f\x4a\x12\x9a\xeb\x7a\x21\x56\x0c\x1c\xdb\x8d\xf1\x37\x8c\xec\xc8\xbf\x96\x41\x6c\x47\x53\x88\x13\xd9\x7e\x24\x78\x39\x41\x33\x02\xfe\xad\x2f\x5f\x33\xb3\x29\x0d\xf1\x49\x64\x92\x03\x23\x3d\x7d\xc5\x43\x7e\x22\x6e\x01\xc4\x66\x3e\x9d\xd8\xf4\xc4\xda\x0c\x32\xc5\x1e\x9f\xbb\x71\x27\xf6\xe9\xd1\xd5\x0e\xeb\x5b\x35\xba\xdd\x66\xba\x6a\xc8\xa9\x55\xf9\x41\xb5\x46\x6f\xef\xac\xd6\xe7\xb2\xe7\x11\xd3\x63\xea\x5a\x2d\xca\x2c\x5b\x7b\x69\x27\x73\x8b\x76\xf0\xe5\x4e\x4f\xb7\xf3\x9e\x9a\x58\xcf\xd2\xe5\x1a\x02\x8f\x4d\xef\xc7\x51\x38\x22\x6f\xf9\x71\x98\x38\xae\x98\x30\x1a\xa4\xeb\x7b\x71\x9f\x4c\xf0\x70\x9d\x28\x0e\x46\x8e\x98\xc0\x4e\x61\x22\xc3\xc0\x4d\x70\xc7\x5e\x6b\xa7\x00\x32\x23\xe0\x23\x4e\xf0\xd8\x81\x1b\x7b\x3c\xf0\x48\x82\xb9\x1f\x3a\x91\x24\x1e\xc0\x91\xe6\xf1\x04\x19\x10\x73\x92\xc8\x8b\x9d\x40\x8c\x0d\x72\xe0\xc3\xc1\x01\x92\x63\x2c\x3c\x07\x13\x83\x00\xaa\x98\x0b\xed\xd6\xec\x1f\x03\xb6\xcf\x3b\xb6\xc7\x16\x30\x9f\x9f\x1e\x91\x5d\x7e\xc7\x4a\x61\x4f\x71\xdc\x9a\x9a\x13\x68\x47\x0c\x5a\x2f\x0e\x42\x20\x77\xc4\xc8\x95\x89\xe3\x25\xa4\xba\xeb\x86\xb6\x1f\xc0\xba\xbe\x18\xe3\xce\xf7\xec\xc4\x49\x7c\x68\x6c\xf0\x70\x81\x72\x99\x78\x1e\x80\x6f\x2c\x64\x50\x27\x86\x38\x06\x79\x6c\xd8\xa1\xc7\x63\x6d\x33\x63\x3d\x93\xba\x91\xc9\xb4\xbb\x21\x78\xcf\xee\x1b\xe5\x4c\xbb\x87\xa2\x6f\xa3\x27\xec\xcc\x91\xb4\x65\xe7\x75\x44\x99\x16\x97\x10\x4a\x06\xbe\xf4\x7c\xe8\xe2\x20\x8d\x24\x32\x86\xcd\x88\x1c\x07\xc0\x03\x91\xa5\x1d\xc7\x5e\x18\x79\xd8\x52\x23\xdb\x73\x9c\xc0\x27\x33\x79\x36\xfa\x56\x89\x74\x42\xd4\x28\x72\x43\xc7\x05\xd5\xb7\xa5\xa6\x82\x45\x6c\xfb\x61\xe2\xfb\x44\x86\xc8\xdd\xe0\x18\x1a\x26\x8e\x24\x93\x62\x69\xc7\x77\x62\xa2\x26\x76\xe8\xc5\x9e\x47\x16\x8d\xc0\xd8\x75\x7c\x09\x16\x1e\x4b\x94\x84\x2e\x1b\xda\x73\xc3\xc0\x83\x0b\x29\x6f\xb8\x71\x1c\xd0\xe0\x04\xb7\x1e\xd8\x40\xaa\x88\x6f\x31\x09\x88\x4d\xbc\xc8\x8b\xda\xc7\x81\xed\xcb\xc0\x0b\x7d\xe6\x11\x04\x12\xe8\x94\x1e\x96\x86\x38\x8e\xcf\xeb\x85\x11\xc8\x34\x13\x4a\x3b\x89\xe3\xfb\xa6\x14\x12\xa8\xc6\xcc\x50\x26\xac\x47\xb2\x83\xea\xdb\x41\xd4\xb1\x30\xc8\x04\x02\xad\x9e\x49\x75\xb1\xc6\x9a\xea\x78\xd0\xda\x65\x1b\xfb\x41\x24\x7d\x4f\x4b\x11\xc5\x61\x10\xc2\x42\x7e\x02\x16\xb1\x0c\x3d\x96\x38\x08\x65\x14\x25\x4c\x75\xd8\x16\x7d\x2a\x94\xd3\x6e\x62\x16\x4e\x9c\xc0\x27\x20\x63\x3d\x60\x2e\x0e\xd9\x12\x71\x08\xd3\x60\x30\x96\x46\x7e\x81\x18\x7d\xaa\x67\xbb\x91\x07\xa7\x11\x8b\x0d\xd9\x45\x66\xd0\xc2\x99\xb2\x21\xa9\x07\x1d\x63\x04\x41\xec\xb8\x3e\x51\x1d\x3b\x06\x03\x8f\x59\x24\x76\x94\xc4\x91\xf4\x46\x58\x0a\x00\xd0\x86\xf3\x09\x4e\x89\x0b\xc0\xc9\x24\x86\xd7\x09\x23\xa0\xc2\x69\x6e\x82\x38\x02\xd5\xb3\xa3\x90\xf4\xa3\x88\x87\xe1\x64\x18\xc6\xc8\x5a\x71\x0c\x81\x12\x2f\x86\xc8\x00\x6a\x18\xc5\xbe\x94\xa0\xfa\x76\xac\x45\x06\x8a\x6d\xe8\x8f\x14\x06\xaa\xec\xc0\x32\xa6\xbd\x2c\x49\x82\x00\xd0\x82\x9d\x00\xd4\x24\x48\x60\xfb\xd0\xb3\x43\x24\x02\x20\x59\x46\xfe\x1a\xdf\x21\x20\x1b\x4b\x84\x1a\xa8\x88\x39\x42\x2c\x05\x43\xe4\xd9\x1e\x38\x07\x10\x39\x72\x6c\xe4\x8e\x28\x82\xd6\x51\x02\x0c\x79\x49\x8c\xf5\x30\x2f\xc4\x72\xc8\xc1\x12\xd1\x19\xc4\x48\xdc\x60\x81\xc8\xf6\x7c\x72\x1f\x58\x24\xae\x8d\x00\x88\x5c\x22\x87\xd0\x89\x16\x14\x64\x80\x28\x74\xbc\x08\x5a\x87\x01\x06\x23\xb6\x23\x94\xb2\x01\x4b\x21\xb1\x5c\xe8\x83\x31\x2b\x3d\x76\x91\xb6\x61\x65\x19\x31\xd5\xd5\xde\x73\x65\x62\x07\x09\x1c\x1c\x8e\x48\x25\x28\x8a\xf8\x15\x2e\x82\x4c\x86\x2e\xeb\xbc\xa1\x4e\xe0\x20\x38\x92\x42\xfc\x49\x72\x12\x74\x4e\x1d\xf7\xc8\x30\x1c\x59\x28\x10\x44\x8d\x02\x24\x38\xa2\x62\x6d\x49\x5e\x15\x04\x3e\x2f\x02\xe4\x80\x16\x47\x62\x9a\xce\x22\x94\x51\x5c\x49\xd1\x07\x32\xec\xa9\xa1\x4c\x21\xe0\x00\x18\x31\xe0\xe2\x78\x76\xa0\x13\x03\x45\x91\x17\xb9\x91\x0c\x4c\xea\x98\x92\x84\x0f\x34\x78\x5b\x83\x01\x76\x0f\x89\x27\xea\x31\x0e\x1d\x9b\x4c\xec\x7a\xa6\x14\x13\x8f\x52\x9c\x03\x9b\xc0\xd7\xc0\x7d\x94\x10\x04\x90\x6b\x29\x6d\x61\x8f\x45\xde\x20\x58\x43\x85\x04\xa9\x16\x96\x0b\x7d\xd7\x8b\x61\x7b\xca\x23\x1c\xd5\x3d\xa2\x8b\x48\x86\xa3\x25\x31\x30\xc8\x0e\x42\x92\x64\x13\x26\x5b\x0c\x70\x75\xe0\x98\x32\xe0\x32\xd2\x02\x4f\x0c\x89\xe1\x10\xdf\xef\x5c\xcd\x89\xca\x8b\x82\x70\x14\x22\x5a\x12\x8d\x59\xc3\x14\x58\x9e\xed\x95\x04\x32\xf1\xe9\x6e\x6c\x18\x95\x1f\xc2\xf0\x5e\x88\xc1\x3d\x06\xe4\x25\x6c\x56\xb0\x5a\x6f\x35\x72\x69\xe4\xc3\xcf\xc0\x95\x06\x85\xcf\x7e\x46\xfd\xe1\x60\x28\x9e\xd2\xc8\x28\x36\x89\x14\x54\x1a\xc4\x93\x0d\x15\x69\xb8\x43\xc4\xc4\xc4\xe0\x86\x3c\x26\xf4\xc7\xa1\xe7\x90\xd1\x36\x64\xca\xff\x78\xe2\xc6\x08\x0f\xe4\x15\x44\x8e\x47\x85\xa7\xc4\xb6\x11\xf9\x3e\x32\x39\x85\xbc\xdf\xed\x4d\xc8\x31\x21\x74\x73\x08\xc6\x92\x2a\x0e\x38\x4e\x3a\xd0\x0d\x1b\x56\x82\x68\x44\x25\xe3\xb5\x7c\x0d\x6a\x82\x5d\x43\x07\xd8\xd8\x20\x53\xb0\xb9\x6d\x4e\x90\x94\xb0\x35\xd4\x5c\x5c\x52\xf0\x07\x10\xcd\xa7\x18\xf5\x11\xfc\x2e\xb2\x51\x1b\xfc\xd8\xdd\x90\x14\x1d\x6c\x7b\x9c\x78\x65\x37\x36\xa0\x34\xee\x26\xbe\x4e\xd2\x9d\x72\xbb\xb6\xd8\x27\x36\x6e\xfa\x19\x08\x7e\x5d\x7d\x59\x56\xf3\xb3\xc1\xfa\xcd\xf5\x10\x49\x03\x1b\x1b\x27\x43\x64\x2a\x00\x8e\x7f\x0e\x05\xbf\x08\x1f\x5a\x12\xd4\x43\xb1\x19\x6e\x99\xe3\x2d\x73\xc2\x56\x61\xb0\xa9\xb4\xd7\x17\xdc\x04\x51\x23\xbb\xdd\x02\xe5\x85\xda\x54\xde\xd4\x5c\x6e\x57\xde\x6e\x60\x2a\xb3\xb3\xf4\xee\x66\xd0\x8b\x89\x69\xba\x3c\x1b\xf0\xeb\xb2\x1e\xf9\x9f\x65\xbe\xe8\xe8\x8f\xba\x18\x89\x48\x47\x99\xe1\x5e\x03\x1b\x70\x0d\xfa\x14\xd8\x37\x14\xd8\xaf\x08\x32\x78\x80\x0d\x09\x51\xa5\xaf\x5f\xbb\x5e\x32\x45\x1e\xa6\xe6\x8a\xa8\x16\x20\x1e\xb6\x97\x3c\xe0\x27\xae\x05\x82\x77\x14\xd9\xf4\x80\x2b\x14\x0f\xb3\xbd\xd7\xe4\x37\x74\x49\x31\x33\xf6\xf8\x7f\xa4\x67\x6b\x01\x3e\xee\x68\xb1\x08\xc9\x3c\x7b\x82\x2b\x0d\x29\x6a\xa4\xe0\xf6\x58\x44\xd4\x47\x21\x61\x4b\xea\xf3\xdc\x88\x2f\x5f\x03\x28\x93\xf5\xa4\x8f\x4f\x76\x3f\x30\xfc\xbf\xab\xf7\x31\x59\x77\x9d\xcf\x4e\x00\x4a\xaf\x85\xd0\x48\xac\x2f\x0f\x1f\x75\x44\x3d\x76\xba\x1f\xea\x21\xe6\x77\x41\xc3\xb8\xf9\x97\x30\x62\x3a\x82\xda\x1f\xf8\x48\xfa\x48\x89\xdc\x11\xc4\x04\x90\xd8\x8f\x22\xee\x08\x68\x3f\xf6\x92\x04\xdb\x3b\x91\x7d\x24\x4e\x2a\xf2\x13\x94\xdf\xf8\x89\x3d\x8d\x10\x14\xe0\xa8\x26\x0c\xea\x84\x6a\xa1\x04\xe9\x34\xee\x91\xc7\x5c\x39\x21\x89\x50\xb9\xbc\x21\x03\x7a\x60\x82\x52\x6f\xbd\x9c\xef\x9a\xc4\x8d\x44\x93\x0d\x55\x22\xf5\x48\x3f\xd0\x99\x79\x17\x55\xd2\x96\x1f\x23\x9f\x8c\x50\xd9\xf9\x28\xc3\xd0\x68\x28\x34\xd7\x9c\x2e\x51\xa5\xf8\xb4\xfd\xf5\x9f\x4c\x5a\x6d\x02\xd2\xb1\xff\x68\x4c\x32\x04\x0e\xaa\xde\x68\x64\x21\x43\xa2\xe2\x82\xec\xae\xb2\xd0\x06\x3a\x23\x0a\x16\xec\x5a\xd8\x14\x44\xcf\x9e\x6d\xf2\xaa\xd4\xb4\x41\xbe\x96\x9f\xe8\xe8\x24\xa0\x8e\xca\xc0\xe1\x46\x16\x37\x9c\xf5\x51\x16\x27\x21\x67\x72\xdc\x83\xad\x1f\xd3\x5e\x25\x48\x49\x6c\x7d\x68\x35\xa1\x2f\xc5\xab\x4b\xe5\x28\x6d\x57\x74\x3b\xa1\xc4\xcc\x17\xdb\x3c\xd7\x37\xa6\x58\x51\xe2\x7f\x56\x03\x84\x9c\x1e\x3b\x9e\x24\x73\x26\x3e\x3a\x60\xde\xe8\xc6\x3e\xa5\x4e\x18\x42\x12\x39\x80\xf1\xb8\x10\x00\x35\xf1\x51\x4b\x06\xda\xfb\x8e\x2e\xa0\x90\xe9\x61\x51\xd4\x20\x1d\x26\x98\x3a\x46\xaa\x97\x48\xf4\x7e\x4c\x98\xc0\x22\x9a\x4c\x1b\x40\x88\x5a\xd9\x45\xe5\xc2\xe5\x2f\x17\x0d\x28\xff\x5d\x2a\x63\xa9\xf8\x41\x55\xe5\xe9\x5a\x72\x8c\x3d\xdd\x45\x51\x15\xc6\x68\x78\x61\x36\x9f\x77\x3a\xb4\x15\x30\x09\x37\x13\xd8\x02\x05\xca\x00\xc0\xd7\x41\x1d\xcd\xb7\x63\x6a\xaa\x7c\x64\x46\x18\x8b\x1f\xa3\xc7\x08\xb1\x83\x3a\x09\xb3\x08\x75\xdf\x00\x6a\x84\x75\x59\xbb\x84\x3a\x4e\x54\x2d\x98\x0a\xa5\x61\xfe\xa0\x25\xb7\x52\x50\xfd\xec\x44\xd8\xc9\x7d\x96\xd8\xdf\x41\x45\x7e\xa5\x7a\xc6\x63\x16\x1b\x32\x76\xfa\x56\x3d\x93\x8a\x00\x5a\x53\xc3\x98\x7a\x5c\x97\x4d\x1f\x13\x3e\xa5\x96\xc2\x0b\xb0\x8b\xd2\x60\x8f\xda\x1b\x2a\xc2\x41\x45\x6f\xc1\x75\x35\x05\x53\xdc\xda\xa2\x4f\xf5\xdb\x2e\x8c\xd4\x83\x1f\x3d\x8a\x84\x98\xde\x41\x71\x0d\x16\x50\xc3\xe2\x25\x68\xc1\x41\x45\x8f\xa1\xcb\x38\x93\x8a\x8a\x3e\xe1\xfe\x70\xdc\xa3\x02\x95\x5a\x36\x53\x34\x14\xf7\x6d\x53\x14\x24\x90\x3d\x41\x01\x86\xe0\x42\x3d\x12\xb6\xcd\x2b\x2e\x7d\x9f\x0b\x04\x72\x89\xb6\x1a\xca\x2e\xc4\xb0\xeb\x3b\x1e\xb7\x7c\xa1\x2e\x10\x42\xae\x9f\x3c\x8f\xda\x6a\xc0\x31\x74\x7d\x1f\xf5\x69\x48\xfd\x4e\xc0\x5d\x07\xbd\x4f\x08\x75\xc1\x8f\xb6\x04\xbe\xf2\x25\x17\x1e\x0e\xaa\x18\x12\x37\x42\x61\x18\x23\xfb\x24\x2e\x77\x76\xda\x36\xe3\x18\xee\xf6\x51\xb7\x48\xa2\xa2\x45\xd5\x6d\x19\xbd\x3d\x88\x02\x09\xb3\x82\xea\x76\xc8\x4e\x00\x56\x0f\xd5\x3e\xf9\xc2\x23\xb6\xba\xd4\x82\x5b\x12\x30\x0e\x49\x5e\x6c\x8b\x81\xee\x66\x28\x84\xe1\x4d\x2a\xf6\x51\xec\x52\xa3\x49\x9b\xa2\x13\x51\xc9\x89\xd1\xfc\xa2\xc3\xe9\xde\x02\x20\xf5\x44\x78\x86\xc6\x8e\xfb\xc8\x98\xd6\x43\xa4\x03\xcd\x14\x6b\xc0\xbb\x4b\x75\x3f\x20\x9b\xf0\x8b\x04\x92\xc2\xe5\xfa\x2b\xd6\x0a\x8f\xa9\xbf\x87\x7d\x25\xd5\xfa\x20\xfb\xda\x6d\xd4\x46\xc2\x58\xb2\x1d\xec\xb6\x9d\x21\x62\x11\x31\x1b\xf8\x3d\xea\x84\x3a\xb1\x08\x25\x5c\x12\x3f\x49\xa6\x72\xad\x6b\xc0\x0d\x32\xc2\x35\x66\x03\x71\x6b\xe8\x48\xfd\xde\xc8\x5d\xbf\x8a\x40\x3b\xe8\xa0\x0f\x72\x02\x6e\xf7\x83\x36\x7b\x20\x25\x52\x91\xcb\xef\x38\x00\x6c\x8d\x60\xea\x22\xe9\xb5\x05\x15\x9d\x01\xda\x2c\x9d\x0f\x24\x7c\xe7\xb8\xdc\x18\x1a\x54\x70\x68\x8b\xca\x1e\x19\xed\x1f\x37\xda\x94\x52\x0c\xc6\xe8\x76\x63\x74\x87\x9e\x29\xc3\x84\x90\x04\x3f\x23\x0a\xb9\x19\x8a\x75\x9f\x3d\x26\x45\xa9\x49\x0e\xa9\xf4\xa5\x5c\xc4\x95\x36\xf7\x0b\x09\x00\xaf\xbb\x3a\xa9\x13\x42\x8f\x0a\xef\xb6\x8d\x53\x8f\x8c\x82\xb7\x6b\x2e\xd6\x8c\x25\x25\x52\x1d\x31\x86\x14\xd4\x02\x47\x5a\xe2\xc9\x46\x64\xf2\xe3\xfa\x75\x0f\xd4\x43\x3b\xc7\x2c\xe8\xdd\x41\xfb\x6a\x60\x63\x0a\x50\xb5\xc1\x7c\xdf\xa5\xea\x9f\xdf\x32\x6c\xcc\xaa\x1f\xd3\xeb\x85\x20\xf4\x92\x3e\x0f\xf8\xa9\x6d\xd5\xcc\x05\xc9\xa9\xd8\x64\xa9\xa7\xf6\xdd\x35\x88\xc8\xff\x6e\xe4\xd0\xbe\xe3\x13\x73\xfd\x9e\xc4\xa4\x06\x14\xc6\xd4\x07\x4c\x4c\x72\xb4\x7e\xeb\x30\x31\x80\x68\x90\xc7\x31\x62\x08\xd8\x4f\x68\x87\xdb\x90\x09\x64\x00\x32\xf5\x6e\xf4\x3e\x23\xd1\xef\xa8\x3c\x7a\xf1\xef\x71\xf7\xc3\xad\x7f\x8b\x2d\xc4\xac\x87\x86\x1a\x0d\x26\xb0\x8c\x18\xd0\x0e\xf4\x1c\x8e\xe6\x40\x33\xa4\x3b\xc8\x98\xe8\xb0\x1a\xd3\x2d\xed\x03\x3a\x01\x78\x10\x3e\x20\x73\x02\x5a\xc8\x26\x2e\xb5\x71\x91\x40\x0f\x87\x80\xa4\xb7\xc2\xc8\xa4\xb2\x8b\xf4\x31\xc8\xc8\x41\x91\x4b\x2f\x7c\xa8\x86\x69\x07\x47\xf4\x96\x0f\xfb\x49\xa2\x93\xb1\x5e\x75\xe7\x4e\x6a\xb6\x39\x16\x1d\x6b\x5b\x57\x7a\x5d\x29\xb8\xeb\xe3\x94\xdd\xe5\x27\xf2\x1d\x4c\x8f\x8c\x38\x12\xae\xfb\xfb\xfd\x8f\x39\xde\x32\x27\x7c\x5e\xff\xf3\xe3\x52\xa4\x55\x55\xde\x6c\xf7\x40\xab\xa5\xc5\xf4\x27\xa4\xb4\x68\x17\x41\xe6\xb3\xb0\x73\xa3\x5e\x3a\xec\xf7\x2f\xc6\x94\x79\xda\x54\xf9\xed\x90\xde\xe5\x4a\x8f\x3f\xfd\xc1\x70\x24\x53\xe1\xc1\x43\xf4\x72\x08\xdd\xb8\x17\x1c\x6e\xd7\xca\xb0\x18\x84\x98\x5b\x14\x64\x68\x72\x90\xdd\x91\x81\x66\x16\xb2\x73\xec\x46\xed\x9f\x42\xfa\xb6\x2f\x7d\xcb\xa5\xfa\x2d\x10\xbb\xee\x84\xbe\xdb\xd1\x70\x90\xee\x2f\xcb\x9b\xc5\x6e\xed\x33\x3c\xf9\x77\xe9\x6f\xf5\x0d\x40\x98\x4d\xbc\xff\x6f\x03\x9c\x1e\x75\x1f\x06\x9e\xd2\x87\x8f\x7c\xa1\xcf\x22\xe9\xc7\x33\xa9\xc7\xdf\xdf\x57\xf4\xd9\xa6\xf8\x53\x3e\x12\x7f\x9a\x56\xab\xf9\x85\x38\x3e\x13\xf6\x8b\x0a\x63\xf9\xf6\xe1\xe1\x34\x15\xb3\x4a\x5d\x9e\x0d\xda\x73\x71\x7a\x98\x3d\xc9\x17\x1f\x1e\x1e\x06\xcf\xfb\xd4\xf7\xea\xb6\xa1\x33\x73\x29\xe8\xf9\xa5\x58\x10\x67\xe1\x3c\x3c\x1c\xdd\xdf\xab\x45\xf6\xf0\xd0\xfe\xd1\x22\x6a\x21\xf4\xa7\xb1\x5a\xb0\x53\x3a\xe7\xd3\x7e\x98\x99\x5f\x8b\x69\x91\xd6\x35\xac\xa4\x9a\xb4\x75\x00\x93\xc9\x83\xed\xc9\xb0\xb5\x5f\xea\x65\xba\x30\xc7\xf3\x21\x1c\x84\x48\xbe\x58\xae\x1a\xd1\xdc\x2d\x11\x98\xf4\x59\xf3\x40\x2c\x8b\x74\xaa\x66\xfc\x89\x17\xf7\x79\x0d\x7d\x1a\xda\xf6\x7c\x7c\x5d\x2e\x3e\xa8\xbb\xd5\x72\xf3\x81\xf0\x01\x22\x8d\xf8\xb7\x6b\xdd\xdf\x5b\x02\xba\xd9\xe7\xd5\x74\x96\x5f\x2b\xad\xcd\xd3\x22\x10\x0a\xe9\x03\xe6\x63\xb1\xb6\xe4\x37\xa9\x9e\x7a\xf6\x31\x5f\x0e\x9e\xe3\x17\x19\x6c\xc7\xe3\x26\xad\xec\xab\x8f\x83\xe7\xfa\x2f\x0d\x7a\x24\xc8\xda\x9c\x1b\xb9\x7e\x80\x39\xff\x5e\x61\xf1\xdf\x93\xac\xeb\x22\x08\xc9\x02\xf4\x59\x99\xf1\xc7\xcb\x30\x92\x5a\x4c\xb5\xcd\xe6\xab\xa2\xc9\x97\x69\xd5\x1c\xd1\x28\x2b\x4b\xe1\x8a\xee\x93\xe8\xad\xe3\x19\x46\xff\x6a\x5a\x5d\xbf\x6d\xd1\x67\x1a\xf5\xb5\xe6\x59\xa8\xf5\xb9\xd7\xdd\x13\xeb\xd5\xc5\x3c\x87\x2c\xd7\x69\xb1\xc2\xed\x8f\x4b\xb2\xe2\x46\x6a\x16\x68\x9d\xfc\x0c\xb3\xfc\x0b\xea\xfe\x21\x85\x34\x8c\xb4\x42\xf3\x0f\x59\x5e\x6d\x61\x6a\xa1\x6e\x04\xa8\x68\xdf\x4a\x00\xf4\x8f\x29\x39\x46\xe0\x35\xea\x33\x94\xec\xfb\x1e\xe1\x9e\x5f\x77\x81\xdf\x5d\x19\x11\x54\xe4\x35\x9d\x1a\xee\x82\x48\x9f\x27\x4b\xab\x3c\xb5\x32\x55\x4f\xab\xfc\x42\x65\x17\x77\x8f\x83\xaa\xe9\x4e\xc6\xf2\x4d\xb5\x96\x0a\x9b\xce\xe9\x91\xd1\x91\x9a\x3d\xf3\x1a\xc4\x74\x44\xe6\x8c\xac\x04\x5b\xf0\xd1\xbe\x3f\xf3\x79\x8a\xb3\xb4\x9e\x0e\x3a\xb9\xf8\x2c\x10\x9f\xa5\xd1\x67\x2d\x9e\xf3\xc9\x8a\xf6\x61\x53\x2e\xd7\xc7\x1f\x24\x1c\xb8\x3e\x15\x61\x07\x74\xd7\x3f\x7f\x41\xc7\x6e\x5f\x94\xb7\x48\xac\xf4\x8e\xcc\x45\xd1\xe1\x22\xb5\x0a\x54\x59\x5e\x80\xb2\x2a\x01\x6b\x3a\x07\xce\xe7\x2b\x8e\xb5\x84\x5f\xae\xf7\x40\x68\x83\x87\xcf\x75\xaa\x34\x45\xd0\x27\x78\xfe\xbd\x52\x18\x7b\x51\x5f\x0e\x84\x7b\x67\xd5\x4f\x58\x77\x87\x55\x5b\x5b\xd2\xf9\x65\x83\xc9\x67\x7a\x8c\x8e\x1d\x3d\xcd\x93\x0e\xc1\x3c\xcd\xb3\x1b\xdc\x9d\x3b\x1a\x3c\xb5\x48\x93\x7f\x4a\x70\x7d\xac\x5b\x65\x7f\x64\x21\x63\x00\x2e\xab\xcd\x65\x0f\xc2\x74\xde\x67\x17\x9e\x33\x9a\x9f\x99\xf7\x8f\x04\xb7\xed\x8d\x36\xfd\x14\x43\x87\xe7\x06\xcf\xff\x52\x8a\xd5\xb2\x17\xa2\xb4\xbc\xa9\x40\x8f\xff\x9f\xe7\x74\x0e\xf3\x64\x8b\xfc\x58\xaf\xcf\x1d\x67\x0c\x30\xf4\xa7\x2c\xa1\x37\x78\xfb\xd5\x02\x85\x8a\xaa\xd7\x7b\x42\x53\x75\x4c\x38\x29\xef\xd0\xfd\x29\x93\x74\xfb\xcc\x9b\xfa\x65\x5e\x75\xfc\xda\x43\x51\x5d\xa0\xe8\xe0\xe8\x42\x45\xfe\x4e\xa4\x78\x92\xea\xa4\x9d\xd1\xd1\x1e\x57\xea\x45\x86\x29\x88\x2a\x6a\xf5\x7f\x22\x03\x7d\x52\xeb\xb9\xde\x4e\x19\x72\x6d\xe1\x27\x24\xd8\xec\xc0\x5b\xc0\xa0\xf0\xc4\xbc\xad\xfa\xc9\xfe\xf1\x87\x89\x51\x38\xd9\x13\x95\x5e\xea\x92\xa9\x8f\x1e\xd3\xfc\xbb\x4d\x4e\x40\xa0\x0d\xd9\xd2\x91\x34\xb0\xe4\x4e\xbc\x3c\x32\xd3\xf6\xbc\xfb\x7b\x9b\xe2\x9a\x84\x3a\xa5\xf0\x7f\xbe\x26\x40\x22\xba\x7f\xc4\xcd\x50\xb9\x13\x0d\x41\xfb\x1e\x51\x2d\xfe\x53\xa4\x97\xa8\x9a\x5e\x2d\xcb\xe9\x4c\xf4\x96\x7c\x8c\x59\x4a\x03\x7c\xea\x90\x2e\x58\x8e\x8e\x8b\x36\x90\x71\x4b\x5f\xc3\x98\xef\x90\x64\x5b\xaf\x47\x8b\xfc\xcf\x7f\xfd\xf7\xa7\xc4\xdf\x39\x67\x0b\xe9\x7f\x7a\x5c\x52\xed\x2e\x23\xca\x85\xde\xce\xcf\x0e\x2a\xd5\xac\x2a\xfa\x8a\xd1\x02\x5b\xdf\x7c\x38\x78\xa9\x0a\xd5\x28\x31\x10\xcf\xf8\xab\x48\x76\xc6\xf7\x36\xef\xfa\xa0\x0d\xbe\x19\xe8\x93\x85\x3b\x2a\x04\x7d\x40\xbf\x2b\x38\xf4\xc4\x75\xbd\xd0\x07\xd1\xe0\x09\x16\x5b\x45\x86\x16\xc6\x48\xce\x66\x95\xb1\xc3\x44\x4f\xa6\x16\x63\x14\x9e\x6c\x92\x2b\x6e\xc8\x90\xfd\x82\xe4\xf4\xa8\x2b\xee\x4f\xa9\xe4\x58\x36\xfc\xf8\x3a\xad\x84\xae\xb3\x5f\x15\xe2\x4c\x64\xe5\x74\x35\x57\x8b\xc6\xbe\x52\xcd\xab\x42\xd1\xe5\x8b\xbb\x37\xd9\xb0\xad\xc5\x0f\x0e\xe9\xcc\xe8\x5e\x37\xc1\xbe\xc4\xf0\x7a\xd8\x12\x57\xa8\x59\xe9\x3b\x61\x5d\xd9\xce\x87\x41\xf5\x0a\xbf\x81\xf5\x7a\x12\x5b\xc1\x46\x36\x9c\x0f\x0f\xed\xa6\x9c\x94\x37\xaa\x1a\xa7\xb5\x6a\xf9\xf0\x04\x58\x68\x5e\x9b\xf2\xfc\xb6\x52\xd5\xdd\x3b\x90\xa9\xaa\x3b\x2f\x8a\xe1\x41\x53\xd9\x94\x18\x5a\x91\xf6\x78\x06\x04\xaa\x5e\xa5\xd3\xd9\xb0\x13\x66\xa8\x8a\x4e\x8e\x3d\x20\x69\xf8\xc5\x6f\xeb\x5b\xcc\xb0\xb9\xee\xb4\xdb\xb2\x13\xcb\x1d\x1c\x9c\xb4\x0f\x35\x80\xda\xbb\xd6\xc6\x24\x18\xa1\x80\x2d\x85\xd9\x3d\x99\x86\x07\x7c\x9e\xbb\x13\x67\x3d\xf8\xa7\x94\x46\xeb\x69\x36\x95\xae\x63\xfd\x7d\xa2\x4f\x18\x80\x25\x6d\xe7\xda\xf9\x22\x53\xb7\xdf\x5f\x0e\x21\xf8\x17\x67\x67\xc2\x92\x9f\xa7\xc0\x03\x87\xe6\x27\x87\xd2\x27\x7b\x07\x3d\x0d\x1f\xb4\x00\x0f\x3d\x77\x16\xe5\x34\x2d\x90\x85\x5e\xb6\x79\x62\xa8\xe8\xdb\x53\x10\x6a\x84\x0a\xb6\x13\x86\x24\x56\xa6\x7a\xe2\x0c\xc2\xd2\x77\x4c\x2e\x51\xc8\x67\x6b\x99\x4d\xb3\x3e\xac\xbd\x9d\x91\x85\x50\xb5\xd3\x12\x60\x03\xec\x9d\x37\xed\xf7\xe5\x86\x07\x5d\x7e\x3a\x38\x6c\xcd\x43\x6b\xe5\xf5\xdb\xf4\xed\x30\x3b\x5c\x33\xde\x62\x61\x48\x62\x1a\xf5\xd1\xb4\x5d\x7e\xd6\xbf\xb7\xb4\x11\x19\x7b\x8a\x5e\x12\xbd\x6b\xe8\xab\x5a\xc3\x5f\x7e\x1d\x89\xfb\x8c\xba\x95\x81\x6b\x65\xf9\x15\x02\x7c\x24\xe6\x18\x3f\xeb\x51\xee\x54\x5a\x81\xb0\x00\x8c\xab\x7c\x0a\xc2\xac\x5c\x55\xfd\x39\xf9\x02\x8a\xf6\x48\xb5\x42\xe6\xca\x0c\x92\xe9\x19\xb2\x18\x19\x64\x82\xa6\x02\x82\x9d\x57\x55\x7a\x67\x2f\xab\xb2\x29\x29\xdb\xd8\x35\x7d\xdf\xd1\x86\xa0\xc5\x70\x47\x34\xd7\x2f\xee\xde\xa7\x57\x54\x9a\x0e\x07\xc4\x64\xd0\x5a\xb5\x63\xb8\x8e\xa0\x6d\xb7\x63\x58\xb7\x38\xb8\xfd\x58\x15\x7f\x4b\x2b\x70\xa1\x43\xdf\x88\xed\x0e\x2c\x5b\x8f\x86\x35\x5f\x9a\xa9\x00\x94\x2b\x85\x2d\x18\xb3\x6e\x00\xa3\xf2\xc6\xa6\x95\x68\xb2\x5d\x2b\x6a\xbc\x6d\x64\xcb\x5a\x9b\x58\xd2\x51\x76\xfc\xd4\x18\xff\x13\xb5\x4b\x48\x6a\x94\x15\x3a\x1e\x36\xb0\x9c\x37\xc3\x83\x3f\x1f\x74\x03\xd7\x2b\xbf\xe5\xef\x56\xb0\xdf\xb5\xe0\x7b\xf4\xd5\xb0\x61\x8e\xd9\xce\x89\xc8\xc5\xa9\xe8\x31\xb5\x0b\xb5\xb8\x6a\x66\x78\xf2\xec\xd9\x1a\x1c\x7d\x6e\xb4\xae\x39\xe5\x97\xfc\xd7\x6e\xfd\xb3\x83\xd6\x3a\x1a\x65\xfd\x79\xbf\x38\xbf\x72\x30\xf4\x4d\xd1\x21\x4f\x6c\x0d\x96\xbf\xf6\x23\x47\x7c\x23\x9a\x0a\x7b\xd4\xb1\xa0\x2f\x6f\x65\xd0\xfa\xcd\xb8\x9c\x2f\x11\xba\x8b\x66\xf8\x68\xee\xe1\x63\x20\x3f\xf4\x93\x73\x7b\xb6\x9e\xb7\x5c\x9a\x74\xb8\xf1\x0c\x57\x23\xd0\x72\xdb\x87\x07\xfc\xe0\x60\x2b\x3b\x13\x96\x76\x6f\x18\x80\xd8\xb8\x63\x6f\x2c\x74\xd2\x79\x61\x48\x2c\xd8\x11\x23\xa1\xcd\xce\xe9\x54\xcf\xdd\x38\x02\x2e\xda\xe5\x14\x9a\x3c\x5d\x55\xd5\x6b\xd4\x74\xc6\x3c\xf2\x06\x95\x79\xeb\x60\x1f\xea\xe2\xea\xec\x80\x3a\xec\x83\xc3\x7b\xd1\x9a\x9d\xe7\xcf\xae\x30\xb5\xe3\x62\x57\x8a\xdf\x1f\x0c\xf5\xd0\x91\x38\x48\x69\xc6\xc9\x3a\x75\xf6\x57\xa0\x99\xb3\xab\xfe\xce\x60\x2c\x97\x7e\xf6\x6a\xa9\x5e\x4c\xcb\xf7\x07\x56\xdb\xe5\x56\x7a\x5f\x48\xa8\xa4\xe3\x36\xfc\x45\x09\xaa\x1b\xcd\xb0\x5b\x2d\x72\xf6\xd7\x2f\x07\x2f\x68\xd1\xbf\xf2\xef\xef\xf8\xf7\x5f\xf8\xf7\x7b\xfe\xfd\x37\xfe\xfd\x8a\x7f\xff\x83\x7f\xff\xfc\xe2\xe0\xd7\x8d\xe7\x75\xfc\xf0\xed\xcd\x0c\x6b\xf1\x3a\xe2\xf9\x99\x90\x8e\xeb\x6f\x02\x87\x88\x47\x9a\xd8\x8a\xfe\xec\x59\x6e\x66\xfd\x16\xfc\x4b\xfa\x2a\xef\xb7\x45\x99\x36\x5a\x60\xe4\xd8\x6f\xf3\x5b\xc5\xdf\x74\x79\x26\x0e\xf0\xef\x99\x96\x1c\xe6\x68\x13\x60\x4f\x6d\xf3\x9b\x21\x66\x8e\xa1\x2f\xd0\x3e\x09\xce\x75\xfe\xa3\x61\x83\x43\x33\x3d\x6c\x54\xd4\x29\x82\xf8\xec\x4c\x0d\xb3\xd5\x3c\x5d\xd0\xba\x18\xbc\xd3\xf6\xec\xc0\x7c\xb1\x50\xd5\xeb\xf7\xdf\x4d\x3a\xf7\x3e\x7e\x82\xf9\x6b\x5e\x86\x77\xf5\x8b\xe3\xae\x4c\x3b\x3d\xd2\xb5\xdd\xe9\x91\xfe\xca\xf4\xff\x02\x7d\x40\x35\x60\xe2\x3f\x00\x00"),
 		},
 	}
 	fs["/"].(*vfsgen۰DirInfo).entries = []os.FileInfo{
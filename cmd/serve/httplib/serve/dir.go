@@ -37,6 +37,8 @@ type Directory struct {
 	Breadcrumb   []Crumb
 	Sort         string
 	Order        string
+	ReadWrite    bool // if set show the upload, mkdir and delete forms
+	Archive      bool // if set show links to download the directory as an archive
 }
 
 // Crumb is a breadcrumb entry
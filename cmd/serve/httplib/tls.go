@@ -0,0 +1,203 @@
+package httplib
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+)
+
+// certReloadInterval is how often the certificate files are checked
+// for changes
+var certReloadInterval = 10 * time.Second
+
+// certReloader loads the TLS certificate, key and client certificate
+// authority from their files and reloads them if the files change or
+// rclone is sent SIGHUP so certificates can be rotated without a
+// restart.
+//
+// If the files can't be loaded when reloading then an error is
+// logged and the old certificates are kept.
+type certReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	base         *tls.Config // settings which don't come from the files
+	mu           sync.RWMutex
+	config       *tls.Config // current config made from base and the files
+	stamp        string      // size and modification time of the files loaded
+	stop         chan struct{}
+	done         chan struct{}
+}
+
+// newCertReloader makes a certReloader and loads the files
+func newCertReloader(base *tls.Config, certFile, keyFile, clientCAFile string) (*certReloader, error) {
+	r := &certReloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+		base:         base,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	err := r.reload()
+	if err != nil {
+		return nil, err
+	}
+	go r.run()
+	return r, nil
+}
+
+// fileStamp returns a string which changes when any of the files do
+func (r *certReloader) fileStamp() string {
+	stamp := ""
+	for _, file := range []string{r.certFile, r.keyFile, r.clientCAFile} {
+		if file == "" {
+			continue
+		}
+		fi, err := os.Stat(file)
+		if err != nil {
+			stamp += "missing;"
+			continue
+		}
+		stamp += fmt.Sprintf("%d:%d;", fi.Size(), fi.ModTime().UnixNano())
+	}
+	return stamp
+}
+
+// reload loads the files and makes a new config from them
+func (r *certReloader) reload() error {
+	stamp := r.fileStamp()
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to load certificate and key")
+	}
+	config := r.base.Clone()
+	config.Certificates = []tls.Certificate{cert}
+	if r.clientCAFile != "" {
+		pem, err := ioutil.ReadFile(r.clientCAFile)
+		if err != nil {
+			return errors.Wrap(err, "failed to read client certificate authority")
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(pem) {
+			return errors.New("can't parse client certificate authority")
+		}
+		config.ClientCAs = certPool
+		if config.ClientAuth == tls.NoClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	r.mu.Lock()
+	r.config = config
+	r.stamp = stamp
+	r.mu.Unlock()
+	return nil
+}
+
+// reloadIfChanged reloads the files if force is set or they have
+// changed since they were loaded
+func (r *certReloader) reloadIfChanged(force bool) {
+	r.mu.RLock()
+	changed := r.stamp != r.fileStamp()
+	r.mu.RUnlock()
+	if !force && !changed {
+		return
+	}
+	err := r.reload()
+	if err != nil {
+		fs.Errorf(nil, "Failed to reload TLS certificates - keeping old ones: %v", err)
+		return
+	}
+	fs.Infof(nil, "Reloaded TLS certificate %q", r.certFile)
+}
+
+// run reloads the files when they change or on SIGHUP until close is
+// called
+func (r *certReloader) run() {
+	defer close(r.done)
+	sigHup := make(chan os.Signal, 1)
+	signal.Notify(sigHup, syscall.SIGHUP)
+	defer signal.Stop(sigHup)
+	ticker := time.NewTicker(certReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-sigHup:
+			r.reloadIfChanged(true)
+		case <-ticker.C:
+			r.reloadIfChanged(false)
+		}
+	}
+}
+
+// getConfigForClient returns the current config for each new
+// connection
+func (r *certReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.config, nil
+}
+
+// TLSConfig returns a config which always uses the latest files
+func (r *certReloader) TLSConfig() *tls.Config {
+	config := r.base.Clone()
+	config.GetConfigForClient = r.getConfigForClient
+	return config
+}
+
+// close stops the reloading
+func (r *certReloader) close() {
+	close(r.stop)
+	<-r.done
+}
+
+// The fields of a client certificate which can be used as the user
+// name with --client-cert-user
+var clientCertUserFields = map[string]func(cert *x509.Certificate) string{
+	"cn": func(cert *x509.Certificate) string {
+		return cert.Subject.CommonName
+	},
+	"email": func(cert *x509.Certificate) string {
+		if len(cert.EmailAddresses) == 0 {
+			return ""
+		}
+		return cert.EmailAddresses[0]
+	},
+	"dns": func(cert *x509.Certificate) string {
+		if len(cert.DNSNames) == 0 {
+			return ""
+		}
+		return cert.DNSNames[0]
+	},
+	"uri": func(cert *x509.Certificate) string {
+		if len(cert.URIs) == 0 {
+			return ""
+		}
+		return cert.URIs[0].String()
+	},
+}
+
+// clientCertUser returns the user name from field of the verified
+// client certificate of r or "" if there isn't one
+func clientCertUser(r *http.Request, field string) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	userFn := clientCertUserFields[field]
+	if userFn == nil {
+		return ""
+	}
+	return userFn(r.TLS.VerifiedChains[0][0])
+}
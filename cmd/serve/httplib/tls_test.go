@@ -0,0 +1,260 @@
+package httplib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCert is a certificate and key for testing
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert makes a certificate from template signed by parent or
+// self signed if parent is nil
+func newTestCert(t *testing.T, template *x509.Certificate, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// newTestCA makes a certificate authority
+func newTestCA(t *testing.T, name string) *testCert {
+	return newTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+}
+
+// newTestServerCert makes a server certificate for localhost
+func newTestServerCert(t *testing.T, ca *testCert, serial int64) *testCert {
+	return newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}, ca)
+}
+
+// writeFile writes data to name in dir returning the path
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+	return path
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "Test CA")
+	cert1 := newTestServerCert(t, ca, 2)
+	certFile := writeFile(t, dir, "cert.pem", cert1.certPEM)
+	keyFile := writeFile(t, dir, "key.pem", cert1.keyPEM)
+	caFile := writeFile(t, dir, "ca.pem", ca.certPEM)
+
+	r, err := newCertReloader(&tls.Config{MinVersion: tls.VersionTLS12}, certFile, keyFile, caFile)
+	require.NoError(t, err)
+	defer r.close()
+
+	config, err := r.TLSConfig().GetConfigForClient(nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+	require.Equal(t, 1, len(config.Certificates))
+	assert.Equal(t, cert1.cert.Raw, config.Certificates[0].Certificate[0])
+
+	// nothing changed so nothing is reloaded
+	r.reloadIfChanged(false)
+	config2, err := r.getConfigForClient(nil)
+	require.NoError(t, err)
+	assert.True(t, config == config2)
+
+	// a bad certificate is ignored
+	writeFile(t, dir, "cert.pem", []byte("potato"))
+	r.reloadIfChanged(true)
+	config2, err = r.getConfigForClient(nil)
+	require.NoError(t, err)
+	assert.True(t, config == config2)
+
+	// a new certificate is loaded when the files change
+	cert2 := newTestServerCert(t, ca, 3)
+	writeFile(t, dir, "cert.pem", cert2.certPEM)
+	writeFile(t, dir, "key.pem", cert2.keyPEM)
+	r.reloadIfChanged(false)
+	config2, err = r.getConfigForClient(nil)
+	require.NoError(t, err)
+	assert.Equal(t, cert2.cert.Raw, config2.Certificates[0].Certificate[0])
+
+	// errors on start
+	_, err = newCertReloader(&tls.Config{}, filepath.Join(dir, "missing"), keyFile, "")
+	assert.Error(t, err)
+	_, err = newCertReloader(&tls.Config{}, certFile, keyFile, keyFile)
+	assert.Error(t, err)
+}
+
+func TestClientCertUser(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "Test CA")
+	serverCert := newTestServerCert(t, ca, 2)
+	clientURI, err := url.Parse("spiffe://example.com/alice")
+	require.NoError(t, err)
+	clientCert := newTestCert(t, &x509.Certificate{
+		SerialNumber:   big.NewInt(3),
+		Subject:        pkix.Name{CommonName: "alice"},
+		EmailAddresses: []string{"alice@example.com"},
+		URIs:           []*url.URL{clientURI},
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+	}, ca)
+	clientKeyPair, err := tls.X509KeyPair(clientCert.certPEM, clientCert.keyPEM)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := &http.Client{
+		Transport: &http.Transport{
+			ForceAttemptHTTP2: true,
+			TLSClientConfig: &tls.Config{
+				RootCAs:      roots,
+				Certificates: []tls.Certificate{clientKeyPair},
+			},
+		},
+	}
+
+	for _, test := range []struct {
+		field  string
+		status int
+		want   string
+	}{
+		{"cn", http.StatusOK, "alice"},
+		{"email", http.StatusOK, "alice@example.com"},
+		{"uri", http.StatusOK, "spiffe://example.com/alice"},
+		{"dns", http.StatusUnauthorized, ""},
+	} {
+		t.Run(test.field, func(t *testing.T) {
+			opt := DefaultOpt
+			opt.ListenAddr = "localhost:0"
+			opt.SslCert = writeFile(t, dir, "cert.pem", serverCert.certPEM)
+			opt.SslKey = writeFile(t, dir, "key.pem", serverCert.keyPEM)
+			opt.ClientCA = writeFile(t, dir, "ca.pem", ca.certPEM)
+			opt.ClientCertUser = test.field
+			s := NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				user, _ := r.Context().Value(ContextUserKey).(string)
+				_, _ = w.Write([]byte(user))
+			}), &opt)
+			require.NoError(t, s.Serve())
+			defer func() {
+				s.Close()
+				s.Wait()
+			}()
+			assert.True(t, s.UsingAuth())
+
+			resp, err := client.Get(s.URL())
+			require.NoError(t, err)
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			assert.Equal(t, test.status, resp.StatusCode)
+			if test.status == http.StatusOK {
+				assert.Equal(t, test.want, string(body))
+				assert.Equal(t, "HTTP/2.0", resp.Proto)
+			}
+		})
+	}
+}
+
+// TestClientCertUserFallback checks clients without a certificate
+// can use the other authentication methods
+func TestClientCertUserFallback(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "Test CA")
+	serverCert := newTestServerCert(t, ca, 2)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs: roots,
+			},
+		},
+	}
+
+	opt := DefaultOpt
+	opt.ListenAddr = "localhost:0"
+	opt.SslCert = writeFile(t, dir, "cert.pem", serverCert.certPEM)
+	opt.SslKey = writeFile(t, dir, "key.pem", serverCert.keyPEM)
+	opt.ClientCA = writeFile(t, dir, "ca.pem", ca.certPEM)
+	opt.ClientCertUser = "cn"
+	opt.BasicUser = "bob"
+	opt.BasicPass = "secret"
+	s := NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ := r.Context().Value(ContextUserKey).(string)
+		_, _ = w.Write([]byte(user))
+	}), &opt)
+	require.NoError(t, s.Serve())
+	defer func() {
+		s.Close()
+		s.Wait()
+	}()
+
+	get := func(user, pass string) (int, string) {
+		req, err := http.NewRequest("GET", s.URL(), nil)
+		require.NoError(t, err)
+		if user != "" {
+			req.SetBasicAuth(user, pass)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp.StatusCode, string(body)
+	}
+
+	status, _ := get("", "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = get("bob", "potato")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, body := get("bob", "secret")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "bob", body)
+}
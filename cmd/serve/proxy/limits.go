@@ -0,0 +1,358 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/vfs"
+)
+
+// Errors returned when an upload breaks the Limits
+var (
+	ErrUploadTooLarge = errors.New("upload too large")
+	ErrQuotaExceeded  = errors.New("quota exceeded")
+)
+
+// Limits are the per-user settings the proxy can return along with
+// the config for the backend.
+//
+// A nil *Limits has no limits so the methods may be called on it.
+type Limits struct {
+	ReadOnly      bool          // if set the user can't make changes
+	AppendOnly    bool          // if set the user can't delete or overwrite data (restic only)
+	MaxUploadSize fs.SizeSuffix // largest file the user can upload or -1 for no limit
+	Quota         fs.SizeSuffix // most bytes the user can store or -1 for no limit
+
+	ctx  context.Context
+	f    fs.Fs
+	mu   sync.Mutex
+	used int64 // bytes in use or -1 if not known yet
+}
+
+// parseLimits reads the Limits from the config returned by the proxy
+//
+// It returns nil if there aren't any.
+func parseLimits(config configmap.Simple) (l *Limits, err error) {
+	readOnly, hasReadOnly := config.Get("_read_only")
+	maxUploadSize, hasMaxUploadSize := config.Get("_max_upload_size")
+	quota, hasQuota := config.Get("_quota")
+	appendOnly, hasAppendOnly := config.Get("_append_only")
+	if !hasReadOnly && !hasMaxUploadSize && !hasQuota && !hasAppendOnly {
+		return nil, nil
+	}
+	l = &Limits{
+		MaxUploadSize: -1,
+		Quota:         -1,
+		used:          -1,
+	}
+	if hasReadOnly {
+		l.ReadOnly, err = strconv.ParseBool(readOnly)
+		if err != nil {
+			return nil, errors.Wrap(err, "proxy: bad _read_only")
+		}
+	}
+	if hasAppendOnly {
+		l.AppendOnly, err = strconv.ParseBool(appendOnly)
+		if err != nil {
+			return nil, errors.Wrap(err, "proxy: bad _append_only")
+		}
+	}
+	if hasMaxUploadSize {
+		err = l.MaxUploadSize.Set(maxUploadSize)
+		if err != nil {
+			return nil, errors.Wrap(err, "proxy: bad _max_upload_size")
+		}
+	}
+	if hasQuota {
+		err = l.Quota.Set(quota)
+		if err != nil {
+			return nil, errors.Wrap(err, "proxy: bad _quota")
+		}
+	}
+	return l, nil
+}
+
+// setFs sets the backend the Limits apply to
+func (l *Limits) setFs(ctx context.Context, f fs.Fs) {
+	l.ctx = ctx
+	l.f = f
+}
+
+// _readUsed reads how much the user has stored from the backend if
+// it hasn't been read already - call with mu held.
+//
+// If the backend can't say then the usage starts at 0 and is a
+// running tally of the bytes uploaded.
+func (l *Limits) _readUsed() {
+	if l.used >= 0 {
+		return
+	}
+	l.used = 0
+	if l.f == nil {
+		return
+	}
+	if about := l.f.Features().About; about != nil {
+		usage, err := about(l.ctx)
+		if err == nil && usage.Used != nil {
+			l.used = *usage.Used
+			return
+		}
+		if err != nil {
+			fs.Errorf(l.f, "quota: failed to read usage: %v", err)
+		}
+	}
+	fs.Debugf(l.f, "quota: backend doesn't report usage so counting uploads from 0")
+}
+
+// Used returns the number of bytes the user is using
+func (l *Limits) Used() int64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l._readUsed()
+	return l.used
+}
+
+// add adds n bytes to the usage, returning ErrQuotaExceeded without
+// adding them if that would take the user over quota.
+func (l *Limits) add(n int64) error {
+	if l.Quota < 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l._readUsed()
+	if l.used+n > int64(l.Quota) {
+		return ErrQuotaExceeded
+	}
+	l.used += n
+	return nil
+}
+
+// Freed takes n bytes off the usage. It should be called when data
+// stops being stored, for example when a file is deleted or
+// overwritten, with the size returned by Size before the change.
+func (l *Limits) Freed(n int64) {
+	if l == nil || l.Quota < 0 || n <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.used < 0 {
+		// not read yet so will be up to date when it is
+		return
+	}
+	l.used -= n
+	if l.used < 0 {
+		l.used = 0
+	}
+}
+
+// Size returns the bytes stored in name on VFS, including everything
+// in it if it is a directory, or 0 if it doesn't exist.
+//
+// It returns 0 without looking if there is no quota.
+func (l *Limits) Size(VFS *vfs.VFS, name string) (size int64) {
+	if l == nil || l.Quota < 0 {
+		return 0
+	}
+	node, err := VFS.Stat(name)
+	if err != nil {
+		return 0
+	}
+	return nodeSize(node)
+}
+
+// nodeSize returns the bytes stored in node and everything in it
+func nodeSize(node vfs.Node) (size int64) {
+	dir, ok := node.(*vfs.Dir)
+	if !ok {
+		return node.Size()
+	}
+	nodes, err := dir.ReadDirAll()
+	if err != nil {
+		fs.Errorf(dir, "quota: failed to read directory: %v", err)
+		return 0
+	}
+	for _, node := range nodes {
+		size += nodeSize(node)
+	}
+	return size
+}
+
+// Upload checks the data written by a single upload against the
+// Limits.
+type Upload struct {
+	l        *Limits
+	name     string
+	mu       sync.Mutex
+	end      int64 // highest offset written so far
+	added    int64 // bytes added to the usage
+	replaces int64 // size of the data the upload replaces when done
+}
+
+// NewUpload starts checking an upload to name which is size bytes
+// long already (0 unless appending to it).
+func (l *Limits) NewUpload(name string, size int64) *Upload {
+	return &Upload{
+		l:    l,
+		name: name,
+		end:  size,
+	}
+}
+
+// Check should be called before writing up to offset end in the
+// file. It returns an error if that would break the Limits.
+func (u *Upload) Check(end int64) error {
+	if u.l == nil {
+		return nil
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if end <= u.end {
+		return nil
+	}
+	if u.l.MaxUploadSize >= 0 && end > int64(u.l.MaxUploadSize) {
+		fs.Infof(u.name, "Upload rejected: bigger than %v", u.l.MaxUploadSize)
+		return ErrUploadTooLarge
+	}
+	// data up to the size of what is being replaced is already
+	// counted
+	start := u.end
+	if start < u.replaces {
+		start = u.replaces
+	}
+	if end > start {
+		err := u.l.add(end - start)
+		if err != nil {
+			fs.Infof(u.name, "Upload rejected: over quota of %v", u.l.Quota)
+			return err
+		}
+		u.added += end - start
+	}
+	u.end = end
+	return nil
+}
+
+// Replaces should be called before the upload starts if it will
+// replace data of size bytes when it is done, like an object being
+// overwritten. Only the data beyond that size is counted while
+// uploading and Done takes the rest off the usage if the upload is
+// smaller.
+func (u *Upload) Replaces(size int64) {
+	if u.l == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.replaces = size
+}
+
+// Done should be called when the upload has finished successfully
+func (u *Upload) Done() {
+	if u.l == nil {
+		return
+	}
+	u.mu.Lock()
+	freed := u.replaces - u.end
+	u.replaces = 0
+	u.mu.Unlock()
+	u.l.Freed(freed)
+}
+
+// Removed should be called if the file being uploaded is removed,
+// for example because the upload failed. It takes the bytes the
+// upload added off the usage.
+func (u *Upload) Removed() {
+	if u.l == nil {
+		return
+	}
+	u.mu.Lock()
+	added := u.added
+	u.added = 0
+	u.mu.Unlock()
+	u.l.Freed(added)
+}
+
+// Reader returns a reader which reads from in returning an error
+// instead of data which would break the Limits. The data read is
+// assumed to be written after the data already in the file.
+func (u *Upload) Reader(in io.Reader) io.Reader {
+	if u.l == nil {
+		return in
+	}
+	return &uploadReader{u: u, in: in, pos: u.end}
+}
+
+type uploadReader struct {
+	u   *Upload
+	in  io.Reader
+	pos int64 // offset in the file of the next byte read
+}
+
+func (r *uploadReader) Read(p []byte) (n int, err error) {
+	n, err = r.in.Read(p)
+	if n > 0 {
+		checkErr := r.u.Check(r.pos + int64(n))
+		if checkErr != nil {
+			return 0, checkErr
+		}
+		r.pos += int64(n)
+	}
+	return n, err
+}
+
+// Writer returns a writer which writes to w, which is positioned at
+// offset in the file, returning an error instead of writing data
+// which would break the Limits.
+func (u *Upload) Writer(w io.Writer, offset int64) io.Writer {
+	if u.l == nil {
+		return w
+	}
+	return &uploadWriter{u: u, w: w, pos: offset}
+}
+
+type uploadWriter struct {
+	u   *Upload
+	w   io.Writer
+	pos int64 // offset in the file of the next byte written
+}
+
+func (w *uploadWriter) Write(p []byte) (n int, err error) {
+	err = w.u.Check(w.pos + int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	n, err = w.w.Write(p)
+	w.pos += int64(n)
+	return n, err
+}
+
+// WriterAt returns a WriterAt which writes to w returning an error
+// instead of writing data which would break the Limits.
+func (u *Upload) WriterAt(w io.WriterAt) io.WriterAt {
+	if u.l == nil {
+		return w
+	}
+	return &uploadWriterAt{u: u, w: w}
+}
+
+type uploadWriterAt struct {
+	u *Upload
+	w io.WriterAt
+}
+
+func (w *uploadWriterAt) WriteAt(p []byte, off int64) (n int, err error) {
+	err = w.u.Check(off + int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	return w.w.WriteAt(p, off)
+}
@@ -0,0 +1,195 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimits(t *testing.T) {
+	l, err := parseLimits(configmap.Simple{"type": "local"})
+	require.NoError(t, err)
+	assert.Nil(t, l)
+
+	l, err = parseLimits(configmap.Simple{"_quota": "1k"})
+	require.NoError(t, err)
+	require.NotNil(t, l)
+	assert.False(t, l.ReadOnly)
+	assert.Equal(t, fs.SizeSuffix(-1), l.MaxUploadSize)
+	assert.Equal(t, fs.SizeSuffix(1024), l.Quota)
+
+	l, err = parseLimits(configmap.Simple{"_read_only": "true", "_max_upload_size": "10b"})
+	require.NoError(t, err)
+	require.NotNil(t, l)
+	assert.True(t, l.ReadOnly)
+	assert.Equal(t, fs.SizeSuffix(10), l.MaxUploadSize)
+	assert.Equal(t, fs.SizeSuffix(-1), l.Quota)
+
+	l, err = parseLimits(configmap.Simple{"_append_only": "true"})
+	require.NoError(t, err)
+	require.NotNil(t, l)
+	assert.True(t, l.AppendOnly)
+	assert.False(t, l.ReadOnly)
+
+	_, err = parseLimits(configmap.Simple{"_read_only": "potato"})
+	assert.Error(t, err)
+	_, err = parseLimits(configmap.Simple{"_append_only": "potato"})
+	assert.Error(t, err)
+	_, err = parseLimits(configmap.Simple{"_quota": "potato"})
+	assert.Error(t, err)
+}
+
+func TestLimitsNil(t *testing.T) {
+	var l *Limits
+	u := l.NewUpload("file", 0)
+	assert.NoError(t, u.Check(1<<40))
+	in := strings.NewReader("hello")
+	assert.Equal(t, io.Reader(in), u.Reader(in))
+	assert.Equal(t, int64(0), l.Used())
+	l.Freed(10)
+	assert.Equal(t, int64(0), l.Size(nil, "file"))
+	u.Replaces(10)
+	u.Done()
+	u.Removed()
+}
+
+func TestLimitsUpload(t *testing.T) {
+	l, err := parseLimits(configmap.Simple{"_max_upload_size": "10b", "_quota": "15b"})
+	require.NoError(t, err)
+
+	// too big
+	u := l.NewUpload("big", 0)
+	_, err = ioutil.ReadAll(u.Reader(strings.NewReader("0123456789A")))
+	assert.Equal(t, ErrUploadTooLarge, err)
+	assert.Equal(t, int64(0), l.Used())
+
+	// fits
+	u = l.NewUpload("one", 0)
+	data, err := ioutil.ReadAll(u.Reader(strings.NewReader("0123456789")))
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+	assert.Equal(t, int64(10), l.Used())
+
+	// rewriting the same bytes doesn't count twice
+	var buf bytes.Buffer
+	w := u.Writer(&buf, 0)
+	_, err = w.Write([]byte("01234"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), l.Used())
+
+	// appending is checked against the size of the file
+	u = l.NewUpload("one", 10)
+	_, err = u.Writer(&buf, 10).Write([]byte("A"))
+	assert.Equal(t, ErrUploadTooLarge, err)
+
+	// over quota
+	u = l.NewUpload("two", 0)
+	wa := u.WriterAt(nopWriterAt{})
+	_, err = wa.WriteAt([]byte("01234"), 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), l.Used())
+	_, err = wa.WriteAt([]byte("5"), 5)
+	assert.Equal(t, ErrQuotaExceeded, err)
+	_, err = wa.WriteAt([]byte("0"), 4)
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), l.Used())
+}
+
+type nopWriterAt struct{}
+
+func (nopWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	return len(p), nil
+}
+
+func TestLimitsFreed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rclone-limits-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "dir", "sub"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "dir", "a"), []byte("aaa"), 0666))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "dir", "sub", "b"), []byte("bbbb"), 0666))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "c"), []byte("ccccc"), 0666))
+	f, err := fs.NewFs(context.Background(), dir)
+	require.NoError(t, err)
+	VFS := vfs.New(f, nil)
+	defer VFS.Shutdown()
+
+	l, err := parseLimits(configmap.Simple{"_quota": "100b"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), l.Size(VFS, "dir"))
+	assert.Equal(t, int64(5), l.Size(VFS, "c"))
+	assert.Equal(t, int64(0), l.Size(VFS, "missing"))
+
+	_, err = l.NewUpload("file", 0).Writer(ioutil.Discard, 0).Write(make([]byte, 12))
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), l.Used())
+
+	// deleting
+	l.Freed(l.Size(VFS, "dir"))
+	assert.Equal(t, int64(5), l.Used())
+	l.Freed(100)
+	assert.Equal(t, int64(0), l.Used())
+
+	// no quota so no need to look
+	l, err = parseLimits(configmap.Simple{"_max_upload_size": "100b"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), l.Size(VFS, "dir"))
+}
+
+func TestLimitsReplaces(t *testing.T) {
+	l, err := parseLimits(configmap.Simple{"_quota": "15b"})
+	require.NoError(t, err)
+	write := func(u *Upload, n int) error {
+		_, err := ioutil.ReadAll(u.Reader(bytes.NewReader(make([]byte, n))))
+		return err
+	}
+
+	u := l.NewUpload("file", 0)
+	require.NoError(t, write(u, 10))
+	u.Done()
+	assert.Equal(t, int64(10), l.Used())
+
+	// overwriting with a bigger file only counts the difference
+	u = l.NewUpload("file", 0)
+	u.Replaces(10)
+	require.NoError(t, write(u, 14))
+	assert.Equal(t, int64(14), l.Used())
+	u.Done()
+	assert.Equal(t, int64(14), l.Used())
+
+	// overwriting with a smaller file frees the difference
+	u = l.NewUpload("file", 0)
+	u.Replaces(14)
+	require.NoError(t, write(u, 4))
+	assert.Equal(t, int64(14), l.Used())
+	u.Done()
+	assert.Equal(t, int64(4), l.Used())
+
+	// a failed overwrite leaves the old file
+	u = l.NewUpload("file", 0)
+	u.Replaces(4)
+	require.NoError(t, write(u, 8))
+	assert.Equal(t, int64(8), l.Used())
+	u.Removed()
+	assert.Equal(t, int64(4), l.Used())
+
+	// over quota
+	u = l.NewUpload("file", 0)
+	u.Replaces(4)
+	assert.Equal(t, ErrQuotaExceeded, write(u, 16))
+	u.Removed()
+	assert.Equal(t, int64(4), l.Used())
+}
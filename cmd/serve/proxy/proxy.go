@@ -48,8 +48,23 @@ config.
 This config generated must have this extra parameter
 - |_root| - root to use for the backend
 
-And it may have this parameter
+And it may have these parameters
 - |_obscure| - comma separated strings for parameters to obscure
+- |_read_only| - set to |true| to stop the user making any changes
+- |_max_upload_size| - largest file the user may upload, e.g. |100M|
+- |_quota| - most data the user may store, e.g. |10G|
+- |_append_only| - set to |true| to stop the user deleting or
+  overwriting repository data with |rclone serve restic|
+
+Sizes are in KiB unless a suffix is given.
+
+The quota is checked against the space the backend says is used if
+it supports the about command, otherwise against a running tally of
+the data the user has uploaded since the proxy was called. Deleting or
+overwriting files takes their size off the usage. Uploads
+which would go over the quota or the maximum upload size fail part
+way through. These limits are enforced by the ftp, sftp, webdav and
+restic servers.
 
 If password authentication was used by the client, input to the proxy
 process (on STDIN) would look similar to this:
@@ -126,6 +141,7 @@ type Proxy struct {
 // cacheEntry is what is stored in the vfsCache
 type cacheEntry struct {
 	vfs    *vfs.VFS          // stored VFS
+	limits *Limits           // per-user limits or nil for none
 	pwHash [sha256.Size]byte // sha256 hash of the password/publicKey
 }
 
@@ -210,6 +226,11 @@ func (p *Proxy) call(user, auth string, isPublicKey bool) (value interface{}, er
 		return nil, errors.New("proxy: _root not set in result")
 	}
 
+	limits, err := parseLimits(config)
+	if err != nil {
+		return nil, err
+	}
+
 	// Find the backend
 	fsInfo, err := fs.Find(fsName)
 	if err != nil {
@@ -240,8 +261,14 @@ func (p *Proxy) call(user, auth string, isPublicKey bool) (value interface{}, er
 		// We hash the auth here so we don't copy the auth more than we
 		// need to in memory. An attacker would find it easier to go
 		// after the unencrypted password in memory most likely.
+		vfsOpt := vfsflags.Opt
+		if limits != nil {
+			limits.setFs(p.ctx, f)
+			vfsOpt.ReadOnly = vfsOpt.ReadOnly || limits.ReadOnly
+		}
 		entry := cacheEntry{
-			vfs:    vfs.New(f, &vfsflags.Opt),
+			vfs:    vfs.New(f, &vfsOpt),
+			limits: limits,
 			pwHash: sha256.Sum256([]byte(auth)),
 		}
 		return entry, true, nil
@@ -297,3 +324,14 @@ func (p *Proxy) Get(key string) *vfs.VFS {
 	entry := value.(cacheEntry)
 	return entry.vfs
 }
+
+// Limits gets the per-user limits from the cache using key - returns
+// nil if not found or if the user doesn't have any.
+func (p *Proxy) Limits(key string) *Limits {
+	value, ok := p.vfsCache.GetMaybe(key)
+	if !ok {
+		return nil
+	}
+	entry := value.(cacheEntry)
+	return entry.limits
+}
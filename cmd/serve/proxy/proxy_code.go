@@ -34,6 +34,11 @@ func main() {
 	if out["_root"] == "" {
 		out["_root"] = ""
 	}
+	if in["user"] == "limited" {
+		out["_read_only"] = "true"
+		out["_max_upload_size"] = "1k"
+		out["_quota"] = "1M"
+	}
 	json.NewEncoder(os.Stdout).Encode(&out)
 	if err != nil {
 		log.Fatal(err)
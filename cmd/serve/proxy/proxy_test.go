@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/configmap"
 	"github.com/rclone/rclone/fs/config/obscure"
 	"github.com/stretchr/testify/assert"
@@ -147,6 +148,26 @@ func TestRun(t *testing.T) {
 
 	})
 
+	t.Run("Call w/Limits", func(t *testing.T) {
+		defer p.vfsCache.Clear()
+
+		vfs, vfsKey, err := p.Call(testUser, testPass, false)
+		require.NoError(t, err)
+		assert.False(t, vfs.Opt.ReadOnly)
+		assert.Nil(t, p.Limits(vfsKey))
+
+		vfs, vfsKey, err = p.Call("limited", testPass, false)
+		require.NoError(t, err)
+		assert.True(t, vfs.Opt.ReadOnly)
+		limits := p.Limits(vfsKey)
+		require.NotNil(t, limits)
+		assert.True(t, limits.ReadOnly)
+		assert.Equal(t, fs.SizeSuffix(1024), limits.MaxUploadSize)
+		assert.Equal(t, fs.SizeSuffix(1024*1024), limits.Quota)
+
+		assert.Nil(t, p.Limits("unknown"))
+	})
+
 	privateKey, privateKeyErr := rsa.GenerateKey(rand.Reader, 2048)
 	if privateKeyErr != nil {
 		log.Fatal("error generating test private key " + privateKeyErr.Error())
@@ -0,0 +1,101 @@
+package restic
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/cmd/serve/proxy"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/file"
+)
+
+// Names of the groups in the --htgroup file which set the policy
+const (
+	appendOnlyGroup = "append-only"
+	readOnlyGroup   = "read-only"
+	statsGroup      = "stats"
+)
+
+// policy is what a user may do to the repositories
+type policy struct {
+	appendOnly bool // can't delete or overwrite repository data
+	readOnly   bool // can't write anything but locks
+	allStats   bool // can see the stats for all the repositories
+}
+
+// groupFile is an apache style group file, which has lines of
+// "group: user1 user2", reloaded when it changes.
+type groupFile struct {
+	file *file.Reloader
+}
+
+// newGroupFile reads the groups from path
+func newGroupFile(path string) (*groupFile, error) {
+	f, err := file.NewReloader(path, func(data []byte) (interface{}, error) {
+		groups, err := parseGroups(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse group file %q", path)
+		}
+		return groups, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read group file")
+	}
+	return &groupFile{file: f}, nil
+}
+
+// parseGroups parses the group file contents
+func parseGroups(data []byte) (map[string]map[string]struct{}, error) {
+	groups := make(map[string]map[string]struct{})
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		colon := strings.IndexRune(line, ':')
+		if colon <= 0 {
+			return nil, errors.Errorf("line %d: expecting \"group: user...\"", lineNumber)
+		}
+		group := strings.TrimSpace(line[:colon])
+		users := groups[group]
+		if users == nil {
+			users = make(map[string]struct{})
+			groups[group] = users
+		}
+		for _, user := range strings.Fields(line[colon+1:]) {
+			users[user] = struct{}{}
+		}
+	}
+	return groups, scanner.Err()
+}
+
+// inGroup returns true if user is in group
+func (g *groupFile) inGroup(user, group string) bool {
+	groups, err := g.file.Get()
+	if err != nil {
+		fs.Errorf(nil, "Keeping old groups: %v", err)
+	}
+	_, found := groups.(map[string]map[string]struct{})[group][user]
+	return found
+}
+
+// getPolicy works out the policy for user from the global flags, the
+// group file and the limits from the auth proxy
+func (s *Server) getPolicy(user string, limits *proxy.Limits) (p policy) {
+	p.appendOnly = appendOnly
+	if s.groups != nil && user != "" {
+		p.appendOnly = p.appendOnly || s.groups.inGroup(user, appendOnlyGroup)
+		p.readOnly = s.groups.inGroup(user, readOnlyGroup)
+		p.allStats = s.groups.inGroup(user, statsGroup)
+	}
+	if limits != nil {
+		p.appendOnly = p.appendOnly || limits.AppendOnly
+		p.readOnly = p.readOnly || limits.ReadOnly
+	}
+	return p
+}
@@ -5,17 +5,24 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io/ioutil"
+	"log"
 	"net/http"
 	"os"
 	"path"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/cmd/serve/auth"
+	"github.com/rclone/rclone/cmd/serve/auth/authflags"
 	"github.com/rclone/rclone/cmd/serve/httplib"
 	"github.com/rclone/rclone/cmd/serve/httplib/httpflags"
 	"github.com/rclone/rclone/cmd/serve/httplib/serve"
+	"github.com/rclone/rclone/cmd/serve/proxy"
+	"github.com/rclone/rclone/cmd/serve/proxy/proxyflags"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/accounting"
 	"github.com/rclone/rclone/fs/config/flags"
@@ -23,6 +30,7 @@ import (
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fs/walk"
 	"github.com/rclone/rclone/lib/terminal"
+	"github.com/rclone/rclone/vfs"
 	"github.com/spf13/cobra"
 	"golang.org/x/net/http2"
 )
@@ -32,15 +40,21 @@ var (
 	appendOnly   bool
 	privateRepos bool
 	cacheObjects bool
+	htgroup      string
+	statsEnabled bool
 )
 
 func init() {
 	httpflags.AddFlags(Command.Flags())
 	flagSet := Command.Flags()
+	authflags.AddFlagsPrefix(flagSet, "", &authflags.Opt)
 	flags.BoolVarP(flagSet, &stdio, "stdio", "", false, "run an HTTP2 server on stdin/stdout")
 	flags.BoolVarP(flagSet, &appendOnly, "append-only", "", false, "disallow deletion of repository data")
 	flags.BoolVarP(flagSet, &privateRepos, "private-repos", "", false, "users can only access their private repo")
 	flags.BoolVarP(flagSet, &cacheObjects, "cache-objects", "", true, "cache listed objects")
+	flags.StringVarP(flagSet, &htgroup, "htgroup", "", "", "Group file to set the policy for each user with")
+	flags.BoolVarP(flagSet, &statsEnabled, "repo-stats", "", false, "Serve the stats for each repository on /stats and /metrics")
+	proxyflags.AddFlags(flagSet)
 }
 
 // Command definition for cobra
@@ -127,13 +141,77 @@ these **must** end with /.  Eg
 #### Private repositories ####
 
 The "--private-repos" flag can be used to limit users to repositories starting
-with a path of ` + "`/<username>/`" + `.
-` + httplib.Help,
+with a path of ` + "`/<username>/`" + `. This works with users
+authenticated by the authentication backends below too.
+
+#### Policies for each user ####
+
+The "--append-only" flag stops all users deleting or overwriting
+repository data, which makes it safe against a compromised client
+running "restic forget --prune". Only lock files may be deleted.
+
+To set this for some users only, use "--htgroup" with an apache style
+group file, which has lines like
+
+    append-only: alice bob
+    read-only: carol
+    stats: monitor
+
+Users in the "append-only" group are append only as above. Users in
+the "read-only" group can read the repositories but can't change
+anything except the lock files, so restic can still lock the
+repository to read it. Users in the "stats" group can see the stats
+for all the repositories (see below). The file is re-read when it
+changes.
+
+When used with "--auth-proxy" each user gets the backend the proxy
+returns for them. The proxy can set "_append_only" and "_read_only"
+to "true" to set the policy for the user, and "_max_upload_size" and
+"_quota" to limit what they can upload.
+
+#### Repository statistics ####
+
+If "--repo-stats" is set then rclone serves statistics for each
+repository: as JSON on "/stats" and for Prometheus on "/metrics".
+These give the number of objects and bytes stored in each repository,
+along with the bytes and objects transferred, deletes and errors
+since rclone started. The transfers are kept in the rclone stats
+group "restic:/path/to/repo/" so can be seen with the "core/stats" rc
+command too.
+
+The objects are counted by listing the remote the first time the
+stats are read and kept up to date as objects are uploaded and
+deleted after that.
+
+With "--private-repos" users only see the stats for their own
+repositories, and with "--auth-proxy" for the repositories on their
+own backend, unless they are in the "stats" group.
+` + httplib.Help + auth.Help + proxy.Help,
 	Run: func(command *cobra.Command, args []string) {
-		cmd.CheckArgs(1, 1, command, args)
-		f := cmd.NewFsSrc(args)
+		var f fs.Fs
+		if proxyflags.Opt.AuthProxy == "" {
+			cmd.CheckArgs(1, 1, command, args)
+			f = cmd.NewFsSrc(args)
+		} else {
+			cmd.CheckArgs(0, 0, command, args)
+		}
 		cmd.Run(false, true, command, func() error {
-			s := NewServer(f, &httpflags.Opt)
+			opt := &httpflags.Opt
+			if proxyflags.Opt.AuthProxy != "" {
+				if stdio {
+					return errors.New("can't use --auth-proxy with --stdio")
+				}
+				if authflags.Opt.HTTPEnabled() {
+					return errors.New("can't use --auth-proxy with the authentication backends")
+				}
+			} else {
+				var err error
+				opt, err = authflags.HTTPOptions(context.Background(), nil, opt)
+				if err != nil {
+					return err
+				}
+			}
+			s := NewServer(f, opt)
 			if stdio {
 				if terminal.IsTerminal(int(os.Stdout.Fd())) {
 					return errors.New("Refusing to run HTTP2 server directly on a terminal, please let restic start rclone")
@@ -168,22 +246,56 @@ const (
 // Server contains everything to run the Server
 type Server struct {
 	*httplib.Server
-	f     fs.Fs
-	cache *cache
+	f      fs.Fs
+	cache  *cache
+	proxy  *proxy.Proxy
+	groups *groupFile // groups from --htgroup or nil
+	stats  *stats     // repository stats if --repo-stats is set
+
+	cachesMu sync.Mutex
+	caches   map[fs.Fs]*cache // object caches for the backends from the auth proxy
 }
 
 // NewServer returns an HTTP server that speaks the rest protocol
 func NewServer(f fs.Fs, opt *httplib.Options) *Server {
+	ctx := context.Background()
 	mux := http.NewServeMux()
 	s := &Server{
-		Server: httplib.NewServer(mux, opt),
 		f:      f,
 		cache:  newCache(),
+		caches: make(map[fs.Fs]*cache),
 	}
+	if proxyflags.Opt.AuthProxy != "" {
+		s.proxy = proxy.New(ctx, &proxyflags.Opt)
+		// override auth
+		copyOpt := *opt
+		copyOpt.Auth = s.auth
+		opt = &copyOpt
+	}
+	if htgroup != "" {
+		var err error
+		s.groups, err = newGroupFile(htgroup)
+		if err != nil {
+			log.Fatalf("Failed to read --htgroup: %v", err)
+		}
+	}
+	if statsEnabled {
+		s.stats = newStats(ctx)
+	}
+	s.Server = httplib.NewServer(mux, opt)
 	mux.HandleFunc(s.Opt.BaseURL+"/", s.ServeHTTP)
 	return s
 }
 
+// auth does proxy authorization
+func (s *Server) auth(user, pass string) (value interface{}, err error) {
+	VFS, _, err := s.proxy.Call(user, pass, false)
+	if err != nil {
+		return nil, err
+	}
+	return VFS, err
+}
+
 // Serve runs the http server in the background.
 //
 // Use s.Close() and s.Wait() to shutdown server
@@ -215,6 +327,42 @@ func makeRemote(path string) string {
 	return prefix + fileName[:2] + "/" + fileName
 }
 
+// request holds the state for a single request
+type request struct {
+	f      fs.Fs         // backend to use
+	cache  *cache        // object cache for f
+	limits *proxy.Limits // per-user limits from the auth proxy or nil
+	policy policy        // what the user may do
+	stats  *repoStats    // stats for the repository or nil
+}
+
+// getFs gets the backend for the request along with any per-user
+// limits from the auth proxy
+func (s *Server) getFs(ctx context.Context, user string) (f fs.Fs, c *cache, limits *proxy.Limits, err error) {
+	if s.proxy == nil {
+		return s.f, s.cache, nil, nil
+	}
+	VFS, ok := ctx.Value(httplib.ContextAuthKey).(*vfs.VFS)
+	if !ok {
+		return nil, nil, nil, errors.New("no VFS found in context")
+	}
+	f = VFS.Fs()
+	s.cachesMu.Lock()
+	c = s.caches[f]
+	if c == nil {
+		c = newCache()
+		s.caches[f] = c
+	}
+	s.cachesMu.Unlock()
+	return f, c, s.proxy.Limits(user), nil
+}
+
+// isLock returns true if the URL path is for a lock file
+func isLock(urlPath string) bool {
+	parts := strings.Split(urlPath, "/")
+	return len(parts) >= 2 && parts[len(parts)-2] == "locks"
+}
+
 // ServeHTTP reads incoming requests and dispatches them
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Accept-Ranges", "bytes")
@@ -224,33 +372,75 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		return
 	}
+	user, _ := r.Context().Value(httplib.ContextUserKey).(string)
+	f, c, limits, err := s.getFs(r.Context(), user)
+	if err != nil {
+		fs.Errorf(path, "%s request error: %v", r.Method, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	rq := &request{
+		f:      f,
+		cache:  c,
+		limits: limits,
+		policy: s.getPolicy(user, limits),
+	}
+
+	if s.stats != nil && (path == "/stats" || path == "/metrics") {
+		s.serveStats(w, r, f, user, rq.policy)
+		return
+	}
+
 	remote := makeRemote(path)
-	fs.Debugf(s.f, "%s %s", r.Method, path)
+	fs.Debugf(f, "%s %s", r.Method, path)
 
-	v := r.Context().Value(httplib.ContextUserKey)
-	if privateRepos && (v == nil || !strings.HasPrefix(path, "/"+v.(string)+"/")) {
+	if privateRepos && (user == "" || !strings.HasPrefix(path, "/"+user+"/")) {
 		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
 		return
 	}
 
+	// Only locks can be changed by read only users so they can
+	// still lock the repository to read it
+	isDir := strings.HasSuffix(path, "/")
+	if rq.policy.readOnly && (r.Method == "POST" || r.Method == "DELETE") && (isDir || !isLock(path)) {
+		fs.Errorf(remote, "%s request: refusing to change repository for read only user", r.Method)
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	// Account transfers to the repository if it exists or is
+	// being made by uploading its config
+	if s.stats != nil {
+		rq.stats = s.stats.get(s.statsUser(user), f, remote, isDir, func(repo string) bool {
+			if r.Method == "POST" && remote == repoConfig(repo) {
+				return true
+			}
+			_, err := rq.newObject(r.Context(), repoConfig(repo))
+			return err == nil
+		})
+		if rq.stats != nil {
+			r = r.WithContext(accounting.WithStatsGroup(r.Context(), rq.stats.group()))
+		}
+	}
+
 	// Dispatch on path then method
-	if strings.HasSuffix(path, "/") {
+	if isDir {
 		switch r.Method {
 		case "GET":
-			s.listObjects(w, r, remote)
+			s.listObjects(w, r, rq, remote)
 		case "POST":
-			s.createRepo(w, r, remote)
+			s.createRepo(w, r, rq, remote)
 		default:
 			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 		}
 	} else {
 		switch r.Method {
 		case "GET", "HEAD":
-			s.serveObject(w, r, remote)
+			s.serveObject(w, r, rq, remote)
 		case "POST":
-			s.postObject(w, r, remote)
+			s.postObject(w, r, rq, remote)
 		case "DELETE":
-			s.deleteObject(w, r, remote)
+			s.deleteObject(w, r, rq, remote)
 		default:
 			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 		}
@@ -259,22 +449,22 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // newObject returns an object with the remote given either from the
 // cache or directly
-func (s *Server) newObject(ctx context.Context, remote string) (fs.Object, error) {
-	o := s.cache.find(remote)
+func (rq *request) newObject(ctx context.Context, remote string) (fs.Object, error) {
+	o := rq.cache.find(remote)
 	if o != nil {
 		return o, nil
 	}
-	o, err := s.f.NewObject(ctx, remote)
+	o, err := rq.f.NewObject(ctx, remote)
 	if err != nil {
 		return o, err
 	}
-	s.cache.add(remote, o)
+	rq.cache.add(remote, o)
 	return o, nil
 }
 
 // get the remote
-func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, remote string) {
-	o, err := s.newObject(r.Context(), remote)
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, rq *request, remote string) {
+	o, err := rq.newObject(r.Context(), remote)
 	if err != nil {
 		fs.Debugf(remote, "%s request error: %v", r.Method, err)
 		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
@@ -284,44 +474,73 @@ func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, remote stri
 }
 
 // postObject posts an object to the repository
-func (s *Server) postObject(w http.ResponseWriter, r *http.Request, remote string) {
-	if appendOnly {
+func (s *Server) postObject(w http.ResponseWriter, r *http.Request, rq *request, remote string) {
+	old := rq.cache.find(remote)
+	if rq.policy.appendOnly {
 		// make sure the file does not exist yet
-		_, err := s.newObject(r.Context(), remote)
+		var err error
+		old, err = rq.newObject(r.Context(), remote)
 		if err == nil {
 			fs.Errorf(remote, "Post request: file already exists, refusing to overwrite in append-only mode")
 			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
 
 			return
 		}
+	} else if old == nil && rq.limits != nil {
+		// find the size of the object being overwritten for the quota
+		if o, err := rq.newObject(r.Context(), remote); err == nil {
+			old = o
+		}
 	}
 
-	o, err := operations.RcatSize(r.Context(), s.f, remote, r.Body, r.ContentLength, time.Now())
+	// Make the reader before checking the length so the data it
+	// reads isn't counted again
+	upload := rq.limits.NewUpload(remote, 0)
+	if old != nil {
+		upload.Replaces(old.Size())
+	}
+	in := upload.Reader(r.Body)
+	if r.ContentLength >= 0 {
+		if err := upload.Check(r.ContentLength); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+	}
+	o, err := operations.RcatSize(r.Context(), rq.f, remote, ioutil.NopCloser(in), r.ContentLength, time.Now())
 	if err != nil {
+		overLimit := errors.Is(err, proxy.ErrUploadTooLarge) || errors.Is(err, proxy.ErrQuotaExceeded)
 		err = accounting.Stats(r.Context()).Error(err)
 		fs.Errorf(remote, "Post request rcat error: %v", err)
+		upload.Removed() // a failed upload doesn't store the object
+		if overLimit {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 
 		return
 	}
 
 	// if successfully uploaded add to cache
-	s.cache.add(remote, o)
+	rq.cache.add(remote, o)
+	upload.Done()
+	if old != nil {
+		s.stats.add(rq.stats, 0, o.Size()-old.Size())
+	} else {
+		s.stats.add(rq.stats, 1, o.Size())
+	}
 }
 
 // delete the remote
-func (s *Server) deleteObject(w http.ResponseWriter, r *http.Request, remote string) {
-	if appendOnly {
-		parts := strings.Split(r.URL.Path, "/")
-
-		// if path doesn't end in "/locks/:name", disallow the operation
-		if len(parts) < 2 || parts[len(parts)-2] != "locks" {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
-			return
-		}
+func (s *Server) deleteObject(w http.ResponseWriter, r *http.Request, rq *request, remote string) {
+	// if path doesn't end in "/locks/:name", disallow the operation
+	if rq.policy.appendOnly && !isLock(r.URL.Path) {
+		fs.Errorf(remote, "Delete request: refusing to delete in append-only mode")
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
 	}
 
-	o, err := s.newObject(r.Context(), remote)
+	o, err := rq.newObject(r.Context(), remote)
 	if err != nil {
 		fs.Debugf(remote, "Delete request error: %v", err)
 		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
@@ -339,7 +558,12 @@ func (s *Server) deleteObject(w http.ResponseWriter, r *http.Request, remote str
 	}
 
 	// remove object from cache
-	s.cache.remove(remote)
+	rq.cache.remove(remote)
+	rq.limits.Freed(o.Size())
+	if rq.stats != nil {
+		accounting.Stats(r.Context()).Deletes(1)
+		s.stats.add(rq.stats, -1, -o.Size())
+	}
 }
 
 // listItem is an element returned for the restic v2 list response
@@ -360,7 +584,7 @@ func (ls *listItems) add(o fs.Object) {
 }
 
 // listObjects lists all Objects of a given type in an arbitrary order.
-func (s *Server) listObjects(w http.ResponseWriter, r *http.Request, remote string) {
+func (s *Server) listObjects(w http.ResponseWriter, r *http.Request, rq *request, remote string) {
 	fs.Debugf(remote, "list request")
 
 	if r.Header.Get("Accept") != resticAPIV2 {
@@ -373,14 +597,14 @@ func (s *Server) listObjects(w http.ResponseWriter, r *http.Request, remote stri
 	ls := listItems{}
 
 	// Remove all existing values from the cache
-	s.cache.removePrefix(remote)
+	rq.cache.removePrefix(remote)
 
 	// if remote supports ListR use that directly, otherwise use recursive Walk
-	err := walk.ListR(r.Context(), s.f, remote, true, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+	err := walk.ListR(r.Context(), rq.f, remote, true, -1, walk.ListObjects, func(entries fs.DirEntries) error {
 		for _, entry := range entries {
 			if o, ok := entry.(fs.Object); ok {
 				ls.add(o)
-				s.cache.add(o.Remote(), o)
+				rq.cache.add(o.Remote(), o)
 			}
 		}
 		return nil
@@ -407,7 +631,7 @@ func (s *Server) listObjects(w http.ResponseWriter, r *http.Request, remote stri
 // createRepo creates repository directories.
 //
 // We don't bother creating the data dirs as rclone will create them on the fly
-func (s *Server) createRepo(w http.ResponseWriter, r *http.Request, remote string) {
+func (s *Server) createRepo(w http.ResponseWriter, r *http.Request, rq *request, remote string) {
 	fs.Infof(remote, "Creating repository")
 
 	if r.URL.Query().Get("create") != "true" {
@@ -415,7 +639,7 @@ func (s *Server) createRepo(w http.ResponseWriter, r *http.Request, remote strin
 		return
 	}
 
-	err := s.f.Mkdir(r.Context(), remote)
+	err := rq.f.Mkdir(r.Context(), remote)
 	if err != nil {
 		fs.Errorf(remote, "Create repo failed to Mkdir: %v", err)
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -424,7 +648,7 @@ func (s *Server) createRepo(w http.ResponseWriter, r *http.Request, remote strin
 
 	for _, name := range []string{"data", "index", "keys", "locks", "snapshots"} {
 		dirRemote := path.Join(remote, name)
-		err := s.f.Mkdir(r.Context(), dirRemote)
+		err := rq.f.Mkdir(r.Context(), dirRemote)
 		if err != nil {
 			fs.Errorf(dirRemote, "Create repo failed to Mkdir: %v", err)
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
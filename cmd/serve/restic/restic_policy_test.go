@@ -0,0 +1,166 @@
+package restic
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/cmd/serve/httplib"
+	"github.com/rclone/rclone/cmd/serve/httplib/httpflags"
+	"github.com/rclone/rclone/cmd/serve/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newUserRequest returns a new HTTP request from user
+func newUserRequest(t testing.TB, user, method, path string, body string) *http.Request {
+	req := newRequest(t, method, path, strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), httplib.ContextUserKey, user))
+	req.Header.Add("Accept", resticAPIV2)
+	return req
+}
+
+func TestParseGroups(t *testing.T) {
+	groups, err := parseGroups([]byte(`
+# comment
+append-only: alice bob
+read-only:carol
+append-only: dave
+empty:
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]struct{}{
+		"append-only": {"alice": {}, "bob": {}, "dave": {}},
+		"read-only":   {"carol": {}},
+		"empty":       {},
+	}, groups)
+
+	_, err = parseGroups([]byte("potato\n"))
+	assert.EqualError(t, err, `line 1: expecting "group: user..."`)
+	_, err = parseGroups([]byte(": alice\n"))
+	assert.Error(t, err)
+}
+
+func TestGroupFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "htgroup")
+	require.NoError(t, ioutil.WriteFile(path, []byte("read-only: alice\n"), 0600))
+	g, err := newGroupFile(path)
+	require.NoError(t, err)
+	assert.True(t, g.inGroup("alice", readOnlyGroup))
+	assert.False(t, g.inGroup("bob", readOnlyGroup))
+	assert.False(t, g.inGroup("alice", appendOnlyGroup))
+
+	// changes to the file are picked up
+	require.NoError(t, ioutil.WriteFile(path, []byte("read-only: bob\n"), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	assert.False(t, g.inGroup("alice", readOnlyGroup))
+	assert.True(t, g.inGroup("bob", readOnlyGroup))
+
+	_, err = newGroupFile(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+// TestResticPolicies checks the policies from the group file
+func TestResticPolicies(t *testing.T) {
+	dir := t.TempDir()
+	prev := htgroup
+	htgroup = filepath.Join(dir, "htgroup")
+	defer func() {
+		htgroup = prev
+	}()
+	require.NoError(t, ioutil.WriteFile(htgroup, []byte("append-only: alice\nread-only: carol\n"), 0600))
+
+	f := cmd.NewFsSrc([]string{filepath.Join(dir, "repo")})
+	srv := NewServer(f, &httpflags.Opt)
+
+	for _, test := range []struct {
+		user   string
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		// bob has no restrictions
+		{"bob", "POST", "/?create=true", "", http.StatusOK},
+		{"bob", "POST", "/config", "config", http.StatusOK},
+		{"bob", "POST", "/data/1234", "data", http.StatusOK},
+		{"bob", "POST", "/data/1234", "new data", http.StatusOK},
+		{"bob", "POST", "/data/5678", "data", http.StatusOK},
+		{"bob", "DELETE", "/data/5678", "", http.StatusOK},
+
+		// alice is append only
+		{"alice", "POST", "/data/abcd", "data", http.StatusOK},
+		{"alice", "POST", "/data/abcd", "new data", http.StatusForbidden},
+		{"alice", "DELETE", "/data/abcd", "", http.StatusForbidden},
+		{"alice", "POST", "/locks/lock1", "lock", http.StatusOK},
+		{"alice", "DELETE", "/locks/lock1", "", http.StatusOK},
+
+		// carol is read only
+		{"carol", "GET", "/config", "", http.StatusOK},
+		{"carol", "GET", "/data/", "", http.StatusOK},
+		{"carol", "POST", "/?create=true", "", http.StatusForbidden},
+		{"carol", "POST", "/data/efgh", "data", http.StatusForbidden},
+		{"carol", "DELETE", "/data/1234", "", http.StatusForbidden},
+		{"carol", "POST", "/locks/lock2", "lock", http.StatusOK},
+		{"carol", "DELETE", "/locks/lock2", "", http.StatusOK},
+	} {
+		req := newUserRequest(t, test.user, test.method, test.path, test.body)
+		checkRequest(t, srv.ServeHTTP, req, []wantFunc{wantCode(test.code)})
+	}
+
+	// data is as expected
+	checkRequest(t, srv.ServeHTTP, newUserRequest(t, "carol", "GET", "/data/1234", ""),
+		[]wantFunc{wantCode(http.StatusOK), wantBody("new data")})
+	checkRequest(t, srv.ServeHTTP, newUserRequest(t, "carol", "GET", "/data/abcd", ""),
+		[]wantFunc{wantCode(http.StatusOK), wantBody("data")})
+}
+
+// TestResticUploadLimits checks uploads are counted once against the
+// limits from the auth proxy
+func TestResticUploadLimits(t *testing.T) {
+	f := cmd.NewFsSrc([]string{t.TempDir()})
+	srv := NewServer(f, &httpflags.Opt)
+	limits := &proxy.Limits{MaxUploadSize: 1024, Quota: 1536}
+	post := func(remote string, size int) int {
+		req := newRequest(t, "POST", "/"+remote, strings.NewReader(strings.Repeat("x", size)))
+		if remote == "data/stream" {
+			req.Body = ioutil.NopCloser(req.Body)
+			req.ContentLength = -1 // unknown
+		}
+		rq := &request{f: f, cache: srv.cache, limits: limits}
+		rr := httptest.NewRecorder()
+		srv.postObject(rr, req, rq, remote)
+		return rr.Code
+	}
+
+	// close to the limits
+	assert.Equal(t, http.StatusOK, post("data/1", 1000))
+	assert.Equal(t, int64(1000), limits.Used())
+	assert.Equal(t, http.StatusOK, post("data/2", 500))
+	assert.Equal(t, int64(1500), limits.Used())
+
+	// over the limits
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("data/3", 1025))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("data/4", 37))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post("data/stream", 37))
+	assert.Equal(t, int64(1500), limits.Used())
+
+	// overwriting only counts the difference in size
+	assert.Equal(t, http.StatusOK, post("data/1", 1020))
+	assert.Equal(t, int64(1520), limits.Used())
+	assert.Equal(t, http.StatusOK, post("data/1", 100))
+	assert.Equal(t, int64(600), limits.Used())
+
+	// deleting frees the space
+	rr := httptest.NewRecorder()
+	srv.deleteObject(rr, newRequest(t, "DELETE", "/data/2", nil), &request{f: f, cache: srv.cache, limits: limits}, "data/2")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, int64(100), limits.Used())
+}
@@ -0,0 +1,125 @@
+package restic
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/cmd/serve/httplib/httpflags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepoOf(t *testing.T) {
+	for _, test := range []struct {
+		remote string
+		isDir  bool
+		want   string
+		ok     bool
+	}{
+		{"config", false, "", true},
+		{"alice/repo/config", false, "alice/repo", true},
+		{"alice/repo/data/1234", false, "alice/repo", true},
+		{"alice/repo/data/12/1234", false, "alice/repo", true},
+		{"alice/repo/keys/1234", false, "alice/repo", true},
+		{"data/12/1234", false, "", true},
+		{"alice/repo/potato", false, "", false},
+		{"alice/repo/", true, "alice/repo", true},
+		{"alice/repo/data/", true, "alice/repo", true},
+		{"", true, "", true},
+	} {
+		got, ok := repoOf(test.remote, test.isDir)
+		assert.Equal(t, test.ok, ok, test.remote)
+		assert.Equal(t, test.want, got, test.remote)
+	}
+}
+
+// TestResticStats checks the stats served on /stats and /metrics
+func TestResticStats(t *testing.T) {
+	dir := t.TempDir()
+	prev, prevPrivate, prevGroup := statsEnabled, privateRepos, htgroup
+	statsEnabled, privateRepos = true, true
+	htgroup = filepath.Join(dir, "htgroup")
+	defer func() {
+		statsEnabled, privateRepos, htgroup = prev, prevPrivate, prevGroup
+	}()
+	require.NoError(t, ioutil.WriteFile(htgroup, []byte("stats: admin\n"), 0600))
+
+	// an existing repository which is counted by listing
+	root := filepath.Join(dir, "root")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "bob", "repo", "data", "12"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "bob", "repo", "config"), []byte("config"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "bob", "repo", "data", "12", "1234"), []byte("data"), 0600))
+
+	f := cmd.NewFsSrc([]string{root})
+	srv := NewServer(f, &httpflags.Opt)
+
+	getStats := func(user string) (stats []RepoStats) {
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, newUserRequest(t, user, "GET", "/stats", ""))
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &stats))
+		return stats
+	}
+
+	for _, req := range []*http.Request{
+		newUserRequest(t, "alice", "POST", "/alice/repo/?create=true", ""),
+		newUserRequest(t, "alice", "POST", "/alice/repo/config", "config"),
+		newUserRequest(t, "alice", "POST", "/alice/repo/data/5678", "hello"),
+		newUserRequest(t, "alice", "POST", "/alice/repo/locks/lock", "lock"),
+		newUserRequest(t, "alice", "GET", "/alice/repo/config", ""),
+	} {
+		checkRequest(t, srv.ServeHTTP, req, []wantFunc{wantCode(http.StatusOK)})
+	}
+
+	// paths which aren't repositories don't make stats
+	n := len(srv.stats.repos)
+	for _, req := range []*http.Request{
+		newUserRequest(t, "alice", "GET", "/alice/potato/", ""),
+		newUserRequest(t, "alice", "GET", "/alice/potato/config", ""),
+		newUserRequest(t, "alice", "GET", "/alice/potato/data/1234", ""),
+		newUserRequest(t, "alice", "DELETE", "/alice/potato/keys/1234", ""),
+	} {
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+	}
+	assert.Equal(t, n, len(srv.stats.repos))
+
+	// alice only sees her own repository
+	stats := getStats("alice")
+	require.Equal(t, 1, len(stats))
+	assert.Equal(t, "/alice/repo/", stats[0].Path)
+	assert.Equal(t, int64(3), stats[0].Objects)
+	assert.Equal(t, int64(len("config")+len("hello")+len("lock")), stats[0].Bytes)
+	assert.Equal(t, int64(4), stats[0].Transfers)
+
+	// deletes are counted
+	checkRequest(t, srv.ServeHTTP, newUserRequest(t, "alice", "DELETE", "/alice/repo/locks/lock", ""), []wantFunc{wantCode(http.StatusOK)})
+	stats = getStats("alice")
+	require.Equal(t, 1, len(stats))
+	assert.Equal(t, int64(2), stats[0].Objects)
+	assert.Equal(t, int64(len("config")+len("hello")), stats[0].Bytes)
+	assert.Equal(t, int64(1), stats[0].Deletes)
+
+	// admin sees all the repositories
+	stats = getStats("admin")
+	require.Equal(t, 2, len(stats))
+	assert.Equal(t, "/alice/repo/", stats[0].Path)
+	assert.Equal(t, "/bob/repo/", stats[1].Path)
+	assert.Equal(t, int64(2), stats[1].Objects)
+	assert.Equal(t, int64(len("config")+len("data")), stats[1].Bytes)
+
+	// and the metrics
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, newUserRequest(t, "admin", "GET", "/metrics", ""))
+	require.Equal(t, http.StatusOK, rr.Code)
+	metrics := rr.Body.String()
+	assert.True(t, strings.Contains(metrics, `rclone_restic_repo_objects{repo="/bob/repo/",user=""} 2`), metrics)
+	assert.True(t, strings.Contains(metrics, `rclone_restic_repo_files_deleted_total{repo="/alice/repo/",user=""} 1`), metrics)
+}
@@ -0,0 +1,382 @@
+package restic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/walk"
+)
+
+// repoTypes are the directories in a restic repository
+var repoTypes = map[string]bool{
+	"data":      true,
+	"index":     true,
+	"keys":      true,
+	"locks":     true,
+	"snapshots": true,
+}
+
+// repoOf returns the remote of the repository that the object or
+// directory at remote is in, or false if it isn't in one.
+//
+// This understands both the flat layout of the URLs and the layout
+// with data/XX/ directories that makeRemote makes.
+func repoOf(remote string, isDir bool) (repo string, ok bool) {
+	remote = strings.Trim(remote, "/")
+	parts := strings.Split(remote, "/")
+	n := len(parts)
+	join := func(parts []string) string {
+		return strings.Join(parts, "/")
+	}
+	if isDir {
+		if repoTypes[parts[n-1]] {
+			return join(parts[:n-1]), true
+		}
+		return remote, true
+	}
+	switch {
+	case parts[n-1] == "config":
+		return join(parts[:n-1]), true
+	case n >= 2 && repoTypes[parts[n-2]]:
+		return join(parts[:n-2]), true
+	case n >= 3 && parts[n-3] == "data":
+		return join(parts[:n-3]), true
+	}
+	return "", false
+}
+
+// repoStats are the statistics for one repository
+type repoStats struct {
+	user    string // owner if the backend is per-user from the auth proxy
+	path    string // URL path of the repository, e.g. "/alice/repo/"
+	f       fs.Fs  // backend the repository is on
+	remote  string // remote of the repository on f
+	counted bool   // set once the objects have been counted
+	objects int64  // number of objects stored
+	bytes   int64  // bytes stored
+}
+
+// group returns the name of the accounting stats group used for
+// transfers in the repository
+func (rs *repoStats) group() string {
+	if rs.user != "" {
+		return "restic:" + rs.user + ":" + rs.path
+	}
+	return "restic:" + rs.path
+}
+
+// RepoStats is the JSON returned by /stats for each repository
+type RepoStats struct {
+	Path             string `json:"path"`             // URL path of the repository
+	User             string `json:"user,omitempty"`   // user if the backend is from the auth proxy
+	Objects          int64  `json:"objects"`          // number of objects stored
+	Bytes            int64  `json:"bytes"`            // bytes stored
+	BytesTransferred int64  `json:"bytesTransferred"` // bytes uploaded and downloaded
+	Transfers        int64  `json:"transfers"`        // objects uploaded and downloaded
+	Deletes          int64  `json:"deletes"`          // objects deleted
+	Errors           int64  `json:"errors"`           // errors transferring objects
+}
+
+// stats keeps the statistics for each repository
+type stats struct {
+	ctx        context.Context
+	mu         sync.Mutex
+	repos      map[string]*repoStats // by user and path
+	discovered map[fs.Fs]bool        // set if all the repos on the Fs have been found
+}
+
+// newStats makes a new stats
+func newStats(ctx context.Context) *stats {
+	return &stats{
+		ctx:        ctx,
+		repos:      make(map[string]*repoStats),
+		discovered: make(map[fs.Fs]bool),
+	}
+}
+
+// repoPath returns the URL path of the repository at remote
+func repoPath(remote string) string {
+	if remote == "" {
+		return "/"
+	}
+	return "/" + remote + "/"
+}
+
+// repoConfig returns the remote of the config of the repository at
+// remote
+func repoConfig(remote string) string {
+	return path.Join(remote, "config")
+}
+
+// _get finds or makes the repoStats for the repository at remote on
+// f - call with mu held
+func (st *stats) _get(user string, f fs.Fs, remote string) *repoStats {
+	key := user + ":" + repoPath(remote)
+	rs := st.repos[key]
+	if rs == nil {
+		rs = &repoStats{
+			user:   user,
+			path:   repoPath(remote),
+			f:      f,
+			remote: remote,
+		}
+		st.repos[key] = rs
+	}
+	return rs
+}
+
+// get finds the repoStats for the repository that remote is in,
+// returning nil if it isn't in one.
+//
+// The repoStats are only made if exists returns true for the
+// repository so that requests for paths which aren't repositories
+// don't make stats which are kept forever.
+func (st *stats) get(user string, f fs.Fs, remote string, isDir bool, exists func(repo string) bool) *repoStats {
+	repo, ok := repoOf(remote, isDir)
+	if !ok {
+		return nil
+	}
+	st.mu.Lock()
+	rs := st.repos[user+":"+repoPath(repo)]
+	st.mu.Unlock()
+	if rs != nil {
+		return rs
+	}
+	if !exists(repo) {
+		return nil
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st._get(user, f, repo)
+}
+
+// add adds objects and bytes to the repository if it has been
+// counted
+func (st *stats) add(rs *repoStats, objects, bytes int64) {
+	if rs == nil {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if rs.counted {
+		rs.objects += objects
+		rs.bytes += bytes
+	}
+}
+
+// walk counts the objects under remote on f adding them to the
+// repositories they are in.
+//
+// Uploads and deletes made while the walk is running may be counted
+// twice or not at all.
+func (st *stats) walk(user string, f fs.Fs, remote string) error {
+	type count struct {
+		objects, bytes int64
+	}
+	counts := make(map[string]*count)
+	err := walk.ListR(st.ctx, f, remote, true, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			o, ok := entry.(fs.Object)
+			if !ok {
+				continue
+			}
+			repo, ok := repoOf(o.Remote(), false)
+			if !ok {
+				continue
+			}
+			c := counts[repo]
+			if c == nil {
+				c = &count{}
+				counts[repo] = c
+			}
+			c.objects++
+			c.bytes += o.Size()
+		}
+		return nil
+	})
+	if err != nil && err != fs.ErrorDirNotFound {
+		return err
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for repo, c := range counts {
+		rs := st._get(user, f, repo)
+		rs.objects, rs.bytes, rs.counted = c.objects, c.bytes, true
+	}
+	if remote != "" {
+		rs := st._get(user, f, remote)
+		if !rs.counted {
+			rs.objects, rs.bytes, rs.counted = 0, 0, true
+		}
+	}
+	return nil
+}
+
+// report returns the stats for the repositories which visible
+// returns true for, sorted by user and path.
+//
+// If all the repositories on f, which belongs to user, haven't been
+// found yet then they are found first. Any repositories which haven't
+// been counted are counted.
+func (st *stats) report(user string, f fs.Fs, visible func(rs *repoStats) bool) []RepoStats {
+	st.mu.Lock()
+	discover := f != nil && !st.discovered[f]
+	st.mu.Unlock()
+	if discover {
+		fs.Debugf(f, "stats: counting objects in all repositories")
+		err := st.walk(user, f, "")
+		if err != nil {
+			fs.Errorf(f, "stats: failed to count objects: %v", err)
+		} else {
+			st.mu.Lock()
+			st.discovered[f] = true
+			st.mu.Unlock()
+		}
+	}
+
+	st.mu.Lock()
+	var uncounted []*repoStats
+	for _, rs := range st.repos {
+		if !rs.counted && visible(rs) {
+			uncounted = append(uncounted, rs)
+		}
+	}
+	st.mu.Unlock()
+	for _, rs := range uncounted {
+		err := st.walk(rs.user, rs.f, rs.remote)
+		if err != nil {
+			fs.Errorf(rs.path, "stats: failed to count objects: %v", err)
+		}
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	out := []RepoStats{}
+	for _, rs := range st.repos {
+		if !visible(rs) {
+			continue
+		}
+		acc := accounting.StatsGroup(st.ctx, rs.group())
+		if rs.counted && rs.objects == 0 && acc.GetTransfers() == 0 {
+			// skip paths which aren't repositories
+			continue
+		}
+		out = append(out, RepoStats{
+			Path:             rs.path,
+			User:             rs.user,
+			Objects:          rs.objects,
+			Bytes:            rs.bytes,
+			BytesTransferred: acc.GetBytes(),
+			Transfers:        acc.GetTransfers(),
+			Deletes:          acc.Deletes(0),
+			Errors:           acc.GetErrors(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].User != out[j].User {
+			return out[i].User < out[j].User
+		}
+		return out[i].Path < out[j].Path
+	})
+	return out
+}
+
+// Descriptions of the Prometheus metrics for each repository
+var (
+	repoLabels      = []string{"repo", "user"}
+	repoObjectsDesc = prometheus.NewDesc("rclone_restic_repo_objects",
+		"Number of objects stored in the repository", repoLabels, nil)
+	repoBytesDesc = prometheus.NewDesc("rclone_restic_repo_bytes",
+		"Bytes stored in the repository", repoLabels, nil)
+	repoBytesTransferredDesc = prometheus.NewDesc("rclone_restic_repo_bytes_transferred_total",
+		"Bytes uploaded to and downloaded from the repository", repoLabels, nil)
+	repoTransfersDesc = prometheus.NewDesc("rclone_restic_repo_files_transferred_total",
+		"Objects uploaded to and downloaded from the repository", repoLabels, nil)
+	repoDeletesDesc = prometheus.NewDesc("rclone_restic_repo_files_deleted_total",
+		"Objects deleted from the repository", repoLabels, nil)
+	repoErrorsDesc = prometheus.NewDesc("rclone_restic_repo_errors_total",
+		"Errors transferring objects in the repository", repoLabels, nil)
+)
+
+// repoCollector is a Prometheus collector for the stats of some
+// repositories
+type repoCollector []RepoStats
+
+// Describe is part of the Collector interface
+func (c repoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- repoObjectsDesc
+	ch <- repoBytesDesc
+	ch <- repoBytesTransferredDesc
+	ch <- repoTransfersDesc
+	ch <- repoDeletesDesc
+	ch <- repoErrorsDesc
+}
+
+// Collect is part of the Collector interface
+func (c repoCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, rs := range c {
+		ch <- prometheus.MustNewConstMetric(repoObjectsDesc, prometheus.GaugeValue, float64(rs.Objects), rs.Path, rs.User)
+		ch <- prometheus.MustNewConstMetric(repoBytesDesc, prometheus.GaugeValue, float64(rs.Bytes), rs.Path, rs.User)
+		ch <- prometheus.MustNewConstMetric(repoBytesTransferredDesc, prometheus.CounterValue, float64(rs.BytesTransferred), rs.Path, rs.User)
+		ch <- prometheus.MustNewConstMetric(repoTransfersDesc, prometheus.CounterValue, float64(rs.Transfers), rs.Path, rs.User)
+		ch <- prometheus.MustNewConstMetric(repoDeletesDesc, prometheus.CounterValue, float64(rs.Deletes), rs.Path, rs.User)
+		ch <- prometheus.MustNewConstMetric(repoErrorsDesc, prometheus.CounterValue, float64(rs.Errors), rs.Path, rs.User)
+	}
+}
+
+// statsUser returns the user the stats for the repositories on the
+// backend for user are kept under. This is only set with the auth
+// proxy as otherwise all the users share the same backend.
+func (s *Server) statsUser(user string) string {
+	if s.proxy != nil {
+		return user
+	}
+	return ""
+}
+
+// statsVisible returns a function which returns true for the
+// repositories the user may see the stats of
+func (s *Server) statsVisible(user string, p policy) func(rs *repoStats) bool {
+	return func(rs *repoStats) bool {
+		switch {
+		case p.allStats:
+			return true
+		case s.proxy != nil:
+			return rs.user == user
+		case privateRepos:
+			return user != "" && strings.HasPrefix(rs.path, "/"+user+"/")
+		}
+		return true
+	}
+}
+
+// serveStats serves the stats for the repositories the user can see
+// as JSON on /stats or for Prometheus on /metrics. f is the backend
+// for the request.
+func (s *Server) serveStats(w http.ResponseWriter, r *http.Request, f fs.Fs, user string, p policy) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	repos := s.stats.report(s.statsUser(user), f, s.statsVisible(user, p))
+	if path.Base(r.URL.Path) == "metrics" {
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(repoCollector(repos))
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(repos)
+	if err != nil {
+		fs.Errorf(nil, "stats: failed to write stats: %v", err)
+	}
+}
@@ -10,10 +10,9 @@ import (
 	"regexp"
 	"strings"
 
-	"github.com/pkg/errors"
 	"github.com/pkg/sftp"
+	"github.com/rclone/rclone/cmd/serve/proxy"
 	"github.com/rclone/rclone/fs"
-	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/vfs"
 	"golang.org/x/crypto/ssh"
 )
@@ -47,119 +46,11 @@ func shellUnEscape(str string) string {
 // Info about the current connection
 type conn struct {
 	vfs      *vfs.VFS
+	limits   *proxy.Limits // per-user limits from the proxy or nil
 	handlers sftp.Handlers
 	what     string
 }
 
-// execCommand implements an extremely limited number of commands to
-// interoperate with the rclone sftp backend
-func (c *conn) execCommand(ctx context.Context, out io.Writer, command string) (err error) {
-	binary, args := command, ""
-	space := strings.Index(command, " ")
-	if space >= 0 {
-		binary = command[:space]
-		args = strings.TrimLeft(command[space+1:], " ")
-	}
-	args = shellUnEscape(args)
-	fs.Debugf(c.what, "exec command: binary = %q, args = %q", binary, args)
-	switch binary {
-	case "df":
-		about := c.vfs.Fs().Features().About
-		if about == nil {
-			return errors.New("df not supported")
-		}
-		usage, err := about(ctx)
-		if err != nil {
-			return errors.Wrap(err, "About failed")
-		}
-		total, used, free := int64(-1), int64(-1), int64(-1)
-		if usage.Total != nil {
-			total = *usage.Total / 1024
-		}
-		if usage.Used != nil {
-			used = *usage.Used / 1024
-		}
-		if usage.Free != nil {
-			free = *usage.Free / 1024
-		}
-		perc := int64(0)
-		if total > 0 && used >= 0 {
-			perc = (100 * used) / total
-		}
-		_, err = fmt.Fprintf(out, `		Filesystem                   1K-blocks      Used Available Use%% Mounted on
-/dev/root %d %d  %d  %d%% /
-`, total, used, free, perc)
-		if err != nil {
-			return errors.Wrap(err, "send output failed")
-		}
-	case "md5sum", "sha1sum":
-		ht := hash.MD5
-		if binary == "sha1sum" {
-			ht = hash.SHA1
-		}
-		var hashSum string
-		if args == "" {
-			// empty hash for no input
-			if ht == hash.MD5 {
-				hashSum = "d41d8cd98f00b204e9800998ecf8427e"
-			} else {
-				hashSum = "da39a3ee5e6b4b0d3255bfef95601890afd80709"
-			}
-			args = "-"
-		} else {
-			node, err := c.vfs.Stat(args)
-			if err != nil {
-				return errors.Wrapf(err, "hash failed finding file %q", args)
-			}
-			if node.IsDir() {
-				return errors.New("can't hash directory")
-			}
-			o, ok := node.DirEntry().(fs.ObjectInfo)
-			if !ok {
-				return errors.New("unexpected non file")
-			}
-			hashSum, err = o.Hash(ctx, ht)
-			if err != nil {
-				return errors.Wrap(err, "hash failed")
-			}
-		}
-		_, err = fmt.Fprintf(out, "%s  %s\n", hashSum, args)
-		if err != nil {
-			return errors.Wrap(err, "send output failed")
-		}
-	case "echo":
-		// special cases for rclone command detection
-		switch args {
-		case "'abc' | md5sum":
-			if c.vfs.Fs().Hashes().Contains(hash.MD5) {
-				_, err = fmt.Fprintf(out, "0bee89b07a248e27c83fc3d5951213c1  -\n")
-				if err != nil {
-					return errors.Wrap(err, "send output failed")
-				}
-			} else {
-				return errors.New("md5 hash not supported")
-			}
-		case "'abc' | sha1sum":
-			if c.vfs.Fs().Hashes().Contains(hash.SHA1) {
-				_, err = fmt.Fprintf(out, "03cfd743661f07975fa2f1220c5194cbaff48451  -\n")
-				if err != nil {
-					return errors.Wrap(err, "send output failed")
-				}
-			} else {
-				return errors.New("sha1 hash not supported")
-			}
-		default:
-			_, err = fmt.Fprintf(out, "%s\n", args)
-			if err != nil {
-				return errors.Wrap(err, "send output failed")
-			}
-		}
-	default:
-		return errors.Errorf("%q not implemented\n", command)
-	}
-	return nil
-}
-
 // handle a new incoming channel request
 func (c *conn) handleChannel(newChannel ssh.NewChannel) {
 	fs.Debugf(c.what, "Incoming channel: %s\n", newChannel.ChannelType())
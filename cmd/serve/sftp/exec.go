@@ -0,0 +1,792 @@
+// +build !plan9
+
+package sftp
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	gohash "hash"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/vfs"
+)
+
+// execFn runs an exec command writing its output to out
+//
+// args is the rest of the command line after the name of the command
+// as sent by the client, still shell escaped.
+type execFn func(ctx context.Context, c *conn, out io.Writer, args string) error
+
+// execCommands is the table of exec commands which aren't hash
+// commands - see findExecCommand
+var execCommands = map[string]execFn{
+	"df":      execDf,
+	"echo":    execEcho,
+	"find":    execFind,
+	"hashsum": execHashsum,
+	"ls":      execLs,
+	"stat":    execStat,
+}
+
+// extraHashes are hash commands for hashes rclone doesn't know about
+// which are calculated by reading the file
+var extraHashes = map[string]func() gohash.Hash{
+	"sha256sum": sha256.New,
+	"sha512sum": sha512.New,
+}
+
+// hashCommandName returns the name of the command which makes a
+// checksum of type ht, e.g. "md5sum" for MD5 or "crc32sum" for CRC-32
+func hashCommandName(ht hash.Type) string {
+	return strings.ToLower(strings.Replace(ht.String(), "-", "", -1)) + "sum"
+}
+
+// findHashCommand returns the hasher for the command called binary
+// or nil if it isn't a hash command
+func findHashCommand(binary string) *hasher {
+	if newHash, ok := extraHashes[binary]; ok {
+		return &hasher{newHash: newHash}
+	}
+	for _, ht := range hash.Supported().Array() {
+		if hashCommandName(ht) == binary {
+			return &hasher{ht: ht}
+		}
+	}
+	return nil
+}
+
+// findExecCommand returns the function to run the command called
+// binary or nil if it isn't supported
+func findExecCommand(binary string) execFn {
+	if fn, ok := execCommands[binary]; ok {
+		return fn
+	}
+	if h := findHashCommand(binary); h != nil {
+		return func(ctx context.Context, c *conn, out io.Writer, args string) error {
+			return execHash(ctx, c, out, h, args)
+		}
+	}
+	return nil
+}
+
+// execCommand runs the shell command sent by the client
+//
+// This implements a small number of commands from the table above
+// in a restricted way - enough to interoperate with the rclone sftp
+// backend and tools which probe for checksums and file info.
+func (c *conn) execCommand(ctx context.Context, out io.Writer, command string) (err error) {
+	binary, args := command, ""
+	space := strings.Index(command, " ")
+	if space >= 0 {
+		binary = command[:space]
+		args = strings.TrimLeft(command[space+1:], " ")
+	}
+	fs.Debugf(c.what, "exec command: binary = %q, args = %q", binary, args)
+	fn := findExecCommand(binary)
+	if fn == nil {
+		return errors.Errorf("%q not implemented\n", command)
+	}
+	return fn(ctx, c, out, args)
+}
+
+// shellSplit splits str into words as a POSIX shell would, removing
+// quotes and backslash escapes.
+//
+// Shell metacharacters other than quotes and escapes aren't supported
+// so an unescaped | ; & < > $ or ` is an error.
+func shellSplit(str string) (words []string, err error) {
+	var (
+		word    strings.Builder
+		inWord  bool
+		escaped bool
+		quote   rune
+	)
+	for _, r := range str {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case strings.ContainsRune("|;&<>$`", r):
+			return nil, errors.Errorf("unsupported shell character %q", r)
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if escaped || quote != 0 {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// stat finds the node at p which may be relative to the root or
+// absolute
+func (c *conn) stat(p string) (vfs.Node, error) {
+	return c.vfs.Stat(path.Clean("/" + p))
+}
+
+// childPath returns the path of the child called name of the
+// directory at p as find prints it
+func childPath(p, name string) string {
+	if strings.HasSuffix(p, "/") {
+		return p + name
+	}
+	return p + "/" + name
+}
+
+// write writes s to out wrapping any error
+func write(out io.Writer, s string) error {
+	_, err := io.WriteString(out, s)
+	if err != nil {
+		return errors.Wrap(err, "send output failed")
+	}
+	return nil
+}
+
+// execDf shows the disk usage from About
+func execDf(ctx context.Context, c *conn, out io.Writer, args string) error {
+	about := c.vfs.Fs().Features().About
+	if about == nil {
+		return errors.New("df not supported")
+	}
+	usage, err := about(ctx)
+	if err != nil {
+		return errors.Wrap(err, "About failed")
+	}
+	total, used, free := int64(-1), int64(-1), int64(-1)
+	if usage.Total != nil {
+		total = *usage.Total / 1024
+	}
+	if usage.Used != nil {
+		used = *usage.Used / 1024
+	}
+	if usage.Free != nil {
+		free = *usage.Free / 1024
+	}
+	perc := int64(0)
+	if total > 0 && used >= 0 {
+		perc = (100 * used) / total
+	}
+	_, err = fmt.Fprintf(out, `		Filesystem                   1K-blocks      Used Available Use%% Mounted on
+/dev/root %d %d  %d  %d%% /
+`, total, used, free, perc)
+	if err != nil {
+		return errors.Wrap(err, "send output failed")
+	}
+	return nil
+}
+
+// execEcho echoes its arguments
+//
+// "echo 'abc' | md5sum" and friends are special cased as the rclone
+// sftp backend uses them to detect which hashes are available.
+func execEcho(ctx context.Context, c *conn, out io.Writer, args string) error {
+	args = shellUnEscape(args)
+	const probe = "'abc' | "
+	if strings.HasPrefix(args, probe) {
+		binary := args[len(probe):]
+		h := findHashCommand(binary)
+		if h == nil || h.ht == hash.None || !c.vfs.Fs().Hashes().Contains(h.ht) {
+			return errors.Errorf("%s not supported", binary)
+		}
+		sum, err := h.sumReader(strings.NewReader("abc\n"))
+		if err != nil {
+			return err
+		}
+		return write(out, sum+"  -\n")
+	}
+	return write(out, args+"\n")
+}
+
+// hasher makes checksums of one type
+type hasher struct {
+	ht      hash.Type          // type of hash if rclone knows about it
+	newHash func() gohash.Hash // otherwise makes a new hash
+}
+
+// sumReader returns the checksum of the data read from in
+func (h *hasher) sumReader(in io.Reader) (string, error) {
+	if h.newHash != nil {
+		hasher := h.newHash()
+		_, err := io.Copy(hasher, in)
+		if err != nil {
+			return "", errors.Wrap(err, "hash failed")
+		}
+		return hex.EncodeToString(hasher.Sum(nil)), nil
+	}
+	sums, err := hash.StreamTypes(in, hash.NewHashSet(h.ht))
+	if err != nil {
+		return "", errors.Wrap(err, "hash failed")
+	}
+	return sums[h.ht], nil
+}
+
+// sumFile returns the checksum of the file at remote
+//
+// This uses the checksum stored by the backend if it has one,
+// otherwise the file is read through the VFS to calculate it.
+func (h *hasher) sumFile(ctx context.Context, c *conn, remote string) (sum string, err error) {
+	node, err := c.stat(remote)
+	if err != nil {
+		return "", errors.Wrapf(err, "hash failed finding file %q", remote)
+	}
+	if node.IsDir() {
+		return "", errors.New("can't hash directory")
+	}
+	if h.newHash == nil && c.vfs.Fs().Hashes().Contains(h.ht) {
+		o, ok := node.DirEntry().(fs.ObjectInfo)
+		if !ok {
+			return "", errors.New("unexpected non file")
+		}
+		sum, err = o.Hash(ctx, h.ht)
+		if err != nil {
+			return "", errors.Wrap(err, "hash failed")
+		}
+		if sum != "" {
+			return sum, nil
+		}
+	}
+	file, ok := node.(*vfs.File)
+	if !ok {
+		return "", errors.New("unexpected non file")
+	}
+	in, err := file.Open(os.O_RDONLY)
+	if err != nil {
+		return "", errors.Wrapf(err, "hash failed opening file %q", remote)
+	}
+	defer fs.CheckClose(in, &err)
+	return h.sumReader(in)
+}
+
+// hashFiles writes the checksums of the files in args to out in the
+// format of md5sum
+func hashFiles(ctx context.Context, c *conn, out io.Writer, h *hasher, args []string) error {
+	if len(args) == 0 {
+		// empty hash for no input
+		args = []string{"-"}
+	}
+	for _, remote := range args {
+		var (
+			sum string
+			err error
+		)
+		if remote == "-" {
+			sum, err = h.sumReader(strings.NewReader(""))
+		} else {
+			sum, err = h.sumFile(ctx, c, remote)
+		}
+		if err != nil {
+			return err
+		}
+		err = write(out, sum+"  "+remote+"\n")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execHash runs a hash command like md5sum
+func execHash(ctx context.Context, c *conn, out io.Writer, h *hasher, args string) error {
+	files, err := shellSplit(args)
+	if err != nil {
+		return err
+	}
+	return hashFiles(ctx, c, out, h, files)
+}
+
+// execHashsum runs "hashsum type file..." which can use any hash
+// type rclone knows about
+func execHashsum(ctx context.Context, c *conn, out io.Writer, args string) error {
+	words, err := shellSplit(args)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		return errors.Errorf("usage: hashsum TYPE [FILE]... where TYPE is one of %v", hash.Supported())
+	}
+	var ht hash.Type
+	err = ht.Set(words[0])
+	if err != nil {
+		return err
+	}
+	return hashFiles(ctx, c, out, &hasher{ht: ht}, words[1:])
+}
+
+// modeString returns mode in the format used by ls -l
+func modeString(mode os.FileMode) string {
+	s := []byte("-rwxrwxrwx")
+	if mode.IsDir() {
+		s[0] = 'd'
+	}
+	for i := 0; i < 9; i++ {
+		if mode&(1<<uint(8-i)) == 0 {
+			s[i+1] = '-'
+		}
+	}
+	return string(s)
+}
+
+// fileType returns the type of node as described by stat
+func fileType(node vfs.Node) string {
+	if node.IsDir() {
+		return "directory"
+	}
+	if node.Size() == 0 {
+		return "regular empty file"
+	}
+	return "regular file"
+}
+
+// expandEscapes expands the backslash escapes used in stat --printf
+// and find -printf formats
+func expandEscapes(format string) string {
+	return strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\0`, "\x00", `\\`, `\`).Replace(format)
+}
+
+// expandFormat expands the % directives in format by calling
+// directive with the letter after each %, or the letter and the
+// following character for the letters in twoChar. It returns an
+// error if directive doesn't know about it.
+func expandFormat(format string, twoChar string, directive func(spec string) (string, bool)) (string, error) {
+	var out strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			out.WriteByte(format[i])
+			continue
+		}
+		i++
+		if i >= len(format) {
+			return "", errors.New("format ends with %")
+		}
+		if format[i] == '%' {
+			out.WriteByte('%')
+			continue
+		}
+		spec := format[i : i+1]
+		if strings.Contains(twoChar, spec) && i+1 < len(format) {
+			i++
+			spec = format[i-1 : i+1]
+		}
+		s, ok := directive(spec)
+		if !ok {
+			return "", errors.Errorf("unsupported format directive %%%s", spec)
+		}
+		out.WriteString(s)
+	}
+	return out.String(), nil
+}
+
+// statTime formats t as stat does for %y
+func statTime(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05.000000000 -0700")
+}
+
+// statDirective expands the stat format directive c for node named
+// name
+func statDirective(name string, node vfs.Node, spec string) (string, bool) {
+	switch spec {
+	case "n":
+		return name, true
+	case "N":
+		return "'" + name + "'", true
+	case "s":
+		return strconv.FormatInt(node.Size(), 10), true
+	case "F":
+		return fileType(node), true
+	case "a":
+		return strconv.FormatUint(uint64(node.Mode().Perm()), 8), true
+	case "A":
+		return modeString(node.Mode()), true
+	case "h":
+		return "1", true
+	case "u", "g":
+		return "0", true
+	case "U", "G":
+		return "rclone", true
+	case "x", "y", "z", "w":
+		return statTime(node.ModTime()), true
+	case "X", "Y", "Z", "W":
+		return strconv.FormatInt(node.ModTime().Unix(), 10), true
+	}
+	return "", false
+}
+
+// statDefault is the output of stat without a format
+const statDefault = `  File: %n
+  Size: %s	Type: %F
+Access: (0%a/%A)  Uid: (%u/%U)   Gid: (%g/%G)
+Modify: %y
+`
+
+// execStat runs a restricted stat
+//
+// Usage: stat [-L] [-c FORMAT | --format=FORMAT | --printf=FORMAT] FILE...
+func execStat(ctx context.Context, c *conn, out io.Writer, args string) error {
+	words, err := shellSplit(args)
+	if err != nil {
+		return err
+	}
+	format := statDefault
+	var files []string
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		switch {
+		case word == "-L" || word == "--dereference":
+			// no symlinks so nothing to do
+		case word == "-c":
+			i++
+			if i >= len(words) {
+				return errors.New("stat: -c needs a FORMAT")
+			}
+			format = words[i] + "\n"
+		case strings.HasPrefix(word, "--format="):
+			format = strings.TrimPrefix(word, "--format=") + "\n"
+		case strings.HasPrefix(word, "--printf="):
+			format = expandEscapes(strings.TrimPrefix(word, "--printf="))
+		case strings.HasPrefix(word, "-") && word != "-":
+			return errors.Errorf("stat: unsupported option %q", word)
+		default:
+			files = append(files, word)
+		}
+	}
+	if len(files) == 0 {
+		return errors.New("stat: missing operand")
+	}
+	for _, name := range files {
+		node, err := c.stat(name)
+		if err != nil {
+			return errors.Wrapf(err, "stat: cannot stat %q", name)
+		}
+		s, err := expandFormat(format, "", func(spec string) (string, bool) {
+			return statDirective(name, node, spec)
+		})
+		if err != nil {
+			return errors.Wrap(err, "stat")
+		}
+		err = write(out, s)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findOpt are the options for execFind
+type findOpt struct {
+	fileType byte   // 'f', 'd' or 0 for any
+	name     string // pattern the leaf name must match if set
+	iname    bool   // if set match name case insensitively
+	minDepth int
+	maxDepth int    // -1 for unlimited
+	printf   string // format to print in or "" for the path
+}
+
+// match returns true if node at depth should be printed
+func (opt *findOpt) match(node vfs.Node, depth int) (bool, error) {
+	if depth < opt.minDepth {
+		return false, nil
+	}
+	switch opt.fileType {
+	case 'f':
+		if node.IsDir() {
+			return false, nil
+		}
+	case 'd':
+		if !node.IsDir() {
+			return false, nil
+		}
+	}
+	if opt.name != "" {
+		name, pattern := node.Name(), opt.name
+		if opt.iname {
+			name, pattern = strings.ToLower(name), strings.ToLower(pattern)
+		}
+		matched, err := path.Match(pattern, name)
+		if err != nil {
+			return false, errors.Wrap(err, "find: bad pattern")
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// findDirective expands the find -printf directive spec for node
+// found at p below the starting point start
+func findDirective(start, p string, node vfs.Node, spec string) (string, bool) {
+	switch spec {
+	case "p":
+		return p, true
+	case "P":
+		return strings.TrimPrefix(strings.TrimPrefix(p, start), "/"), true
+	case "f":
+		return path.Base(p), true
+	case "h":
+		return path.Dir(p), true
+	case "s":
+		return strconv.FormatInt(node.Size(), 10), true
+	case "y":
+		if node.IsDir() {
+			return "d", true
+		}
+		return "f", true
+	case "m":
+		return strconv.FormatUint(uint64(node.Mode().Perm()), 8), true
+	case "M":
+		return modeString(node.Mode()), true
+	case "T@":
+		modTime := node.ModTime()
+		return fmt.Sprintf("%d.%09d", modTime.Unix(), modTime.Nanosecond()), true
+	}
+	return "", false
+}
+
+// execFind runs a restricted find
+//
+// Usage: find [PATH...] [-type f|d] [-name PATTERN] [-iname PATTERN]
+// [-mindepth N] [-maxdepth N] [-print] [-printf FORMAT]
+func execFind(ctx context.Context, c *conn, out io.Writer, args string) error {
+	words, err := shellSplit(args)
+	if err != nil {
+		return err
+	}
+	opt := findOpt{maxDepth: -1}
+	var starts []string
+	for len(words) > 0 && !strings.HasPrefix(words[0], "-") {
+		starts = append(starts, words[0])
+		words = words[1:]
+	}
+	if len(starts) == 0 {
+		starts = []string{"."}
+	}
+	for i := 0; i < len(words); i++ {
+		option := words[i]
+		if option == "-print" {
+			continue
+		}
+		i++
+		if i >= len(words) {
+			return errors.Errorf("find: missing argument to %q", option)
+		}
+		value := words[i]
+		switch option {
+		case "-type":
+			if value != "f" && value != "d" {
+				return errors.Errorf("find: unsupported -type %q", value)
+			}
+			opt.fileType = value[0]
+		case "-name", "-iname":
+			opt.name = value
+			opt.iname = option == "-iname"
+		case "-mindepth", "-maxdepth":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return errors.Errorf("find: bad argument %q to %s", value, option)
+			}
+			if option == "-mindepth" {
+				opt.minDepth = n
+			} else {
+				opt.maxDepth = n
+			}
+		case "-printf":
+			opt.printf = expandEscapes(value)
+		default:
+			return errors.Errorf("find: unsupported option %q", option)
+		}
+	}
+	for _, start := range starts {
+		node, err := c.stat(start)
+		if err != nil {
+			return errors.Wrapf(err, "find: %q", start)
+		}
+		err = find(c, out, &opt, start, start, node, 0)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// find prints node at p if it matches opt then recurses into it if
+// it is a directory
+func find(c *conn, out io.Writer, opt *findOpt, start, p string, node vfs.Node, depth int) error {
+	matched, err := opt.match(node, depth)
+	if err != nil {
+		return err
+	}
+	if matched {
+		line := p + "\n"
+		if opt.printf != "" {
+			line, err = expandFormat(opt.printf, "T", func(spec string) (string, bool) {
+				return findDirective(start, p, node, spec)
+			})
+			if err != nil {
+				return errors.Wrap(err, "find")
+			}
+		}
+		err = write(out, line)
+		if err != nil {
+			return err
+		}
+	}
+	dir, ok := node.(*vfs.Dir)
+	if !ok || (opt.maxDepth >= 0 && depth >= opt.maxDepth) {
+		return nil
+	}
+	nodes, err := dir.ReadDirAll()
+	if err != nil {
+		return errors.Wrapf(err, "find: failed to list %q", p)
+	}
+	for _, child := range nodes {
+		err = find(c, out, opt, start, childPath(p, child.Name()), child, depth+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lsTime formats t for ls -l, showing the year instead of the time
+// if it is more than six months from now
+func lsTime(t time.Time) string {
+	if d := time.Since(t); d > 183*24*time.Hour || d < -183*24*time.Hour {
+		return t.Format("Jan _2  2006")
+	}
+	return t.Format("Jan _2 15:04")
+}
+
+// lsLine returns the line ls shows for node called name
+func lsLine(long bool, name string, node vfs.Node) string {
+	if !long {
+		return name + "\n"
+	}
+	return modeString(node.Mode()) + " 1 rclone rclone " + strconv.FormatInt(node.Size(), 10) + " " + lsTime(node.ModTime()) + " " + name + "\n"
+}
+
+// execLs runs a restricted ls
+//
+// Usage: ls [-1aAdl] [PATH...]
+func execLs(ctx context.Context, c *conn, out io.Writer, args string) error {
+	words, err := shellSplit(args)
+	if err != nil {
+		return err
+	}
+	var (
+		long, all, almostAll, dirsAsFiles bool
+		paths                             []string
+	)
+	for _, word := range words {
+		if !strings.HasPrefix(word, "-") || word == "-" {
+			paths = append(paths, word)
+			continue
+		}
+		for _, flag := range word[1:] {
+			switch flag {
+			case '1':
+			case 'l':
+				long = true
+			case 'a':
+				all = true
+			case 'A':
+				almostAll = true
+			case 'd':
+				dirsAsFiles = true
+			default:
+				return errors.Errorf("ls: unsupported option %q", flag)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	for i, p := range paths {
+		node, err := c.stat(p)
+		if err != nil {
+			return errors.Wrapf(err, "ls: cannot access %q", p)
+		}
+		dir, isDir := node.(*vfs.Dir)
+		if !isDir || dirsAsFiles {
+			err = write(out, lsLine(long, p, node))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if len(paths) > 1 {
+			heading := p + ":\n"
+			if i > 0 {
+				heading = "\n" + heading
+			}
+			err = write(out, heading)
+			if err != nil {
+				return err
+			}
+		}
+		nodes, err := dir.ReadDirAll()
+		if err != nil {
+			return errors.Wrapf(err, "ls: failed to list %q", p)
+		}
+		var lines []string
+		if all {
+			lines = append(lines, lsLine(long, ".", dir), lsLine(long, "..", dir))
+		}
+		sort.Slice(nodes, func(i, j int) bool {
+			return nodes[i].Name() < nodes[j].Name()
+		})
+		for _, child := range nodes {
+			if strings.HasPrefix(child.Name(), ".") && !all && !almostAll {
+				continue
+			}
+			lines = append(lines, lsLine(long, child.Name(), child))
+		}
+		err = write(out, strings.Join(lines, ""))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// +build !plan9
+
+package sftp
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/memory"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellSplit(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want []string
+		err  bool
+	}{
+		{"", nil, false},
+		{"  a  b ", []string{"a", "b"}, false},
+		{`a\ b c`, []string{"a b", "c"}, false},
+		{`'a b' "c \"d\"" ''`, []string{"a b", `c "d"`, ""}, false},
+		{"/test/'\n'", []string{"/test/\n"}, false},
+		{`\$\(rm\ -rf\ /\)`, []string{"$(rm -rf /)"}, false},
+		{"a | b", nil, true},
+		{"$(rm -rf /)", nil, true},
+		{"'a", nil, true},
+		{`a\`, nil, true},
+	} {
+		got, err := shellSplit(test.in)
+		if test.err {
+			assert.Error(t, err, test.in)
+		} else {
+			require.NoError(t, err, test.in)
+			assert.Equal(t, test.want, got, test.in)
+		}
+	}
+}
+
+func TestExecCommand(t *testing.T) {
+	ctx := context.Background()
+	f, err := fs.NewFs(ctx, ":memory:sftp-exec-test")
+	require.NoError(t, err)
+	modTime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	for remote, contents := range map[string]string{
+		"hello.txt":        "hello\n",
+		"dir/a file.txt":   "abc\n",
+		"dir/sub/b.dat":    "",
+		"dir/.hidden":      "secret",
+		"dir/sub/deep.txt": "deep",
+	} {
+		_, err := operations.Rcat(ctx, f, remote, ioutil.NopCloser(strings.NewReader(contents)), modTime)
+		require.NoError(t, err)
+	}
+	c := &conn{
+		vfs:  vfs.New(f, nil),
+		what: "test",
+	}
+	defer c.vfs.Shutdown()
+
+	run := func(command string) (string, error) {
+		var out bytes.Buffer
+		err := c.execCommand(ctx, &out, command)
+		return out.String(), err
+	}
+	check := func(command, want string) {
+		got, err := run(command)
+		require.NoError(t, err, command)
+		assert.Equal(t, want, got, command)
+	}
+
+	// hashes
+	check("md5sum /hello.txt", "b1946ac92492d2347c6235b4d2611184  /hello.txt\n")
+	check(`md5sum dir/a\ file.txt hello.txt`, "0bee89b07a248e27c83fc3d5951213c1  dir/a file.txt\nb1946ac92492d2347c6235b4d2611184  hello.txt\n")
+	check("md5sum", "d41d8cd98f00b204e9800998ecf8427e  -\n")
+	check("sha1sum", "da39a3ee5e6b4b0d3255bfef95601890afd80709  -\n")
+	check("sha1sum hello.txt", "f572d396fae9206628714fb2ce00f72e94f2258f  hello.txt\n")
+	check("sha256sum hello.txt", "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  hello.txt\n")
+	check("crc32sum hello.txt", "363a3020  hello.txt\n")
+	check("hashsum SHA-1 hello.txt", "f572d396fae9206628714fb2ce00f72e94f2258f  hello.txt\n")
+	_, err = run("hashsum potato hello.txt")
+	assert.Error(t, err)
+	_, err = run("md5sum dir")
+	assert.Error(t, err)
+	_, err = run("md5sum missing")
+	assert.Error(t, err)
+
+	// echo and the hash probes
+	check("echo hello", "hello\n")
+	check("echo 'abc' | md5sum", "0bee89b07a248e27c83fc3d5951213c1  -\n")
+	_, err = run("echo 'abc' | sha1sum")
+	assert.Error(t, err, "memory backend doesn't support SHA-1")
+	_, err = run("echo 'abc' | sha256sum")
+	assert.Error(t, err)
+
+	// stat
+	check("stat -c '%n %s %F %Y' hello.txt", "hello.txt 6 regular file 981173106\n")
+	check("stat -c '%n %F %A' hello.txt dir", "hello.txt regular file -rw-rw-rw-\ndir directory drwxrwxrwx\n")
+	check(`stat --printf='%s\t%a\n' /dir/sub/b.dat`, "0\t666\n")
+	check("stat -c %F dir/sub/b.dat", "regular empty file\n")
+	got, err := run("stat hello.txt")
+	require.NoError(t, err)
+	assert.Contains(t, got, "  File: hello.txt\n  Size: 6\tType: regular file\n")
+	_, err = run("stat -c %Q hello.txt")
+	assert.Error(t, err)
+	_, err = run("stat missing")
+	assert.Error(t, err)
+
+	// find
+	check("find dir", "dir\ndir/.hidden\ndir/a file.txt\ndir/sub\ndir/sub/b.dat\ndir/sub/deep.txt\n")
+	check("find / -maxdepth 1 -type f", "/hello.txt\n")
+	check("find . -type d", ".\n./dir\n./dir/sub\n")
+	check("find dir -name '*.txt' -print", "dir/a file.txt\ndir/sub/deep.txt\n")
+	check("find dir -iname '*.DAT'", "dir/sub/b.dat\n")
+	check("find dir -mindepth 2", "dir/sub/b.dat\ndir/sub/deep.txt\n")
+	check(`find dir/sub -type f -printf '%s %P %y %T@\n'`, "0 b.dat f 981173106.000000000\n4 deep.txt f 981173106.000000000\n")
+	_, err = run("find dir -newer hello.txt")
+	assert.Error(t, err)
+	_, err = run("find dir -type l")
+	assert.Error(t, err)
+
+	// ls
+	check("ls", "dir\nhello.txt\n")
+	check("ls dir", "a file.txt\nsub\n")
+	check("ls -1A dir", ".hidden\na file.txt\nsub\n")
+	check("ls -a dir/sub", ".\n..\nb.dat\ndeep.txt\n")
+	check("ls hello.txt dir/sub", "hello.txt\n\ndir/sub:\nb.dat\ndeep.txt\n")
+	check("ls -l dir/sub/deep.txt", "-rw-rw-rw- 1 rclone rclone 4 Feb  3  2001 dir/sub/deep.txt\n")
+	got, err = run("ls -ld dir")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(got, "drwxrwxrwx 1 rclone rclone 0 "), got)
+	assert.True(t, strings.HasSuffix(got, " dir\n"), got)
+	_, err = run("ls -R")
+	assert.Error(t, err)
+
+	// unknown commands
+	_, err = run("rm -rf /")
+	assert.Error(t, err)
+}
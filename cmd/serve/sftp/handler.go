@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/pkg/sftp"
+	"github.com/rclone/rclone/cmd/serve/proxy"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/vfs"
 )
@@ -16,11 +17,12 @@ import (
 // vfsHandler converts the VFS to be served by SFTP
 type vfsHandler struct {
 	*vfs.VFS
+	limits *proxy.Limits // per-user limits from the proxy or nil
 }
 
 // vfsHandler returns a Handlers object with the test handlers.
-func newVFSHandler(vfs *vfs.VFS) sftp.Handlers {
-	v := vfsHandler{VFS: vfs}
+func newVFSHandler(vfs *vfs.VFS, limits *proxy.Limits) sftp.Handlers {
+	v := vfsHandler{VFS: vfs, limits: limits}
 	return sftp.Handlers{
 		FileGet:  v,
 		FilePut:  v,
@@ -38,13 +40,33 @@ func (v vfsHandler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
 }
 
 func (v vfsHandler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	freed := v.limits.Size(v.VFS, r.Filepath)
 	file, err := v.OpenFile(r.Filepath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0777)
 	if err != nil {
 		return nil, err
 	}
+	if v.limits != nil {
+		v.limits.Freed(freed) // the old contents were truncated
+		return limitedHandle{
+			Handle: file,
+			w:      v.limits.NewUpload(r.Filepath, 0).WriterAt(file),
+		}, nil
+	}
 	return file, nil
 }
 
+// limitedHandle is a file handle whose writes are checked against
+// the per-user limits
+type limitedHandle struct {
+	vfs.Handle
+	w io.WriterAt
+}
+
+// WriteAt writes p at off unless that would break the limits
+func (h limitedHandle) WriteAt(p []byte, off int64) (n int, err error) {
+	return h.w.WriteAt(p, off)
+}
+
 func (v vfsHandler) Filecmd(r *sftp.Request) error {
 	switch r.Method {
 	case "Setstat":
@@ -58,30 +80,33 @@ func (v vfsHandler) Filecmd(r *sftp.Request) error {
 		}
 		return nil
 	case "Rename":
+		var freed int64
+		if r.Filepath != r.Target {
+			freed = v.limits.Size(v.VFS, r.Target)
+		}
 		err := v.Rename(r.Filepath, r.Target)
 		if err != nil {
 			return err
 		}
+		v.limits.Freed(freed)
 	case "Rmdir", "Remove":
+		freed := v.limits.Size(v.VFS, r.Filepath)
 		err := v.Remove(r.Filepath)
 		if err != nil {
 			return err
 		}
+		v.limits.Freed(freed)
 	case "Mkdir":
 		err := v.Mkdir(r.Filepath, 0777)
 		if err != nil {
 			return err
 		}
 	case "Symlink":
-		// FIXME
-		// _, err := v.fetch(r.Filepath)
-		// if err != nil {
-		// 	return err
-		// }
-		// link := newMemFile(r.Target, false)
-		// link.symlink = r.Filepath
-		// v.files[r.Target] = link
-		return sftp.ErrSshFxOpUnsupported
+		// r.Filepath is the target and r.Target is the new link
+		err := v.VFS.Symlink(r.Filepath, r.Target)
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -130,14 +155,22 @@ func (v vfsHandler) Filelist(r *sftp.Request) (l sftp.ListerAt, err error) {
 		}
 		return listerat([]os.FileInfo{node}), nil
 	case "Readlink":
-		// FIXME
-		// if file.symlink != "" {
-		// 	file, err = v.fetch(file.symlink)
-		// 	if err != nil {
-		// 		return nil, err
-		// 	}
-		// }
-		// return listerat([]os.FileInfo{file}), nil
+		target, err := v.VFS.Readlink(r.Filepath)
+		if err != nil {
+			return nil, err
+		}
+		return listerat([]os.FileInfo{linkInfo(target)}), nil
 	}
 	return nil, sftp.ErrSshFxOpUnsupported
 }
+
+// linkInfo is returned for Readlink - the sftp library sends Name()
+// back as the destination of the link
+type linkInfo string
+
+func (l linkInfo) Name() string       { return string(l) }
+func (l linkInfo) Size() int64        { return int64(len(l)) }
+func (l linkInfo) Mode() os.FileMode  { return os.ModeSymlink | 0777 }
+func (l linkInfo) ModTime() time.Time { return time.Time{} }
+func (l linkInfo) IsDir() bool        { return false }
+func (l linkInfo) Sys() interface{}   { return nil }
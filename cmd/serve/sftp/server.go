@@ -19,6 +19,9 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/rclone/rclone/cmd/serve/auth"
+	"github.com/rclone/rclone/cmd/serve/auth/authflags"
+	"github.com/rclone/rclone/cmd/serve/httplib"
 	"github.com/rclone/rclone/cmd/serve/proxy"
 	"github.com/rclone/rclone/cmd/serve/proxy/proxyflags"
 	"github.com/rclone/rclone/fs"
@@ -31,17 +34,18 @@ import (
 
 // server contains everything to run the server
 type server struct {
-	f        fs.Fs
-	opt      Options
-	vfs      *vfs.VFS
-	ctx      context.Context // for global config
-	config   *ssh.ServerConfig
-	listener net.Listener
-	waitChan chan struct{} // for waiting on the listener to close
-	proxy    *proxy.Proxy
+	f         fs.Fs
+	opt       Options
+	vfs       *vfs.VFS
+	ctx       context.Context // for global config
+	config    *ssh.ServerConfig
+	listeners []net.Listener // one for each address in --addr
+	waitChan  chan struct{}  // for waiting on the listeners to close
+	proxy     *proxy.Proxy
+	auth      *auth.Auth // authentication backends if in use
 }
 
-func newServer(ctx context.Context, f fs.Fs, opt *Options) *server {
+func newServer(ctx context.Context, f fs.Fs, opt *Options) (*server, error) {
 	s := &server{
 		f:        f,
 		ctx:      ctx,
@@ -49,38 +53,66 @@ func newServer(ctx context.Context, f fs.Fs, opt *Options) *server {
 		waitChan: make(chan struct{}),
 	}
 	if proxyflags.Opt.AuthProxy != "" {
+		if authflags.Opt.Enabled() {
+			return nil, errors.New("can't use --auth-proxy with the authentication backends")
+		}
 		s.proxy = proxy.New(ctx, &proxyflags.Opt)
-	} else {
+		return s, nil
+	}
+	if authflags.Opt.Enabled() {
+		var err error
+		s.auth, err = auth.New(ctx, f, &authflags.Opt)
+		if err != nil {
+			return nil, err
+		}
+		// share the VFS with the authentication backends if possible
+		s.vfs = s.auth.VFS()
+	}
+	if s.vfs == nil {
 		s.vfs = vfs.New(f, &vfsflags.Opt)
 	}
-	return s
+	return s, nil
 }
 
-// getVFS gets the vfs from s or the proxy
-func (s *server) getVFS(what string, sshConn *ssh.ServerConn) (VFS *vfs.VFS) {
+// getVFS gets the vfs from s or the proxy along with any per-user
+// limits
+func (s *server) getVFS(what string, sshConn *ssh.ServerConn) (VFS *vfs.VFS, limits *proxy.Limits) {
 	if s.proxy == nil {
-		return s.vfs
+		if s.auth == nil || sshConn.Permissions == nil {
+			return s.vfs, nil
+		}
+		key := sshConn.Permissions.Extensions["_vfsKey"]
+		if key == "" {
+			return s.vfs, nil
+		}
+		VFS = s.auth.Get(key)
+		if VFS == nil {
+			fs.Infof(what, "failed to read VFS from cache")
+		}
+		return VFS, nil
 	}
 	if sshConn.Permissions == nil && sshConn.Permissions.Extensions == nil {
 		fs.Infof(what, "SSH Permissions Extensions not found")
-		return nil
+		return nil, nil
 	}
 	key := sshConn.Permissions.Extensions["_vfsKey"]
 	if key == "" {
 		fs.Infof(what, "VFS key not found")
-		return nil
+		return nil, nil
 	}
 	VFS = s.proxy.Get(key)
 	if VFS == nil {
 		fs.Infof(what, "failed to read VFS from cache")
-		return nil
+		return nil, nil
 	}
-	return VFS
+	return VFS, s.proxy.Limits(key)
 }
 
-func (s *server) acceptConnections() {
+// acceptConnections accepts the connections from ln until it is
+// closed
+func (s *server) acceptConnections(ln net.Listener) {
 	for {
-		nConn, err := s.listener.Accept()
+		nConn, err := ln.Accept()
 		if err != nil {
 			if strings.Contains(err.Error(), "use of closed network connection") {
 				return
@@ -104,14 +136,14 @@ func (s *server) acceptConnections() {
 
 		c := &conn{
 			what: what,
-			vfs:  s.getVFS(what, sshConn),
 		}
+		c.vfs, c.limits = s.getVFS(what, sshConn)
 		if c.vfs == nil {
 			fs.Infof(what, "Closing unauthenticated connection (couldn't find VFS)")
 			_ = nConn.Close()
 			continue
 		}
-		c.handlers = newVFSHandler(c.vfs)
+		c.handlers = newVFSHandler(c.vfs, c.limits)
 
 		// Accept all channels
 		go c.handleChannels(chans)
@@ -138,8 +170,8 @@ func (s *server) serve() (err error) {
 		fs.Logf(nil, "Loaded %d authorized keys from %q", len(authorizedKeysMap), authKeysFile)
 	}
 
-	if !s.opt.NoAuth && len(authorizedKeysMap) == 0 && s.opt.User == "" && s.opt.Pass == "" && s.proxy == nil {
-		return errors.New("no authorization found, use --user/--pass or --authorized-keys or --no-auth or --auth-proxy")
+	if !s.opt.NoAuth && len(authorizedKeysMap) == 0 && s.opt.User == "" && s.opt.Pass == "" && s.proxy == nil && s.auth == nil {
+		return errors.New("no authorization found, use --user/--pass or --authorized-keys or --no-auth or --auth-proxy or an authentication backend")
 	}
 
 	// An SSH server is represented by a ServerConfig, which holds
@@ -160,7 +192,20 @@ func (s *server) serve() (err error) {
 						"_vfsKey": vfsKey,
 					},
 				}, nil
-			} else if s.opt.User != "" && s.opt.Pass != "" {
+			}
+			if s.auth != nil {
+				_, vfsKey, err := s.auth.Call(c.User(), string(pass))
+				if err == nil {
+					// just return the Key so we can get it back from the cache
+					return &ssh.Permissions{
+						Extensions: map[string]string{
+							"_vfsKey": vfsKey,
+						},
+					}, nil
+				}
+				fs.Debugf(describeConn(c), "%v", err)
+			}
+			if s.opt.User != "" && s.opt.Pass != "" {
 				userOK := subtle.ConstantTimeCompare([]byte(c.User()), []byte(s.opt.User))
 				passOK := subtle.ConstantTimeCompare(pass, []byte(s.opt.Pass))
 				if (userOK & passOK) == 1 {
@@ -242,20 +287,31 @@ func (s *server) serve() (err error) {
 
 	// Once a ServerConfig has been configured, connections can be
 	// accepted.
-	s.listener, err = net.Listen("tcp", s.opt.ListenAddr)
-	if err != nil {
-		return errors.Wrap(err, "failed to listen for connection")
+	for _, addr := range httplib.SplitListenAddrs(s.opt.ListenAddr) {
+		ln, err := httplib.Listen(addr)
+		if err != nil {
+			for _, ln := range s.listeners {
+				_ = ln.Close()
+			}
+			s.listeners = nil
+			return errors.Wrap(err, "failed to listen for connection")
+		}
+		s.listeners = append(s.listeners, ln)
+	}
+	for _, ln := range s.listeners {
+		fs.Logf(nil, "SFTP server listening on %v\n", ln.Addr())
+		go s.acceptConnections(ln)
 	}
-	fs.Logf(nil, "SFTP server listening on %v\n", s.listener.Addr())
-
-	go s.acceptConnections()
 
 	return nil
 }
 
 // Addr returns the address the server is listening on
+//
+// If the server is listening on more than one address this is the
+// first of them.
 func (s *server) Addr() string {
-	return s.listener.Addr().String()
+	return s.listeners[0].Addr().String()
 }
 
 // Serve runs the sftp server in the background.
@@ -276,10 +332,11 @@ func (s *server) Wait() {
 
 // Close shuts the running server down
 func (s *server) Close() {
-	err := s.listener.Close()
-	if err != nil {
-		fs.Errorf(nil, "Error on closing SFTP server: %v", err)
-		return
+	for _, ln := range s.listeners {
+		err := ln.Close()
+		if err != nil {
+			fs.Errorf(nil, "Error on closing SFTP server: %v", err)
+		}
 	}
 	close(s.waitChan)
 }
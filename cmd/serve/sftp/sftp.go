@@ -8,6 +8,8 @@ import (
 	"context"
 
 	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/cmd/serve/auth"
+	"github.com/rclone/rclone/cmd/serve/auth/authflags"
 	"github.com/rclone/rclone/cmd/serve/proxy"
 	"github.com/rclone/rclone/cmd/serve/proxy/proxyflags"
 	"github.com/rclone/rclone/fs"
@@ -21,7 +23,7 @@ import (
 
 // Options contains options for the http Server
 type Options struct {
-	ListenAddr     string   // Port to listen on
+	ListenAddr     string   // Port to listen on - may be a comma separated list
 	HostKeys       []string // Paths to private host keys
 	AuthorizedKeys string   // Path to authorized keys file
 	User           string   // single username
@@ -41,7 +43,7 @@ var Opt = DefaultOpt
 // AddFlags adds flags for the sftp
 func AddFlags(flagSet *pflag.FlagSet, Opt *Options) {
 	rc.AddOption("sftp", &Opt)
-	flags.StringVarP(flagSet, &Opt.ListenAddr, "addr", "", Opt.ListenAddr, "IPaddress:Port or :Port to bind server to.")
+	flags.StringVarP(flagSet, &Opt.ListenAddr, "addr", "", Opt.ListenAddr, "IPaddress:Port or :Port to bind server to, or a comma separated list of them or unix:PATH sockets.")
 	flags.StringArrayVarP(flagSet, &Opt.HostKeys, "key", "", Opt.HostKeys, "SSH private host key file (Can be multi-valued, leave blank to auto generate)")
 	flags.StringVarP(flagSet, &Opt.AuthorizedKeys, "authorized-keys", "", Opt.AuthorizedKeys, "Authorized keys file")
 	flags.StringVarP(flagSet, &Opt.User, "user", "", Opt.User, "User name for authentication.")
@@ -52,6 +54,8 @@ func AddFlags(flagSet *pflag.FlagSet, Opt *Options) {
 func init() {
 	vfsflags.AddFlags(Command.Flags())
 	proxyflags.AddFlags(Command.Flags())
+	authflags.AddFlags(Command.Flags())
+	authflags.AddHtPasswdFlag(Command.Flags())
 	AddFlags(Command.Flags(), &Opt)
 }
 
@@ -73,24 +77,49 @@ control the stats printing.
 
 You must provide some means of authentication, either with --user/--pass,
 an authorized keys file (specify location with --authorized-keys - the
-default is the same as ssh), an --auth-proxy, or set the --no-auth flag for no
+default is the same as ssh), an --auth-proxy, the authentication
+backends described below, or set the --no-auth flag for no
 authentication when logging in.
 
+The bearer tokens and JWTs from the authentication backends are used
+as the password with the user name they authenticate.
+
 Note that this also implements a small number of shell commands so
 that it can provide md5sum/sha1sum/df information for the rclone sftp
 backend.  This means that is can support SHA1SUMs, MD5SUMs and the
 about command when paired with the rclone sftp backend.
 
+The shell commands are restricted versions of the Unix ones, enough
+for backup tools and verifiers which probe the server over ssh:
+
+- df - show the usage from the about command
+- md5sum, sha1sum, etc. - one for each hash rclone supports (e.g.
+  crc32sum, whirlpoolsum), using the hash from the backend if it has it
+- sha256sum, sha512sum - calculated by reading the file
+- hashsum TYPE FILE... - checksum with any hash rclone supports
+- stat [-c FORMAT | --printf=FORMAT] FILE... - the common % directives
+- find [PATH...] with -type f|d, -name, -iname, -mindepth, -maxdepth,
+  -print and -printf
+- ls [-1aAdl] [PATH...]
+- echo
+
+Arguments may be quoted and escaped as in a shell, but pipes,
+redirections and other shell syntax aren't supported.
+
 If you don't supply a --key then rclone will generate one and cache it
 for later use.
 
 By default the server binds to localhost:2022 - if you want it to be
 reachable externally then supply "--addr :2022" for example.
 
+--addr may be a comma separated list of addresses to listen on more
+than one, and an address of the form unix:/path/to/socket listens on
+a unix socket, e.g. --addr localhost:2022,unix:/run/rclone-sftp.sock
+
 Note that the default of "--vfs-cache-mode off" is fine for the rclone
 sftp backend, but it may not be with other SFTP clients.
 
-` + vfs.Help + proxy.Help,
+` + auth.Help + auth.RootHelp + vfs.Help + proxy.Help,
 	Run: func(command *cobra.Command, args []string) {
 		var f fs.Fs
 		if proxyflags.Opt.AuthProxy == "" {
@@ -100,8 +129,11 @@ sftp backend, but it may not be with other SFTP clients.
 			cmd.CheckArgs(0, 0, command, args)
 		}
 		cmd.Run(false, true, command, func() error {
-			s := newServer(context.Background(), f, &Opt)
-			err := s.Serve()
+			s, err := newServer(context.Background(), f, &Opt)
+			if err != nil {
+				return err
+			}
+			err = s.Serve()
 			if err != nil {
 				return err
 			}
@@ -9,6 +9,10 @@ package sftp
 
 import (
 	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -18,7 +22,10 @@ import (
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/config/configmap"
 	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/vfs/vfsflags"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
 )
 
 const (
@@ -45,7 +52,8 @@ func TestSftp(t *testing.T) {
 		opt.User = testUser
 		opt.Pass = testPass
 
-		w := newServer(context.Background(), f, &opt)
+		w, err := newServer(context.Background(), f, &opt)
+		require.NoError(t, err)
 		require.NoError(t, w.serve())
 
 		// Read the host and port we started on
@@ -70,3 +78,129 @@ func TestSftp(t *testing.T) {
 
 	servetest.Run(t, "sftp", start)
 }
+
+// newTestServer starts a server for f listening on addrs and returns
+// it along with a function to stop it
+func newTestServer(t *testing.T, f fs.Fs, addrs string) (*server, func()) {
+	opt := DefaultOpt
+	opt.ListenAddr = addrs
+	opt.User = testUser
+	opt.Pass = testPass
+	w, err := newServer(context.Background(), f, &opt)
+	require.NoError(t, err)
+	require.NoError(t, w.serve())
+	return w, func() {
+		w.Close()
+		w.Wait()
+	}
+}
+
+// dialTestServer connects an SFTP client to the server listening on
+// ln
+func dialTestServer(t *testing.T, ln net.Listener) *sftp.Client {
+	nConn, err := net.Dial(ln.Addr().Network(), ln.Addr().String())
+	require.NoError(t, err)
+	sshConn, chans, reqs, err := ssh.NewClientConn(nConn, ln.Addr().String(), &ssh.ClientConfig{
+		User:            testUser,
+		Auth:            []ssh.AuthMethod{ssh.Password(testPass)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	require.NoError(t, err)
+	client, err := sftp.NewClient(ssh.NewClient(sshConn, chans, reqs))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	return client
+}
+
+// TestSftpListenAddrs checks the server listens on all the addresses
+func TestSftpListenAddrs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rclone-sftp-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	root := filepath.Join(dir, "root")
+	require.NoError(t, os.Mkdir(root, 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "file.txt"), []byte("hello"), 0600))
+	f, err := fs.NewFs(context.Background(), root)
+	require.NoError(t, err)
+
+	sock := filepath.Join(dir, "sftp.sock")
+	w, stop := newTestServer(t, f, testBindAddress+", "+testBindAddress+",unix:"+sock)
+	defer stop()
+	require.Equal(t, 3, len(w.listeners))
+	assert.Equal(t, "unix", w.listeners[2].Addr().Network())
+
+	for _, ln := range w.listeners {
+		client := dialTestServer(t, ln)
+		fi, err := client.Stat("file.txt")
+		require.NoError(t, err, ln.Addr().String())
+		assert.Equal(t, int64(5), fi.Size())
+	}
+}
+
+// TestSftpSymlinks checks symlinks are stored as .rclonelink files
+// when --links is set
+func TestSftpSymlinks(t *testing.T) {
+	root, err := ioutil.TempDir("", "rclone-sftp-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(root))
+	}()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "file.txt"), []byte("hello"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "foo"), []byte("real"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "foo.rclonelink"), []byte("file.txt"), 0600))
+	f, err := fs.NewFs(context.Background(), root)
+	require.NoError(t, err)
+
+	// without --links symlinks aren't supported
+	w, stop := newTestServer(t, f, testBindAddress)
+	client := dialTestServer(t, w.listeners[0])
+	assert.Error(t, client.Symlink("file.txt", "nolink"))
+	_, err = client.ReadLink("foo.rclonelink")
+	assert.Error(t, err)
+	stop()
+
+	oldLinks := vfsflags.Opt.Links
+	vfsflags.Opt.Links = true
+	defer func() {
+		vfsflags.Opt.Links = oldLinks
+	}()
+	w, stop = newTestServer(t, f, testBindAddress)
+	defer stop()
+	client = dialTestServer(t, w.listeners[0])
+
+	require.NoError(t, client.Symlink("/file.txt", "link"))
+	target, err := client.ReadLink("link")
+	require.NoError(t, err)
+	assert.Equal(t, "/file.txt", target)
+	fi, err := client.Lstat("link")
+	require.NoError(t, err)
+	assert.True(t, fi.Mode()&os.ModeSymlink != 0)
+	data, err := ioutil.ReadFile(filepath.Join(root, "link.rclonelink"))
+	require.NoError(t, err)
+	assert.Equal(t, "/file.txt", string(data))
+
+	// a link can't be created over an existing file
+	assert.Error(t, client.Symlink("/file.txt", "file.txt"))
+	_, err = client.ReadLink("file.txt")
+	assert.Error(t, err)
+
+	// foo.rclonelink is shown as a regular file as foo exists
+	fis, err := client.ReadDir("/")
+	require.NoError(t, err)
+	var names []string
+	for _, fi := range fis {
+		names = append(names, fi.Name())
+		if fi.Name() == "foo.rclonelink" {
+			assert.True(t, fi.Mode().IsRegular())
+		}
+	}
+	assert.Equal(t, []string{"file.txt", "foo", "foo.rclonelink", "link"}, names)
+	_, err = client.ReadLink("foo.rclonelink")
+	assert.Error(t, err)
+	_, err = client.ReadLink("foo")
+	assert.Error(t, err)
+}
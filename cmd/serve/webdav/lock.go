@@ -0,0 +1,141 @@
+package webdav
+
+import (
+	"context"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs"
+	"github.com/rclone/rclone/vfs/vfslock"
+	"golang.org/x/net/webdav"
+)
+
+// lockSystem is a webdav.LockSystem which also takes the locks in
+// the VFS lock manager so WebDAV clients see the locks taken by
+// mounts and other rclone processes and vice versa.
+//
+// The WebDAV side of the locking (tokens, depth, confirmation) is
+// done by the in memory lock system. Each WebDAV lock takes a whole
+// file write lock on its root in the VFS owned by its token. Infinite
+// depth locks on directories only lock the directory itself in the
+// VFS.
+type lockSystem struct {
+	webdav.LockSystem
+	vfs    *vfs.VFS
+	mu     sync.Mutex
+	tokens map[string]lockedPath // path locked by each token
+}
+
+// lockedPath is the path locked by a token and when it expires
+type lockedPath struct {
+	path    string
+	expires time.Time // zero for never
+}
+
+// newLockSystem makes a new lock system using the locks in VFS
+func newLockSystem(VFS *vfs.VFS) *lockSystem {
+	return &lockSystem{
+		LockSystem: webdav.NewMemLS(),
+		vfs:        VFS,
+		tokens:     make(map[string]lockedPath),
+	}
+}
+
+// check interface
+var _ webdav.LockSystem = (*lockSystem)(nil)
+
+// lockOwner returns the owner of the VFS lock for token
+func lockOwner(token string) string {
+	return "webdav:" + token
+}
+
+// lockExpires returns the expiry time of a lock of duration taken at
+// now - zero for never
+func lockExpires(now time.Time, duration time.Duration) time.Time {
+	if duration < 0 {
+		return time.Time{}
+	}
+	return now.Add(duration)
+}
+
+// Create creates a new lock with the given depth, duration, owner and
+// root (name).
+func (ls *lockSystem) Create(now time.Time, details webdav.LockDetails) (token string, err error) {
+	token, err = ls.LockSystem.Create(now, details)
+	if err != nil {
+		return token, err
+	}
+	p := strings.Trim(path.Clean(details.Root), "/")
+	expires := lockExpires(now, details.Duration)
+	err = ls.vfs.Locks().Lock(context.TODO(), vfslock.Lock{
+		Path:    p,
+		Owner:   lockOwner(token),
+		Type:    vfslock.Write,
+		Start:   0,
+		End:     vfslock.EOF,
+		Expires: expires,
+	})
+	if err != nil {
+		_ = ls.LockSystem.Unlock(now, token)
+		if err == vfslock.ErrLocked {
+			return "", webdav.ErrLocked
+		}
+		fs.Errorf(p, "webdav: failed to lock: %v", err)
+		return "", err
+	}
+	ls.mu.Lock()
+	ls._purge(now)
+	ls.tokens[token] = lockedPath{path: p, expires: expires}
+	ls.mu.Unlock()
+	return token, nil
+}
+
+// _purge removes the expired tokens - call with the lock held
+func (ls *lockSystem) _purge(now time.Time) {
+	for token, locked := range ls.tokens {
+		if !locked.expires.IsZero() && now.After(locked.expires) {
+			delete(ls.tokens, token)
+		}
+	}
+}
+
+// Refresh refreshes the lock with the given token.
+func (ls *lockSystem) Refresh(now time.Time, token string, duration time.Duration) (webdav.LockDetails, error) {
+	details, err := ls.LockSystem.Refresh(now, token, duration)
+	if err != nil {
+		return details, err
+	}
+	ls.mu.Lock()
+	locked, ok := ls.tokens[token]
+	if ok {
+		locked.expires = lockExpires(now, duration)
+		ls.tokens[token] = locked
+	}
+	ls.mu.Unlock()
+	if ok {
+		err = ls.vfs.Locks().Refresh(context.TODO(), locked.path, lockOwner(token), locked.expires)
+		if err != nil {
+			fs.Errorf(locked.path, "webdav: failed to refresh lock: %v", err)
+		}
+	}
+	return details, nil
+}
+
+// Unlock unlocks the lock with the given token.
+func (ls *lockSystem) Unlock(now time.Time, token string) error {
+	err := ls.LockSystem.Unlock(now, token)
+	ls.mu.Lock()
+	locked, ok := ls.tokens[token]
+	delete(ls.tokens, token)
+	ls.mu.Unlock()
+	if ok {
+		unlockErr := ls.vfs.Locks().UnlockAll(context.TODO(), locked.path, lockOwner(token))
+		if unlockErr != nil {
+			fs.Errorf(locked.path, "webdav: failed to unlock: %v", unlockErr)
+		}
+	}
+	return err
+}
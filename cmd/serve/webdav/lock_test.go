@@ -0,0 +1,52 @@
+package webdav
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/memory"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs"
+	"github.com/rclone/rclone/vfs/vfslock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+)
+
+func TestLockSystem(t *testing.T) {
+	ctx := context.Background()
+	f, err := fs.NewFs(ctx, ":memory:webdav-lock-test")
+	require.NoError(t, err)
+	VFS := vfs.New(f, nil)
+	defer VFS.Shutdown()
+	ls := newLockSystem(VFS)
+	now := time.Now()
+
+	token, err := ls.Create(now, webdav.LockDetails{Root: "/dir/file.docx", Duration: time.Minute, ZeroDepth: true})
+	require.NoError(t, err)
+
+	// the lock is visible in the VFS
+	locks := VFS.Locks().Locks("dir/file.docx")
+	require.Equal(t, 1, len(locks))
+	assert.Equal(t, vfslock.Write, locks[0].Type)
+	assert.Equal(t, lockOwner(token), locks[0].Owner)
+	assert.Equal(t, vfslock.ErrLocked, VFS.Locks().Lock(ctx, vfslock.Lock{Path: "dir/file.docx", Owner: "mount", Type: vfslock.Read, End: vfslock.EOF}))
+
+	_, err = ls.Refresh(now, token, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, VFS.Locks().Locks("dir/file.docx")[0].Expires.After(now.Add(time.Minute)))
+
+	require.NoError(t, ls.Unlock(now, token))
+	assert.Equal(t, 0, len(VFS.Locks().Locks("dir/file.docx")))
+
+	// a lock held in the VFS stops WebDAV locking the file
+	require.NoError(t, VFS.Locks().Lock(ctx, vfslock.Lock{Path: "dir/file.docx", Owner: "mount", Type: vfslock.Read, End: vfslock.EOF}))
+	_, err = ls.Create(now, webdav.LockDetails{Root: "/dir/file.docx", Duration: time.Minute, ZeroDepth: true})
+	assert.Equal(t, webdav.ErrLocked, err)
+	assert.Equal(t, 0, len(ls.tokens))
+
+	// other files are fine
+	_, err = ls.Create(now, webdav.LockDetails{Root: "/dir/other.docx", Duration: -1, ZeroDepth: true})
+	require.NoError(t, err)
+}
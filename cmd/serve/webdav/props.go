@@ -0,0 +1,443 @@
+package webdav
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/cmd/serve/proxy"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs"
+	"golang.org/x/net/webdav"
+)
+
+// propsFileName is the name of the sidecar file the dead properties
+// of a directory and the files in it are stored in if --props-sidecar
+// is set
+const propsFileName = ".rclone-webdav-props.json"
+
+// isPropsFile returns true if name is a properties sidecar or is
+// inside one. These are hidden from WebDAV clients.
+func isPropsFile(name string) bool {
+	for _, elem := range strings.Split(name, "/") {
+		if elem == propsFileName {
+			return true
+		}
+	}
+	return false
+}
+
+// The RFC 4331 quota properties
+var (
+	quotaAvailableBytes = xml.Name{Space: "DAV:", Local: "quota-available-bytes"}
+	quotaUsedBytes      = xml.Name{Space: "DAV:", Local: "quota-used-bytes"}
+)
+
+// isQuotaProp returns true if name is one of the quota properties
+// which are computed and can't be set
+func isQuotaProp(name xml.Name) bool {
+	return name == quotaAvailableBytes || name == quotaUsedBytes
+}
+
+// props are the dead properties of a single file or directory
+type props map[xml.Name]webdav.Property
+
+// propStore stores the dead properties set with PROPPATCH.
+//
+// The properties are kept in memory and, if sidecar is set, also in
+// a hidden file in each directory so they survive a restart and move
+// along with the directory.
+//
+// The properties of the files in a directory are stored under their
+// leaf names and the properties of the directory itself under ".".
+type propStore struct {
+	sidecar bool
+	mu      sync.Mutex
+	dirs    map[string]map[string]props // dir -> leaf -> properties
+}
+
+// newPropStore makes a new empty propStore
+func newPropStore(sidecar bool) *propStore {
+	return &propStore{
+		sidecar: sidecar,
+		dirs:    make(map[string]map[string]props),
+	}
+}
+
+// propKey returns the directory and leaf the properties of the node
+// at p are stored under
+func propKey(p string, isDir bool) (dir, leaf string) {
+	p = strings.Trim(path.Clean("/"+p), "/")
+	if isDir {
+		return p, "."
+	}
+	dir = path.Dir(p)
+	if dir == "." {
+		dir = ""
+	}
+	return dir, path.Base(p)
+}
+
+// storedProp is how a property is stored in the sidecar file
+type storedProp struct {
+	Space string
+	Local string
+	Lang  string `json:",omitempty"`
+	Value string
+}
+
+// _load returns the properties for dir reading them from the sidecar
+// if necessary - call with mu held
+func (ps *propStore) _load(VFS *vfs.VFS, dir string) (map[string]props, error) {
+	entries, ok := ps.dirs[dir]
+	if ok {
+		return entries, nil
+	}
+	entries = make(map[string]props)
+	if ps.sidecar {
+		name := path.Join(dir, propsFileName)
+		data, err := VFS.ReadFile(name)
+		if err == nil {
+			var stored map[string][]storedProp
+			err = json.Unmarshal(data, &stored)
+			if err != nil {
+				fs.Errorf(name, "Ignoring corrupted WebDAV properties: %v", err)
+			}
+			for leaf, storedProps := range stored {
+				entry := make(props, len(storedProps))
+				for _, sp := range storedProps {
+					xmlName := xml.Name{Space: sp.Space, Local: sp.Local}
+					entry[xmlName] = webdav.Property{
+						XMLName:  xmlName,
+						Lang:     sp.Lang,
+						InnerXML: []byte(sp.Value),
+					}
+				}
+				entries[leaf] = entry
+			}
+		} else if err != vfs.ENOENT {
+			return nil, errors.Wrap(err, "failed to read WebDAV properties")
+		}
+	}
+	ps.dirs[dir] = entries
+	return entries, nil
+}
+
+// copyEntries returns a copy of the properties of a directory which
+// can be changed without affecting the cached ones
+//
+// The props for each leaf are shared as they are replaced rather
+// than modified.
+func copyEntries(entries map[string]props) map[string]props {
+	out := make(map[string]props, len(entries))
+	for leaf, entry := range entries {
+		out[leaf] = entry
+	}
+	return out
+}
+
+// _save writes entries as the properties for dir to the sidecar and
+// caches them if successful - call with mu held
+//
+// entries should be a copy of the cached properties made with
+// copyEntries so the cache isn't changed if the save fails.
+func (ps *propStore) _save(VFS *vfs.VFS, dir string, entries map[string]props) error {
+	err := ps._write(VFS, dir, entries)
+	if err != nil {
+		return err
+	}
+	ps.dirs[dir] = entries
+	return nil
+}
+
+// _write writes entries to the sidecar for dir - call with mu held
+func (ps *propStore) _write(VFS *vfs.VFS, dir string, entries map[string]props) error {
+	if !ps.sidecar {
+		return nil
+	}
+	name := path.Join(dir, propsFileName)
+	if len(entries) == 0 {
+		err := VFS.Remove(name)
+		if err != nil && err != vfs.ENOENT {
+			return errors.Wrap(err, "failed to remove WebDAV properties")
+		}
+		return nil
+	}
+	stored := make(map[string][]storedProp, len(entries))
+	for leaf, entry := range entries {
+		storedProps := make([]storedProp, 0, len(entry))
+		for xmlName, prop := range entry {
+			storedProps = append(storedProps, storedProp{
+				Space: xmlName.Space,
+				Local: xmlName.Local,
+				Lang:  prop.Lang,
+				Value: string(prop.InnerXML),
+			})
+		}
+		sort.Slice(storedProps, func(i, j int) bool {
+			if storedProps[i].Space != storedProps[j].Space {
+				return storedProps[i].Space < storedProps[j].Space
+			}
+			return storedProps[i].Local < storedProps[j].Local
+		})
+		stored[leaf] = storedProps
+	}
+	data, err := json.MarshalIndent(stored, "", "\t")
+	if err != nil {
+		return err
+	}
+	fd, err := VFS.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return errors.Wrap(err, "failed to write WebDAV properties")
+	}
+	_, err = fd.Write(data)
+	closeErr := fd.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to write WebDAV properties")
+	}
+	return nil
+}
+
+// get returns a copy of the dead properties of the node at p
+func (ps *propStore) get(VFS *vfs.VFS, p string, isDir bool) (props, error) {
+	dir, leaf := propKey(p, isDir)
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	entries, err := ps._load(VFS, dir)
+	if err != nil {
+		return nil, err
+	}
+	out := make(props, len(entries[leaf]))
+	for xmlName, prop := range entries[leaf] {
+		out[xmlName] = prop
+	}
+	return out, nil
+}
+
+// patch applies patches to the dead properties of the node at p
+func (ps *propStore) patch(VFS *vfs.VFS, p string, isDir bool, patches []webdav.Proppatch) error {
+	dir, leaf := propKey(p, isDir)
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	entries, err := ps._load(VFS, dir)
+	if err != nil {
+		return err
+	}
+	entries = copyEntries(entries)
+	entry := make(props, len(entries[leaf]))
+	for xmlName, prop := range entries[leaf] {
+		entry[xmlName] = prop
+	}
+	for _, patch := range patches {
+		for _, prop := range patch.Props {
+			if patch.Remove {
+				delete(entry, prop.XMLName)
+			} else {
+				entry[prop.XMLName] = prop
+			}
+		}
+	}
+	if len(entry) == 0 {
+		delete(entries, leaf)
+	} else {
+		entries[leaf] = entry
+	}
+	return ps._save(VFS, dir, entries)
+}
+
+// isInDir returns true if p is dir or inside it
+func isInDir(p, dir string) bool {
+	return dir == "" || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// rename moves the dead properties of the node at oldName to newName
+// after it has been renamed.
+func (ps *propStore) rename(VFS *vfs.VFS, oldName, newName string, isDir bool) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if isDir {
+		// The sidecars move with the directory so just fix up the
+		// cached properties
+		oldDir, _ := propKey(oldName, true)
+		newDir, _ := propKey(newName, true)
+		moved := make(map[string]map[string]props)
+		for dir, entries := range ps.dirs {
+			if isInDir(dir, oldDir) {
+				moved[newDir+strings.TrimPrefix(dir, oldDir)] = entries
+			}
+			if isInDir(dir, oldDir) || isInDir(dir, newDir) {
+				delete(ps.dirs, dir)
+			}
+		}
+		for dir, entries := range moved {
+			ps.dirs[dir] = entries
+		}
+		return nil
+	}
+	oldDir, oldLeaf := propKey(oldName, false)
+	newDir, newLeaf := propKey(newName, false)
+	oldEntries, err := ps._load(VFS, oldDir)
+	if err != nil {
+		return err
+	}
+	newEntries, err := ps._load(VFS, newDir)
+	if err != nil {
+		return err
+	}
+	entry, found := oldEntries[oldLeaf]
+	_, existing := newEntries[newLeaf]
+	if !found && !existing {
+		return nil
+	}
+	oldEntries = copyEntries(oldEntries)
+	if newDir == oldDir {
+		newEntries = oldEntries
+	} else {
+		newEntries = copyEntries(newEntries)
+	}
+	delete(oldEntries, oldLeaf)
+	if found {
+		newEntries[newLeaf] = entry
+	} else {
+		delete(newEntries, newLeaf)
+	}
+	err = ps._save(VFS, oldDir, oldEntries)
+	if err != nil {
+		return err
+	}
+	if newDir != oldDir {
+		return ps._save(VFS, newDir, newEntries)
+	}
+	return nil
+}
+
+// remove removes the dead properties of the node at p after it has
+// been removed
+func (ps *propStore) remove(VFS *vfs.VFS, p string, isDir bool) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if isDir {
+		// The sidecars were removed with the directory
+		dir, _ := propKey(p, true)
+		for cachedDir := range ps.dirs {
+			if isInDir(cachedDir, dir) {
+				delete(ps.dirs, cachedDir)
+			}
+		}
+		return nil
+	}
+	dir, leaf := propKey(p, false)
+	entries, err := ps._load(VFS, dir)
+	if err != nil {
+		return err
+	}
+	if _, found := entries[leaf]; !found {
+		return nil
+	}
+	entries = copyEntries(entries)
+	delete(entries, leaf)
+	return ps._save(VFS, dir, entries)
+}
+
+// quota returns the bytes used and available to the user of VFS or
+// -1 if not known.
+//
+// The per-user quota from the auth proxy is used if there is one,
+// otherwise the usage of the backend.
+func quota(VFS *vfs.VFS, limits *proxy.Limits) (used, free int64) {
+	used, free = -1, -1
+	if limits != nil && limits.Quota >= 0 {
+		used = limits.Used()
+		free = int64(limits.Quota) - used
+		if free < 0 {
+			free = 0
+		}
+		return used, free
+	}
+	if VFS.Fs().Features().About != nil {
+		_, used, free = VFS.Statfs()
+	}
+	return used, free
+}
+
+// DeadProps returns the dead properties of the file or directory
+// along with the quota properties for directories.
+//
+// This implements webdav.DeadPropsHolder
+func (h Handle) DeadProps() (map[xml.Name]webdav.Property, error) {
+	if h.props == nil {
+		return nil, nil
+	}
+	node := h.Node()
+	out, err := h.props.get(h.vfs, node.Path(), node.IsDir())
+	if err != nil {
+		return nil, err
+	}
+	if node.IsDir() {
+		used, free := quota(h.vfs, h.limits)
+		if used >= 0 {
+			out[quotaUsedBytes] = webdav.Property{
+				XMLName:  quotaUsedBytes,
+				InnerXML: []byte(strconv.FormatInt(used, 10)),
+			}
+		}
+		if free >= 0 {
+			out[quotaAvailableBytes] = webdav.Property{
+				XMLName:  quotaAvailableBytes,
+				InnerXML: []byte(strconv.FormatInt(free, 10)),
+			}
+		}
+	}
+	return out, nil
+}
+
+// Patch patches the dead properties of the file or directory.
+//
+// The quota properties are computed so can't be patched and nothing
+// can be patched if the VFS is read only.
+//
+// This implements webdav.DeadPropsHolder
+func (h Handle) Patch(patches []webdav.Proppatch) ([]webdav.Propstat, error) {
+	forbidden := webdav.Propstat{Status: http.StatusForbidden}
+	failedDep := webdav.Propstat{Status: http.StatusFailedDependency}
+	for _, patch := range patches {
+		for _, prop := range patch.Props {
+			if h.props == nil || h.vfs.Opt.ReadOnly || isQuotaProp(prop.XMLName) {
+				forbidden.Props = append(forbidden.Props, webdav.Property{XMLName: prop.XMLName})
+			} else {
+				failedDep.Props = append(failedDep.Props, webdav.Property{XMLName: prop.XMLName})
+			}
+		}
+	}
+	if len(forbidden.Props) > 0 {
+		if len(failedDep.Props) == 0 {
+			return []webdav.Propstat{forbidden}, nil
+		}
+		return []webdav.Propstat{forbidden, failedDep}, nil
+	}
+	node := h.Node()
+	err := h.props.patch(h.vfs, node.Path(), node.IsDir(), patches)
+	if err != nil {
+		return nil, err
+	}
+	ok := webdav.Propstat{Status: http.StatusOK}
+	for _, patch := range patches {
+		for _, prop := range patch.Props {
+			ok.Props = append(ok.Props, webdav.Property{XMLName: prop.XMLName})
+		}
+	}
+	return []webdav.Propstat{ok}, nil
+}
+
+// check interface
+var _ webdav.DeadPropsHolder = Handle{}
@@ -0,0 +1,288 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/rclone/rclone/backend/local"
+	_ "github.com/rclone/rclone/backend/memory"
+	"github.com/rclone/rclone/cmd/serve/httplib"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/vfs"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+)
+
+func TestPropKey(t *testing.T) {
+	for _, test := range []struct {
+		p     string
+		isDir bool
+		dir   string
+		leaf  string
+	}{
+		{"/", true, "", "."},
+		{"", true, "", "."},
+		{"/dir/", true, "dir", "."},
+		{"/file.txt", false, "", "file.txt"},
+		{"dir/sub/file.txt", false, "dir/sub", "file.txt"},
+	} {
+		dir, leaf := propKey(test.p, test.isDir)
+		assert.Equal(t, test.dir, dir, test.p)
+		assert.Equal(t, test.leaf, leaf, test.p)
+	}
+}
+
+func TestPropStore(t *testing.T) {
+	ctx := context.Background()
+	f, err := fs.NewFs(ctx, ":memory:webdav-props-test")
+	require.NoError(t, err)
+	VFS := vfs.New(f, nil)
+	defer VFS.Shutdown()
+	require.NoError(t, VFS.Mkdir("dir", 0777))
+	require.NoError(t, VFS.Mkdir("dir/sub", 0777))
+
+	colour := xml.Name{Space: "http://example.com/", Local: "colour"}
+	size := xml.Name{Space: "http://example.com/", Local: "size"}
+	set := func(ps *propStore, p string, isDir bool, name xml.Name, value string) {
+		require.NoError(t, ps.patch(VFS, p, isDir, []webdav.Proppatch{{
+			Props: []webdav.Property{{XMLName: name, InnerXML: []byte(value)}},
+		}}))
+	}
+	get := func(ps *propStore, p string, isDir bool) map[string]string {
+		got, err := ps.get(VFS, p, isDir)
+		require.NoError(t, err)
+		out := map[string]string{}
+		for xmlName, prop := range got {
+			out[xmlName.Local] = string(prop.InnerXML)
+		}
+		return out
+	}
+
+	ps := newPropStore(true)
+	set(ps, "/dir/file.txt", false, colour, "red")
+	set(ps, "/dir/file.txt", false, size, "big")
+	set(ps, "/dir/sub", true, colour, "blue")
+	assert.Equal(t, map[string]string{"colour": "red", "size": "big"}, get(ps, "dir/file.txt", false))
+	assert.Equal(t, map[string]string{"colour": "blue"}, get(ps, "dir/sub/", true))
+	assert.Equal(t, map[string]string{}, get(ps, "dir", true))
+
+	// the copy returned can't change the store
+	got, err := ps.get(VFS, "dir/file.txt", false)
+	require.NoError(t, err)
+	delete(got, colour)
+	assert.Equal(t, map[string]string{"colour": "red", "size": "big"}, get(ps, "dir/file.txt", false))
+
+	// removing a property
+	require.NoError(t, ps.patch(VFS, "dir/file.txt", false, []webdav.Proppatch{{
+		Remove: true,
+		Props:  []webdav.Property{{XMLName: size}},
+	}}))
+	assert.Equal(t, map[string]string{"colour": "red"}, get(ps, "dir/file.txt", false))
+
+	// the sidecars are read by a new store
+	_, err = VFS.Stat("dir/" + propsFileName)
+	require.NoError(t, err)
+	ps = newPropStore(true)
+	assert.Equal(t, map[string]string{"colour": "red"}, get(ps, "dir/file.txt", false))
+	assert.Equal(t, map[string]string{"colour": "blue"}, get(ps, "dir/sub", true))
+
+	// renaming a file moves its properties
+	require.NoError(t, ps.rename(VFS, "dir/file.txt", "file2.txt", false))
+	assert.Equal(t, map[string]string{}, get(ps, "dir/file.txt", false))
+	assert.Equal(t, map[string]string{"colour": "red"}, get(ps, "file2.txt", false))
+	_, err = VFS.Stat("dir/" + propsFileName)
+	assert.Equal(t, vfs.ENOENT, err, "empty sidecar should be removed")
+
+	// renaming a directory moves the properties inside it
+	require.NoError(t, VFS.Rename("dir", "dir2"))
+	require.NoError(t, ps.rename(VFS, "dir", "dir2", true))
+	assert.Equal(t, map[string]string{"colour": "blue"}, get(ps, "dir2/sub", true))
+	assert.Equal(t, map[string]string{}, get(ps, "dir/sub", true))
+	_, err = f.NewObject(ctx, "dir2/sub/"+propsFileName)
+	require.NoError(t, err, "sidecar should move with the directory")
+
+	// removing forgets the properties
+	require.NoError(t, ps.remove(VFS, "file2.txt", false))
+	assert.Equal(t, map[string]string{}, get(ps, "file2.txt", false))
+	node, err := VFS.Stat("dir2")
+	require.NoError(t, err)
+	require.NoError(t, node.RemoveAll())
+	require.NoError(t, ps.remove(VFS, "dir2", true))
+	assert.Equal(t, map[string]string{}, get(ps, "dir2/sub", true))
+
+	// without the sidecar nothing is written
+	ps = newPropStore(false)
+	set(ps, "file3.txt", false, colour, "green")
+	assert.Equal(t, map[string]string{"colour": "green"}, get(ps, "file3.txt", false))
+	_, err = VFS.Stat(propsFileName)
+	assert.Equal(t, vfs.ENOENT, err)
+
+	// if the sidecar can't be written the properties aren't changed
+	ps = newPropStore(true)
+	set(ps, "file4.txt", false, colour, "green")
+	opt := vfscommon.DefaultOpt
+	opt.ReadOnly = true
+	roVFS := vfs.New(f, &opt)
+	defer roVFS.Shutdown()
+	err = ps.patch(roVFS, "file4.txt", false, []webdav.Proppatch{{
+		Props: []webdav.Property{{XMLName: colour, InnerXML: []byte("black")}},
+	}})
+	assert.Error(t, err)
+	assert.Equal(t, map[string]string{"colour": "green"}, get(ps, "file4.txt", false))
+	err = ps.patch(roVFS, "file4.txt", false, []webdav.Proppatch{{
+		Remove: true,
+		Props:  []webdav.Property{{XMLName: colour}},
+	}})
+	assert.Error(t, err)
+	assert.Equal(t, map[string]string{"colour": "green"}, get(ps, "file4.txt", false))
+	assert.Error(t, ps.remove(roVFS, "file4.txt", false))
+	assert.Equal(t, map[string]string{"colour": "green"}, get(ps, "file4.txt", false))
+}
+
+func TestETagHash(t *testing.T) {
+	ctx := context.Background()
+	memory, err := fs.NewFs(ctx, ":memory:webdav-etag-test")
+	require.NoError(t, err)
+	local, err := fs.NewFs(ctx, t.TempDir())
+	require.NoError(t, err)
+	defer func() {
+		hashName = ""
+		hashType = hash.None
+	}()
+
+	hashName, hashType = "", hash.None
+	assert.Equal(t, hash.None, etagHash(memory), "off by default")
+	assert.Equal(t, hash.None, etagHash(local), "off by default")
+
+	hashName = "auto"
+	assert.Equal(t, hash.MD5, etagHash(memory))
+	assert.Equal(t, local.Hashes().GetOne(), etagHash(local))
+
+	hashName = "off"
+	assert.Equal(t, hash.None, etagHash(memory))
+
+	hashName, hashType = "SHA-1", hash.SHA1
+	assert.Equal(t, hash.SHA1, etagHash(local))
+}
+
+// davRequest does a WebDAV request returning the status and body
+func davRequest(t *testing.T, method, url, body string, headers ...string) (int, string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data)
+}
+
+func TestProperties(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte("hello"), 0666))
+	f, err := fs.NewFs(ctx, dir)
+	require.NoError(t, err)
+	propsSidecar = true
+	defer func() {
+		propsSidecar = false
+	}()
+
+	opt := httplib.DefaultOpt
+	opt.ListenAddr = testBindAddress
+	w, err := newWebDAV(ctx, f, &opt)
+	require.NoError(t, err)
+	require.NoError(t, w.serve())
+	defer func() {
+		w.Close()
+		w.Wait()
+	}()
+	testURL := w.Server.URL()
+
+	const proppatch = `<?xml version="1.0" encoding="utf-8" ?>
+<D:propertyupdate xmlns:D="DAV:" xmlns:Z="http://example.com/">
+  <D:set><D:prop><Z:colour>red</Z:colour></D:prop></D:set>
+</D:propertyupdate>`
+	status, body := davRequest(t, "PROPPATCH", testURL+"file.txt", proppatch)
+	require.Equal(t, http.StatusMultiStatus, status, body)
+	assert.Contains(t, body, "200 OK")
+
+	const propfind = `<?xml version="1.0" encoding="utf-8" ?>
+<D:propfind xmlns:D="DAV:" xmlns:Z="http://example.com/">
+  <D:prop><Z:colour/><D:quota-available-bytes/><D:quota-used-bytes/></D:prop>
+</D:propfind>`
+	status, body = davRequest(t, "PROPFIND", testURL+"file.txt", propfind, "Depth", "0")
+	require.Equal(t, http.StatusMultiStatus, status, body)
+	assert.Contains(t, body, ">red</")
+
+	// the directory has the quota properties and the sidecar is hidden
+	status, body = davRequest(t, "PROPFIND", testURL, propfind, "Depth", "1")
+	require.Equal(t, http.StatusMultiStatus, status, body)
+	assert.Contains(t, body, "quota-available-bytes>")
+	assert.Contains(t, body, "quota-used-bytes>")
+	assert.NotContains(t, body, propsFileName)
+	_, err = os.Stat(filepath.Join(dir, propsFileName))
+	assert.NoError(t, err)
+
+	// the sidecar can't be used directly
+	sidecar, err := ioutil.ReadFile(filepath.Join(dir, propsFileName))
+	require.NoError(t, err)
+	for _, test := range []struct {
+		method string
+		path   string
+		header []string
+	}{
+		{"GET", propsFileName, nil},
+		{"HEAD", propsFileName, nil},
+		{"GET", propsFileName + "/", nil},
+		{"PROPFIND", propsFileName, []string{"Depth", "0"}},
+		{"PUT", propsFileName, nil},
+		{"DELETE", propsFileName, nil},
+		{"MKCOL", propsFileName, nil},
+		{"MOVE", propsFileName, []string{"Destination", testURL + "stolen.json"}},
+		{"COPY", propsFileName, []string{"Destination", testURL + "stolen.json"}},
+		{"MOVE", "file.txt", []string{"Destination", testURL + propsFileName}},
+		{"COPY", "file.txt", []string{"Destination", testURL + propsFileName}},
+	} {
+		what := test.method + " " + test.path
+		status, _ = davRequest(t, test.method, testURL+test.path, "{}", test.header...)
+		assert.True(t, status >= 400, what)
+		got, err := ioutil.ReadFile(filepath.Join(dir, propsFileName))
+		require.NoError(t, err, what)
+		assert.Equal(t, string(sidecar), string(got), what)
+	}
+	_, err = os.Stat(filepath.Join(dir, "stolen.json"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "file.txt"))
+	assert.NoError(t, err)
+
+	// the quota properties can't be set
+	const quotaPatch = `<?xml version="1.0" encoding="utf-8" ?>
+<D:propertyupdate xmlns:D="DAV:">
+  <D:set><D:prop><D:quota-used-bytes>0</D:quota-used-bytes></D:prop></D:set>
+</D:propertyupdate>`
+	status, body = davRequest(t, "PROPPATCH", testURL, quotaPatch)
+	require.Equal(t, http.StatusMultiStatus, status, body)
+	assert.Contains(t, body, "403 Forbidden")
+
+	// the properties move with the file
+	status, body = davRequest(t, "MOVE", testURL+"file.txt", "", "Destination", testURL+"moved.txt")
+	require.Equal(t, http.StatusCreated, status, body)
+	status, body = davRequest(t, "PROPFIND", testURL+"moved.txt", propfind, "Depth", "0")
+	require.Equal(t, http.StatusMultiStatus, status, body)
+	assert.Contains(t, body, ">red</")
+}
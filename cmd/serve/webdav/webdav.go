@@ -3,12 +3,16 @@ package webdav
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rclone/rclone/cmd"
+	"github.com/rclone/rclone/cmd/serve/auth"
+	"github.com/rclone/rclone/cmd/serve/auth/authflags"
 	"github.com/rclone/rclone/cmd/serve/httplib"
 	"github.com/rclone/rclone/cmd/serve/httplib/httpflags"
 	"github.com/rclone/rclone/cmd/serve/httplib/serve"
@@ -28,6 +32,7 @@ var (
 	hashName      string
 	hashType      = hash.None
 	disableGETDir = false
+	propsSidecar  = false
 )
 
 func init() {
@@ -35,8 +40,10 @@ func init() {
 	httpflags.AddFlags(flagSet)
 	vfsflags.AddFlags(flagSet)
 	proxyflags.AddFlags(flagSet)
+	authflags.AddFlags(flagSet)
 	flags.StringVarP(flagSet, &hashName, "etag-hash", "", "", "Which hash to use for the ETag, or auto or blank for off")
 	flags.BoolVarP(flagSet, &disableGETDir, "disable-dir-list", "", false, "Disable HTML directory list on GET request for a directory")
+	flags.BoolVarP(flagSet, &propsSidecar, "props-sidecar", "", false, "Store the properties set by clients in a hidden file in each directory")
 }
 
 // Command definition for cobra
@@ -53,16 +60,32 @@ type webdav to read and write it.
 
 #### --etag-hash 
 
-This controls the ETag header.  Without this flag the ETag will be
-based on the ModTime and Size of the object.
+This controls the ETag header and the getetag property.  Without this
+flag (or if it is set to "off") the ETag will be based on the ModTime
+and Size of the object.
 
 If this flag is set to "auto" then rclone will choose the first
-supported hash on the backend or you can use a named hash such as
+supported hash on the backend, or you can use a named hash such as
 "MD5" or "SHA-1".
 
 Use "rclone hashsum" to see the full list.
 
-` + httplib.Help + vfs.Help + proxy.Help,
+#### Properties and quota
+
+Clients can set their own (dead) properties on files and directories
+with PROPPATCH and these are returned by PROPFIND. By default they are
+kept in memory so are lost when the server is restarted. Use
+` + "`--props-sidecar`" + ` to store them in a hidden file called
+` + "`" + propsFileName + "`" + ` in each directory instead. These files
+can't be read, written or listed by WebDAV clients and move with their
+directory when it is renamed.
+
+Directories have the RFC 4331 quota-available-bytes and
+quota-used-bytes properties which Windows and macOS use to show the
+free space. These come from the backend's usage if it supports
+"rclone about" or from the per-user quota set by the auth proxy.
+
+` + httplib.Help + auth.Help + auth.RootHelp + vfs.Help + proxy.Help,
 	RunE: func(command *cobra.Command, args []string) error {
 		var f fs.Fs
 		if proxyflags.Opt.AuthProxy == "" {
@@ -72,20 +95,21 @@ Use "rclone hashsum" to see the full list.
 			cmd.CheckArgs(0, 0, command, args)
 		}
 		hashType = hash.None
-		if hashName == "auto" {
-			hashType = f.Hashes().GetOne()
-		} else if hashName != "" {
+		switch hashName {
+		case "", "auto", "off":
+		default:
 			err := hashType.Set(hashName)
 			if err != nil {
 				return err
 			}
-		}
-		if hashType != hash.None {
 			fs.Debugf(f, "Using hash %v for ETag", hashType)
 		}
 		cmd.Run(false, false, command, func() error {
-			s := newWebDAV(context.Background(), f, &httpflags.Opt)
-			err := s.serve()
+			s, err := newWebDAV(context.Background(), f, &httpflags.Opt)
+			if err != nil {
+				return err
+			}
+			err = s.serve()
 			if err != nil {
 				return err
 			}
@@ -114,36 +138,58 @@ type WebDAV struct {
 	_vfs          *vfs.VFS // don't use directly, use getVFS
 	webdavhandler *webdav.Handler
 	proxy         *proxy.Proxy
+	perUser       bool            // set if each user has their own VFS
 	ctx           context.Context // for global config
+	propsMu       sync.Mutex
+	props         map[string]*propStore // dead properties for each user
 }
 
 // check interface
 var _ webdav.FileSystem = (*WebDAV)(nil)
 
 // Make a new WebDAV to serve the remote
-func newWebDAV(ctx context.Context, f fs.Fs, opt *httplib.Options) *WebDAV {
+func newWebDAV(ctx context.Context, f fs.Fs, opt *httplib.Options) (*WebDAV, error) {
 	w := &WebDAV{
-		f:   f,
-		ctx: ctx,
+		f:     f,
+		ctx:   ctx,
+		props: make(map[string]*propStore),
 	}
 	if proxyflags.Opt.AuthProxy != "" {
+		if authflags.Opt.HTTPEnabled() {
+			return nil, errors.New("can't use --auth-proxy with the authentication backends")
+		}
 		w.proxy = proxy.New(ctx, &proxyflags.Opt)
+		w.perUser = true
 		// override auth
 		copyOpt := *opt
 		copyOpt.Auth = w.auth
 		opt = &copyOpt
 	} else {
-		w._vfs = vfs.New(f, &vfsflags.Opt)
+		authOpt, err := authflags.HTTPOptions(ctx, f, opt)
+		if err != nil {
+			return nil, err
+		}
+		if authOpt == opt {
+			w._vfs = vfs.New(f, &vfsflags.Opt)
+		}
+		w.perUser = authflags.Opt.Root != ""
+		opt = authOpt
 	}
 	w.Server = httplib.NewServer(http.HandlerFunc(w.handler), opt)
+	// With the auth proxy or authentication backends the VFS comes
+	// with each request so the locks can't be shared with it
+	var lockSystem webdav.LockSystem = webdav.NewMemLS()
+	if w._vfs != nil {
+		lockSystem = newLockSystem(w._vfs)
+	}
 	webdavHandler := &webdav.Handler{
 		Prefix:     w.Server.Opt.BaseURL,
 		FileSystem: w,
-		LockSystem: webdav.NewMemLS(),
+		LockSystem: lockSystem,
 		Logger:     w.logRequest, // FIXME
 	}
 	w.webdavhandler = webdavHandler
-	return w
+	return w, nil
 }
 
 // Gets the VFS in use for this request
@@ -162,6 +208,38 @@ func (w *WebDAV) getVFS(ctx context.Context) (VFS *vfs.VFS, err error) {
 	return VFS, nil
 }
 
+// Gets the per-user limits for this request or nil if there aren't
+// any
+func (w *WebDAV) getLimits(ctx context.Context) *proxy.Limits {
+	if w.proxy == nil {
+		return nil
+	}
+	user, ok := ctx.Value(httplib.ContextUserKey).(string)
+	if !ok {
+		return nil
+	}
+	return w.proxy.Limits(user)
+}
+
+// Gets the dead property store for this request
+//
+// If each user has their own VFS they have their own store so it
+// survives their VFS being expired from the cache.
+func (w *WebDAV) getProps(ctx context.Context) *propStore {
+	var user string
+	if w.perUser {
+		user, _ = ctx.Value(httplib.ContextUserKey).(string)
+	}
+	w.propsMu.Lock()
+	defer w.propsMu.Unlock()
+	ps := w.props[user]
+	if ps == nil {
+		ps = newPropStore(propsSidecar)
+		w.props[user] = ps
+	}
+	return ps
+}
+
 // auth does proxy authorization
 func (w *WebDAV) auth(user, pass string) (value interface{}, err error) {
 	VFS, _, err := w.proxy.Call(user, pass, false)
@@ -196,6 +274,9 @@ func (w *WebDAV) serveDir(rw http.ResponseWriter, r *http.Request, dirRemote str
 	}
 	// List the directory
 	node, err := VFS.Stat(dirRemote)
+	if err == nil && isPropsFile(dirRemote) {
+		err = vfs.ENOENT
+	}
 	if err == vfs.ENOENT {
 		http.Error(rw, "Directory not found", http.StatusNotFound)
 		return
@@ -218,6 +299,9 @@ func (w *WebDAV) serveDir(rw http.ResponseWriter, r *http.Request, dirRemote str
 	// Make the entries for display
 	directory := serve.NewDirectory(dirRemote, w.HTMLTemplate)
 	for _, node := range dirEntries {
+		if node.Name() == propsFileName {
+			continue
+		}
 		if vfsflags.Opt.NoModTime {
 			directory.AddHTMLEntry(node.Path(), node.IsDir(), node.Size(), time.Time{})
 		} else {
@@ -252,6 +336,9 @@ func (w *WebDAV) logRequest(r *http.Request, err error) {
 // Mkdir creates a directory
 func (w *WebDAV) Mkdir(ctx context.Context, name string, perm os.FileMode) (err error) {
 	// defer log.Trace(name, "perm=%v", perm)("err = %v", &err)
+	if isPropsFile(name) {
+		return vfs.ENOENT
+	}
 	VFS, err := w.getVFS(ctx)
 	if err != nil {
 		return err
@@ -267,20 +354,63 @@ func (w *WebDAV) Mkdir(ctx context.Context, name string, perm os.FileMode) (err
 // OpenFile opens a file or a directory
 func (w *WebDAV) OpenFile(ctx context.Context, name string, flags int, perm os.FileMode) (file webdav.File, err error) {
 	// defer log.Trace(name, "flags=%v, perm=%v", flags, perm)("err = %v", &err)
+	if isPropsFile(name) {
+		return nil, vfs.ENOENT
+	}
 	VFS, err := w.getVFS(ctx)
 	if err != nil {
 		return nil, err
 	}
+	// The webdav library opens files O_RDWR with no other flags only
+	// to PROPPATCH them. The properties aren't stored in the file and
+	// the VFS can't always open files (or directories) read/write so
+	// open it read only instead.
+	if flags == os.O_RDWR && perm == 0 {
+		flags = os.O_RDONLY
+	}
+	limits := w.getLimits(ctx)
+	var freed int64
+	if flags&os.O_TRUNC != 0 && flags&(os.O_WRONLY|os.O_RDWR) != 0 {
+		freed = limits.Size(VFS, name)
+	}
 	f, err := VFS.OpenFile(name, flags, perm)
 	if err != nil {
 		return nil, err
 	}
-	return Handle{f}, nil
+	limits.Freed(freed) // the old contents were truncated
+	h := Handle{
+		Handle: f,
+		vfs:    VFS,
+		limits: limits,
+		props:  w.getProps(ctx),
+	}
+	if limits != nil && flags&(os.O_WRONLY|os.O_RDWR) != 0 {
+		var size, offset int64
+		if flags&os.O_TRUNC == 0 {
+			fi, err := f.Stat()
+			if err != nil {
+				_ = f.Close()
+				return nil, err
+			}
+			size = fi.Size()
+			if flags&os.O_APPEND != 0 {
+				offset = size
+			}
+		}
+		return limitedHandle{
+			Handle: h,
+			w:      limits.NewUpload(name, size).Writer(f, offset),
+		}, nil
+	}
+	return h, nil
 }
 
 // RemoveAll removes a file or a directory and its contents
 func (w *WebDAV) RemoveAll(ctx context.Context, name string) (err error) {
 	// defer log.Trace(name, "")("err = %v", &err)
+	if isPropsFile(name) {
+		return vfs.ENOENT
+	}
 	VFS, err := w.getVFS(ctx)
 	if err != nil {
 		return err
@@ -289,26 +419,49 @@ func (w *WebDAV) RemoveAll(ctx context.Context, name string) (err error) {
 	if err != nil {
 		return err
 	}
+	limits := w.getLimits(ctx)
+	freed := limits.Size(VFS, name)
 	err = node.RemoveAll()
 	if err != nil {
 		return err
 	}
-	return nil
+	limits.Freed(freed)
+	return w.getProps(ctx).remove(VFS, name, node.IsDir())
 }
 
 // Rename a file or a directory
 func (w *WebDAV) Rename(ctx context.Context, oldName, newName string) (err error) {
 	// defer log.Trace(oldName, "newName=%q", newName)("err = %v", &err)
+	if isPropsFile(oldName) || isPropsFile(newName) {
+		return vfs.ENOENT
+	}
 	VFS, err := w.getVFS(ctx)
 	if err != nil {
 		return err
 	}
-	return VFS.Rename(oldName, newName)
+	node, err := VFS.Stat(oldName)
+	if err != nil {
+		return err
+	}
+	limits := w.getLimits(ctx)
+	var freed int64
+	if oldName != newName {
+		freed = limits.Size(VFS, newName)
+	}
+	err = VFS.Rename(oldName, newName)
+	if err != nil {
+		return err
+	}
+	limits.Freed(freed)
+	return w.getProps(ctx).rename(VFS, oldName, newName, node.IsDir())
 }
 
 // Stat returns info about the file or directory
 func (w *WebDAV) Stat(ctx context.Context, name string) (fi os.FileInfo, err error) {
 	// defer log.Trace(name, "")("fi=%+v, err = %v", &fi, &err)
+	if isPropsFile(name) {
+		return nil, vfs.ENOENT
+	}
 	VFS, err := w.getVFS(ctx)
 	if err != nil {
 		return nil, err
@@ -323,6 +476,9 @@ func (w *WebDAV) Stat(ctx context.Context, name string) (fi os.FileInfo, err err
 // Handle represents an open file
 type Handle struct {
 	vfs.Handle
+	vfs    *vfs.VFS
+	limits *proxy.Limits
+	props  *propStore
 }
 
 // Readdir reads directory entries from the handle
@@ -331,11 +487,15 @@ func (h Handle) Readdir(count int) (fis []os.FileInfo, err error) {
 	if err != nil {
 		return nil, err
 	}
-	// Wrap each FileInfo
-	for i := range fis {
-		fis[i] = FileInfo{fis[i]}
+	// Wrap each FileInfo, hiding the properties sidecar
+	out := fis[:0]
+	for _, fi := range fis {
+		if fi.Name() == propsFileName {
+			continue
+		}
+		out = append(out, FileInfo{fi})
 	}
-	return fis, nil
+	return out, nil
 }
 
 // Stat the handle
@@ -347,6 +507,18 @@ func (h Handle) Stat() (fi os.FileInfo, err error) {
 	return FileInfo{fi}, nil
 }
 
+// limitedHandle is an open file whose writes are checked against the
+// per-user limits
+type limitedHandle struct {
+	Handle
+	w io.Writer
+}
+
+// Write writes p unless that would break the limits
+func (h limitedHandle) Write(p []byte) (n int, err error) {
+	return h.w.Write(p)
+}
+
 // FileInfo represents info about a file satisfying os.FileInfo and
 // also some additional interfaces for webdav for ETag and ContentType
 type FileInfo struct {
@@ -356,9 +528,6 @@ type FileInfo struct {
 // ETag returns an ETag for the FileInfo
 func (fi FileInfo) ETag(ctx context.Context) (etag string, err error) {
 	// defer log.Trace(fi, "")("etag=%q, err=%v", &etag, &err)
-	if hashType == hash.None {
-		return "", webdav.ErrNotImplemented
-	}
 	node, ok := (fi.FileInfo).(vfs.Node)
 	if !ok {
 		fs.Errorf(fi, "Expecting vfs.Node, got %T", fi.FileInfo)
@@ -369,13 +538,29 @@ func (fi FileInfo) ETag(ctx context.Context) (etag string, err error) {
 	if !ok {
 		return "", webdav.ErrNotImplemented
 	}
-	hash, err := o.Hash(ctx, hashType)
+	ht := etagHash(o.Fs())
+	if ht == hash.None {
+		return "", webdav.ErrNotImplemented
+	}
+	hash, err := o.Hash(ctx, ht)
 	if err != nil || hash == "" {
 		return "", webdav.ErrNotImplemented
 	}
 	return `"` + hash + `"`, nil
 }
 
+// etagHash returns the hash to use for the ETags of objects on f or
+// hash.None to base them on the ModTime and Size
+func etagHash(f fs.Info) hash.Type {
+	if hashType != hash.None {
+		return hashType
+	}
+	if hashName == "auto" {
+		return f.Hashes().GetOne()
+	}
+	return hash.None
+}
+
 // ContentType returns a content type for the FileInfo
 func (fi FileInfo) ContentType(ctx context.Context) (contentType string, err error) {
 	// defer log.Trace(fi, "")("etag=%q, err=%v", &contentType, &err)
@@ -57,7 +57,8 @@ func TestWebDav(t *testing.T) {
 		hashType = hash.MD5
 
 		// Start the server
-		w := newWebDAV(context.Background(), f, &opt)
+		w, err := newWebDAV(context.Background(), f, &opt)
+		require.NoError(t, err)
 		assert.NoError(t, w.serve())
 
 		// Config for the backend we'll use to connect to the server
@@ -102,7 +103,8 @@ func TestHTTPFunction(t *testing.T) {
 	opt.Template = testTemplate
 
 	// Start the server
-	w := newWebDAV(context.Background(), f, &opt)
+	w, err := newWebDAV(context.Background(), f, &opt)
+	require.NoError(t, err)
 	assert.NoError(t, w.serve())
 	defer func() {
 		w.Close()
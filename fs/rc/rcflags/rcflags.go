@@ -2,6 +2,7 @@
 package rcflags
 
 import (
+	"github.com/rclone/rclone/cmd/serve/auth/authflags"
 	"github.com/rclone/rclone/cmd/serve/httplib/httpflags"
 	"github.com/rclone/rclone/fs/config/flags"
 	"github.com/rclone/rclone/fs/rc"
@@ -10,7 +11,8 @@ import (
 
 // Options set by command line flags
 var (
-	Opt = rc.DefaultOpt
+	Opt     = rc.DefaultOpt
+	AuthOpt = authflags.Opt // authentication backends - HtPasswd comes from Opt.HTTPOptions
 )
 
 // AddFlags adds the remote control flags to the flagSet
@@ -30,4 +32,5 @@ func AddFlags(flagSet *pflag.FlagSet) {
 	flags.DurationVarP(flagSet, &Opt.JobExpireDuration, "rc-job-expire-duration", "", Opt.JobExpireDuration, "expire finished async jobs older than this value")
 	flags.DurationVarP(flagSet, &Opt.JobExpireInterval, "rc-job-expire-interval", "", Opt.JobExpireInterval, "interval to check for expired async jobs")
 	httpflags.AddFlagsPrefix(flagSet, "rc-", &Opt.HTTPOptions)
+	authflags.AddFlagsPrefix(flagSet, "rc-", &AuthOpt)
 }
@@ -25,6 +25,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/skratchdot/open-golang/open"
 
+	"github.com/rclone/rclone/cmd/serve/auth"
 	"github.com/rclone/rclone/cmd/serve/httplib"
 	"github.com/rclone/rclone/cmd/serve/httplib/serve"
 	"github.com/rclone/rclone/fs"
@@ -93,11 +94,13 @@ func newServer(ctx context.Context, opt *rc.Options, mux *http.ServeMux) *Server
 			opt.NoAuth = false
 			fs.Infof(nil, "Cannot run Web GUI without authentication, using default auth")
 		}
-		if opt.HTTPOptions.BasicUser == "" {
+		if rcflags.AuthOpt.HTTPEnabled() {
+			fs.Infof(nil, "Using the authentication backends for the Web GUI")
+		} else if opt.HTTPOptions.BasicUser == "" {
 			opt.HTTPOptions.BasicUser = "gui"
 			fs.Infof(nil, "No username specified. Using default username: %s \n", rcflags.Opt.HTTPOptions.BasicUser)
 		}
-		if opt.HTTPOptions.BasicPass == "" {
+		if opt.HTTPOptions.BasicUser != "" && opt.HTTPOptions.BasicPass == "" {
 			randomPass, err := random.Password(128)
 			if err != nil {
 				log.Fatalf("Failed to make password: %v", err)
@@ -113,8 +116,12 @@ func newServer(ctx context.Context, opt *rc.Options, mux *http.ServeMux) *Server
 		pluginsHandler = http.FileServer(http.Dir(webgui.PluginsPath))
 	}
 
+	httpOpt, err := auth.NewHTTPOptions(ctx, nil, &rcflags.AuthOpt, &opt.HTTPOptions)
+	if err != nil {
+		log.Fatalf("Failed to set up the remote control authentication: %v", err)
+	}
 	s := &Server{
-		Server:         httplib.NewServer(mux, &opt.HTTPOptions),
+		Server:         httplib.NewServer(mux, httpOpt),
 		ctx:            ctx,
 		opt:            opt,
 		files:          fileHandler,
@@ -0,0 +1,137 @@
+// Package base32768 implements the base32768 binary to text encoding
+//
+// base32768 packs 15 bits into each character using characters from
+// the Unicode Basic Multilingual Plane, so each character is one
+// UTF-16 code unit. This makes it the densest encoding for systems
+// which limit names by UTF-16 code units rather than bytes.
+//
+// This is compatible with the encoding at
+// https://github.com/qntm/base32768
+package base32768
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	bitsPerChar  = 15 // bits encoded by each character
+	bitsPerFinal = 7  // bits encoded by the short final character
+)
+
+// repertoires are pairs of characters giving ranges of 32 code points
+// which the 15 bit and 7 bit values map onto in order
+const (
+	repertoire15 = "ҠҿԀԟڀڿݠޟ߀ߟကဟႠႿᄀᅟᆀᆟᇠሿበቿዠዿጠጿᎠᏟᐠᙟᚠᛟកសᠠᡟᣀᣟᦀᦟ᧠᧿ᨠᨿᯀᯟᰀᰟᴀᴟ⇠⇿⋀⋟⍀⏟␀␟─❟➀➿⠀⥿⦠⦿⨠⩟⪀⪿⫠⭟ⰀⰟⲀⳟⴀⴟⵀⵟ⺠⻟㇀㇟㐀䶟䷀龿ꀀꑿ꒠꒿ꔀꗿꙀꙟꚠꛟ꜀ꝟꞀꞟꡀꡟ"
+	repertoire7  = "ƀƟɀʟ"
+)
+
+// value is what a character decodes to
+type value struct {
+	bits int    // number of bits - 0 if not a valid character
+	z    uint16 // the bits
+}
+
+var (
+	encode15 [1 << bitsPerChar]rune
+	encode7  [1 << bitsPerFinal]rune
+	decode   = map[rune]value{}
+)
+
+// expand fills encode with the code points of the ranges in repertoire
+func expand(repertoire string, bits int, encode []rune) {
+	ranges := []rune(repertoire)
+	z := 0
+	for i := 0; i < len(ranges); i += 2 {
+		for r := ranges[i]; r <= ranges[i+1]; r++ {
+			encode[z] = r
+			decode[r] = value{bits: bits, z: uint16(z)}
+			z++
+		}
+	}
+	if z != len(encode) {
+		panic("base32768: bad repertoire")
+	}
+}
+
+func init() {
+	expand(repertoire15, bitsPerChar, encode15[:])
+	expand(repertoire7, bitsPerFinal, encode7[:])
+}
+
+// CorruptInputError is returned when the input isn't valid base32768.
+// The value is the byte offset of the bad character.
+type CorruptInputError int64
+
+func (e CorruptInputError) Error() string {
+	return "illegal base32768 data at input byte " + strconv.FormatInt(int64(e), 10)
+}
+
+// Encoding is the base32768 encoding
+type Encoding struct{}
+
+// StdEncoding is the base32768 encoding
+var StdEncoding = Encoding{}
+
+// EncodeToString returns the base32768 encoding of src
+func (Encoding) EncodeToString(src []byte) string {
+	var out strings.Builder
+	out.Grow(((len(src)*8 + bitsPerChar - 1) / bitsPerChar) * 3)
+	z, n := 0, 0
+	for _, b := range src {
+		for j := 7; j >= 0; j-- {
+			z = z<<1 | int(b>>uint(j)&1)
+			n++
+			if n == bitsPerChar {
+				out.WriteRune(encode15[z])
+				z, n = 0, 0
+			}
+		}
+	}
+	if n != 0 {
+		// pad the final bits with 1s to fill a character
+		final := bitsPerFinal
+		if n > bitsPerFinal {
+			final = bitsPerChar
+		}
+		for ; n < final; n++ {
+			z = z<<1 | 1
+		}
+		if final == bitsPerFinal {
+			out.WriteRune(encode7[z])
+		} else {
+			out.WriteRune(encode15[z])
+		}
+	}
+	return out.String()
+}
+
+// DecodeString returns the bytes represented by the base32768 string s
+func (Encoding) DecodeString(s string) ([]byte, error) {
+	out := make([]byte, 0, utf8.RuneCountInString(s)*bitsPerChar/8)
+	b, n := 0, 0
+	for i, r := range s {
+		v := decode[r]
+		if v.bits == 0 {
+			return nil, CorruptInputError(i)
+		}
+		// a short character is only allowed at the end
+		if v.bits != bitsPerChar && i+utf8.RuneLen(r) != len(s) {
+			return nil, CorruptInputError(i)
+		}
+		for j := v.bits - 1; j >= 0; j-- {
+			b = b<<1 | int(v.z>>uint(j)&1)
+			n++
+			if n == 8 {
+				out = append(out, byte(b))
+				b, n = 0, 0
+			}
+		}
+	}
+	// any bits left over must be padding
+	if n != 0 && b != 1<<uint(n)-1 {
+		return nil, CorruptInputError(len(s))
+	}
+	return out, nil
+}
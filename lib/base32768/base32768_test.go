@@ -0,0 +1,62 @@
+package base32768
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	for _, test := range []struct {
+		in       []byte
+		expected string
+	}{
+		{[]byte{}, ""},
+		// 00000000 + 1111111 padding = 127 which is the last of ڀ-ڿ
+		{[]byte{0x00}, "ڿ"},
+		// 15 zero bits then 1 zero bit + 111111 padding = 63 in the 7 bit repertoire
+		{[]byte{0x00, 0x00}, "Ҡɟ"},
+	} {
+		got := StdEncoding.EncodeToString(test.in)
+		assert.Equal(t, test.expected, got, test.in)
+		decoded, err := StdEncoding.DecodeString(got)
+		require.NoError(t, err)
+		assert.Equal(t, test.in, decoded)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 200; n++ {
+		in := make([]byte, n)
+		_, _ = r.Read(in)
+		encoded := StdEncoding.EncodeToString(in)
+		// one UTF-16 code unit per 15 bits
+		assert.Equal(t, (n*8+14)/15, len(utf16.Encode([]rune(encoded))), n)
+		decoded, err := StdEncoding.DecodeString(encoded)
+		require.NoError(t, err, n)
+		assert.True(t, bytes.Equal(in, decoded), n)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, test := range []struct {
+		in  string
+		err error
+	}{
+		{"a", CorruptInputError(0)},
+		{"ڿa", CorruptInputError(2)},
+		// 7 bit character before the end
+		{"ʟҠ", CorruptInputError(0)},
+		// padding isn't all 1s
+		{"Ҡ", CorruptInputError(2)},
+		{"\xff", CorruptInputError(0)},
+	} {
+		_, err := StdEncoding.DecodeString(test.in)
+		assert.Equal(t, test.err, err, test.in)
+	}
+}
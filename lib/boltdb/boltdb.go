@@ -0,0 +1,96 @@
+// Package boltdb opens bolt databases which can be shared by all the
+// users of them in this process.
+//
+// A bolt database can only be opened by one process at once and
+// opening it a second time in the same process blocks, so users
+// which may open the same database more than once, such as several
+// remotes using the same cache, should open it with this.
+package boltdb
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/lib/atexit"
+	bolt "go.etcd.io/bbolt"
+)
+
+// DB is a bolt database shared by the users in this process
+type DB struct {
+	*bolt.DB
+	path string
+	refs int // protected by dbsMu
+}
+
+var (
+	dbsMu      sync.Mutex
+	dbs        = map[string]*DB{}
+	atexitOnce sync.Once
+)
+
+// Open the database at path creating it, and the bucket in it, if
+// necessary.
+//
+// what is a description of the database, eg "hash", used in the
+// errors. Each Open should be balanced with a Close.
+func Open(path string, bucket []byte, what string) (*DB, error) {
+	dbsMu.Lock()
+	defer dbsMu.Unlock()
+	if d, ok := dbs[path]; ok {
+		d.refs++
+		return d, nil
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to make %s database directory", what)
+	}
+	boltDB, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s database %q - is it in use by another rclone?", what, path)
+	}
+	err = boltDB.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		_ = boltDB.Close()
+		return nil, errors.Wrapf(err, "failed to create %s bucket", what)
+	}
+	fs.Debugf(nil, "%s database: opened %q", what, path)
+	atexitOnce.Do(func() {
+		atexit.Register(closeAll)
+	})
+	d := &DB{
+		DB:   boltDB,
+		path: path,
+		refs: 1,
+	}
+	dbs[path] = d
+	return d, nil
+}
+
+// closeAll closes the databases which are still open
+func closeAll() {
+	dbsMu.Lock()
+	defer dbsMu.Unlock()
+	for path, d := range dbs {
+		_ = d.DB.Close()
+		delete(dbs, path)
+	}
+}
+
+// Close the database if this is the last user
+func (d *DB) Close() error {
+	dbsMu.Lock()
+	defer dbsMu.Unlock()
+	d.refs--
+	if d.refs > 0 || dbs[d.path] != d {
+		return nil
+	}
+	delete(dbs, d.path)
+	return d.DB.Close()
+}
@@ -0,0 +1,52 @@
+package boltdb
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucket = []byte("test")
+
+func TestOpenShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "test.db")
+	d, err := Open(path, bucket, "test")
+	require.NoError(t, err)
+
+	d2, err := Open(path, bucket, "test")
+	require.NoError(t, err)
+	assert.True(t, d == d2)
+	require.NoError(t, d2.Close())
+
+	// still open
+	require.NoError(t, d.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte("key"), []byte("value"))
+	}))
+	require.NoError(t, d.Close())
+	_, found := dbs[path]
+	assert.False(t, found)
+
+	// reopening sees the data
+	d, err = Open(path, bucket, "test")
+	require.NoError(t, err)
+	require.NoError(t, d.View(func(tx *bolt.Tx) error {
+		assert.Equal(t, []byte("value"), tx.Bucket(bucket).Get([]byte("key")))
+		return nil
+	}))
+	require.NoError(t, d.Close())
+}
+
+func TestCloseAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	d, err := Open(path, bucket, "test")
+	require.NoError(t, err)
+	closeAll()
+	_, found := dbs[path]
+	assert.False(t, found)
+
+	// closing after closeAll does nothing
+	require.NoError(t, d.Close())
+}
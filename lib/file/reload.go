@@ -0,0 +1,79 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reloader holds the parsed contents of a file, reading it again
+// whenever its size or modification time changes.
+//
+// This is used for files like user lists which can be edited while
+// they are in use.
+type Reloader struct {
+	path    string
+	parse   func(data []byte) (interface{}, error)
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	value   interface{}
+}
+
+// NewReloader reads the file at path and parses its contents with
+// parse.
+func NewReloader(path string, parse func(data []byte) (interface{}, error)) (*Reloader, error) {
+	r := &Reloader{
+		path:  path,
+		parse: parse,
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	err = r._load(fi)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// _load reads the file whose info is fi - call with mu held
+func (r *Reloader) _load(fi os.FileInfo) error {
+	data, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+	value, err := r.parse(data)
+	if err != nil {
+		return err
+	}
+	r.value = value
+	r.modTime = fi.ModTime()
+	r.size = fi.Size()
+	return nil
+}
+
+// Get returns the parsed contents of the file, reading it again
+// first if it has changed.
+//
+// If reading the changed file fails then the old contents are
+// returned along with the error. The file isn't read again until it
+// changes again.
+func (r *Reloader) Get() (value interface{}, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fi, statErr := os.Stat(r.path)
+	if statErr == nil && (!fi.ModTime().Equal(r.modTime) || fi.Size() != r.size) {
+		err = r._load(fi)
+		if err != nil {
+			// don't try again until the file changes
+			r.modTime = fi.ModTime()
+			r.size = fi.Size()
+		}
+	}
+	return r.value, err
+}
@@ -0,0 +1,64 @@
+package file
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader(t *testing.T) {
+	dir, tidy := testDir(t)
+	defer tidy()
+	path := filepath.Join(dir, "file")
+	parse := func(data []byte) (interface{}, error) {
+		if string(data) == "bad" {
+			return nil, errors.New("bad file")
+		}
+		return string(data), nil
+	}
+	write := func(contents string, age time.Duration) {
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+		require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(age)))
+	}
+
+	_, err := NewReloader(path, parse)
+	assert.True(t, os.IsNotExist(err))
+
+	write("bad", 0)
+	_, err = NewReloader(path, parse)
+	assert.EqualError(t, err, "bad file")
+
+	write("one", 0)
+	r, err := NewReloader(path, parse)
+	require.NoError(t, err)
+	value, err := r.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "one", value)
+
+	// changes are picked up
+	write("two", time.Minute)
+	value, err = r.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "two", value)
+
+	// a bad file keeps the old contents and is only reported once
+	write("bad", 2*time.Minute)
+	value, err = r.Get()
+	assert.EqualError(t, err, "bad file")
+	assert.Equal(t, "two", value)
+	value, err = r.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "two", value)
+
+	// a deleted file keeps the old contents
+	require.NoError(t, os.Remove(path))
+	value, err = r.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "two", value)
+}
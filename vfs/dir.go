@@ -15,6 +15,7 @@ import (
 	"github.com/rclone/rclone/fs/dirtree"
 	"github.com/rclone/rclone/fs/list"
 	"github.com/rclone/rclone/fs/log"
+	"github.com/rclone/rclone/fs/object"
 	"github.com/rclone/rclone/fs/operations"
 	"github.com/rclone/rclone/fs/walk"
 	"github.com/rclone/rclone/vfs/vfscommon"
@@ -86,6 +87,9 @@ func (d *Dir) IsDir() bool {
 
 // Mode bits of the directory - satisfies Node interface
 func (d *Dir) Mode() (mode os.FileMode) {
+	if d.vfs.meta != nil {
+		return os.ModeDir | d.vfs.perms(d.Path(), d.vfs.Opt.DirPerms)
+	}
 	return d.vfs.Opt.DirPerms
 }
 
@@ -536,11 +540,31 @@ func (mv manageVirtuals) end(d *Dir) {
 func (d *Dir) _readDirFromEntries(entries fs.DirEntries, dirTree dirtree.DirTree, when time.Time) error {
 	var err error
 	mv := d._newManageVirtuals()
+	// With --vfs-links a link "foo.rclonelink" would be shown as
+	// "foo", so if there is a real "foo" as well then the link is
+	// shown as a file with its full name instead.
+	var names map[string]struct{}
+	if d.vfs.Opt.Links {
+		names = make(map[string]struct{}, len(entries))
+		for _, entry := range entries {
+			names[path.Base(entry.Remote())] = struct{}{}
+		}
+	}
 	for _, entry := range entries {
 		name := path.Base(entry.Remote())
-		if name == "." || name == ".." {
+		if name == "." || name == ".." || d.isLockDir(name) {
 			continue
 		}
+		isLink := false
+		if _, ok := entry.(fs.Object); ok && d.vfs.Opt.Links {
+			if linkName, ok := trimLinkSuffix(name); ok {
+				if _, found := names[linkName]; found {
+					fs.Debugf(d, "Showing %q as a file as %q exists", name, linkName)
+				} else {
+					name, isLink = linkName, true
+				}
+			}
+		}
 		node := d.items[name]
 		if mv.add(d, name) {
 			continue
@@ -549,10 +573,12 @@ func (d *Dir) _readDirFromEntries(entries fs.DirEntries, dirTree dirtree.DirTree
 		case fs.Object:
 			obj := item
 			// Reuse old file value if it exists
-			if file, ok := node.(*File); node != nil && ok {
+			if file, ok := node.(*File); node != nil && ok && file.isLink == isLink {
 				file.setObjectNoUpdate(obj)
 			} else {
-				node = newFile(d, d.path, obj, name)
+				file := newFile(d, d.path, obj, name)
+				file.isLink = isLink
+				node = file
 			}
 		case fs.Directory:
 			// Reuse old dir value if it exists
@@ -584,6 +610,15 @@ func (d *Dir) _readDirFromEntries(entries fs.DirEntries, dirTree dirtree.DirTree
 	return nil
 }
 
+// trimLinkSuffix removes the link suffix from name returning true if
+// it was found.
+func trimLinkSuffix(name string) (string, bool) {
+	if len(name) > len(vfscommon.LinkSuffix) && strings.HasSuffix(name, vfscommon.LinkSuffix) {
+		return name[:len(name)-len(vfscommon.LinkSuffix)], true
+	}
+	return name, false
+}
+
 // readDirTree forces a refresh of the complete directory tree
 func (d *Dir) readDirTree() error {
 	d.mu.RLock()
@@ -773,6 +808,35 @@ func (d *Dir) Create(name string, flags int) (*File, error) {
 	return newFile(d, d.Path(), nil, name), nil
 }
 
+// Symlink makes a new symlink called name pointing to target
+//
+// The symlink is stored on the remote as a file with the link suffix
+// containing the target so this needs --vfs-links to be set.
+func (d *Dir) Symlink(target, name string) (*File, error) {
+	if d.vfs.Opt.ReadOnly {
+		return nil, EROFS
+	}
+	if !d.vfs.Opt.Links {
+		return nil, ENOSYS
+	}
+	if _, err := d.stat(name); err == nil {
+		return nil, EEXIST
+	} else if err != ENOENT {
+		return nil, err
+	}
+	remote := path.Join(d.Path(), name) + vfscommon.LinkSuffix
+	src := object.NewStaticObjectInfo(remote, time.Now(), int64(len(target)), true, nil, d.f)
+	o, err := d.f.Put(context.TODO(), strings.NewReader(target), src)
+	if err != nil {
+		fs.Errorf(d, "Dir.Symlink error: %v", err)
+		return nil, err
+	}
+	file := newFile(d, d.Path(), o, name)
+	file.isLink = true
+	d.addObject(file)
+	return file, nil
+}
+
 // Mkdir creates a new directory
 func (d *Dir) Mkdir(name string) (*Dir, error) {
 	if d.vfs.Opt.ReadOnly {
@@ -832,6 +896,7 @@ func (d *Dir) Remove() error {
 	if d.parent != nil {
 		d.parent.delObject(d.Name())
 	}
+	d.vfs.deleteMetadata(d.Path())
 	return nil
 }
 
@@ -943,6 +1008,7 @@ func (d *Dir) Rename(oldName, newName string, destDir *Dir) error {
 	// Show moved - delete from old dir and add to new
 	d.delObject(oldName)
 	destDir.addObject(oldNode)
+	d.vfs.renameMetadata(oldPath, newPath)
 
 	// fs.Debugf(newPath, "Dir.Rename renamed from %q", oldPath)
 	return nil
@@ -2,6 +2,7 @@ package vfs
 
 import (
 	"context"
+	"io/ioutil"
 	"os"
 	"path"
 	"sync"
@@ -35,8 +36,9 @@ import (
 
 // File represents a file
 type File struct {
-	inode uint64 // inode number - read only
-	size  int64  // size of file - read and written with atomic int64 - must be 64 bit aligned
+	inode  uint64 // inode number - read only
+	size   int64  // size of file - read and written with atomic int64 - must be 64 bit aligned
+	isLink bool   // file is a symlink stored as a .rclonelink file - read only
 
 	mu               sync.RWMutex                    // protects the following
 	d                *Dir                            // parent directory
@@ -93,6 +95,12 @@ func (f *File) Mode() (mode os.FileMode) {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 	mode = f.d.vfs.Opt.FilePerms
+	if f.d.vfs.meta != nil {
+		mode = f.d.vfs.perms(f._path(), mode)
+	}
+	if f.isLink {
+		mode |= os.ModeSymlink
+	}
 	if f.appendMode {
 		mode |= os.ModeAppend
 	}
@@ -120,6 +128,44 @@ func (f *File) Path() string {
 	return path.Join(dPath, leaf)
 }
 
+// _remote returns the path of the object on the remote for a file
+// with path p - this has the link suffix added if the file is a
+// symlink
+func (f *File) _remote(p string) string {
+	if f.isLink {
+		return p + vfscommon.LinkSuffix
+	}
+	return p
+}
+
+// IsSymlink returns true if the file is a symlink
+func (f *File) IsSymlink() bool {
+	return f.isLink
+}
+
+// Readlink returns the target of the symlink
+//
+// It returns EINVAL if the file isn't a symlink.
+func (f *File) Readlink() (target string, err error) {
+	if !f.isLink {
+		return "", EINVAL
+	}
+	o, err := f.waitForValidObject()
+	if err != nil {
+		return "", err
+	}
+	in, err := o.Open(context.TODO())
+	if err != nil {
+		return "", err
+	}
+	defer fs.CheckClose(in, &err)
+	buf, err := ioutil.ReadAll(in)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
 // Sys returns underlying data source (can be nil) - satisfies Node interface
 func (f *File) Sys() interface{} {
 	return f.sys.Load()
@@ -174,6 +220,7 @@ func (f *File) rename(ctx context.Context, destDir *Dir, newName string) error {
 	oldPath := f.Path()
 	// File.mu is unlocked here to call Dir.Path()
 	newPath := path.Join(destDir.Path(), newName)
+	newRemote := f._remote(newPath)
 
 	renameCall := func(ctx context.Context) (err error) {
 		// chain rename calls if any
@@ -191,13 +238,13 @@ func (f *File) rename(ctx context.Context, destDir *Dir, newName string) error {
 		var newObject fs.Object
 		// if o is nil then are writing the file so no need to rename the object
 		if o != nil {
-			if o.Remote() == newPath {
+			if o.Remote() == newRemote {
 				return nil // no need to rename
 			}
 
 			// do the move of the remote object
-			dstOverwritten, _ := d.Fs().NewObject(ctx, newPath)
-			newObject, err = operations.Move(ctx, d.Fs(), dstOverwritten, newPath, o)
+			dstOverwritten, _ := d.Fs().NewObject(ctx, newRemote)
+			newObject, err = operations.Move(ctx, d.Fs(), dstOverwritten, newRemote, o)
 			if err != nil {
 				fs.Errorf(f.Path(), "File.Rename error: %v", err)
 				return err
@@ -572,6 +619,9 @@ func (f *File) Remove() (err error) {
 			fs.Debugf(f._path(), "File.Remove file error: %v", err)
 		}
 	}
+	if err == nil {
+		d.vfs.deleteMetadata(f.Path())
+	}
 	return err
 }
 
@@ -650,6 +700,12 @@ func (f *File) Open(flags int) (fd Handle, err error) {
 		return nil, EPERM
 	}
 
+	// Symlinks can only be read - use Symlink to change them
+	if f.isLink && (write || flags&(os.O_APPEND|os.O_TRUNC) != 0) {
+		fs.Debugf(f.Path(), "Can't open symlink for write")
+		return nil, EPERM
+	}
+
 	// If append is set then set read to force openRW
 	if flags&os.O_APPEND != 0 {
 		read = true
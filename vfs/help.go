@@ -83,6 +83,8 @@ find that you need one or the other or both.
     --vfs-cache-max-size SizeSuffix      Max total size of objects in the cache. (default off)
     --vfs-cache-poll-interval duration   Interval to poll the cache for stale objects. (default 1m0s)
     --vfs-write-back duration            Time to writeback files after last use when using cache. (default 5s)
+    --vfs-cache-password string          Password to encrypt the cache with (obscured with rclone obscure).
+    --vfs-cache-key-file string          File containing the key to encrypt the cache with.
 
 If run with ` + "`-vv`" + ` rclone will print the location of the file cache.  The
 files are stored in the user cache file area which is OS dependent but
@@ -104,6 +106,28 @@ for two reasons.  Firstly because it is only checked every
 --vfs-cache-poll-interval.  Secondly because open files cannot be
 evicted from the cache.
 
+#### Encrypting the cache
+
+The cache stores the plaintext of the files it caches, even if the
+remote is a crypt remote. To encrypt the data and metadata files in
+the cache set --vfs-cache-password to a password obscured with
+` + "`rclone obscure`" + ` or --vfs-cache-key-file to a file containing a secret
+key. The key for each cache is derived from the secret and the
+location of the cache.
+
+The file data is encrypted and authenticated in 64k blocks with
+AES-256-GCM, using a new random nonce each time a block is written, so
+any part of a file can be read or rewritten and sparse files still work
+with --vfs-cache-mode full. The metadata is encrypted and authenticated
+with AES-256-GCM too, and records which blocks have been written so
+every one of those is authenticated when read. Note that the names of
+the files in the cache are not encrypted.
+
+A record made with the key is stored with the cache, so if the key is
+changed or is wrong rclone refuses to start rather than discarding the
+files in the cache, which might not have been uploaded yet. Use the old
+key, or remove the cache directory if its contents aren't needed.
+
 #### --vfs-cache-mode off
 
 In this mode (the default) the cache will read directly from the remote and write
@@ -171,6 +195,13 @@ FAT/exFAT do not. Rclone will perform very badly if the cache
 directory is on a filesystem which doesn't support sparse files and it
 will log an ERROR message if one is detected.
 
+In this mode files can be downloaded into the cache in advance with
+the "vfs/prefetch" remote control command. Files can also be pinned
+in the cache with "vfs/pin" which downloads them and exempts them
+from --vfs-cache-max-age and --vfs-cache-max-size, so they stay
+available when the remote can't be reached. Use "vfs/unpin" to
+return them to the normal expiry rules.
+
 ### VFS Performance
 
 These flags may be used to enable/disable features of the VFS for
@@ -212,6 +243,72 @@ modified files from cache (the related global flag --checkers have no effect on
 
     --transfers int  Number of file transfers to run in parallel. (default 4)
 
+### VFS Symlinks
+
+Rclone can't store symlinks on most remotes, but the local backend
+with --links and the sftp backend with "links = true" translate
+symlinks into regular files with a ".rclonelink" extension which
+contain the target of the link.
+
+If the --vfs-links flag is set then these files are shown as
+symlinks (without the ".rclonelink" extension) and creating a symlink
+makes a ".rclonelink" file on the remote. This means that copying a
+tree with symlinks in through the mount and out again preserves them.
+This works with mount and cmount and with serve sftp. Note that serve
+sftp stores link targets as absolute paths from the root it serves.
+
+Symlinks can be read, renamed and removed but they can't be opened
+for writing - remove them and make a new symlink instead.
+
+If a directory contains both "foo" and "foo.rclonelink" then "foo" is
+shown as it is and "foo.rclonelink" is shown as a regular file with
+its extension rather than as a symlink.
+
+### VFS Permissions and Extended Attributes
+
+Most remotes can't store permissions, ownership or extended
+attributes so by default all files and directories are shown with
+the permissions from --file-perms and --dir-perms (masked with
+--umask), and owned by --uid and --gid. Changing them with chmod or
+chown succeeds but does nothing, and extended attributes aren't
+supported.
+
+If the --vfs-metadata-store flag is set then rclone stores any
+permissions files and directories are created with, and any
+permissions, ownership and extended attributes set on them later, in
+a local database in the cache directory (one per
+remote) and shows them instead of the defaults. The stored values
+follow files and directories when they are renamed through rclone and
+are removed when they are deleted. This is useful for build tools and
+git repositories which rely on the executable bit being preserved.
+
+Note that changes made to the remote by other means won't update the
+database and only one rclone process can use it at once.
+
+### VFS File Locking
+
+Advisory locks taken on files, with flock or fcntl through
+mount and mount2 or with LOCK through serve webdav, are kept by
+rclone so all the users of the same VFS in one rclone process see
+each other's locks. Byte range locks are supported and whole file
+locks (flock and WebDAV locks) lock the whole file. Note that cmount
+doesn't support locking, so the kernel only enforces locks between
+the processes using the mount.
+
+To share the locks with other rclone processes, possibly on other
+hosts, use the --vfs-lock-remote flag. This writes a small lock file
+for each locked file into a ".rclonelocks" directory at the root of
+the remote (which is hidden from the VFS) and reads the lock files of
+the other processes before taking a lock. The lock files are
+refreshed while the locks are held. If an rclone process dies, its
+lock files are ignored once they are older than --vfs-lock-expiry.
+
+The locks are advisory: they only stop programs which take locks
+themselves and they don't stop changes made to the remote by other
+means. Taking a lock with --vfs-lock-remote is not atomic on most
+remotes, so if two processes try to lock the same file at exactly
+the same time both may fail.
+
 ### VFS Case Sensitivity
 
 Linux file systems are case-sensitive: two files can differ only
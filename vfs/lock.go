@@ -0,0 +1,34 @@
+package vfs
+
+import (
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs/vfslock"
+)
+
+// newLockManager makes the manager for the advisory locks on the
+// files in the VFS
+func (vfs *VFS) newLockManager() {
+	var f fs.Fs
+	if vfs.Opt.LockRemote {
+		f = vfs.f
+	}
+	vfs.locks = vfslock.New(f, vfs.Opt.LockExpiry)
+}
+
+// Locks returns the manager for the advisory locks on the files in
+// the VFS.
+//
+// These are used by the mounts for flock and fcntl locks and by serve
+// webdav for its locks so they all see each other's locks. If
+// --vfs-lock-remote is set then they are also written to the remote
+// for other rclone processes to see.
+func (vfs *VFS) Locks() *vfslock.Manager {
+	return vfs.locks
+}
+
+// isLockDir returns true if name in d is the directory the lock files
+// are kept in, which is hidden if locks are being written to the
+// remote.
+func (d *Dir) isLockDir(name string) bool {
+	return d.path == "" && name == vfslock.Dir && d.vfs.locks.Remote()
+}
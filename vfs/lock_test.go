@@ -0,0 +1,40 @@
+package vfs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/rclone/rclone/vfs/vfslock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVFSLockRemote(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.LockRemote = true
+	r, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+
+	ctx := context.Background()
+	r.WriteObject(ctx, "file", "hello", t1)
+	assert.True(t, vfs.Locks().Remote())
+	require.NoError(t, vfs.Locks().Lock(ctx, vfslock.Lock{Path: "file", Owner: "test", Type: vfslock.Write, End: vfslock.EOF}))
+
+	// the lock directory exists on the remote
+	entries, err := r.Fremote.List(ctx, vfslock.Dir+"/file")
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(entries))
+
+	// but isn't shown in the VFS
+	vfs.FlushDirCache()
+	nodes, err := vfs.ReadDir("")
+	require.NoError(t, err)
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Name())
+	}
+	assert.Equal(t, []string{"file"}, names)
+
+	require.NoError(t, vfs.Locks().UnlockAll(ctx, "file", "test"))
+}
@@ -0,0 +1,335 @@
+package vfs
+
+import (
+	"os"
+	"sort"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs/vfsmeta"
+)
+
+// Flags for Setxattr - these are the same as setxattr(2) on Linux
+const (
+	XattrCreate  = 1 // fail with EEXIST if the attribute exists
+	XattrReplace = 2 // fail with ENOATTR if the attribute doesn't exist
+)
+
+// openMetadataStore opens the database to store the metadata the
+// remote can't, disabling the store if it fails
+func (vfs *VFS) openMetadataStore() {
+	dbPath, err := vfsmeta.DBPath(vfs.f)
+	if err == nil {
+		vfs.meta, err = vfsmeta.Open(dbPath)
+	}
+	if err != nil {
+		fs.Errorf(nil, "Failed to open vfs metadata store - disabling: %v", err)
+		vfs.Opt.MetadataStore = false
+		vfs.meta = nil
+	}
+}
+
+// closeMetadataStore closes the metadata database if open
+func (vfs *VFS) closeMetadataStore() {
+	if vfs.meta == nil {
+		return
+	}
+	err := vfs.meta.Close()
+	if err != nil {
+		fs.Errorf(nil, "Failed to close vfs metadata store: %v", err)
+	}
+	vfs.meta = nil
+}
+
+// metadata returns the metadata stored for the node at p or nil if
+// there isn't any
+func (vfs *VFS) metadata(p string) *vfsmeta.Metadata {
+	if vfs.meta == nil {
+		return nil
+	}
+	m, err := vfs.meta.Get(p)
+	if err != nil {
+		fs.Errorf(p, "vfs metadata: %v", err)
+		return nil
+	}
+	return m
+}
+
+// renameMetadata moves the metadata for oldPath and anything below it
+// to newPath
+func (vfs *VFS) renameMetadata(oldPath, newPath string) {
+	if vfs.meta == nil {
+		return
+	}
+	err := vfs.meta.Rename(oldPath, newPath)
+	if err != nil {
+		fs.Errorf(oldPath, "vfs metadata: failed to rename to %q: %v", newPath, err)
+	}
+}
+
+// deleteMetadata removes the metadata for p and anything below it
+func (vfs *VFS) deleteMetadata(p string) {
+	if vfs.meta == nil {
+		return
+	}
+	err := vfs.meta.Delete(p)
+	if err != nil {
+		fs.Errorf(p, "vfs metadata: failed to delete: %v", err)
+	}
+}
+
+// perms returns the permission bits for the node at p defaulting to
+// defaultPerms
+func (vfs *VFS) perms(p string, defaultPerms os.FileMode) os.FileMode {
+	if m := vfs.metadata(p); m != nil && m.Mode != nil {
+		return os.FileMode(*m.Mode).Perm()
+	}
+	return defaultPerms.Perm()
+}
+
+// owner returns the uid and gid for the node at p
+func (vfs *VFS) owner(p string) (uid, gid uint32) {
+	uid, gid = vfs.Opt.UID, vfs.Opt.GID
+	if m := vfs.metadata(p); m != nil {
+		if m.UID != nil {
+			uid = *m.UID
+		}
+		if m.GID != nil {
+			gid = *m.GID
+		}
+	}
+	return uid, gid
+}
+
+// chmod sets the permission bits for the node at p
+//
+// This is a no-op if the metadata store isn't in use.
+func (vfs *VFS) chmod(p string, mode os.FileMode) error {
+	if vfs.meta == nil {
+		return nil
+	}
+	if vfs.Opt.ReadOnly {
+		return EROFS
+	}
+	perm := uint32(mode.Perm())
+	return vfs.meta.Update(p, func(m *vfsmeta.Metadata) error {
+		m.Mode = &perm
+		return nil
+	})
+}
+
+// SaveCreateMode stores the permission bits of mode for node, which
+// has just been created, if --vfs-metadata-store is set.
+//
+// Errors are logged rather than returned as the node has been created.
+func SaveCreateMode(node Node, mode os.FileMode) {
+	if node.VFS().meta == nil {
+		return
+	}
+	err := node.Chmod(mode)
+	if err != nil {
+		fs.Errorf(node.Path(), "vfs metadata: failed to save mode: %v", err)
+	}
+}
+
+// chown sets the owner of the node at p - a uid or gid of -1 means
+// leave it unchanged
+//
+// This is a no-op if the metadata store isn't in use.
+func (vfs *VFS) chown(p string, uid, gid int) error {
+	if vfs.meta == nil {
+		return nil
+	}
+	if vfs.Opt.ReadOnly {
+		return EROFS
+	}
+	return vfs.meta.Update(p, func(m *vfsmeta.Metadata) error {
+		if uid >= 0 {
+			id := uint32(uid)
+			m.UID = &id
+		}
+		if gid >= 0 {
+			id := uint32(gid)
+			m.GID = &id
+		}
+		return nil
+	})
+}
+
+// getxattr reads the extended attribute name for the node at p
+func (vfs *VFS) getxattr(p string, name string) ([]byte, error) {
+	if vfs.meta == nil {
+		return nil, ENOSYS
+	}
+	m := vfs.metadata(p)
+	if m == nil {
+		return nil, ENOATTR
+	}
+	value, ok := m.Xattrs[name]
+	if !ok {
+		return nil, ENOATTR
+	}
+	return value, nil
+}
+
+// setxattr sets the extended attribute name for the node at p
+//
+// flags may be XattrCreate or XattrReplace.
+func (vfs *VFS) setxattr(p string, name string, value []byte, flags int) error {
+	if vfs.Opt.ReadOnly {
+		return EROFS
+	}
+	if vfs.meta == nil {
+		return ENOSYS
+	}
+	if name == "" {
+		return EINVAL
+	}
+	return vfs.meta.Update(p, func(m *vfsmeta.Metadata) error {
+		_, exists := m.Xattrs[name]
+		if flags&XattrCreate != 0 && exists {
+			return EEXIST
+		}
+		if flags&XattrReplace != 0 && !exists {
+			return ENOATTR
+		}
+		if m.Xattrs == nil {
+			m.Xattrs = make(map[string][]byte, 1)
+		}
+		m.Xattrs[name] = append([]byte(nil), value...)
+		return nil
+	})
+}
+
+// listxattr returns the sorted names of the extended attributes for
+// the node at p
+func (vfs *VFS) listxattr(p string) ([]string, error) {
+	if vfs.meta == nil {
+		return nil, ENOSYS
+	}
+	var names []string
+	if m := vfs.metadata(p); m != nil {
+		for name := range m.Xattrs {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// removexattr removes the extended attribute name for the node at p
+func (vfs *VFS) removexattr(p string, name string) error {
+	if vfs.Opt.ReadOnly {
+		return EROFS
+	}
+	if vfs.meta == nil {
+		return ENOSYS
+	}
+	return vfs.meta.Update(p, func(m *vfsmeta.Metadata) error {
+		if _, exists := m.Xattrs[name]; !exists {
+			return ENOATTR
+		}
+		delete(m.Xattrs, name)
+		return nil
+	})
+}
+
+// Owner returns the uid and gid of the file
+func (f *File) Owner() (uid, gid uint32) {
+	return f.VFS().owner(f.Path())
+}
+
+// Chmod changes the permission bits of the file
+//
+// These are only stored if --vfs-metadata-store is set, otherwise
+// this does nothing.
+func (f *File) Chmod(mode os.FileMode) error {
+	return f.VFS().chmod(f.Path(), mode)
+}
+
+// Chown changes the owner of the file - -1 means leave unchanged
+//
+// This is only stored if --vfs-metadata-store is set, otherwise this
+// does nothing.
+func (f *File) Chown(uid, gid int) error {
+	return f.VFS().chown(f.Path(), uid, gid)
+}
+
+// Getxattr returns the extended attribute called name
+func (f *File) Getxattr(name string) ([]byte, error) {
+	return f.VFS().getxattr(f.Path(), name)
+}
+
+// Setxattr sets the extended attribute called name
+func (f *File) Setxattr(name string, value []byte, flags int) error {
+	return f.VFS().setxattr(f.Path(), name, value, flags)
+}
+
+// Listxattr lists the names of the extended attributes
+func (f *File) Listxattr() ([]string, error) {
+	return f.VFS().listxattr(f.Path())
+}
+
+// Removexattr removes the extended attribute called name
+func (f *File) Removexattr(name string) error {
+	return f.VFS().removexattr(f.Path(), name)
+}
+
+// Owner returns the uid and gid of the directory
+func (d *Dir) Owner() (uid, gid uint32) {
+	return d.vfs.owner(d.Path())
+}
+
+// Chmod changes the permission bits of the directory
+//
+// These are only stored if --vfs-metadata-store is set, otherwise
+// this does nothing.
+func (d *Dir) Chmod(mode os.FileMode) error {
+	return d.vfs.chmod(d.Path(), mode)
+}
+
+// Chown changes the owner of the directory - -1 means leave unchanged
+//
+// This is only stored if --vfs-metadata-store is set, otherwise this
+// does nothing.
+func (d *Dir) Chown(uid, gid int) error {
+	return d.vfs.chown(d.Path(), uid, gid)
+}
+
+// Getxattr returns the extended attribute called name
+func (d *Dir) Getxattr(name string) ([]byte, error) {
+	return d.vfs.getxattr(d.Path(), name)
+}
+
+// Setxattr sets the extended attribute called name
+func (d *Dir) Setxattr(name string, value []byte, flags int) error {
+	return d.vfs.setxattr(d.Path(), name, value, flags)
+}
+
+// Listxattr lists the names of the extended attributes
+func (d *Dir) Listxattr() ([]string, error) {
+	return d.vfs.listxattr(d.Path())
+}
+
+// Removexattr removes the extended attribute called name
+func (d *Dir) Removexattr(name string) error {
+	return d.vfs.removexattr(d.Path(), name)
+}
+
+// Chmod changes the permission bits of the named file or directory
+func (vfs *VFS) Chmod(name string, mode os.FileMode) error {
+	node, err := vfs.Stat(name)
+	if err != nil {
+		return err
+	}
+	return node.Chmod(mode)
+}
+
+// Chown changes the owner of the named file or directory - -1 means
+// leave unchanged
+func (vfs *VFS) Chown(name string, uid, gid int) error {
+	node, err := vfs.Stat(name)
+	if err != nil {
+		return err
+	}
+	return node.Chown(uid, gid)
+}
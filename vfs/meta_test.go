@@ -0,0 +1,157 @@
+package vfs
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestVFSMeta(t *testing.T) (r *fstest.Run, vfs *VFS, cleanup func()) {
+	oldCacheDir := config.CacheDir
+	dir, err := ioutil.TempDir("", "rclone-vfs-meta-test")
+	require.NoError(t, err)
+	config.CacheDir = dir
+
+	opt := vfscommon.DefaultOpt
+	opt.MetadataStore = true
+	opt.UID = 100
+	opt.GID = 200
+	r, vfs, cleanupVFS := newTestVFSOpt(t, &opt)
+	require.NotNil(t, vfs.meta)
+	return r, vfs, func() {
+		cleanupVFS()
+		config.CacheDir = oldCacheDir
+		require.NoError(t, os.RemoveAll(dir))
+	}
+}
+
+func TestVFSMetadataChmodChown(t *testing.T) {
+	r, vfs, cleanup := newTestVFSMeta(t)
+	defer cleanup()
+
+	r.WriteObject(context.Background(), "dir/file", "hello", t1)
+
+	node, err := vfs.Stat("dir/file")
+	require.NoError(t, err)
+	assert.Equal(t, vfs.Opt.FilePerms, node.Mode())
+	uid, gid := node.Owner()
+	assert.Equal(t, uint32(100), uid)
+	assert.Equal(t, uint32(200), gid)
+
+	require.NoError(t, vfs.Chmod("dir/file", 0750))
+	require.NoError(t, vfs.Chown("dir/file", 1000, -1))
+	require.NoError(t, vfs.Chmod("dir", 0700))
+	assert.Equal(t, os.FileMode(0750), node.Mode())
+	uid, gid = node.Owner()
+	assert.Equal(t, uint32(1000), uid)
+	assert.Equal(t, uint32(200), gid)
+
+	dir, err := vfs.Stat("dir")
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeDir|0700, dir.Mode())
+
+	// Renames carry the metadata along
+	require.NoError(t, vfs.Rename("dir", "dir2"))
+	node, err = vfs.Stat("dir2/file")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), node.Mode())
+	dir, err = vfs.Stat("dir2")
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeDir|0700, dir.Mode())
+
+	require.NoError(t, vfs.Rename("dir2/file", "file2"))
+	node, err = vfs.Stat("file2")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), node.Mode())
+
+	// Removing deletes the metadata
+	require.NoError(t, vfs.Remove("file2"))
+	r.WriteObject(context.Background(), "file2", "hello", t1)
+	vfs.FlushDirCache()
+	node, err = vfs.Stat("file2")
+	require.NoError(t, err)
+	assert.Equal(t, vfs.Opt.FilePerms, node.Mode())
+	uid, _ = node.Owner()
+	assert.Equal(t, uint32(100), uid)
+}
+
+func TestVFSMetadataXattr(t *testing.T) {
+	r, vfs, cleanup := newTestVFSMeta(t)
+	defer cleanup()
+
+	r.WriteObject(context.Background(), "file", "hello", t1)
+	node, err := vfs.Stat("file")
+	require.NoError(t, err)
+
+	_, err = node.Getxattr("user.potato")
+	assert.Equal(t, ENOATTR, err)
+	names, err := node.Listxattr()
+	require.NoError(t, err)
+	assert.Equal(t, []string(nil), names)
+
+	require.NoError(t, node.Setxattr("user.potato", []byte("jersey"), 0))
+	require.NoError(t, node.Setxattr("user.apple", []byte("cox"), XattrCreate))
+	assert.Equal(t, EEXIST, node.Setxattr("user.apple", []byte("cox"), XattrCreate))
+	assert.Equal(t, ENOATTR, node.Setxattr("user.pear", []byte("conference"), XattrReplace))
+	require.NoError(t, node.Setxattr("user.apple", []byte("braeburn"), XattrReplace))
+
+	value, err := node.Getxattr("user.potato")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("jersey"), value)
+	value, err = node.Getxattr("user.apple")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("braeburn"), value)
+	names, err = node.Listxattr()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user.apple", "user.potato"}, names)
+
+	require.NoError(t, node.Removexattr("user.potato"))
+	assert.Equal(t, ENOATTR, node.Removexattr("user.potato"))
+	names, err = node.Listxattr()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user.apple"}, names)
+}
+
+func TestVFSMetadataDisabled(t *testing.T) {
+	r, vfs, cleanup := newTestVFS(t)
+	defer cleanup()
+
+	r.WriteObject(context.Background(), "file", "hello", t1)
+	node, err := vfs.Stat("file")
+	require.NoError(t, err)
+
+	// chmod and chown are silently ignored
+	require.NoError(t, node.Chmod(0700))
+	require.NoError(t, node.Chown(1, 2))
+	assert.Equal(t, vfs.Opt.FilePerms, node.Mode())
+
+	// xattrs aren't supported
+	_, err = node.Getxattr("user.potato")
+	assert.Equal(t, ENOSYS, err)
+	assert.Equal(t, ENOSYS, node.Setxattr("user.potato", nil, 0))
+}
+
+func TestVFSMetadataReadOnly(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.ReadOnly = true
+	r, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+
+	r.WriteObject(context.Background(), "file", "hello", t1)
+	node, err := vfs.Stat("file")
+	require.NoError(t, err)
+
+	// without the metadata store chmod and chown are still ignored
+	require.NoError(t, node.Chmod(0700))
+	require.NoError(t, node.Chown(1, 2))
+	require.NoError(t, vfs.Chmod("file", 0700))
+	require.NoError(t, vfs.Chown("file", 1, 2))
+	assert.Equal(t, vfs.Opt.FilePerms, node.Mode())
+}
@@ -0,0 +1,107 @@
+package vfs
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metricsNamespace = "rclone_vfs_"
+
+// vfsCollector is a Prometheus collector for the active VFSes
+//
+// Each metric is labelled with the name of the VFS as used in the
+// "fs" parameter of the vfs/* remote control commands.
+type vfsCollector struct {
+	metrics []vfsMetric
+}
+
+// vfsMetric describes a single metric read from the disk cache stats
+type vfsMetric struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	key       string // key into the diskCache stats
+}
+
+// newVFSCollector makes a new vfsCollector
+func newVFSCollector() *vfsCollector {
+	newMetric := func(name, help string, valueType prometheus.ValueType, key string) vfsMetric {
+		return vfsMetric{
+			desc:      prometheus.NewDesc(metricsNamespace+name, help, []string{"fs"}, nil),
+			valueType: valueType,
+			key:       key,
+		}
+	}
+	return &vfsCollector{
+		metrics: []vfsMetric{
+			newMetric("cache_bytes_used", "Total size of the files in the VFS disk cache", prometheus.GaugeValue, "bytesUsed"),
+			newMetric("cache_files", "Number of files in the VFS disk cache", prometheus.GaugeValue, "files"),
+			newMetric("cache_open_files", "Number of files in the VFS disk cache which are open", prometheus.GaugeValue, "openFiles"),
+			newMetric("cache_dirty_files", "Number of files in the VFS disk cache waiting to be uploaded", prometheus.GaugeValue, "dirtyFiles"),
+			newMetric("cache_dirty_bytes", "Total size of the files in the VFS disk cache waiting to be uploaded", prometheus.GaugeValue, "dirtyBytes"),
+			newMetric("cache_pinned_files", "Number of files pinned in the VFS disk cache", prometheus.GaugeValue, "pinnedFiles"),
+			newMetric("cache_errored_files", "Number of files in the VFS disk cache in an error state", prometheus.GaugeValue, "erroredFiles"),
+			newMetric("cache_hits_total", "Number of reads satisfied from the VFS disk cache", prometheus.CounterValue, "hits"),
+			newMetric("cache_misses_total", "Number of reads which needed data from the remote", prometheus.CounterValue, "misses"),
+			newMetric("uploads_in_progress", "Number of uploads from the VFS disk cache in progress", prometheus.GaugeValue, "uploadsInProgress"),
+			newMetric("uploads_queued", "Number of uploads from the VFS disk cache waiting to start", prometheus.GaugeValue, "uploadsQueued"),
+		},
+	}
+}
+
+// Describe is part of the Collector interface: https://godoc.org/github.com/prometheus/client_golang/prometheus#Collector
+func (c *vfsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, metric := range c.metrics {
+		ch <- metric.desc
+	}
+}
+
+// Collect is part of the Collector interface: https://godoc.org/github.com/prometheus/client_golang/prometheus#Collector
+func (c *vfsCollector) Collect(ch chan<- prometheus.Metric) {
+	activeMu.Lock()
+	var vfses = map[string]*VFS{}
+	for name, activeVFS := range active {
+		if len(activeVFS) == 1 {
+			vfses[name] = activeVFS[0]
+		} else {
+			for i, vfs := range activeVFS {
+				vfses[fmt.Sprintf("%s[%d]", name, i)] = vfs
+			}
+		}
+	}
+	activeMu.Unlock()
+
+	for name, vfs := range vfses {
+		if vfs.cache == nil {
+			continue
+		}
+		stats := vfs.cache.Stats()
+		for _, metric := range c.metrics {
+			value, ok := toFloat(stats[metric.key])
+			if !ok {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(metric.desc, metric.valueType, value, name)
+		}
+	}
+}
+
+// toFloat converts the numeric values found in rc.Params to float64
+func toFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+// check interface
+var _ prometheus.Collector = (*vfsCollector)(nil)
+
+func init() {
+	prometheus.MustRegister(newVFSCollector())
+}
@@ -0,0 +1,27 @@
+package vfs
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	c := newVFSCollector()
+
+	r, vfs, cleanup := newTestVFS(t)
+	defer cleanup()
+	_ = vfs
+
+	// No metrics without a disk cache
+	assert.Equal(t, 0, testutil.CollectAndCount(c))
+
+	opt := vfscommon.DefaultOpt
+	opt.CacheMode = vfscommon.CacheModeFull
+	vfs2 := New(r.Fremote, &opt)
+	defer vfs2.Shutdown()
+
+	assert.Equal(t, len(c.metrics), testutil.CollectAndCount(c))
+}
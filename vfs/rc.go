@@ -10,7 +10,10 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/cache"
+	"github.com/rclone/rclone/fs/filter"
 	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/vfs/vfscache/writeback"
+	"github.com/rclone/rclone/vfs/vfscommon"
 )
 
 const getVFSHelp = ` 
@@ -389,3 +392,420 @@ func rcList(ctx context.Context, in rc.Params) (out rc.Params, err error) {
 	out["vfses"] = names
 	return out, nil
 }
+
+const pinFilesHelp = `
+Pass files in as file=path and directories as dir=path. Any parameter
+key starting with file will select that file and any starting with dir
+will select all the files in that directory and its subdirectories,
+e.g.
+
+    rclone rc %s file=hello.txt dir=projects/offline
+
+The files selected from directories can be narrowed down with
+include=glob and exclude=glob using the same syntax as --include and
+--exclude. These are added to any filters set on the command line.
+
+    rclone rc %s dir=projects include=*.doc
+`
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "vfs/pin",
+		Fn:    rcPin,
+		Title: "Pin files in the VFS cache.",
+		Help: `
+This marks files as pinned in the VFS cache and starts downloading
+them into the cache in the background. Pinned files are never removed
+from the cache by --vfs-cache-max-age or --vfs-cache-max-size so they
+remain available if the remote can't be reached.
+
+This needs --vfs-cache-mode full.
+` + fmt.Sprintf(pinFilesHelp, "vfs/pin", "vfs/pin") + `
+It returns a list of the pinned files under the key "pinned".
+
+If no files or directories are passed in then it lists the state of
+all the pinned files under the key "pins" instead. Each entry has the
+"name" and "size" of the file, the number of bytes "cached" and
+whether the file is "complete" in the cache.
+
+    rclone rc vfs/pin
+` + getVFSHelp,
+	})
+	rc.Add(rc.Call{
+		Path:  "vfs/unpin",
+		Fn:    rcUnpin,
+		Title: "Unpin files in the VFS cache.",
+		Help: `
+This removes the pin from files pinned with vfs/pin so they become
+subject to the normal cache expiry rules again.
+` + fmt.Sprintf(pinFilesHelp, "vfs/unpin", "vfs/unpin") + `
+It returns a list of the unpinned files under the key "unpinned".
+` + getVFSHelp,
+	})
+	rc.Add(rc.Call{
+		Path:  "vfs/prefetch",
+		Fn:    rcPrefetch,
+		Title: "Download files into the VFS cache.",
+		Help: `
+This starts downloading files into the VFS cache in the background
+without pinning them, so they may be removed by the cache cleaner in
+the usual way.
+
+This needs --vfs-cache-mode full.
+` + fmt.Sprintf(pinFilesHelp, "vfs/prefetch", "vfs/prefetch") + `
+It returns a list of the files being downloaded under the key
+"prefetching".
+` + getVFSHelp,
+	})
+}
+
+// getPinFiles reads the file=, dir=, include= and exclude= parameters
+// used by vfs/pin, vfs/unpin and vfs/prefetch and returns the files
+// they select.
+func getPinFiles(ctx context.Context, vfs *VFS, in rc.Params) (files []*File, err error) {
+	opt := filter.GetConfig(ctx).Opt
+	opt.IncludeRule = append([]string{}, opt.IncludeRule...)
+	opt.ExcludeRule = append([]string{}, opt.ExcludeRule...)
+	for _, k := range []string{"include", "exclude"} {
+		glob, err := in.GetString(k)
+		if rc.IsErrParamNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if k == "include" {
+			opt.IncludeRule = append(opt.IncludeRule, glob)
+		} else {
+			opt.ExcludeRule = append(opt.ExcludeRule, glob)
+		}
+		delete(in, k)
+	}
+	fi, err := filter.NewFilter(&opt)
+	if err != nil {
+		return nil, errors.Wrap(err, "bad include or exclude")
+	}
+
+	var walk func(dir *Dir) error
+	walk = func(dir *Dir) error {
+		nodes, err := dir.ReadDirAll()
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			switch x := node.(type) {
+			case *File:
+				if fi.Include(x.Path(), x.Size(), x.ModTime()) {
+					files = append(files, x)
+				}
+			case *Dir:
+				err = walk(x)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	for k, v := range in {
+		path, ok := v.(string)
+		if !ok {
+			return nil, errors.Errorf("value must be string %q=%v", k, v)
+		}
+		path = strings.Trim(path, "/")
+		if !strings.HasPrefix(k, "file") && !strings.HasPrefix(k, "dir") {
+			return nil, errors.Errorf("unknown key %q", k)
+		}
+		node, err := vfs.Stat(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find %q", path)
+		}
+		switch x := node.(type) {
+		case *File:
+			if strings.HasPrefix(k, "dir") {
+				return nil, errors.Errorf("%q is not a directory", path)
+			}
+			files = append(files, x)
+		case *Dir:
+			if strings.HasPrefix(k, "file") {
+				return nil, errors.Errorf("%q is a directory", path)
+			}
+			err = walk(x)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to list %q", path)
+			}
+		}
+	}
+	return files, nil
+}
+
+// filePaths returns the paths of files
+func filePaths(files []*File) (paths []string) {
+	paths = []string{}
+	for _, file := range files {
+		paths = append(paths, file.Path())
+	}
+	return paths
+}
+
+// checkCacheFull returns an error if vfs isn't using --vfs-cache-mode full
+func checkCacheFull(vfs *VFS) error {
+	if vfs.cache == nil || vfs.Opt.CacheMode < vfscommon.CacheModeFull {
+		return errors.New("need --vfs-cache-mode full")
+	}
+	return nil
+}
+
+// prefetch downloads files into the cache one at a time
+//
+// This is intended to be run in the background so logs any errors
+func (vfs *VFS) prefetch(files []*File) {
+	for _, file := range files {
+		o, err := file.waitForValidObject()
+		if err != nil {
+			fs.Errorf(file, "vfs cache: prefetch failed: %v", err)
+			continue
+		}
+		err = vfs.cache.Prefetch(file.Path(), o)
+		if err != nil {
+			fs.Errorf(file, "vfs cache: prefetch failed: %v", err)
+			continue
+		}
+		fs.Debugf(file, "vfs cache: prefetch complete")
+	}
+}
+
+func rcPin(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(in)
+	if err != nil {
+		return nil, err
+	}
+	err = checkCacheFull(vfs)
+	if err != nil {
+		return nil, err
+	}
+	if len(in) == 0 {
+		return rc.Params{
+			"pins": vfs.cache.Pinned(),
+		}, nil
+	}
+	files, err := getPinFiles(ctx, vfs, in)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		err = vfs.cache.Pin(file.Path())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to pin %q", file.Path())
+		}
+	}
+	go vfs.prefetch(files)
+	return rc.Params{
+		"pinned": filePaths(files),
+	}, nil
+}
+
+func rcUnpin(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(in)
+	if err != nil {
+		return nil, err
+	}
+	if vfs.cache == nil {
+		return nil, errors.New("vfs cache is not in use")
+	}
+	files, err := getPinFiles(ctx, vfs, in)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		err = vfs.cache.Unpin(file.Path())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to unpin %q", file.Path())
+		}
+	}
+	return rc.Params{
+		"unpinned": filePaths(files),
+	}, nil
+}
+
+func rcPrefetch(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(in)
+	if err != nil {
+		return nil, err
+	}
+	err = checkCacheFull(vfs)
+	if err != nil {
+		return nil, err
+	}
+	files, err := getPinFiles(ctx, vfs, in)
+	if err != nil {
+		return nil, err
+	}
+	go vfs.prefetch(files)
+	return rc.Params{
+		"prefetching": filePaths(files),
+	}, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "vfs/stats",
+		Title: "Stats for a VFS.",
+		Help: `
+This returns stats for the selected VFS.
+
+    {
+        // Status of the Disk Cache - only present if --vfs-cache-mode > off
+        "diskCache": {
+            "bytesUsed": 0,
+            "dirtyBytes": 0,
+            "dirtyFiles": 0,
+            "erroredFiles": 0,
+            "files": 0,
+            "hashType": 1,
+            "hitRatio": 0,
+            "hits": 0,
+            "misses": 0,
+            "openFiles": 0,
+            "outOfSpace": false,
+            "path": "/home/user/.cache/rclone/vfs/local/mnt/a",
+            "pathMeta": "/home/user/.cache/rclone/vfsMeta/local/mnt/a",
+            "pinnedFiles": 0,
+            "uploadsInProgress": 0,
+            "uploadsQueued": 0
+        },
+        "fs": "/mnt/a",
+        "inUse": 1,
+        // Status of the in memory metadata cache
+        "metadataCache": {
+            "dirs": 1,
+            "files": 0
+        },
+        // Options as returned by options/get
+        "opt": {
+            "CacheMaxAge": 3600000000000,
+            // ...
+            "WriteWait": 1000000000
+        }
+    }
+
+"hits" and "misses" count the reads which could and couldn't be
+satisfied from the disk cache and "hitRatio" is hits/(hits+misses).
+"dirtyBytes" is the total size of the files which are waiting to be
+uploaded.
+` + getVFSHelp,
+		Fn: rcStats,
+	})
+}
+
+func rcStats(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(in)
+	if err != nil {
+		return nil, err
+	}
+	return vfs.Stats(), nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "vfs/queue",
+		Title: "Queue info for a VFS.",
+		Help: strings.ReplaceAll(`
+This returns info about the upload queue for the selected VFS.
+
+This is only useful if |--vfs-cache-mode| > off. If you call it when
+the |--vfs-cache-mode| is off, it will return an empty result.
+
+    {
+        "queued": // an array of files queued for upload
+        [
+            {
+                "name":      "file",   // string: name (full path) of the file,
+                "id":        123,      // integer: id of this item in the queue,
+                "size":      79,       // integer: size of the file in bytes
+                "expiry":    1.5       // float: time until file is eligible for transfer, lowest goes first
+                "tries":     1,        // integer: number of times we have tried to upload
+                "delay":     5.0,      // float: seconds between upload attempts
+                "uploading": false,    // boolean: true if item is being uploaded
+                "error":     "",       // string: error from the last upload attempt or empty
+            },
+       ],
+    }
+
+The |expiry| time is the time until the file is elegible for being
+uploaded in floating point seconds. This may go negative. As rclone
+only transfers |--transfers| files at once, only the lowest
+|--transfers| expiry times will have |uploading| as |true|. So there
+may be files with negative expiry times for which |uploading| is
+|false|.
+
+Files which have failed to upload have their |tries| count
+incremented, the |error| set and are retried after |delay| seconds.
+
+`, "|", "`") + getVFSHelp,
+		Fn: rcQueue,
+	})
+}
+
+func rcQueue(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(in)
+	if err != nil {
+		return nil, err
+	}
+	out = make(rc.Params)
+	if vfs.cache == nil {
+		out["queued"] = []writeback.QueueInfo{}
+		return out, nil
+	}
+	out["queued"] = vfs.cache.Queue()
+	return out, nil
+}
+
+func init() {
+	rc.Add(rc.Call{
+		Path:  "vfs/queue-set-expiry",
+		Title: "Set the expiry time for an item queued for upload.",
+		Help: strings.ReplaceAll(`
+
+Use this to adjust the |expiry| time for an item in the upload queue.
+You will need to read the |id| of the item using |vfs/queue| before
+using this call.
+
+You can then set |expiry| to a floating point number of seconds from
+now when the item is eligible for upload. If you want the item to be
+uploaded as soon as possible then set it to 0 or a negative number.
+
+Items which are currently uploading can't have their expiry changed.
+
+    rclone rc vfs/queue-set-expiry id=123 expiry=0
+
+Note that the |id| and |expiry| parameters are required.
+`, "|", "`") + getVFSHelp,
+		Fn: rcQueueSetExpiry,
+	})
+}
+
+func rcQueueSetExpiry(ctx context.Context, in rc.Params) (out rc.Params, err error) {
+	vfs, err := getVFS(in)
+	if err != nil {
+		return nil, err
+	}
+	if vfs.cache == nil {
+		return nil, errors.New("can't call this unless using the VFS cache")
+	}
+
+	// Read input values
+	id, err := in.GetInt64("id")
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := in.GetFloat64("expiry")
+	if err != nil {
+		return nil, err
+	}
+
+	// Set expiry
+	expiryTime := time.Now().Add(time.Duration(float64(time.Second) * expiry))
+	err = vfs.cache.QueueSetExpiry(writeback.Handle(id), expiryTime)
+	return nil, err
+}
@@ -3,10 +3,14 @@ package vfs
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/obscure"
 	"github.com/rclone/rclone/fs/rc"
 	"github.com/rclone/rclone/fstest"
+	"github.com/rclone/rclone/vfs/vfscache"
+	"github.com/rclone/rclone/vfs/vfscache/writeback"
 	"github.com/rclone/rclone/vfs/vfscommon"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -119,3 +123,107 @@ func TestRcList(t *testing.T) {
 		},
 	}, out)
 }
+
+func TestRcPin(t *testing.T) {
+	r, vfs, cleanup, call := rcNewRun(t, "vfs/pin")
+	_ = vfs
+
+	// Needs --vfs-cache-mode full
+	_, err := call.Fn(context.Background(), rc.Params{"fs": fs.ConfigString(r.Fremote)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "need --vfs-cache-mode full")
+	cleanup()
+
+	opt := vfscommon.DefaultOpt
+	opt.CacheMode = vfscommon.CacheModeFull
+	r, vfs, cleanup = newTestVFSOpt(t, &opt)
+	defer cleanup()
+	_ = vfs
+
+	ctx := context.Background()
+	r.WriteObject(ctx, "dir/file1.txt", "file1 contents", t1)
+	r.WriteObject(ctx, "dir/sub/file2.txt", "file2 contents", t1)
+	r.WriteObject(ctx, "dir/file3.jpg", "file3 contents", t1)
+
+	out, err := call.Fn(ctx, rc.Params{"dir": "dir", "include": "*.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"pinned": []string{"dir/file1.txt", "dir/sub/file2.txt"},
+	}, out)
+
+	// Wait for the background prefetch to complete
+	var pins []vfscache.PinInfo
+	for i := 0; i < 100; i++ {
+		out, err = call.Fn(ctx, rc.Params{})
+		require.NoError(t, err)
+		pins = out["pins"].([]vfscache.PinInfo)
+		if len(pins) == 2 && pins[0].Complete && pins[1].Complete {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, []vfscache.PinInfo{
+		{Name: "dir/file1.txt", Size: 14, Cached: 14, Complete: true},
+		{Name: "dir/sub/file2.txt", Size: 14, Cached: 14, Complete: true},
+	}, pins)
+
+	unpin := rc.Calls.Get("vfs/unpin")
+	out, err = unpin.Fn(ctx, rc.Params{"file": "dir/file1.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"unpinned": []string{"dir/file1.txt"},
+	}, out)
+
+	out, err = call.Fn(ctx, rc.Params{})
+	require.NoError(t, err)
+	assert.Equal(t, []vfscache.PinInfo{
+		{Name: "dir/sub/file2.txt", Size: 14, Cached: 14, Complete: true},
+	}, out["pins"])
+
+	_, err = call.Fn(ctx, rc.Params{"file": "dir"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is a directory")
+}
+
+func TestRcStats(t *testing.T) {
+	r, vfs, cleanup, call := rcNewRun(t, "vfs/stats")
+	defer cleanup()
+	out, err := call.Fn(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, fs.ConfigString(r.Fremote), out["fs"])
+	assert.Equal(t, int32(1), out["inUse"])
+	assert.Equal(t, 1, out["metadataCache"].(rc.Params)["dirs"])
+	assert.Equal(t, 0, out["metadataCache"].(rc.Params)["files"])
+	assert.Equal(t, vfs.Opt, out["opt"])
+	_, found := out["diskCache"]
+	assert.False(t, found)
+
+	opt := vfscommon.DefaultOpt
+	opt.CacheMode = vfscommon.CacheModeFull
+	opt.CachePassword = obscure.MustObscure("potato")
+	vfs2 := New(r.Fremote, &opt)
+	defer vfs2.Shutdown()
+	stats := vfs2.Stats()
+	assert.Equal(t, "", stats["opt"].(vfscommon.Options).CachePassword, "cache password not returned")
+	diskCache := stats["diskCache"].(rc.Params)
+	assert.Equal(t, 0, diskCache["files"])
+	assert.Equal(t, int64(0), diskCache["dirtyBytes"])
+	assert.Equal(t, 0.0, diskCache["hitRatio"])
+}
+
+func TestRcQueue(t *testing.T) {
+	r, vfs, cleanup, call := rcNewRun(t, "vfs/queue")
+	defer cleanup()
+	_ = vfs
+
+	out, err := call.Fn(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, rc.Params{
+		"queued": []writeback.QueueInfo{},
+	}, out)
+
+	setExpiry := rc.Calls.Get("vfs/queue-set-expiry")
+	_, err = setExpiry.Fn(context.Background(), rc.Params{"fs": fs.ConfigString(r.Fremote), "id": 1, "expiry": 0})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "VFS cache")
+}
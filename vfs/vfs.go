@@ -36,8 +36,11 @@ import (
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/cache"
 	"github.com/rclone/rclone/fs/log"
+	"github.com/rclone/rclone/fs/rc"
 	"github.com/rclone/rclone/vfs/vfscache"
 	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/rclone/rclone/vfs/vfslock"
+	"github.com/rclone/rclone/vfs/vfsmeta"
 )
 
 // Node represents either a directory (*Dir) or a file (*File)
@@ -55,6 +58,13 @@ type Node interface {
 	Truncate(size int64) error
 	Path() string
 	SetSys(interface{})
+	Owner() (uid, gid uint32)
+	Chmod(mode os.FileMode) error
+	Chown(uid, gid int) error
+	Getxattr(name string) ([]byte, error)
+	Setxattr(name string, value []byte, flags int) error
+	Listxattr() ([]string, error)
+	Removexattr(name string) error
 }
 
 // Check interfaces
@@ -162,6 +172,8 @@ type VFS struct {
 	Opt         vfscommon.Options
 	cache       *vfscache.Cache
 	cancelCache context.CancelFunc
+	meta        *vfsmeta.Store // may be nil if not storing metadata
+	locks       *vfslock.Manager
 	usageMu     sync.Mutex
 	usageTime   time.Time
 	usage       *fs.Usage
@@ -212,6 +224,9 @@ func New(f fs.Fs, opt *vfscommon.Options) *VFS {
 	// Put the VFS into the active cache
 	active[configName] = append(active[configName], vfs)
 
+	// Create the lock manager before anything lists the root
+	vfs.newLockManager()
+
 	// Create root directory
 	vfs.root = newDir(vfs, f, nil, fsDir)
 
@@ -232,6 +247,10 @@ func New(f fs.Fs, opt *vfscommon.Options) *VFS {
 
 	vfs.SetCacheMode(vfs.Opt.CacheMode)
 
+	if vfs.Opt.MetadataStore {
+		vfs.openMetadataStore()
+	}
+
 	// Pin the Fs into the cache so that when we use cache.NewFs
 	// with the same remote string we get this one. The Pin is
 	// removed when the vfs is finalized
@@ -254,6 +273,35 @@ func activeCacheEntries() (vfs *VFS, count int) {
 	return vfs, count
 }
 
+// Stats returns info about the VFS
+func (vfs *VFS) Stats() (out rc.Params) {
+	out = make(rc.Params)
+	out["fs"] = fs.ConfigString(vfs.f)
+	// Don't leak the key the cache is encrypted with
+	opt := vfs.Opt
+	opt.CachePassword = ""
+	out["opt"] = opt
+	out["inUse"] = atomic.LoadInt32(&vfs.inUse)
+
+	var (
+		dirs  int
+		files int
+	)
+	vfs.root.walk(func(d *Dir) {
+		dirs++
+		files += len(d.items)
+	})
+	inf := make(rc.Params)
+	out["metadataCache"] = inf
+	inf["dirs"] = dirs
+	inf["files"] = files
+
+	if vfs.cache != nil {
+		out["diskCache"] = vfs.cache.Stats()
+	}
+	return out
+}
+
 // Fs returns the Fs passed into the New call
 func (vfs *VFS) Fs() fs.Fs {
 	return vfs.f
@@ -307,6 +355,8 @@ func (vfs *VFS) Shutdown() {
 	activeMu.Unlock()
 
 	vfs.shutdownCache()
+	vfs.closeMetadataStore()
+	vfs.locks.Shutdown()
 }
 
 // CleanUp deletes the contents of the on disk cache
@@ -477,6 +527,7 @@ func (vfs *VFS) OpenFile(name string, flags int, perm os.FileMode) (fd Handle, e
 		if err != nil {
 			return nil, err
 		}
+		SaveCreateMode(node, perm)
 	}
 	return node.Open(flags)
 }
@@ -617,13 +668,42 @@ func (vfs *VFS) Mkdir(name string, perm os.FileMode) error {
 	if err != nil {
 		return err
 	}
-	_, err = dir.Mkdir(leaf)
+	newDir, err := dir.Mkdir(leaf)
 	if err != nil {
 		return err
 	}
+	SaveCreateMode(newDir, perm)
 	return nil
 }
 
+// Symlink creates newname as a symbolic link to target.
+//
+// This needs --vfs-links to be set.
+func (vfs *VFS) Symlink(target, newname string) error {
+	dir, leaf, err := vfs.StatParent(newname)
+	if err != nil {
+		return err
+	}
+	_, err = dir.Symlink(target, leaf)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Readlink returns the destination of the named symbolic link.
+func (vfs *VFS) Readlink(name string) (string, error) {
+	node, err := vfs.Stat(name)
+	if err != nil {
+		return "", err
+	}
+	file, ok := node.(*File)
+	if !ok {
+		return "", EINVAL
+	}
+	return file.Readlink()
+}
+
 // ReadDir reads the directory named by dirname and returns
 // a list of directory entries sorted by filename.
 func (vfs *VFS) ReadDir(dirname string) ([]os.FileInfo, error) {
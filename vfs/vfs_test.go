@@ -321,6 +321,116 @@ func TestVFSRename(t *testing.T) {
 	assert.Equal(t, os.ErrNotExist, err)
 }
 
+func TestVFSSymlink(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.Links = true
+	r, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+
+	file1 := r.WriteObject(context.Background(), "dir/link1"+vfscommon.LinkSuffix, "../file", t1)
+	fstest.CheckItems(t, r.Fremote, file1)
+
+	// Existing link files are shown as symlinks
+	node, err := vfs.Stat("dir/link1")
+	require.NoError(t, err)
+	assert.Equal(t, "link1", node.Name())
+	assert.True(t, node.Mode()&os.ModeSymlink != 0)
+	assert.Equal(t, int64(len("../file")), node.Size())
+	_, err = vfs.Stat("dir/link1" + vfscommon.LinkSuffix)
+	assert.Equal(t, ENOENT, err)
+
+	target, err := vfs.Readlink("dir/link1")
+	require.NoError(t, err)
+	assert.Equal(t, "../file", target)
+
+	// Links can't be written to
+	_, err = vfs.OpenFile("dir/link1", os.O_WRONLY, 0777)
+	assert.Equal(t, EPERM, err)
+
+	// Make a new link
+	require.NoError(t, vfs.Symlink("/absolute/target", "dir/link2"))
+	target, err = vfs.Readlink("dir/link2")
+	require.NoError(t, err)
+	assert.Equal(t, "/absolute/target", target)
+	assert.Equal(t, EEXIST, vfs.Symlink("other", "dir/link2"))
+	file2 := fstest.NewItem("dir/link2"+vfscommon.LinkSuffix, "/absolute/target", t1)
+	fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, file2}, []string{"dir"}, fs.ModTimeNotSupported)
+
+	// Rename a link
+	features := r.Fremote.Features()
+	if features.Move != nil || features.Copy != nil {
+		require.NoError(t, vfs.Rename("dir/link1", "link3"))
+		target, err = vfs.Readlink("link3")
+		require.NoError(t, err)
+		assert.Equal(t, "../file", target)
+		file1.Path = "link3" + vfscommon.LinkSuffix
+		fstest.CheckListingWithPrecision(t, r.Fremote, []fstest.Item{file1, file2}, []string{"dir"}, fs.ModTimeNotSupported)
+	}
+
+	// Remove a link
+	require.NoError(t, vfs.Remove("dir/link2"))
+	_, err = vfs.Readlink("dir/link2")
+	assert.Equal(t, ENOENT, err)
+
+	// Readlink on things which aren't links
+	_, err = vfs.Readlink("dir")
+	assert.Equal(t, EINVAL, err)
+
+	// Without --vfs-links the files are shown as is and symlinks
+	// can't be made
+	vfs.Opt.Links = false
+	vfs.FlushDirCache()
+	_, err = vfs.Stat("link3" + vfscommon.LinkSuffix)
+	require.NoError(t, err)
+	_, err = vfs.Readlink("link3" + vfscommon.LinkSuffix)
+	assert.Equal(t, EINVAL, err)
+	assert.Equal(t, ENOSYS, vfs.Symlink("target", "link4"))
+}
+
+// TestVFSSymlinkCollision checks a real file "foo" wins over a link
+// "foo.rclonelink" which is then shown as a file with its full name
+func TestVFSSymlinkCollision(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.Links = true
+	r, vfs, cleanup := newTestVFSOpt(t, &opt)
+	defer cleanup()
+
+	file1 := r.WriteObject(context.Background(), "dir/foo", "real file", t1)
+	file2 := r.WriteObject(context.Background(), "dir/foo"+vfscommon.LinkSuffix, "target", t1)
+	file3 := r.WriteObject(context.Background(), "dir/bar"+vfscommon.LinkSuffix, "target", t1)
+	fstest.CheckItems(t, r.Fremote, file1, file2, file3)
+
+	node, err := vfs.Stat("dir/foo")
+	require.NoError(t, err)
+	assert.True(t, node.Mode().IsRegular())
+	assert.Equal(t, int64(len("real file")), node.Size())
+
+	node, err = vfs.Stat("dir/foo" + vfscommon.LinkSuffix)
+	require.NoError(t, err)
+	assert.True(t, node.Mode().IsRegular())
+	_, err = vfs.Readlink("dir/foo" + vfscommon.LinkSuffix)
+	assert.Equal(t, EINVAL, err)
+
+	// links which don't collide are still translated
+	target, err := vfs.Readlink("dir/bar")
+	require.NoError(t, err)
+	assert.Equal(t, "target", target)
+
+	// all the entries are listed
+	dir, err := vfs.Stat("dir")
+	require.NoError(t, err)
+	nodes, err := dir.(*Dir).ReadDirAll()
+	require.NoError(t, err)
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Name())
+	}
+	assert.Equal(t, []string{"bar", "foo", "foo" + vfscommon.LinkSuffix}, names)
+
+	// a symlink can't be made over the real file
+	assert.Equal(t, EEXIST, vfs.Symlink("other", "dir/foo"))
+}
+
 func TestVFSStatfs(t *testing.T) {
 	r, vfs, cleanup := newTestVFS(t)
 	defer cleanup()
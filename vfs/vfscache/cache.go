@@ -11,6 +11,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	sysdnotify "github.com/iguanesolutions/go-systemd/v5/notify"
@@ -21,6 +22,7 @@ import (
 	"github.com/rclone/rclone/fs/fserrors"
 	"github.com/rclone/rclone/fs/hash"
 	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/rc"
 	"github.com/rclone/rclone/lib/file"
 	"github.com/rclone/rclone/vfs/vfscache/writeback"
 	"github.com/rclone/rclone/vfs/vfscommon"
@@ -37,6 +39,10 @@ import (
 
 // Cache opened files
 type Cache struct {
+	// read and written with atomic - keep at the top for alignment
+	hits   int64 // number of reads satisfied from the cache
+	misses int64 // number of reads which needed data from the remote
+
 	// read only - no locking needed to read these
 	fremote    fs.Fs                // fs for the remote we are caching
 	fcache     fs.Fs                // fs for the cache directory
@@ -44,10 +50,12 @@ type Cache struct {
 	opt        *vfscommon.Options   // vfs Options
 	root       string               // root of the cache directory
 	metaRoot   string               // root of the cache metadata directory
+	keyPath    string               // file to check the cache key against
 	hashType   hash.Type            // hash to use locally and remotely
 	hashOption *fs.HashesOption     // corresponding OpenOption
 	writeback  *writeback.WriteBack // holds Items for writeback
 	avFn       AddVirtualFn         // if set, can be called to add dir entries
+	cipher     *cacheCipher         // if set, encrypts the files in the cache
 
 	mu            sync.Mutex       // protects the following variables
 	cond          *sync.Cond       // cond lock for synchronous cache cleaning
@@ -90,6 +98,7 @@ func New(ctx context.Context, fremote fs.Fs, opt *vfscommon.Options, avFn AddVir
 	fs.Debugf(nil, "vfs cache: root is %q", root)
 	metaRoot := file.UNCPath(filepath.Join(cacheDir, "vfsMeta", fremote.Name(), fRoot))
 	fs.Debugf(nil, "vfs cache: metadata root is %q", root)
+	keyPath := file.UNCPath(filepath.Join(cacheDir, "vfsKey", fremote.Name(), fRoot, "key-check"))
 
 	fcache, err := fscache.Get(ctx, root)
 	if err != nil {
@@ -102,6 +111,14 @@ func New(ctx context.Context, fremote fs.Fs, opt *vfscommon.Options, avFn AddVir
 
 	hashType, hashOption := operations.CommonHash(ctx, fcache, fremote)
 
+	cacheCipher, err := newCacheCipher(opt, root)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to set up cache encryption")
+	}
+	if cacheCipher != nil {
+		fs.Debugf(nil, "vfs cache: encrypting cache files")
+	}
+
 	c := &Cache{
 		fremote:    fremote,
 		fcache:     fcache,
@@ -109,12 +126,14 @@ func New(ctx context.Context, fremote fs.Fs, opt *vfscommon.Options, avFn AddVir
 		opt:        opt,
 		root:       root,
 		metaRoot:   metaRoot,
+		keyPath:    keyPath,
 		item:       make(map[string]*Item),
 		errItems:   make(map[string]error),
 		hashType:   hashType,
 		hashOption: hashOption,
 		writeback:  writeback.New(ctx, opt),
 		avFn:       avFn,
+		cipher:     cacheCipher,
 	}
 
 	// Make sure cache directories exist
@@ -123,12 +142,28 @@ func New(ctx context.Context, fremote fs.Fs, opt *vfscommon.Options, avFn AddVir
 		return nil, errors.Wrap(err, "failed to make cache directory")
 	}
 
+	// Check the key before reading any of the files
+	if c.cipher != nil {
+		err = c.cipher.checkKey(keyPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// load in the cache and metadata off disk
 	err = c.reload(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to load cache")
 	}
 
+	// The cache isn't encrypted any more so forget the old key
+	if c.cipher == nil {
+		err = os.Remove(keyPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrap(err, "failed to remove cache key check")
+		}
+	}
+
 	// Remove any empty directories
 	c.purgeEmptyDirs()
 
@@ -362,14 +397,100 @@ func (c *Cache) SetModTime(name string, modTime time.Time) {
 	item.setModTime(modTime)
 }
 
+// Pin marks name as pinned so it is never removed from the cache by
+// the cache cleaner, whatever its age or the size of the cache.
+//
+// name should be a remote path not an osPath
+func (c *Cache) Pin(name string) error {
+	item, _ := c.get(name)
+	return item.setPinned(true)
+}
+
+// Unpin reverses the effect of Pin so name becomes subject to the
+// normal cache expiry rules.
+//
+// name should be a remote path not an osPath
+func (c *Cache) Unpin(name string) error {
+	name = clean(name)
+	c.mu.Lock()
+	item := c.item[name]
+	c.mu.Unlock()
+	if item == nil {
+		return nil
+	}
+	return item.setPinned(false)
+}
+
+// Prefetch downloads the whole of the object o into the cache under
+// name using the downloaders, returning when it is complete.
+//
+// name should be a remote path not an osPath
+func (c *Cache) Prefetch(name string, o fs.Object) (err error) {
+	item, _ := c.get(name)
+	err = item.Open(o)
+	if err != nil {
+		return errors.Wrap(err, "vfs cache: prefetch failed to open item")
+	}
+	defer func() {
+		closeErr := item.Close(nil)
+		if err == nil {
+			err = closeErr
+		}
+	}()
+	err = item.prefetch()
+	if err != nil {
+		return errors.Wrap(err, "vfs cache: prefetch failed")
+	}
+	return nil
+}
+
+// PinInfo describes the state of a pinned item in the cache
+type PinInfo struct {
+	Name     string `json:"name"`     // name of the item in the VFS
+	Size     int64  `json:"size"`     // size of the file
+	Cached   int64  `json:"cached"`   // number of bytes present in the cache
+	Complete bool   `json:"complete"` // set if the whole file is in the cache
+}
+
+// Pinned returns info about all the pinned items in the cache sorted
+// by name.
+func (c *Cache) Pinned() (pins []PinInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pins = []PinInfo{}
+	for name, item := range c.item {
+		item.mu.Lock()
+		if item.info.Pinned {
+			pins = append(pins, PinInfo{
+				Name:     name,
+				Size:     item.info.Size,
+				Cached:   item.info.Rs.Size(),
+				Complete: item._exists() && item._present(),
+			})
+		}
+		item.mu.Unlock()
+	}
+	sort.Slice(pins, func(i, j int) bool {
+		return pins[i].Name < pins[j].Name
+	})
+	return pins
+}
+
 // CleanUp empties the cache of everything
 func (c *Cache) CleanUp() error {
 	err1 := os.RemoveAll(c.root)
 	err2 := os.RemoveAll(c.metaRoot)
+	err3 := os.Remove(c.keyPath)
 	if err1 != nil {
 		return err1
 	}
-	return err2
+	if err2 != nil {
+		return err2
+	}
+	if err3 != nil && !os.IsNotExist(err3) {
+		return err3
+	}
+	return nil
 }
 
 // walk walks the cache calling the function
@@ -405,6 +526,9 @@ func (c *Cache) reload(ctx context.Context) error {
 				return nil
 			}
 			item, found := c.get(name)
+			if item.loadErr != nil {
+				return errors.Wrapf(item.loadErr, "can't read %q in the cache", name)
+			}
 			if !found {
 				err := item.reload(ctx)
 				if err != nil {
@@ -500,7 +624,7 @@ func (c *Cache) purgeClean(quota int64) {
 
 	// Make a slice of clean cache files
 	for _, item := range c.item {
-		if !item.IsDataDirty() {
+		if !item.IsDataDirty() && !item.IsPinned() {
 			items = append(items, item)
 		}
 	}
@@ -589,9 +713,9 @@ func (c *Cache) purgeOverQuota(quota int64) {
 
 	var items Items
 
-	// Make a slice of unused files
+	// Make a slice of unused files which aren't pinned
 	for _, item := range c.item {
-		if !item.inUse() {
+		if !item.inUse() && !item.IsPinned() {
 			items = append(items, item)
 		}
 	}
@@ -710,6 +834,81 @@ func (c *Cache) TotalInUse() (n int) {
 	return n
 }
 
+// recordRead records whether a read was satisfied from the cache or
+// not for the stats
+func (c *Cache) recordRead(hit bool) {
+	if hit {
+		atomic.AddInt64(&c.hits, 1)
+	} else {
+		atomic.AddInt64(&c.misses, 1)
+	}
+}
+
+// Stats returns info about the Cache
+func (c *Cache) Stats() (out rc.Params) {
+	out = make(rc.Params)
+	// read only - no locking needed to read these
+	out["path"] = c.root
+	out["pathMeta"] = c.metaRoot
+	out["hashType"] = c.hashType
+	hits, misses := atomic.LoadInt64(&c.hits), atomic.LoadInt64(&c.misses)
+	out["hits"] = hits
+	out["misses"] = misses
+	hitRatio := 0.0
+	if hits+misses > 0 {
+		hitRatio = float64(hits) / float64(hits+misses)
+	}
+	out["hitRatio"] = hitRatio
+
+	uploadsInProgress, uploadsQueued := c.writeback.Stats()
+	out["uploadsInProgress"] = uploadsInProgress
+	out["uploadsQueued"] = uploadsQueued
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var (
+		openFiles   int
+		dirtyFiles  int
+		dirtyBytes  int64
+		pinnedFiles int
+	)
+	for _, item := range c.item {
+		item.mu.Lock()
+		if item.opens > 0 {
+			openFiles++
+		}
+		if item.info.Dirty {
+			dirtyFiles++
+			dirtyBytes += item.info.Size
+		}
+		if item.info.Pinned {
+			pinnedFiles++
+		}
+		item.mu.Unlock()
+	}
+	out["files"] = len(c.item)
+	out["openFiles"] = openFiles
+	out["dirtyFiles"] = dirtyFiles
+	out["dirtyBytes"] = dirtyBytes
+	out["pinnedFiles"] = pinnedFiles
+	out["erroredFiles"] = len(c.errItems)
+	out["bytesUsed"] = c.used
+	out["outOfSpace"] = c.outOfSpace
+	return out
+}
+
+// Queue returns info about the items in the Cache's writeback queue
+func (c *Cache) Queue() []writeback.QueueInfo {
+	return c.writeback.Queue()
+}
+
+// QueueSetExpiry updates the expiry of a single item in the upload
+// queue so it is uploaded at expiry. Setting expiry to now makes it
+// upload as soon as possible.
+func (c *Cache) QueueSetExpiry(id writeback.Handle, expiry time.Time) error {
+	return c.writeback.SetExpiry(id, expiry)
+}
+
 // Dump the cache into a string for debugging purposes
 func (c *Cache) Dump() string {
 	if c == nil {
@@ -490,6 +490,58 @@ func TestCachePurgeClean(t *testing.T) {
 	assert.Equal(t, []string(nil), itemAsString(c))
 }
 
+func TestCachePin(t *testing.T) {
+	r, c, cleanup := newTestCache(t)
+	defer cleanup()
+
+	potato := c.Item("sub/dir/potato")
+	require.NoError(t, potato.Open(nil))
+	require.NoError(t, potato.Close(nil))
+	potato2 := c.Item("sub/dir2/potato2")
+	require.NoError(t, potato2.Open(nil))
+	require.NoError(t, potato2.Close(nil))
+
+	require.NoError(t, c.Pin("sub/dir/potato"))
+	assert.True(t, potato.IsPinned())
+	assert.False(t, potato2.IsPinned())
+
+	c.purgeOld(-10 * time.Second)
+
+	assert.Equal(t, []string{
+		`name="sub/dir/potato" opens=0 size=0`,
+	}, itemAsString(c))
+
+	c.purgeOverQuota(1)
+
+	assert.Equal(t, []string{
+		`name="sub/dir/potato" opens=0 size=0`,
+	}, itemAsString(c))
+
+	assert.Equal(t, []PinInfo{
+		{Name: "sub/dir/potato", Size: 0, Cached: 0, Complete: true},
+	}, c.Pinned())
+
+	// Prefetch a remote object into the cache and pin it
+	contents := "hello world"
+	file1 := r.WriteObject(context.Background(), "sub/file1", contents, time.Now())
+	o, err := r.Fremote.NewObject(context.Background(), file1.Path)
+	require.NoError(t, err)
+	require.NoError(t, c.Pin("sub/file1"))
+	require.NoError(t, c.Prefetch("sub/file1", o))
+	assert.Equal(t, []PinInfo{
+		{Name: "sub/dir/potato", Size: 0, Cached: 0, Complete: true},
+		{Name: "sub/file1", Size: int64(len(contents)), Cached: int64(len(contents)), Complete: true},
+	}, c.Pinned())
+
+	require.NoError(t, c.Unpin("sub/dir/potato"))
+	require.NoError(t, c.Unpin("sub/file1"))
+	assert.Equal(t, []PinInfo{}, c.Pinned())
+
+	c.purgeOld(-10 * time.Second)
+
+	assert.Equal(t, []string(nil), itemAsString(c))
+}
+
 func TestCacheInUse(t *testing.T) {
 	_, c, cleanup := newTestCache(t)
 	defer cleanup()
@@ -0,0 +1,563 @@
+package vfscache
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/lib/ranges"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"golang.org/x/crypto/scrypt"
+)
+
+// The data files in the cache are split into blocks of
+// cacheBlockSize bytes, each of which is encrypted and authenticated
+// with AES-GCM using a fresh random nonce every time it is written,
+// so rewriting part of a file never reuses a nonce. The block number
+// and a random ID for the file, which is stored in the (encrypted)
+// metadata, are authenticated with each block so blocks can't be
+// moved within or between files.
+//
+// Each block is stored at a fixed offset as the nonce followed by the
+// ciphertext and tag, so any block can be read or written
+// independently and sparse files and ranged reads keep working. Only
+// the last block of a file may be shorter than cacheBlockSize.
+//
+// The blocks which have been written are recorded in the metadata.
+// Every one of those is authenticated when it is read and any other
+// block is a hole in the sparse file which reads as zeroes whatever is
+// on disk.
+//
+// The metadata files are encrypted and authenticated with AES-GCM.
+
+const (
+	cacheKeySize       = 32 // AES-256
+	cacheFileIDSize    = 16
+	cacheBlockSize     = 64 * 1024 // size of the plaintext in a block
+	cacheBlockNonce    = 12        // size of the GCM nonce
+	cacheBlockOverhead = cacheBlockNonce + 16
+	cacheBlockDiskSize = cacheBlockSize + cacheBlockOverhead
+)
+
+// cacheMetaMagic is the header of an encrypted metadata file
+var cacheMetaMagic = []byte("RCLONE\x00vfsmeta\x00")
+
+// cacheKeyCheck is sealed with the key and stored with the cache so
+// a wrong key is detected before any of the files are read
+var cacheKeyCheck = []byte("rclone vfs cache key check")
+
+// Errors returned when metadata can't be decrypted with the key in
+// use. Items with these errors are never removed as they may hold
+// data which hasn't been uploaded yet.
+var (
+	errMetaNotEncrypted = errors.New("metadata is not encrypted - was the cache made without --vfs-cache-password?")
+	errMetaEncrypted    = errors.New("metadata is encrypted - set --vfs-cache-password or --vfs-cache-key-file")
+	errMetaAuth         = errors.New("failed to authenticate metadata - wrong key?")
+)
+
+// isMetaKeyError returns true if err means the metadata couldn't be
+// decrypted with the key in use
+func isMetaKeyError(err error) bool {
+	switch errors.Cause(err) {
+	case errMetaNotEncrypted, errMetaEncrypted, errMetaAuth:
+		return true
+	}
+	return false
+}
+
+// cacheFile is the backing file for an Item
+//
+// This is satisfied by *os.File and by *encryptedFile
+type cacheFile interface {
+	io.ReaderAt
+	io.WriterAt
+	io.Closer
+	Stat() (os.FileInfo, error)
+	Truncate(size int64) error
+	Sync() error
+}
+
+// check interfaces
+var (
+	_ cacheFile = (*os.File)(nil)
+	_ cacheFile = (*encryptedFile)(nil)
+)
+
+// cacheCipher encrypts and decrypts the files in the cache
+type cacheCipher struct {
+	block cipher.Block // for encrypting the data files
+	aead  cipher.AEAD  // for encrypting the metadata files
+}
+
+// newCacheCipher makes a cacheCipher from the options if encryption
+// of the cache is configured or returns nil if it isn't.
+//
+// The key is derived from the password or key file with scrypt
+// salted with root so each cache gets its own key.
+func newCacheCipher(opt *vfscommon.Options, root string) (*cacheCipher, error) {
+	var secret []byte
+	switch {
+	case opt.CachePassword != "" && opt.CacheKeyFile != "":
+		return nil, errors.New("can't use --vfs-cache-password and --vfs-cache-key-file together")
+	case opt.CachePassword != "":
+		password, err := obscure.Reveal(opt.CachePassword)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decrypt --vfs-cache-password - did you obscure it?")
+		}
+		secret = []byte(password)
+	case opt.CacheKeyFile != "":
+		var err error
+		secret, err = ioutil.ReadFile(opt.CacheKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read --vfs-cache-key-file")
+		}
+		if len(secret) == 0 {
+			return nil, errors.New("--vfs-cache-key-file is empty")
+		}
+	default:
+		return nil, nil
+	}
+	key, err := scrypt.Key(secret, []byte(root), 16384, 8, 1, cacheKeySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to derive cache key")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &cacheCipher{
+		block: block,
+		aead:  aead,
+	}, nil
+}
+
+// newFileID makes a new random ID for a data file
+func (cc *cacheCipher) newFileID() ([]byte, error) {
+	id := make([]byte, cacheFileIDSize)
+	_, err := io.ReadFull(rand.Reader, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make file ID")
+	}
+	return id, nil
+}
+
+// plaintextSize returns the size of the plaintext in a data file of
+// diskSize bytes
+func plaintextSize(diskSize int64) int64 {
+	size := diskSize / cacheBlockDiskSize * cacheBlockSize
+	if rem := diskSize % cacheBlockDiskSize; rem > cacheBlockOverhead {
+		size += rem - cacheBlockOverhead
+	}
+	return size
+}
+
+// diskSize returns the size of a data file holding size bytes of
+// plaintext
+func diskSize(size int64) int64 {
+	disk := size / cacheBlockSize * cacheBlockDiskSize
+	if rem := size % cacheBlockSize; rem > 0 {
+		disk += rem + cacheBlockOverhead
+	}
+	return disk
+}
+
+// sealMeta encrypts and authenticates the metadata in plaintext
+func (cc *cacheCipher) sealMeta(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, cc.aead.NonceSize())
+	_, err := io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make nonce")
+	}
+	out := make([]byte, 0, len(cacheMetaMagic)+len(nonce)+len(plaintext)+cc.aead.Overhead())
+	out = append(out, cacheMetaMagic...)
+	out = append(out, nonce...)
+	return cc.aead.Seal(out, nonce, plaintext, nil), nil
+}
+
+// openMeta decrypts and checks metadata sealed with sealMeta
+func (cc *cacheCipher) openMeta(ciphertext []byte) ([]byte, error) {
+	if !bytes.HasPrefix(ciphertext, cacheMetaMagic) {
+		return nil, errMetaNotEncrypted
+	}
+	ciphertext = ciphertext[len(cacheMetaMagic):]
+	nonceSize := cc.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("encrypted metadata too short")
+	}
+	plaintext, err := cc.aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], nil)
+	if err != nil {
+		return nil, errMetaAuth
+	}
+	return plaintext, nil
+}
+
+// checkKey checks the key is the one the cache was made with using
+// the record at keyPath, writing the record if there isn't one.
+func (cc *cacheCipher) checkKey(keyPath string) error {
+	data, err := ioutil.ReadFile(keyPath)
+	if os.IsNotExist(err) {
+		data, err = cc.sealMeta(cacheKeyCheck)
+		if err != nil {
+			return err
+		}
+		err = os.MkdirAll(filepath.Dir(keyPath), 0700)
+		if err != nil {
+			return errors.Wrap(err, "failed to make cache key check directory")
+		}
+		err = ioutil.WriteFile(keyPath, data, 0600)
+		if err != nil {
+			return errors.Wrap(err, "failed to write cache key check")
+		}
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read cache key check")
+	}
+	plaintext, err := cc.openMeta(data)
+	if err != nil || !bytes.Equal(plaintext, cacheKeyCheck) {
+		return errors.New("--vfs-cache-password or --vfs-cache-key-file doesn't match the one the cache was made with - use the old one or remove the cache directory")
+	}
+	return nil
+}
+
+// encryptedFile wraps an *os.File encrypting and decrypting the data
+// a block at a time on the fly
+type encryptedFile struct {
+	mu     sync.Mutex
+	fd     *os.File
+	aead   cipher.AEAD
+	id     []byte
+	size   int64         // size of the plaintext
+	blocks ranges.Ranges // block numbers which have been written
+}
+
+// newEncryptedFile wraps fd so the data written to it is encrypted
+// and authenticated with the file ID passed in
+//
+// blocks are the block numbers which have been written already.
+func (cc *cacheCipher) newEncryptedFile(fd *os.File, id []byte, blocks ranges.Ranges) (*encryptedFile, error) {
+	fi, err := fd.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedFile{
+		fd:     fd,
+		aead:   cc.aead,
+		id:     id,
+		size:   plaintextSize(fi.Size()),
+		blocks: append(ranges.Ranges(nil), blocks...),
+	}, nil
+}
+
+// Blocks returns a copy of the block numbers which have been written
+func (ef *encryptedFile) Blocks() ranges.Ranges {
+	ef.mu.Lock()
+	defer ef.mu.Unlock()
+	return append(ranges.Ranges(nil), ef.blocks...)
+}
+
+// additionalData returns the data authenticated with block i
+func (ef *encryptedFile) additionalData(i int64) []byte {
+	ad := make([]byte, len(ef.id)+8)
+	copy(ad, ef.id)
+	binary.BigEndian.PutUint64(ad[len(ef.id):], uint64(i))
+	return ad
+}
+
+// blockLen returns the length of the plaintext in block i for a file
+// of size bytes
+func blockLen(i, size int64) int64 {
+	n := size - i*cacheBlockSize
+	if n > cacheBlockSize {
+		n = cacheBlockSize
+	} else if n < 0 {
+		n = 0
+	}
+	return n
+}
+
+// _readBlock reads and decrypts block i
+//
+// Call with the lock held
+func (ef *encryptedFile) _readBlock(i int64) ([]byte, error) {
+	n := blockLen(i, ef.size)
+	if n == 0 {
+		return nil, nil
+	}
+	if !ef.blocks.Present(ranges.Range{Pos: i, Size: 1}) {
+		// a hole which has never been written
+		return make([]byte, n), nil
+	}
+	buf := make([]byte, n+cacheBlockOverhead)
+	_, err := ef.fd.ReadAt(buf, i*cacheBlockDiskSize)
+	if err == io.EOF {
+		// the block is short so won't authenticate
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	nonce, ciphertext := buf[:cacheBlockNonce], buf[cacheBlockNonce:]
+	plaintext, err := ef.aead.Open(ciphertext[:0], nonce, ciphertext, ef.additionalData(i))
+	if err != nil {
+		return nil, errors.Errorf("failed to authenticate block %d of cache file - corrupted?", i)
+	}
+	return plaintext, nil
+}
+
+// _writeBlock encrypts plaintext with a new nonce and writes it as
+// block i
+//
+// Call with the lock held
+func (ef *encryptedFile) _writeBlock(i int64, plaintext []byte) error {
+	buf := make([]byte, cacheBlockNonce, len(plaintext)+cacheBlockOverhead)
+	_, err := io.ReadFull(rand.Reader, buf)
+	if err != nil {
+		return errors.Wrap(err, "failed to make nonce")
+	}
+	buf = ef.aead.Seal(buf, buf, plaintext, ef.additionalData(i))
+	_, err = ef.fd.WriteAt(buf, i*cacheBlockDiskSize)
+	if err != nil {
+		return err
+	}
+	ef.blocks.Insert(ranges.Range{Pos: i, Size: 1})
+	return nil
+}
+
+// _resizeBlock rewrites block i so it holds n bytes of plaintext,
+// padding it with zeroes if necessary
+//
+// Call with the lock held
+func (ef *encryptedFile) _resizeBlock(i, n int64) error {
+	plaintext, err := ef._readBlock(i)
+	if err != nil {
+		return err
+	}
+	if n > int64(len(plaintext)) {
+		plaintext = append(plaintext, make([]byte, n-int64(len(plaintext)))...)
+	}
+	return ef._writeBlock(i, plaintext[:n])
+}
+
+// ReadAt reads and decrypts len(b) bytes from the file at off
+func (ef *encryptedFile) ReadAt(b []byte, off int64) (n int, err error) {
+	ef.mu.Lock()
+	defer ef.mu.Unlock()
+	for len(b) > 0 {
+		if off >= ef.size {
+			return n, io.EOF
+		}
+		i := off / cacheBlockSize
+		plaintext, err := ef._readBlock(i)
+		if err != nil {
+			return n, err
+		}
+		nn := copy(b, plaintext[off-i*cacheBlockSize:])
+		b = b[nn:]
+		off += int64(nn)
+		n += nn
+	}
+	return n, nil
+}
+
+// WriteAt encrypts and writes len(b) bytes to the file at off
+//
+// Each block written to is written in full with a new nonce, reading
+// the parts of it which aren't being overwritten first.
+func (ef *encryptedFile) WriteAt(b []byte, off int64) (n int, err error) {
+	ef.mu.Lock()
+	defer ef.mu.Unlock()
+	end := off + int64(len(b))
+	// If writing after a short last block then it must be filled
+	// up as it won't be the last block any more
+	last := ef.size / cacheBlockSize
+	if ef.size%cacheBlockSize != 0 && off >= (last+1)*cacheBlockSize {
+		err = ef._resizeBlock(last, cacheBlockSize)
+		if err != nil {
+			return 0, err
+		}
+		ef.size = (last + 1) * cacheBlockSize
+	}
+	newSize := ef.size
+	if end > newSize {
+		newSize = end
+	}
+	for len(b) > 0 {
+		i := off / cacheBlockSize
+		blockOff := off - i*cacheBlockSize
+		plaintext := make([]byte, blockLen(i, newSize))
+		nn := copy(plaintext[blockOff:], b)
+		if nn != len(plaintext) {
+			// Read the parts of the block not being written
+			old, err := ef._readBlock(i)
+			if err != nil {
+				return n, err
+			}
+			copy(plaintext[:blockOff], old)
+			if int(blockOff)+nn < len(old) {
+				copy(plaintext[int(blockOff)+nn:], old[int(blockOff)+nn:])
+			}
+		}
+		err = ef._writeBlock(i, plaintext)
+		if err != nil {
+			return n, err
+		}
+		b = b[nn:]
+		off += int64(nn)
+		n += nn
+		if off > ef.size {
+			ef.size = off
+		}
+	}
+	return n, nil
+}
+
+// Close the underlying file
+func (ef *encryptedFile) Close() error {
+	return ef.fd.Close()
+}
+
+// encryptedFileInfo is the os.FileInfo of an encrypted file which
+// reports the size of the plaintext
+type encryptedFileInfo struct {
+	os.FileInfo
+	size int64
+}
+
+// Size returns the size of the plaintext
+func (fi encryptedFileInfo) Size() int64 {
+	return fi.size
+}
+
+// Stat the underlying file returning the size of the plaintext
+func (ef *encryptedFile) Stat() (os.FileInfo, error) {
+	ef.mu.Lock()
+	defer ef.mu.Unlock()
+	fi, err := ef.fd.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return encryptedFileInfo{FileInfo: fi, size: ef.size}, nil
+}
+
+// Truncate the file to size bytes of plaintext
+//
+// The last block is rewritten with a new nonce if it changes size.
+// Any extension reads as zeroes.
+func (ef *encryptedFile) Truncate(size int64) error {
+	ef.mu.Lock()
+	defer ef.mu.Unlock()
+	if size == ef.size {
+		return nil
+	}
+	var err error
+	if size < ef.size {
+		// Shorten the block the new end is in
+		if size%cacheBlockSize != 0 {
+			err = ef._resizeBlock(size/cacheBlockSize, size%cacheBlockSize)
+		}
+	} else if ef.size%cacheBlockSize != 0 {
+		// Fill up the old last block as far as the new end
+		last := ef.size / cacheBlockSize
+		err = ef._resizeBlock(last, blockLen(last, size))
+	}
+	if err != nil {
+		return err
+	}
+	err = ef.fd.Truncate(diskSize(size))
+	if err != nil {
+		return err
+	}
+	ef.size = size
+	// Any blocks cut off are holes if the file is extended again
+	nBlocks := (size + cacheBlockSize - 1) / cacheBlockSize
+	ef.blocks = ef.blocks.Intersection(ranges.Range{Pos: 0, Size: nBlocks})
+	return nil
+}
+
+// Sync the underlying file to disk
+func (ef *encryptedFile) Sync() error {
+	return ef.fd.Sync()
+}
+
+// decryptingObject wraps the fs.Object for a cache file so that
+// reading it returns the plaintext. It is used to upload the cache
+// file to the remote.
+type decryptingObject struct {
+	fs.Object
+	cc     *cacheCipher
+	osPath string
+	id     []byte
+	blocks ranges.Ranges // block numbers which have been written
+}
+
+// Size returns the size of the plaintext
+func (o *decryptingObject) Size() int64 {
+	return plaintextSize(o.Object.Size())
+}
+
+// Open the cache file for read returning the plaintext
+func (o *decryptingObject) Open(ctx context.Context, options ...fs.OpenOption) (in io.ReadCloser, err error) {
+	var offset, limit int64 = 0, -1
+	for _, option := range options {
+		switch x := option.(type) {
+		case *fs.SeekOption:
+			offset = x.Offset
+		case *fs.RangeOption:
+			offset, limit = x.Decode(o.Size())
+		default:
+			if option.Mandatory() {
+				fs.Logf(o, "Unsupported mandatory option: %v", option)
+			}
+		}
+	}
+	fd, err := os.Open(o.osPath)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 0 {
+		limit = o.Size() - offset
+	}
+	ef, err := o.cc.newEncryptedFile(fd, o.id, o.blocks)
+	if err != nil {
+		_ = fd.Close()
+		return nil, err
+	}
+	return sectionReadCloser{
+		SectionReader: io.NewSectionReader(ef, offset, limit),
+		Closer:        fd,
+	}, nil
+}
+
+// sectionReadCloser reads a section of a file and closes the file
+type sectionReadCloser struct {
+	*io.SectionReader
+	io.Closer
+}
+
+// Hash returns an empty string as the local hash is of the ciphertext
+// so the plaintext hash isn't known
+func (o *decryptingObject) Hash(ctx context.Context, ht hash.Type) (string, error) {
+	return "", nil
+}
+
+// UnWrap returns the underlying object
+func (o *decryptingObject) UnWrap() fs.Object {
+	return o.Object
+}
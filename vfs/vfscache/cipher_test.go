@@ -0,0 +1,426 @@
+package vfscache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/lib/random"
+	"github.com/rclone/rclone/lib/ranges"
+	"github.com/rclone/rclone/vfs/vfscommon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCacheCipher(t *testing.T) *cacheCipher {
+	opt := vfscommon.DefaultOpt
+	opt.CachePassword = obscure.MustObscure("potato")
+	cc, err := newCacheCipher(&opt, "/cache/root")
+	require.NoError(t, err)
+	require.NotNil(t, cc)
+	return cc
+}
+
+func TestNewCacheCipher(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+
+	// not configured
+	cc, err := newCacheCipher(&opt, "/cache/root")
+	require.NoError(t, err)
+	assert.Nil(t, cc)
+
+	// password not obscured
+	opt.CachePassword = "potato"
+	_, err = newCacheCipher(&opt, "/cache/root")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "obscure")
+
+	// both set
+	opt.CachePassword = obscure.MustObscure("potato")
+	opt.CacheKeyFile = "/path/to/key"
+	_, err = newCacheCipher(&opt, "/cache/root")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "together")
+
+	// key file
+	dir, err := ioutil.TempDir("", "rclone-vfscache-test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	opt.CachePassword = ""
+	opt.CacheKeyFile = filepath.Join(dir, "key")
+	_, err = newCacheCipher(&opt, "/cache/root")
+	require.Error(t, err)
+	require.NoError(t, ioutil.WriteFile(opt.CacheKeyFile, []byte("secret key"), 0600))
+	cc, err = newCacheCipher(&opt, "/cache/root")
+	require.NoError(t, err)
+	assert.NotNil(t, cc)
+}
+
+func TestCacheCipherMeta(t *testing.T) {
+	cc := newTestCacheCipher(t)
+
+	plaintext := []byte(`{"Size":100}`)
+	ciphertext, err := cc.sealMeta(plaintext)
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(ciphertext, plaintext))
+
+	got, err := cc.openMeta(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, got)
+
+	// corrupted
+	ciphertext[len(ciphertext)-1] ^= 1
+	_, err = cc.openMeta(ciphertext)
+	require.Error(t, err)
+
+	// not encrypted
+	_, err = cc.openMeta(plaintext)
+	require.Error(t, err)
+
+	// wrong key
+	opt := vfscommon.DefaultOpt
+	opt.CachePassword = obscure.MustObscure("potato")
+	cc2, err := newCacheCipher(&opt, "/other/cache/root")
+	require.NoError(t, err)
+	ciphertext[len(ciphertext)-1] ^= 1
+	_, err = cc2.openMeta(ciphertext)
+	require.Error(t, err)
+}
+
+// newTestEncryptedFile makes an encryptedFile in a temporary file
+func newTestEncryptedFile(t *testing.T, cc *cacheCipher) *encryptedFile {
+	id, err := cc.newFileID()
+	require.NoError(t, err)
+	fd, err := ioutil.TempFile("", "rclone-vfscache-test")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = fd.Close()
+		require.NoError(t, os.Remove(fd.Name()))
+	})
+	ef, err := cc.newEncryptedFile(fd, id, nil)
+	require.NoError(t, err)
+	return ef
+}
+
+// checkEncryptedFile checks ef contains want
+func checkEncryptedFile(t *testing.T, ef *encryptedFile, want []byte) {
+	fi, err := ef.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(want)), fi.Size())
+	raw, err := ef.fd.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, diskSize(int64(len(want))), raw.Size())
+	got := make([]byte, len(want))
+	n, err := ef.ReadAt(got, 0)
+	require.NoError(t, err)
+	assert.Equal(t, len(want), n)
+	assert.True(t, bytes.Equal(want, got), "contents differ")
+}
+
+func TestDiskSize(t *testing.T) {
+	for _, size := range []int64{0, 1, cacheBlockSize - 1, cacheBlockSize, cacheBlockSize + 1, 3*cacheBlockSize + 17} {
+		assert.Equal(t, size, plaintextSize(diskSize(size)), size)
+	}
+	assert.Equal(t, int64(0), diskSize(0))
+	assert.Equal(t, int64(1+cacheBlockOverhead), diskSize(1))
+	assert.Equal(t, int64(2*cacheBlockDiskSize), diskSize(2*cacheBlockSize))
+}
+
+func TestEncryptedFile(t *testing.T) {
+	cc := newTestCacheCipher(t)
+	ef := newTestEncryptedFile(t, cc)
+
+	const size = 1000
+	contents := []byte(random.String(size))
+
+	// write the contents in unaligned chunks out of order
+	for _, r := range []struct{ off, end int }{{500, 1000}, {3, 17}, {0, 3}, {17, 500}} {
+		n, err := ef.WriteAt(contents[r.off:r.end], int64(r.off))
+		require.NoError(t, err)
+		assert.Equal(t, r.end-r.off, n)
+	}
+	checkEncryptedFile(t, ef, contents)
+
+	// check the data on disk is encrypted
+	raw, err := ioutil.ReadFile(ef.fd.Name())
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(raw, contents[100:200]))
+
+	// read back at unaligned offsets
+	for _, r := range []struct{ off, end int }{{0, 1000}, {1, 2}, {15, 33}, {999, 1000}, {123, 877}} {
+		buf := make([]byte, r.end-r.off)
+		n, err := ef.ReadAt(buf, int64(r.off))
+		require.NoError(t, err)
+		assert.Equal(t, r.end-r.off, n)
+		assert.Equal(t, contents[r.off:r.end], buf)
+	}
+
+	// reading off the end
+	buf := make([]byte, 10)
+	n, err := ef.ReadAt(buf, size-5)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 5, n)
+}
+
+func TestEncryptedFileBlocks(t *testing.T) {
+	cc := newTestCacheCipher(t)
+	ef := newTestEncryptedFile(t, cc)
+	var want []byte
+
+	// write sets off to data in want and ef
+	write := func(off int, data []byte) {
+		if off+len(data) > len(want) {
+			want = append(want, make([]byte, off+len(data)-len(want))...)
+		}
+		copy(want[off:], data)
+		n, err := ef.WriteAt(data, int64(off))
+		require.NoError(t, err)
+		assert.Equal(t, len(data), n)
+		checkEncryptedFile(t, ef, want)
+	}
+
+	// truncate sets the size of want and ef
+	truncate := func(size int) {
+		if size > len(want) {
+			want = append(want, make([]byte, size-len(want))...)
+		}
+		want = want[:size]
+		require.NoError(t, ef.Truncate(int64(size)))
+		checkEncryptedFile(t, ef, want)
+	}
+
+	// across block boundaries
+	write(0, []byte(random.String(cacheBlockSize+100)))
+	write(cacheBlockSize-10, []byte(random.String(20)))
+	// leaving a gap of a whole block after a short last block
+	write(3*cacheBlockSize+5, []byte(random.String(10)))
+	// leaving a gap within the last block
+	write(3*cacheBlockSize+100, []byte(random.String(10)))
+	// filling in the gap
+	write(cacheBlockSize+50, []byte(random.String(2*cacheBlockSize)))
+
+	truncate(2*cacheBlockSize + 7)
+	truncate(2 * cacheBlockSize)
+	truncate(2*cacheBlockSize + 3)
+	truncate(5*cacheBlockSize + 9)
+	truncate(17)
+	truncate(0)
+	write(10, []byte("hello"))
+}
+
+func TestEncryptedFileRewrite(t *testing.T) {
+	cc := newTestCacheCipher(t)
+	ef := newTestEncryptedFile(t, cc)
+	contents := []byte(random.String(100))
+
+	_, err := ef.WriteAt(contents, 0)
+	require.NoError(t, err)
+	raw1, err := ioutil.ReadFile(ef.fd.Name())
+	require.NoError(t, err)
+
+	// writing the same data again gives different ciphertext
+	_, err = ef.WriteAt(contents[10:20], 10)
+	require.NoError(t, err)
+	raw2, err := ioutil.ReadFile(ef.fd.Name())
+	require.NoError(t, err)
+	require.Equal(t, len(raw1), len(raw2))
+	assert.NotEqual(t, raw1, raw2)
+	for i := cacheBlockNonce; i < len(raw1); i++ {
+		assert.NotEqual(t, raw1[i:i+4], raw2[i:i+4], "ciphertext reused at %d", i)
+		i += 3
+	}
+
+	// and after truncating and rewriting
+	require.NoError(t, ef.Truncate(0))
+	_, err = ef.WriteAt(contents, 0)
+	require.NoError(t, err)
+	raw3, err := ioutil.ReadFile(ef.fd.Name())
+	require.NoError(t, err)
+	assert.NotEqual(t, raw1, raw3)
+	assert.NotEqual(t, raw2, raw3)
+	checkEncryptedFile(t, ef, contents)
+}
+
+func TestEncryptedFileTampered(t *testing.T) {
+	cc := newTestCacheCipher(t)
+	ef := newTestEncryptedFile(t, cc)
+	contents := []byte(random.String(2 * cacheBlockSize))
+	_, err := ef.WriteAt(contents, 0)
+	require.NoError(t, err)
+	buf := make([]byte, 10)
+
+	// flipping a bit is detected
+	raw := make([]byte, 1)
+	_, err = ef.fd.ReadAt(raw, 100)
+	require.NoError(t, err)
+	_, err = ef.fd.WriteAt([]byte{raw[0] ^ 1}, 100)
+	require.NoError(t, err)
+	_, err = ef.ReadAt(buf, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "authenticate")
+
+	// as is swapping blocks
+	block0 := make([]byte, cacheBlockDiskSize)
+	_, err = ef.fd.ReadAt(block0, 0)
+	require.NoError(t, err)
+	block1 := make([]byte, cacheBlockDiskSize)
+	_, err = ef.fd.ReadAt(block1, cacheBlockDiskSize)
+	require.NoError(t, err)
+	_, err = ef.fd.WriteAt(block1, 0)
+	require.NoError(t, err)
+	_, err = ef.ReadAt(buf, 0)
+	require.Error(t, err)
+
+	// and moving a block to another file
+	ef2 := newTestEncryptedFile(t, cc)
+	_, err = ef2.WriteAt(contents, 0)
+	require.NoError(t, err)
+	_, err = ef2.fd.WriteAt(block1, cacheBlockDiskSize)
+	require.NoError(t, err)
+	_, err = ef2.ReadAt(buf, cacheBlockSize)
+	require.Error(t, err)
+
+	// and zeroing a block
+	_, err = ef2.fd.WriteAt(make([]byte, cacheBlockDiskSize), cacheBlockDiskSize)
+	require.NoError(t, err)
+	_, err = ef2.ReadAt(buf, cacheBlockSize)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "authenticate")
+
+	// and a block which can't be read in full
+	require.NoError(t, ef2.fd.Truncate(cacheBlockDiskSize+10))
+	_, err = ef2.ReadAt(buf, cacheBlockSize)
+	require.Error(t, err)
+}
+
+func TestEncryptedFileHoles(t *testing.T) {
+	cc := newTestCacheCipher(t)
+	ef := newTestEncryptedFile(t, cc)
+	contents := []byte(random.String(10))
+
+	// write to block 2 leaving blocks 0 and 1 as holes
+	_, err := ef.WriteAt(contents, 2*cacheBlockSize)
+	require.NoError(t, err)
+	assert.Equal(t, ranges.Ranges{{Pos: 2, Size: 1}}, ef.Blocks())
+
+	// data put in a hole is ignored
+	_, err = ef.fd.WriteAt([]byte(random.String(100)), 10)
+	require.NoError(t, err)
+	buf := make([]byte, 100)
+	_, err = ef.ReadAt(buf, 0)
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, 100), buf)
+
+	// the blocks written are kept when the file is opened again
+	ef2, err := cc.newEncryptedFile(ef.fd, ef.id, ef.Blocks())
+	require.NoError(t, err)
+	want := append(make([]byte, 2*cacheBlockSize), contents...)
+	checkEncryptedFile(t, ef2, want)
+	ef3, err := cc.newEncryptedFile(ef.fd, ef.id, nil)
+	require.NoError(t, err)
+	checkEncryptedFile(t, ef3, make([]byte, len(want)))
+
+	// truncating forgets the blocks cut off
+	require.NoError(t, ef.Truncate(cacheBlockSize))
+	assert.Equal(t, ranges.Ranges(nil), ef.Blocks())
+	require.NoError(t, ef.Truncate(3*cacheBlockSize))
+	checkEncryptedFile(t, ef, make([]byte, 3*cacheBlockSize))
+}
+
+func TestItemEncrypted(t *testing.T) {
+	opt := vfscommon.DefaultOpt
+	opt.CachePollInterval = 0
+	opt.WriteBack = 0
+	opt.CachePassword = obscure.MustObscure("potato")
+	r, c, cleanup := newTestCacheOpt(t, opt)
+	defer cleanup()
+	require.NotNil(t, c.cipher)
+
+	contents, obj, item := newFile(t, r, c, "existing")
+
+	require.NoError(t, item.Open(obj))
+
+	n, err := item.WriteAt([]byte("HELLO"), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	n, err = item.WriteAt([]byte("THEVERYEND"), 120)
+	require.NoError(t, err)
+	assert.Equal(t, 10, n)
+
+	require.NoError(t, item.Truncate(140))
+
+	want := contents[:10] + "HELLO" + contents[15:] + zeroes[:20] + "THEVERYEND" + zeroes[:10]
+
+	buf := make([]byte, len(want))
+	n, err = item.ReadAt(buf, 0)
+	require.NoError(t, err)
+	assert.Equal(t, len(want), n)
+	assert.Equal(t, want, string(buf))
+
+	require.NoError(t, item.Close(nil))
+
+	// check the remote gets the plaintext
+	checkObject(t, r, "existing", want)
+
+	// check the data and metadata are encrypted on disk
+	raw, err := ioutil.ReadFile(c.toOSPath("existing"))
+	require.NoError(t, err)
+	assert.Equal(t, diskSize(int64(len(want))), int64(len(raw)))
+	assert.False(t, strings.Contains(string(raw), "HELLO"))
+	assert.False(t, strings.Contains(string(raw), contents[20:40]))
+	rawMeta, err := ioutil.ReadFile(c.toOSPathMeta("existing"))
+	require.NoError(t, err)
+	assert.False(t, strings.Contains(string(rawMeta), "Fingerprint"))
+
+	// Reload the cache with the correct key - item is kept
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c2, err := New(ctx, r.Fremote, &opt, addVirtual)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`name="existing" opens=0 size=140`}, itemAsString(c2))
+	item2, _ := c2.get("existing")
+	require.NoError(t, item2.Open(obj))
+	buf = make([]byte, len(want))
+	n, err = item2.ReadAt(buf, 0)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(buf[:n]))
+	require.NoError(t, item2.Close(nil))
+
+	// Reload the cache with the wrong key - the cache won't start
+	// and the item is kept
+	opt.CachePassword = obscure.MustObscure("wrong")
+	_, err = New(ctx, r.Fremote, &opt, addVirtual)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "doesn't match")
+	assertPathExist(t, c.toOSPath("existing"))
+	assertPathExist(t, c.toOSPathMeta("existing"))
+
+	// Reload the cache without a key - the item is kept
+	opt.CachePassword = ""
+	_, err = New(ctx, r.Fremote, &opt, addVirtual)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "metadata is encrypted")
+	assertPathExist(t, c.toOSPath("existing"))
+	assertPathExist(t, c.toOSPathMeta("existing"))
+	assertPathExist(t, c.keyPath)
+
+	// Metadata which doesn't authenticate with the right key isn't
+	// removed either
+	opt.CachePassword = obscure.MustObscure("potato")
+	rawMeta[len(rawMeta)-1] ^= 1
+	require.NoError(t, ioutil.WriteFile(c.toOSPathMeta("existing"), rawMeta, 0600))
+	_, err = New(ctx, r.Fremote, &opt, addVirtual)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to authenticate metadata")
+	assertPathExist(t, c.toOSPath("existing"))
+	assertPathExist(t, c.toOSPathMeta("existing"))
+}
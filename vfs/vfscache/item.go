@@ -1,10 +1,12 @@
 package vfscache
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"sync"
 	"time"
@@ -62,13 +64,14 @@ type Item struct {
 	opens           int                      // number of times file is open
 	downloaders     *downloaders.Downloaders // a record of the downloaders in action - may be nil
 	o               fs.Object                // object we are caching - may be nil
-	fd              *os.File                 // handle we are using to read and write to the file
+	fd              cacheFile                // handle we are using to read and write to the file
 	metaDirty       bool                     // set if the info needs writeback
 	modified        bool                     // set if the file has been modified since the last Open
 	info            Info                     // info about the file to persist to backing store
 	writeBackID     writeback.Handle         // id of any writebacks in progress
 	pendingAccesses int                      // number of threads - cache reset not allowed if not zero
 	beingReset      bool                     // cache cleaner is resetting the cache file, access not allowed
+	loadErr         error                    // set if the metadata couldn't be decrypted so the item was kept
 }
 
 // Info is persisted to backing store
@@ -79,6 +82,9 @@ type Info struct {
 	Rs          ranges.Ranges // which parts of the file are present
 	Fingerprint string        // fingerprint of remote object
 	Dirty       bool          // set if the backing file has been modified
+	Pinned      bool          // set if the item should never be removed by the cleaner
+	FileID      []byte        // random ID of the data file if the cache is encrypted
+	Blocks      ranges.Ranges // blocks of the data file written if the cache is encrypted
 }
 
 // Items are a slice of *Item ordered by ATime
@@ -95,11 +101,12 @@ const (
 	RemovedNotInUse                         // Item not used. Remove instead of reset
 	ResetFailed                             // Reset failed with an error
 	ResetComplete                           // Reset completed successfully
+	SkippedPinned                           // Pinned item cannot be reset
 )
 
 func (rr ResetResult) String() string {
 	return [...]string{"Dirty item skipped", "In-access item skipped", "Empty item skipped",
-		"Not-in-use item removed", "Item reset failed", "Item reset completed"}[rr]
+		"Not-in-use item removed", "Item reset failed", "Item reset completed", "Pinned item skipped"}[rr]
 }
 
 func (v Items) Len() int      { return len(v) }
@@ -119,8 +126,13 @@ func (v Items) Less(i, j int) bool {
 }
 
 // clean the item after its cache file has been deleted
+//
+// The pinned state is preserved as it belongs to the name rather
+// than the contents.
 func (info *Info) clean() {
+	pinned := info.Pinned
 	*info = Info{}
+	info.Pinned = pinned
 	info.ModTime = time.Now()
 	info.ATime = info.ModTime
 }
@@ -155,13 +167,17 @@ func newItem(c *Cache, name string) (item *Item) {
 	exists, err := item.load()
 	if !exists {
 		item._removeFile("metadata doesn't exist")
+	} else if isMetaKeyError(err) {
+		// Don't remove the item as it might be dirty
+		fs.Errorf(name, "vfs cache: not removing item as %v", err)
+		item.loadErr = err
 	} else if err != nil {
 		item.remove(fmt.Sprintf("failed to load metadata: %v", err))
 	}
 
 	// Get size estimate (which is best we can do until Open() called)
 	if statErr == nil {
-		item.info.Size = fi.Size()
+		item.info.Size = item.diskToSize(fi.Size())
 	}
 	return item
 }
@@ -203,8 +219,30 @@ func (item *Item) load() (exists bool, err error) {
 		return true, errors.Wrap(err, "vfs cache item: failed to read metadata")
 	}
 	defer fs.CheckClose(in, &err)
-	decoder := json.NewDecoder(in)
-	err = decoder.Decode(&item.info)
+	if item.c.cipher != nil {
+		ciphertext, err := ioutil.ReadAll(in)
+		if err != nil {
+			return true, errors.Wrap(err, "vfs cache item: failed to read metadata")
+		}
+		plaintext, err := item.c.cipher.openMeta(ciphertext)
+		if err != nil {
+			return true, errors.Wrap(err, "vfs cache item: failed to decrypt metadata")
+		}
+		err = json.Unmarshal(plaintext, &item.info)
+		if err != nil {
+			return true, errors.Wrap(err, "vfs cache item: corrupt metadata")
+		}
+		item.metaDirty = false
+		return true, nil
+	}
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return true, errors.Wrap(err, "vfs cache item: failed to read metadata")
+	}
+	if bytes.HasPrefix(data, cacheMetaMagic) {
+		return true, errors.Wrap(errMetaEncrypted, "vfs cache item: failed to decode metadata")
+	}
+	err = json.Unmarshal(data, &item.info)
 	if err != nil {
 		return true, errors.Wrap(err, "vfs cache item: corrupt metadata")
 	}
@@ -216,12 +254,29 @@ func (item *Item) load() (exists bool, err error) {
 //
 // call with the lock held
 func (item *Item) _save() (err error) {
+	item._updateBlocks(item.fd)
 	osPathMeta := item.c.toOSPathMeta(item.name) // No locking in Cache
 	out, err := os.Create(osPathMeta)
 	if err != nil {
 		return errors.Wrap(err, "vfs cache item: failed to write metadata")
 	}
 	defer fs.CheckClose(out, &err)
+	if item.c.cipher != nil {
+		plaintext, err := json.Marshal(item.info)
+		if err != nil {
+			return errors.Wrap(err, "vfs cache item: failed to encode metadata")
+		}
+		ciphertext, err := item.c.cipher.sealMeta(plaintext)
+		if err != nil {
+			return errors.Wrap(err, "vfs cache item: failed to encrypt metadata")
+		}
+		_, err = out.Write(ciphertext)
+		if err != nil {
+			return errors.Wrap(err, "vfs cache item: failed to write metadata")
+		}
+		item.metaDirty = false
+		return nil
+	}
 	encoder := json.NewEncoder(out)
 	encoder.SetIndent("", "\t")
 	err = encoder.Encode(item.info)
@@ -244,40 +299,51 @@ func (item *Item) _truncate(size int64) (err error) {
 	}
 
 	// Use open handle if available
-	fd := item.fd
+	var fd cacheFile = item.fd
 	if fd == nil {
 		// If the metadata says we have some blocks cached then the
 		// file should exist, so open without O_CREATE
 		oFlags := os.O_WRONLY
+		if item.c.cipher != nil {
+			// blocks are read before being rewritten
+			oFlags = os.O_RDWR
+		}
 		if item.info.Rs.Size() == 0 {
 			oFlags |= os.O_CREATE
 		}
 		osPath := item.c.toOSPath(item.name) // No locking in Cache
-		fd, err = file.OpenFile(osPath, oFlags, 0600)
+		var osFd *os.File
+		osFd, err = file.OpenFile(osPath, oFlags, 0600)
 		if err != nil && os.IsNotExist(err) {
 			// If the metadata has info but the file doesn't
 			// not exist then it has been externally removed
 			fs.Errorf(item.name, "vfs cache: detected external removal of cache file")
 			item.info.Rs = nil      // show we have no blocks cached
+			item.info.Blocks = nil  // and none are encrypted
 			item.info.Dirty = false // file can't be dirty if it doesn't exist
 			item._removeMeta("cache file externally deleted")
-			fd, err = file.OpenFile(osPath, os.O_CREATE|os.O_WRONLY, 0600)
+			osFd, err = file.OpenFile(osPath, oFlags|os.O_CREATE, 0600)
 		}
 		if err != nil {
 			return errors.Wrap(err, "vfs cache: truncate: failed to open cache file")
 		}
 
-		defer fs.CheckClose(fd, &err)
+		defer fs.CheckClose(osFd, &err)
 
-		err = file.SetSparse(fd)
+		err = file.SetSparse(osFd)
 		if err != nil {
 			fs.Errorf(item.name, "vfs cache: truncate: failed to set as a sparse file: %v", err)
 		}
+		fd, err = item._wrapFile(osFd)
+		if err != nil {
+			return errors.Wrap(err, "vfs cache: truncate")
+		}
 	}
 
 	fs.Debugf(item.name, "vfs cache: truncate to size=%d", size)
 
 	err = fd.Truncate(size)
+	item._updateBlocks(fd)
 	if err != nil {
 		return errors.Wrap(err, "vfs cache: truncate")
 	}
@@ -375,8 +441,10 @@ func (item *Item) _getSize() (size int64, err error) {
 			size = item.o.Size()
 			err = nil
 		}
-	} else {
+	} else if item.fd != nil {
 		size = fi.Size()
+	} else {
+		size = item.diskToSize(fi.Size())
 	}
 	if err == nil {
 		item.info.Size = size
@@ -474,7 +542,11 @@ func (item *Item) _createFile(osPath string) (err error) {
 	if err != nil {
 		fs.Errorf(item.name, "vfs cache: failed to set as a sparse file: %v", err)
 	}
-	item.fd = fd
+	item.fd, err = item._wrapFile(fd)
+	if err != nil {
+		_ = fd.Close()
+		return errors.Wrap(err, "vfs cache item: open failed")
+	}
 
 	err = item._save()
 	if err != nil {
@@ -583,6 +655,18 @@ func (item *Item) _store(ctx context.Context, storeFn StoreFn) (err error) {
 		return errors.Wrap(err, "vfs cache: failed to find cache file")
 	}
 
+	// Read the plaintext from the cache file if it is encrypted
+	if cacheObj != nil && item.c.cipher != nil {
+		item._updateBlocks(item.fd)
+		cacheObj = &decryptingObject{
+			Object: cacheObj,
+			cc:     item.c.cipher,
+			osPath: item.c.toOSPath(item.name), // No locking in Cache
+			id:     item.info.FileID,
+			blocks: item.info.Blocks,
+		}
+	}
+
 	// Object has disappeared if cacheObj == nil
 	if cacheObj != nil {
 		o, name := item.o, item.name
@@ -686,6 +770,7 @@ func (item *Item) Close(storeFn StoreFn) (err error) {
 	if item.fd == nil {
 		checkErr(errors.New("vfs cache item: internal error: didn't Open file"))
 	} else {
+		item._updateBlocks(item.fd)
 		checkErr(item.fd.Close())
 		item.fd = nil
 	}
@@ -714,8 +799,9 @@ func (item *Item) Close(storeFn StoreFn) (err error) {
 			// asynchronous writeback
 			item.c.writeback.SetID(&item.writeBackID)
 			id := item.writeBackID
+			size := item.info.Size
 			item.mu.Unlock()
-			item.c.writeback.Add(id, item.name, item.modified, func(ctx context.Context) error {
+			item.c.writeback.Add(id, item.name, size, item.modified, func(ctx context.Context) error {
 				return item.store(ctx, storeFn)
 			})
 			item.mu.Lock()
@@ -882,7 +968,7 @@ func (item *Item) RemoveNotInUse(maxAge time.Duration, emptyOnly bool) (removed
 	spaceFreed = 0
 	removed = false
 
-	if item.opens != 0 || item.metaDirty || item.info.Dirty {
+	if item.opens != 0 || item.metaDirty || item.info.Dirty || item.info.Pinned {
 		return
 	}
 
@@ -917,6 +1003,11 @@ func (item *Item) Reset() (rr ResetResult, spaceFreed int64, err error) {
 	item.mu.Lock()
 	defer item.mu.Unlock()
 
+	// do not reset or remove pinned files
+	if item.info.Pinned {
+		return SkippedPinned, 0, nil
+	}
+
 	// The item is not being used now.  Just remove it instead of resetting it.
 	if item.opens == 0 && !item.metaDirty && !item.info.Dirty {
 		spaceFreed = item.info.Rs.Size()
@@ -1064,6 +1155,47 @@ func (item *Item) postAccess() {
 	item.cond.Broadcast()
 }
 
+// setPinned sets the pinned state of the item and saves the metadata
+func (item *Item) setPinned(pinned bool) (err error) {
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	if item.info.Pinned == pinned {
+		return nil
+	}
+	item.info.Pinned = pinned
+	if !item._exists() {
+		// Nothing cached so no metadata to keep in step
+		return nil
+	}
+	return item._save()
+}
+
+// IsPinned returns true if the item is pinned in the cache
+func (item *Item) IsPinned() bool {
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	return item.info.Pinned
+}
+
+// prefetch makes sure the whole of the item is present in the
+// backing file, downloading any missing parts with the downloaders.
+//
+// The item must be open.
+func (item *Item) prefetch() (err error) {
+	item.preAccess()
+	defer item.postAccess()
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	if item.fd == nil {
+		return errors.New("vfs cache item prefetch: internal error: didn't Open file")
+	}
+	if item.info.Size <= 0 {
+		return nil
+	}
+	item.info.ATime = time.Now()
+	return item._ensure(0, item.info.Size)
+}
+
 // _present returns true if the whole file has been downloaded
 //
 // call with the lock held
@@ -1129,6 +1261,50 @@ func (item *Item) _ensure(offset, size int64) (err error) {
 	return item.downloaders.Download(r)
 }
 
+// diskToSize returns the size of the data in a cache file which is
+// diskSize bytes on disk
+func (item *Item) diskToSize(diskSize int64) int64 {
+	if item.c.cipher == nil {
+		return diskSize
+	}
+	return plaintextSize(diskSize)
+}
+
+// _wrapFile returns fd wrapped so it is encrypted if the cache is
+// encrypted, making an ID for the file if it doesn't have one yet.
+//
+// call with lock held
+func (item *Item) _wrapFile(fd *os.File) (cacheFile, error) {
+	if item.c.cipher == nil {
+		return fd, nil
+	}
+	if len(item.info.FileID) == 0 {
+		id, err := item.c.cipher.newFileID()
+		if err != nil {
+			return nil, err
+		}
+		item.info.FileID = id
+		item.metaDirty = true
+	}
+	return item.c.cipher.newEncryptedFile(fd, item.info.FileID, item.info.Blocks)
+}
+
+// _updateBlocks records the blocks written to fd in the metadata if
+// it is encrypted
+//
+// call with lock held
+func (item *Item) _updateBlocks(fd cacheFile) {
+	ef, ok := fd.(*encryptedFile)
+	if !ok {
+		return
+	}
+	blocks := ef.Blocks()
+	if !blocks.Equal(item.info.Blocks) {
+		item.info.Blocks = blocks
+		item.metaDirty = true
+	}
+}
+
 // _written marks the (offset, size) as present in the backing file
 //
 // This is called by the downloader downloading file segments and the
@@ -1227,6 +1403,11 @@ func (item *Item) readAt(b []byte, off int64) (n int, err error) {
 	}
 	defer item.mu.Unlock()
 
+	// Record whether the read can be satisfied from the cache
+	r := ranges.Range{Pos: off, Size: int64(len(b))}
+	r.Clip(item.info.Size)
+	item.c.recordRead(item.info.Rs.Present(r))
+
 	err = item._ensure(off, int64(len(b)))
 	if err != nil {
 		return 0, err
@@ -32,8 +32,19 @@ type Options struct {
 	ReadWait          time.Duration // time to wait for in-sequence read
 	WriteBack         time.Duration // time to wait before writing back dirty files
 	ReadAhead         fs.SizeSuffix // bytes to read ahead in cache mode "full"
+	CachePassword     string        // obscured password to encrypt the cache with
+	CacheKeyFile      string        // file containing the key to encrypt the cache with
+	Links             bool          // if set present .rclonelink files as symlinks
+	MetadataStore     bool          // if set store permissions, owners and xattrs in a local database
+	LockRemote        bool          // if set write lock files to the remote so other rclones can see them
+	LockExpiry        time.Duration // how long lock files on the remote last if not refreshed
 }
 
+// LinkSuffix is the extension of the files which are presented as
+// symlinks if Links is set. This is the same as used by the local
+// backend with --links.
+const LinkSuffix = ".rclonelink"
+
 // DefaultOpt is the default values uses for Opt
 var DefaultOpt = Options{
 	NoModTime:         false,
@@ -58,4 +69,5 @@ var DefaultOpt = Options{
 	ReadWait:          20 * time.Millisecond,
 	WriteBack:         5 * time.Second,
 	ReadAhead:         0 * fs.MebiByte,
+	LockExpiry:        5 * time.Minute,
 }
@@ -37,5 +37,11 @@ func AddFlags(flagSet *pflag.FlagSet) {
 	flags.DurationVarP(flagSet, &Opt.ReadWait, "vfs-read-wait", "", Opt.ReadWait, "Time to wait for in-sequence read before seeking.")
 	flags.DurationVarP(flagSet, &Opt.WriteBack, "vfs-write-back", "", Opt.WriteBack, "Time to writeback files after last use when using cache.")
 	flags.FVarP(flagSet, &Opt.ReadAhead, "vfs-read-ahead", "", "Extra read ahead over --buffer-size when using cache-mode full.")
+	flags.StringVarP(flagSet, &Opt.CachePassword, "vfs-cache-password", "", Opt.CachePassword, "Password to encrypt the cache with (obscured with rclone obscure).")
+	flags.StringVarP(flagSet, &Opt.CacheKeyFile, "vfs-cache-key-file", "", Opt.CacheKeyFile, "File containing the key to encrypt the cache with.")
+	flags.BoolVarP(flagSet, &Opt.Links, "vfs-links", "", Opt.Links, "Translate symlinks to/from regular files with a '"+vfscommon.LinkSuffix+"' extension.")
+	flags.BoolVarP(flagSet, &Opt.MetadataStore, "vfs-metadata-store", "", Opt.MetadataStore, "Store permissions, ownership and xattrs set on files in a local database.")
+	flags.BoolVarP(flagSet, &Opt.LockRemote, "vfs-lock-remote", "", Opt.LockRemote, "Write file locks to the remote so other rclone processes see them.")
+	flags.DurationVarP(flagSet, &Opt.LockExpiry, "vfs-lock-expiry", "", Opt.LockExpiry, "Time after which lock files on the remote of a dead rclone are ignored.")
 	platformFlags(flagSet)
 }
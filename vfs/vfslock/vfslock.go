@@ -0,0 +1,582 @@
+// Package vfslock implements advisory locks on the files in a VFS.
+//
+// The locks are byte range locks with the same semantics as POSIX
+// fcntl locks: a lock is held by an owner, an owner may hold any
+// number of non overlapping locks on a file, and locking or unlocking
+// a range replaces whatever the owner held on that range. Whole file
+// locks (flock, WebDAV) are locks on the range 0 to EOF.
+//
+// Locks are always tracked in process so that all the users of a VFS
+// (eg a mount and serve webdav in the same rclone) see each other's
+// locks. Optionally they can be written as lock files on the remote
+// so that other rclone processes, possibly on other hosts, can see
+// them too. These lock files expire unless they are refreshed, so the
+// locks of a process which has died are eventually released.
+package vfslock
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/object"
+)
+
+// Type of a lock
+type Type int
+
+// Lock types
+const (
+	Read  Type = 1 // shared lock - conflicts with Write locks only
+	Write Type = 2 // exclusive lock - conflicts with all other locks
+)
+
+// String turns a Type into a string
+func (t Type) String() string {
+	switch t {
+	case Read:
+		return "read"
+	case Write:
+		return "write"
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+// EOF is the End of a lock which extends to the end of the file
+// however big it gets
+const EOF = math.MaxInt64
+
+// Dir is the directory in the root of the remote the lock files are
+// stored in
+const Dir = ".rclonelocks"
+
+// lockFileSuffix is the extension of the lock files
+const lockFileSuffix = ".lock"
+
+// ErrLocked is returned when a lock can't be taken because it
+// conflicts with a lock held by another owner
+var ErrLocked = errors.New("file is locked")
+
+// ErrNotLocked is returned when refreshing a lock which isn't held
+var ErrNotLocked = errors.New("file is not locked")
+
+// Lock describes a lock held on a file
+type Lock struct {
+	Path    string    `json:"path"`              // path of the file relative to the VFS root
+	Owner   string    `json:"owner"`             // who holds the lock
+	Type    Type      `json:"type"`              // Read or Write
+	Start   int64     `json:"start"`             // first byte locked
+	End     int64     `json:"end"`               // last byte locked (inclusive) - EOF for the whole file
+	Expires time.Time `json:"expires,omitempty"` // when the lock expires - zero for never
+}
+
+// String turns a Lock into a string for debug
+func (l *Lock) String() string {
+	return fmt.Sprintf("%s lock on %q %d..%d by %q", l.Type, l.Path, l.Start, l.End, l.Owner)
+}
+
+// expired returns true if the lock has expired at now
+func (l *Lock) expired(now time.Time) bool {
+	return !l.Expires.IsZero() && now.After(l.Expires)
+}
+
+// overlaps returns true if the ranges of l and o overlap
+func (l *Lock) overlaps(o *Lock) bool {
+	return l.Start <= o.End && o.Start <= l.End
+}
+
+// conflicts returns true if l can't be held at the same time as o
+func (l *Lock) conflicts(o *Lock) bool {
+	return l.Owner != o.Owner && l.overlaps(o) && (l.Type == Write || o.Type == Write)
+}
+
+// lockFile is the contents of a lock file on the remote
+//
+// There is one lock file per path and owner.
+type lockFile struct {
+	Host    string    `json:"host"`    // host which wrote the file - for information only
+	Expires time.Time `json:"expires"` // ignore the file after this time
+	Locks   []*Lock   `json:"locks"`   // the locks held
+}
+
+// ownerKey identifies the locks an owner holds on a path
+type ownerKey struct {
+	path  string
+	owner string
+}
+
+// Manager keeps track of the locks held on the files in a VFS
+type Manager struct {
+	f       fs.Fs         // remote to store lock files on - nil for in process only
+	expiry  time.Duration // how long the lock files last without being refreshed
+	id      string        // unique id of this Manager used in lock file names
+	host    string        // host name to write into the lock files
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	locks   map[string][]*Lock     // locks held by path
+	remote  map[ownerKey]fs.Object // lock files written to the remote
+	changed chan struct{}          // closed and replaced when locks are released
+}
+
+// New makes a new lock Manager
+//
+// If f is not nil then lock files are written to f in Dir and
+// refreshed so that they last for expiry after this process stops.
+func New(f fs.Fs, expiry time.Duration) *Manager {
+	if expiry <= 0 {
+		expiry = 5 * time.Minute
+	}
+	m := &Manager{
+		f:       f,
+		expiry:  expiry,
+		id:      newID(),
+		locks:   make(map[string][]*Lock),
+		remote:  make(map[ownerKey]fs.Object),
+		changed: make(chan struct{}),
+	}
+	m.host, _ = os.Hostname()
+	if f != nil {
+		var ctx context.Context
+		ctx, m.cancel = context.WithCancel(context.Background())
+		m.wg.Add(1)
+		go m.refresher(ctx)
+	}
+	return m
+}
+
+// newID makes a random id for the manager
+func newID() string {
+	var buf [8]byte
+	_, err := rand.Read(buf[:])
+	if err != nil {
+		// fall back to something which is probably unique
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf[:])
+}
+
+// Remote returns true if the locks are written to the remote
+func (m *Manager) Remote() bool {
+	return m.f != nil
+}
+
+// Shutdown stops the background refresher and removes any lock
+// files this Manager has written to the remote.
+func (m *Manager) Shutdown() {
+	if m.cancel != nil {
+		m.cancel()
+		m.wg.Wait()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ctx := context.Background()
+	for k, o := range m.remote {
+		m.removeLockFile(ctx, k, o)
+	}
+	m.locks = make(map[string][]*Lock)
+	m._notify()
+}
+
+// _notify wakes anyone waiting for a lock - call with the lock held
+func (m *Manager) _notify() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+// _current returns the unexpired locks on p, discarding expired
+// ones - call with the lock held
+func (m *Manager) _current(p string, now time.Time) []*Lock {
+	locks := m.locks[p]
+	out := locks[:0]
+	expired := false
+	for _, l := range locks {
+		if l.expired(now) {
+			fs.Debugf(p, "vfs lock: expired %v", l)
+			expired = true
+			continue
+		}
+		out = append(out, l)
+	}
+	if len(out) == 0 {
+		delete(m.locks, p)
+	} else {
+		m.locks[p] = out
+	}
+	if expired {
+		m._notify()
+	}
+	return out
+}
+
+// ownerLocks returns the locks in locks held by owner
+func ownerLocks(locks []*Lock, owner string) (out []*Lock) {
+	for _, l := range locks {
+		if l.Owner == owner {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// removeRange removes the range start..end from locks, splitting any
+// which straddle its ends
+func removeRange(locks []*Lock, start, end int64) (out []*Lock) {
+	for _, l := range locks {
+		if l.End < start || l.Start > end {
+			out = append(out, l)
+			continue
+		}
+		if l.Start < start {
+			before := *l
+			before.End = start - 1
+			out = append(out, &before)
+		}
+		if l.End > end {
+			after := *l
+			after.Start = end + 1
+			out = append(out, &after)
+		}
+	}
+	return out
+}
+
+// _set replaces the locks owner holds on p with newLocks - call
+// with the lock held
+func (m *Manager) _set(p, owner string, newLocks []*Lock) {
+	var out []*Lock
+	for _, l := range m.locks[p] {
+		if l.Owner != owner {
+			out = append(out, l)
+		}
+	}
+	out = append(out, newLocks...)
+	if len(out) == 0 {
+		delete(m.locks, p)
+	} else {
+		m.locks[p] = out
+	}
+}
+
+// check the lock is valid
+func check(lk *Lock) error {
+	if lk.Type != Read && lk.Type != Write {
+		return errors.Errorf("invalid lock type %v", lk.Type)
+	}
+	if lk.Start < 0 || lk.End < lk.Start {
+		return errors.Errorf("invalid lock range %d..%d", lk.Start, lk.End)
+	}
+	return nil
+}
+
+// Lock tries to take the lock lk returning ErrLocked if it conflicts
+// with a lock held by someone else.
+//
+// Any locks the owner already holds on the range are replaced, so
+// this can be used to upgrade or downgrade a lock.
+func (m *Manager) Lock(ctx context.Context, lk Lock) error {
+	if err := check(&lk); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if conflict := m._conflict(ctx, &lk, now); conflict != nil {
+		fs.Debugf(lk.Path, "vfs lock: %v conflicts with %v", &lk, conflict)
+		return ErrLocked
+	}
+	oldLocks := ownerLocks(m.locks[lk.Path], lk.Owner)
+	newLocks := append(removeRange(oldLocks, lk.Start, lk.End), &lk)
+	if m.f != nil {
+		k := ownerKey{path: lk.Path, owner: lk.Owner}
+		err := m.writeLockFile(ctx, k, newLocks, now)
+		if err != nil {
+			return err
+		}
+		// Check again in case someone else wrote a conflicting
+		// lock file at the same time. If they did then both back
+		// off.
+		if conflict := m.remoteConflict(ctx, &lk, now); conflict != nil {
+			fs.Debugf(lk.Path, "vfs lock: %v conflicts with %v on the remote - backing off", &lk, conflict)
+			m.restoreLockFile(ctx, k, oldLocks, now)
+			return ErrLocked
+		}
+	}
+	m._set(lk.Path, lk.Owner, newLocks)
+	fs.Debugf(lk.Path, "vfs lock: took %v", &lk)
+	return nil
+}
+
+// LockWait takes the lock lk waiting until it is available or ctx is
+// cancelled.
+func (m *Manager) LockWait(ctx context.Context, lk Lock) error {
+	for {
+		m.mu.Lock()
+		changed := m.changed
+		m.mu.Unlock()
+		err := m.Lock(ctx, lk)
+		if err != ErrLocked {
+			return err
+		}
+		// Wait for a lock to be released in process, or poll
+		// for the remote and expiring locks.
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// Unlock releases the locks owner holds on the range start..end of p
+//
+// It isn't an error to unlock a range which isn't locked.
+func (m *Manager) Unlock(ctx context.Context, p, owner string, start, end int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	oldLocks := ownerLocks(m.locks[p], owner)
+	if len(oldLocks) == 0 {
+		return nil
+	}
+	newLocks := removeRange(oldLocks, start, end)
+	if m.f != nil {
+		k := ownerKey{path: p, owner: owner}
+		err := m.writeLockFile(ctx, k, newLocks, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	m._set(p, owner, newLocks)
+	m._notify()
+	fs.Debugf(p, "vfs lock: %q unlocked %d..%d", owner, start, end)
+	return nil
+}
+
+// UnlockAll releases all the locks owner holds on p
+func (m *Manager) UnlockAll(ctx context.Context, p, owner string) error {
+	return m.Unlock(ctx, p, owner, 0, EOF)
+}
+
+// Refresh sets the expiry time of all the locks owner holds on p
+//
+// It returns ErrNotLocked if there aren't any.
+func (m *Manager) Refresh(ctx context.Context, p, owner string, expires time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	locks := ownerLocks(m._current(p, now), owner)
+	if len(locks) == 0 {
+		return ErrNotLocked
+	}
+	for _, l := range locks {
+		l.Expires = expires
+	}
+	if m.f != nil {
+		return m.writeLockFile(ctx, ownerKey{path: p, owner: owner}, locks, now)
+	}
+	return nil
+}
+
+// Test returns a lock which conflicts with lk or nil if lk could be
+// taken
+func (m *Manager) Test(ctx context.Context, lk Lock) *Lock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	conflict := m._conflict(ctx, &lk, time.Now())
+	if conflict != nil {
+		out := *conflict
+		return &out
+	}
+	return nil
+}
+
+// Locks returns a copy of the locks held in this process on p sorted
+// by owner and start
+func (m *Manager) Locks(p string) (out []Lock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, l := range m._current(p, time.Now()) {
+		out = append(out, *l)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Owner != out[j].Owner {
+			return out[i].Owner < out[j].Owner
+		}
+		return out[i].Start < out[j].Start
+	})
+	return out
+}
+
+// _conflict returns a lock which conflicts with lk, checking the
+// remote if necessary - call with the lock held
+func (m *Manager) _conflict(ctx context.Context, lk *Lock, now time.Time) *Lock {
+	for _, l := range m._current(lk.Path, now) {
+		if lk.conflicts(l) {
+			return l
+		}
+	}
+	if m.f != nil {
+		return m.remoteConflict(ctx, lk, now)
+	}
+	return nil
+}
+
+// lockDir returns the directory the lock files for p are stored in
+func lockDir(p string) string {
+	return path.Join(Dir, p)
+}
+
+// lockFileName returns the name of the lock file for k
+func (m *Manager) lockFileName(k ownerKey) string {
+	hash := sha1.Sum([]byte(k.owner))
+	return path.Join(lockDir(k.path), m.id+"-"+hex.EncodeToString(hash[:8])+lockFileSuffix)
+}
+
+// remoteConflict reads the lock files written by other Managers for
+// lk.Path and returns the first conflicting lock or nil.
+//
+// Lock files which can't be read are ignored.
+func (m *Manager) remoteConflict(ctx context.Context, lk *Lock, now time.Time) *Lock {
+	entries, err := m.f.List(ctx, lockDir(lk.Path))
+	if err != nil {
+		if err != fs.ErrorDirNotFound {
+			fs.Errorf(lk.Path, "vfs lock: failed to list lock files: %v", err)
+		}
+		return nil
+	}
+	for _, entry := range entries {
+		o, ok := entry.(fs.Object)
+		if !ok {
+			continue
+		}
+		leaf := path.Base(o.Remote())
+		if !strings.HasSuffix(leaf, lockFileSuffix) || strings.HasPrefix(leaf, m.id+"-") {
+			continue
+		}
+		lf, err := readLockFile(ctx, o)
+		if err != nil {
+			fs.Debugf(o, "vfs lock: ignoring lock file: %v", err)
+			continue
+		}
+		if now.After(lf.Expires) {
+			continue
+		}
+		for _, l := range lf.Locks {
+			if l.Path == lk.Path && !l.expired(now) && lk.conflicts(l) {
+				return l
+			}
+		}
+	}
+	return nil
+}
+
+// readLockFile reads and decodes the lock file o
+func readLockFile(ctx context.Context, o fs.Object) (lf *lockFile, err error) {
+	in, err := o.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer fs.CheckClose(in, &err)
+	lf = new(lockFile)
+	err = json.NewDecoder(in).Decode(lf)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode lock file")
+	}
+	return lf, nil
+}
+
+// writeLockFile writes the lock file for k containing locks,
+// removing it if there are no locks.
+func (m *Manager) writeLockFile(ctx context.Context, k ownerKey, locks []*Lock, now time.Time) error {
+	o := m.remote[k]
+	if len(locks) == 0 {
+		if o != nil {
+			m.removeLockFile(ctx, k, o)
+		}
+		return nil
+	}
+	data, err := json.Marshal(&lockFile{
+		Host:    m.host,
+		Expires: now.Add(m.expiry),
+		Locks:   locks,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode lock file")
+	}
+	src := object.NewStaticObjectInfo(m.lockFileName(k), now, int64(len(data)), true, nil, m.f)
+	if o != nil {
+		err = o.Update(ctx, bytes.NewReader(data), src)
+	} else {
+		o, err = m.f.Put(ctx, bytes.NewReader(data), src)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to write lock file")
+	}
+	m.remote[k] = o
+	return nil
+}
+
+// restoreLockFile puts the lock file for k back to locks, logging
+// any errors
+func (m *Manager) restoreLockFile(ctx context.Context, k ownerKey, locks []*Lock, now time.Time) {
+	err := m.writeLockFile(ctx, k, locks, now)
+	if err != nil {
+		fs.Errorf(k.path, "vfs lock: failed to restore lock file: %v", err)
+	}
+}
+
+// removeLockFile removes the lock file o for k, logging any errors
+func (m *Manager) removeLockFile(ctx context.Context, k ownerKey, o fs.Object) {
+	delete(m.remote, k)
+	err := o.Remove(ctx)
+	if err != nil {
+		fs.Errorf(o, "vfs lock: failed to remove lock file: %v", err)
+		return
+	}
+	// Tidy up the directory if it is now empty - this fails
+	// harmlessly if it isn't
+	_ = m.f.Rmdir(ctx, path.Dir(o.Remote()))
+}
+
+// refresher rewrites the lock files before they expire
+func (m *Manager) refresher(ctx context.Context) {
+	defer m.wg.Done()
+	ticker := time.NewTicker(m.expiry / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.refresh(ctx)
+		}
+	}
+}
+
+// refresh rewrites all the lock files this Manager holds
+func (m *Manager) refresh(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for k := range m.remote {
+		locks := ownerLocks(m._current(k.path, now), k.owner)
+		err := m.writeLockFile(ctx, k, locks, now)
+		if err != nil {
+			fs.Errorf(k.path, "vfs lock: failed to refresh lock file: %v", err)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package vfslock
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/rclone/rclone/backend/memory"
+	"github.com/rclone/rclone/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ctx = context.Background()
+
+// ranges returns the locks held on p as owner:type:start-end
+func ranges(m *Manager, p string) (out []string) {
+	for _, l := range m.Locks(p) {
+		end := fmt.Sprint(l.End)
+		if l.End == EOF {
+			end = "EOF"
+		}
+		out = append(out, fmt.Sprintf("%s:%v:%d-%s", l.Owner, l.Type, l.Start, end))
+	}
+	return out
+}
+
+func TestRemoveRange(t *testing.T) {
+	locks := []*Lock{{Start: 0, End: 9}, {Start: 20, End: 29}}
+	out := removeRange(locks, 5, 24)
+	require.Equal(t, 2, len(out))
+	assert.Equal(t, int64(0), out[0].Start)
+	assert.Equal(t, int64(4), out[0].End)
+	assert.Equal(t, int64(25), out[1].Start)
+	assert.Equal(t, int64(29), out[1].End)
+
+	out = removeRange(locks, 3, 6)
+	require.Equal(t, 3, len(out))
+	assert.Equal(t, int64(2), out[0].End)
+	assert.Equal(t, int64(7), out[1].Start)
+	assert.Equal(t, int64(9), out[1].End)
+
+	assert.Equal(t, 0, len(removeRange(locks, 0, EOF)))
+}
+
+func TestLockUnlock(t *testing.T) {
+	m := New(nil, 0)
+	defer m.Shutdown()
+
+	require.NoError(t, m.Lock(ctx, Lock{Path: "file", Owner: "a", Type: Read, Start: 0, End: EOF}))
+	require.NoError(t, m.Lock(ctx, Lock{Path: "file", Owner: "b", Type: Read, Start: 0, End: 99}))
+	assert.Equal(t, ErrLocked, m.Lock(ctx, Lock{Path: "file", Owner: "c", Type: Write, Start: 50, End: 50}))
+
+	// other files aren't affected
+	require.NoError(t, m.Lock(ctx, Lock{Path: "file2", Owner: "c", Type: Write, Start: 0, End: EOF}))
+
+	// can't upgrade while someone else holds a read lock
+	assert.Equal(t, ErrLocked, m.Lock(ctx, Lock{Path: "file", Owner: "a", Type: Write, Start: 0, End: EOF}))
+	conflict := m.Test(ctx, Lock{Path: "file", Owner: "a", Type: Write, Start: 0, End: EOF})
+	require.NotNil(t, conflict)
+	assert.Equal(t, "b", conflict.Owner)
+
+	// but can upgrade the part b doesn't hold
+	require.NoError(t, m.Lock(ctx, Lock{Path: "file", Owner: "a", Type: Write, Start: 100, End: EOF}))
+	assert.Equal(t, []string{"a:read:0-99", "a:write:100-EOF", "b:read:0-99"}, ranges(m, "file"))
+
+	// unlock the middle of b's lock
+	require.NoError(t, m.Unlock(ctx, "file", "b", 10, 19))
+	assert.Equal(t, []string{"a:read:0-99", "a:write:100-EOF", "b:read:0-9", "b:read:20-99"}, ranges(m, "file"))
+
+	// unlocking something not locked is fine
+	require.NoError(t, m.Unlock(ctx, "file", "z", 0, EOF))
+
+	require.NoError(t, m.UnlockAll(ctx, "file", "b"))
+	require.NoError(t, m.Lock(ctx, Lock{Path: "file", Owner: "a", Type: Write, Start: 0, End: EOF}))
+	assert.Equal(t, []string{"a:write:0-EOF"}, ranges(m, "file"))
+	assert.Nil(t, m.Test(ctx, Lock{Path: "file", Owner: "a", Type: Read, Start: 0, End: EOF}))
+
+	require.NoError(t, m.UnlockAll(ctx, "file", "a"))
+	assert.Equal(t, []string(nil), ranges(m, "file"))
+
+	// invalid locks
+	assert.Error(t, m.Lock(ctx, Lock{Path: "file", Owner: "a", Start: 0, End: EOF}))
+	assert.Error(t, m.Lock(ctx, Lock{Path: "file", Owner: "a", Type: Read, Start: 10, End: 9}))
+}
+
+func TestLockExpiry(t *testing.T) {
+	m := New(nil, 0)
+	defer m.Shutdown()
+
+	require.NoError(t, m.Lock(ctx, Lock{Path: "file", Owner: "a", Type: Write, End: EOF, Expires: time.Now().Add(-time.Second)}))
+	require.NoError(t, m.Lock(ctx, Lock{Path: "file", Owner: "b", Type: Write, End: EOF, Expires: time.Now().Add(time.Hour)}))
+	assert.Equal(t, ErrNotLocked, m.Refresh(ctx, "file", "a", time.Now().Add(time.Hour)))
+	assert.Equal(t, ErrLocked, m.Lock(ctx, Lock{Path: "file", Owner: "a", Type: Write, End: EOF}))
+	require.NoError(t, m.Refresh(ctx, "file", "b", time.Now().Add(-time.Second)))
+	require.NoError(t, m.Lock(ctx, Lock{Path: "file", Owner: "a", Type: Write, End: EOF}))
+}
+
+func TestLockWait(t *testing.T) {
+	m := New(nil, 0)
+	defer m.Shutdown()
+
+	require.NoError(t, m.Lock(ctx, Lock{Path: "file", Owner: "a", Type: Write, End: EOF}))
+
+	done := make(chan error)
+	go func() {
+		done <- m.LockWait(ctx, Lock{Path: "file", Owner: "b", Type: Write, End: EOF})
+	}()
+	select {
+	case <-done:
+		t.Fatal("lock taken too early")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.NoError(t, m.UnlockAll(ctx, "file", "a"))
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"b:write:0-EOF"}, ranges(m, "file"))
+
+	// cancelling the context stops the wait
+	ctxTimeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, m.LockWait(ctxTimeout, Lock{Path: "file", Owner: "a", Type: Read, End: EOF}))
+}
+
+func TestLockRemote(t *testing.T) {
+	f, err := fs.NewFs(ctx, ":memory:vfslock-test")
+	require.NoError(t, err)
+
+	// two managers on the same remote simulate two processes
+	m1 := New(f, time.Hour)
+	m2 := New(f, time.Hour)
+	defer m2.Shutdown()
+
+	require.NoError(t, m1.Lock(ctx, Lock{Path: "dir/file", Owner: "a", Type: Read, End: EOF}))
+	lockFiles, err := f.List(ctx, Dir+"/dir/file")
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(lockFiles))
+
+	require.NoError(t, m2.Lock(ctx, Lock{Path: "dir/file", Owner: "b", Type: Read, End: EOF}))
+	assert.Equal(t, ErrLocked, m2.Lock(ctx, Lock{Path: "dir/file", Owner: "b", Type: Write, End: EOF}))
+	conflict := m2.Test(ctx, Lock{Path: "dir/file", Owner: "b", Type: Write, End: EOF})
+	require.NotNil(t, conflict)
+	assert.Equal(t, "a", conflict.Owner)
+
+	// the lock files go when the locks are released
+	require.NoError(t, m2.UnlockAll(ctx, "dir/file", "b"))
+	lockFiles, err = f.List(ctx, Dir+"/dir/file")
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(lockFiles))
+
+	// and when the manager is shut down
+	m1.Shutdown()
+	require.NoError(t, m2.Lock(ctx, Lock{Path: "dir/file", Owner: "b", Type: Write, End: EOF}))
+
+	// expired lock files are ignored
+	m3 := New(f, time.Millisecond)
+	defer m3.Shutdown()
+	m3.cancel() // stop the refresher
+	m3.wg.Wait()
+	require.NoError(t, m3.Lock(ctx, Lock{Path: "file", Owner: "c", Type: Write, End: EOF}))
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, m2.Lock(ctx, Lock{Path: "file", Owner: "b", Type: Write, End: EOF}))
+}
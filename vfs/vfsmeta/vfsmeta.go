@@ -0,0 +1,200 @@
+// Package vfsmeta stores the metadata for VFS nodes which the remote
+// can't store, such as permissions, ownership and extended
+// attributes.
+//
+// The metadata is kept in a bolt database in the cache directory, one
+// per remote, keyed by the path of the node relative to the root of
+// the VFS.
+package vfsmeta
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/lib/boltdb"
+	"github.com/rclone/rclone/lib/file"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucket is the name of the bolt bucket the metadata is stored in
+var bucket = []byte("metadata")
+
+// Metadata is the information stored about a node
+//
+// The fields are nil if they haven't been set.
+type Metadata struct {
+	Mode   *uint32           `json:"mode,omitempty"`   // permission bits
+	UID    *uint32           `json:"uid,omitempty"`    // owner
+	GID    *uint32           `json:"gid,omitempty"`    // group
+	Xattrs map[string][]byte `json:"xattrs,omitempty"` // extended attributes
+}
+
+// IsEmpty returns true if nothing is set in the metadata
+func (m *Metadata) IsEmpty() bool {
+	return m.Mode == nil && m.UID == nil && m.GID == nil && len(m.Xattrs) == 0
+}
+
+// Store is a metadata database
+//
+// This is shared by all the users of the same database in this
+// process.
+type Store struct {
+	db *boltdb.DB
+}
+
+// DBPath returns the path of the metadata database for the remote f
+func DBPath(f fs.Fs) (string, error) {
+	fRoot := filepath.FromSlash(f.Root())
+	if runtime.GOOS == "windows" {
+		if strings.HasPrefix(fRoot, `\\?`) {
+			fRoot = fRoot[3:]
+		}
+		fRoot = strings.Replace(fRoot, ":", "", -1)
+	}
+	cacheDir, err := filepath.Abs(config.CacheDir)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to make --cache-dir absolute")
+	}
+	return file.UNCPath(filepath.Join(cacheDir, "vfsAttr", f.Name(), fRoot) + ".db"), nil
+}
+
+// Open the metadata database at dbPath creating it if necessary
+//
+// The database can only be opened by one process at once but it can
+// be opened more than once in the same process. Each Open should be
+// balanced with a Close.
+func Open(dbPath string) (*Store, error) {
+	db, err := boltdb.Open(dbPath, bucket, "metadata")
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close the database if this is the last user
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Get the metadata for the node at p
+//
+// It returns nil if there is no metadata stored.
+func (s *Store) Get(p string) (m *Metadata, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get(key(p))
+		if data == nil {
+			return nil
+		}
+		m = new(Metadata)
+		return json.Unmarshal(data, m)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read metadata for %q", p)
+	}
+	return m, nil
+}
+
+// Update the metadata for the node at p
+//
+// fn is called with the current metadata (which will be empty if
+// there isn't any) for it to modify. If fn returns an error then
+// nothing is written. If the metadata ends up empty it is removed.
+func (s *Store) Update(p string, fn func(m *Metadata) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		k := key(p)
+		m := new(Metadata)
+		if data := b.Get(k); data != nil {
+			err := json.Unmarshal(data, m)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read metadata for %q", p)
+			}
+		}
+		err := fn(m)
+		if err != nil {
+			return err
+		}
+		if m.IsEmpty() {
+			return b.Delete(k)
+		}
+		data, err := json.Marshal(m)
+		if err != nil {
+			return errors.Wrapf(err, "failed to encode metadata for %q", p)
+		}
+		return b.Put(k, data)
+	})
+}
+
+// key returns the database key for the node at p
+//
+// This has a leading "/" as bolt doesn't allow empty keys which the
+// root would otherwise have.
+func key(p string) []byte {
+	return []byte("/" + p)
+}
+
+// keysUnder returns the keys of p and all the nodes below it
+func keysUnder(b *bolt.Bucket, p string) (keys [][]byte) {
+	prefix := key(p)
+	if p != "" {
+		if b.Get(prefix) != nil {
+			keys = append(keys, prefix)
+		}
+		prefix = append(prefix, '/')
+	}
+	c := b.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	return keys
+}
+
+// Delete the metadata for the node at p and everything below it
+func (s *Store) Delete(p string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		for _, k := range keysUnder(b, p) {
+			err := b.Delete(k)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Rename the metadata for the node at oldPath and everything below
+// it to newPath, replacing anything which was at newPath.
+func (s *Store) Rename(oldPath, newPath string) error {
+	if oldPath == newPath {
+		return nil
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		for _, k := range keysUnder(b, newPath) {
+			err := b.Delete(k)
+			if err != nil {
+				return err
+			}
+		}
+		oldKey, newKey := key(oldPath), key(newPath)
+		for _, k := range keysUnder(b, oldPath) {
+			value := append([]byte(nil), b.Get(k)...)
+			err := b.Put(append(append([]byte(nil), newKey...), k[len(oldKey):]...), value)
+			if err != nil {
+				return err
+			}
+			err = b.Delete(k)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,127 @@
+package vfsmeta
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func newTestStore(t *testing.T) (s *Store, cleanup func()) {
+	dir, err := ioutil.TempDir("", "rclone-vfsmeta-test")
+	require.NoError(t, err)
+	s, err = Open(filepath.Join(dir, "sub", "test.db"))
+	require.NoError(t, err)
+	return s, func() {
+		require.NoError(t, s.Close())
+		require.NoError(t, os.RemoveAll(dir))
+	}
+}
+
+func setMode(t *testing.T, s *Store, p string, mode uint32) {
+	require.NoError(t, s.Update(p, func(m *Metadata) error {
+		m.Mode = &mode
+		return nil
+	}))
+}
+
+// paths returns the paths with metadata in the store
+func paths(t *testing.T, s *Store) (out []string) {
+	require.NoError(t, s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(k, v []byte) error {
+			out = append(out, string(k[1:]))
+			return nil
+		})
+	}))
+	sort.Strings(out)
+	return out
+}
+
+func TestOpenShared(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	s2, err := Open(s.db.Path())
+	require.NoError(t, err)
+	assert.True(t, s.db == s2.db)
+	require.NoError(t, s2.Close())
+
+	// still open
+	_, err = s.Get("potato")
+	require.NoError(t, err)
+}
+
+func TestGetUpdate(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	m, err := s.Get("file")
+	require.NoError(t, err)
+	assert.Nil(t, m)
+
+	setMode(t, s, "file", 0755)
+	require.NoError(t, s.Update("file", func(m *Metadata) error {
+		m.Xattrs = map[string][]byte{"user.test": []byte("hello")}
+		return nil
+	}))
+	setMode(t, s, "", 0700)
+
+	m, err = s.Get("file")
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	require.NotNil(t, m.Mode)
+	assert.Equal(t, uint32(0755), *m.Mode)
+	assert.Nil(t, m.UID)
+	assert.Equal(t, []byte("hello"), m.Xattrs["user.test"])
+
+	m, err = s.Get("")
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	assert.Equal(t, uint32(0700), *m.Mode)
+
+	// an error means nothing is written
+	require.Error(t, s.Update("file", func(m *Metadata) error {
+		m.Mode = nil
+		return os.ErrInvalid
+	}))
+	m, err = s.Get("file")
+	require.NoError(t, err)
+	assert.NotNil(t, m.Mode)
+
+	// empty metadata is removed
+	require.NoError(t, s.Update("file", func(m *Metadata) error {
+		*m = Metadata{}
+		return nil
+	}))
+	assert.Equal(t, []string{""}, paths(t, s))
+}
+
+func TestDeleteRename(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	for _, p := range []string{"dir", "dir/a", "dir/sub/b", "dir2", "dir2/c", "dirx"} {
+		setMode(t, s, p, 0600)
+	}
+
+	require.NoError(t, s.Rename("dir", "new/dir"))
+	assert.Equal(t, []string{"dir2", "dir2/c", "dirx", "new/dir", "new/dir/a", "new/dir/sub/b"}, paths(t, s))
+
+	// rename over the top
+	require.NoError(t, s.Rename("dirx", "dir2"))
+	assert.Equal(t, []string{"dir2", "new/dir", "new/dir/a", "new/dir/sub/b"}, paths(t, s))
+
+	require.NoError(t, s.Delete("new/dir/sub"))
+	assert.Equal(t, []string{"dir2", "new/dir", "new/dir/a"}, paths(t, s))
+
+	require.NoError(t, s.Delete("new"))
+	assert.Equal(t, []string{"dir2"}, paths(t, s))
+
+	require.NoError(t, s.Delete(""))
+	assert.Equal(t, []string(nil), paths(t, s))
+}
@@ -87,6 +87,7 @@ func RunTests(t *testing.T, useVFS bool, fn mountlib.MountFn) {
 			t.Run("TestWriteFileFsync", TestWriteFileFsync)
 			t.Run("TestWriteFileDup", TestWriteFileDup)
 			t.Run("TestWriteFileAppend", TestWriteFileAppend)
+			t.Run("TestWriteFileMode", TestWriteFileMode)
 		})
 		log.Printf("Finished test run with %s (ok=%v)", what, ok)
 		if !ok {
@@ -167,9 +168,14 @@ func findMountPath() string {
 }
 
 func (r *Run) mount() {
+	r.mountOpt(&vfsflags.Opt)
+}
+
+// mountOpt mounts the remote with the VFS options given
+func (r *Run) mountOpt(opt *vfscommon.Options) {
 	log.Printf("mount %q %q", r.fremote, r.mountPath)
 	var err error
-	r.vfs = vfs.New(r.fremote, &vfsflags.Opt)
+	r.vfs = vfs.New(r.fremote, opt)
 	r.umountResult, r.umountFn, err = mountFn(r.vfs, r.mountPath, &mountlib.Opt)
 	if err != nil {
 		log.Printf("mount FAILED: %v", err)
@@ -247,6 +253,20 @@ func (r *Run) cacheMode(cacheMode vfscommon.CacheMode, writeBack time.Duration)
 
 }
 
+// metadataStore remounts with --vfs-metadata-store set to on,
+// keeping the other options
+func (r *Run) metadataStore(on bool) {
+	if r.skip {
+		log.Printf("FUSE not found so skipping metadataStore")
+		return
+	}
+	r.vfs.WaitForWriters(30 * time.Second)
+	opt := r.vfs.Opt
+	opt.MetadataStore = on
+	r.umount()
+	r.mountOpt(&opt)
+}
+
 func (r *Run) skipIfNoFUSE(t *testing.T) {
 	if r.skip {
 		t.Skip("FUSE not found so skipping test")
@@ -176,3 +176,32 @@ func TestWriteFileAppend(t *testing.T) {
 	run.waitForWriters()
 	run.rm(t, "to be synced")
 }
+
+// TestWriteFileMode tests the modes files and directories are created
+// with are kept with --vfs-metadata-store
+func TestWriteFileMode(t *testing.T) {
+	run.skipIfNoFUSE(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on Windows")
+	}
+	run.metadataStore(true)
+	defer run.metadataStore(false)
+
+	fd, err := run.os.OpenFile(run.path("testmode.sh"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0755)
+	require.NoError(t, err)
+	_, err = fd.Write([]byte("#!/bin/sh\n"))
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+	require.NoError(t, run.os.Mkdir(run.path("testmodedir"), 0700))
+
+	fi, err := run.os.Stat(run.path("testmode.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+	fi, err = run.os.Stat(run.path("testmodedir"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), fi.Mode().Perm())
+
+	run.waitForWriters()
+	run.rm(t, "testmode.sh")
+	run.rmdir(t, "testmodedir")
+}